@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// addressPattern 匹配S7-200风格地址：区域 + 可选宽度(B/W/D) + 字节偏移 + 可选位号，
+// 如 V100.3、VB100、VW100、VD204、M10.1、IW4、SMB0、AIW16、T37、C10
+var addressPattern = regexp.MustCompile(`^(?i)(SM|AI|AQ|V|I|Q|M|T|C)(B|W|D)?(\d+)(?:\.(\d+))?$`)
+
+// s7Address 解析后的地址
+type s7Address struct {
+	area    string
+	size    string // "" 表示位（T/C为编号），B/W/D 分别表示字节/字/双字
+	byteOff int
+	bit     int
+}
+
+// parseAddress 解析S7-200风格地址字符串。位地址必须带位号，字节/字/双字地址不能带位号；
+// AI/AQ只能按字访问，T/C只能按编号访问。
+func parseAddress(s string) (s7Address, error) {
+	s = strings.TrimSpace(s)
+	m := addressPattern.FindStringSubmatch(s)
+	if m == nil {
+		return s7Address{}, fmt.Errorf(tr("无效的地址: %q"), s)
+	}
+
+	a := s7Address{area: strings.ToUpper(m[1]), size: strings.ToUpper(m[2])}
+	off, err := strconv.Atoi(m[3])
+	if err != nil {
+		return s7Address{}, fmt.Errorf(tr("无效的地址偏移: %q"), s)
+	}
+	a.byteOff = off
+
+	switch a.area {
+	case s7viewer.AreaT, s7viewer.AreaC:
+		if a.size != "" || m[4] != "" {
+			return s7Address{}, fmt.Errorf(tr("%s区按编号访问，例如 %s37: %q"), a.area, a.area, s)
+		}
+		return a, nil
+	case s7viewer.AreaAI, s7viewer.AreaAQ:
+		if a.size != "W" || m[4] != "" {
+			return s7Address{}, fmt.Errorf(tr("%s区只能按字访问，例如 %sW16: %q"), a.area, a.area, s)
+		}
+		return a, nil
+	}
+
+	switch {
+	case a.size == "" && m[4] == "":
+		return s7Address{}, fmt.Errorf(tr("位地址需要指定位号，例如 %s%d.0"), a.area, a.byteOff)
+	case a.size != "" && m[4] != "":
+		return s7Address{}, fmt.Errorf(tr("%s%s%d 不能带位号"), a.area, a.size, a.byteOff)
+	case a.size == "":
+		a.bit, _ = strconv.Atoi(m[4])
+		if a.bit > 7 {
+			return s7Address{}, fmt.Errorf(tr("位号超出范围(0-7): %q"), s)
+		}
+	}
+	return a, nil
+}
+
+// parseVAddress 解析V区地址，其他区域的地址视为错误
+func parseVAddress(s string) (s7Address, error) {
+	a, err := parseAddress(s)
+	if err != nil {
+		return s7Address{}, err
+	}
+	if a.area != s7viewer.AreaV {
+		return s7Address{}, fmt.Errorf(tr("只支持V区地址: %q"), s)
+	}
+	return a, nil
+}
+
+// width 地址占用的字节数（T/C为当前值的2字节）
+func (a s7Address) width() int {
+	if s7viewer.IsCounterArea(a.area) {
+		return 2
+	}
+	switch a.size {
+	case "W":
+		return 2
+	case "D":
+		return 4
+	}
+	return 1
+}
+
+// length 地址隐含的读取长度：字节区为字节数，T/C区为个数
+func (a s7Address) length() int {
+	if s7viewer.IsCounterArea(a.area) {
+		return 1
+	}
+	return a.width()
+}
+
+func (a s7Address) String() string {
+	switch {
+	case s7viewer.IsCounterArea(a.area):
+		return fmt.Sprintf("%s%d", a.area, a.byteOff)
+	case a.size == "":
+		return fmt.Sprintf("%s%d.%d", a.area, a.byteOff, a.bit)
+	}
+	return fmt.Sprintf("%s%s%d", a.area, a.size, a.byteOff)
+}
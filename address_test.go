@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   s7Address
+		length int
+	}{
+		{"V100.3", s7Address{area: "V", byteOff: 100, bit: 3}, 1},
+		{"VB100", s7Address{area: "V", size: "B", byteOff: 100}, 1},
+		{"vw100", s7Address{area: "V", size: "W", byteOff: 100}, 2},
+		{"VD204", s7Address{area: "V", size: "D", byteOff: 204}, 4},
+		{"M10.1", s7Address{area: "M", byteOff: 10, bit: 1}, 1},
+		{"IW4", s7Address{area: "I", size: "W", byteOff: 4}, 2},
+		{"Q0.0", s7Address{area: "Q", byteOff: 0, bit: 0}, 1},
+		{"SMB0", s7Address{area: "SM", size: "B", byteOff: 0}, 1},
+		{"SM0.1", s7Address{area: "SM", byteOff: 0, bit: 1}, 1},
+		{"AIW16", s7Address{area: "AI", size: "W", byteOff: 16}, 2},
+		{"AQW0", s7Address{area: "AQ", size: "W", byteOff: 0}, 2},
+		{"T37", s7Address{area: "T", byteOff: 37}, 1},
+		{" C10 ", s7Address{area: "C", byteOff: 10}, 1},
+	}
+	for _, tt := range tests {
+		got, err := parseAddress(tt.in)
+		if err != nil {
+			t.Errorf("parseAddress(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAddress(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.length() != tt.length {
+			t.Errorf("parseAddress(%q).length() = %d, want %d", tt.in, got.length(), tt.length)
+		}
+	}
+}
+
+func TestParseAddressMalformed(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"100",
+		"V",
+		"V100",    // 位地址缺少位号
+		"V100.8",  // 位号超出范围
+		"V100.",   // 位号为空
+		"VW100.1", // 字地址带位号
+		"VX100",   // 未知宽度
+		"X100.0",  // 未知区域
+		"AI16",    // AI只能按字访问
+		"AIB16",   // AI只能按字访问
+		"TW37",    // T按编号访问
+		"C10.1",   // C按编号访问
+		"V-1.0",   // 负偏移
+		"VB1 00",  // 中间有空格
+		"V1e3.0",  // 非十进制偏移
+		"V99999999999999999999.0",
+	} {
+		if a, err := parseAddress(in); err == nil {
+			t.Errorf("parseAddress(%q) = %+v, want error", in, a)
+		}
+	}
+}
+
+func TestParseVAddressRejectsOtherAreas(t *testing.T) {
+	if _, err := parseVAddress("M10.1"); err == nil {
+		t.Error("parseVAddress(\"M10.1\") want error")
+	}
+	if a, err := parseVAddress("VW2"); err != nil || a.byteOff != 2 {
+		t.Errorf("parseVAddress(\"VW2\") = %+v, %v", a, err)
+	}
+}
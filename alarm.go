@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// conditionPattern 匹配形如 "V100.0 == 1"、"VW120 > 500" 的报警条件
+var conditionPattern = regexp.MustCompile(`^(\S+?)\s*(==|!=|>=|<=|>|<|=)\s*(-?\d+)$`)
+
+// condition 单个V区地址的比较条件
+type condition struct {
+	text  string
+	addr  s7Address
+	op    string
+	value int64
+}
+
+// parseCondition 解析报警条件，支持 V位(V100.0)、VB、VW(有符号INT)、VD(有符号DINT)
+func parseCondition(s string) (*condition, error) {
+	s = strings.TrimSpace(s)
+	m := conditionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf(tr("无效的报警条件: %q，示例: V100.0 == 1 或 VW120 > 500"), s)
+	}
+
+	addr, err := parseVAddress(m[1])
+	if err != nil {
+		return nil, err
+	}
+	c := &condition{text: s, addr: addr, op: m[2]}
+	c.value, _ = strconv.ParseInt(m[3], 10, 64)
+	if c.op == "=" {
+		c.op = "=="
+	}
+	return c, nil
+}
+
+// evaluate 在从startAddress开始读取的数据上求值，字和双字按order的字节顺序解码，ok为false表示地址不在读取范围内
+func (c *condition) evaluate(order string, startAddress int, data []byte) (result bool, ok bool) {
+	off := c.addr.byteOff - startAddress
+	if off < 0 || off+c.addr.width() > len(data) {
+		return false, false
+	}
+
+	var v int64
+	switch c.addr.size {
+	case "":
+		v = int64((data[off] >> c.addr.bit) & 1)
+	case "B":
+		v = int64(data[off])
+	case "W":
+		v = int64(int16(binary.BigEndian.Uint16(s7viewer.ToBigEndian(order, data[off:off+2]))))
+	case "D":
+		v = int64(int32(binary.BigEndian.Uint32(s7viewer.ToBigEndian(order, data[off:off+4]))))
+	}
+
+	switch c.op {
+	case "==":
+		return v == c.value, true
+	case "!=":
+		return v != c.value, true
+	case ">":
+		return v > c.value, true
+	case ">=":
+		return v >= c.value, true
+	case "<":
+		return v < c.value, true
+	case "<=":
+		return v <= c.value, true
+	}
+	return false, false
+}
+
+// quickAlarm 条件报警，仅在条件由假变真（上升沿）时触发一次
+type quickAlarm struct {
+	cond   *condition
+	active bool
+}
+
+// check 用新数据求值，返回本次是否应触发报警
+func (a *quickAlarm) check(order string, startAddress int, data []byte) bool {
+	if a == nil || a.cond == nil {
+		return false
+	}
+	result, ok := a.cond.evaluate(order, startAddress, data)
+	if !ok {
+		return false
+	}
+	fired := result && !a.active
+	a.active = result
+	return fired
+}
+
+// alarmRulePattern 匹配状态表行的报警条件，地址可省略，可附带持续时间：
+// "> 1500"、"V100.3 == 1 for > 5 s"、"== 1 持续 5秒"
+var alarmRulePattern = regexp.MustCompile(`(?i)^([A-Z]\S*?)?\s*(==|!=|>=|<=|>|<|=)\s*(-?\d+(?:\.\d+)?)` +
+	`(?:\s*(?:for|持续)\s*>?\s*(\d+(?:\.\d+)?)\s*(ms|s|秒|min|分钟)?)?$`)
+
+// alarmRule 状态表一行的报警条件：当前值与value比较，持续delay后触发
+type alarmRule struct {
+	text  string
+	op    string
+	value float64
+	delay time.Duration
+}
+
+// parseAlarmRule 解析状态表地址addr上的报警条件。条件中带地址时必须与该行地址一致。
+func parseAlarmRule(s string, addr s7Address) (*alarmRule, error) {
+	s = strings.TrimSpace(s)
+	m := alarmRulePattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf(tr("无效的报警条件: %q，示例: > 1500 或 == 1 for > 5 s"), s)
+	}
+	if m[1] != "" {
+		a, _, err := parseWatchAddress(m[1])
+		if err != nil {
+			return nil, err
+		}
+		if a != addr {
+			return nil, fmt.Errorf(tr("报警条件的地址 %s 与该行地址 %s 不一致"), a, addr)
+		}
+	}
+
+	r := &alarmRule{text: s, op: m[2]}
+	r.value, _ = strconv.ParseFloat(m[3], 64)
+	if r.op == "=" {
+		r.op = "=="
+	}
+	if m[4] != "" {
+		n, _ := strconv.ParseFloat(m[4], 64)
+		unit := time.Second
+		switch strings.ToLower(m[5]) {
+		case "ms":
+			unit = time.Millisecond
+		case "min", "分钟":
+			unit = time.Minute
+		}
+		r.delay = time.Duration(n * float64(unit))
+	}
+	return r, nil
+}
+
+// match 判断当前值是否满足条件
+func (r *alarmRule) match(v float64) bool {
+	switch r.op {
+	case "==":
+		return v == r.value
+	case "!=":
+		return v != r.value
+	case ">":
+		return v > r.value
+	case ">=":
+		return v >= r.value
+	case "<":
+		return v < r.value
+	case "<=":
+		return v <= r.value
+	}
+	return false
+}
+
+// alarmState 一条报警条件的状态：条件成立的起始时间和是否已触发
+type alarmState struct {
+	since  time.Time
+	active bool
+}
+
+// update 用当前值更新状态，条件成立满delay时触发，不再成立时解除。
+// changed表示本次触发或解除，此时active为新的状态。
+func (s *alarmState) update(r *alarmRule, v float64, now time.Time) (changed bool) {
+	if !r.match(v) {
+		s.since = time.Time{}
+		if s.active {
+			s.active = false
+			return true
+		}
+		return false
+	}
+	if s.since.IsZero() {
+		s.since = now
+	}
+	if !s.active && now.Sub(s.since) >= r.delay {
+		s.active = true
+		return true
+	}
+	return false
+}
+
+// alarmEvent 报警的触发或解除
+type alarmEvent struct {
+	time    time.Time
+	plc     string
+	tag     string // 地址，有位标签时附带标签
+	rule    string
+	value   string
+	cleared bool
+
+	// 触发记录的确认人和确认时间，未确认时ackBy为空
+	ackBy   string
+	ackTime time.Time
+}
+
+func (e alarmEvent) String() string {
+	state := tr("触发")
+	if e.cleared {
+		state = tr("解除")
+	}
+	s := fmt.Sprintf(tr("%s [%s] %s %s [%s] 当前值 %s"), e.time.Format("2006-01-02 15:04:05"), state, e.plc, e.tag, e.rule, e.value)
+	if e.ackBy != "" {
+		s += fmt.Sprintf(tr(" — %s 于 %s 确认"), e.ackBy, e.ackTime.Format("15:04:05"))
+	}
+	return s
+}
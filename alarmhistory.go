@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// prefAlarmHistoryPath 报警历史文件路径的偏好键
+const prefAlarmHistoryPath = "alarm.historyPath"
+
+// 报警历史记录的类型
+const (
+	alarmRecordTrigger = "trigger"
+	alarmRecordClear   = "clear"
+	alarmRecordAck     = "ack"
+)
+
+// alarmRecord 报警历史文件中的一行（JSON Lines）。确认记录的Ref为被确认的触发记录的时间。
+type alarmRecord struct {
+	Time      time.Time  `json:"time"`
+	Event     string     `json:"event"`
+	PLC       string     `json:"plc"`
+	Tag       string     `json:"tag"`
+	Condition string     `json:"condition,omitempty"`
+	Value     string     `json:"value,omitempty"`
+	Operator  string     `json:"operator,omitempty"`
+	Ref       *time.Time `json:"ref,omitempty"`
+}
+
+// alarmHistoryMu 多个标签页追加同一个历史文件时串行写入
+var alarmHistoryMu sync.Mutex
+
+// defaultAlarmHistoryPath 默认的报警历史文件，位于用户目录
+func defaultAlarmHistoryPath() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "plc-alarms.jsonl")
+	}
+	return "plc-alarms.jsonl"
+}
+
+// appendAlarmRecord 追加一条记录到历史文件
+func appendAlarmRecord(path string, r alarmRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	alarmHistoryMu.Lock()
+	defer alarmHistoryMu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadAlarmHistory 读取历史文件中最近的max条触发和解除记录，确认记录合并到对应的触发记录。
+// 文件不存在时返回空列表，无法解析的行跳过。
+func loadAlarmHistory(path string, max int) ([]alarmEvent, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []alarmEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r alarmRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue
+		}
+		if r.Event == alarmRecordAck {
+			if r.Ref == nil {
+				continue
+			}
+			for i := len(events) - 1; i >= 0; i-- {
+				e := &events[i]
+				if !e.cleared && e.plc == r.PLC && e.tag == r.Tag && e.time.Equal(*r.Ref) {
+					e.ackBy, e.ackTime = r.Operator, r.Time
+					break
+				}
+			}
+			continue
+		}
+		events = append(events, alarmEvent{
+			time:    r.Time,
+			plc:     r.PLC,
+			tag:     r.Tag,
+			rule:    r.Condition,
+			value:   r.Value,
+			cleared: r.Event == alarmRecordClear,
+		})
+		if len(events) > 2*max {
+			events = append([]alarmEvent(nil), events[len(events)-max:]...)
+		}
+	}
+	if len(events) > max {
+		events = events[len(events)-max:]
+	}
+	return events, scanner.Err()
+}
+
+// record 报警事件对应的历史记录
+func (e alarmEvent) record() alarmRecord {
+	event := alarmRecordTrigger
+	if e.cleared {
+		event = alarmRecordClear
+	}
+	return alarmRecord{Time: e.time, Event: event, PLC: e.plc, Tag: e.tag, Condition: e.rule, Value: e.value}
+}
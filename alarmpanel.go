@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 报警通知动作的偏好键
+const (
+	prefAlarmBeep     = "alarm.beep"     // 触发时响铃
+	prefAlarmNotify   = "alarm.notify"   // 触发时发送桌面通知
+	prefAlarmOperator = "alarm.operator" // 确认报警的操作员
+)
+
+// maxAlarmEntries 报警列表保留的最大条数，超出时丢弃最早的
+const maxAlarmEntries = 500
+
+// alarmBlinkInterval 未确认的报警闪烁的间隔
+const alarmBlinkInterval = 500 * time.Millisecond
+
+// 报警历史的筛选
+const (
+	alarmFilterAll     = "全部"
+	alarmFilterUnacked = "未确认"
+	alarmFilterTrigger = "仅触发"
+	alarmFilterClear   = "仅解除"
+)
+
+var alarmFilters = []string{alarmFilterAll, alarmFilterUnacked, alarmFilterTrigger, alarmFilterClear}
+
+// alarmAction 报警触发或解除时可选执行的通知动作，勾选状态保存在偏好设置中
+type alarmAction struct {
+	check *widget.Check
+	run   func(alarmEvent)
+}
+
+// alarmList 报警历史：记录状态表报警条件的触发、解除和确认，保存到历史文件，
+// 并按勾选执行通知动作。本次运行中触发的报警在确认前一直闪烁。所有方法都在UI线程中调用。
+type alarmList struct {
+	prefs   fyne.Preferences
+	path    string
+	started time.Time // 之前运行中触发的报警不再闪烁
+	entries []alarmEvent
+	shown   []int // 按筛选条件显示的entries下标
+	filter  string
+	search  string
+	blink   bool // 闪烁中，当前为亮
+	running bool // 闪烁协程在运行
+
+	// flash 闪烁时调用，text为要显示的报警，熄灭和停止闪烁时text为空
+	flash func(text string)
+
+	list     *widget.List
+	selected int // 选中的entries下标，-1为未选中
+	operator *widget.Entry
+	status   *widget.Label
+	actions  []alarmAction
+	content  fyne.CanvasObject
+	// actionBox 通知动作的勾选框，放在报警通知设置中
+	actionBox *fyne.Container
+}
+
+func newAlarmList(prefs fyne.Preferences) *alarmList {
+	l := &alarmList{
+		prefs:     prefs,
+		path:      prefs.StringWithFallback(prefAlarmHistoryPath, defaultAlarmHistoryPath()),
+		started:   time.Now(),
+		filter:    alarmFilterAll,
+		selected:  -1,
+		operator:  widget.NewEntry(),
+		status:    widget.NewLabel(""),
+		actionBox: container.NewHBox(widget.NewLabel(tr("触发时:"))),
+	}
+	if events, err := loadAlarmHistory(l.path, maxAlarmEntries); err != nil {
+		log.Printf(tr("读取报警历史失败: %v"), err)
+	} else {
+		l.entries = events
+	}
+
+	l.list = widget.NewList(
+		func() int { return len(l.shown) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			e := l.entries[l.shown[id]]
+			label.SetText(e.String())
+			switch {
+			case e.cleared:
+				label.Importance = widget.SuccessImportance
+			case e.ackBy != "":
+				label.Importance = widget.MediumImportance
+			case l.flashing(e) && !l.blink:
+				label.Importance = widget.WarningImportance
+			default:
+				label.Importance = widget.DangerImportance
+			}
+			label.Refresh()
+		},
+	)
+	l.list.OnSelected = func(id widget.ListItemID) {
+		if id < len(l.shown) {
+			l.selected = l.shown[id]
+		}
+	}
+	l.list.OnUnselected = func(widget.ListItemID) { l.selected = -1 }
+
+	filterSelect := newTrSelect(alarmFilters, func(filter string) {
+		l.filter = filter
+		l.reload()
+	})
+	filterSelect.SetSelected(tr(alarmFilterAll))
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder(tr("按PLC、地址或标签筛选"))
+	searchEntry.OnChanged = func(s string) {
+		l.search = strings.ToLower(strings.TrimSpace(s))
+		l.reload()
+	}
+
+	l.operator.SetPlaceHolder(tr("操作员姓名"))
+	l.operator.SetText(prefs.String(prefAlarmOperator))
+	ackButton := widget.NewButton(tr("确认选中"), func() {
+		if l.selected >= 0 {
+			l.acknowledge([]int{l.selected})
+		}
+	})
+	ackAllButton := widget.NewButton(tr("全部确认"), func() {
+		var unacked []int
+		for i, e := range l.entries {
+			if !e.cleared && e.ackBy == "" {
+				unacked = append(unacked, i)
+			}
+		}
+		l.acknowledge(unacked)
+	})
+	clearButton := widget.NewButton(tr("清空列表"), func() {
+		l.entries = nil
+		l.reload()
+	})
+	l.reload()
+
+	toolbar := container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel(tr("显示:")), filterSelect),
+		container.NewHBox(widget.NewLabel(tr("操作员:")), container.NewGridWrap(fyne.NewSize(120, l.operator.MinSize().Height), l.operator), ackButton, ackAllButton),
+		searchEntry)
+	l.content = container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel(tr("在状态表的“报警条件”列为各行设置条件，如 > 1500、== 1 for > 5 s（条件持续5秒后触发）")),
+			toolbar),
+		container.NewHBox(clearButton, widget.NewLabel(tr("历史文件: ")+l.path), l.status),
+		nil, nil,
+		l.list,
+	)
+	return l
+}
+
+// addAction 添加一个可勾选的通知动作，pref为保存勾选状态的偏好键
+func (l *alarmList) addAction(name, pref string, enabled bool, run func(alarmEvent)) {
+	check := widget.NewCheck(name, func(on bool) { l.prefs.SetBool(pref, on) })
+	check.SetChecked(l.prefs.BoolWithFallback(pref, enabled))
+	l.actions = append(l.actions, alarmAction{check: check, run: run})
+	l.actionBox.Add(check)
+}
+
+// add 记录一次报警触发或解除，写入历史文件，并执行已勾选的通知动作
+func (l *alarmList) add(e alarmEvent) {
+	if e.cleared {
+		log.Printf(tr("报警解除: %s %s [%s] 当前值 %s"), e.plc, e.tag, e.rule, e.value)
+	} else {
+		log.Printf(tr("报警触发: %s %s [%s] 当前值 %s"), e.plc, e.tag, e.rule, e.value)
+	}
+	if err := appendAlarmRecord(l.path, e.record()); err != nil {
+		log.Printf(tr("写入报警历史失败: %v"), err)
+	}
+	l.entries = append(l.entries, e)
+	if n := len(l.entries) - maxAlarmEntries; n > 0 {
+		l.entries = append([]alarmEvent(nil), l.entries[n:]...)
+		l.selected -= n
+	}
+	l.reload()
+	l.list.ScrollToBottom()
+	if !e.cleared {
+		l.startBlink()
+	}
+
+	for _, a := range l.actions {
+		if a.check.Checked {
+			a.run(e)
+		}
+	}
+}
+
+// acknowledge 以填写的操作员确认entries中下标为ids的触发记录，确认后停止闪烁
+func (l *alarmList) acknowledge(ids []int) {
+	operator := strings.TrimSpace(l.operator.Text)
+	if operator == "" {
+		l.status.SetText(tr("请先填写操作员姓名"))
+		return
+	}
+	l.prefs.SetString(prefAlarmOperator, operator)
+	now := time.Now()
+	acked := 0
+	for _, i := range ids {
+		e := &l.entries[i]
+		if e.cleared || e.ackBy != "" {
+			continue
+		}
+		e.ackBy, e.ackTime = operator, now
+		ref := e.time
+		r := alarmRecord{Time: now, Event: alarmRecordAck, PLC: e.plc, Tag: e.tag, Operator: operator, Ref: &ref}
+		if err := appendAlarmRecord(l.path, r); err != nil {
+			log.Printf(tr("写入报警历史失败: %v"), err)
+		}
+		log.Printf(tr("报警已确认: %s %s [%s] 操作员 %s"), e.plc, e.tag, e.rule, operator)
+		acked++
+	}
+	l.status.SetText(fmt.Sprintf(tr("已确认%d条报警"), acked))
+	l.reload()
+}
+
+// flashing 报警是否需要闪烁：本次运行中触发且未确认
+func (l *alarmList) flashing(e alarmEvent) bool {
+	return !e.cleared && e.ackBy == "" && !e.time.Before(l.started)
+}
+
+// unacked 返回最近一条需要闪烁的报警，没有时ok为false
+func (l *alarmList) unacked() (e alarmEvent, ok bool) {
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if l.flashing(l.entries[i]) {
+			return l.entries[i], true
+		}
+	}
+	return alarmEvent{}, false
+}
+
+// startBlink 开始闪烁，直到没有未确认的报警
+func (l *alarmList) startBlink() {
+	if l.running {
+		return
+	}
+	l.running = true
+	go func() {
+		ticker := time.NewTicker(alarmBlinkInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			stop := false
+			fyne.DoAndWait(func() {
+				e, ok := l.unacked()
+				l.blink = ok && !l.blink
+				stop = !ok
+				l.running = ok
+				if l.flash != nil {
+					if l.blink {
+						l.flash(e.plc + " " + e.tag + " " + e.rule)
+					} else {
+						l.flash("")
+					}
+				}
+				l.list.Refresh()
+			})
+			if stop {
+				return
+			}
+		}
+	}()
+}
+
+// reload 按筛选条件刷新显示的记录，选中的记录不再显示时取消选中
+func (l *alarmList) reload() {
+	l.shown = l.shown[:0]
+	for i, e := range l.entries {
+		switch l.filter {
+		case alarmFilterUnacked:
+			if e.cleared || e.ackBy != "" {
+				continue
+			}
+		case alarmFilterTrigger:
+			if e.cleared {
+				continue
+			}
+		case alarmFilterClear:
+			if !e.cleared {
+				continue
+			}
+		}
+		if l.search != "" && !strings.Contains(strings.ToLower(e.plc+" "+e.tag+" "+e.rule), l.search) {
+			continue
+		}
+		l.shown = append(l.shown, i)
+	}
+	l.list.Refresh()
+	if i := slices.Index(l.shown, l.selected); i >= 0 {
+		l.list.Select(i)
+	} else {
+		l.list.UnselectAll()
+		l.selected = -1
+	}
+}
+
+// sendDesktopNotification 通过系统通知中心提示报警触发，窗口最小化时也能看到。
+// 条件报警（rule为空）只显示tag中的条件。
+func sendDesktopNotification(e alarmEvent) {
+	if e.cleared {
+		return
+	}
+	body := e.tag
+	if e.rule != "" {
+		body = fmt.Sprintf(tr("%s [%s] 当前值 %s"), e.tag, e.rule, e.value)
+	}
+	fyne.CurrentApp().SendNotification(fyne.NewNotification(tr("PLC报警: ")+e.plc, body))
+}
+
+// alarmTitle 某个标签页要在窗口标题中显示的报警
+type alarmTitle struct {
+	owner *plcPanel
+	text  string
+}
+
+// alarmTitles 正在窗口标题中显示报警的标签页，后设置的在后面。只在UI线程中访问
+var alarmTitles []alarmTitle
+
+// setAlarmTitle 设置owner在窗口标题中显示的报警，text为空时撤销。
+// 多个标签页同时报警时显示最后设置的，都撤销后恢复原标题
+func setAlarmTitle(win fyne.Window, owner *plcPanel, text string) {
+	alarmTitles = slices.DeleteFunc(alarmTitles, func(t alarmTitle) bool { return t.owner == owner })
+	if text != "" {
+		alarmTitles = append(alarmTitles, alarmTitle{owner, text})
+	}
+	if len(alarmTitles) == 0 {
+		win.SetTitle(tr(windowTitle))
+		return
+	}
+	win.SetTitle(tr("【报警】") + alarmTitles[len(alarmTitles)-1].text)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 模拟量视图设置的偏好键
+const (
+	prefAnalogAI      = "analog.ai"
+	prefAnalogAICount = "analog.aicount"
+	prefAnalogAQ      = "analog.aq"
+	prefAnalogAQCount = "analog.aqcount"
+	prefAnalogPresets = "analog.presets" // 各通道的量程，每项为“地址=量程”，如 AIW16=4–20 mA
+)
+
+// maxAnalogChannels 每组最多显示的通道数
+const maxAnalogChannels = 32
+
+// analogFullScale S7-200 SMART模拟量模块的满量程原始值
+const analogFullScale = 27648
+
+// analogPreset 模拟量量程预设，raw为true时直接显示原始值
+type analogPreset struct {
+	name  string
+	scale euScale
+	raw   bool
+}
+
+// analogPresets S7-200 SMART模拟量模块的常用量程：单极性0~27648，双极性-27648~27648
+var analogPresets = []analogPreset{
+	{name: "0–10 V", scale: euScale{rawMax: analogFullScale, euMax: 10, unit: "V"}},
+	{name: "±10 V", scale: euScale{rawMin: -analogFullScale, rawMax: analogFullScale, euMin: -10, euMax: 10, unit: "V"}},
+	{name: "±5 V", scale: euScale{rawMin: -analogFullScale, rawMax: analogFullScale, euMin: -5, euMax: 5, unit: "V"}},
+	{name: "±2.5 V", scale: euScale{rawMin: -analogFullScale, rawMax: analogFullScale, euMin: -2.5, euMax: 2.5, unit: "V"}},
+	{name: "0–20 mA", scale: euScale{rawMax: analogFullScale, euMax: 20, unit: "mA"}},
+	{name: "4–20 mA", scale: euScale{rawMax: analogFullScale, euMin: 4, euMax: 20, unit: "mA"}},
+	{name: "原始值", raw: true},
+}
+
+// analogPresetNames 量程下拉框的选项
+func analogPresetNames() []string {
+	names := make([]string, len(analogPresets))
+	for i, p := range analogPresets {
+		names[i] = p.name
+	}
+	return names
+}
+
+// findAnalogPreset 按名称查找量程，未找到时返回第一个（0–10 V）
+func findAnalogPreset(name string) analogPreset {
+	for _, p := range analogPresets {
+		if p.name == name {
+			return p
+		}
+	}
+	return analogPresets[0]
+}
+
+// format 将原始值按量程格式化，如 13824 → "5.000 V"
+func (p analogPreset) format(raw int16) string {
+	if p.raw {
+		return strconv.Itoa(int(raw))
+	}
+	return p.scale.format(float64(raw))
+}
+
+// encode 将输入的工程值换算为原始值，超出量程或INT范围时返回错误
+func (p analogPreset) encode(text string) (int16, error) {
+	v, err := parseLeadingFloat(strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf(tr("无效的数值: %q"), strings.TrimSpace(text))
+	}
+	if p.raw {
+		if v != math.Trunc(v) || v < math.MinInt16 || v > math.MaxInt16 {
+			return 0, fmt.Errorf(tr("原始值应为%d到%d的整数"), math.MinInt16, math.MaxInt16)
+		}
+		return int16(v), nil
+	}
+	lo, hi := min(p.scale.euMin, p.scale.euMax), max(p.scale.euMin, p.scale.euMax)
+	if v < lo || v > hi {
+		return 0, fmt.Errorf(tr("%v 超出量程 %s"), v, p.name)
+	}
+	return int16(math.Round(p.scale.raw(v))), nil
+}
+
+// analogChannel 模拟量视图中的一个通道
+type analogChannel struct {
+	addr     s7Address
+	preset   analogPreset
+	rawLabel *widget.Label
+	euLabel  *widget.Label
+}
+
+// analogGroup 一组连续的模拟量通道（AIW或AQW），读取时一次读出整组
+type analogGroup struct {
+	area     string
+	start    int
+	channels []*analogChannel
+}
+
+// show 显示从start起读到的数据
+func (g *analogGroup) show(data []byte) {
+	for i, ch := range g.channels {
+		if 2*i+2 > len(data) {
+			break
+		}
+		raw := int16(binary.BigEndian.Uint16(data[2*i:]))
+		ch.rawLabel.SetText(strconv.Itoa(int(raw)))
+		ch.euLabel.SetText(ch.preset.format(raw))
+	}
+}
+
+// newAnalogPanel 创建模拟量视图：按量程预设把AIW/AQW的原始值换算为电压或电流显示，
+// 可按工程值写入AQW，用于回路检查
+func newAnalogPanel(prefs fyne.Preferences, getViewer func() (*s7viewer.Viewer, context.Context)) fyne.CanvasObject {
+	aiEntry := widget.NewEntry()
+	aiEntry.SetText(prefs.StringWithFallback(prefAnalogAI, "AIW16"))
+	aiCountEntry := widget.NewEntry()
+	aiCountEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefAnalogAICount, 4)))
+	aqEntry := widget.NewEntry()
+	aqEntry.SetText(prefs.StringWithFallback(prefAnalogAQ, "AQW16"))
+	aqCountEntry := widget.NewEntry()
+	aqCountEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefAnalogAQCount, 2)))
+	statusLabel := widget.NewLabel("")
+
+	// 各通道的量程，键为地址
+	presets := make(map[string]string)
+	for _, item := range prefs.StringList(prefAnalogPresets) {
+		if addr, name, ok := strings.Cut(item, "="); ok {
+			presets[addr] = name
+		}
+	}
+	savePresets := func() {
+		var list []string
+		for addr, name := range presets {
+			list = append(list, addr+"="+name)
+		}
+		prefs.SetStringList(prefAnalogPresets, list)
+	}
+
+	// 当前显示的两组通道，只在UI线程中修改
+	var groups []*analogGroup
+	rows := container.NewVBox()
+
+	write := func(ch *analogChannel, text string) {
+		viewer, ctx := getViewer()
+		if viewer == nil || !viewer.IsConnected() {
+			statusLabel.SetText(tr("PLC未连接"))
+			return
+		}
+		raw, err := ch.preset.encode(text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		data := binary.BigEndian.AppendUint16(nil, uint16(raw))
+		go func() {
+			err := viewer.WriteArea(ctx, ch.addr.area, ch.addr.byteOff, data)
+			fyne.Do(func() {
+				if err != nil {
+					statusLabel.SetText(err.Error())
+					log.Printf(tr("写入 %s 失败: %v"), ch.addr, err)
+					return
+				}
+				ch.rawLabel.SetText(strconv.Itoa(int(raw)))
+				ch.euLabel.SetText(ch.preset.format(raw))
+				statusLabel.SetText(fmt.Sprintf(tr("已写入 %s = %s (%d)"), ch.addr, ch.preset.format(raw), raw))
+				log.Printf(tr("已写入 %s = %s (%d)"), ch.addr, ch.preset.format(raw), raw)
+			})
+		}()
+	}
+
+	// newGroup 解析起始地址和通道数并创建通道行
+	newGroup := func(area, addrText, countText string) (*analogGroup, error) {
+		addr, err := parseAddress(strings.ToUpper(strings.TrimSpace(addrText)))
+		if err != nil {
+			return nil, err
+		}
+		if addr.area != area {
+			return nil, fmt.Errorf(tr("%s 不是%sW地址"), addr, area)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(countText))
+		if err != nil || count < 0 || count > maxAnalogChannels {
+			return nil, fmt.Errorf(tr("通道数应为0到%d"), maxAnalogChannels)
+		}
+		g := &analogGroup{area: area, start: addr.byteOff}
+		for i := range count {
+			ch := &analogChannel{
+				addr:     s7Address{area: area, size: "W", byteOff: addr.byteOff + 2*i},
+				rawLabel: widget.NewLabel("-"),
+				euLabel:  widget.NewLabel("-"),
+			}
+			ch.preset = findAnalogPreset(presets[ch.addr.String()])
+			g.channels = append(g.channels, ch)
+		}
+		return g, nil
+	}
+
+	// rebuild 按设置重建通道行
+	rebuild := func() {
+		ai, err := newGroup(s7viewer.AreaAI, aiEntry.Text, aiCountEntry.Text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		aq, err := newGroup(s7viewer.AreaAQ, aqEntry.Text, aqCountEntry.Text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		prefs.SetString(prefAnalogAI, strings.ToUpper(strings.TrimSpace(aiEntry.Text)))
+		prefs.SetInt(prefAnalogAICount, len(ai.channels))
+		prefs.SetString(prefAnalogAQ, strings.ToUpper(strings.TrimSpace(aqEntry.Text)))
+		prefs.SetInt(prefAnalogAQCount, len(aq.channels))
+		groups = []*analogGroup{ai, aq}
+
+		rows.Objects = nil
+		var writeButtons []fyne.Disableable
+		for _, g := range groups {
+			if len(g.channels) == 0 {
+				continue
+			}
+			header := []fyne.CanvasObject{
+				widget.NewLabelWithStyle(tr("地址"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle(tr("量程"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle(tr("原始值"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle(tr("工程值"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			}
+			grid := container.NewGridWithColumns(len(header), header...)
+			for _, ch := range g.channels {
+				presetSelect := newTrSelect(analogPresetNames(), func(name string) {
+					ch.preset = findAnalogPreset(name)
+					presets[ch.addr.String()] = name
+					savePresets()
+					if raw, err := strconv.Atoi(ch.rawLabel.Text); err == nil {
+						ch.euLabel.SetText(ch.preset.format(int16(raw)))
+					}
+				})
+				presetSelect.SetSelected(tr(ch.preset.name))
+				var writeCell fyne.CanvasObject = widget.NewLabel("")
+				if g.area == s7viewer.AreaAQ {
+					valueEntry := widget.NewEntry()
+					valueEntry.SetPlaceHolder(tr("工程值"))
+					writeButton := widget.NewButton(tr("写入"), func() { write(ch, valueEntry.Text) })
+					writeButtons = append(writeButtons, writeButton)
+					writeCell = container.NewBorder(nil, nil, nil, writeButton, valueEntry)
+				}
+				grid.Add(widget.NewLabel(ch.addr.String()))
+				grid.Add(presetSelect)
+				grid.Add(ch.rawLabel)
+				grid.Add(ch.euLabel)
+				grid.Add(writeCell)
+			}
+			rows.Add(grid)
+			rows.Add(widget.NewSeparator())
+		}
+		disableInReadOnly(writeButtons...)
+		rows.Refresh()
+		statusLabel.SetText("")
+	}
+	rebuild()
+
+	// read 读取当前的各组通道，修改通道设置后自动刷新的下一次读取使用新的通道
+	read := func(ctx context.Context, viewer *s7viewer.Viewer) {
+		var current []*analogGroup
+		fyne.DoAndWait(func() { current = groups })
+		for _, g := range current {
+			if len(g.channels) == 0 {
+				continue
+			}
+			data, err := viewer.ReadArea(ctx, g.area, g.start, 2*len(g.channels))
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf(tr("读取%s区失败: %v"), g.area, err)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					statusLabel.SetText(tr("读取失败: ") + err.Error())
+					return
+				}
+				g.show(data)
+				statusLabel.SetText(tr("读取时间 ") + time.Now().Format("15:04:05"))
+			})
+		}
+	}
+
+	readButton, autoCheck := newReadControls(getViewer, statusLabel, read)
+
+	return container.NewBorder(
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem(tr("模拟量输入:"), container.NewGridWithColumns(4,
+					aiEntry, widget.NewLabel(tr("通道数:")), aiCountEntry, widget.NewLabel(""))),
+				widget.NewFormItem(tr("模拟量输出:"), container.NewGridWithColumns(4,
+					aqEntry, widget.NewLabel(tr("通道数:")), aqCountEntry, widget.NewButton(tr("应用"), rebuild))),
+			),
+			container.NewHBox(readButton, autoCheck, statusLabel),
+			widget.NewLabel(tr("单极性量程0~27648，双极性-27648~27648；在AQW行输入工程值后点击写入。")),
+		),
+		nil, nil, nil,
+		container.NewVScroll(rows),
+	)
+}
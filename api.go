@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// maxAPIReadBytes 单次GET请求允许读取的最大字节数
+const maxAPIReadBytes = 4096
+
+// apiResolver 按PLC地址查找已连接的viewer，plc为空时返回默认PLC
+type apiResolver func(plc string) (*s7viewer.Viewer, error)
+
+// newAPIHandler 创建嵌入式HTTP接口，MES等脚本可通过它读写PLC而无需实现S7协议：
+//
+//	GET  /v?start=100&len=4[&area=M][&plc=192.168.1.11]  读取字节
+//	POST /v/100.3   请求体为 1/0，写入位
+//	POST /v/VW100   请求体为数值，按地址宽度写入（VB/VW/VD，VR为REAL）
+//	GET  /ws[?plc=192.168.1.11]  WebSocket，监控时每次扫描推送一帧JSON
+//	GET  /metrics   Prometheus指标
+//	GET  /          网页看板，显示位网格和状态表
+//	GET  /watch[?plc=192.168.1.11]  各PLC状态表当前显示的值
+func newAPIHandler(metrics func() []metricsSource, resolve apiResolver) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", dashboardHandler())
+	mux.HandleFunc("GET /watch", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIJSON(w, http.StatusOK, watchValues(metrics(), r.URL.Query().Get("plc")))
+	})
+	mux.Handle("GET /ws", liveStream)
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, metrics())
+	})
+	mux.HandleFunc("GET /v", func(w http.ResponseWriter, r *http.Request) {
+		viewer, err := resolve(r.URL.Query().Get("plc"))
+		if err != nil {
+			writeAPIError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		q := r.URL.Query()
+		area := strings.ToUpper(q.Get("area"))
+		if area == "" {
+			area = s7viewer.AreaV
+		}
+		if !containsString(s7viewer.MemoryAreas, area) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf(tr("不支持的存储区: %s"), area))
+			return
+		}
+		start, err := strconv.Atoi(q.Get("start"))
+		if err != nil || start < 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf(tr("无效的起始地址: %q"), q.Get("start")))
+			return
+		}
+		length := 1
+		if s := q.Get("len"); s != "" {
+			if length, err = strconv.Atoi(s); err != nil || length <= 0 || length > maxAPIReadBytes {
+				writeAPIError(w, http.StatusBadRequest, fmt.Errorf(tr("无效的长度: %q（1-%d）"), s, maxAPIReadBytes))
+				return
+			}
+		}
+
+		data, err := viewer.ReadRange(r.Context(), area, start, length)
+		if err != nil {
+			writeAPIError(w, plcErrorStatus(err), err)
+			return
+		}
+		values := make([]int, len(data))
+		for i, b := range data {
+			values[i] = int(b)
+		}
+		writeAPIJSON(w, http.StatusOK, map[string]any{
+			"time":  time.Now().Format(time.RFC3339Nano),
+			"area":  area,
+			"start": start,
+			"len":   length,
+			"data":  values,
+			"hex":   fmt.Sprintf("% X", data),
+		})
+	})
+
+	mux.HandleFunc("POST /v/{addr}", func(w http.ResponseWriter, r *http.Request) {
+		viewer, err := resolve(r.URL.Query().Get("plc"))
+		if err != nil {
+			writeAPIError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		addr, dataType, err := parseAPIAddress(r.PathValue("addr"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 256))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		value := strings.TrimSpace(string(body))
+		encoded, err := s7viewer.EncodeValue(viewer.ByteOrder(), dataType, value)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if dataType == s7viewer.TypeBool {
+			err = viewer.WriteVBit(r.Context(), addr.byteOff, addr.bit, encoded[0] == 1)
+		} else {
+			err = viewer.WriteV(r.Context(), addr.byteOff, encoded)
+		}
+		if err != nil {
+			writeAPIError(w, plcErrorStatus(err), err)
+			return
+		}
+		log.Printf(tr("API写入 %s = %s"), addr, value)
+		writeAPIJSON(w, http.StatusOK, map[string]any{
+			"address": addr.String(),
+			"type":    dataType,
+			"value":   value,
+		})
+	})
+	return mux
+}
+
+// parseAPIAddress 解析写入地址。纯数字按V区处理：100.3为位，100为字节
+func parseAPIAddress(s string) (s7Address, string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		if strings.Contains(s, ".") {
+			s = "V" + s
+		} else {
+			s = "VB" + s
+		}
+	}
+	if addr, err := parseVAddress(s); err == nil && addr.size == "" {
+		return addr, s7viewer.TypeBool, nil
+	}
+	return parseWriteAddress(s)
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf(tr("API响应写入失败: %v"), err)
+	}
+}
+
+// plcErrorStatus 按PLC读写错误的类别选择HTTP状态码
+func plcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, s7viewer.ErrNotConnected), errors.Is(err, s7viewer.ErrConnectionLost):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, s7viewer.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, s7viewer.ErrOutOfRange):
+		return http.StatusBadRequest
+	case errors.Is(err, s7viewer.ErrAccessDenied), errors.Is(err, s7viewer.ErrReadOnly):
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// startAPIServer 在后台启动HTTP接口，监听失败时记录日志
+func startAPIServer(addr string, metrics func() []metricsSource, resolve apiResolver) {
+	go func() {
+		log.Printf(tr("HTTP接口监听于 %s"), addr)
+		if err := http.ListenAndServe(addr, newAPIHandler(metrics, resolve)); err != nil {
+			log.Printf(tr("HTTP接口启动失败: %v"), err)
+		}
+	}()
+}
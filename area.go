@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// bitAddressName 返回从start开始的数据中第bitIndex位（网格顺序，每字节高位在前）的地址，
+// 如 V101.5。T/C区和模拟量按字寻址，返回字地址加字内位号，如 T37 位15。
+func bitAddressName(area string, start, bitIndex int) string {
+	byteIndex, bit := bitIndex/8, 7-bitIndex%8
+	switch area {
+	case s7viewer.AreaT, s7viewer.AreaC:
+		return fmt.Sprintf("%s 位%d", s7viewer.ByteAddressName(area, start+byteIndex/2), (1-byteIndex%2)*8+bit)
+	case s7viewer.AreaAI, s7viewer.AreaAQ:
+		word := byteIndex &^ 1
+		return fmt.Sprintf("%s 位%d", s7viewer.ByteAddressName(area, start+word), (1-(byteIndex-word))*8+bit)
+	}
+	return s7Address{area: area, byteOff: start + byteIndex, bit: bit}.String()
+}
+
+// tagValues 将一次读取的数据展开为变量名和值：位（V100.3）为0/1，字（VW100）为十进制（按order的字节顺序）。
+// T/C区和模拟量只有字值，始终为PLC本身的大端顺序。
+func tagValues(order, area string, start int, data []byte) map[string]string {
+	values := make(map[string]string)
+	switch area {
+	case s7viewer.AreaT, s7viewer.AreaC:
+		for i := 0; i+1 < len(data); i += 2 {
+			values[s7viewer.ByteAddressName(area, start+i/2)] = strconv.Itoa(int(binary.BigEndian.Uint16(data[i:])))
+		}
+		return values
+	case s7viewer.AreaAI, s7viewer.AreaAQ:
+		for i := 0; i+1 < len(data); i += 2 {
+			values[s7viewer.ByteAddressName(area, start+i)] = strconv.Itoa(int(int16(binary.BigEndian.Uint16(data[i:]))))
+		}
+		return values
+	}
+	for i, b := range data {
+		for bit := 0; bit < 8; bit++ {
+			values[fmt.Sprintf("%s%d.%d", area, start+i, bit)] = strconv.Itoa(int(b>>bit) & 1)
+		}
+		if i+1 < len(data) && i%2 == 0 {
+			values[fmt.Sprintf("%sW%d", area, start+i)] = strconv.Itoa(int(binary.BigEndian.Uint16(s7viewer.ToBigEndian(order, data[i:i+2]))))
+		}
+	}
+	return values
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 审计记录的操作类型
+const (
+	auditWrite   = "write"
+	auditForce   = "force"
+	auditRelease = "release"
+)
+
+// maxAuditRows 写入审计页显示的最近记录数
+const maxAuditRows = 500
+
+// auditKeySize 审计密钥的字节数
+const auditKeySize = 32
+
+// auditRecord 写入审计文件中的一条记录（JSON Lines）。
+// 每条记录的hash是记录内容和上一条hash的HMAC，密钥保存在审计文件之外，
+// 修改、删除或调换记录后不知道密钥就无法重新计算hash，校验会失败。
+type auditRecord struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	PLC     string    `json:"plc"`
+	Action  string    `json:"action"`
+	Address string    `json:"address"`
+	Old     string    `json:"old,omitempty"` // 写入前的值（十六进制），读取失败时为空
+	New     string    `json:"new"`
+	Error   string    `json:"error,omitempty"`
+	Prev    string    `json:"prev"`
+	Hash    string    `json:"hash"`
+}
+
+// sum 计算记录的hash：Hash字段置空后的JSON的HMAC-SHA256
+func (r auditRecord) sum(key []byte) string {
+	r.Hash = ""
+	data, _ := json.Marshal(r)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// defaultAuditPath 默认的写入审计文件，位于用户目录
+func defaultAuditPath() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "plc-write-audit.jsonl")
+	}
+	return "plc-write-audit.jsonl"
+}
+
+// auditKeyPath 返回审计密钥文件：用户配置目录下的 plc-binary-viewer/audit.key，与审计文件分开保存
+func auditKeyPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf(tr("无法确定配置目录: %v"), err)
+	}
+	return filepath.Join(dir, "plc-binary-viewer", "audit.key"), nil
+}
+
+// loadAuditKey 读取审计密钥（十六进制文本）。文件不存在时create为true则生成随机密钥并以只有本用户可读的权限保存，
+// 否则返回错误
+func loadAuditKey(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key := make([]byte, auditKeySize)
+		rand.Read(key)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			// 其他进程刚刚生成了密钥
+			return loadAuditKey(path, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		log.Printf(tr("已生成审计密钥 %s"), path)
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(tr("读取审计密钥失败: %v"), err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) < auditKeySize {
+		return nil, fmt.Errorf(tr("审计密钥 %s 无效"), path)
+	}
+	return key, nil
+}
+
+// 写入审计文件的状态，多个标签页和后台写入共用
+var (
+	auditMu        sync.Mutex
+	auditListeners []func() // 追加记录后调用（不在UI线程中）
+)
+
+// auditIdentity 记录中的用户名和计算机名
+func auditIdentity() (string, string) {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	} else if name == "" {
+		name = os.Getenv("USERNAME")
+	}
+	host, _ := os.Hostname()
+	return name, host
+}
+
+// appendAudit 在审计文件末尾追加一条记录，补全用户、计算机名和以key计算的hash链。
+// 界面、命令行和无界面模式可能同时写入同一文件，追加时对旁边的 .lock 文件加锁，并在锁内重新读取最后一条记录的hash
+func appendAudit(path string, key []byte, r auditRecord) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.User, r.Host = auditIdentity()
+
+	auditMu.Lock()
+	err := withFileLock(path+".lock", func() error {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if r.Prev, err = lastAuditHash(f); err != nil {
+			return err
+		}
+		r.Hash = r.sum(key)
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(append(line, '\n'))
+		return err
+	})
+	listeners := auditListeners
+	auditMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	for _, fn := range listeners {
+		fn()
+	}
+	return nil
+}
+
+// withFileLock 持有锁文件path上的排他锁执行fn，锁文件不存在时创建
+func withFileLock(path string, fn func() error) error {
+	lock, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf(tr("锁定 %s 失败: %v"), path, err)
+	}
+	defer unlockFile(lock)
+	return fn()
+}
+
+// lastAuditHash 从文件末尾向前查找最后一条可解析的记录，返回它的hash；没有记录时返回空
+func lastAuditHash(f *os.File) (string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+	for chunk := int64(4096); ; chunk *= 2 {
+		offset := max(size-chunk, 0)
+		buf := make([]byte, size-offset)
+		if _, err := f.ReadAt(buf, offset); err != nil {
+			return "", err
+		}
+		lines := strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+		// 读取的起点不在文件开头时第一行可能不完整，留到读取更多内容后再解析
+		first := 0
+		if offset > 0 {
+			first = 1
+		}
+		for i := len(lines) - 1; i >= first; i-- {
+			var r auditRecord
+			if json.Unmarshal([]byte(lines[i]), &r) == nil {
+				return r.Hash, nil
+			}
+		}
+		if offset == 0 {
+			return "", nil
+		}
+	}
+}
+
+// addAuditListener 注册追加记录后的回调
+func addAuditListener(fn func()) {
+	auditMu.Lock()
+	auditListeners = append(auditListeners, fn)
+	auditMu.Unlock()
+}
+
+// auditWriteHandler 返回记录写入的回调，设置给Viewer后通过它的所有写入（界面、API、脚本等）都会记录
+func auditWriteHandler(plc func() string) func(s7viewer.WriteEvent) {
+	return func(e s7viewer.WriteEvent) {
+		r := auditRecord{
+			Time:    e.Time,
+			PLC:     plc(),
+			Action:  auditWrite,
+			Address: s7viewer.ByteAddressName(e.Area, e.Start),
+			New:     formatHex(e.New),
+		}
+		if e.Old != nil {
+			r.Old = formatHex(e.Old)
+		}
+		if e.Err != nil {
+			r.Error = e.Err.Error()
+		}
+		recordAudit(r)
+	}
+}
+
+// recordAudit 将记录追加到默认的审计文件，失败时记录日志
+func recordAudit(r auditRecord) {
+	keyPath, err := auditKeyPath()
+	var key []byte
+	if err == nil {
+		key, err = loadAuditKey(keyPath, true)
+	}
+	if err == nil {
+		err = appendAudit(defaultAuditPath(), key, r)
+	}
+	if err != nil {
+		log.Printf(tr("写入审计记录失败: %v"), err)
+	}
+}
+
+// loadAudit 读取审计文件中最近的max条记录，按时间顺序返回；文件不存在时返回空列表
+func loadAudit(path string, max int) ([]auditRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []auditRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r auditRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue
+		}
+		records = append(records, r)
+		if len(records) > 2*max {
+			records = append([]auditRecord(nil), records[len(records)-max:]...)
+		}
+	}
+	if len(records) > max {
+		records = records[len(records)-max:]
+	}
+	return records, scanner.Err()
+}
+
+// verifyAudit 用key校验审计文件的hash链，返回记录数；发现被修改、删除、插入或调换的记录时返回所在行的错误
+func verifyAudit(path string, key []byte) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	prev := ""
+	n := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return n, fmt.Errorf(tr("第%d行无法解析: %v"), line, err)
+		}
+		if r.Prev != prev || !hmac.Equal([]byte(r.Hash), []byte(r.sum(key))) {
+			return n, fmt.Errorf(tr("第%d行的记录被修改，或其前面的记录被删除"), line)
+		}
+		prev = r.Hash
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// String 审计页中显示的一行
+func (r auditRecord) String() string {
+	text := fmt.Sprintf("%s  %s@%s  %s  ", r.Time.Format("2006-01-02 15:04:05"), r.User, r.Host, r.PLC)
+	switch r.Action {
+	case auditForce:
+		text += fmt.Sprintf(tr("强制 %s = %s"), r.Address, r.New)
+	case auditRelease:
+		text += fmt.Sprintf(tr("释放强制 %s"), r.Address)
+	default:
+		old := r.Old
+		if old == "" {
+			old = "?"
+		}
+		text += fmt.Sprintf("%s: %s → %s", r.Address, old, r.New)
+	}
+	if r.Error != "" {
+		text += "  " + tr("失败: ") + r.Error
+	}
+	return text
+}
+
+// newAuditPanel 创建写入审计页：显示最近的写入和强制记录（新的在前），可校验文件的hash链
+func newAuditPanel() fyne.CanvasObject {
+	path := defaultAuditPath()
+	statusLabel := widget.NewLabel(tr("审计文件: ") + path)
+	var records []auditRecord // 新的在前，只在UI线程中访问
+	list := widget.NewList(
+		func() int { return len(records) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(records[i].String()) },
+	)
+
+	reload := func() {
+		go func() {
+			loaded, err := loadAudit(path, maxAuditRows)
+			fyne.Do(func() {
+				if err != nil {
+					statusLabel.SetText(tr("读取审计文件失败: ") + err.Error())
+					return
+				}
+				records = records[:0]
+				for i := len(loaded) - 1; i >= 0; i-- {
+					records = append(records, loaded[i])
+				}
+				list.Refresh()
+			})
+		}()
+	}
+	addAuditListener(func() { fyne.Do(reload) })
+	reload()
+
+	verifyButton := widget.NewButton(tr("校验"), func() {
+		go func() {
+			// 先确认审计文件存在，没有记录时也就还没有生成密钥
+			n := 0
+			_, err := os.Stat(path)
+			var keyPath string
+			if err == nil {
+				keyPath, err = auditKeyPath()
+			}
+			var key []byte
+			if err == nil {
+				key, err = loadAuditKey(keyPath, false)
+			}
+			if err == nil {
+				n, err = verifyAudit(path, key)
+			}
+			fyne.Do(func() {
+				switch {
+				case errors.Is(err, os.ErrNotExist):
+					statusLabel.SetText(tr("还没有审计记录"))
+				case err != nil:
+					statusLabel.SetText(tr("校验失败: ") + err.Error())
+					log.Printf(tr("写入审计文件校验失败: %v"), err)
+				default:
+					statusLabel.SetText(fmt.Sprintf(tr("校验通过，共%d条记录"), n))
+				}
+			})
+		}()
+	})
+
+	return container.NewBorder(
+		container.NewHBox(widget.NewButton(tr("刷新"), reload), verifyButton, statusLabel),
+		nil, nil, nil,
+		list,
+	)
+}
+
+// auditForceChange 记录强制或释放
+func auditForceChange(plc, action string, addr s7Address, value bool) {
+	r := auditRecord{PLC: plc, Action: action, Address: addr.String()}
+	if action == auditForce {
+		r.New = fmt.Sprint(boolToInt(value))
+	}
+	recordAudit(r)
+}
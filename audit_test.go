@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestAudit 用key写入n条写入记录，返回审计文件路径和各行内容
+func writeTestAudit(t *testing.T, key []byte, n int) (string, [][]byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	for i := range n {
+		r := auditRecord{Time: start.Add(time.Duration(i) * time.Minute), PLC: "192.168.2.1", Action: auditWrite,
+			Address: fmt.Sprintf("VB%d", i), Old: "00", New: fmt.Sprintf("%02X", i+1)}
+		if err := appendAudit(path, key, r); err != nil {
+			t.Fatalf("appendAudit: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+}
+
+// rewriteAudit 用lines覆盖审计文件
+func rewriteAudit(t *testing.T, path string, lines [][]byte) {
+	t.Helper()
+	if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAudit(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5A}, auditKeySize)
+	otherKey := bytes.Repeat([]byte{0xA5}, auditKeySize)
+
+	path, _ := writeTestAudit(t, key, 4)
+	if n, err := verifyAudit(path, key); err != nil || n != 4 {
+		t.Fatalf("verifyAudit(untouched) = %d, %v, want 4, nil", n, err)
+	}
+
+	// edit 修改第2条的新值但不重新计算hash
+	edit := func(lines [][]byte) [][]byte {
+		var r auditRecord
+		json.Unmarshal(lines[1], &r)
+		r.New = "FF"
+		lines[1], _ = json.Marshal(r)
+		return lines
+	}
+	// forge 修改第2条后用另一个密钥重新计算其后的整条hash链，模拟不知道密钥的改写
+	forge := func(lines [][]byte) [][]byte {
+		var prev auditRecord
+		json.Unmarshal(lines[0], &prev)
+		for i := 1; i < len(lines); i++ {
+			var r auditRecord
+			json.Unmarshal(lines[i], &r)
+			if i == 1 {
+				r.New = "FF"
+			}
+			r.Prev = prev.Hash
+			r.Hash = r.sum(otherKey)
+			lines[i], _ = json.Marshal(r)
+			prev = r
+		}
+		return lines
+	}
+	tests := []struct {
+		name   string
+		change func(lines [][]byte) [][]byte
+		line   int // 校验失败的行
+	}{
+		{"edited", edit, 2},
+		{"edited with rehashed chain", forge, 2},
+		{"deleted first", func(l [][]byte) [][]byte { return l[1:] }, 1},
+		{"deleted middle", func(l [][]byte) [][]byte { return append(l[:1:1], l[2:]...) }, 2},
+		{"reordered", func(l [][]byte) [][]byte { l[1], l[2] = l[2], l[1]; return l }, 2},
+		{"duplicated", func(l [][]byte) [][]byte { return append(l[:2:2], l[1:]...) }, 3},
+		{"garbage line", func(l [][]byte) [][]byte { l[2] = []byte("{"); return l }, 3},
+	}
+	for _, tt := range tests {
+		path, lines := writeTestAudit(t, key, 4)
+		rewriteAudit(t, path, tt.change(lines))
+		_, err := verifyAudit(path, key)
+		if err == nil {
+			t.Errorf("%s: verifyAudit succeeded, want error", tt.name)
+			continue
+		}
+		if want := fmt.Sprintf("第%d行", tt.line); !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %q, want it to mention %s", tt.name, err, want)
+		}
+	}
+
+	// 不知道密钥时无法校验通过，也就无法伪造
+	if _, err := verifyAudit(path, otherKey); err == nil || !strings.Contains(err.Error(), "第1行") {
+		t.Errorf("verifyAudit(wrong key) error = %v, want failure at line 1", err)
+	}
+}
+
+func TestAppendAuditContinuesChain(t *testing.T) {
+	key := bytes.Repeat([]byte{0x11}, auditKeySize)
+	path, lines := writeTestAudit(t, key, 2)
+	// 其他进程在两次追加之间写入的记录，其中一条长于首次读取的文件末尾
+	var prev auditRecord
+	json.Unmarshal(lines[len(lines)-1], &prev)
+	for _, text := range []string{"timeout", strings.Repeat("x", 10000)} {
+		r := auditRecord{Time: time.Now(), PLC: "192.168.2.2", Action: auditWrite, Address: "VB9", New: "01", Error: text, Prev: prev.Hash}
+		r.Hash = r.sum(key)
+		line, _ := json.Marshal(r)
+		lines = append(lines, line)
+		prev = r
+	}
+	rewriteAudit(t, path, lines)
+	if err := appendAudit(path, key, auditRecord{PLC: "192.168.2.1", Action: auditForce, Address: "V0.0", New: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := verifyAudit(path, key); err != nil || n != 5 {
+		t.Errorf("verifyAudit = %d, %v, want 5, nil", n, err)
+	}
+}
+
+func TestLoadAuditKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "audit.key")
+	if _, err := loadAuditKey(path, false); err == nil {
+		t.Fatal("loadAuditKey(missing, false) succeeded, want error")
+	}
+	key, err := loadAuditKey(path, true)
+	if err != nil || len(key) != auditKeySize {
+		t.Fatalf("loadAuditKey(missing, true) = %x, %v", key, err)
+	}
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("key file mode = %v, want 0600", fi.Mode().Perm())
+		}
+	}
+	again, err := loadAuditKey(path, true)
+	if err != nil || !bytes.Equal(again, key) {
+		t.Errorf("second loadAuditKey = %x, %v, want the saved key %x", again, err, key)
+	}
+
+	if err := os.WriteFile(path, []byte("not hex\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAuditKey(path, true); err == nil {
+		t.Error("loadAuditKey(invalid) succeeded, want error")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// batchValueColumns 批量写入CSV中值列的列名（中英文均可）
+var batchValueColumns = []string{"值", "数值", "新值", "写入值", "value"}
+
+// batchWriteRow 批量写入文件中的一行
+type batchWriteRow struct {
+	line     int // 在文件中的行号
+	addr     s7Address
+	dataType string
+	value    string
+	err      error // 解析错误或写入错误
+	written  bool
+}
+
+// status 预览和结果列表中该行的状态
+func (r *batchWriteRow) status() string {
+	switch {
+	case r.err != nil:
+		return tr("错误: ") + r.err.Error()
+	case r.written:
+		return tr("已写入")
+	}
+	return tr("待写入")
+}
+
+// String 预览和结果列表中显示的一行
+func (r *batchWriteRow) String() string {
+	return fmt.Sprintf(tr("第%d行  %s = %s (%s)  %s"), r.line, r.addr, r.value, r.dataType, r.status())
+}
+
+// parseBatchWrite 解析批量写入的CSV或制表符文本。表头需含 地址 和 值 列，类型列可省略（按地址宽度推断）。
+// 只支持V区；无法解析的行不中断解析，而是在该行的err中说明，写入时跳过。
+func parseBatchWrite(r io.Reader) ([]*batchWriteRow, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(tr("读取批量写入文件失败: %v"), err)
+	}
+
+	// 第一个非空、非注释行为表头
+	header := -1
+	for i, line := range lines {
+		if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "//") && !strings.HasPrefix(t, "#") {
+			header = i
+			break
+		}
+	}
+	if header < 0 {
+		return nil, errors.New(tr("批量写入文件为空"))
+	}
+	delim, cols, ok := detectLayoutHeader(lines[header])
+	valueCol := -1
+	if ok {
+		for i, field := range strings.Split(lines[header], string(delim)) {
+			if containsString(batchValueColumns, strings.ToLower(strings.Trim(strings.TrimSpace(field), `"`))) {
+				valueCol = i
+			}
+		}
+	}
+	if valueCol < 0 {
+		return nil, errors.New(tr("未找到批量写入的表头（需要 地址 和 值 列）"))
+	}
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []*batchWriteRow
+	for i, line := range lines[header+1:] {
+		if t := strings.TrimSpace(line); t == "" || strings.HasPrefix(t, "//") || strings.HasPrefix(t, "#") {
+			continue
+		}
+		row := &batchWriteRow{line: header + 2 + i}
+		rows = append(rows, row)
+
+		cr := csv.NewReader(strings.NewReader(line))
+		cr.Comma = delim
+		cr.LazyQuotes = true
+		cr.FieldsPerRecord = -1
+		record, err := cr.Read()
+		if err != nil {
+			row.err = fmt.Errorf(tr("无法解析: %v"), err)
+			continue
+		}
+		row.value = field(record, valueCol)
+		addr, dataType, err := parseWatchAddress(field(record, cols.address))
+		if err != nil {
+			row.err = err
+			continue
+		}
+		row.addr = addr
+		if t := strings.ToUpper(field(record, cols.dataType)); t != "" {
+			dataType = t
+		}
+		row.dataType = dataType
+		switch {
+		case addr.area != s7viewer.AreaV:
+			row.err = fmt.Errorf(tr("仅支持写入V区，%s 未写入"), addr)
+		case row.value == "":
+			row.err = errors.New(tr("缺少值"))
+		default:
+			if row.err = checkWatchType(addr, dataType); row.err == nil {
+				// 预先检查值能否编码，字节顺序不影响取值范围；写入时再按PLC的字节顺序编码
+				_, row.err = s7viewer.EncodeValue(s7viewer.OrderBigEndian, dataType, row.value)
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.New(tr("批量写入文件中没有数据行"))
+	}
+	return rows, nil
+}
+
+// batchWriteReport 批量写入结果的文本报告，每行一条
+func batchWriteReport(rows []*batchWriteRow) string {
+	lines := make([]string, len(rows))
+	for i, r := range rows {
+		lines[i] = r.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showBatchWrite 选择CSV文件后预览各行，确认后依次写入并逐行显示结果。
+// 用于更换CPU后恢复机器参数；某行失败不影响其余行，onWritten在有行写入成功后调用。
+func showBatchWrite(win fyne.Window, getViewer func() (*s7viewer.Viewer, context.Context), onWritten func()) {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		rows, err := parseBatchWrite(reader)
+		reader.Close()
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		log.Printf(tr("已打开批量写入文件 %s，共%d行"), path, len(rows))
+		showBatchWritePreview(win, path, rows, getViewer, onWritten)
+	}, win)
+}
+
+// showBatchWritePreview 显示批量写入的预览和结果
+func showBatchWritePreview(win fyne.Window, path string, rows []*batchWriteRow, getViewer func() (*s7viewer.Viewer, context.Context), onWritten func()) {
+	valid := 0
+	for _, r := range rows {
+		if r.err == nil {
+			valid++
+		}
+	}
+	statusLabel := widget.NewLabel(fmt.Sprintf(tr("共%d行，其中%d行有错误，将写入%d行"), len(rows), len(rows)-valid, valid))
+	list := widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			r := rows[i]
+			label.SetText(r.String())
+			switch {
+			case r.err != nil:
+				label.Importance = widget.DangerImportance
+			case r.written:
+				label.Importance = widget.SuccessImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.Refresh()
+		},
+	)
+
+	// 关闭对话框时取消尚未完成的写入
+	ctx, cancel := context.WithCancel(context.Background())
+	var writeButton *widget.Button
+	writeButton = widget.NewButton(tr("全部写入"), func() {
+		viewer, connCtx := getViewer()
+		if viewer == nil || !viewer.IsConnected() {
+			statusLabel.SetText(tr("请先连接PLC"))
+			return
+		}
+		writeButton.Disable()
+		statusLabel.SetText(tr("正在写入..."))
+		order := viewer.ByteOrder()
+		go func() {
+			written, failed := 0, 0
+			for _, r := range rows {
+				if r.err != nil || r.written {
+					continue
+				}
+				if ctx.Err() != nil || connCtx.Err() != nil {
+					break
+				}
+				data, err := s7viewer.EncodeValue(order, r.dataType, r.value)
+				if err == nil {
+					err = writeVValue(connCtx, viewer, r.addr, r.dataType, data)
+				}
+				fyne.DoAndWait(func() {
+					if err != nil {
+						r.err = err
+						failed++
+						log.Printf(tr("写入 %s 失败: %v"), r.addr, err)
+					} else {
+						r.written = true
+						written++
+						log.Printf(tr("已写入 %s = %s (%s)"), r.addr, r.value, r.dataType)
+					}
+					list.Refresh()
+				})
+			}
+			log.Printf(tr("批量写入 %s 完成: 成功%d行，失败%d行"), path, written, failed)
+			fyne.Do(func() {
+				statusLabel.SetText(fmt.Sprintf(tr("批量写入完成: 成功%d行，失败%d行"), written, failed))
+				if written > 0 && onWritten != nil {
+					onWritten()
+				}
+			})
+		}()
+	})
+	if isReadOnly() {
+		writeButton.Disable()
+	}
+	copyButton := widget.NewButton(tr("复制报告"), func() {
+		win.Clipboard().SetContent(batchWriteReport(rows))
+	})
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel(tr("文件: ")+path),
+			container.NewHBox(writeButton, copyButton, statusLabel),
+		),
+		nil, nil, nil,
+		list,
+	)
+	d := dialog.NewCustom(tr("批量写入"), tr("关闭"), content, win)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(640, 480))
+	d.Show()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+func TestParseBatchWrite(t *testing.T) {
+	// want 各数据行：行号、地址、类型、值，以及是否有错误
+	type row struct {
+		line     int
+		addr     string
+		dataType string
+		value    string
+		bad      bool
+	}
+	tests := []struct {
+		name string
+		text string
+		want []row
+	}{
+		{
+			"typed csv",
+			"地址,类型,值\nVW100,INT,1500\nVD4,REAL,2.5\n",
+			[]row{{2, "VW100", s7viewer.TypeInt, "1500", false}, {3, "VD4", s7viewer.TypeReal, "2.5", false}},
+		},
+		{
+			"type inferred from width",
+			"address,value\nVB0,255\nVW2,-1\nVD4,70000\nVR8,1.5\nV10.3,1\n",
+			[]row{
+				{2, "VB0", s7viewer.TypeByte, "255", false},
+				{3, "VW2", s7viewer.TypeInt, "-1", false},
+				{4, "VD4", s7viewer.TypeDInt, "70000", false},
+				{5, "VD8", s7viewer.TypeReal, "1.5", false},
+				{6, "V10.3", s7viewer.TypeBool, "1", false},
+			},
+		},
+		{
+			"bom, comments and tabs",
+			"\uFEFF# 机器参数\n地址\t数值\n\n// 速度\nVW20\t 300 \n",
+			[]row{{5, "VW20", s7viewer.TypeInt, "300", false}},
+		},
+		{
+			"bad rows kept with errors",
+			"地址,类型,值\nMB0,,1\nVW0,,\nVB0,,300\nVW0,BOOL,1\nXYZ,,1\nVW2,,7\n",
+			[]row{
+				{2, "MB0", s7viewer.TypeByte, "1", true},
+				{3, "VW0", s7viewer.TypeInt, "", true},
+				{4, "VB0", s7viewer.TypeByte, "300", true},
+				{5, "VW0", s7viewer.TypeBool, "1", true},
+				{6, "", "", "1", true},
+				{7, "VW2", s7viewer.TypeInt, "7", false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		rows, err := parseBatchWrite(strings.NewReader(tt.text))
+		if err != nil {
+			t.Errorf("%s: parseBatchWrite: %v", tt.name, err)
+			continue
+		}
+		if len(rows) != len(tt.want) {
+			t.Errorf("%s: %d rows, want %d", tt.name, len(rows), len(tt.want))
+			continue
+		}
+		for i, r := range rows {
+			w := tt.want[i]
+			var addr string
+			if r.addr != (s7Address{}) {
+				addr = r.addr.String()
+			}
+			if r.line != w.line || addr != w.addr || r.dataType != w.dataType || r.value != w.value || (r.err != nil) != w.bad {
+				t.Errorf("%s: row %d = {%d %s %s %q err=%v}, want %+v", tt.name, i, r.line, addr, r.dataType, r.value, r.err, w)
+			}
+		}
+	}
+}
+
+func TestParseBatchWriteFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"comments only", "# 参数\n// 无数据\n"},
+		{"no value column", "地址,类型\nVW0,INT\n"},
+		{"no header", "VW0,1\n"},
+		{"header only", "地址,值\n"},
+	}
+	for _, tt := range tests {
+		if rows, err := parseBatchWrite(strings.NewReader(tt.text)); err == nil {
+			t.Errorf("%s: parseBatchWrite = %d rows, want error", tt.name, len(rows))
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// beep 向终端输出响铃字符
+func beep() {
+	os.Stdout.WriteString("\a")
+}
@@ -0,0 +1,10 @@
+package main
+
+import "syscall"
+
+var procMessageBeep = syscall.NewLazyDLL("user32.dll").NewProc("MessageBeep")
+
+// beep 播放系统提示音
+func beep() {
+	procMessageBeep.Call(0xFFFFFFFF)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// bitStats 统计读取范围内每个位的上升沿、下降沿次数和接通、断开的累计时间。
+// 每次读取或监控扫描的数据都参与统计，可以发现刷新显示时看不到的抖动；两次数据之间的时间
+// 计入前一次的值。区域、起始地址或长度变化时重新统计。只在UI线程中使用。
+type bitStats struct {
+	area    string
+	start   int
+	since   time.Time // 开始统计的时间
+	last    time.Time // 上一次数据的时间
+	prev    []byte
+	rising  []int
+	falling []int
+	on, off []time.Duration
+}
+
+// update 与上一次的数据比较，累计变化的位的边沿次数和各位的接通、断开时间。
+// muted判断位是否在网格中被屏蔽，屏蔽的位不计边沿和接通、断开时间，nil表示没有屏蔽
+func (c *bitStats) update(t time.Time, area string, start int, data []byte, muted func(bitIndex int) bool) {
+	if area != c.area || start != c.start || len(data) != len(c.prev) {
+		n := len(data) * 8
+		*c = bitStats{area: area, start: start, since: t,
+			rising: make([]int, n), falling: make([]int, n), on: make([]time.Duration, n), off: make([]time.Duration, n)}
+	}
+	if c.prev != nil {
+		dt := t.Sub(c.last)
+		for i, b := range c.prev {
+			for bit := 0; bit < 8; bit++ {
+				switch {
+				case muted != nil && muted(i*8+bit):
+				case b&(0x80>>bit) != 0:
+					c.on[i*8+bit] += dt
+				default:
+					c.off[i*8+bit] += dt
+				}
+			}
+		}
+		for i, b := range data {
+			changed := b ^ c.prev[i]
+			if changed == 0 {
+				continue
+			}
+			for bit := 0; bit < 8; bit++ {
+				mask := byte(0x80) >> bit
+				if changed&mask == 0 || (muted != nil && muted(i*8+bit)) {
+					continue
+				}
+				if b&mask != 0 {
+					c.rising[i*8+bit]++
+				} else {
+					c.falling[i*8+bit]++
+				}
+			}
+		}
+	}
+	c.prev = append(c.prev[:0], data...)
+	c.last = t
+}
+
+// counts 返回范围内第bitIndex位（与网格的位索引相同）的边沿次数
+func (c *bitStats) counts(bitIndex int) (rising, falling int, ok bool) {
+	if bitIndex < 0 || bitIndex >= len(c.rising) {
+		return 0, 0, false
+	}
+	return c.rising[bitIndex], c.falling[bitIndex], true
+}
+
+// value 返回第bitIndex位最近一次的值
+func (c *bitStats) value(bitIndex int) int {
+	return int(c.prev[bitIndex/8]>>(7-bitIndex%8)) & 1
+}
+
+// duty 返回第bitIndex位的占空比（接通时间占比），还没有统计时间时ok为false
+func (c *bitStats) duty(bitIndex int) (duty float64, ok bool) {
+	total := c.on[bitIndex] + c.off[bitIndex]
+	if total <= 0 {
+		return 0, false
+	}
+	return float64(c.on[bitIndex]) / float64(total), true
+}
+
+// bits 返回统计的位数
+func (c *bitStats) bits() int {
+	return len(c.rising)
+}
+
+// reset 清零所有统计，下一次数据作为新的起点
+func (c *bitStats) reset() {
+	*c = bitStats{}
+}
+
+// edgeCount 单个位的边沿计数，用于状态表中的BOOL行，零值需用newEdgeCount初始化
+type edgeCount struct {
+	prev    int // 上一次的值，-1表示还没有值
+	rising  int
+	falling int
+}
+
+func newEdgeCount() edgeCount {
+	return edgeCount{prev: -1}
+}
+
+// update 用新值（0或1）累计边沿
+func (e *edgeCount) update(v int) {
+	switch {
+	case e.prev == 0 && v == 1:
+		e.rising++
+	case e.prev == 1 && v == 0:
+		e.falling++
+	}
+	e.prev = v
+}
+
+// formatEdges 格式化边沿次数，如 "↑3 ↓2"
+func formatEdges(rising, falling int) string {
+	return fmt.Sprintf("↑%d ↓%d", rising, falling)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+var bitStatsHeaders = []string{"地址", "标签", "当前值", "上升沿", "下降沿", "接通时间", "断开时间", "占空比"}
+
+// bitStatsRefresh 监控时统计表刷新的最短间隔
+const bitStatsRefresh = 500 * time.Millisecond
+
+// bitStatsTable 位统计表：各位的边沿次数、累计接通和断开时间及占空比。
+// 默认只列出有变化或有标签的位。只在UI线程中使用。
+type bitStatsTable struct {
+	stats   *bitStats
+	labelOf func(addr string) string
+	rows    []int // 显示的位索引
+	all     bool  // 列出范围内所有的位
+	shown   time.Time
+	table   *widget.Table
+	summary *widget.Label
+	content fyne.CanvasObject
+}
+
+func newBitStatsTable(stats *bitStats, labelOf func(addr string) string) *bitStatsTable {
+	t := &bitStatsTable{stats: stats, labelOf: labelOf, summary: widget.NewLabel("")}
+	t.table = widget.NewTable(
+		func() (int, int) { return len(t.rows), len(bitStatsHeaders) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(t.cellText(id.Row, id.Col))
+		})
+	t.table.ShowHeaderRow = true
+	t.table.CreateHeader = func() fyne.CanvasObject { return widget.NewLabel("") }
+	t.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 && id.Col < len(bitStatsHeaders) {
+			o.(*widget.Label).SetText(tr(bitStatsHeaders[id.Col]))
+		}
+	}
+	for col, width := range []float32{100, 160, 70, 80, 80, 110, 110, 80} {
+		t.table.SetColumnWidth(col, width)
+	}
+
+	allCheck := widget.NewCheck(tr("列出所有位"), func(on bool) {
+		t.all = on
+		t.refresh()
+	})
+	t.content = container.NewBorder(
+		container.NewHBox(allCheck, t.summary),
+		widget.NewLabel(tr("每次读取和监控扫描的数据都参与统计，两次扫描之间的时间计入前一次的值；“边沿计数清零”或“清除显示”重新开始。")),
+		nil, nil,
+		t.table,
+	)
+	return t
+}
+
+// update 统计数据变化后调用，监控时按bitStatsRefresh限制刷新频率
+func (t *bitStatsTable) update() {
+	if time.Since(t.shown) < bitStatsRefresh && t.stats.bits() > 0 {
+		return
+	}
+	t.refresh()
+}
+
+// refresh 重新选择显示的位并刷新表格
+func (t *bitStatsTable) refresh() {
+	t.shown = time.Now()
+	t.rows = t.rows[:0]
+	for i := 0; i < t.stats.bits(); i++ {
+		if t.all || t.stats.rising[i]+t.stats.falling[i] > 0 || t.labelOf(t.address(i)) != "" {
+			t.rows = append(t.rows, i)
+		}
+	}
+	if t.stats.bits() > 0 {
+		t.summary.SetText(fmt.Sprintf(tr("统计时长 %s，%d个位有变化"), formatStatDuration(t.stats.last.Sub(t.stats.since)), t.changedBits()))
+	} else {
+		t.summary.SetText(tr("读取或监控后开始统计"))
+	}
+	t.table.Refresh()
+}
+
+func (t *bitStatsTable) changedBits() int {
+	n := 0
+	for i := 0; i < t.stats.bits(); i++ {
+		if t.stats.rising[i]+t.stats.falling[i] > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *bitStatsTable) address(bitIndex int) string {
+	return bitAddressName(t.stats.area, t.stats.start, bitIndex)
+}
+
+func (t *bitStatsTable) cellText(row, col int) string {
+	if row < 0 || row >= len(t.rows) {
+		return ""
+	}
+	i := t.rows[row]
+	if i >= t.stats.bits() {
+		return ""
+	}
+	switch col {
+	case 0:
+		return t.address(i)
+	case 1:
+		return t.labelOf(t.address(i))
+	case 2:
+		return fmt.Sprint(t.stats.value(i))
+	case 3:
+		return fmt.Sprint(t.stats.rising[i])
+	case 4:
+		return fmt.Sprint(t.stats.falling[i])
+	case 5:
+		return formatStatDuration(t.stats.on[i])
+	case 6:
+		return formatStatDuration(t.stats.off[i])
+	case 7:
+		if duty, ok := t.stats.duty(i); ok {
+			return fmt.Sprintf("%.1f%%", duty*100)
+		}
+	}
+	return ""
+}
+
+// formatStatDuration 格式化累计时间，如 1:02:03.4
+func formatStatDuration(d time.Duration) string {
+	d = d.Round(100 * time.Millisecond)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	s := float64(d%time.Minute) / float64(time.Second)
+	return fmt.Sprintf("%d:%02d:%04.1f", h, m, s)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 突发采样设置的偏好键
+const (
+	prefBurstAddr    = "burst.addr"
+	prefBurstLength  = "burst.length"
+	prefBurstSeconds = "burst.seconds"
+)
+
+// 突发采样的限制：时长和采样数都有上限，避免长时间占满连接和内存
+const (
+	maxBurstSeconds = 60
+	maxBurstSamples = 100000
+)
+
+// newBurstPanel 创建突发采样面板：在短时间内背靠背地读取一小段范围，期间不刷新界面，
+// 结束后以show显示带时间戳的采样（在UI线程中调用），可保存为录制文件并用open打开回放。
+func newBurstPanel(prefs fyne.Preferences, win fyne.Window, getViewer func() (*s7viewer.Viewer, context.Context), plc func() string,
+	show func(area string, start int, samples []s7viewer.Sample), open func(path string)) fyne.CanvasObject {
+	addrEntry := widget.NewEntry()
+	addrEntry.SetPlaceHolder(tr("起始字节，如 VB100"))
+	addrEntry.SetText(prefs.String(prefBurstAddr))
+	lengthEntry := widget.NewEntry()
+	lengthEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefBurstLength, 4)))
+	secondsEntry := widget.NewEntry()
+	secondsEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefBurstSeconds, 5)))
+	resultLabel := widget.NewLabel("")
+
+	// 最近一次突发采样的结果，只在UI线程中访问
+	var (
+		lastArea    string
+		lastStart   int
+		lastSamples []s7viewer.Sample
+		cancel      context.CancelFunc
+	)
+
+	var startButton, stopButton, saveButton *widget.Button
+	startButton = widget.NewButton(tr("开始突发采样"), func() {
+		viewer, connCtx := getViewer()
+		if viewer == nil {
+			resultLabel.SetText(tr("请先连接PLC"))
+			return
+		}
+		if viewer.IsMonitoring() {
+			resultLabel.SetText(tr("请先停止监控，突发采样需要独占连接"))
+			return
+		}
+		addr, err := parseAddress(strings.TrimSpace(addrEntry.Text))
+		if err != nil {
+			resultLabel.SetText(err.Error())
+			return
+		}
+		length, lerr := strconv.Atoi(strings.TrimSpace(lengthEntry.Text))
+		seconds, serr := strconv.Atoi(strings.TrimSpace(secondsEntry.Text))
+		if lerr != nil || serr != nil || length <= 0 || seconds <= 0 || seconds > maxBurstSeconds {
+			resultLabel.SetText(fmt.Sprintf(tr("长度应为正整数，时长应为1到%d秒"), maxBurstSeconds))
+			return
+		}
+		prefs.SetString(prefBurstAddr, strings.TrimSpace(addrEntry.Text))
+		prefs.SetInt(prefBurstLength, length)
+		prefs.SetInt(prefBurstSeconds, seconds)
+
+		ctx, stop := context.WithCancel(connCtx)
+		cancel = stop
+		startButton.Disable()
+		stopButton.Enable()
+		saveButton.Disable()
+		resultLabel.SetText(fmt.Sprintf(tr("采样中，%d秒后结束…"), seconds))
+		go func() {
+			samples, err := viewer.Burst(ctx, addr.area, addr.byteOff, length, time.Duration(seconds)*time.Second, maxBurstSamples)
+			stop()
+			fyne.Do(func() {
+				cancel = nil
+				startButton.Enable()
+				stopButton.Disable()
+				if err != nil {
+					log.Printf(tr("突发采样失败: %v"), err)
+				}
+				if len(samples) == 0 {
+					if err != nil {
+						resultLabel.SetText(fmt.Sprintf(tr("突发采样失败: %v"), err))
+					} else {
+						resultLabel.SetText(tr("没有得到采样"))
+					}
+					return
+				}
+				lastArea, lastStart, lastSamples = addr.area, addr.byteOff, samples
+				text := summarizeBurst(samples)
+				if err != nil {
+					text += "  " + fmt.Sprintf(tr("突发采样失败: %v"), err)
+				}
+				resultLabel.SetText(text)
+				log.Print(text)
+				saveButton.Enable()
+				show(addr.area, addr.byteOff, samples)
+			})
+		}()
+	})
+	stopButton = widget.NewButton(tr("停止"), func() {
+		if cancel != nil {
+			cancel()
+		}
+	})
+	stopButton.Disable()
+
+	saveButton = widget.NewButton(tr("保存为录制"), func() {
+		area, start, samples := lastArea, lastStart, lastSamples
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			// 录制器自行创建带开始时间的文件，这里只取路径
+			base := writer.URI().Path()
+			writer.Close()
+			r := newRecorder(base)
+			for _, s := range samples {
+				if err := r.record(s.Time, plc(), area, start, s.Data); err != nil {
+					dialog.ShowError(err, win)
+					return
+				}
+			}
+			if _, err := r.close(); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			log.Printf(tr("已保存突发采样到 %s"), r.path)
+			open(r.path)
+		}, win)
+		saveDialog.SetFileName("burst.rec")
+		saveDialog.Show()
+	})
+	saveButton.Disable()
+
+	return container.NewVBox(
+		widget.NewLabel(tr("突发采样在短时间内不等扫描周期连续读取一小段范围，期间不刷新界面，用于捕捉1秒扫描看不到的快速握手信号。结果显示在时序图中。")),
+		widget.NewForm(
+			widget.NewFormItem(tr("起始地址:"), addrEntry),
+			widget.NewFormItem(tr("长度(字节):"), lengthEntry),
+			widget.NewFormItem(tr("时长(秒):"), secondsEntry),
+		),
+		container.NewHBox(startButton, stopButton, saveButton),
+		resultLabel,
+	)
+}
+
+// summarizeBurst 采样数、总时长和采样间隔的统计
+func summarizeBurst(samples []s7viewer.Sample) string {
+	total := samples[len(samples)-1].Time.Sub(samples[0].Time)
+	if len(samples) < 2 {
+		return fmt.Sprintf(tr("%d个采样"), len(samples))
+	}
+	minGap, maxGap := total, time.Duration(0)
+	for i := 1; i < len(samples); i++ {
+		gap := samples[i].Time.Sub(samples[i-1].Time)
+		minGap, maxGap = min(minGap, gap), max(maxGap, gap)
+	}
+	avg := total / time.Duration(len(samples)-1)
+	return fmt.Sprintf(tr("%d个采样，用时 %s，间隔平均 %s、最小 %s、最大 %s"), len(samples),
+		formatTimingDelta(total), formatTimingDelta(avg), formatTimingDelta(minGap), formatTimingDelta(maxGap))
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// cliOptions 命令行参数，除api、grpc和sim外仅在无界面模式下使用
+type cliOptions struct {
+	api      string
+	grpc     string
+	ip       string
+	rack     int
+	slot     int
+	port     int
+	timeout  time.Duration
+	area     string
+	address  string
+	length   int
+	monitor  bool
+	interval time.Duration
+	format   string
+	vAccess  string
+	out      string
+	watch    string
+
+	retries    int
+	retryDelay time.Duration
+
+	sim        string
+	simPattern string
+	readOnly   bool
+	config     string
+}
+
+// registerCLIFlags 注册命令行参数
+func registerCLIFlags(fs *flag.FlagSet) *cliOptions {
+	o := &cliOptions{}
+	fs.StringVar(&o.api, "api", "", tr("启用HTTP接口的监听地址，如 :8080"))
+	fs.StringVar(&o.grpc, "grpc", "", tr("启用gRPC接口的监听地址，如 :50051"))
+	fs.StringVar(&o.ip, "ip", defaultIP, tr("PLC IP地址"))
+	fs.IntVar(&o.rack, "rack", s7viewer.DefaultRack, tr("机架号"))
+	fs.IntVar(&o.slot, "slot", s7viewer.DefaultSlot, tr("槽位号"))
+	fs.IntVar(&o.port, "port", s7viewer.DefaultPort, tr("TCP端口"))
+	fs.DurationVar(&o.timeout, "timeout", s7viewer.DefaultTimeout, tr("连接超时"))
+	fs.IntVar(&o.retries, "retries", s7viewer.DefaultRetryPolicy.Count, tr("读写超时或连接断开后的重试次数，0表示不重试"))
+	fs.DurationVar(&o.retryDelay, "retry-delay", s7viewer.DefaultRetryPolicy.Delay, tr("每次重试前的等待时间"))
+	fs.StringVar(&o.area, "area", s7viewer.AreaV, tr("存储区（-addr为纯数字时使用）: ")+strings.Join(s7viewer.MemoryAreas, "/"))
+	fs.StringVar(&o.address, "addr", "100", tr("起始地址，纯数字或S7地址，如 VW100、M10.1、IW4"))
+	fs.IntVar(&o.length, "len", 0, tr("读取长度（字节，T/C为个数），0表示按地址宽度"))
+	fs.BoolVar(&o.monitor, "monitor", false, tr("持续监控，按Ctrl+C停止；界面中为启动后自动连接并监控第一个标签页"))
+	fs.DurationVar(&o.interval, "interval", s7viewer.DefaultScanInterval, tr("监控扫描周期"))
+	fs.StringVar(&o.format, "format", "hex", tr("输出格式: hex/dec/bin/json/ndjson，json和ndjson输出带解码值的记录"))
+	fs.StringVar(&o.vAccess, "vaccess", "auto", tr("V区访问方式: auto/db1/mb"))
+	fs.StringVar(&o.out, "out", "", tr("scan命令的输出文件，为空时以十六进制输出到标准输出"))
+	fs.StringVar(&o.watch, "watch", "", tr("tui命令显示及json输出解码的变量，逗号分隔，可用“:类型”指定类型，如 VW100,VD4:REAL,M10.0"))
+	fs.StringVar(&o.sim, "sim", "", tr("启动内置S7模拟器的监听地址，如 127.0.0.1:1102，连接时填写该IP和端口"))
+	fs.StringVar(&o.simPattern, "sim-pattern", simPatternAll, tr("模拟器数据变化模式: ")+strings.Join(simPatterns, "/"))
+	fs.StringVar(&o.config, "config", "", tr("启动时载入的配置文件（YAML或JSON），定义连接、状态表、轮询组、日志和报警"))
+	fs.BoolVar(&o.readOnly, "readonly", false, tr("只读模式：禁止写入、强制、RUN/STOP和设置时钟，界面中不能关闭"))
+	return o
+}
+
+// runHeadless 不创建任何窗口，连接PLC读取或监控指定范围并输出到标准输出，返回进程退出码。
+// 指定-api时持续运行并提供HTTP接口，直到按Ctrl+C。
+func runHeadless(o *cliOptions) int {
+	area, start, length, err := o.parseRange()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	format := formatHex
+	var values []watchSpec // json/ndjson记录中解码的变量
+	switch o.format {
+	case "hex":
+	case "dec":
+		format = formatDec
+	case "bin":
+		format = formatBin
+	case "json", "ndjson":
+		if values, err = o.recordValues(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	default:
+		fmt.Fprintf(os.Stderr, tr("无效的输出格式: %s\n"), o.format)
+		return 2
+	}
+
+	viewer, code := o.connect()
+	if viewer == nil {
+		return code
+	}
+	defer viewer.Disconnect()
+
+	printData := func(data []byte) {
+		liveStream.broadcast(o.ip, area, start, data)
+		grpcStream.broadcast(o.ip, viewer.ByteOrder(), area, start, data)
+		if o.format == "json" || o.format == "ndjson" {
+			writeCLIRecord(os.Stdout, o.format == "json", newCLIRecord(o.ip, viewer.ByteOrder(), area, start, data, values))
+			return
+		}
+		fmt.Printf("%s %s: %s\n", time.Now().Format("2006-01-02 15:04:05.000"), s7viewer.ByteAddressName(area, start), format(data))
+	}
+
+	resolve := func(plc string) (*s7viewer.Viewer, error) {
+		if plc != "" && plc != o.ip {
+			return nil, fmt.Errorf(tr("未连接PLC %s"), plc)
+		}
+		return viewer, nil
+	}
+	if o.api != "" {
+		metrics := func() []metricsSource {
+			return []metricsSource{{plc: o.ip, viewer: viewer}}
+		}
+		startAPIServer(o.api, metrics, resolve)
+	}
+	if o.grpc != "" {
+		startGRPCServer(o.grpc, resolve)
+	}
+
+	// Ctrl+C取消尚未完成的读取并结束监控
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if !o.monitor && o.api == "" && o.grpc == "" {
+		data, err := viewer.ReadRange(ctx, area, start, length)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printData(data)
+		return 0
+	}
+
+	if o.monitor {
+		viewer.SetScanInterval(o.interval)
+		viewer.Subscribe(ctx, area, start, length, printData)
+		log.Printf(tr("开始监控 %s, 长度%d, 按Ctrl+C停止"), s7viewer.ByteAddressName(area, start), length)
+	}
+	<-ctx.Done()
+	viewer.Unsubscribe()
+	return 0
+}
+
+// connect 按命令行参数连接PLC，失败时输出错误并返回nil和进程退出码
+func (o *cliOptions) connect() (*s7viewer.Viewer, int) {
+	viewer := s7viewer.New()
+	viewer.SetWriteHandler(auditWriteHandler(func() string { return o.ip }))
+	viewer.SetReadOnly(o.readOnly || persistedReadOnly())
+	switch o.vAccess {
+	case "auto":
+	case "db1":
+		viewer.SetVAccess(s7viewer.VAccessDB1)
+	case "mb":
+		viewer.SetVAccess(s7viewer.VAccessMB)
+	default:
+		fmt.Fprintf(os.Stderr, tr("无效的V区访问方式: %s\n"), o.vAccess)
+		return nil, 2
+	}
+
+	cfg := s7viewer.Config{IP: o.ip, Rack: o.rack, Slot: o.slot, Port: o.port, Timeout: o.timeout,
+		Retry: s7viewer.RetryPolicy{Count: o.retries, Delay: o.retryDelay}}
+	if err := viewer.Connect(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 1
+	}
+	return viewer, 0
+}
+
+// parseRange 解析地址和长度，S7地址隐含存储区和默认长度
+func (o *cliOptions) parseRange() (area string, start, length int, err error) {
+	area, length = strings.ToUpper(o.area), 1
+	if start, err = strconv.Atoi(strings.TrimSpace(o.address)); err != nil {
+		addr, err := parseAddress(o.address)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		area, start, length = addr.area, addr.byteOff, addr.length()
+	} else if !containsString(s7viewer.MemoryAreas, area) {
+		return "", 0, 0, fmt.Errorf(tr("不支持的存储区: %s"), o.area)
+	}
+	if o.length > 0 {
+		length = o.length
+	}
+	return area, start, length, nil
+}
+
+// guiPresetFlags 界面启动时填入第一个标签页的连接参数
+var guiPresetFlags = []string{"ip", "rack", "slot", "port", "timeout", "retries", "retry-delay", "area", "addr", "len", "interval"}
+
+// guiPreset 把命令行中明确指定的连接参数组成配置，供界面填入第一个标签页，如桌面快捷方式
+// “-ip 192.168.1.11 -addr 100 -len 4 -monitor”。没有指定任何连接参数时ok为false；未指定-ip时IP为空，保留标签页原有的地址
+func (o *cliOptions) guiPreset(fs *flag.FlagSet) (pr profile, ok bool, err error) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range guiPresetFlags {
+		ok = ok || set[name]
+	}
+	if !ok {
+		return profile{}, false, nil
+	}
+
+	pr = profile{
+		Rack:         o.rack,
+		Slot:         o.slot,
+		Port:         o.port,
+		TimeoutMs:    int(o.timeout / time.Millisecond),
+		Retries:      o.retries,
+		RetryDelayMs: int(o.retryDelay / time.Millisecond),
+	}
+	if set["ip"] {
+		pr.IP = strings.TrimSpace(o.ip)
+	}
+	if set["area"] || set["addr"] || set["len"] {
+		area, start, length, err := o.parseRange()
+		if err != nil {
+			return profile{}, false, err
+		}
+		pr.Area, pr.Address, pr.Length = area, strconv.Itoa(start), length
+	}
+	if set["interval"] {
+		pr.ScanMs = int(o.interval / time.Millisecond)
+	}
+	return pr, true, nil
+}
+
+// 输出格式
+func formatHex(data []byte) string {
+	return fmt.Sprintf("% X", data)
+}
+
+func formatDec(data []byte) string {
+	values := convertBytesTo16BitInts(data)
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatBin(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%08b", b)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 子命令的进程退出码：0成功，1连接或读写PLC失败，2命令行参数错误
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// cliCommandUsage 子命令的用法，参数错误时输出
+const cliCommandUsage = `子命令（不打开窗口，参数可写在子命令前后）:
+  read ADDR [LEN]             读取一次，如 read V100 4、read MW10
+  write ADDR VALUE            写入V区，如 write VW200 1234、write V10.3 1
+  monitor ADDR [LEN]          持续监控，如 monitor V100 2 --interval 500ms，按Ctrl+C停止
+  scan START LEN              读取整段存储区（-area，默认V），如 scan 0 4096 --out dump.bin
+  tui ADDR [LEN]              终端界面，显示位网格和 -watch 指定的变量，如 tui V100 16 --watch VW100,VD4:REAL
+退出码: 0成功，1连接或读写PLC失败，2参数错误
+`
+
+// isCLICommand 返回args[0]是否为子命令
+func isCLICommand(name string) bool {
+	switch name {
+	case "read", "write", "monitor", "scan", "tui":
+		return true
+	}
+	return false
+}
+
+// runCLICommand 执行子命令并返回进程退出码。fs为已解析过全局参数的FlagSet，
+// 子命令之后的参数再用它解析，因此 -ip、-interval 等可写在子命令前后。
+func runCLICommand(o *cliOptions, fs *flag.FlagSet, args []string) int {
+	name := args[0]
+	pos, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return exitUsage
+	}
+	usage := func(format string, a ...any) int {
+		fmt.Fprintf(os.Stderr, format+"\n", a...)
+		fmt.Fprint(os.Stderr, tr(cliCommandUsage))
+		return exitUsage
+	}
+
+	switch name {
+	case "read", "monitor", "tui":
+		if len(pos) < 1 || len(pos) > 2 {
+			return usage(tr("%s需要地址和可选的长度"), name)
+		}
+		o.address, o.length, o.monitor = pos[0], 0, name == "monitor"
+		if len(pos) == 2 {
+			if o.length, err = strconv.Atoi(pos[1]); err != nil || o.length <= 0 {
+				return usage(tr("无效的长度: %q"), pos[1])
+			}
+		}
+		if name == "tui" {
+			return runTUI(o)
+		}
+		return runHeadless(o)
+	case "write":
+		if len(pos) != 2 {
+			return usage(tr("write需要地址和值"))
+		}
+		return runWriteCommand(o, pos[0], pos[1])
+	case "scan":
+		if len(pos) != 2 {
+			return usage(tr("scan需要起始地址和长度"))
+		}
+		start, err1 := strconv.Atoi(pos[0])
+		length, err2 := strconv.Atoi(pos[1])
+		if err1 != nil || err2 != nil || start < 0 || length <= 0 {
+			return usage(tr("无效的范围: %s %s"), pos[0], pos[1])
+		}
+		return runScanCommand(o, start, length)
+	}
+	return usage(tr("未知的命令: %s"), name)
+}
+
+// parseInterspersed 解析夹在位置参数之间的选项，返回位置参数。
+// 负数按位置参数处理（如 write VW200 -5），"--" 之后全部为位置参数。
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		for len(args) > 0 && (!strings.HasPrefix(args[0], "-") || isNumber(args[0])) {
+			pos, args = append(pos, args[0]), args[1:]
+		}
+		if len(args) == 0 {
+			return pos, nil
+		}
+		if args[0] == "--" {
+			return append(pos, args[1:]...), nil
+		}
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(pos, rest...), nil
+		}
+		args = rest
+	}
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// runWriteCommand 写入V区的位或数值，地址格式与HTTP接口相同
+func runWriteCommand(o *cliOptions, address, value string) int {
+	addr, dataType, err := parseAPIAddress(address)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	// 命令行没有字节顺序选项，按PLC本身的大端顺序编码
+	data, err := s7viewer.EncodeValue(s7viewer.OrderBigEndian, dataType, value)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	viewer, code := o.connect()
+	if viewer == nil {
+		return code
+	}
+	defer viewer.Disconnect()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if dataType == s7viewer.TypeBool {
+		err = viewer.WriteVBit(ctx, addr.byteOff, addr.bit, data[0] == 1)
+	} else {
+		err = viewer.WriteV(ctx, addr.byteOff, data)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	fmt.Printf("%s = %s\n", addr, value)
+	return exitOK
+}
+
+// runScanCommand 读取整段存储区，指定-out时保存为二进制文件，否则每行16字节输出十六进制
+func runScanCommand(o *cliOptions, start, length int) int {
+	area := strings.ToUpper(o.area)
+	if !containsString(s7viewer.MemoryAreas, area) {
+		fmt.Fprintf(os.Stderr, tr("不支持的存储区: %s")+"\n", o.area)
+		return exitUsage
+	}
+	viewer, code := o.connect()
+	if viewer == nil {
+		return code
+	}
+	defer viewer.Disconnect()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	data, err := viewer.ReadRange(ctx, area, start, length)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if o.out != "" {
+		if err := os.WriteFile(o.out, data, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		fmt.Fprintf(os.Stderr, tr("已保存 %s 起的 %d 字节到 %s")+"\n", s7viewer.ByteAddressName(area, start), len(data), o.out)
+		return exitOK
+	}
+	for i := 0; i < len(data); i += 16 {
+		line := data[i:min(i+16, len(data))]
+		fmt.Printf("%-8s %s\n", s7viewer.ByteAddressName(area, start+i), formatHex(line))
+	}
+	return exitOK
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// cliRecord 无界面模式 -format json/ndjson 输出的一次读取记录。
+// json为缩进的记录，ndjson每次读取一行，适合监控时用管道交给其他程序解析。
+type cliRecord struct {
+	Time    time.Time  `json:"time"`
+	PLC     string     `json:"plc"`
+	Address string     `json:"address"` // 起始地址，如 VB100
+	Bytes   []int      `json:"bytes"`
+	Hex     string     `json:"hex"`
+	Values  []cliValue `json:"values,omitempty"`
+}
+
+// cliValue 记录中一个变量的解码值，value按类型为布尔、数值或字符串，解码失败时只有error
+type cliValue struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Value   any    `json:"value,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// recordValues 返回记录中要解码的变量：-addr为带宽度的S7地址（如 VW100、VR20）时为该变量，再加上 -watch 列出的变量
+func (o *cliOptions) recordValues() ([]watchSpec, error) {
+	specs, err := parseWatchList(o.watch)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := strconv.Atoi(strings.TrimSpace(o.address)); err != nil {
+		if addr, dataType, err := parseWatchAddress(o.address); err == nil {
+			specs = append([]watchSpec{{addr: addr, dataType: dataType}}, specs...)
+		}
+	}
+	return specs, nil
+}
+
+// newCLIRecord 由一次读取的数据按字节顺序order生成记录，不在读取范围内的变量不输出
+func newCLIRecord(plc, order, area string, start int, data []byte, specs []watchSpec) cliRecord {
+	r := cliRecord{
+		Time:    time.Now(),
+		PLC:     plc,
+		Address: s7viewer.ByteAddressName(area, start),
+		Bytes:   make([]int, len(data)),
+		Hex:     formatHex(data),
+	}
+	for i, b := range data {
+		r.Bytes[i] = int(b)
+	}
+	for _, s := range specs {
+		v, ok, err := s.decodeFrom(order, area, start, data)
+		if !ok {
+			continue
+		}
+		value := cliValue{Address: s.addr.String(), Type: s.dataType}
+		if err != nil {
+			value.Error = err.Error()
+		} else if f, isReal := v.(float32); isReal && (math.IsNaN(float64(f)) || math.IsInf(float64(f), 0)) {
+			// JSON没有NaN和无穷大，按文本输出
+			value.Value = strconv.FormatFloat(float64(f), 'g', -1, 32)
+		} else {
+			value.Value = v
+		}
+		r.Values = append(r.Values, value)
+	}
+	return r
+}
+
+// writeCLIRecord 输出一条记录，indent为true时缩进（json），否则占一行（ndjson）
+func writeCLIRecord(w io.Writer, indent bool, r cliRecord) {
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(r); err != nil {
+		log.Printf(tr("输出记录失败: %v"), err)
+	}
+}
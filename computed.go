@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// prefComputedTags 计算变量设置的偏好键
+const prefComputedTags = "computed.tags"
+
+// computedType 快照中计算变量的类型
+const computedType = "EXPR"
+
+// computedNamePattern 计算变量的名称：字母或下划线开头，可含中文
+var computedNamePattern = regexp.MustCompile(`^[\p{L}_][\p{L}\d_]*$`)
+
+// computedTag 一个计算变量，如 flow = (VW100 - 6400) * 0.01
+type computedTag struct {
+	name string
+	expr string
+	eval exprFunc
+}
+
+// computedSet 一组计算变量，按定义顺序求值，后面的可以引用前面的。specs为表达式引用的PLC地址。
+type computedSet struct {
+	tags  []computedTag
+	specs []watchSpec
+}
+
+// parseComputedTags 解析计算变量，每行（或分号分隔的每项）为“名称 = 表达式”。
+// 表达式中可引用地址（VW100、V10.0、VR20、AIW16、T37）和前面定义的计算变量，
+// 支持 + - * / %、比较、&& || !、括号和函数 abs、sqrt、round、min、max，真为1、假为0。
+func parseComputedTags(text string) (*computedSet, error) {
+	set := &computedSet{}
+	defined := make(map[string]bool)
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ';' || r == '；' }) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, expr, ok := strings.Cut(line, "=")
+		name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+		if !ok || expr == "" {
+			return nil, fmt.Errorf(tr("无效的计算变量: %q，示例: flow = (VW100 - 6400) * 0.01"), strings.TrimSpace(line))
+		}
+		if !computedNamePattern.MatchString(name) {
+			return nil, fmt.Errorf(tr("无效的计算变量名称: %q"), name)
+		}
+		if _, _, err := parseWatchAddress(name); err == nil {
+			return nil, fmt.Errorf(tr("计算变量名称 %s 不能是地址"), name)
+		}
+		if defined[name] {
+			return nil, fmt.Errorf(tr("计算变量 %s 重复定义"), name)
+		}
+		eval, err := compileExpr(expr, func(ident string) (string, error) {
+			if defined[ident] {
+				return ident, nil
+			}
+			addr, dataType, err := parseWatchAddress(ident)
+			if err != nil {
+				return "", fmt.Errorf(tr("未定义的变量 %s"), ident)
+			}
+			spec := watchSpec{addr: addr, dataType: dataType}
+			set.addSpec(spec)
+			return spec.specKey(), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf(tr("计算变量 %s: %v"), name, err)
+		}
+		set.tags = append(set.tags, computedTag{name: name, expr: expr, eval: eval})
+		defined[name] = true
+	}
+	return set, nil
+}
+
+// addSpec 记录表达式引用的地址，同一地址只读取一次
+func (c *computedSet) addSpec(s watchSpec) {
+	for _, have := range c.specs {
+		if have.addr == s.addr && have.dataType == s.dataType {
+			return
+		}
+	}
+	c.specs = append(c.specs, s)
+}
+
+// specKey 地址在求值时的变量名，与表达式中的写法一致：VR按REAL读取，其他为s7Address.String()
+func (s watchSpec) specKey() string {
+	key := s.addr.String()
+	if s.dataType == s7viewer.TypeReal {
+		key = "VR" + key[2:]
+	}
+	return key
+}
+
+// tag 该行的变量名：计算变量的名称或地址
+func (s watchSpec) tag() string {
+	if s.computed != "" {
+		return s.computed
+	}
+	return s.addr.String()
+}
+
+// formatComputed 格式化计算结果，最多10位有效数字，避免显示 12.340000000000001
+func formatComputed(v float64) string {
+	return strconv.FormatFloat(v, 'g', 10, 64)
+}
+
+// evaluate 按定义顺序求值，vars为引用地址的原始值，结果和出错的变量的错误分别返回
+func (c *computedSet) evaluate(vars map[string]float64) (map[string]float64, map[string]error) {
+	values := make(map[string]float64, len(vars)+len(c.tags))
+	for k, v := range vars {
+		values[k] = v
+	}
+	results := make(map[string]float64, len(c.tags))
+	errs := make(map[string]error)
+	for _, t := range c.tags {
+		v, err := t.eval(values)
+		if err != nil {
+			errs[t.name] = err
+			continue
+		}
+		values[t.name], results[t.name] = v, v
+	}
+	return results, errs
+}
+
+// has 是否定义了名为name的计算变量
+func (c *computedSet) has(name string) bool {
+	for _, t := range c.tags {
+		if t.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// 当前生效的计算变量及最近一次的计算结果，状态表每次扫描时求值，趋势图读取结果
+var (
+	computedMu     sync.Mutex
+	activeComputed *computedSet
+	latestComputed map[string]float64
+)
+
+// currentComputed 返回当前的计算变量，未设置时为nil
+func currentComputed() *computedSet {
+	computedMu.Lock()
+	defer computedMu.Unlock()
+	return activeComputed
+}
+
+// setComputedResults 保存最近一次的计算结果
+func setComputedResults(results map[string]float64) {
+	computedMu.Lock()
+	latestComputed = results
+	computedMu.Unlock()
+}
+
+// computedValue 返回计算变量最近一次的值
+func computedValue(name string) (float64, bool) {
+	computedMu.Lock()
+	defer computedMu.Unlock()
+	v, ok := latestComputed[name]
+	return v, ok
+}
+
+// newComputedSettings 创建计算变量设置，修改后立即生效，并调用onChange（在UI线程中）
+func newComputedSettings(prefs fyne.Preferences, onChange func()) fyne.CanvasObject {
+	entry := widget.NewMultiLineEntry()
+	entry.SetMinRowsVisible(3)
+	entry.SetPlaceHolder(tr("每行一个：名称 = 表达式，如 flow = (VW100 - 6400) * 0.01 或 fault = V10.0 && !V10.1"))
+	statusLabel := widget.NewLabel("")
+	apply := func(text string) {
+		set, err := parseComputedTags(text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf(tr("已定义%d个计算变量"), len(set.tags)))
+		prefs.SetString(prefComputedTags, text)
+		if len(set.tags) == 0 {
+			set = nil
+		}
+		computedMu.Lock()
+		activeComputed = set
+		computedMu.Unlock()
+		if onChange != nil {
+			onChange()
+		}
+	}
+	entry.SetText(prefs.String(prefComputedTags))
+	apply(entry.Text)
+	entry.OnChanged = apply
+
+	return container.NewVBox(
+		widget.NewLabel(tr("计算变量每次扫描时按引用的地址的原始值重新计算，在状态表的地址栏填写名称即可显示、设置报警和导出快照，也可加入趋势图。")),
+		entry,
+		statusLabel,
+	)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"gopkg.in/yaml.v3"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// plantConfig 启动时用 -config 载入的配置文件（YAML或JSON），描述一套现场的连接、状态表、日志和报警，
+// 便于复现和纳入版本管理。载入时写入偏好设置，界面中的修改照常保存，不会写回配置文件。
+type plantConfig struct {
+	// Connections 每台PLC一个标签页，字段与保存的连接配置相同
+	Connections []configConnection `json:"connections"`
+	// Watch 状态表的变量，替换原有的行
+	Watch   []configTag    `json:"watch,omitempty"`
+	Logging *configLogging `json:"logging,omitempty"`
+	Alarms  *configAlarms  `json:"alarms,omitempty"`
+}
+
+// configConnection 一台PLC的连接
+type configConnection struct {
+	profile
+	// Monitor 启动后自动连接并开始监控
+	Monitor bool `json:"monitor,omitempty"`
+}
+
+// UnmarshalJSON 解析一个连接，省略的机架号和槽位号使用默认值
+func (c *configConnection) UnmarshalJSON(data []byte) error {
+	type plain configConnection
+	p := plain{profile: profile{Rack: s7viewer.DefaultRack, Slot: s7viewer.DefaultSlot}}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return err
+	}
+	*c = configConnection(p)
+	return nil
+}
+
+// configTag 状态表中的一个变量，Group为轮询组（如 "1 s" 或 "500ms"），为空时随扫描读取
+type configTag struct {
+	Address string `json:"address"`
+	Type    string `json:"type,omitempty"`
+	Alarm   string `json:"alarm,omitempty"`
+	Group   string `json:"group,omitempty"`
+}
+
+// configLogging 数据记录和转发的设置，省略的部分保持原设置
+type configLogging struct {
+	CSV *struct {
+		Path     string `json:"path"`
+		FlushSec int    `json:"flushSec,omitempty"`
+		Bits     string `json:"bits,omitempty"`
+	} `json:"csv,omitempty"`
+	Influx *struct {
+		URL    string `json:"url"`
+		Org    string `json:"org,omitempty"`
+		Bucket string `json:"bucket,omitempty"`
+		Batch  int    `json:"batch,omitempty"`
+	} `json:"influx,omitempty"`
+	MQTT *struct {
+		Broker     string `json:"broker"`
+		Username   string `json:"username,omitempty"`
+		Prefix     string `json:"prefix,omitempty"`
+		QoS        int    `json:"qos,omitempty"`
+		Retain     bool   `json:"retain,omitempty"`
+		Mode       string `json:"mode,omitempty"`
+		IntervalMs int    `json:"intervalMs,omitempty"`
+	} `json:"mqtt,omitempty"`
+}
+
+// configAlarms 报警通知的设置，省略的部分保持原设置
+type configAlarms struct {
+	Beep        *bool  `json:"beep,omitempty"`
+	Notify      *bool  `json:"notify,omitempty"`
+	Operator    string `json:"operator,omitempty"`
+	HistoryPath string `json:"historyPath,omitempty"`
+	Email       *struct {
+		Enabled     bool   `json:"enabled"`
+		Server      string `json:"server"`
+		User        string `json:"user,omitempty"`
+		From        string `json:"from,omitempty"`
+		To          string `json:"to"`
+		ThrottleMin int    `json:"throttleMin,omitempty"`
+	} `json:"email,omitempty"`
+	Webhook *struct {
+		Enabled bool     `json:"enabled"`
+		URLs    []string `json:"urls"`
+		Kind    string   `json:"kind,omitempty"`
+	} `json:"webhook,omitempty"`
+}
+
+// loadPlantConfig 读取并检查配置文件，扩展名为.json时按JSON解析，否则按YAML解析。
+// YAML先转换为JSON再解析，两种格式使用相同的字段名；未知字段报错，以便发现拼写错误。
+func loadPlantConfig(path string) (*plantConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(tr("读取配置文件失败: %v"), err)
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf(tr("解析配置文件 %s 失败: %v"), path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf(tr("解析配置文件 %s 失败: %v"), path, err)
+		}
+	}
+	var cfg plantConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf(tr("解析配置文件 %s 失败: %v"), path, err)
+	}
+	if err := cfg.check(); err != nil {
+		return nil, fmt.Errorf(tr("配置文件 %s 有误: %v"), path, err)
+	}
+	return &cfg, nil
+}
+
+// check 检查各项的取值，并把轮询组和选项统一为界面中使用的值
+func (c *plantConfig) check() error {
+	for i, conn := range c.Connections {
+		if strings.TrimSpace(conn.IP) == "" {
+			return fmt.Errorf(tr("connections第%d项缺少ip"), i+1)
+		}
+	}
+	for i := range c.Watch {
+		t := &c.Watch[i]
+		addr, dataType, err := parseWatchAddress(t.Address)
+		if err != nil {
+			return fmt.Errorf("watch %s: %v", t.Address, err)
+		}
+		if t.Type = strings.ToUpper(strings.TrimSpace(t.Type)); t.Type == "" {
+			t.Type = dataType
+		}
+		if err := checkWatchType(addr, t.Type); err != nil {
+			return fmt.Errorf("watch %s: %v", t.Address, err)
+		}
+		if t.Group, err = configPollGroup(t.Group); err != nil {
+			return fmt.Errorf("watch %s: %v", t.Address, err)
+		}
+	}
+	if l := c.Logging; l != nil && l.MQTT != nil && l.MQTT.Mode != "" {
+		mode, err := configChoice("logging.mqtt.mode", l.MQTT.Mode, mqttModes)
+		if err != nil {
+			return err
+		}
+		l.MQTT.Mode = mode
+	}
+	if a := c.Alarms; a != nil && a.Webhook != nil && a.Webhook.Kind != "" {
+		kind, err := configChoice("alarms.webhook.kind", a.Webhook.Kind, webhookKinds)
+		if err != nil {
+			return err
+		}
+		a.Webhook.Kind = kind
+	}
+	return nil
+}
+
+// configPollGroup 把配置中的轮询组转换为状态表的轮询组名，接受 "1 s" 和 "1s" 两种写法，为空时随扫描
+func configPollGroup(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == watchWithScan || s == tr(watchWithScan) {
+		return watchWithScan, nil
+	}
+	if _, ok := watchRates[s]; ok {
+		return s, nil
+	}
+	if d, err := time.ParseDuration(strings.ReplaceAll(s, " ", "")); err == nil {
+		for name, rate := range watchRates {
+			if rate == d {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf(tr("无效的轮询组 %q，可选: %s"), s, strings.Join(watchRateNames[1:], ", "))
+}
+
+// configChoice 在choices中查找value，原文和当前语言的译文均可
+func configChoice(field, value string, choices []string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, c := range choices {
+		if value == c || strings.EqualFold(value, tr(c)) {
+			return c, nil
+		}
+	}
+	names := make([]string, len(choices))
+	for i, c := range choices {
+		names[i] = tr(c)
+	}
+	return "", fmt.Errorf(tr("%s 无效: %q，可选: %s"), field, value, strings.Join(names, ", "))
+}
+
+// applyPrefs 把状态表、日志和报警设置写入偏好设置，在创建界面前调用
+func (c *plantConfig) applyPrefs(prefs fyne.Preferences) {
+	if len(c.Watch) > 0 {
+		rows := make([]string, len(c.Watch))
+		for i, t := range c.Watch {
+			rows[i] = strings.ToUpper(strings.TrimSpace(t.Address)) + "|" + t.Type + "|" + strings.TrimSpace(t.Alarm) + "|" + t.Group
+		}
+		prefs.SetStringList(prefWatchRows, rows)
+	}
+
+	if l := c.Logging; l != nil {
+		if l.CSV != nil {
+			prefs.SetString(prefLogPath, l.CSV.Path)
+			if l.CSV.FlushSec > 0 {
+				prefs.SetInt(prefLogFlush, l.CSV.FlushSec)
+			}
+			prefs.SetString(prefLogBits, l.CSV.Bits)
+		}
+		if l.Influx != nil {
+			prefs.SetString(prefInfluxURL, l.Influx.URL)
+			prefs.SetString(prefInfluxOrg, l.Influx.Org)
+			if l.Influx.Bucket != "" {
+				prefs.SetString(prefInfluxBucket, l.Influx.Bucket)
+			}
+			if l.Influx.Batch > 0 {
+				prefs.SetInt(prefInfluxBatch, l.Influx.Batch)
+			}
+		}
+		if l.MQTT != nil {
+			prefs.SetString(prefMQTTBroker, l.MQTT.Broker)
+			prefs.SetString(prefMQTTUsername, l.MQTT.Username)
+			if l.MQTT.Prefix != "" {
+				prefs.SetString(prefMQTTPrefix, l.MQTT.Prefix)
+			}
+			prefs.SetInt(prefMQTTQoS, l.MQTT.QoS)
+			prefs.SetBool(prefMQTTRetain, l.MQTT.Retain)
+			if l.MQTT.Mode != "" {
+				prefs.SetString(prefMQTTMode, l.MQTT.Mode)
+			}
+			if l.MQTT.IntervalMs > 0 {
+				prefs.SetInt(prefMQTTInterval, l.MQTT.IntervalMs)
+			}
+		}
+	}
+
+	if a := c.Alarms; a != nil {
+		if a.Beep != nil {
+			prefs.SetBool(prefAlarmBeep, *a.Beep)
+		}
+		if a.Notify != nil {
+			prefs.SetBool(prefAlarmNotify, *a.Notify)
+		}
+		if a.Operator != "" {
+			prefs.SetString(prefAlarmOperator, a.Operator)
+		}
+		if a.HistoryPath != "" {
+			prefs.SetString(prefAlarmHistoryPath, a.HistoryPath)
+		}
+		if a.Email != nil {
+			prefs.SetBool(prefEmailOn, a.Email.Enabled)
+			prefs.SetString(prefEmailServer, a.Email.Server)
+			prefs.SetString(prefEmailUser, a.Email.User)
+			prefs.SetString(prefEmailFrom, a.Email.From)
+			prefs.SetString(prefEmailTo, a.Email.To)
+			if a.Email.ThrottleMin > 0 {
+				prefs.SetInt(prefEmailThrottle, a.Email.ThrottleMin)
+			}
+		}
+		if a.Webhook != nil {
+			prefs.SetBool(prefWebhookOn, a.Webhook.Enabled)
+			prefs.SetString(prefWebhookURLs, strings.Join(a.Webhook.URLs, "\n"))
+			if a.Webhook.Kind != "" {
+				prefs.SetString(prefWebhookKind, a.Webhook.Kind)
+			}
+		}
+	}
+}
+
+// ips 返回各连接的IP，用于创建标签页
+func (c *plantConfig) ips() []string {
+	ips := make([]string, len(c.Connections))
+	for i, conn := range c.Connections {
+		ips[i] = strings.TrimSpace(conn.IP)
+	}
+	return ips
+}
+
+// applyConfig 用配置文件中第一个连接和状态表补充命令行参数，命令行中明确指定的参数优先
+func (o *cliOptions) applyConfig(cfg *plantConfig, fs *flag.FlagSet) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if len(cfg.Connections) > 0 {
+		c := cfg.Connections[0]
+		if !set["ip"] {
+			o.ip = strings.TrimSpace(c.IP)
+		}
+		if !set["rack"] {
+			o.rack = c.Rack
+		}
+		if !set["slot"] {
+			o.slot = c.Slot
+		}
+		if !set["port"] && c.Port > 0 {
+			o.port = c.Port
+		}
+		if !set["timeout"] && c.TimeoutMs > 0 {
+			o.timeout = time.Duration(c.TimeoutMs) * time.Millisecond
+		}
+		if !set["retries"] && c.Retries > 0 {
+			o.retries = c.Retries
+		}
+		if !set["retry-delay"] && c.RetryDelayMs > 0 {
+			o.retryDelay = time.Duration(c.RetryDelayMs) * time.Millisecond
+		}
+		if !set["area"] && c.Area != "" {
+			o.area = c.Area
+		}
+		if !set["addr"] && c.Address != "" {
+			o.address = c.Address
+		}
+		if !set["len"] && c.Length > 0 {
+			o.length = c.Length
+		}
+		if !set["interval"] && c.ScanMs > 0 {
+			o.interval = time.Duration(c.ScanMs) * time.Millisecond
+		}
+		if !set["monitor"] {
+			o.monitor = c.Monitor
+		}
+	}
+	if !set["watch"] && len(cfg.Watch) > 0 {
+		tags := make([]string, len(cfg.Watch))
+		for i, t := range cfg.Watch {
+			tags[i] = strings.ToUpper(strings.TrimSpace(t.Address)) + ":" + t.Type
+		}
+		o.watch = strings.Join(tags, ",")
+	}
+}
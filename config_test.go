@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// writeConfig 把text写到临时目录中的name，返回路径
+func writeConfig(t *testing.T, name, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPlantConfig(t *testing.T) {
+	yamlText := `
+connections:
+  - ip: 192.168.2.1
+    monitor: true
+  - ip: " 192.168.2.2 "
+    rack: 1
+    slot: 2
+    scanMs: 500
+watch:
+  - address: VW100
+  - address: V10.0
+    alarm: "== 1"
+    group: 1s
+  - address: vr20
+    group: "500 ms"
+  - address: VB4
+    type: byte
+logging:
+  mqtt:
+    broker: tcp://broker:1883
+    mode: 变化时发布
+alarms:
+  beep: false
+  webhook:
+    enabled: true
+    urls: [https://example.com/hook]
+    kind: 企业微信机器人
+`
+	jsonText := `{
+  "connections": [{"ip": "192.168.2.1", "monitor": true}, {"ip": " 192.168.2.2 ", "rack": 1, "slot": 2, "scanMs": 500}],
+  "watch": [
+    {"address": "VW100"},
+    {"address": "V10.0", "alarm": "== 1", "group": "1s"},
+    {"address": "vr20", "group": "500 ms"},
+    {"address": "VB4", "type": "byte"}
+  ],
+  "logging": {"mqtt": {"broker": "tcp://broker:1883", "mode": "变化时发布"}},
+  "alarms": {"beep": false, "webhook": {"enabled": true, "urls": ["https://example.com/hook"], "kind": "企业微信机器人"}}
+}`
+	for _, name := range []string{"plant.yaml", "plant.yml", "plant.json", "PLANT.JSON"} {
+		text := yamlText
+		if strings.EqualFold(filepath.Ext(name), ".json") {
+			text = jsonText
+		}
+		cfg, err := loadPlantConfig(writeConfig(t, name, text))
+		if err != nil {
+			t.Fatalf("%s: loadPlantConfig: %v", name, err)
+		}
+
+		if got := cfg.ips(); len(got) != 2 || got[0] != "192.168.2.1" || got[1] != "192.168.2.2" {
+			t.Errorf("%s: ips() = %q", name, got)
+		}
+		// 省略的机架号和槽位号使用默认值
+		if c := cfg.Connections[0]; c.Rack != s7viewer.DefaultRack || c.Slot != s7viewer.DefaultSlot || !c.Monitor {
+			t.Errorf("%s: connections[0] = %+v, want default rack/slot and monitor", name, c)
+		}
+		if c := cfg.Connections[1]; c.Rack != 1 || c.Slot != 2 || c.ScanMs != 500 || c.Monitor {
+			t.Errorf("%s: connections[1] = %+v", name, c)
+		}
+
+		// 类型按地址宽度推断，轮询组统一为状态表的写法
+		want := []configTag{
+			{Address: "VW100", Type: s7viewer.TypeInt, Group: watchWithScan},
+			{Address: "V10.0", Type: s7viewer.TypeBool, Alarm: "== 1", Group: "1 s"},
+			{Address: "vr20", Type: s7viewer.TypeReal, Group: "500 ms"},
+			{Address: "VB4", Type: s7viewer.TypeByte, Group: watchWithScan},
+		}
+		if len(cfg.Watch) != len(want) {
+			t.Fatalf("%s: %d watch tags, want %d", name, len(cfg.Watch), len(want))
+		}
+		for i, w := range want {
+			if cfg.Watch[i] != w {
+				t.Errorf("%s: watch[%d] = %+v, want %+v", name, i, cfg.Watch[i], w)
+			}
+		}
+
+		if m := cfg.Logging.MQTT; m == nil || m.Mode != mqttOnChange || m.Broker != "tcp://broker:1883" {
+			t.Errorf("%s: logging.mqtt = %+v", name, m)
+		}
+		if a := cfg.Alarms; a.Beep == nil || *a.Beep || a.Notify != nil || a.Webhook == nil || a.Webhook.Kind != webhookWeCom {
+			t.Errorf("%s: alarms = %+v", name, a)
+		}
+	}
+}
+
+func TestLoadPlantConfigTranslatedChoices(t *testing.T) {
+	setLanguage(langEN)
+	t.Cleanup(func() { setLanguage(langZH) })
+
+	// 英文界面下选项也可以写译文，载入后统一为原值
+	cfg, err := loadPlantConfig(writeConfig(t, "plant.yaml", `
+connections: [{ip: 10.0.0.1}]
+logging: {mqtt: {broker: "broker:1883", mode: publish on change}}
+alarms: {webhook: {enabled: true, urls: [], kind: WeChat Work robot}}
+`))
+	if err != nil {
+		t.Fatalf("loadPlantConfig: %v", err)
+	}
+	if cfg.Logging.MQTT.Mode != mqttOnChange || cfg.Alarms.Webhook.Kind != webhookWeCom {
+		t.Errorf("mode = %q, kind = %q; want %q, %q", cfg.Logging.MQTT.Mode, cfg.Alarms.Webhook.Kind, mqttOnChange, webhookWeCom)
+	}
+}
+
+func TestLoadPlantConfigErrors(t *testing.T) {
+	tests := []struct {
+		name, file, text string
+		want             string // 错误信息中应包含的内容
+	}{
+		{"yaml syntax", "plant.yaml", "connections: [ip: 1", "plant.yaml"},
+		{"json syntax", "plant.json", `{"connections": [`, "plant.json"},
+		{"unknown field", "plant.yaml", "connections: [{ip: 10.0.0.1}]\nwatchs: []", "watchs"},
+		{"unknown connection field", "plant.yaml", "connections: [{ip: 10.0.0.1, sloot: 1}]", "sloot"},
+		{"missing ip", "plant.yaml", "connections: [{rack: 0}]", "ip"},
+		{"bad watch address", "plant.yaml", "watch: [{address: VX100}]", "VX100"},
+		{"type does not fit address", "plant.yaml", "watch: [{address: VW100, type: BOOL}]", "VW100"},
+		{"bad poll group", "plant.yaml", "watch: [{address: VW100, group: 3s}]", "3s"},
+		{"bad mqtt mode", "plant.yaml", "logging: {mqtt: {broker: b, mode: sometimes}}", "logging.mqtt.mode"},
+		{"bad webhook kind", "plant.yaml", "alarms: {webhook: {enabled: true, urls: [], kind: slack}}", "alarms.webhook.kind"},
+	}
+	for _, tt := range tests {
+		_, err := loadPlantConfig(writeConfig(t, tt.file, tt.text))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: loadPlantConfig error = %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := loadPlantConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadPlantConfig(missing file) error = nil")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// cpuStatePollInterval 状态栏中CPU运行状态的刷新周期
+const cpuStatePollInterval = 2 * time.Second
+
+// cpuControl 状态栏中的CPU运行状态和RUN/STOP按钮。
+// 切换运行状态会影响现场设备，须在确认对话框中输入PLC的IP地址才执行。
+type cpuControl struct {
+	content   fyne.CanvasObject
+	win       fyne.Window
+	getViewer func() (*s7viewer.Viewer, context.Context)
+	getIP     func() string
+
+	stateLabel            *widget.Label
+	runButton, stopButton *widget.Button
+	active                bool // 已连接并在刷新状态，只在UI线程中访问
+}
+
+// newCPUControl 创建CPU运行状态显示和RUN/STOP按钮，连接后调用start开始刷新
+func newCPUControl(win fyne.Window, getViewer func() (*s7viewer.Viewer, context.Context), getIP func() string) *cpuControl {
+	c := &cpuControl{
+		win:        win,
+		getViewer:  getViewer,
+		getIP:      getIP,
+		stateLabel: widget.NewLabel(tr("CPU: -")),
+	}
+	c.runButton = widget.NewButton(tr("切换到RUN"), func() { c.command(s7viewer.CPUStateRun) })
+	c.stopButton = widget.NewButton(tr("切换到STOP"), func() { c.command(s7viewer.CPUStateStop) })
+	c.content = container.NewHBox(c.stateLabel, c.runButton, c.stopButton)
+	onReadOnlyChange(func(bool) { c.updateButtons() })
+	return c
+}
+
+// updateButtons 已连接且不在只读模式时启用RUN/STOP按钮
+func (c *cpuControl) updateButtons() {
+	if c.active && !isReadOnly() {
+		c.runButton.Enable()
+		c.stopButton.Enable()
+	} else {
+		c.runButton.Disable()
+		c.stopButton.Disable()
+	}
+}
+
+// start 在后台周期读取CPU运行状态，直到连接的ctx取消
+func (c *cpuControl) start() {
+	viewer, ctx := c.getViewer()
+	if viewer == nil || !viewer.IsConnected() {
+		return
+	}
+	c.active = true
+	c.updateButtons()
+	go func() {
+		ticker := time.NewTicker(cpuStatePollInterval)
+		defer ticker.Stop()
+		for {
+			state, err := viewer.CPUState(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			fyne.Do(func() { c.show(state, err) })
+			if errors.Is(err, s7viewer.ErrNotSupported) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// reset 断开连接后清除状态显示并禁用按钮
+func (c *cpuControl) reset() {
+	c.stateLabel.SetText(tr("CPU: -"))
+	c.active = false
+	c.updateButtons()
+}
+
+// show 显示读取到的运行状态，读取失败时显示为未知，详细错误由心跳检测报告
+func (c *cpuControl) show(state s7viewer.CPUState, err error) {
+	switch {
+	case errors.Is(err, s7viewer.ErrNotSupported):
+		c.stateLabel.SetText(tr("CPU: 不支持"))
+	case err != nil:
+		c.stateLabel.SetText(tr("CPU: ?"))
+	default:
+		c.stateLabel.SetText("CPU: " + tr(state.String()))
+	}
+}
+
+// command 确认后将CPU切换到state，确认时须输入当前PLC的IP地址
+func (c *cpuControl) command(state s7viewer.CPUState) {
+	viewer, ctx := c.getViewer()
+	if viewer == nil || !viewer.IsConnected() {
+		return
+	}
+	ip := c.getIP()
+	ipEntry := widget.NewEntry()
+	ipEntry.SetPlaceHolder(ip)
+	ipEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) != ip {
+			return errors.New(tr("输入的IP地址与当前PLC不一致"))
+		}
+		return nil
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem("", widget.NewLabel(fmt.Sprintf(tr("将PLC %s 切换到 %s，可能使现场设备启动或停止。\n请输入PLC的IP地址确认："), ip, state))),
+		widget.NewFormItem(tr("IP地址:"), ipEntry),
+	}
+	title := fmt.Sprintf(tr("切换CPU到%s"), state)
+	d := dialog.NewForm(title, tr("执行"), tr("取消"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			err := viewer.SetCPUState(ctx, state)
+			if err != nil {
+				log.Printf(tr("切换PLC %s 到%s失败: %v"), ip, state, err)
+			} else {
+				log.Printf(tr("已将PLC %s 切换到%s"), ip, state)
+			}
+			newState, stateErr := viewer.CPUState(ctx)
+			fyne.Do(func() {
+				c.show(newState, stateErr)
+				if err != nil {
+					dialog.ShowError(err, c.win)
+				}
+			})
+		}()
+	}, c.win)
+	d.Resize(fyne.NewSize(420, d.MinSize().Height))
+	d.Show()
+}
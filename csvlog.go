@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// csvLogger 每次扫描向CSV追加一行（时间、原始字节、16位值、选定位的状态）。
+// 文件按天滚动：scan.csv 实际写入 scan_2006-01-02.csv。
+type csvLogger struct {
+	mu         sync.Mutex
+	basePath   string
+	bits       []s7Address
+	flushEvery time.Duration
+
+	file      *os.File
+	w         *csv.Writer
+	day       string
+	lastFlush time.Time
+	last      map[string]float64 // 设置了死区时上次记录的值
+}
+
+// newCSVLogger 创建记录器，文件在第一次写入时打开
+func newCSVLogger(basePath string, bits []s7Address, flushEvery time.Duration) *csvLogger {
+	return &csvLogger{basePath: basePath, bits: bits, flushEvery: flushEvery, last: make(map[string]float64)}
+}
+
+// parseBitList 解析逗号或空格分隔的位地址列表，如 "V100.0, M2.3"
+func parseBitList(text string) ([]s7Address, error) {
+	var bits []s7Address
+	for _, f := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '，' || r == ' ' || r == ';' }) {
+		addr, err := parseAddress(f)
+		if err != nil {
+			return nil, err
+		}
+		if addr.size != "" || s7viewer.IsCounterArea(addr.area) {
+			return nil, fmt.Errorf(tr("%s 不是位地址"), addr)
+		}
+		bits = append(bits, addr)
+	}
+	return bits, nil
+}
+
+// dayPath 返回某天的滚动文件路径
+func (l *csvLogger) dayPath(day string) string {
+	ext := filepath.Ext(l.basePath)
+	return strings.TrimSuffix(l.basePath, ext) + "_" + day + ext
+}
+
+// log 追加一次扫描的数据，跨天时切换到新文件。按flushEvery周期性刷新到磁盘。
+// db不为nil时只在有变量变化超过死区时记录，字按order的字节顺序比较。
+func (l *csvLogger) log(t time.Time, order, area string, start int, data []byte, db deadbands) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if db != nil && len(db.filter(l.last, tagValues(order, area, start, data))) == 0 {
+		return nil
+	}
+
+	if day := t.Format("2006-01-02"); day != l.day || l.file == nil {
+		if err := l.closeLocked(); err != nil {
+			return err
+		}
+		if err := l.openLocked(day); err != nil {
+			return err
+		}
+	}
+
+	var values []string
+	for _, v := range convertBytesTo16BitInts(data) {
+		values = append(values, strconv.Itoa(v))
+	}
+	row := []string{
+		t.Format("2006-01-02 15:04:05.000"),
+		s7viewer.ByteAddressName(area, start),
+		fmt.Sprintf("% X", data),
+		strings.Join(values, " "),
+	}
+	for _, b := range l.bits {
+		off := b.byteOff - start
+		if b.area != area || off < 0 || off >= len(data) {
+			row = append(row, "")
+			continue
+		}
+		row = append(row, strconv.Itoa(int(data[off]>>b.bit)&1))
+	}
+	if err := l.w.Write(row); err != nil {
+		return err
+	}
+
+	if time.Since(l.lastFlush) >= l.flushEvery {
+		l.w.Flush()
+		l.lastFlush = time.Now()
+		return l.w.Error()
+	}
+	return nil
+}
+
+func (l *csvLogger) openLocked(day string) error {
+	path := l.dayPath(day)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf(tr("创建记录目录失败: %v"), err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf(tr("打开记录文件失败: %v"), err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.file, l.w, l.day = f, csv.NewWriter(f), day
+	l.lastFlush = time.Now()
+	if info.Size() == 0 {
+		// 新文件先写入UTF-8 BOM和表头，方便Excel直接打开
+		f.WriteString("\uFEFF")
+		header := []string{"时间", "起始地址", "原始字节", "16位值"}
+		for _, b := range l.bits {
+			header = append(header, b.String())
+		}
+		return l.w.Write(header)
+	}
+	return nil
+}
+
+// flush 把缓冲的行写入磁盘，停止监控时调用
+func (l *csvLogger) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return nil
+	}
+	l.w.Flush()
+	l.lastFlush = time.Now()
+	return l.w.Error()
+}
+
+// close 刷新并关闭当前文件
+func (l *csvLogger) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closeLocked()
+}
+
+func (l *csvLogger) closeLocked() error {
+	if l.file == nil {
+		return nil
+	}
+	l.w.Flush()
+	err := l.w.Error()
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file, l.w = nil, nil
+	return err
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// CSV记录设置的偏好键
+const (
+	prefLogPath  = "log.path"
+	prefLogFlush = "log.flushSec"
+	prefLogBits  = "log.bits"
+)
+
+// newCSVLogPanel 创建CSV记录设置面板。current返回启用中的记录器，未启用时为nil，
+// 可在监控协程中调用。
+func newCSVLogPanel(prefs fyne.Preferences) (content fyne.CanvasObject, current func() *csvLogger) {
+	var mu sync.Mutex
+	var logger *csvLogger
+	current = func() *csvLogger {
+		mu.Lock()
+		defer mu.Unlock()
+		return logger
+	}
+
+	defaultPath := "plc-scan.csv"
+	if home, err := os.UserHomeDir(); err == nil {
+		defaultPath = filepath.Join(home, "plc-scan.csv")
+	}
+	pathEntry := widget.NewEntry()
+	pathEntry.SetText(prefs.StringWithFallback(prefLogPath, defaultPath))
+	flushEntry := widget.NewEntry()
+	flushEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefLogFlush, 5)))
+	bitsEntry := widget.NewEntry()
+	bitsEntry.SetText(prefs.String(prefLogBits))
+	bitsEntry.SetPlaceHolder(tr("例如 V100.0, V100.3（可留空）"))
+	statusLabel := widget.NewLabel(tr("记录: 未启用"))
+
+	enableCheck := widget.NewCheck(tr("记录到CSV"), func(enabled bool) {
+		mu.Lock()
+		old := logger
+		logger = nil
+		mu.Unlock()
+		if old != nil {
+			if err := old.close(); err != nil {
+				log.Printf(tr("关闭记录文件失败: %v"), err)
+			}
+		}
+		if !enabled {
+			statusLabel.SetText(tr("记录: 未启用"))
+			return
+		}
+
+		path := strings.TrimSpace(pathEntry.Text)
+		secs, err := strconv.Atoi(strings.TrimSpace(flushEntry.Text))
+		if path == "" || err != nil || secs < 0 {
+			statusLabel.SetText(tr("记录: 路径或刷新间隔无效"))
+			return
+		}
+		bits, err := parseBitList(bitsEntry.Text)
+		if err != nil {
+			statusLabel.SetText(tr("记录: 位地址无效"))
+			log.Printf(tr("记录位地址无效: %v"), err)
+			return
+		}
+		prefs.SetString(prefLogPath, path)
+		prefs.SetInt(prefLogFlush, secs)
+		prefs.SetString(prefLogBits, bitsEntry.Text)
+
+		l := newCSVLogger(path, bits, time.Duration(secs)*time.Second)
+		mu.Lock()
+		logger = l
+		mu.Unlock()
+		statusLabel.SetText(fmt.Sprintf(tr("记录: %s"), l.dayPath(time.Now().Format("2006-01-02"))))
+		log.Printf(tr("开始记录到 %s"), path)
+	})
+
+	content = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(tr("文件路径:"), pathEntry),
+			widget.NewFormItem(tr("刷新间隔 (秒):"), flushEntry),
+			widget.NewFormItem(tr("记录的位:"), bitsEntry),
+		),
+		widget.NewLabel(tr("监控时每次扫描追加一行，文件按天滚动（文件名后追加日期）。")),
+		enableCheck,
+		statusLabel,
+	)
+	return content, current
+}
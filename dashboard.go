@@ -0,0 +1,42 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// dashboardHTML 网页看板，通过 /ws 接收每次扫描的数据显示位网格，定时请求 /watch 刷新状态表。
+// 同事可在浏览器中打开 http://本机IP:端口/ 查看现场状态，?plc=IP 只显示一台PLC。
+//
+//go:embed web/dashboard.html
+var dashboardHTML []byte
+
+func dashboardHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(dashboardHTML)
+	})
+}
+
+// dashboardWatch 一台PLC的状态表，由 /watch 返回
+type dashboardWatch struct {
+	PLC    string          `json:"plc"`
+	Values []snapshotValue `json:"values"`
+}
+
+// watchValues 返回各PLC状态表当前显示的值，plc不为空时只返回该PLC
+func watchValues(sources []metricsSource, plc string) []dashboardWatch {
+	watches := []dashboardWatch{}
+	for _, src := range sources {
+		if plc != "" && src.plc != plc {
+			continue
+		}
+		values := src.watch
+		if values == nil {
+			values = []snapshotValue{}
+		}
+		watches = append(watches, dashboardWatch{PLC: src.plc, Values: values})
+	}
+	return watches
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// prefDeadbands 死区设置的偏好键
+const prefDeadbands = "deadband.rules"
+
+// deadbandDefault 死区设置中表示所有字变量的默认值的名称
+const deadbandDefault = "*"
+
+// deadband 一个变量的死区，eu为true时value为工程单位，按该变量的换算折算为原始计数
+type deadband struct {
+	value float64
+	eu    bool
+}
+
+// deadbands 各变量的死区：值与上次记录或发布的值相差超过死区时才再次输出。
+// 键为变量名（VW100、AIW16、T37），"*"为所有字变量的默认值；位变量不受默认值影响，变化即输出。
+type deadbands map[string]deadband
+
+// parseDeadbands 解析死区设置，每项为“变量 死区”或“变量=死区”，死区后加EU表示工程单位，
+// 以换行、逗号或分号分隔，如 "VW100 20, AIW16=50, VW320 0.5 EU, * 5"
+func parseDeadbands(text string) (deadbands, error) {
+	d := make(deadbands)
+	for _, item := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ',' || r == '，' || r == ';' }) {
+		fields := strings.Fields(strings.NewReplacer("=", " ", ":", " ", "：", " ").Replace(item))
+		if len(fields) == 0 {
+			continue
+		}
+		eu := false
+		if n := len(fields); n == 3 && strings.EqualFold(fields[2], "EU") {
+			fields, eu = fields[:2], true
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf(tr("无效的死区设置: %q，示例: VW100 20"), strings.TrimSpace(item))
+		}
+		name := fields[0]
+		if name != deadbandDefault {
+			addr, err := parseAddress(name)
+			if err != nil {
+				return nil, err
+			}
+			name = addr.String()
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf(tr("无效的死区: %q"), fields[1])
+		}
+		d[name] = deadband{value: v, eu: eu}
+	}
+	return d, nil
+}
+
+// of 返回变量的死区（原始计数），未设置时字变量使用默认值，位变量为0。
+// 按工程单位设置的死区在变量没有换算时按原始计数处理。
+func (d deadbands) of(tag string) float64 {
+	db, ok := d[tag]
+	if !ok {
+		if strings.Contains(tag, ".") {
+			return 0
+		}
+		db = d[deadbandDefault]
+	}
+	if db.eu {
+		if sc, ok := euScaleOf(tag); ok {
+			return sc.counts(db.value)
+		}
+	}
+	return db.value
+}
+
+// pass 判断tag的新值是否需要输出：首次出现、无法解析为数值或相对last中的值变化超过死区（死区为0时变化即输出）。
+// 需要输出时更新last。
+func (d deadbands) pass(last map[string]float64, tag, value string) bool {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return true
+	}
+	if prev, ok := last[tag]; ok && math.Abs(v-prev) <= d.of(tag) {
+		return false
+	}
+	last[tag] = v
+	return true
+}
+
+// filter 返回values中需要输出的变量，并更新last
+func (d deadbands) filter(last map[string]float64, values map[string]string) map[string]string {
+	out := make(map[string]string)
+	for tag, value := range values {
+		if d.pass(last, tag, value) {
+			out[tag] = value
+		}
+	}
+	return out
+}
+
+// newDeadbandSettings 创建死区设置。current返回当前的死区，未设置时为nil（不过滤），可在监控协程中调用。
+func newDeadbandSettings(prefs fyne.Preferences) (content fyne.CanvasObject, current func() deadbands) {
+	var mu sync.Mutex
+	var active deadbands
+	current = func() deadbands {
+		mu.Lock()
+		defer mu.Unlock()
+		return active
+	}
+
+	entry := widget.NewMultiLineEntry()
+	entry.SetMinRowsVisible(2)
+	entry.SetPlaceHolder(tr("每项为 变量 死区，如 VW100 20, AIW16 50, VW320 0.5 EU（工程单位）；* 5 为所有字变量的默认值"))
+	statusLabel := widget.NewLabel("")
+	apply := func(text string) {
+		d, err := parseDeadbands(text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		if len(d) == 0 {
+			d = nil
+			statusLabel.SetText(tr("未设置死区，每次扫描都输出"))
+		} else {
+			statusLabel.SetText(fmt.Sprintf(tr("已设置%d项死区"), len(d)))
+		}
+		prefs.SetString(prefDeadbands, text)
+		mu.Lock()
+		active = d
+		mu.Unlock()
+	}
+	entry.SetText(prefs.String(prefDeadbands))
+	apply(entry.Text)
+	entry.OnChanged = apply
+
+	content = container.NewVBox(
+		widget.NewLabelWithStyle(tr("死区（CSV记录、MQTT和InfluxDB共用）"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel(tr("设置死区后，变量的值与上次输出相差超过死区（原始计数，加EU为工程单位）才再次记录或发布；位变量变化即输出。")),
+		entry,
+		statusLabel,
+	)
+	return content, current
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// defaultDiscoverCIDR 由当前填写的IP推断要搜索的/24网段，无法解析时使用常见的出厂网段
+func defaultDiscoverCIDR(ip string) string {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil || !addr.Is4() {
+		return "192.168.2.0/24"
+	}
+	prefix, _ := addr.Prefix(24)
+	return prefix.String()
+}
+
+// showDiscoverDialog 显示“搜索PLC”对话框：扫描网段中S7端口（102）开放的设备，可选S7连接确认并读取订货号。
+// 点击结果时调用onPick并关闭对话框，rack和slot为确认时的连接参数。
+func showDiscoverDialog(win fyne.Window, ip string, rack, slot int, onPick func(ip string)) {
+	cidrEntry := widget.NewEntry()
+	cidrEntry.SetText(defaultDiscoverCIDR(ip))
+	confirmCheck := widget.NewCheck(tr("S7连接确认并读取订货号"), nil)
+	confirmCheck.SetChecked(true)
+	progress := widget.NewProgressBar()
+	statusLabel := widget.NewLabel(tr("输入网段后点击搜索"))
+
+	var devices []s7viewer.Device // 只在UI线程中访问
+	list := widget.NewList(
+		func() int { return len(devices) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			d := devices[i]
+			text := d.IP
+			switch {
+			case d.OrderCode != "":
+				text += "  " + d.OrderCode
+			case d.S7:
+				text += "  " + tr("S7连接成功")
+			case d.Err != nil:
+				text += "  " + tr("端口开放，S7连接失败: ") + d.Err.Error()
+			default:
+				text += "  " + tr("端口102开放")
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+
+	var mu sync.Mutex
+	var cancel context.CancelFunc
+	stop := func() {
+		mu.Lock()
+		if cancel != nil {
+			cancel()
+			cancel = nil
+		}
+		mu.Unlock()
+	}
+
+	var searchButton *widget.Button
+	searchButton = widget.NewButton(tr("搜索"), func() {
+		stop()
+		cidr := strings.TrimSpace(cidrEntry.Text)
+		if _, err := s7viewer.DiscoverHosts(cidr); err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		devices = nil
+		list.Refresh()
+		progress.SetValue(0)
+		statusLabel.SetText(fmt.Sprintf(tr("正在搜索 %s…"), cidr))
+		searchButton.Disable()
+
+		ctx, c := context.WithCancel(context.Background())
+		mu.Lock()
+		cancel = c
+		mu.Unlock()
+		opts := s7viewer.DiscoverOptions{
+			Confirm: confirmCheck.Checked,
+			Rack:    rack,
+			Slot:    slot,
+			Progress: func(done, total int) {
+				fyne.Do(func() { progress.SetValue(float64(done) / float64(total)) })
+			},
+		}
+		go func() {
+			err := s7viewer.Discover(ctx, cidr, opts, func(d s7viewer.Device) {
+				log.Printf(tr("搜索PLC: 发现 %s %s"), d.IP, d.OrderCode)
+				fyne.Do(func() {
+					devices = append(devices, d)
+					list.Refresh()
+				})
+			})
+			fyne.Do(func() {
+				searchButton.Enable()
+				if err != nil {
+					statusLabel.SetText(tr("搜索已停止"))
+					return
+				}
+				statusLabel.SetText(fmt.Sprintf(tr("搜索完成，找到 %d 台设备，点击设备填入IP地址"), len(devices)))
+			})
+		}()
+	})
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewForm(widget.NewFormItem(tr("网段:"), container.NewBorder(nil, nil, nil, searchButton, cidrEntry))),
+			confirmCheck,
+			progress,
+			statusLabel,
+		),
+		nil, nil, nil,
+		list,
+	)
+	d := dialog.NewCustom(tr("搜索PLC"), tr("关闭"), content, win)
+	list.OnSelected = func(i widget.ListItemID) {
+		onPick(devices[i].IP)
+		d.Hide()
+	}
+	d.SetOnClosed(stop)
+	d.Resize(fyne.NewSize(520, 420))
+	d.Show()
+}
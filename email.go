@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 邮件通知设置的偏好键（密码不保存）
+const (
+	prefEmailServer   = "alarm.email.server"
+	prefEmailUser     = "alarm.email.user"
+	prefEmailFrom     = "alarm.email.from"
+	prefEmailTo       = "alarm.email.to"
+	prefEmailThrottle = "alarm.email.throttleMin"
+	prefEmailOn       = "alarm.email"
+)
+
+// smtpTimeout 连接和发送邮件的超时
+const smtpTimeout = 30 * time.Second
+
+// emailConfig SMTP服务器和收件人。Server为host:port，端口465使用SSL直连，其他端口在服务器支持时使用STARTTLS。
+type emailConfig struct {
+	Server   string
+	User     string
+	Password string
+	From     string
+	To       []string
+	Throttle time.Duration // 两封邮件之间的最短间隔，期间的报警合并到下一封
+}
+
+// emailNotifier 报警邮件：按最短间隔节流，节流期间的报警在间隔结束时合并为一封邮件发送
+type emailNotifier struct {
+	mu       sync.Mutex
+	cfg      emailConfig
+	lastSent time.Time
+	pending  []alarmEvent
+	snap     *snapshot   // 附在下一封邮件中的快照，取最近一次报警时的
+	timer    *time.Timer // 节流期间等待发送合并的邮件
+}
+
+// notify 发送报警邮件并附上快照（snap为nil时不附），节流期间只记录报警。在后台发送，可在UI线程中调用。
+func (n *emailNotifier) notify(cfg emailConfig, e alarmEvent, snap *snapshot) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cfg = cfg
+	n.pending = append(n.pending, e)
+	n.snap = snap
+	if n.timer != nil {
+		return
+	}
+	wait := cfg.Throttle - time.Since(n.lastSent)
+	if n.lastSent.IsZero() || wait <= 0 {
+		go n.flush()
+		return
+	}
+	n.timer = time.AfterFunc(wait, n.flush)
+}
+
+// flush 发送积累的报警
+func (n *emailNotifier) flush() {
+	n.mu.Lock()
+	events, snap, cfg := n.pending, n.snap, n.cfg
+	n.pending, n.snap, n.timer = nil, nil, nil
+	n.lastSent = time.Now()
+	n.mu.Unlock()
+	if len(events) == 0 {
+		return
+	}
+	if err := sendAlarmEmail(cfg, events, snap); err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	log.Printf(tr("已发送报警邮件: %d条报警"), len(events))
+}
+
+// sendAlarmEmail 将报警列表作为正文、快照作为JSON附件发送
+func sendAlarmEmail(cfg emailConfig, events []alarmEvent, snap *snapshot) error {
+	subject := tr("PLC报警")
+	if len(events) > 0 {
+		subject += ": " + events[0].plc + " " + events[0].tag
+	}
+	if len(events) > 1 {
+		subject += fmt.Sprintf(tr(" 等%d条"), len(events))
+	}
+	var body strings.Builder
+	for _, e := range events {
+		body.WriteString(e.String() + "\n")
+	}
+
+	var attachment []byte
+	var attachmentName string
+	if snap != nil {
+		var buf bytes.Buffer
+		if err := writeSnapshotJSON(&buf, *snap); err != nil {
+			return err
+		}
+		attachment = buf.Bytes()
+		attachmentName = fmt.Sprintf("snapshot_%s_%s.json", snap.PLC.IP, snap.Time.Format("20060102_150405"))
+	}
+	msg, err := buildEmail(cfg.From, cfg.To, subject, body.String(), attachmentName, attachment)
+	if err != nil {
+		return err
+	}
+	if err := sendSMTP(cfg, msg); err != nil {
+		return fmt.Errorf(tr("发送报警邮件失败: %v"), err)
+	}
+	return nil
+}
+
+// buildEmail 生成UTF-8纯文本邮件，attachment不为nil时作为附件
+func buildEmail(from string, to []string, subject, body, attachmentName string, attachment []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64Lines(part, []byte(body))
+
+	if attachment != nil {
+		part, err = mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"application/json; name=" + attachmentName},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {"attachment; filename=" + attachmentName},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64Lines(part, attachment)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64Lines 按每行76个字符写入base64编码的数据
+func writeBase64Lines(w io.Writer, data []byte) {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		w.Write([]byte(enc[:76] + "\r\n"))
+		enc = enc[76:]
+	}
+	w.Write([]byte(enc + "\r\n"))
+}
+
+// sendSMTP 连接服务器发送邮件，用户名不为空时使用PLAIN认证
+func sendSMTP(cfg emailConfig, msg []byte) error {
+	host, port, err := net.SplitHostPort(cfg.Server)
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	if port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", cfg.Server, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", cfg.Server)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && port != "465" {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if cfg.User != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.User, cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// newEmailSettings 创建邮件通知设置。send按当前设置发送报警邮件，currentSnapshot返回要附上的快照，
+// 设置无效时记录日志；在UI线程中调用。
+func newEmailSettings(prefs fyne.Preferences, currentSnapshot func() (snapshot, bool)) (content fyne.CanvasObject, send func(alarmEvent)) {
+	serverEntry := widget.NewEntry()
+	serverEntry.SetPlaceHolder("smtp.example.com:465")
+	serverEntry.SetText(prefs.String(prefEmailServer))
+	userEntry := widget.NewEntry()
+	userEntry.SetText(prefs.String(prefEmailUser))
+	passwordEntry := widget.NewPasswordEntry()
+	fromEntry := widget.NewEntry()
+	fromEntry.SetText(prefs.String(prefEmailFrom))
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder(tr("多个收件人用逗号分隔"))
+	toEntry.SetText(prefs.String(prefEmailTo))
+	throttleEntry := widget.NewEntry()
+	throttleEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefEmailThrottle, 10)))
+
+	notifier := &emailNotifier{}
+	// config 读取并保存当前设置
+	config := func() (emailConfig, error) {
+		cfg := emailConfig{
+			Server:   strings.TrimSpace(serverEntry.Text),
+			User:     strings.TrimSpace(userEntry.Text),
+			Password: passwordEntry.Text,
+			From:     strings.TrimSpace(fromEntry.Text),
+		}
+		for _, to := range strings.FieldsFunc(toEntry.Text, func(r rune) bool { return r == ',' || r == ';' || r == '，' }) {
+			if to = strings.TrimSpace(to); to != "" {
+				cfg.To = append(cfg.To, to)
+			}
+		}
+		minutes, err := strconv.Atoi(strings.TrimSpace(throttleEntry.Text))
+		if _, _, serr := net.SplitHostPort(cfg.Server); serr != nil || cfg.From == "" || len(cfg.To) == 0 || err != nil || minutes < 0 {
+			return cfg, errors.New(tr("邮件设置无效：需要服务器(主机:端口)、发件人、收件人和最短间隔(分钟)"))
+		}
+		cfg.Throttle = time.Duration(minutes) * time.Minute
+		prefs.SetString(prefEmailServer, cfg.Server)
+		prefs.SetString(prefEmailUser, cfg.User)
+		prefs.SetString(prefEmailFrom, cfg.From)
+		prefs.SetString(prefEmailTo, toEntry.Text)
+		prefs.SetInt(prefEmailThrottle, minutes)
+		return cfg, nil
+	}
+
+	send = func(e alarmEvent) {
+		if e.cleared {
+			return
+		}
+		cfg, err := config()
+		if err != nil {
+			log.Printf("%v", err)
+			return
+		}
+		var snap *snapshot
+		if s, ok := currentSnapshot(); ok {
+			snap = &s
+		}
+		notifier.notify(cfg, e, snap)
+	}
+	testButton := widget.NewButton(tr("发送测试邮件"), func() {
+		cfg, err := config()
+		if err != nil {
+			log.Printf("%v", err)
+			return
+		}
+		var snap *snapshot
+		if s, ok := currentSnapshot(); ok {
+			snap = &s
+		}
+		go func() {
+			if err := sendAlarmEmail(cfg, []alarmEvent{{time: time.Now(), plc: "-", tag: tr("测试"), rule: "-", value: "-"}}, snap); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			log.Print(tr("已发送测试邮件"))
+		}()
+	})
+
+	content = widget.NewForm(
+		widget.NewFormItem(tr("SMTP服务器:"), container.NewGridWithColumns(2, serverEntry, container.NewBorder(nil, nil, widget.NewLabel(tr("最短间隔(分钟):")), nil, throttleEntry))),
+		widget.NewFormItem(tr("用户名/密码:"), container.NewGridWithColumns(2, userEntry, passwordEntry)),
+		widget.NewFormItem(tr("发件人/收件人:"), container.NewBorder(nil, nil, nil, testButton, container.NewGridWithColumns(2, fromEntry, toEntry))),
+	)
+	return content, send
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件日志级别，按严重程度递增
+const (
+	eventInfo = iota
+	eventWarn
+	eventError
+)
+
+var eventLevelNames = []string{"信息", "警告", "错误"}
+
+// maxEventEntries 事件日志保留的最大条数，超出时丢弃最早的
+const maxEventEntries = 2000
+
+type eventEntry struct {
+	time  time.Time
+	level int
+	text  string
+}
+
+func (e eventEntry) String() string {
+	return e.time.Format("2006-01-02 15:04:05") + " [" + tr(eventLevelNames[e.level]) + "] " + e.text
+}
+
+// eventLog 收集标准日志的输出，供窗口内的日志面板显示。作为io.Writer与终端输出一起
+// 设置给log包，任何协程都可以写入；onAdd在写入后调用（不在UI线程中），不能再写日志。
+type eventLog struct {
+	mu      sync.Mutex
+	entries []eventEntry
+	onAdd   func()
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{}
+}
+
+// Write 解析log包输出的一行或多行，去掉log添加的日期时间前缀，按内容判断级别
+func (l *eventLog) Write(p []byte) (int, error) {
+	now := time.Now()
+	var added []eventEntry
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if t, err := time.ParseInLocation("2006/01/02 15:04:05", prefixOf(line, 19), time.Local); err == nil {
+			now, line = t, strings.TrimPrefix(line[19:], " ")
+		}
+		if line == "" {
+			continue
+		}
+		added = append(added, eventEntry{time: now, level: eventLevel(line), text: line})
+	}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, added...)
+	if n := len(l.entries) - maxEventEntries; n > 0 {
+		l.entries = append([]eventEntry(nil), l.entries[n:]...)
+	}
+	onAdd := l.onAdd
+	l.mu.Unlock()
+	if onAdd != nil && len(added) > 0 {
+		onAdd()
+	}
+	return len(p), nil
+}
+
+// filtered 返回级别不低于minLevel的日志
+func (l *eventLog) filtered(minLevel int) []eventEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []eventEntry
+	for _, e := range l.entries {
+		if e.level >= minLevel {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (l *eventLog) setOnAdd(fn func()) {
+	l.mu.Lock()
+	l.onAdd = fn
+	l.mu.Unlock()
+}
+
+func (l *eventLog) clear() {
+	l.mu.Lock()
+	l.entries = nil
+	l.mu.Unlock()
+}
+
+// eventLevel 日志都用log.Printf输出，按关键字判断级别：失败和错误为错误，异常、重连和报警为警告。
+// 英文界面下日志也是英文，同时匹配英文关键字。
+func eventLevel(text string) int {
+	lower := strings.ToLower(text)
+	for _, k := range []string{"失败", "错误", "无效", "failed", "error", "invalid"} {
+		if strings.Contains(lower, k) {
+			return eventError
+		}
+	}
+	for _, k := range []string{"异常", "重连", "报警", "跳过", "警告", "unstable", "reconnect", "alarm", "skipped", "warning"} {
+		if strings.Contains(lower, k) {
+			return eventWarn
+		}
+	}
+	return eventInfo
+}
+
+// prefixOf 返回s的前n个字节，不足n个时返回s
+func prefixOf(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
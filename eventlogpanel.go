@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image/color"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 日志面板的级别筛选，选项对应显示的最低级别
+var eventFilterNames = []string{"全部", "警告及错误", "仅错误"}
+
+// eventLogHeight 日志面板展开时列表的高度
+const eventLogHeight = 160
+
+// newEventLogPanel 创建可折叠的日志面板，显示连接、断开、读取错误和写入等事件，
+// 可按级别筛选并复制全部内容，不必再从终端启动程序查看日志。
+func newEventLogPanel(win fyne.Window, events *eventLog) fyne.CanvasObject {
+	// 当前显示的日志，只在UI线程中访问
+	var shown []eventEntry
+	minLevel := eventInfo
+
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			e := shown[id]
+			label.SetText(e.String())
+			label.Importance = widget.MediumImportance
+			switch e.level {
+			case eventWarn:
+				label.Importance = widget.WarningImportance
+			case eventError:
+				label.Importance = widget.DangerImportance
+			}
+			label.Refresh()
+		},
+	)
+
+	reload := func() {
+		shown = events.filtered(minLevel)
+		list.Refresh()
+		list.ScrollToBottom()
+	}
+
+	filterSelect := newTrSelect(eventFilterNames, func(name string) {
+		minLevel = slices.Index(eventFilterNames, name)
+		reload()
+	})
+	filterSelect.SetSelected(tr(eventFilterNames[0]))
+
+	copyButton := widget.NewButton(tr("复制全部"), func() {
+		lines := make([]string, len(shown))
+		for i, e := range shown {
+			lines[i] = e.String()
+		}
+		win.Clipboard().SetContent(strings.Join(lines, "\n"))
+	})
+	clearButton := widget.NewButton(tr("清空"), func() {
+		events.clear()
+		reload()
+	})
+
+	events.setOnAdd(func() { fyne.Do(reload) })
+	reload()
+
+	spacer := canvas.NewRectangle(color.Transparent)
+	spacer.SetMinSize(fyne.NewSize(0, eventLogHeight))
+	content := container.NewBorder(
+		container.NewHBox(widget.NewLabel(tr("级别:")), filterSelect, copyButton, clearButton),
+		nil, nil, nil,
+		container.NewStack(spacer, list),
+	)
+	return widget.NewAccordion(widget.NewAccordionItem(tr("日志"), content))
+}
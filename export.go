@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// capture 一次读取得到的数据及其采集信息
+type capture struct {
+	Time         time.Time `json:"time"`
+	IP           string    `json:"ip"`
+	Area         string    `json:"area"`
+	StartAddress int       `json:"startAddress"`
+	Data         []byte    `json:"data"`
+	Note         string    `json:"note,omitempty"` // 用户备注，可为空
+}
+
+// writeCaptureCSV 将采集数据按字节写成CSV，采集信息和备注以#注释行写在文件头
+func writeCaptureCSV(w io.Writer, c capture) error {
+	// T/C区每个编号占2字节，长度按编号个数计
+	length := fmt.Sprintf(tr("# 起始地址: %s, 长度: %d字节"), s7viewer.ByteAddressName(c.Area, c.StartAddress), len(c.Data))
+	if s7viewer.IsCounterArea(c.Area) {
+		length = fmt.Sprintf(tr("# 起始地址: %s, 长度: %d个"), s7viewer.ByteAddressName(c.Area, c.StartAddress), len(c.Data)/2)
+	}
+	header := []string{
+		tr("# 采集时间: ") + c.Time.Format("2006-01-02 15:04:05"),
+		"# PLC: " + c.IP,
+		length,
+	}
+	if note := strings.TrimSpace(c.Note); note != "" {
+		for _, line := range strings.Split(note, "\n") {
+			header = append(header, tr("# 备注: ")+strings.TrimRight(line, "\r"))
+		}
+	}
+	for _, line := range header {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{tr("地址"), tr("十进制"), tr("十六进制"), tr("二进制")}); err != nil {
+		return err
+	}
+	for i, b := range c.Data {
+		offset := c.StartAddress + i
+		if s7viewer.IsCounterArea(c.Area) {
+			offset = c.StartAddress + i/2
+		}
+		row := []string{
+			s7viewer.ByteAddressName(c.Area, offset),
+			strconv.Itoa(int(b)),
+			fmt.Sprintf("%02X", b),
+			fmt.Sprintf("%08b", b),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
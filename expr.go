@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprFunc 编译后的表达式，vars为各变量的当前值。比较和逻辑运算的结果为1或0，非0视为真。
+type exprFunc func(vars map[string]float64) (float64, error)
+
+// exprFuncs 表达式中可用的函数及其参数个数
+var exprFuncs = map[string]struct {
+	args int
+	fn   func(a []float64) float64
+}{
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"round": {1, func(a []float64) float64 { return math.Round(a[0]) }},
+	"min":   {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":   {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+}
+
+// exprToken 词法单元：kind为'n'数值、'i'标识符、'o'运算符或括号、0表示结束
+type exprToken struct {
+	kind byte
+	text string
+	num  float64
+}
+
+// tokenizeExpr 将表达式拆分为词法单元。标识符可含点，如地址V10.0
+func tokenizeExpr(src string) ([]exprToken, error) {
+	var toks []exprToken
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1]):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			v, err := strconv.ParseFloat(string(rs[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf(tr("无效的数值: %q"), string(rs[i:j]))
+			}
+			toks = append(toks, exprToken{kind: 'n', text: string(rs[i:j]), num: v})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '.') {
+				j++
+			}
+			toks = append(toks, exprToken{kind: 'i', text: string(rs[i:j])})
+			i = j
+		default:
+			op := string(r)
+			if i+1 < len(rs) {
+				switch two := string(rs[i : i+2]); two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					op = two
+				}
+			}
+			if !strings.Contains("+-*/%()!<>,", op) && len(op) == 1 {
+				return nil, fmt.Errorf(tr("表达式中的无效字符: %q"), op)
+			}
+			toks = append(toks, exprToken{kind: 'o', text: op})
+			i += len([]rune(op))
+		}
+	}
+	return append(toks, exprToken{}), nil
+}
+
+// exprParser 按优先级递归下降解析：|| < && < 比较 < 加减 < 乘除取余 < 一元运算
+type exprParser struct {
+	toks []exprToken
+	pos  int
+	// resolve 将标识符转换为vars中的键，未定义时返回错误
+	resolve func(ident string) (string, error)
+}
+
+// compileExpr 编译表达式，resolve将其中的标识符转换为变量名
+func compileExpr(src string, resolve func(ident string) (string, error)) (exprFunc, error) {
+	toks, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks, resolve: resolve}
+	f, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != 0 {
+		return nil, fmt.Errorf(tr("表达式中多余的 %q"), t.text)
+	}
+	return f, nil
+}
+
+func (p *exprParser) peek() exprToken { return p.toks[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	t := p.toks[p.pos]
+	if t.kind != 0 {
+		p.pos++
+	}
+	return t
+}
+
+// exprLevels 各优先级的二元运算符，从低到高
+var exprLevels = [][]string{{"||"}, {"&&"}, {"==", "!=", "<", "<=", ">", ">="}, {"+", "-"}, {"*", "/", "%"}}
+
+func (p *exprParser) parseBinary(level int) (exprFunc, error) {
+	if level == len(exprLevels) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != 'o' || !slices.Contains(exprLevels[level], t.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(t.text, left, right)
+	}
+}
+
+// binaryExpr 组合二元运算，&&和||短路求值
+func binaryExpr(op string, left, right exprFunc) exprFunc {
+	return func(vars map[string]float64) (float64, error) {
+		a, err := left(vars)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "&&":
+			if a == 0 {
+				return 0, nil
+			}
+		case "||":
+			if a != 0 {
+				return 1, nil
+			}
+		}
+		b, err := right(vars)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "&&", "||":
+			return boolValue(b != 0), nil
+		case "==":
+			return boolValue(a == b), nil
+		case "!=":
+			return boolValue(a != b), nil
+		case "<":
+			return boolValue(a < b), nil
+		case "<=":
+			return boolValue(a <= b), nil
+		case ">":
+			return boolValue(a > b), nil
+		case ">=":
+			return boolValue(a >= b), nil
+		case "+":
+			return a + b, nil
+		case "-":
+			return a - b, nil
+		case "*":
+			return a * b, nil
+		}
+		if b == 0 {
+			return 0, errors.New(tr("除数为0"))
+		}
+		if op == "%" {
+			return math.Mod(a, b), nil
+		}
+		return a / b, nil
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *exprParser) parseUnary() (exprFunc, error) {
+	t := p.peek()
+	if t.kind == 'o' && (t.text == "!" || t.text == "-" || t.text == "+") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(vars map[string]float64) (float64, error) {
+			v, err := operand(vars)
+			switch t.text {
+			case "!":
+				return boolValue(v == 0), err
+			case "-":
+				return -v, err
+			}
+			return v, err
+		}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprFunc, error) {
+	t := p.next()
+	switch {
+	case t.kind == 'n':
+		return func(map[string]float64) (float64, error) { return t.num, nil }, nil
+	case t.kind == 'o' && t.text == "(":
+		f, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.next().text != ")" {
+			return nil, errors.New(tr("表达式缺少右括号"))
+		}
+		return f, nil
+	case t.kind == 'i' && p.peek().text == "(":
+		return p.parseCall(t.text)
+	case t.kind == 'i':
+		key, err := p.resolve(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return func(vars map[string]float64) (float64, error) {
+			v, ok := vars[key]
+			if !ok {
+				return 0, fmt.Errorf(tr("%s 没有值"), t.text)
+			}
+			return v, nil
+		}, nil
+	case t.kind == 0:
+		return nil, errors.New(tr("表达式不完整"))
+	}
+	return nil, fmt.Errorf(tr("表达式中意外的 %q"), t.text)
+}
+
+// parseCall 解析函数调用，如 max(VW100, 0)
+func (p *exprParser) parseCall(name string) (exprFunc, error) {
+	def, ok := exprFuncs[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf(tr("未知的函数 %s"), name)
+	}
+	p.next() // (
+	var args []exprFunc
+	for p.peek().text != ")" {
+		if len(args) > 0 && p.next().text != "," {
+			return nil, fmt.Errorf(tr("函数 %s 的参数应以逗号分隔"), name)
+		}
+		arg, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next() // )
+	if len(args) != def.args {
+		return nil, fmt.Errorf(tr("函数 %s 需要%d个参数"), name, def.args)
+	}
+	return func(vars map[string]float64) (float64, error) {
+		a := make([]float64, len(args))
+		for i, arg := range args {
+			v, err := arg(vars)
+			if err != nil {
+				return 0, err
+			}
+			a[i] = v
+		}
+		return def.fn(a), nil
+	}, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testExprResolve 测试用的标识符解析：只认识VW100、VD4、V10.0和x
+func testExprResolve(ident string) (string, error) {
+	switch ident {
+	case "VW100", "VD4", "V10.0", "x":
+		return ident, nil
+	}
+	return "", fmt.Errorf("未定义的变量 %s", ident)
+}
+
+func TestExprEval(t *testing.T) {
+	vars := map[string]float64{"VW100": 250, "VD4": -4, "V10.0": 1}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"24 / 4 / 2", 3},
+		{"7 % 4 * 2", 6},
+		{"1 + 2 < 4", 1},
+		{"2 * 3 == 6 && 1 < 0", 0},
+		{"0 || 1 && 0", 0},
+		{"1 || 0 && 0", 1},
+		{"1 < 2 == 1", 1},
+		{"-2 * 3", -6},
+		{"2 - -3", 5},
+		{"-(2 + 3)", -5},
+		{"+4", 4},
+		{"!0", 1},
+		{"!VW100", 0},
+		{"!!3", 1},
+		{"-VD4 + 1", 5},
+		{"VW100 / 10", 25},
+		{"V10.0 && VW100 >= 250", 1},
+		{"VW100 != 250 || VD4 <= -4", 1},
+		{".5 + 1.25", 1.75},
+		{"max(VW100, 300) - min(1, 2)", 299},
+		{"ABS(VD4) + round(2.5) + sqrt(16)", 11},
+		{"max(1 + 2, 2 * 2)", 4},
+	}
+	for _, tt := range tests {
+		f, err := compileExpr(tt.src, testExprResolve)
+		if err != nil {
+			t.Errorf("compileExpr(%q): %v", tt.src, err)
+			continue
+		}
+		got, err := f(vars)
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%q = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestExprShortCircuit(t *testing.T) {
+	// x没有值，只有在被求值时才报错
+	vars := map[string]float64{"VW100": 1}
+	tests := []struct {
+		src     string
+		want    float64
+		wantErr bool
+	}{
+		{"0 && x", 0, false},
+		{"VW100 || x", 1, false},
+		{"VW100 - 1 && x / 0", 0, false},
+		{"1 && x", 0, true},
+		{"0 || x", 0, true},
+		{"x && 0", 0, true},
+	}
+	for _, tt := range tests {
+		f, err := compileExpr(tt.src, testExprResolve)
+		if err != nil {
+			t.Errorf("compileExpr(%q): %v", tt.src, err)
+			continue
+		}
+		got, err := f(vars)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "x") {
+				t.Errorf("%q = %v, %v, want error for x", tt.src, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestExprDivideByZero(t *testing.T) {
+	vars := map[string]float64{"VW100": 0}
+	for _, src := range []string{"1 / 0", "5 % 0", "10 / VW100", "1 + 2 / (3 - 3)", "max(1, 1 / VW100)", "-(1 / 0)"} {
+		f, err := compileExpr(src, testExprResolve)
+		if err != nil {
+			t.Errorf("compileExpr(%q): %v", src, err)
+			continue
+		}
+		if got, err := f(vars); err == nil {
+			t.Errorf("%q = %v, want division by zero error", src, got)
+		}
+	}
+}
+
+func TestExprCompileErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string // 错误信息中应包含的内容
+	}{
+		// 函数参数个数
+		{"abs()", "abs"},
+		{"abs(1, 2)", "abs"},
+		{"max(1)", "max"},
+		{"min(1, 2, 3)", "min"},
+		{"log(1)", "log"},
+		// 未定义的标识符
+		{"VW200 + 1", "VW200"},
+		{"max(VW100, M0.0)", "M0.0"},
+		// 格式错误
+		{"", ""},
+		{"1 +", ""},
+		{"(1 + 2", ""},
+		{"1 + 2)", ")"},
+		{"1 2", "2"},
+		{"* 3", "*"},
+		{"1..2", "1..2"},
+		{"VW100 # 2", "#"},
+		{"max(1 2)", "max"},
+		{"max(1,", ""},
+		{"max(1, 2", ""},
+		{"VW100 = 1", "="},
+		{"1 & 2", "&"},
+	}
+	for _, tt := range tests {
+		f, err := compileExpr(tt.src, testExprResolve)
+		if err == nil {
+			v, evalErr := f(map[string]float64{})
+			t.Errorf("compileExpr(%q) succeeded (= %v, %v), want error", tt.src, v, evalErr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("compileExpr(%q) error = %q, want it to mention %q", tt.src, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// forceAreas 允许强制的存储区。输入每个扫描周期由硬件刷新，强制没有意义
+var forceAreas = []string{s7viewer.AreaQ, s7viewer.AreaV, s7viewer.AreaM}
+
+// forceEntry 一个被强制的位
+type forceEntry struct {
+	addr  s7Address
+	value bool
+}
+
+// forceTable 强制表：在释放前按扫描周期把选中的Q/V/M位反复写为指定值，模拟Micro/WIN的强制功能。
+// 与PLC的真正强制不同，程序在两次写入之间仍可能改写该位；断开连接时全部释放。
+type forceTable struct {
+	mu      sync.Mutex
+	entries []forceEntry // 按地址排序
+	cancel  context.CancelFunc
+	lastErr error
+	// plc 返回当前PLC的地址，记录到写入审计
+	plc func() string
+	// onChange 强制项或写入状态变化时在UI线程中调用
+	onChange func()
+}
+
+// parseForceAddress 解析要强制的位地址，只接受Q、V、M区的位
+func parseForceAddress(s string) (s7Address, error) {
+	addr, err := parseAddress(strings.ToUpper(strings.TrimSpace(s)))
+	if err != nil {
+		return s7Address{}, err
+	}
+	if addr.size != "" || !containsString(forceAreas, addr.area) {
+		return s7Address{}, fmt.Errorf(tr("只能强制Q、V、M区的位，如 Q0.0、V10.1: %q"), strings.TrimSpace(s))
+	}
+	return addr, nil
+}
+
+// set 强制addr为value，已强制的位改为新值；强制循环未运行时在viewer上启动
+func (f *forceTable) set(ctx context.Context, viewer *s7viewer.Viewer, addr s7Address, value bool) {
+	f.mu.Lock()
+	replaced := false
+	for i := range f.entries {
+		if f.entries[i].addr == addr {
+			f.entries[i].value = value
+			replaced = true
+		}
+	}
+	if !replaced {
+		f.entries = append(f.entries, forceEntry{addr: addr, value: value})
+		sort.Slice(f.entries, func(i, j int) bool { return f.entries[i].addr.String() < f.entries[j].addr.String() })
+	}
+	if f.cancel == nil {
+		// 反复写入不逐次审计，只记录强制和释放
+		loopCtx, cancel := context.WithCancel(s7viewer.WithoutWriteHandler(ctx))
+		f.cancel = cancel
+		go f.run(loopCtx, viewer)
+	}
+	f.mu.Unlock()
+	log.Printf(tr("强制 %s = %d"), addr, boolToInt(value))
+	auditForceChange(f.plc(), auditForce, addr, value)
+	f.changed()
+}
+
+// release 释放一个位的强制，释放后该位保持最后写入的值
+func (f *forceTable) release(addr s7Address) {
+	f.mu.Lock()
+	found := false
+	for i, e := range f.entries {
+		if e.addr == addr {
+			f.entries = append(f.entries[:i], f.entries[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if len(f.entries) == 0 {
+		f.stopLocked()
+	}
+	f.mu.Unlock()
+	if !found {
+		return
+	}
+	log.Printf(tr("释放强制 %s"), addr)
+	auditForceChange(f.plc(), auditRelease, addr, false)
+	f.changed()
+}
+
+// releaseAll 释放所有强制并停止强制循环
+func (f *forceTable) releaseAll() {
+	f.mu.Lock()
+	released := f.entries
+	f.entries = nil
+	f.stopLocked()
+	f.mu.Unlock()
+	if len(released) == 0 {
+		return
+	}
+	log.Printf(tr("已释放全部%d个强制"), len(released))
+	for _, e := range released {
+		auditForceChange(f.plc(), auditRelease, e.addr, false)
+	}
+	f.changed()
+}
+
+func (f *forceTable) stopLocked() {
+	if f.cancel != nil {
+		f.cancel()
+		f.cancel = nil
+	}
+	f.lastErr = nil
+}
+
+// snapshot 返回当前的强制项和最近一次写入错误
+func (f *forceTable) snapshot() ([]forceEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]forceEntry(nil), f.entries...), f.lastErr
+}
+
+func (f *forceTable) changed() {
+	if f.onChange != nil {
+		fyne.Do(f.onChange)
+	}
+}
+
+// run 按viewer的扫描周期写入所有强制位：同一字节的位合并为一次读-改-写，值未变时不写
+func (f *forceTable) run(ctx context.Context, viewer *s7viewer.Viewer) {
+	ticker := time.NewTicker(viewer.ScanInterval())
+	defer ticker.Stop()
+	for {
+		entries, prevErr := f.snapshot()
+		err := writeForces(ctx, viewer, entries)
+		if ctx.Err() != nil {
+			return
+		}
+		if (err == nil) != (prevErr == nil) {
+			if err != nil {
+				log.Printf(tr("强制写入失败: %v"), err)
+			}
+			f.mu.Lock()
+			f.lastErr = err
+			f.mu.Unlock()
+			f.changed()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeForces 把强制位按字节分组写入
+func writeForces(ctx context.Context, viewer *s7viewer.Viewer, entries []forceEntry) error {
+	type byteKey struct {
+		area string
+		off  int
+	}
+	masks := make(map[byteKey][2]byte) // 每个字节的 {掩码, 值}
+	var order []byteKey
+	for _, e := range entries {
+		k := byteKey{e.addr.area, e.addr.byteOff}
+		m, ok := masks[k]
+		if !ok {
+			order = append(order, k)
+		}
+		m[0] |= 1 << e.addr.bit
+		if e.value {
+			m[1] |= 1 << e.addr.bit
+		}
+		masks[k] = m
+	}
+	for _, k := range order {
+		data, err := viewer.ReadArea(ctx, k.area, k.off, 1)
+		if err != nil {
+			return err
+		}
+		m := masks[k]
+		b := data[0]&^m[0] | m[1]
+		if b == data[0] {
+			continue
+		}
+		if err := viewer.WriteArea(ctx, k.area, k.off, []byte{b}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forceEntriesText 强制项的简短列表，如 "Q0.0=1, V10.1=0"
+func forceEntriesText(entries []forceEntry) string {
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = fmt.Sprintf("%s=%d", e.addr, boolToInt(e.value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var colorForceBanner = color.RGBA{R: 200, G: 0, B: 0, A: 255}
+
+// newForceBanner 创建窗口顶部醒目的“强制已激活”横幅，没有强制时隐藏。返回横幅和刷新函数（在UI线程中调用）
+func newForceBanner(forces *forceTable) (fyne.CanvasObject, func()) {
+	background := canvas.NewRectangle(colorForceBanner)
+	text := canvas.NewText("", color.White)
+	text.TextStyle = fyne.TextStyle{Bold: true}
+	text.TextSize = theme.TextSubHeadingSize()
+	releaseButton := widget.NewButton(tr("全部释放"), forces.releaseAll)
+	banner := container.NewStack(background,
+		container.NewBorder(nil, nil, nil, releaseButton, container.NewPadded(text)))
+	banner.Hide()
+	refresh := func() {
+		entries, err := forces.snapshot()
+		if len(entries) == 0 {
+			banner.Hide()
+			return
+		}
+		msg := fmt.Sprintf(tr("强制已激活 (%d): %s"), len(entries), forceEntriesText(entries))
+		if err != nil {
+			msg += "  " + tr("写入失败: ") + err.Error()
+		}
+		text.Text = msg
+		text.Refresh()
+		banner.Show()
+	}
+	return banner, refresh
+}
+
+// newForcePanel 创建强制表页：输入位地址和值后强制，列表中可逐个释放
+func newForcePanel(forces *forceTable, getViewer func() (*s7viewer.Viewer, context.Context)) (fyne.CanvasObject, func()) {
+	addrEntry := widget.NewEntry()
+	addrEntry.SetPlaceHolder(tr("位地址，如 Q0.0、V10.1"))
+	valueSelect := widget.NewSelect([]string{"1", "0"}, nil)
+	valueSelect.SetSelected("1")
+	statusLabel := widget.NewLabel("")
+
+	var entries []forceEntry // 只在UI线程中访问
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButton(tr("释放"), nil), widget.NewLabel(""))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			e := entries[i]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s = %d", e.addr, boolToInt(e.value)))
+			row.Objects[1].(*widget.Button).OnTapped = func() { forces.release(e.addr) }
+		},
+	)
+
+	forceButton := widget.NewButton(tr("强制"), func() {
+		viewer, ctx := getViewer()
+		if viewer == nil || !viewer.IsConnected() {
+			statusLabel.SetText(tr("PLC未连接"))
+			return
+		}
+		addr, err := parseForceAddress(addrEntry.Text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		forces.set(ctx, viewer, addr, valueSelect.Selected == "1")
+		statusLabel.SetText("")
+	})
+	disableInReadOnly(forceButton)
+
+	refresh := func() {
+		entries, _ = forces.snapshot()
+		list.Refresh()
+	}
+
+	return container.NewBorder(
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem(tr("地址:"), addrEntry),
+				widget.NewFormItem(tr("值:"), valueSelect),
+			),
+			container.NewHBox(forceButton, widget.NewButton(tr("全部释放"), forces.releaseAll), statusLabel),
+			widget.NewLabel(tr("强制的位按扫描周期反复写入，直到释放或断开连接；程序在两次写入之间仍可能改写该位。")),
+		),
+		nil, nil, nil,
+		list,
+	), refresh
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 寄存器内容的显示格式
+const (
+	formatUnsigned = "无符号16位"
+	formatSigned   = "有符号INT"
+	formatHex16    = "十六进制(字)"
+	formatBinary   = "二进制(字节)"
+	formatDInt     = "有符号DINT"
+	formatDWord    = "无符号DWORD"
+	formatReal     = "REAL(浮点)"
+	formatBCD16    = "BCD16"
+	formatBCD32    = "BCD32"
+)
+
+var registerFormats = []string{formatUnsigned, formatSigned, formatHex16, formatBinary, formatDInt, formatDWord, formatReal, formatBCD16, formatBCD32}
+
+// formatRegisters 按显示格式和字节顺序order将字节数据格式化为逗号分隔的文本。
+// 16位格式在奇数长度时最后一个字节作为低8位；32位格式不足4字节的剩余部分以十六进制附在末尾。
+func formatRegisters(format, order string, data []byte) string {
+	var parts []string
+	switch format {
+	case formatBinary:
+		for _, b := range data {
+			parts = append(parts, fmt.Sprintf("%08b", b))
+		}
+		return strings.Join(parts, " ")
+	case formatDInt, formatDWord, formatReal, formatBCD32:
+		n := len(data) / 4 * 4
+		for i := 0; i < n; i += 4 {
+			v := binary.BigEndian.Uint32(s7viewer.ToBigEndian(order, data[i:i+4]))
+			switch format {
+			case formatDInt:
+				parts = append(parts, strconv.Itoa(int(int32(v))))
+			case formatDWord:
+				parts = append(parts, strconv.FormatUint(uint64(v), 10))
+			case formatBCD32:
+				parts = append(parts, formatBCD(v, 8))
+			default:
+				parts = append(parts, strconv.FormatFloat(float64(math.Float32frombits(v)), 'g', -1, 32))
+			}
+		}
+		if n < len(data) {
+			parts = append(parts, fmt.Sprintf(tr("余 % X"), data[n:]))
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	for i, v := range convertBytesTo16BitInts(data) {
+		if 2*i+1 < len(data) {
+			v = int(binary.BigEndian.Uint16(s7viewer.ToBigEndian(order, data[2*i:2*i+2])))
+		}
+		switch format {
+		case formatSigned:
+			if 2*i+1 < len(data) {
+				v = int(int16(v))
+			}
+			parts = append(parts, strconv.Itoa(v))
+		case formatHex16:
+			parts = append(parts, fmt.Sprintf("%04X", v))
+		case formatBCD16:
+			parts = append(parts, formatBCD(uint32(v), 4))
+		default:
+			parts = append(parts, strconv.Itoa(v))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatBCD 格式化BCD码，无效的BCD码显示为 ?十六进制
+func formatBCD(raw uint32, digits int) string {
+	v, err := s7viewer.DecodeBCD(raw, digits)
+	if err != nil {
+		return fmt.Sprintf("?%0*X", digits, raw)
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
@@ -0,0 +1,646 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 网格显示样式
+const (
+	gridStyleSquare = "标准方块"
+	gridStyleDense  = "紧凑(无间隙)"
+	gridStyleCircle = "圆点(LED)"
+)
+
+var gridStyles = []string{gridStyleSquare, gridStyleDense, gridStyleCircle}
+
+// 方块尺寸范围（缩放滑块）
+const (
+	minCellSize     = 8
+	maxCellSize     = 40
+	defaultCellSize = 25
+)
+
+// 网格尺寸：每行的位数（整字节）和每页的行数
+const (
+	defaultGridCols = 32
+	defaultGridRows = 20
+	maxGridRows     = 64
+)
+
+var gridColumnChoices = []string{"8", "16", "32"}
+
+// 字节内位的排列顺序
+const (
+	bitOrderMSBLeft = "位7在左(7…0)"
+	bitOrderLSBLeft = "位0在左(0…7)"
+)
+
+var bitOrders = []string{bitOrderMSBLeft, bitOrderLSBLeft}
+
+// bitZeroLeft 为true时每个字节的位0画在最左侧，由位顺序设置修改，新建网格时生效
+var bitZeroLeft bool
+
+// 值为1的位除颜色外另加的标记，不依赖颜色也能区分0和1
+const (
+	markerNone  = "仅颜色"
+	markerDot   = "实心圆点"
+	markerGlyph = "数字1"
+)
+
+var bitMarkers = []string{markerNone, markerDot, markerGlyph}
+
+// bitMarker 当前的1位标记，由外观设置修改，新建网格时生效
+var bitMarker = markerNone
+
+// defaultFlashDuration 位变化后黄色边框的默认保持时间
+const defaultFlashDuration = time.Second
+
+// 行/列标题尺寸
+const (
+	rowHeaderWidth  = 36
+	colHeaderHeight = 16
+)
+
+var (
+	colorBitOn    = color.RGBA{R: 0, G: 255, B: 0, A: 255}     // 绿色表示1
+	colorBitOff   = color.RGBA{R: 128, G: 128, B: 128, A: 255} // 灰色表示0或未使用
+	colorBitMuted = color.RGBA{R: 64, G: 64, B: 64, A: 255}    // 深灰表示已屏蔽的行/列
+	colorBitFlash = color.RGBA{R: 255, G: 215, B: 0, A: 255}   // 黄色边框表示刚刚变化的位
+	colorBitStale = color.RGBA{R: 148, G: 0, B: 211, A: 255}   // 紫色表示读取失败，显示的数据已过期
+
+	colorStatusError = color.RGBA{R: 220, G: 0, B: 0, A: 255}   // 红色表示连接异常
+	colorStatusWarn  = color.RGBA{R: 255, G: 165, B: 0, A: 255} // 橙色表示正在自动重连
+)
+
+// bitGridLayout 按固定单元尺寸和间距排列指示器，不随窗口拉伸。
+// objects依次为cols*rows个单元格、cols个列标题、rows个行标题，
+// 其后的对象（悬停提示）只按最小尺寸调整大小，位置由使用者设置。
+// 单元格和列标题按位7在前的顺序排列，reverse为true时每8列左右翻转。
+type bitGridLayout struct {
+	cols, rows int
+	cell       float32
+	gap        float32
+	reverse    bool
+}
+
+// column 返回第col列（位7在前的顺序）实际显示的位置
+func (l *bitGridLayout) column(col int) int {
+	if l.reverse {
+		return col ^ 7
+	}
+	return col
+}
+
+func (l *bitGridLayout) Layout(objects []fyne.CanvasObject, _ fyne.Size) {
+	step := l.cell + l.gap
+	cellCount := l.cols * l.rows
+	for i, o := range objects {
+		switch {
+		case i < cellCount:
+			row, col := i/l.cols, l.column(i%l.cols)
+			o.Move(fyne.NewPos(rowHeaderWidth+float32(col)*step, colHeaderHeight+float32(row)*step))
+			o.Resize(fyne.NewSquareSize(l.cell))
+		case i < cellCount+l.cols:
+			col := l.column(i - cellCount)
+			o.Move(fyne.NewPos(rowHeaderWidth+float32(col)*step, 0))
+			o.Resize(fyne.NewSize(l.cell, colHeaderHeight))
+		case i >= cellCount+l.cols+l.rows:
+			o.Resize(o.MinSize())
+		default:
+			row := i - cellCount - l.cols
+			o.Move(fyne.NewPos(0, colHeaderHeight+float32(row)*step))
+			o.Resize(fyne.NewSize(rowHeaderWidth-2, l.cell))
+		}
+	}
+}
+
+func (l *bitGridLayout) MinSize(_ []fyne.CanvasObject) fyne.Size {
+	step := l.cell + l.gap
+	return fyne.NewSize(rowHeaderWidth+float32(l.cols)*step-l.gap, colHeaderHeight+float32(l.rows)*step-l.gap)
+}
+
+// muteMask 记录被屏蔽（不关注）的行和列。
+// 屏蔽的单元格仍然读取，但灰显且不参与变化分析。
+type muteMask struct {
+	rows map[int]bool
+	cols map[int]bool
+}
+
+func newMuteMask(rows, cols []int) *muteMask {
+	m := &muteMask{rows: make(map[int]bool), cols: make(map[int]bool)}
+	for _, r := range rows {
+		m.rows[r] = true
+	}
+	for _, c := range cols {
+		m.cols[c] = true
+	}
+	return m
+}
+
+// muted 判断指定单元格是否被屏蔽
+func (m *muteMask) muted(row, col int) bool {
+	return m.rows[row] || m.cols[col]
+}
+
+func (m *muteMask) toggleRow(row int) {
+	if m.rows[row] {
+		delete(m.rows, row)
+	} else {
+		m.rows[row] = true
+	}
+}
+
+func (m *muteMask) toggleCol(col int) {
+	if m.cols[col] {
+		delete(m.cols, col)
+	} else {
+		m.cols[col] = true
+	}
+}
+
+// rowList/colList 返回排序后的屏蔽行/列，用于持久化
+func (m *muteMask) rowList() []int { return sortedKeys(m.rows) }
+func (m *muteMask) colList() []int { return sortedKeys(m.cols) }
+
+func sortedKeys(set map[int]bool) []int {
+	keys := make([]int, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// gridHeader 可点击的行/列标题，点击切换该行/列的屏蔽状态
+type gridHeader struct {
+	widget.BaseWidget
+	text  *canvas.Text
+	bg    *canvas.Rectangle
+	onTap func()
+}
+
+func newGridHeader(label string, onTap func()) *gridHeader {
+	h := &gridHeader{
+		text:  canvas.NewText(label, theme.Color(theme.ColorNameForeground)),
+		bg:    canvas.NewRectangle(color.Transparent),
+		onTap: onTap,
+	}
+	h.text.TextSize = 10
+	h.text.Alignment = fyne.TextAlignCenter
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+func (h *gridHeader) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(h.bg, h.text))
+}
+
+func (h *gridHeader) Tapped(*fyne.PointEvent) {
+	if h.onTap != nil {
+		h.onTap()
+	}
+}
+
+func (h *gridHeader) setMuted(muted bool) {
+	if muted {
+		h.bg.FillColor = colorBitMuted
+	} else {
+		h.bg.FillColor = color.Transparent
+	}
+	h.bg.Refresh()
+}
+
+// bitCell 包装单个位指示器，接收双击和鼠标悬停事件
+type bitCell struct {
+	widget.BaseWidget
+	indicator   fyne.CanvasObject
+	onDoubleTap func()
+	onHover     func(pos fyne.Position) // 参数为鼠标在单元格内的位置
+	onHoverEnd  func()
+	onSecondary func()
+}
+
+func newBitCell(indicator fyne.CanvasObject, onDoubleTap func()) *bitCell {
+	c := &bitCell{indicator: indicator, onDoubleTap: onDoubleTap}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *bitCell) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(c.indicator)
+}
+
+func (c *bitCell) DoubleTapped(*fyne.PointEvent) {
+	if c.onDoubleTap != nil {
+		c.onDoubleTap()
+	}
+}
+
+func (c *bitCell) TappedSecondary(*fyne.PointEvent) {
+	if c.onSecondary != nil {
+		c.onSecondary()
+	}
+}
+
+func (c *bitCell) MouseIn(e *desktop.MouseEvent) { c.MouseMoved(e) }
+
+func (c *bitCell) MouseMoved(e *desktop.MouseEvent) {
+	if c.onHover != nil {
+		c.onHover(e.Position)
+	}
+}
+
+func (c *bitCell) MouseOut() {
+	if c.onHoverEnd != nil {
+		c.onHoverEnd()
+	}
+}
+
+// bitGrid 二进制位显示网格
+type bitGrid struct {
+	cols, rows int
+	style      string
+	cells      []fyne.CanvasObject
+	markers    []*canvas.Text // 值为1时显示的标记，bitMarker为markerNone时为nil
+	colHeaders []*gridHeader
+	rowHeaders []*gridHeader
+	mask       *muteMask
+	data       []byte
+	content    *fyne.Container
+	// stale 最近一次读取失败，有数据的位显示为过期颜色，收到新数据后恢复
+	stale bool
+	// cursor 方向键移动的选择光标所在的位索引，-1表示没有光标
+	cursor int
+	layout *bitGridLayout
+
+	// flash 位变化后边框高亮的保持时间，0表示不高亮
+	flash      time.Duration
+	changedAt  []time.Time
+	flashTimer *time.Timer
+	// marked 持续高亮的位（如快照对比中变化的位），与变化闪烁使用同样的边框
+	marked map[int]bool
+
+	// onMaskChanged 点击行/列标题改变屏蔽状态后调用
+	onMaskChanged func()
+	// onBitDoubleTapped 双击有数据的位时调用，参数为位索引
+	onBitDoubleTapped func(bitIndex int)
+	// bitAddress 返回位索引对应的地址名称（如 V101.5），用于悬停提示，为nil时不显示提示
+	bitAddress func(bitIndex int) string
+	// bitLabel 返回位地址的标签，为nil或返回空时提示中只有地址和值
+	bitLabel func(addr string) string
+	// bitEdges 返回位的上升沿和下降沿次数，ok为true时显示在提示中
+	bitEdges func(bitIndex int) (rising, falling int, ok bool)
+	// onBitSecondaryTapped 右键点击有数据的位时调用，用于编辑标签
+	onBitSecondaryTapped func(bitIndex int)
+
+	tip     *fyne.Container
+	tipText *canvas.Text
+}
+
+// newBitGrid 按样式和尺寸创建cols×rows的指示器网格，初始全部为灰色。cols应为8的倍数。
+// 列标题为字节内的位号，行标题为该行首字节相对起始地址的偏移；位的左右顺序按bitZeroLeft。
+func newBitGrid(cols, rows int, style string, cellSize float32, mask *muteMask) *bitGrid {
+	if mask == nil {
+		mask = newMuteMask(nil, nil)
+	}
+	g := &bitGrid{cols: cols, rows: rows, style: style, mask: mask, changedAt: make([]time.Time, cols*rows), cursor: -1}
+
+	gap := float32(4)
+	switch style {
+	case gridStyleDense:
+		gap = 0
+	case gridStyleCircle:
+		gap = 2
+	}
+
+	objects := make([]fyne.CanvasObject, 0, cols*rows+cols+rows)
+	for i := 0; i < cols*rows; i++ {
+		var cell fyne.CanvasObject
+		if style == gridStyleCircle {
+			cell = canvas.NewCircle(colorBitOff)
+		} else {
+			rect := canvas.NewRectangle(colorBitOff)
+			if style == gridStyleDense {
+				// 紧凑模式用细边框区分相邻方块
+				rect.StrokeColor = color.Black
+				rect.StrokeWidth = 1
+			}
+			cell = rect
+		}
+		g.cells = append(g.cells, cell)
+		indicator := cell
+		if bitMarker != markerNone {
+			text := "●"
+			if bitMarker == markerGlyph {
+				text = "1"
+			}
+			marker := canvas.NewText(text, color.Black)
+			marker.TextSize = cellSize * 0.6
+			marker.TextStyle.Bold = true
+			marker.Hide()
+			g.markers = append(g.markers, marker)
+			indicator = container.NewStack(cell, container.NewCenter(marker))
+		}
+		bc := newBitCell(indicator, func() {
+			if i < len(g.data)*8 && g.onBitDoubleTapped != nil {
+				g.onBitDoubleTapped(i)
+			}
+		})
+		bc.onHover = func(pos fyne.Position) { g.showTip(i, bc.Position().Add(pos)) }
+		bc.onHoverEnd = g.hideTip
+		bc.onSecondary = func() {
+			if i < len(g.data)*8 && g.onBitSecondaryTapped != nil {
+				g.onBitSecondaryTapped(i)
+			}
+		}
+		objects = append(objects, bc)
+	}
+
+	for col := 0; col < cols; col++ {
+		h := newGridHeader(fmt.Sprintf("%d", 7-col%8), func() {
+			g.mask.toggleCol(col)
+			g.maskChanged()
+		})
+		g.colHeaders = append(g.colHeaders, h)
+		objects = append(objects, h)
+	}
+	for row := 0; row < rows; row++ {
+		h := newGridHeader(fmt.Sprintf("+%d", row*cols/8), func() {
+			g.mask.toggleRow(row)
+			g.maskChanged()
+		})
+		g.rowHeaders = append(g.rowHeaders, h)
+		objects = append(objects, h)
+	}
+
+	g.tipText = canvas.NewText("", theme.Color(theme.ColorNameForeground))
+	g.tipText.TextSize = 12
+	g.tip = container.NewStack(
+		canvas.NewRectangle(theme.Color(theme.ColorNameOverlayBackground)),
+		container.NewPadded(g.tipText),
+	)
+	g.tip.Hide()
+	objects = append(objects, g.tip)
+
+	g.layout = &bitGridLayout{cols: cols, rows: rows, cell: cellSize, gap: gap, reverse: bitZeroLeft}
+	g.content = container.New(g.layout, objects...)
+	g.refreshHeaders()
+	return g
+}
+
+// setRowOffset 设置首行对应的字节偏移，分页显示时行标题随页码变化
+func (g *bitGrid) setRowOffset(offset int) {
+	for row, h := range g.rowHeaders {
+		h.text.Text = fmt.Sprintf("+%d", offset+row*g.cols/8)
+		h.text.Refresh()
+	}
+}
+
+// showTip 在鼠标位置旁显示位地址、当前值、标签和边沿次数，如 "V101.5 = 1  ↑3 ↓2"；没有数据的位不显示
+func (g *bitGrid) showTip(bitIndex int, pos fyne.Position) {
+	if g.bitAddress == nil || bitIndex >= len(g.data)*8 {
+		g.hideTip()
+		return
+	}
+	addr := g.bitAddress(bitIndex)
+	if addr == "" {
+		g.hideTip()
+		return
+	}
+	value := (g.data[bitIndex/8] >> (7 - bitIndex%8)) & 1
+	g.tipText.Text = fmt.Sprintf("%s = %d", addr, value)
+	if g.bitLabel != nil {
+		if label := g.bitLabel(addr); label != "" {
+			g.tipText.Text += "  " + label
+		}
+	}
+	if g.bitEdges != nil {
+		if rising, falling, ok := g.bitEdges(bitIndex); ok {
+			g.tipText.Text += "  " + formatEdges(rising, falling)
+		}
+	}
+	g.tipText.Refresh()
+
+	size := g.tip.MinSize()
+	g.tip.Resize(size)
+	pos = pos.AddXY(12, 12)
+	if bounds := g.content.Size(); pos.X+size.Width > bounds.Width {
+		pos.X = max(0, pos.X-size.Width-24)
+	}
+	g.tip.Move(pos)
+	g.tip.Show()
+}
+
+func (g *bitGrid) hideTip() {
+	g.tip.Hide()
+}
+
+// pageBytes 一页网格可显示的字节数
+func (g *bitGrid) pageBytes() int {
+	return g.cols * g.rows / 8
+}
+
+func (g *bitGrid) maskChanged() {
+	g.refreshHeaders()
+	g.showBytes(g.data)
+	if g.onMaskChanged != nil {
+		g.onMaskChanged()
+	}
+}
+
+func (g *bitGrid) refreshHeaders() {
+	for col, h := range g.colHeaders {
+		h.setMuted(g.mask.cols[col])
+	}
+	for row, h := range g.rowHeaders {
+		h.setMuted(g.mask.rows[row])
+	}
+}
+
+// isMuted 判断位索引对应的单元格是否被屏蔽，变化分析应跳过屏蔽的位
+func (g *bitGrid) isMuted(bitIndex int) bool {
+	return g.mask.muted(bitIndex/g.cols, bitIndex%g.cols)
+}
+
+// setCellColor 设置指定位索引的指示器颜色
+func (g *bitGrid) setCellColor(index int, c color.Color) {
+	if index < 0 || index >= len(g.cells) {
+		return
+	}
+	switch cell := g.cells[index].(type) {
+	case *canvas.Rectangle:
+		cell.FillColor = c
+		cell.Refresh()
+	case *canvas.Circle:
+		cell.FillColor = c
+		cell.Refresh()
+	}
+}
+
+// setFlash 设置位变化后边框高亮的保持时间，0表示关闭
+func (g *bitGrid) setFlash(d time.Duration) {
+	g.flash = d
+	if d <= 0 {
+		g.clearChanges()
+	}
+}
+
+// clearChanges 清除所有变化高亮，切换页面等数据不连续时调用
+func (g *bitGrid) clearChanges() {
+	clear(g.changedAt)
+	g.refreshOutlines()
+}
+
+// showChanges 与上一次显示的数据比较，变化的位（屏蔽的除外）加黄色边框并保持flash时长，
+// 使两次刷新之间的短脉冲也能被注意到。长度不同时视为新数据，不标记变化。
+// 新数据清除过期状态。
+func (g *bitGrid) showChanges(data []byte) {
+	g.stale = false
+	if g.flash > 0 && len(data) == len(g.data) {
+		now := time.Now()
+		changed := false
+		for i := range data {
+			diff := data[i] ^ g.data[i]
+			for bit := 0; diff != 0 && bit < 8; bit++ {
+				bitIndex := i*8 + bit
+				if diff&(0x80>>bit) != 0 && bitIndex < len(g.changedAt) && !g.isMuted(bitIndex) {
+					g.changedAt[bitIndex] = now
+					changed = true
+				}
+			}
+		}
+		if changed {
+			if g.flashTimer != nil {
+				g.flashTimer.Stop()
+			}
+			g.flashTimer = time.AfterFunc(g.flash, func() { fyne.Do(g.refreshOutlines) })
+		}
+	}
+	g.showBytes(data)
+}
+
+// setCursor 将选择光标放到位索引处，超出网格时取消光标
+func (g *bitGrid) setCursor(bitIndex int) {
+	if bitIndex < 0 || bitIndex >= len(g.cells) {
+		bitIndex = -1
+	}
+	g.cursor = bitIndex
+	g.refreshOutlines()
+}
+
+// moveCursor 按显示位置移动选择光标，dx为列（向右为正）、dy为行（向下为正），
+// 到达边缘时停住；没有光标时放到第一个位。返回新的位索引。
+func (g *bitGrid) moveCursor(dx, dy int) int {
+	if g.cursor < 0 {
+		g.setCursor(0)
+		return g.cursor
+	}
+	row := max(0, min(g.rows-1, g.cursor/g.cols+dy))
+	col := max(0, min(g.cols-1, g.layout.column(g.cursor%g.cols)+dx))
+	g.setCursor(row*g.cols + g.layout.column(col))
+	return g.cursor
+}
+
+// bitValue 返回位索引处的值，没有数据时ok为false
+func (g *bitGrid) bitValue(bitIndex int) (value int, ok bool) {
+	if bitIndex < 0 || bitIndex >= len(g.data)*8 {
+		return 0, false
+	}
+	return int(g.data[bitIndex/8]>>(7-bitIndex%8)) & 1, true
+}
+
+// setMarked 设置持续高亮的位索引，nil清除
+func (g *bitGrid) setMarked(bits []int) {
+	g.marked = make(map[int]bool, len(bits))
+	for _, b := range bits {
+		g.marked[b] = true
+	}
+	g.refreshOutlines()
+}
+
+// refreshOutlines 按变化时间和持续高亮设置每个单元格的边框
+func (g *bitGrid) refreshOutlines() {
+	now := time.Now()
+	for i, cell := range g.cells {
+		var stroke color.Color
+		var width float32
+		if g.style == gridStyleDense {
+			stroke, width = color.Black, 1
+		}
+		if t := g.changedAt[i]; g.marked[i] || !t.IsZero() && now.Sub(t) < g.flash {
+			stroke, width = colorBitFlash, 2
+		}
+		if i == g.cursor {
+			stroke, width = theme.Color(theme.ColorNamePrimary), 3
+		}
+		switch cell := cell.(type) {
+		case *canvas.Rectangle:
+			cell.StrokeColor, cell.StrokeWidth = stroke, width
+			cell.Refresh()
+		case *canvas.Circle:
+			cell.StrokeColor, cell.StrokeWidth = stroke, width
+			cell.Refresh()
+		}
+	}
+}
+
+// showBytes 将字节数据按位（从高位到低位）填充到网格，超出数据部分保持灰色
+func (g *bitGrid) showBytes(data []byte) {
+	g.data = data
+	totalBits := len(data) * 8
+	for bitIndex := 0; bitIndex < len(g.cells); bitIndex++ {
+		on := bitIndex < totalBits && (data[bitIndex/8]>>(7-bitIndex%8))&1 == 1
+		var c color.Color = colorBitOff
+		switch {
+		case g.isMuted(bitIndex):
+			c, on = colorBitMuted, false
+		case g.stale && bitIndex < totalBits:
+			c = colorBitStale
+		case on:
+			c = colorBitOn
+		}
+		g.setCellColor(bitIndex, c)
+		g.setMarker(bitIndex, on, c)
+	}
+	g.refreshOutlines()
+}
+
+// setMarker 显示或隐藏值为1的标记，标记颜色按单元格颜色取黑或白以保证对比度
+func (g *bitGrid) setMarker(index int, on bool, fill color.Color) {
+	if index >= len(g.markers) {
+		return
+	}
+	m := g.markers[index]
+	if !on {
+		m.Hide()
+		return
+	}
+	m.Color = contrastColor(fill)
+	m.Show()
+	m.Refresh()
+}
+
+// contrastColor 按亮度返回与c对比明显的黑色或白色
+func contrastColor(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+	if 299*r+587*g+114*b > 500*0xffff {
+		return color.Black
+	}
+	return color.White
+}
+
+// setStale 读取失败时将有数据的位显示为过期颜色，悬停提示仍显示最后读到的值
+func (g *bitGrid) setStale() {
+	g.stale = true
+	g.showBytes(g.data)
+}
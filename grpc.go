@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// gRPC接口（定义见 proto/plcviewer.proto），基于标准库的明文HTTP/2实现，不依赖grpc库：
+// 请求和应答为带5字节前缀（压缩标志和长度）的protobuf消息，状态码在trailer的grpc-status中。
+//
+// 这不是完整的gRPC实现，只实现了本服务用到的子集，其余部分不符合规范：
+//   - 只支持明文HTTP/2（h2c），不支持TLS
+//   - 不支持消息压缩，压缩的请求返回UNIMPLEMENTED，grpc-accept-encoding被忽略
+//   - 只有一元调用和服务端流，每个请求只读取第一条消息
+//   - 不解析grpc-timeout，客户端超时后取消流时请求随之结束
+//   - 不支持服务器反射，也没有健康检查服务
+//
+// 用grpcurl检验，需用-proto指定接口定义：
+//
+//	grpcurl -plaintext -import-path proto -proto plcviewer.proto -d '{"tags":["VW100"]}' localhost:50051 plcviewer.PLCViewer/Read
+//	grpcurl -plaintext -import-path proto -proto plcviewer.proto -d '{"tags":["V10.0"]}' localhost:50051 plcviewer.PLCViewer/Subscribe
+
+// grpcService gRPC服务的完整名称，方法路径为 /plcviewer.PLCViewer/Read 等
+const grpcService = "/plcviewer.PLCViewer/"
+
+// grpcMaxMessage 请求消息的最大长度
+const grpcMaxMessage = 1 << 20
+
+// gRPC状态码
+const (
+	grpcOK                = 0
+	grpcCanceled          = 1
+	grpcUnknown           = 2
+	grpcInvalidArgument   = 3
+	grpcDeadlineExceeded  = 4
+	grpcPermissionDenied  = 7
+	grpcResourceExhausted = 8
+	grpcOutOfRange        = 11
+	grpcUnimplemented     = 12
+	grpcUnavailable       = 14
+)
+
+// grpcError 带gRPC状态码的错误
+type grpcError struct {
+	code int
+	err  error
+}
+
+func (e *grpcError) Error() string { return e.err.Error() }
+
+func grpcInvalid(err error) error { return &grpcError{code: grpcInvalidArgument, err: err} }
+
+// grpcCode 按错误选择gRPC状态码，与HTTP接口的plcErrorStatus对应
+func grpcCode(err error) int {
+	var ge *grpcError
+	switch {
+	case err == nil:
+		return grpcOK
+	case errors.As(err, &ge):
+		return ge.code
+	case errors.Is(err, s7viewer.ErrNotConnected), errors.Is(err, s7viewer.ErrConnectionLost):
+		return grpcUnavailable
+	case errors.Is(err, context.Canceled):
+		return grpcCanceled
+	case errors.Is(err, s7viewer.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return grpcDeadlineExceeded
+	case errors.Is(err, s7viewer.ErrOutOfRange):
+		return grpcOutOfRange
+	case errors.Is(err, s7viewer.ErrAccessDenied), errors.Is(err, s7viewer.ErrReadOnly):
+		return grpcPermissionDenied
+	}
+	return grpcUnknown
+}
+
+// grpcScan 一次扫描的数据，由监控协程广播给各订阅
+type grpcScan struct {
+	time  time.Time
+	plc   string
+	order string // data的字节顺序
+	area  string
+	start int
+	data  []byte
+}
+
+// grpcStream 进程内的gRPC订阅中心，监控协程通过它向所有Subscribe流广播
+var grpcStream = &grpcHub{subs: make(map[*grpcSubscriber]bool)}
+
+type grpcHub struct {
+	mu   sync.Mutex
+	subs map[*grpcSubscriber]bool
+}
+
+// grpcSubscriber 一个Subscribe流。只保留最新一次还未发送的扫描：发送受HTTP/2流量控制，
+// 客户端接收过慢时发送阻塞，期间的扫描被后来的替换，客户端跳过这些扫描而不会积压
+type grpcSubscriber struct {
+	plc   string        // 只接收该PLC的数据，为空时接收全部
+	ready chan struct{} // 有新的扫描时写入，容量为1
+	mu    sync.Mutex
+	scan  *grpcScan // 最新一次还未发送的扫描
+}
+
+func newGRPCSubscriber(plc string) *grpcSubscriber {
+	return &grpcSubscriber{plc: plc, ready: make(chan struct{}, 1)}
+}
+
+// offer 用新的扫描替换还未发送的扫描，并通知发送的协程
+func (s *grpcSubscriber) offer(scan *grpcScan) {
+	s.mu.Lock()
+	s.scan = scan
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take 取出最新的扫描，没有时返回nil
+func (s *grpcSubscriber) take() *grpcScan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scan := s.scan
+	s.scan = nil
+	return scan
+}
+
+// broadcast 将一次扫描的数据发给订阅了该PLC的流，不等待处理慢的客户端以免拖慢监控
+func (h *grpcHub) broadcast(plc, order, area string, start int, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subs) == 0 {
+		return
+	}
+	scan := &grpcScan{time: time.Now(), plc: plc, order: order, area: area, start: start, data: append([]byte(nil), data...)}
+	for s := range h.subs {
+		if s.plc == "" || s.plc == plc {
+			s.offer(scan)
+		}
+	}
+}
+
+// newGRPCHandler 创建gRPC服务：Read、Write和服务端流式的Subscribe
+func newGRPCHandler(resolve apiResolver) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, tr("需要gRPC请求"), http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		req, err := readGRPCMessage(r.Body)
+		if err == nil {
+			switch method := strings.TrimPrefix(r.URL.Path, grpcService); method {
+			case "Read":
+				err = grpcRead(w, r.Context(), resolve, req)
+			case "Write":
+				err = grpcWrite(w, r.Context(), resolve, req)
+			case "Subscribe":
+				err = grpcSubscribe(w, r.Context(), req)
+			default:
+				err = &grpcError{code: grpcUnimplemented, err: fmt.Errorf(tr("未知的方法 %s"), r.URL.Path)}
+			}
+		}
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(grpcCode(err)))
+		if err != nil {
+			w.Header().Set(http.TrailerPrefix+"Grpc-Message", grpcEncodeMessage(err.Error()))
+		}
+	})
+}
+
+// readGRPCMessage 读取请求中的一条消息，不支持压缩
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, grpcInvalid(fmt.Errorf(tr("读取请求失败: %v"), err))
+	}
+	if head[0] != 0 {
+		return nil, &grpcError{code: grpcUnimplemented, err: errors.New(tr("不支持压缩的消息"))}
+	}
+	n := binary.BigEndian.Uint32(head[1:])
+	if n > grpcMaxMessage {
+		return nil, &grpcError{code: grpcResourceExhausted, err: fmt.Errorf(tr("消息长度%d超过上限"), n)}
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, grpcInvalid(fmt.Errorf(tr("读取请求失败: %v"), err))
+	}
+	return msg, nil
+}
+
+// writeGRPCMessage 发送一条应答消息并立即刷新
+func writeGRPCMessage(w http.ResponseWriter, msg []byte) error {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	if _, err := w.Write(append(frame, msg...)); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// grpcEncodeMessage 按gRPC规范对grpc-message做百分号编码
+func grpcEncodeMessage(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c > 0x7E || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// parseGRPCTagsRequest 解析ReadRequest和SubscribeRequest共用的字段：plc=1，tags=2
+func parseGRPCTagsRequest(msg []byte) (plc string, tags []string, err error) {
+	fields, err := pbFields(msg)
+	if err != nil {
+		return "", nil, grpcInvalid(err)
+	}
+	for _, f := range fields {
+		switch {
+		case f.num == 1 && f.wire == pbBytes:
+			plc = string(f.bytes)
+		case f.num == 2 && f.wire == pbBytes:
+			tags = append(tags, string(f.bytes))
+		}
+	}
+	return plc, tags, nil
+}
+
+func grpcRead(w http.ResponseWriter, ctx context.Context, resolve apiResolver, msg []byte) error {
+	plc, tags, err := parseGRPCTagsRequest(msg)
+	if err != nil {
+		return err
+	}
+	viewer, err := resolve(plc)
+	if err != nil {
+		return &grpcError{code: grpcUnavailable, err: err}
+	}
+	specs := make([]watchSpec, len(tags))
+	var items []s7viewer.Item
+	for i, tag := range tags {
+		specs[i].addr, specs[i].dataType, specs[i].err = parseWatchAddress(tag)
+		if specs[i].err == nil {
+			items = append(items, specs[i].item())
+		}
+	}
+	if len(items) > 0 {
+		if err := viewer.ReadItems(ctx, items); err != nil {
+			return err
+		}
+	}
+	var reply []byte
+	k := 0
+	for i, s := range specs {
+		var v any
+		err := s.err
+		if err == nil {
+			it := items[k]
+			k++
+			if err = it.Err; err == nil {
+				v, err = s7viewer.DecodeTyped(viewer.ByteOrder(), s.dataType, it.Data, 0, s.addr.bit, 0)
+			}
+		}
+		reply = pbAppendBytes(reply, 1, grpcTagValue(tags[i], s.dataType, v, err))
+	}
+	return writeGRPCMessage(w, reply)
+}
+
+func grpcWrite(w http.ResponseWriter, ctx context.Context, resolve apiResolver, msg []byte) error {
+	fields, err := pbFields(msg)
+	if err != nil {
+		return grpcInvalid(err)
+	}
+	var plc, tag, value string
+	for _, f := range fields {
+		if f.wire != pbBytes {
+			continue
+		}
+		switch f.num {
+		case 1:
+			plc = string(f.bytes)
+		case 2:
+			tag = string(f.bytes)
+		case 3:
+			value = strings.TrimSpace(string(f.bytes))
+		}
+	}
+	addr, dataType, err := parseAPIAddress(tag)
+	if err != nil {
+		return grpcInvalid(err)
+	}
+	viewer, err := resolve(plc)
+	if err != nil {
+		return &grpcError{code: grpcUnavailable, err: err}
+	}
+	data, err := s7viewer.EncodeValue(viewer.ByteOrder(), dataType, value)
+	if err != nil {
+		return grpcInvalid(err)
+	}
+	if dataType == s7viewer.TypeBool {
+		err = viewer.WriteVBit(ctx, addr.byteOff, addr.bit, data[0] == 1)
+	} else {
+		err = viewer.WriteV(ctx, addr.byteOff, data)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf(tr("gRPC写入 %s = %s"), addr, value)
+	return writeGRPCMessage(w, pbAppendString(pbAppendString(nil, 1, addr.String()), 2, dataType))
+}
+
+// grpcSubscribe 持续推送扫描数据，直到客户端取消。发送在客户端的HTTP/2接收窗口用完时阻塞，
+// 之后推送的是等待期间最新的一次扫描
+func grpcSubscribe(w http.ResponseWriter, ctx context.Context, msg []byte) error {
+	plc, tags, err := parseGRPCTagsRequest(msg)
+	if err != nil {
+		return err
+	}
+	specs := make([]watchSpec, len(tags))
+	for i, tag := range tags {
+		if specs[i].addr, specs[i].dataType, err = parseWatchAddress(tag); err != nil {
+			return grpcInvalid(err)
+		}
+	}
+
+	sub := newGRPCSubscriber(plc)
+	grpcStream.mu.Lock()
+	grpcStream.subs[sub] = true
+	grpcStream.mu.Unlock()
+	defer func() {
+		grpcStream.mu.Lock()
+		delete(grpcStream.subs, sub)
+		grpcStream.mu.Unlock()
+	}()
+
+	// 先发送应答头，客户端据此确认订阅已建立
+	w.WriteHeader(http.StatusOK)
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		return grpcStreamError(ctx, err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return grpcStreamError(ctx, ctx.Err())
+		case <-sub.ready:
+			scan := sub.take()
+			if scan == nil {
+				continue
+			}
+			var m []byte
+			m = pbAppendInt(m, 1, scan.time.UnixNano())
+			m = pbAppendString(m, 2, scan.plc)
+			m = pbAppendString(m, 3, scan.area)
+			m = pbAppendInt(m, 4, int64(scan.start))
+			m = pbAppendBytes(m, 5, scan.data)
+			for i, s := range specs {
+				if value, ok := scanTagValue(tags[i], s, *scan); ok {
+					m = pbAppendBytes(m, 6, value)
+				}
+			}
+			if err := writeGRPCMessage(w, m); err != nil {
+				return grpcStreamError(ctx, err)
+			}
+		}
+	}
+}
+
+// grpcStreamError 结束订阅流的原因：客户端取消或超时时为CANCELLED或DEADLINE_EXCEEDED，否则为发送失败
+func grpcStreamError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return &grpcError{code: grpcCode(ctx.Err()), err: ctx.Err()}
+	}
+	return &grpcError{code: grpcUnavailable, err: fmt.Errorf(tr("推送失败: %v"), err)}
+}
+
+// scanTagValue 从一次扫描中取变量的值，变量不在扫描范围内时返回false
+func scanTagValue(tag string, s watchSpec, scan grpcScan) ([]byte, bool) {
+	v, ok, err := s.decodeFrom(scan.order, scan.area, scan.start, scan.data)
+	if !ok {
+		return nil, false
+	}
+	return grpcTagValue(tag, s.dataType, v, err), true
+}
+
+// grpcTagValue 编码TagValue消息：按解码结果的Go类型填写oneof字段（零值也要写出），text为显示文本
+func grpcTagValue(tag, dataType string, v any, err error) []byte {
+	m := pbAppendString(nil, 1, tag)
+	m = pbAppendString(m, 2, dataType)
+	if err != nil {
+		return pbAppendString(m, 8, err.Error())
+	}
+	var text string
+	switch v := v.(type) {
+	case bool:
+		m = binary.AppendUvarint(pbAppendTag(m, 3, pbVarint), uint64(boolToInt(v)))
+		text = strconv.Itoa(boolToInt(v))
+	case float32:
+		m = binary.LittleEndian.AppendUint64(pbAppendTag(m, 5, pbFixed64), math.Float64bits(float64(v)))
+		text = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case string:
+		m = pbAppendTag(m, 6, pbBytes)
+		m = binary.AppendUvarint(m, uint64(len(v)))
+		m = append(m, v...)
+		text = v
+	case uint8:
+		m, text = grpcAppendInt(m, int64(v)), strconv.Itoa(int(v))
+	case uint16:
+		m, text = grpcAppendInt(m, int64(v)), strconv.Itoa(int(v))
+	case int16:
+		m, text = grpcAppendInt(m, int64(v)), strconv.Itoa(int(v))
+	case uint32:
+		m, text = grpcAppendInt(m, int64(v)), strconv.FormatUint(uint64(v), 10)
+	case int32:
+		m, text = grpcAppendInt(m, int64(v)), strconv.Itoa(int(v))
+	default:
+		return pbAppendString(m, 8, fmt.Sprintf(tr("无法编码 %T 类型的值"), v))
+	}
+	return pbAppendString(m, 7, text)
+}
+
+// grpcAppendInt 追加TagValue的int64字段，零值也要写出
+func grpcAppendInt(m []byte, n int64) []byte {
+	return binary.AppendUvarint(pbAppendTag(m, 4, pbVarint), uint64(n))
+}
+
+// startGRPCServer 在后台启动gRPC接口（明文HTTP/2），监听失败时记录日志
+func startGRPCServer(addr string, resolve apiResolver) {
+	srv := &http.Server{Addr: addr, Handler: newGRPCHandler(resolve), Protocols: new(http.Protocols)}
+	srv.Protocols.SetUnencryptedHTTP2(true)
+	go func() {
+		log.Printf(tr("gRPC接口监听于 %s"), addr)
+		if err := srv.ListenAndServe(); err != nil {
+			log.Printf(tr("gRPC接口启动失败: %v"), err)
+		}
+	}()
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// pbFieldMap 按字段号整理消息的字段，解码失败时测试失败
+func pbFieldMap(t *testing.T, msg []byte) map[int][]pbField {
+	t.Helper()
+	fields, err := pbFields(msg)
+	if err != nil {
+		t.Fatalf("pbFields(% X): %v", msg, err)
+	}
+	m := make(map[int][]pbField)
+	for _, f := range fields {
+		m[f.num] = append(m[f.num], f)
+	}
+	return m
+}
+
+// pbAppendBool 追加bool字段，false时省略（proto3默认值）
+func pbAppendBool(b []byte, field int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return pbAppendVarint(b, field, 1)
+}
+
+// pbAppendDouble 追加double字段，0时省略（proto3默认值）
+func pbAppendDouble(b []byte, field int, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.LittleEndian.AppendUint64(pbAppendTag(b, field, pbFixed64), math.Float64bits(v))
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	var msg []byte
+	msg = pbAppendString(msg, 1, "192.168.2.1")
+	msg = pbAppendInt(msg, 2, -1)
+	msg = pbAppendDouble(msg, 3, 2.5)
+	msg = pbAppendBool(msg, 4, true)
+	msg = pbAppendVarint(msg, 5, 0) // 默认值省略
+	msg = pbAppendBytes(msg, 6, nil)
+	msg = pbAppendBool(msg, 7, false)
+	msg = pbAppendVarint(msg, 300, 1<<40)
+
+	fields, err := pbFields(msg)
+	if err != nil {
+		t.Fatalf("pbFields: %v", err)
+	}
+	want := []pbField{
+		{num: 1, wire: pbBytes, bytes: []byte("192.168.2.1")},
+		{num: 2, wire: pbVarint, value: math.MaxUint64},
+		{num: 3, wire: pbFixed64, value: math.Float64bits(2.5)},
+		{num: 4, wire: pbVarint, value: 1},
+		{num: 300, wire: pbVarint, value: 1 << 40},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("pbFields returned %d fields, want %d: %+v", len(fields), len(want), fields)
+	}
+	for i, f := range fields {
+		w := want[i]
+		if f.num != w.num || f.wire != w.wire || f.value != w.value || !bytes.Equal(f.bytes, w.bytes) {
+			t.Errorf("field %d = %+v, want %+v", i, f, w)
+		}
+	}
+	if got := int64(fields[1].value); got != -1 {
+		t.Errorf("int64 field = %d, want -1", got)
+	}
+}
+
+func TestProtobufMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"truncated key", []byte{0x80}},
+		{"truncated varint", []byte{0x08, 0x80}},
+		{"truncated fixed64", []byte{0x19, 0x00, 0x00}},
+		{"truncated fixed32", []byte{0x1D, 0x00}},
+		{"bytes past end", []byte{0x0A, 0x05, 'a'}},
+		{"group wire type", []byte{0x0B}},
+	}
+	for _, tt := range tests {
+		if _, err := pbFields(tt.msg); err != errProtobuf {
+			t.Errorf("%s: pbFields(% X) error = %v, want errProtobuf", tt.name, tt.msg, err)
+		}
+	}
+}
+
+func TestGRPCMessageFraming(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := writeGRPCMessage(rec, []byte("hello")); err != nil {
+		t.Fatalf("writeGRPCMessage: %v", err)
+	}
+	if got, want := rec.Body.Bytes(), []byte{0, 0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o'}; !bytes.Equal(got, want) {
+		t.Fatalf("frame = % X, want % X", got, want)
+	}
+	msg, err := readGRPCMessage(rec.Body)
+	if err != nil || string(msg) != "hello" {
+		t.Fatalf("readGRPCMessage = %q, %v", msg, err)
+	}
+
+	tests := []struct {
+		name  string
+		frame []byte
+		code  int
+	}{
+		{"empty", nil, grpcInvalidArgument},
+		{"compressed", []byte{1, 0, 0, 0, 0}, grpcUnimplemented},
+		{"too long", []byte{0, 0x7F, 0xFF, 0xFF, 0xFF}, grpcResourceExhausted},
+		{"truncated", []byte{0, 0, 0, 0, 5, 'a'}, grpcInvalidArgument},
+	}
+	for _, tt := range tests {
+		_, err := readGRPCMessage(bytes.NewReader(tt.frame))
+		if got := grpcCode(err); got != tt.code {
+			t.Errorf("%s: grpcCode(%v) = %d, want %d", tt.name, err, got, tt.code)
+		}
+	}
+}
+
+func TestGRPCTagValue(t *testing.T) {
+	tests := []struct {
+		v     any
+		field int    // oneof中应写出的字段
+		value uint64 // 该字段的varint或fixed64值
+		text  string
+	}{
+		{true, 3, 1, "1"},
+		{false, 3, 0, "0"},
+		{uint8(200), 4, 200, "200"},
+		{uint16(65535), 4, 65535, "65535"},
+		{int16(-2), 4, uint64(math.MaxUint64 - 1), "-2"},
+		{uint32(4000000000), 4, 4000000000, "4000000000"},
+		{int32(0), 4, 0, "0"},
+		{float32(1.5), 5, math.Float64bits(1.5), "1.5"},
+	}
+	for _, tt := range tests {
+		m := pbFieldMap(t, grpcTagValue("VW100", s7viewer.TypeInt, tt.v, nil))
+		if got := string(m[1][0].bytes); got != "VW100" {
+			t.Errorf("%T(%v): tag = %q", tt.v, tt.v, got)
+		}
+		if len(m[tt.field]) != 1 || m[tt.field][0].value != tt.value {
+			t.Errorf("%T(%v): field %d = %+v, want value %d", tt.v, tt.v, tt.field, m[tt.field], tt.value)
+		}
+		if got := string(m[7][0].bytes); got != tt.text {
+			t.Errorf("%T(%v): text = %q, want %q", tt.v, tt.v, got, tt.text)
+		}
+		if len(m[8]) != 0 {
+			t.Errorf("%T(%v): unexpected error field %q", tt.v, tt.v, m[8][0].bytes)
+		}
+	}
+
+	m := pbFieldMap(t, grpcTagValue("VB0", "STRING", "abc", nil))
+	if string(m[6][0].bytes) != "abc" || string(m[7][0].bytes) != "abc" {
+		t.Errorf("string value fields = %+v", m)
+	}
+	m = pbFieldMap(t, grpcTagValue("VW0", s7viewer.TypeInt, nil, s7viewer.ErrTimeout))
+	if len(m[8]) != 1 || len(m[4]) != 0 {
+		t.Errorf("error value fields = %+v, want only error", m)
+	}
+	// 无法编码的类型返回错误，而不是静默写出0
+	m = pbFieldMap(t, grpcTagValue("VW0", s7viewer.TypeInt, int64(7), nil))
+	if len(m[8]) != 1 || !strings.Contains(string(m[8][0].bytes), "int64") || len(m[4]) != 0 {
+		t.Errorf("int64 value fields = %+v, want error", m)
+	}
+}
+
+func TestGRPCHubLatest(t *testing.T) {
+	h := &grpcHub{subs: make(map[*grpcSubscriber]bool)}
+	slow := newGRPCSubscriber("")
+	other := newGRPCSubscriber("10.0.0.2")
+	h.subs[slow] = true
+	h.subs[other] = true
+
+	// 没有发送的流只保留最新一次扫描，广播本身不阻塞，订阅也不注销
+	for i := range 100 {
+		h.broadcast("10.0.0.1", s7viewer.OrderBigEndian, s7viewer.AreaV, i, []byte{byte(i)})
+	}
+	select {
+	case <-slow.ready:
+	default:
+		t.Fatal("subscriber not notified")
+	}
+	if scan := slow.take(); scan == nil || scan.start != 99 {
+		t.Errorf("pending scan = %+v, want the last one (start 99)", scan)
+	}
+	if scan := slow.take(); scan != nil {
+		t.Errorf("second take = %+v, want nil", scan)
+	}
+	if !h.subs[slow] {
+		t.Error("slow subscriber unregistered")
+	}
+	if len(other.ready) != 0 || other.take() != nil {
+		t.Error("subscriber of another PLC received a scan")
+	}
+}
+
+func TestGRPCStreamError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code int
+	}{
+		{"canceled", canceled, grpcCanceled},
+		{"deadline", expired, grpcDeadlineExceeded},
+		{"write failed", context.Background(), grpcUnavailable},
+	}
+	for _, tt := range tests {
+		err := grpcStreamError(tt.ctx, io.ErrClosedPipe)
+		var ge *grpcError
+		if !errors.As(err, &ge) || ge.code != tt.code {
+			t.Errorf("%s: grpcStreamError = %#v, want code %d", tt.name, err, tt.code)
+		}
+	}
+}
+
+// startGRPCTestServer 在明文HTTP/2上启动gRPC接口，返回地址和客户端
+func startGRPCTestServer(t *testing.T, resolve apiResolver) (string, *http.Client) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(newGRPCHandler(resolve))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	transport := &http.Transport{Protocols: new(http.Protocols)}
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	t.Cleanup(transport.CloseIdleConnections)
+	return srv.URL, &http.Client{Transport: transport}
+}
+
+// grpcPost 发送一条gRPC请求，返回应答（应答头已收到）
+func grpcPost(t *testing.T, ctx context.Context, client *http.Client, url, method string, msg []byte) *http.Response {
+	t.Helper()
+	frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(msg)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+grpcService+method, bytes.NewReader(append(frame, msg...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	return resp
+}
+
+// grpcUnary 调用一元方法，返回应答消息（出错时为nil）和grpc-status
+func grpcUnary(t *testing.T, client *http.Client, url, method string, msg []byte) ([]byte, int) {
+	t.Helper()
+	resp := grpcPost(t, context.Background(), client, url, method, msg)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s: reading body: %v", method, err)
+	}
+	status, err := strconv.Atoi(resp.Trailer.Get("Grpc-Status"))
+	if err != nil {
+		t.Fatalf("%s: grpc-status trailer %q", method, resp.Trailer.Get("Grpc-Status"))
+	}
+	if len(body) == 0 {
+		return nil, status
+	}
+	reply, err := readGRPCMessage(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	return reply, status
+}
+
+func TestGRPCHandlerReadWrite(t *testing.T) {
+	sim, viewer := connectSimulator(t, simPatternStatic)
+	url, client := startGRPCTestServer(t, func(string) (*s7viewer.Viewer, error) { return viewer, nil })
+
+	sim.setBytes(s7viewer.S7AreaDB, 100, []byte{0xFF, 0xFE, 0, 0, 0x3F, 0xC0, 0, 0, 0x02})
+	var req []byte
+	for _, tag := range []string{"VW100", "VR104", "V108.1", "X1"} {
+		req = pbAppendString(req, 2, tag)
+	}
+	reply, status := grpcUnary(t, client, url, "Read", req)
+	if status != grpcOK {
+		t.Fatalf("Read status = %d", status)
+	}
+	values := pbFieldMap(t, reply)[1]
+	if len(values) != 4 {
+		t.Fatalf("Read returned %d values, want 4", len(values))
+	}
+	wantText := []string{"-2", "1.5", "1"}
+	for i, want := range wantText {
+		m := pbFieldMap(t, values[i].bytes)
+		if got := string(m[7][0].bytes); got != want {
+			t.Errorf("value %d text = %q, want %q", i, got, want)
+		}
+	}
+	if m := pbFieldMap(t, values[3].bytes); len(m[8]) != 1 {
+		t.Errorf("invalid tag: fields = %+v, want error", m)
+	}
+
+	req = pbAppendString(pbAppendString(nil, 2, "VW200"), 3, "1234")
+	reply, status = grpcUnary(t, client, url, "Write", req)
+	if status != grpcOK {
+		t.Fatalf("Write status = %d", status)
+	}
+	if m := pbFieldMap(t, reply); string(m[1][0].bytes) != "VW200" || string(m[2][0].bytes) != s7viewer.TypeInt {
+		t.Errorf("Write reply = %+v", m)
+	}
+	if got := sim.bytes(s7viewer.S7AreaDB, 200, 2); !bytes.Equal(got, []byte{0x04, 0xD2}) {
+		t.Errorf("VW200 after Write = % X, want 04 D2", got)
+	}
+
+	// 值超出范围、地址无效、只读模式分别对应不同的状态码
+	if _, status := grpcUnary(t, client, url, "Write", pbAppendString(pbAppendString(nil, 2, "VB0"), 3, "300")); status != grpcInvalidArgument {
+		t.Errorf("Write out-of-range value: status = %d, want %d", status, grpcInvalidArgument)
+	}
+	if _, status := grpcUnary(t, client, url, "Write", pbAppendString(pbAppendString(nil, 2, "MB0"), 3, "1")); status != grpcInvalidArgument {
+		t.Errorf("Write to M area: status = %d, want %d", status, grpcInvalidArgument)
+	}
+	viewer.SetReadOnly(true)
+	if _, status := grpcUnary(t, client, url, "Write", pbAppendString(pbAppendString(nil, 2, "VB0"), 3, "1")); status != grpcPermissionDenied {
+		t.Errorf("Write in read-only mode: status = %d, want %d", status, grpcPermissionDenied)
+	}
+}
+
+func TestGRPCHandlerErrors(t *testing.T) {
+	url, client := startGRPCTestServer(t, func(string) (*s7viewer.Viewer, error) { return nil, s7viewer.ErrNotConnected })
+
+	if _, status := grpcUnary(t, client, url, "Read", pbAppendString(nil, 2, "VW0")); status != grpcUnavailable {
+		t.Errorf("Read without PLC: status = %d, want %d", status, grpcUnavailable)
+	}
+	if _, status := grpcUnary(t, client, url, "Delete", nil); status != grpcUnimplemented {
+		t.Errorf("unknown method: status = %d, want %d", status, grpcUnimplemented)
+	}
+	if _, status := grpcUnary(t, client, url, "Read", []byte{0x0A, 0x05}); status != grpcInvalidArgument {
+		t.Errorf("malformed request: status = %d, want %d", status, grpcInvalidArgument)
+	}
+
+	resp, err := client.Post(url+grpcService+"Read", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("non-gRPC request: HTTP status = %d, want %d", resp.StatusCode, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestGRPCHandlerSubscribe(t *testing.T) {
+	url, client := startGRPCTestServer(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req := pbAppendString(pbAppendString(nil, 1, "10.0.0.1"), 2, "VW2")
+	resp := grpcPost(t, ctx, client, url, "Subscribe", req)
+	defer resp.Body.Close()
+
+	// 收到应答头时订阅已登记；其他PLC的扫描不推送
+	grpcStream.broadcast("10.0.0.2", s7viewer.OrderBigEndian, s7viewer.AreaV, 0, []byte{0, 0, 0, 1})
+	grpcStream.broadcast("10.0.0.1", s7viewer.OrderLittleEndian, s7viewer.AreaV, 0, []byte{0, 0, 0x2A, 0})
+	msg, err := readGRPCMessage(resp.Body)
+	if err != nil {
+		t.Fatalf("reading scan: %v", err)
+	}
+	m := pbFieldMap(t, msg)
+	if string(m[2][0].bytes) != "10.0.0.1" || string(m[3][0].bytes) != s7viewer.AreaV || !bytes.Equal(m[5][0].bytes, []byte{0, 0, 0x2A, 0}) {
+		t.Fatalf("scan = %+v", m)
+	}
+	if len(m[6]) != 1 {
+		t.Fatalf("scan has %d tag values, want 1", len(m[6]))
+	}
+	if tv := pbFieldMap(t, m[6][0].bytes); string(tv[7][0].bytes) != "42" {
+		t.Errorf("VW2 in little-endian scan = %q, want 42", tv[7][0].bytes)
+	}
+
+	// 客户端接收过慢时跳过中间的扫描，但最终收到最新的一次，推送的顺序不变
+	for i := 1; i <= 100; i++ {
+		grpcStream.broadcast("10.0.0.1", s7viewer.OrderBigEndian, s7viewer.AreaV, i, []byte{0, 0, 0, byte(i)})
+	}
+	last := 0
+	for last < 100 {
+		msg, err := readGRPCMessage(resp.Body)
+		if err != nil {
+			t.Fatalf("reading scan after %d: %v", last, err)
+		}
+		start := 0
+		if f := pbFieldMap(t, msg)[4]; len(f) == 1 {
+			start = int(f[0].value)
+		}
+		if start <= last {
+			t.Fatalf("scan %d received after %d", start, last)
+		}
+		last = start
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// historian 嵌入式SQLite历史库，只记录变量值的变化，适合长时间采集后按变量和时间段查询
+type historian struct {
+	db   *sql.DB
+	mu   sync.Mutex
+	last map[string]map[string]string // PLC → 变量 → 最近一次记录的值
+}
+
+// historyPoint 一条历史记录
+type historyPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+const historianSchema = `
+CREATE TABLE IF NOT EXISTS samples (
+	ts    INTEGER NOT NULL, -- Unix毫秒
+	plc   TEXT    NOT NULL,
+	tag   TEXT    NOT NULL,
+	value REAL    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS samples_tag_ts ON samples (plc, tag, ts);`
+
+// openHistorian 打开（不存在时创建）历史库
+func openHistorian(path string) (*historian, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf(tr("打开历史库失败: %v"), err)
+	}
+	// SQLite同一时间只允许一个写入者
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(historianSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf(tr("初始化历史库失败: %v"), err)
+	}
+	return &historian{db: db, last: make(map[string]map[string]string)}, nil
+}
+
+// record 写入与上次相比发生变化的变量值，首次记录时写入全部
+func (h *historian) record(plc string, t time.Time, values map[string]string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last := h.last[plc]
+	if last == nil {
+		last = make(map[string]string)
+		h.last[plc] = last
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO samples (ts, plc, tag, value) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	changed := make(map[string]string)
+	for tag, value := range values {
+		if prev, ok := last[tag]; ok && prev == value {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := stmt.Exec(t.UnixMilli(), plc, tag, v); err != nil {
+			tx.Rollback()
+			return err
+		}
+		changed[tag] = value
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for tag, value := range changed {
+		last[tag] = value
+	}
+	return nil
+}
+
+// query 查询某个变量从since开始的记录。为了画出完整的阶梯，
+// 同时返回since之前的最后一条记录（时间记为since）。
+func (h *historian) query(plc, tag string, since time.Time) ([]historyPoint, error) {
+	var points []historyPoint
+
+	var before float64
+	err := h.db.QueryRow("SELECT value FROM samples WHERE plc = ? AND tag = ? AND ts < ? ORDER BY ts DESC LIMIT 1",
+		plc, tag, since.UnixMilli()).Scan(&before)
+	switch {
+	case err == nil:
+		points = append(points, historyPoint{Time: since, Value: before})
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	rows, err := h.db.Query("SELECT ts, value FROM samples WHERE plc = ? AND tag = ? AND ts >= ? ORDER BY ts",
+		plc, tag, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ms int64
+		var p historyPoint
+		if err := rows.Scan(&ms, &p.Value); err != nil {
+			return nil, err
+		}
+		p.Time = time.UnixMilli(ms)
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (h *historian) close() error {
+	return h.db.Close()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const prefHistorianPath = "historian.path"
+
+// 查询时间范围
+var historyRanges = map[string]time.Duration{
+	"15分钟": 15 * time.Minute,
+	"1小时":  time.Hour,
+	"2小时":  2 * time.Hour,
+	"8小时":  8 * time.Hour,
+	"24小时": 24 * time.Hour,
+	"7天":   7 * 24 * time.Hour,
+}
+
+var historyRangeNames = []string{"15分钟", "1小时", "2小时", "8小时", "24小时", "7天"}
+
+// newHistorianPanel 创建历史库面板：启用后监控数据的变化写入SQLite，并可按变量和时间段查询。
+// plc返回当前PLC地址；current返回启用中的历史库，未启用时为nil，可在监控协程中调用。
+func newHistorianPanel(prefs fyne.Preferences, myWindow fyne.Window, plc func() string) (content fyne.CanvasObject, current func() *historian) {
+	var mu sync.Mutex
+	var hist *historian
+	current = func() *historian {
+		mu.Lock()
+		defer mu.Unlock()
+		return hist
+	}
+
+	defaultPath := "plc-history.db"
+	if home, err := os.UserHomeDir(); err == nil {
+		defaultPath = filepath.Join(home, "plc-history.db")
+	}
+	pathEntry := widget.NewEntry()
+	pathEntry.SetText(prefs.StringWithFallback(prefHistorianPath, defaultPath))
+	statusLabel := widget.NewLabel(tr("历史库: 未启用"))
+
+	enableCheck := widget.NewCheck(tr("记录历史 (SQLite)"), func(enabled bool) {
+		mu.Lock()
+		old := hist
+		hist = nil
+		mu.Unlock()
+		if old != nil {
+			if err := old.close(); err != nil {
+				log.Printf(tr("关闭历史库失败: %v"), err)
+			}
+		}
+		if !enabled {
+			statusLabel.SetText(tr("历史库: 未启用"))
+			return
+		}
+
+		path := strings.TrimSpace(pathEntry.Text)
+		h, err := openHistorian(path)
+		if err != nil {
+			statusLabel.SetText(tr("历史库: 打开失败"))
+			log.Printf("%v", err)
+			return
+		}
+		prefs.SetString(prefHistorianPath, path)
+		mu.Lock()
+		hist = h
+		mu.Unlock()
+		statusLabel.SetText(tr("历史库: ") + path)
+	})
+
+	// 查询
+	tagEntry := widget.NewEntry()
+	tagEntry.SetPlaceHolder(tr("例如 V100.3 或 VW100"))
+	rangeSelect := newTrSelect(historyRangeNames, nil)
+	rangeSelect.SetSelected(tr("2小时"))
+
+	var points []historyPoint
+	resultTable := widget.NewTable(
+		func() (int, int) { return len(points), 2 },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			p := points[id.Row]
+			text := p.Time.Format("2006-01-02 15:04:05.000")
+			if id.Col == 1 {
+				text = strconv.FormatFloat(p.Value, 'g', -1, 64)
+			}
+			o.(*widget.Label).SetText(text)
+		})
+	resultTable.SetColumnWidth(0, 200)
+	resultTable.SetColumnWidth(1, 120)
+	resultLabel := widget.NewLabel("")
+
+	queryButton := widget.NewButton(tr("查询"), func() {
+		h := current()
+		if h == nil {
+			dialog.ShowInformation(tr("查询历史"), tr("请先启用历史库"), myWindow)
+			return
+		}
+		tag := strings.ToUpper(strings.TrimSpace(tagEntry.Text))
+		if _, err := parseAddress(tag); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		result, err := h.query(plc(), tag, time.Now().Add(-historyRanges[selectValue(rangeSelect, historyRangeNames)]))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf(tr("查询历史失败: %v"), err), myWindow)
+			return
+		}
+		points = result
+		resultTable.Refresh()
+		resultLabel.SetText(fmt.Sprintf(tr("%s 最近%s: %d条记录"), tag, rangeSelect.Selected, len(points)))
+	})
+
+	content = container.NewBorder(
+		container.NewVBox(
+			widget.NewForm(widget.NewFormItem(tr("数据库文件:"), pathEntry)),
+			container.NewHBox(enableCheck, statusLabel),
+			widget.NewLabel(tr("监控时只记录变化的位（V100.3）和字（VW100）。")),
+			container.NewBorder(nil, nil, widget.NewLabel(tr("变量:")), container.NewHBox(rangeSelect, queryButton), tagEntry),
+			resultLabel,
+		),
+		nil, nil, nil,
+		resultTable,
+	)
+	return content, current
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// prefHSCAddrs 各高速计数器当前值所在V区地址的偏好键
+const prefHSCAddrs = "hsc.addrs"
+
+// hscStatusBytes HSC0~HSC3的状态字节：SMx.5为计数方向（1为增计数），SMx.6为当前值等于预设值，SMx.7为当前值大于预设值
+var hscStatusBytes = []int{36, 46, 56, 136}
+
+// hscCounter 一个高速计数器的显示行。
+// HC区不能通过通信读取，程序需用MOVD把HCx传送到V区（如 MOVD HC0, VD500），这里读取该VD
+type hscCounter struct {
+	addrEntry  *widget.Entry
+	valueLabel *widget.Label
+	rateLabel  *widget.Label
+	dirLabel   *widget.Label
+
+	// 上次读取的值和时间，用于计算每秒变化量；只在UI线程中访问
+	last     int32
+	lastTime time.Time
+}
+
+// update 显示读到的当前值，并与上次读取比较得到每秒变化量；计数值按32位有符号数回绕
+func (c *hscCounter) update(v int32, at time.Time) {
+	c.valueLabel.SetText(strconv.Itoa(int(v)))
+	if !c.lastTime.IsZero() {
+		if secs := at.Sub(c.lastTime).Seconds(); secs > 0 {
+			c.rateLabel.SetText(strconv.FormatFloat(float64(v-c.last)/secs, 'f', 1, 64))
+		}
+	}
+	c.last, c.lastTime = v, at
+}
+
+// showStatus 显示状态字节中的计数方向和与预设值的比较
+func (c *hscCounter) showStatus(status byte) {
+	text := tr("减计数")
+	if status&0x20 != 0 {
+		text = tr("增计数")
+	}
+	switch {
+	case status&0x80 != 0:
+		text += tr("，当前值>预设值")
+	case status&0x40 != 0:
+		text += tr("，当前值=预设值")
+	}
+	c.dirLabel.SetText(text)
+}
+
+// newHSCPanel 创建高速计数器视图：显示HSC0~HSC3的当前值（32位有符号数）、每秒变化量和计数方向，用于编码器排查
+func newHSCPanel(prefs fyne.Preferences, getViewer func() (*s7viewer.Viewer, context.Context)) fyne.CanvasObject {
+	saved := prefs.StringList(prefHSCAddrs)
+	counters := make([]*hscCounter, len(hscStatusBytes))
+	grid := container.NewGridWithColumns(5,
+		widget.NewLabelWithStyle(tr("计数器"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(tr("当前值地址"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(tr("当前值"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(tr("每秒变化"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(tr("状态"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	saveAddrs := func() {
+		list := make([]string, len(counters))
+		for i, c := range counters {
+			list[i] = strings.ToUpper(strings.TrimSpace(c.addrEntry.Text))
+		}
+		prefs.SetStringList(prefHSCAddrs, list)
+	}
+	for i := range counters {
+		c := &hscCounter{
+			addrEntry:  widget.NewEntry(),
+			valueLabel: widget.NewLabel("-"),
+			rateLabel:  widget.NewLabel("-"),
+			dirLabel:   widget.NewLabel("-"),
+		}
+		c.addrEntry.SetPlaceHolder(tr("如 VD500"))
+		if i < len(saved) {
+			c.addrEntry.SetText(saved[i])
+		}
+		c.addrEntry.OnChanged = func(string) {
+			c.lastTime = time.Time{}
+			c.valueLabel.SetText("-")
+			c.rateLabel.SetText("-")
+			saveAddrs()
+		}
+		counters[i] = c
+		grid.Add(widget.NewLabel(fmt.Sprintf("HSC%d", i)))
+		grid.Add(c.addrEntry)
+		grid.Add(c.valueLabel)
+		grid.Add(c.rateLabel)
+		grid.Add(c.dirLabel)
+	}
+	statusLabel := widget.NewLabel("")
+
+	// read 用一次多变量请求读取各计数器的状态字节和填写了地址的当前值
+	read := func(ctx context.Context, viewer *s7viewer.Viewer) {
+		var addrs []string
+		fyne.DoAndWait(func() {
+			for _, c := range counters {
+				addrs = append(addrs, c.addrEntry.Text)
+			}
+		})
+		// items中先是各计数器的状态字节，其后是填写了地址的当前值
+		var items []s7viewer.Item
+		for _, b := range hscStatusBytes {
+			items = append(items, s7viewer.Item{Area: s7viewer.AreaSM, Start: b, Size: 1})
+		}
+		valueItem := make([]int, len(counters)) // 各计数器当前值在items中的下标，-1表示未填写地址
+		for i := range counters {
+			valueItem[i] = -1
+			if strings.TrimSpace(addrs[i]) == "" {
+				continue
+			}
+			addr, err := parseAddress(strings.ToUpper(strings.TrimSpace(addrs[i])))
+			if err != nil || addr.size != "D" {
+				fyne.Do(func() {
+					statusLabel.SetText(fmt.Sprintf(tr("HSC%d 的当前值地址应为双字地址，如 VD500"), i))
+				})
+				return
+			}
+			valueItem[i] = len(items)
+			items = append(items, s7viewer.Item{Area: addr.area, Start: addr.byteOff, Size: 4})
+		}
+
+		err := viewer.ReadItems(ctx, items)
+		at := time.Now()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf(tr("读取高速计数器失败: %v"), err)
+		}
+		fyne.Do(func() {
+			if err != nil {
+				statusLabel.SetText(tr("读取失败: ") + err.Error())
+				return
+			}
+			for i, c := range counters {
+				if it := items[i]; it.Err == nil && len(it.Data) == 1 {
+					c.showStatus(it.Data[0])
+				}
+				if valueItem[i] < 0 {
+					continue
+				}
+				if it := items[valueItem[i]]; it.Err != nil {
+					c.valueLabel.SetText(tr("错误: ") + it.Err.Error())
+				} else {
+					c.update(int32(binary.BigEndian.Uint32(it.Data)), at)
+				}
+			}
+			statusLabel.SetText(tr("读取时间 ") + at.Format("15:04:05"))
+		})
+	}
+	readButton, autoCheck := newReadControls(getViewer, statusLabel, read)
+
+	return container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(readButton, autoCheck, statusLabel),
+			widget.NewLabel(tr("HC区不能通过通信读取，请在程序中用MOVD把HCx传送到V区（如 MOVD HC0, VD500），并在下面填写该地址。")),
+		),
+		nil, nil, nil,
+		container.NewVScroll(grid),
+	)
+}
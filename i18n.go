@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// 界面语言
+const (
+	langZH = "zh-CN"
+	langEN = "en-US"
+)
+
+var languages = []string{langZH, langEN}
+
+// languageNames 语言在菜单中的名称，始终用该语言本身显示
+var languageNames = map[string]string{langZH: "中文", langEN: "English"}
+
+// catalogs 各语言的消息目录，键为源码中的中文原文。zh-CN直接显示原文，没有目录；
+// 目录中缺少的消息也显示原文。
+var catalogs = map[string]map[string]string{langEN: messagesEN}
+
+// language 当前语言，监控协程写日志时也会读取
+var language atomic.Value
+
+func init() {
+	language.Store(defaultLanguage())
+}
+
+// defaultLanguage 未设置语言时按环境变量选择，英文环境使用en-US，其余使用zh-CN。
+// 命令行参数的说明在读取偏好设置之前注册，也按此显示。
+func defaultLanguage() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			if strings.HasPrefix(v, "en") {
+				return langEN
+			}
+			return langZH
+		}
+	}
+	return langZH
+}
+
+func currentLanguage() string {
+	return language.Load().(string)
+}
+
+// setLanguage 切换当前语言，不支持的语言按zh-CN处理。已创建的界面不会更新，需要重新创建。
+func setLanguage(lang string) {
+	if _, ok := languageNames[lang]; !ok {
+		lang = langZH
+	}
+	language.Store(lang)
+}
+
+// tr 返回消息在当前语言中的文本。格式串也按原文查找，译文中的格式动词顺序与原文一致。
+func tr(s string) string {
+	if t, ok := catalogs[currentLanguage()][s]; ok {
+		return t
+	}
+	return s
+}
+
+// newTrSelect 创建按当前语言显示选项的下拉框。values为选项的原始值（保存在偏好设置中、
+// 代码中比较的中文名称），changed收到原始值；选中时用SetSelected(tr(value))，
+// 读取时用selectValue。
+func newTrSelect(values []string, changed func(value string)) *widget.Select {
+	options := make([]string, len(values))
+	for i, v := range values {
+		options[i] = tr(v)
+	}
+	sel := widget.NewSelect(options, nil)
+	if changed != nil {
+		sel.OnChanged = func(string) {
+			changed(selectValue(sel, values))
+		}
+	}
+	return sel
+}
+
+// selectValue 返回newTrSelect创建的下拉框当前选中的原始值，未选中时返回空串
+func selectValue(sel *widget.Select, values []string) string {
+	i := sel.SelectedIndex()
+	if i < 0 || i >= len(values) {
+		return ""
+	}
+	return values[i]
+}
@@ -0,0 +1,932 @@
+package main
+
+// messagesEN en-US消息目录，键为源码中的中文原文
+var messagesEN = map[string]string{
+	"无效的地址: %q":                                 "invalid address: %q",
+	"无效的地址偏移: %q":                               "invalid address offset: %q",
+	"%s区按编号访问，例如 %s37: %q":                      "%s area is addressed by number, e.g. %s37: %q",
+	"%s区只能按字访问，例如 %sW16: %q":                    "%s area can only be accessed by word, e.g. %sW16: %q",
+	"位地址需要指定位号，例如 %s%d.0":                       "bit address needs a bit number, e.g. %s%d.0",
+	"%s%s%d 不能带位号":                              "%s%s%d cannot have a bit number",
+	"位号超出范围(0-7): %q":                           "bit number out of range (0-7): %q",
+	"只支持V区地址: %q":                               "only V area addresses are supported: %q",
+	"无效的报警条件: %q，示例: V100.0 == 1 或 VW120 > 500": "invalid alarm condition: %q, e.g. V100.0 == 1 or VW120 > 500",
+	"不支持的存储区: %s":                               "unsupported memory area: %s",
+	"无效的起始地址: %q":                               "invalid start address: %q",
+	"无效的长度: %q（1-%d）":                           "invalid length: %q (1-%d)",
+	"API写入 %s = %s":                             "API write %s = %s",
+	"API响应写入失败: %v":                             "API response write failed: %v",
+	"HTTP接口监听于 %s":                              "HTTP API listening on %s",
+	"HTTP接口启动失败: %v":                            "HTTP API failed to start: %v",
+	"启用HTTP接口的监听地址，如 :8080":                     "listen address for the HTTP API, e.g. :8080",
+	"PLC IP地址":                                  "PLC IP address",
+	"机架号":                                       "rack number",
+	"槽位号":                                       "slot number",
+	"TCP端口":                                     "TCP port",
+	"连接超时":                                      "connect timeout",
+	"读写超时或连接断开后的重试次数，0表示不重试":          "retries after a read/write timeout or lost connection, 0 disables retries",
+	"每次重试前的等待时间":                      "wait time before each retry",
+	"存储区（-addr为纯数字时使用）: ":             "memory area (used when -addr is a plain number): ",
+	"起始地址，纯数字或S7地址，如 VW100、M10.1、IW4": "start address, a plain number or S7 address, e.g. VW100, M10.1, IW4",
+	"读取长度（字节，T/C为个数），0表示按地址宽度":        "length to read (bytes, count for T/C), 0 uses the address width",
+	"监控扫描周期":              "monitor scan interval",
+	"V区访问方式: auto/db1/mb": "V area access mode: auto/db1/mb",
+	"启动内置S7模拟器的监听地址，如 127.0.0.1:1102，连接时填写该IP和端口": "listen address of the built-in S7 simulator, e.g. 127.0.0.1:1102; connect to that IP and port",
+	"模拟器数据变化模式: ":              "simulator data pattern: ",
+	"无效的输出格式: %s\n":            "invalid output format: %s\n",
+	"无效的V区访问方式: %s\n":          "invalid V area access mode: %s\n",
+	"未连接PLC %s":                "PLC %s not connected",
+	"开始监控 %s, 长度%d, 按Ctrl+C停止": "monitoring %s, length %d, press Ctrl+C to stop",
+	"%s 不是位地址":                 "%s is not a bit address",
+	"创建记录目录失败: %v":             "failed to create log directory: %v",
+	"打开记录文件失败: %v":             "failed to open log file: %v",
+	"例如 V100.0, V100.3（可留空）":   "e.g. V100.0, V100.3 (optional)",
+	"记录: 未启用":                  "Logging: disabled",
+	"记录到CSV":                   "Log to CSV",
+	"关闭记录文件失败: %v":             "failed to close log file: %v",
+	"记录: 路径或刷新间隔无效":            "Logging: invalid path or flush interval",
+	"记录: 位地址无效":                "Logging: invalid bit address",
+	"记录位地址无效: %v":              "invalid logging bit address: %v",
+	"记录: %s":                   "Logging: %s",
+	"开始记录到 %s":                 "logging to %s",
+	"文件路径:":                    "File path:",
+	"刷新间隔 (秒):":                "Flush interval (s):",
+	"记录的位:":                    "Bits to log:",
+	"监控时每次扫描追加一行，文件按天滚动（文件名后追加日期）。": "While monitoring, one row is appended per scan; files roll over daily (the date is appended to the file name).",
+	"复制全部":              "Copy all",
+	"清空":                "Clear",
+	"级别:":               "Level:",
+	"日志":                "Log",
+	"余 % X":             "rest % X",
+	"打开历史库失败: %v":       "failed to open history database: %v",
+	"初始化历史库失败: %v":      "failed to initialize history database: %v",
+	"历史库: 未启用":          "History: disabled",
+	"记录历史 (SQLite)":     "Record history (SQLite)",
+	"关闭历史库失败: %v":       "failed to close history database: %v",
+	"历史库: 打开失败":         "History: open failed",
+	"历史库: ":             "History: ",
+	"例如 V100.3 或 VW100": "e.g. V100.3 or VW100",
+	"2小时":               "2 hours",
+	"查询":                "Query",
+	"查询历史":              "Query history",
+	"请先启用历史库":           "Please enable the history database first",
+	"查询历史失败: %v":        "history query failed: %v",
+	"%s 最近%s: %d条记录":    "%s last %s: %d records",
+	"数据库文件:":            "Database file:",
+	"监控时只记录变化的位（V100.3）和字（VW100）。": "While monitoring, only changed bits (V100.3) and words (VW100) are recorded.",
+	"变量:":                 "Variables:",
+	"写入InfluxDB失败: %v":    "failed to write to InfluxDB: %v",
+	"写入InfluxDB失败: %s %s": "failed to write to InfluxDB: %s %s",
+	"InfluxDB写入过慢，丢弃了%d个数据点":    "InfluxDB writes are too slow, dropped %d points",
+	"InfluxDB: 未启用":             "InfluxDB: disabled",
+	"写入InfluxDB（监控时）":           "Write to InfluxDB (while monitoring)",
+	"InfluxDB: 地址、组织、存储桶或批大小无效": "InfluxDB: invalid URL, org, bucket or batch size",
+	"InfluxDB: 写入 ":             "InfluxDB: writing to ",
+	"地址:":                       "Address:",
+	"组织 (org):":                 "Organization (org):",
+	"存储桶 (bucket):":             "Bucket (bucket):",
+	"令牌 (token):":               "Token (token):",
+	"批大小 (数据点):":                "Batch size (points):",
+	"测量名 plc_value，标签 plc、tag（如 V100.3、VW100），字段 value。": "Measurement plc_value, tags plc and tag (e.g. V100.3, VW100), field value.",
+	"读取数据块定义失败: %v":                                      "failed to read data block definition: %v",
+	"数据块定义为空":                                            "data block definition is empty",
+	"未找到可用的变量定义":                                         "no usable variable definitions found",
+	"第%d行: 无法解析: %v":                                     "line %d: cannot parse: %v",
+	"第%d行: %v":                                           "line %d: %v",
+	"不支持的类型 %q（支持 BOOL/BYTE/WORD/INT/DWORD/DINT/REAL/STRING）": "unsupported type %q (supported: BOOL/BYTE/WORD/INT/DWORD/DINT/REAL/STRING)",
+	"BOOL 需要位地址，实际为 %s":                                       "BOOL needs a bit address, got %s",
+	"%s 不能使用位地址 %s":                                           "%s cannot use bit address %s",
+	"地址 %s 的宽度与类型 %s 不符":                                      "width of address %s does not match type %s",
+	"缺少地址":                                                    "missing address",
+	"错误: ":                                                    "error: ",
+	"无界面模式：连接PLC读取或监控后输出到标准输出":                                "headless mode: connect to the PLC, read or monitor, and print to standard output",
+	"启动后离线查看录制文件（.rec）或快照文件（JSON/CSV），不需要连接PLC": "open a recording (.rec) or snapshot (JSON/CSV) for offline viewing at startup, no PLC needed",
+	"S7模拟器已启动: %s（模式 %s）":                       "S7 simulator started: %s (pattern %s)",
+	"PLC未连接": "PLC not connected",
+	"关闭标签页":  "Close tab",
+	"该PLC正在监控，关闭将停止监控并断开连接。是否继续？": "This PLC is being monitored. Closing stops monitoring and disconnects. Continue?",
+	"切换语言": "Switch language",
+	"切换语言将重新创建界面，停止所有监控并断开连接。是否继续？": "Switching language rebuilds the window, stops all monitoring and disconnects. Continue?",
+	"语言": "Language",
+	"设置": "Settings",
+	"退出": "Quit",
+	"有PLC正在监控，退出将停止所有监控并断开连接。是否继续？": "PLCs are being monitored. Quitting stops all monitoring and disconnects. Continue?",
+	"Modbus网关停止接受连接: %v":            "Modbus gateway stopped accepting connections: %v",
+	"Modbus: 未启用":                   "Modbus: disabled",
+	"启用Modbus TCP网关":                "Enable Modbus TCP gateway",
+	"Modbus: 端口无效":                  "Modbus: invalid port",
+	"Modbus: 启动失败":                  "Modbus: start failed",
+	"Modbus网关启动失败: %v":              "Modbus gateway failed to start: %v",
+	"Modbus: 监听端口 %d":               "Modbus: listening on port %d",
+	"Modbus网关已启动，端口 %d":             "Modbus gateway started on port %d",
+	"端口:":                           "Port:",
+	"监控范围映射为保持寄存器：寄存器0 = 起始地址的字（高字节在前），依次递增。\n支持功能码03/04读取，开始监控后数据才可用。": "The monitored range is mapped to holding registers: register 0 = the word at the start address (high byte first), increasing from there.\nFunction codes 03/04 are supported; data is available after monitoring starts.",
+	"连接MQTT代理失败: %v":         "failed to connect to MQTT broker: %v",
+	"发送CONNECT失败: %v":        "failed to send CONNECT: %v",
+	"等待CONNACK失败: %v":        "failed waiting for CONNACK: %v",
+	"MQTT代理返回了意外的报文: 0x%02X": "MQTT broker returned an unexpected packet: 0x%02X",
+	"MQTT代理拒绝连接，返回码 %d":      "MQTT broker refused the connection, return code %d",
+	"MQTT连接已断开":              "MQTT connection lost",
+	"无效的剩余长度":                "invalid remaining length",
+	"MQTT连接失败: %v":           "MQTT connection failed: %v",
+	"MQTT: 已连接 ":             "MQTT: connected to ",
+	"MQTT发布失败: %v":           "MQTT publish failed: %v",
+	"MQTT: 连接断开，等待重连":        "MQTT: disconnected, waiting to reconnect",
+	"MQTT代理有%d条消息未确认":        "MQTT broker has %d unacknowledged messages",
+	"MQTT发布过慢，丢弃了%d次扫描":      "MQTT publishing is too slow, dropped %d scans",
+	"保留消息(Retain)":           "Retain messages",
+	"MQTT: 未启用":              "MQTT: disabled",
+	"启用MQTT发布（监控时发布）":        "Enable MQTT publishing (while monitoring)",
+	"MQTT: 代理地址或发布周期无效":      "MQTT: invalid broker address or publish interval",
+	"MQTT: 等待监控数据":           "MQTT: waiting for monitor data",
+	"已启用MQTT发布: %s, 主题前缀 %s": "MQTT publishing enabled: %s, topic prefix %s",
+	"代理地址:":                  "Broker address:",
+	"客户端ID:":                 "Client ID:",
+	"用户名:":                   "User name:",
+	"密码:":                    "Password:",
+	"主题前缀:":                  "Topic prefix:",
+	"发布方式:":                  "Publish mode:",
+	"定时周期 (ms):":             "Interval (ms):",
+	"主题格式: 前缀/V100.3（位，0/1）、前缀/VW100（字，十进制）": "Topic format: prefix/V100.3 (bit, 0/1), prefix/VW100 (word, decimal)",
+	"详情":       "Details",
+	"收起":       "Hide",
+	"关闭":       "Close",
+	"%s失败: %s": "%s failed: %s",
+	"未归类":      "unclassified",
+	"操作: %s\n类别: %s\n原始错误: %v":     "Operation: %s\nCategory: %s\nOriginal error: %v",
+	"启动OPC UA服务失败: %v":             "failed to start OPC UA server: %v",
+	"无效的机架号: %q（0-7）":              "invalid rack: %q (0-7)",
+	"无效的槽位号: %q（0-31）":             "invalid slot: %q (0-31)",
+	"无效的端口: %q":                    "invalid port: %q",
+	"无效的超时: %q":                    "invalid timeout: %q",
+	"无效的重试次数: %q（0-10）":            "invalid retry count: %q (0-10)",
+	"无效的重试间隔: %q":                  "invalid retry delay: %q",
+	"100 或 VW100、V100.3、M10.1、IW4": "100 or VW100, V100.3, M10.1, IW4",
+	"实际周期: -":                      "Actual interval: -",
+	"无效的扫描周期: %v":                  "invalid scan interval: %v",
+	"扫描周期已设置为 %v":                  "scan interval set to %v",
+	"无效的合并间隔: %v":                  "invalid coalesce gap: %v",
+	"合并间隔已设置为 %d字节":                "coalesce gap set to %d bytes",
+	"加载连接配置失败: %v":                 "failed to load connection profiles: %v",
+	"已载入配置 %s (%s)":                "loaded profile %s (%s)",
+	"选择已保存的PLC":                    "Select a saved PLC",
+	"无法确定配置目录，配置未保存":               "cannot determine the config directory, profile not saved",
+	"保存配置":                         "Save profile",
+	"例如：1号线 包装机":                   "e.g. Line 1 packer",
+	"保存连接配置":                       "Save connection profile",
+	"保存":                           "Save",
+	"取消":                           "Cancel",
+	"名称:":                          "Name:",
+	"已保存配置 %s":                     "saved profile %s",
+	"删除配置":                         "Delete profile",
+	"删除配置 %s？":                     "Delete profile %s?",
+	"已删除配置 %s":                     "deleted profile %s",
+	"未选择连接配置，位标签将在保存配置时一并保存": "no connection profile selected, bit labels will be saved with the profile",
+	"例如：1号电机运行":                       "e.g. Motor 1 running",
+	"位标签 ":                            "Bit label ",
+	"确定":                              "OK",
+	"标签:":                             "Label:",
+	"读取方式: ":                          "Read mode: ",
+	"未连接":                             "Not connected",
+	"第 1/1 页":                         "Page 1/1",
+	"第 %d/%d 页":                       "Page %d/%d",
+	"上一页":                             "Previous",
+	"下一页":                             "Next",
+	"例如 V100.0 == 1 或 VW120 > 500":    "e.g. V100.0 == 1 or VW120 > 500",
+	"报警: 未启用":                         "Alarm: disabled",
+	"启用报警":                            "Enable alarm",
+	"报警: 条件无效":                        "Alarm: invalid condition",
+	"报警条件无效: %v":                      "invalid alarm condition: %v",
+	"报警: 已布防 [":                       "Alarm: armed [",
+	"【报警】":                            "[ALARM] ",
+	"报警触发: %s %s":                     "alarm triggered: %s %s",
+	"OPC UA: 未启用":                     "OPC UA: disabled",
+	"OPC UA: 状态表中没有变量":                "OPC UA: the watch table has no variables",
+	"OPC UA: 端口无效":                    "OPC UA: invalid port",
+	"OPC UA: 启动失败":                    "OPC UA: start failed",
+	"OPC UA: opc.tcp://%s:%d (%d个变量)": "OPC UA: opc.tcp://%s:%d (%d variables)",
+	"OPC UA服务已启动: opc.tcp://%s:%d":    "OPC UA server started: opc.tcp://%s:%d",
+	"启用OPC UA服务":                      "Enable OPC UA server",
+	"导入数据块":                           "Import data block",
+	"导入数据块失败: %v":                     "failed to import data block: %v",
+	"数据块定义: %s":                       "Data block definition: %s",
+	"已导入%d个变量":                        "imported %d variables",
+	"部分行未导入":                          "Some lines were not imported",
+	"已导入%d个变量，以下%d行被跳过:\n%s": "Imported %d variables; the following %d lines were skipped:\n%s",
+	"导入符号表":       "Import symbol table",
+	"导入符号表失败: %v": "failed to import symbol table: %v",
+	"符号表: %s":     "Symbol table: %s",
+	"已导入%d个符号（%d个位标签，%d个V区变量）": "imported %d symbols (%d bit labels, %d V area variables)",
+	"已导入%d个符号，以下%d行被跳过:\n%s":   "Imported %d symbols; the following %d lines were skipped:\n%s",
+	"寄存器内容按所选格式显示，用逗号分隔":       "Register contents in the selected format, comma separated",
+	"连接PLC":       "Connect PLC",
+	"请输入PLC IP地址": "Please enter the PLC IP address",
+	"连接参数错误":      "Invalid connection parameters",
+	"连接失败":        "Connection failed",
+	"已连接 ":        "Connected ",
+	"（PDU %d字节）":  " (PDU %d bytes)",
+	"PLC连接成功! 协商的PDU长度: %d字节": "PLC connected! Negotiated PDU length: %d bytes",
+	"PLC连接成功!":             "PLC connected!",
+	"监控读取失败":               "Monitor read failed",
+	"连接异常":                 "Connection unstable",
+	"心跳检测失败":               "Heartbeat check failed",
+	"无效的地址: %v":            "invalid address: %v",
+	"无效的长度: %v":            "invalid length: %v",
+	"读取结构化视图数据失败: %v":      "failed to read structured view data: %v",
+	"请先停止监控再查看文件中的数据":      "Stop monitoring before viewing data from a file",
+	"请先连接PLC":              "Please connect to the PLC first",
+	"读取数据失败":               "Read failed",
+	"读取数据":                 "Read data",
+	"仅支持写入V区的位，当前为%s区":     "Only V area bits can be written, current area is %s",
+	"将 V%d.%d 从 %d 改为 %d？": "Change V%d.%d from %d to %d?",
+	"写入位":                  "Write bit",
+	"写入 V%d.%d 失败":         "Write V%d.%d failed",
+	"已写入 V%d.%d = %d":      "wrote V%d.%d = %d",
+	"写入录制文件失败: %v":         "failed to write recording: %v",
+	"记录CSV失败: %v":          "CSV logging failed: %v",
+	"写后校验":                 "Verify after write",
+	"开始监控":                 "Start monitoring",
+	"写入历史库失败: %v":          "failed to write history: %v",
+	"实际周期: %d ms":          "Actual interval: %d ms",
+	"开始监控 %s, 长度%d":        "monitoring %s, length %d",
+	"停止监控":                 "Stop monitoring",
+	"已停止监控":                "monitoring stopped",
+	"导出CSV":                "Export CSV",
+	"没有可导出的数据，请先读取":        "Nothing to export, read data first",
+	"例如：故障发生瞬间":            "e.g. the moment the fault occurred",
+	"下一步":                  "Next",
+	"备注 (可选):":             "Note (optional):",
+	"导出失败":                 "Export failed",
+	"已导出到 %s":              "exported to %s",
+	"导出快照":                 "Export snapshot",
+	"例如：工单号、故障现象":          "e.g. work order number, fault symptom",
+	"导出快照失败":               "Export snapshot failed",
+	"已导出快照到 %s":            "exported snapshot to %s",
+	"快照_%s_%s.json":        "snapshot_%s_%s.json",
+	"# 采集时间: ":             "# Captured: ",
+	"# 起始地址: %s, 长度: %d字节": "# Start address: %s, length: %d bytes",
+	"# 起始地址: %s, 长度: %d个":  "# Start address: %s, length: %d timers/counters",
+	"# 备注: ":               "# Note: ",
+	"十进制":                  "Decimal",
+	"十六进制":                 "Hex",
+	"二进制":                  "Binary",
+	"打开文件失败: %v":           "failed to open file: %v",
+	"离线查看: ":               "Offline view: ",
+	"已打开 %s":               "opened %s",
+	"打开文件":                 "Open file",
+	"PLC已断开连接":             "PLC disconnected",
+	"监控正在运行，继续将停止监控并断开PLC连接。是否继续？": "Monitoring is running. Continuing stops monitoring and disconnects the PLC. Continue?",
+	"断开连接":                "Disconnect",
+	"清除显示":                "Clear display",
+	"连接配置:":               "Connection profile:",
+	"PLC IP地址:":           "PLC IP address:",
+	"机架/槽位/端口/超时(秒):":     "Rack/slot/port/timeout (s):",
+	"重试次数/间隔(ms):":        "Retries/delay (ms):",
+	"存储区:":                "Memory area:",
+	"起始地址:":               "Start address:",
+	"寄存器长度 (字节, T/C为个数):": "Register length (bytes, count for T/C):",
+	"扫描周期 (ms, 回车应用):":    "Scan interval (ms, Enter to apply):",
+	"心跳间隔 (秒):":           "Heartbeat interval (s):",
+	"合并读取间隔 (字节, -1不合并, 回车应用):": "Coalesce read gap (bytes, -1 disables, Enter to apply):",
+	"V区访问方式:":          "V area access mode:",
+	"报警条件:":            "Alarm condition:",
+	"网格样式:":            "Grid style:",
+	"方块大小:":            "Cell size:",
+	"变化高亮(毫秒):":        "Change highlight (ms):",
+	"录制回放":             "Record/Replay",
+	"位网格":              "Bit grid",
+	"结构化视图":            "Structured view",
+	"OPC UA端口:":        "OPC UA port:",
+	"趋势":               "Trend",
+	"状态表":              "Watch table",
+	"快照对比":             "Snapshot diff",
+	"写入":               "Write",
+	"记录":               "Logging",
+	"历史":               "History",
+	"寄存器内容:":           "Register contents:",
+	"字节顺序:":            "Byte order:",
+	"无法确定配置目录: %v":     "cannot determine the config directory: %v",
+	"读取配置文件失败: %v":     "failed to read the config file: %v",
+	"解析配置文件 %s 失败: %v": "failed to parse config file %s: %v",
+	"创建配置目录失败: %v":     "failed to create the config directory: %v",
+	"保存配置文件失败: %v":     "failed to save the config file: %v",
+	"创建录制文件失败: %v":     "failed to create recording: %v",
+	"打开录制文件失败: %v":     "failed to open recording: %v",
+	"不是录制文件":           "not a recording file",
+	"录制文件头不完整":         "recording header is incomplete",
+	"录制文件头无效: %v":      "invalid recording header: %v",
+	"第%d帧无效: %v":       "frame %d is invalid: %v",
+	"录制文件中没有数据":        "recording contains no data",
+	"帧长度%d过大":          "frame length %d is too large",
+	"缺少完整帧":            "missing a full frame",
+	"变化偏移%d超出帧长度":      "change offset %d exceeds frame length",
+	"未知的帧类型 %d":        "unknown frame type %d",
+	"录制: 未启用":          "Recording: disabled",
+	"录制（监控时）":          "Record (while monitoring)",
+	"关闭录制文件失败: %v":     "failed to close recording: %v",
+	"录制结束，共%d帧":        "recording finished, %d frames",
+	"录制: 路径无效":         "Recording: invalid path",
+	"录制: %s（文件名后追加开始时间）":  "Recording: %s (the start time is appended to the file name)",
+	"开始录制到 %s":            "recording to %s",
+	"未打开录制":               "No recording opened",
+	"播放":                  "Play",
+	"第 %d/%d 帧  %s":       "Frame %d/%d  %s",
+	"暂停":                  "Pause",
+	"打开录制":                "Open recording",
+	"录制文件中没有数据帧":          "recording contains no data frames",
+	"%s  %s %s，%d帧，时长 %s": "%s  %s %s, %d frames, duration %s",
+	"录制":                  "Record",
+	"监控时每次扫描写入一帧，未变化的扫描只记时间，变化时只记变化的字节。": "While monitoring, each scan writes a frame; unchanged scans record only the time, changes record only the changed bytes.",
+	"回放":  "Replay",
+	"速度:": "Speed:",
+	"回放时在位网格、寄存器内容、结构化视图和趋势图中显示录制的数据，需先停止监控。": "Replay shows the recorded data in the bit grid, register contents, structured view and trend chart; stop monitoring first.",
+	"无效的模拟模式: %s（可选 %v）":          "invalid simulator pattern: %s (one of %v)",
+	"启动S7模拟器失败: %v":               "failed to start S7 simulator: %v",
+	"S7模拟器: 无法识别的报文 % X":          "S7 simulator: unrecognized packet % X",
+	"无效的TPKT头: % X":               "invalid TPKT header: % X",
+	"两个快照的存储区不同（%s / %s）":         "the two snapshots are from different memory areas (%s / %s)",
+	"读取快照失败: %v":                  "failed to read snapshot: %v",
+	"解析快照失败: %v":                  "failed to parse snapshot: %v",
+	"快照起始地址无效: %v":                "invalid snapshot start address: %v",
+	"不是有效的快照文件：缺少起始地址":            "not a valid snapshot file: missing start address",
+	"快照中 %s 的值无效: %q":             "invalid value for %s in snapshot: %q",
+	"前: 未载入":                      "Before: not loaded",
+	"后: 未载入":                      "After: not loaded",
+	"位变化 %d 个，字变化 %d 个，变量变化 %d 个": "%d bit changes, %d word changes, %d variable changes",
+	"%s: %s %s %s (%d字节)":         "%s: %s %s %s (%d bytes)",
+	"载入快照失败: %v":                  "failed to load snapshot: %v",
+	"没有可记录的数据，请先读取":               "no data to record, read data first",
+	"当前":             "current",
+	"载入前快照":          "Load before snapshot",
+	"前":              "Before",
+	"记录当前为前":         "Record current as before",
+	"载入后快照":          "Load after snapshot",
+	"后":              "After",
+	"记录当前为后":         "Record current as after",
+	"快照中的原始数据无效: %v": "invalid raw data in snapshot: %v",
+	"读取符号表失败: %v":    "failed to read symbol table: %v",
+	"未找到符号表表头（需要 符号 和 地址 列）":   "symbol table header not found (needs Symbol and Address columns)",
+	"第%d行: 缺少符号或地址":            "line %d: missing symbol or address",
+	"符号表中没有可用的符号":              "no usable symbols in the symbol table",
+	"最多同时显示%d个变量":              "at most %d variables can be shown at once",
+	"应用":                       "Apply",
+	"时间范围:":                    "Time range:",
+	"%s区的当前值只能按 INT 或 WORD 显示": "current values in the %s area can only be shown as INT or WORD",
+	"%s 需要字节地址，如 VB100":        "%s needs a byte address, e.g. VB100",
+	"地址":                       "Address",
+	"数据类型":                     "Data type",
+	"当前值":                      "Current value",
+	"新值":                       "New value",
+	"添加行":                      "Add row",
+	"写入新值":                     "Write new values",
+	"仅支持写入V区，%s 未写入":           "only V area can be written, %s not written",
+	"写入 %s 失败: %v":             "write %s failed: %v",
+	"已写入 %s = %s (%s)":         "wrote %s = %s (%s)",
+	"已写入%d项":                   "wrote %d items",
+	"，写入已验证":                   ", write verified",
+	"字符串长度%d超过最大长度%d":          "string length %d exceeds the maximum length %d",
+	"实时数据编码失败: %v":             "failed to encode live data: %v",
+	"需要WebSocket连接":            "WebSocket connection required",
+	"不支持WebSocket":             "WebSocket not supported",
+	"WebSocket握手失败: %v":        "WebSocket handshake failed: %v",
+	"WebSocket帧过大: %d":         "WebSocket frame too large: %d",
+	"位地址请在网格中双击写入":             "Write bit addresses by double-clicking them in the grid",
+	"例如 VW100、VD200、VR104":     "e.g. VW100, VD200, VR104",
+	"十进制，整数也可用0x前缀":            "Decimal; integers may also use a 0x prefix",
+	"数据类型:":                    "Data type:",
+	"值:":                       "Value:",
+	"外观...":                    "Appearance...",
+	"外观":                       "Appearance",
+	"主题:":                      "Theme:",
+	"强调色:":                     "Accent color:",
+	"强调色":                      "Accent color",
+	"1的颜色:":                    "Color for 1:",
+	"1的颜色":                     "Color for 1",
+	"0的颜色:":                    "Color for 0:",
+	"0的颜色":                     "Color for 0",
+	"变化高亮:":                    "Change highlight:",
+	"变化高亮颜色":                   "Change highlight color",
+	"选择...":                    "Choose...",
+	"默认":                       "Default",
+	"行数应为1-%d":                 "Rows must be 1-%d",
+	"每行位数/行数 (回车应用):":          "Bits per row/rows (Enter to apply):",
+	"位顺序:":                     "Bit order:",
+	"未使用的颜色":                   "Unused color",
+	"读取失败/过期的颜色":               "Read error/stale color",
+	"未使用(屏蔽):":                 "Unused (muted):",
+	"读取失败/过期:":                 "Read error/stale:",
+	"选择预设配色":                   "Choose a preset",
+	"配色方案:":                    "Color scheme:",
+	"1的标记:":                    "Marker for 1:",
+	"看板模式":                     "Kiosk mode",
+	"显示":                       "View",
+	"启动后以全屏看板模式显示第一个标签页的PLC，自动连接并监控，按Esc退出": "start in full-screen kiosk mode for the first tab's PLC, connecting and monitoring automatically; press Esc to exit",
+	"%s [%s] %s %s [%s] 当前值 %s": "%s [%s] %s %s [%s] value %s",
+	"如 > 1500 或 ":               "= 1 for > 5 s=e.g. > 1500 or == 1 for > 5 s",
+	"报警":                        "Alarms",
+	"报警条件":                      "Alarm condition",
+	"报警条件: %s 不支持 %s 类型":        "Alarm condition: %s does not support type %s",
+	"报警条件: %s: %v":              "Alarm condition %s: %v",
+	"报警条件的地址 %s 与该行地址 %s 不一致":                 "Alarm condition address %s does not match the row address %s",
+	"无效的报警条件: %q，示例: > 1500 或 == 1 for > 5 s": "invalid alarm condition: %q, e.g. > 1500 or == 1 for > 5 s",
+	"解除": "cleared",
+	"触发": "triggered",
+	"在状态表的“报警条件”列为各行设置条件，如 > 1500、== 1 for > 5 s（条件持续5秒后触发）": "Set per-row conditions in the Alarm condition column of the watch table, e.g. > 1500 or == 1 for > 5 s (triggers after the condition holds for 5 seconds)",
+	"报警解除: %s %s [%s] 当前值 %s": "Alarm cleared: %s %s [%s] value %s",
+	"报警触发: %s %s [%s] 当前值 %s": "Alarm triggered: %s %s [%s] value %s",
+	"清空列表":                    "Clear list",
+	"触发时:":                    "On trigger:",
+	"PLC报警: ":                 "PLC alarm: ",
+	"%s [%s] 当前值 %s":          "%s [%s] value %s",
+	"桌面通知":                    "Desktop notification",
+	"按地址识别":                   "Detect from URL",
+	"通用JSON":                  "Generic JSON",
+	"企业微信机器人":                 "WeChat Work robot",
+	"钉钉机器人":                   "DingTalk robot",
+	"PLC报警":                   "PLC alarm",
+	"推送Webhook失败: %v":         "webhook push failed: %v",
+	"推送Webhook失败: %s %s":      "webhook push failed: %s %s",
+	"推送Webhook失败: errcode":    "%d %s=webhook push failed: errcode=%d %s",
+	"每行一个地址，如 https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=...": "One URL per line, e.g. https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=...",
+	"已推送 %s":           "Pushed %s",
+	"发送测试":             "Send test",
+	"测试":               "Test",
+	"Webhook地址:":       "Webhook URLs:",
+	"消息模板:":            "Template:",
+	"推送Webhook（触发和解除）": "Push webhook (trigger and clear)",
+	" 等%d条":            " (%d alarms)",
+	"SMTP服务器:":         "SMTP server:",
+	"发件人/收件人:":         "From/To:",
+	"发送报警邮件失败: %v":     "failed to send alarm email: %v",
+	"发送测试邮件":           "Send test email",
+	"多个收件人用逗号分隔":       "Separate recipients with commas",
+	"已发送报警邮件: %d条报警":   "Alarm email sent: %d alarms",
+	"已发送测试邮件":          "Test email sent",
+	"最短间隔(分钟):":        "Min interval (min):",
+	"用户名/密码:":          "User/password:",
+	"邮件设置无效：需要服务器(主机:端口)、发件人、收件人和最短间隔(分钟)": "Invalid email settings: server (host:port), sender, recipients and minimum interval (minutes) are required",
+	"发送邮件":          "Send email",
+	" — %s 于 %s 确认": " — acknowledged by %s at %s",
+	"全部确认":          "Acknowledge all",
+	"写入报警历史失败: %v":  "failed to write alarm history: %v",
+	"历史文件: ":        "History file: ",
+	"响铃":            "Beep",
+	"已确认%d条报警":      "Acknowledged %d alarms",
+	"报警历史":          "Alarm history",
+	"报警已确认: %s %s [%s] 操作员 %s": "Alarm acknowledged: %s %s [%s] by %s",
+	"报警通知":         "Alarm notifications",
+	"按PLC、地址或标签筛选": "Filter by PLC, address or label",
+	"操作员:":         "Operator:",
+	"操作员姓名":        "Operator name",
+	"显示:":          "Show:",
+	"确认选中":         "Acknowledge selected",
+	"请先填写操作员姓名":    "Enter the operator name first",
+	"读取报警历史失败: %v": "failed to read alarm history: %v",
+	"未确认":          "Unacknowledged",
+	"仅触发":          "Triggered only",
+	"仅解除":          "Cleared only",
+	"边沿次数":         "Edges",
+	"边沿计数清零":       "Reset edge counts",
+	"上升沿":          "Rising",
+	"下降沿":          "Falling",
+	"接通时间":         "On time",
+	"断开时间":         "Off time",
+	"占空比":          "Duty cycle",
+	"列出所有位":        "List all bits",
+	"每次读取和监控扫描的数据都参与统计，两次扫描之间的时间计入前一次的值；“边沿计数清零”或“清除显示”重新开始。": "Every read and monitoring scan is counted; the time between scans is credited to the previous value. Reset edge counts or Clear display starts over.",
+	"统计时长 %s，%d个位有变化":        "Duration %s, %d bits changed",
+	"读取或监控后开始统计":             "Statistics start after a read or monitoring",
+	"位统计":                    "Bit statistics",
+	"标签":                     "Label",
+	"跨度 %s":                  "Span %s",
+	"如 V100.0, V100.3, M0.1": "e.g. V100.0, V100.3, M0.1",
+	"滚轮缩放，拖动平移；左键放置光标A，右键放置光标B，靠近边沿时自动吸附": "Scroll to zoom, drag to pan; left-click places cursor A, right-click places cursor B, snapping to nearby edges",
+	"最多同时显示%d个位": "At most %d bits can be shown at once",
+	"放大":         "Zoom in",
+	"缩小":         "Zoom out",
+	"最新":         "Latest",
+	"清除光标":       "Clear cursors",
+	"位:":         "Bits:",
+	"时序图":        "Timing",
+	"要导出的位，如 V100.0, V100.3；为空时导出所有位": "Bits to export, e.g. V100.0, V100.3; leave empty to export all bits",
+	"导出VCD":         "Export VCD",
+	"请先打开录制":        "Open a recording first",
+	"录制中没有位地址":      "The recording has no bit addresses",
+	"导出VCD失败: %v":   "VCD export failed: %v",
+	"已导出VCD到 %s":    "Exported VCD to %s",
+	"%s 不在录制范围内":    "%s is outside the recorded range",
+	"条件触发":          "Triggered",
+	"如 V50.0":       "e.g. V50.0",
+	"触发前后时间应为0到%d秒": "Pre- and post-trigger times must be 0 to %d s",
+	"录制: %v":        "Recording: %v",
+	"录制: 等待 %s %s，保存触发前%d秒、触发后%d秒": "Recording: waiting for %s %s, keeping %d s before and %d s after the trigger",
+	"开始条件录制到 %s":                   "Started triggered recording to %s",
+	"触发前(秒):":                      "Pre-trigger (s):",
+	"触发后(秒):":                      "Post-trigger (s):",
+	"条件触发时只在内存中保留最近的扫描，触发后保存触发前后的数据，每次触发一个文件；修改条件后需重新勾选录制。": "When triggered, recent scans are kept only in memory and the data around each trigger is saved to its own file; re-check recording after changing the condition.",
+	"录制: 第%d次触发于 %s":           "Recording: trigger #%d at %s",
+	"录制: 已保存第%d次触发到 %s，等待下次触发": "Recording: saved trigger #%d to %s, waiting for the next trigger",
+	"起始字节，如 VB100":             "Start byte, e.g. VB100",
+	"开始突发采样":                   "Start burst",
+	"请先停止监控，突发采样需要独占连接":        "Stop monitoring first; burst sampling needs the connection to itself",
+	"长度应为正整数，时长应为1到%d秒":        "Length must be a positive integer and duration 1 to %d s",
+	"采样中，%d秒后结束…":              "Sampling, finishing in %d s…",
+	"突发采样失败: %v":               "Burst sampling failed: %v",
+	"没有得到采样":                   "No samples",
+	"停止":                       "Stop",
+	"保存为录制":                    "Save as recording",
+	"已保存突发采样到 %s":              "Saved burst samples to %s",
+	"突发采样在短时间内不等扫描周期连续读取一小段范围，期间不刷新界面，用于捕捉1秒扫描看不到的快速握手信号。结果显示在时序图中。": "Burst sampling reads a small range back-to-back for a short time without waiting for the scan interval and without refreshing the UI, to catch fast handshakes the 1 s scan misses. Results are shown in the timing view.",
+	"长度(字节):": "Length (bytes):",
+	"时长(秒):":  "Duration (s):",
+	"%d个采样":   "%d samples",
+	"%d个采样，用时 %s，间隔平均 %s、最小 %s、最大 %s": "%d samples in %s, interval avg %s, min %s, max %s",
+	"突发采样": "Burst",
+	"随扫描":  "With scan",
+	"轮询组":  "Poll group",
+	"无效的死区设置: %q，示例: VW100 20":  "Invalid deadband setting: %q, e.g. VW100 20",
+	"无效的死区: %q":                 "Invalid deadband: %q",
+	"未设置死区，每次扫描都输出":             "No deadbands set; every scan is output",
+	"已设置%d项死区":                  "%d deadbands set",
+	"死区（CSV记录、MQTT和InfluxDB共用）": "Deadbands (shared by CSV logging, MQTT and InfluxDB)",
+	"每项为 变量 死区，如 VW100 20, AIW16 50, VW320 0.5 EU（工程单位）；* 5 为所有字变量的默认值": "One entry per tag and deadband, e.g. VW100 20, AIW16 50, VW320 0.5 EU (engineering units); * 5 sets the default for all word tags",
+	"设置死区后，变量的值与上次输出相差超过死区（原始计数，加EU为工程单位）才再次记录或发布；位变量变化即输出。":            "With deadbands set, a value is logged or published again only when it differs from the last output by more than its deadband (raw counts, or engineering units with EU); bits are output on every change.",
+	"无效的换算设置: %q，示例: VW320 0 10000 0 100 °C":                            "Invalid scaling: %q, e.g. VW320 0 10000 0 100 °C",
+	"%s 是位地址，不能换算":      "%s is a bit address and cannot be scaled",
+	"无效的数值: %q":         "Invalid number: %q",
+	"%s 的原始最小值和最大值不能相同": "Raw min and max of %s must differ",
+	"每行一个变量：地址 原始最小 原始最大 工程最小 工程最大 单位，如 VW320 0 10000 0 100 °C": "One tag per line: address raw-min raw-max EU-min EU-max unit, e.g. VW320 0 10000 0 100 °C",
+	"已设置%d个变量的换算": "Scaling set for %d tags",
+	"设置换算的变量在状态表、趋势图和报警中按工程单位显示和比较，快照导出中也是工程值。": "Scaled tags are shown and compared in engineering units in the watch table, trend chart and alarms, and exported as engineering values in snapshots.",
+	"工程单位换算":                        "Engineering-unit scaling",
+	"如 VW100, VD200, VR300 或计算变量名":  "e.g. VW100, VD200, VR300 or a computed tag name",
+	"如 V100.0、VW10、VR20、MB0 或计算变量名": "e.g. V100.0, VW10, VR20, MB0 or a computed tag name",
+	"表达式中的无效字符: %q":                 "invalid character in expression: %q",
+	"表达式中多余的 %q":                    "unexpected trailing %q in expression",
+	"除数为0":                          "division by zero",
+	"表达式缺少右括号":                      "missing closing parenthesis in expression",
+	"%s 没有值":                        "%s has no value",
+	"表达式不完整":                        "incomplete expression",
+	"表达式中意外的 %q":                    "unexpected %q in expression",
+	"未知的函数 %s":                      "unknown function %s",
+	"函数 %s 的参数应以逗号分隔":               "arguments of function %s must be separated by commas",
+	"函数 %s 需要%d个参数":                 "function %s takes %d arguments",
+	"无效的计算变量: %q，示例: flow = (VW100 - 6400) * 0.01": "invalid computed tag: %q, example: flow = (VW100 - 6400) * 0.01",
+	"无效的计算变量名称: %q":                                "invalid computed tag name: %q",
+	"计算变量名称 %s 不能是地址":                              "computed tag name %s must not be an address",
+	"计算变量 %s 重复定义":                                 "computed tag %s is defined twice",
+	"未定义的变量 %s":                                    "undefined variable %s",
+	"计算变量 %s: %v":                                  "computed tag %s: %v",
+	"每行一个：名称 = 表达式，如 flow = (VW100 - 6400) * 0.01 或 fault = V10.0 && !V10.1": "One per line: name = expression, e.g. flow = (VW100 - 6400) * 0.01 or fault = V10.0 && !V10.1",
+	"已定义%d个计算变量": "%d computed tags defined",
+	"计算变量每次扫描时按引用的地址的原始值重新计算，在状态表的地址栏填写名称即可显示、设置报警和导出快照，也可加入趋势图。": "Computed tags are recalculated every scan from the raw values of the addresses they reference. Enter a name in the watch table's address column to display it, set alarms and include it in snapshots; names can also be added to the trend chart.",
+	"计算变量":         "Computed tags",
+	"脚本: 未启用":      "Script: disabled",
+	"启用脚本":         "Enable script",
+	"脚本: 运行中":      "Script: running",
+	"已启动脚本: %s":    "Started script: %s",
+	"脚本: %s":       "Script: %s",
+	"脚本: ":         "Script: ",
+	"脚本写入 %s = %s": "Script wrote %s = %s",
+	"脚本处理过慢，丢弃了%d个事件": "Script is too slow, dropped %d events",
+	"发送给脚本":                "Send to script",
+	"脚本":                   "Script",
+	"请选择脚本文件":              "Please choose a script file",
+	"加载脚本失败: %v":           "failed to load script: %v",
+	"脚本执行超过%v，已中止":         "script ran longer than %v and was aborted",
+	"脚本出错: %v":             "Script error: %v",
+	"Lua脚本文件，如 recipe.lua": "Lua script file, e.g. recipe.lua",
+	"脚本文件:":                "Script file:",
+	"脚本用Lua编写，由程序内嵌的解释器运行。定义 function on_scan(tags, ip) 在每次扫描时调用，tags为各变量的值，如 tags[\"VW100\"]；定义 function on_alarm(e) 在报警触发或解除时调用（e.tag、e.rule、e.value、e.cleared）。\n可调用 plc.read(tag)、plc.write(tag, value)（失败时返回nil和错误信息）、plc.log(text)、plc.notify(text) 和 plc.alarm{tag=..., text=..., value=...}；print写入日志。\n脚本不能访问文件或启动进程，每次调用超过5秒时中止。修改脚本后重新勾选启用以重新加载。": "Scripts are written in Lua and run by the built-in interpreter. Define function on_scan(tags, ip) to be called on every scan, where tags holds the tag values, e.g. tags[\"VW100\"]; define function on_alarm(e) to be called when an alarm is raised or cleared (e.tag, e.rule, e.value, e.cleared).\nScripts can call plc.read(tag), plc.write(tag, value) (both return nil and an error message on failure), plc.log(text), plc.notify(text) and plc.alarm{tag=..., text=..., value=...}; print writes to the log.\nScripts cannot access files or start processes, and each call is aborted after 5 seconds. Re-check Enable after editing the script to reload it.",
+	"已载入插件 %s（%s）":                 "Loaded plugin %s (%s)",
+	"载入插件失败: %v":                   "Failed to load plugin: %v",
+	"插件描述无效: %v":                   "invalid plugin manifest: %v",
+	"插件缺少名称":                       "plugin has no name",
+	"插件缺少命令":                       "plugin has no command",
+	"未知的插件种类 %q，应为 sink 或 decoder": "unknown plugin kind %q, expected sink or decoder",
+	"解码器插件需要设置宽度（字节数）":             "decoder plugins need a width (number of bytes)",
+	"没有名为 %s 的接收端":                 "no sink named %s",
+	"插件 %s 已退出: %v":                "Plugin %s exited: %v",
+	"插件 %s 已退出":                    "plugin %s has exited",
+	"插件 %s: %s":                    "Plugin %s: %s",
+	"插件 %s: 不支持的命令 %q":             "Plugin %s: unsupported command %q",
+	"插件 %s 应答超时":                   "plugin %s did not reply in time",
+	"已停用接收端 %s":                    "Sink %s disabled",
+	"启用接收端 %s 失败: %v":              "Failed to enable sink %s: %v",
+	"已启用接收端 %s，监控时写入":              "Sink %s enabled, written while monitoring",
+	"没有接收端插件":                      "No sink plugins",
+	"没有解码器插件":                      "No decoder plugins",
+	"插件目录: ":                       "Plugins directory: ",
+	"每个插件为目录中的一个描述文件（*.json），如 {\"name\":\"historian\",\"kind\":\"sink\",\"command\":\"python hist.py\"} 或 {\"name\":\"TIME32\",\"kind\":\"decoder\",\"command\":\"node time32.js\",\"width\":4}。插件以子进程运行，每行一个JSON：\n接收端收到 {\"event\":\"scan\",\"area\":\"V\",\"start\":0,\"hex\":...,\"tags\":{...}}；解码器收到 {\"event\":\"decode\",\"id\":1,\"hex\":\"0012AB05\"}，以 {\"cmd\":\"value\",\"id\":1,\"value\":\"...\"} 应答，载入后可在状态表中选择该数据类型。插件在程序启动时载入。": "Each plugin is a manifest file (*.json) in this directory, e.g. {\"name\":\"historian\",\"kind\":\"sink\",\"command\":\"python hist.py\"} or {\"name\":\"TIME32\",\"kind\":\"decoder\",\"command\":\"node time32.js\",\"width\":4}. Plugins run as child processes, one JSON object per line:\nsinks receive {\"event\":\"scan\",\"area\":\"V\",\"start\":0,\"hex\":...,\"tags\":{...}}; decoders receive {\"event\":\"decode\",\"id\":1,\"hex\":\"0012AB05\"} and reply {\"cmd\":\"value\",\"id\":1,\"value\":\"...\"}; once loaded, the data type can be selected in the watch table. Plugins are loaded at startup.",
+	"接收端":            "Sinks",
+	"解码器":            "Decoders",
+	"写入接收端失败: %v":    "Failed to write to sink: %v",
+	"插件":             "Plugins",
+	"插件 %s 没有填写命令":   "Plugin %s has no command",
+	"启动插件 %s 失败: %v": "failed to start plugin %s: %v",
+	"插件 %s 处理过慢，丢弃了%d个事件": "Plugin %s is too slow, dropped %d events",
+	"需要gRPC请求":               "gRPC request required",
+	"未知的方法 %s":               "unknown method %s",
+	"读取请求失败: %v":             "failed to read request: %v",
+	"不支持压缩的消息":               "compressed messages are not supported",
+	"消息长度%d超过上限":             "message length %d exceeds the limit",
+	"gRPC写入 %s = %s":         "gRPC write %s = %s",
+	"推送失败: %v":               "Push failed: %v",
+	"gRPC接口监听于 %s":           "gRPC API listening on %s",
+	"gRPC接口启动失败: %v":         "gRPC API failed to start: %v",
+	"无法编码 %T 类型的值":           "Cannot encode value of type %T",
+	"启用gRPC接口的监听地址，如 :50051": "listen address for the gRPC API, e.g. :50051",
+	"%s需要地址和可选的长度":           "%s requires an address and an optional length",
+	"无效的长度: %q":              "invalid length: %q",
+	"write需要地址和值":            "write requires an address and a value",
+	"scan需要起始地址和长度":          "scan requires a start address and a length",
+	"无效的范围: %s %s":           "invalid range: %s %s",
+	"未知的命令: %s":              "unknown command: %s",
+	"已保存 %s 起的 %d 字节到 %s":    "Saved %[2]d bytes starting at %[1]s to %[3]s",
+	"用法: %s [参数] [子命令]":      "Usage: %s [flags] [subcommand]",
+	"scan命令的输出文件，为空时以十六进制输出到标准输出": "output file for the scan command; prints hex to standard output when empty",
+	"子命令（不打开窗口，参数可写在子命令前后）:\n  read ADDR [LEN]             读取一次，如 read V100 4、read MW10\n  write ADDR VALUE            写入V区，如 write VW200 1234、write V10.3 1\n  monitor ADDR [LEN]          持续监控，如 monitor V100 2 --interval 500ms，按Ctrl+C停止\n  scan START LEN              读取整段存储区（-area，默认V），如 scan 0 4096 --out dump.bin\n  tui ADDR [LEN]              终端界面，显示位网格和 -watch 指定的变量，如 tui V100 16 --watch VW100,VD4:REAL\n退出码: 0成功，1连接或读写PLC失败，2参数错误\n": "Subcommands (no window; flags may appear before or after the subcommand):\n  read ADDR [LEN]             read once, e.g. read V100 4, read MW10\n  write ADDR VALUE            write to the V area, e.g. write VW200 1234, write V10.3 1\n  monitor ADDR [LEN]          monitor continuously, e.g. monitor V100 2 --interval 500ms; press Ctrl+C to stop\n  scan START LEN              read a whole memory range (-area, default V), e.g. scan 0 4096 --out dump.bin\n  tui ADDR [LEN]              terminal UI showing the bit grid and the -watch variables, e.g. tui V100 16 --watch VW100,VD4:REAL\nExit codes: 0 success, 1 PLC connection or read/write failure, 2 invalid arguments\n",
+	"%s  %s 起 %d %s  扫描周期 %v  按Ctrl+C退出": "%[1]s  %[3]d %[4]s from %[2]s  scan interval %[5]v  press Ctrl+C to quit",
+	"个":           "items",
+	"字节":          "bytes",
+	"等待数据…":       "Waiting for data…",
+	"最近读取 %s":     "Last read %s",
+	"未知的数据类型: %s": "unknown data type: %s",
+	"输出格式: hex/dec/bin/json/ndjson，json和ndjson输出带解码值的记录":          "output format: hex/dec/bin/json/ndjson; json and ndjson print records with decoded values",
+	"tui命令显示及json输出解码的变量，逗号分隔，可用“:类型”指定类型，如 VW100,VD4:REAL,M10.0": "variables shown by the tui command and decoded in json output, comma separated, with an optional \":TYPE\", e.g. VW100,VD4:REAL,M10.0",
+	"输出记录失败: %v":      "failed to write record: %v",
+	"S7连接确认并读取订货号":    "Confirm with an S7 connection and read the order code",
+	"输入网段后点击搜索":       "Enter a network range and click Search",
+	"S7连接成功":          "S7 connection OK",
+	"端口开放，S7连接失败: ":   "port open, S7 connection failed: ",
+	"端口102开放":         "port 102 open",
+	"搜索":              "Search",
+	"正在搜索 %s…":        "Searching %s…",
+	"搜索PLC: 发现 %s %s": "PLC search: found %s %s",
+	"搜索已停止":           "Search stopped",
+	"搜索完成，找到 %d 台设备，点击设备填入IP地址": "Search finished, %d devices found; click a device to use its IP address",
+	"网段:":                 "Range:",
+	"搜索PLC":               "Find PLCs",
+	"连接后自动读取":             "Read automatically after connecting",
+	"订货号:":                "Order code:",
+	"固件版本:":               "Firmware:",
+	"模块类型:":               "Module type:",
+	"序列号:":                "Serial number:",
+	"模块名称:":               "Module name:",
+	"系统名称:":               "System name:",
+	"刷新":                  "Refresh",
+	"正在读取…":               "Reading…",
+	"读取CPU信息失败: %v":       "Failed to read CPU info: %v",
+	"读取失败: ":              "Read failed: ",
+	"读取时间 ":               "Read at ",
+	"CPU信息: %s %s，序列号 %s": "CPU info: %s %s, serial number %s",
+	"PLC信息":               "PLC info",
+	"PLC时钟":               "PLC clock",
+	"PLC时间:":              "PLC time:",
+	"相对电脑:":               "Versus PC:",
+	"读取时钟":                "Read clock",
+	"同步时间":                "Sync time",
+	"读取PLC时钟失败: %v":       "Failed to read PLC clock: %v",
+	"将PLC %s 的时钟设为电脑时间？":  "Set the clock of PLC %s to the PC time?",
+	"同步PLC时钟失败: %v":       "Failed to sync PLC clock: %v",
+	"同步失败: ":              "Sync failed: ",
+	"已将PLC %s 的时钟同步为电脑时间": "Synchronized the clock of PLC %s to the PC time",
+	"快 %v":             "%v ahead",
+	"慢 %v":             "%v behind",
+	"一致":               "in sync",
+	"CPU: -":           "CPU: -",
+	"切换到RUN":           "To RUN",
+	"切换到STOP":          "To STOP",
+	"CPU: 不支持":         "CPU: not supported",
+	"CPU: ?":           "CPU: ?",
+	"未知":               "unknown",
+	"输入的IP地址与当前PLC不一致": "the IP address does not match the current PLC",
+	"将PLC %s 切换到 %s，可能使现场设备启动或停止。\n请输入PLC的IP地址确认：": "Switching PLC %s to %s may start or stop field equipment.\nType the PLC IP address to confirm:",
+	"IP地址:":              "IP address:",
+	"切换CPU到%s":           "Switch CPU to %s",
+	"执行":                 "Execute",
+	"切换PLC %s 到%s失败: %v": "Failed to switch PLC %s to %s: %v",
+	"已将PLC %s 切换到%s":     "Switched PLC %s to %s",
+	"含义":                 "Meaning",
+	"读取":                 "Read",
+	"%d 毫秒":              "%d ms",
+	"读取特殊存储器失败: %v":      "Failed to read special memory: %v",
+	"自动刷新（1秒）":           "Auto refresh (1 s)",
+	"特殊存储器":              "Special memory",
+	"始终为1":               "Always on",
+	"首次扫描为1（只在第一个扫描周期接通，通信读取通常看不到）": "First scan (on only during the first scan cycle, rarely seen over communication)",
+	"保持性数据丢失":             "Retentive data lost",
+	"上电后进入RUN的第一个扫描周期为1":  "On for the first scan after power-up into RUN",
+	"1分钟时钟脉冲（30秒1、30秒0）":  "1-minute clock pulse (30 s on, 30 s off)",
+	"1秒时钟脉冲（0.5秒1、0.5秒0）": "1-second clock pulse (0.5 s on, 0.5 s off)",
+	"扫描周期时钟（每个扫描周期翻转）":    "Scan cycle clock (toggles every scan)",
+	"运算结果为0":              "Result is zero",
+	"运算溢出或数值非法":           "Overflow or illegal value",
+	"运算结果为负":              "Result is negative",
+	"填表指令表已满":             "Table full (ATT)",
+	"LIFO/FIFO表为空":        "LIFO/FIFO table empty",
+	"BCD转二进制的数值非法":        "Illegal BCD value in BCD-to-binary",
+	"ASCII转十六进制的字符非法":     "Illegal ASCII character in ASCII-to-hex",
+	"通信中断队列溢出":            "Communication interrupt queue overflow",
+	"输入中断队列溢出":            "Input interrupt queue overflow",
+	"定时中断队列溢出":            "Timed interrupt queue overflow",
+	"运行时编程错误":             "Run-time programming error",
+	"全局中断已允许":             "Global interrupts enabled",
+	"端口0发送器空闲":            "Port 0 transmitter idle",
+	"端口1发送器空闲":            "Port 1 transmitter idle",
+	"有强制的数据":              "Values are forced",
+	"I/O错误":               "I/O error",
+	"上次扫描时间":              "Last scan time",
+	"最短扫描时间":              "Minimum scan time",
+	"最长扫描时间":              "Maximum scan time",
+	"量程":                  "Range",
+	"原始值":                 "Raw",
+	"工程值":                 "Engineering value",
+	"通道数:":                "Channels:",
+	"已写入 %s = %s (%d)":    "Wrote %s = %s (%d)",
+	"读取%s区失败: %v":         "Failed to read %s area: %v",
+	"原始值应为%d到%d的整数":       "raw value must be an integer from %d to %d",
+	"%v 超出量程 %s":          "%v is outside the range %s",
+	"%s 不是%sW地址":          "%s is not an %sW address",
+	"通道数应为0到%d":           "channel count must be 0 to %d",
+	"模拟量输入:":              "Analog inputs:",
+	"模拟量输出:":              "Analog outputs:",
+	"模拟量":                 "Analog",
+	"单极性量程0~27648，双极性-27648~27648；在AQW行输入工程值后点击写入。": "Unipolar ranges map to 0–27648, bipolar ranges to -27648–27648. Enter an engineering value in an AQW row and click Write.",
+	"减计数":      "Counting down",
+	"增计数":      "Counting up",
+	"，当前值>预设值": ", CV > PV",
+	"，当前值=预设值": ", CV = PV",
+	"计数器":      "Counter",
+	"当前值地址":    "Current value address",
+	"每秒变化":     "Change per second",
+	"状态":       "Status",
+	"如 VD500":  "e.g. VD500",
+	"HSC%d 的当前值地址应为双字地址，如 VD500": "the current value address of HSC%d must be a double word, e.g. VD500",
+	"读取高速计数器失败: %v":              "Failed to read high-speed counters: %v",
+	"HC区不能通过通信读取，请在程序中用MOVD把HCx传送到V区（如 MOVD HC0, VD500），并在下面填写该地址。": "The HC area cannot be read over communication. Copy HCx to V memory in the program (e.g. MOVD HC0, VD500) and enter that address below.",
+	"高速计数器": "High-speed counters",
+	"起始编号应为0到255，个数应为1到%d": "the start number must be 0 to 255 and the count 1 to %d",
+	"编号":    "Number",
+	"定时时间":  "Elapsed time",
+	"预设值":   "Preset",
+	"状态位":   "Status bit",
+	" 时基":   " time base",
+	"选填":    "optional",
+	"起始编号:": "Start number:",
+	"个数:":   "Count:",
+	"通信读不到定时器/计数器位，填写预设值后按“当前值≥预设值”推算状态位（TON、TONR、CTU）。": "Timer and counter bits cannot be read over communication. Enter a preset to derive the status bit as current value ≥ preset (TON, TONR, CTU).",
+	"定时器/计数器": "Timers/counters",
+	"只能强制Q、V、M区的位，如 Q0.0、V10.1: %q": "only Q, V and M bits can be forced, e.g. Q0.0, V10.1: %q",
+	"强制 %s = %d":       "Force %s = %d",
+	"释放强制 %s":          "Released force on %s",
+	"已释放全部%d个强制":       "Released all %d forces",
+	"强制写入失败: %v":       "Force write failed: %v",
+	"全部释放":             "Release all",
+	"强制已激活 (%d): %s":   "FORCES ACTIVE (%d): %s",
+	"写入失败: ":           "Write failed: ",
+	"位地址，如 Q0.0、V10.1": "Bit address, e.g. Q0.0, V10.1",
+	"释放":               "Release",
+	"强制":               "Force",
+	"强制的位按扫描周期反复写入，直到释放或断开连接；程序在两次写入之间仍可能改写该位。": "Forced bits are rewritten every scan interval until released or disconnected. The program can still change the bit between writes.",
+	"写入审计记录失败: %v":          "Failed to write audit record: %v",
+	"锁定 %s 失败: %v":          "Failed to lock %s: %v",
+	"第%d行无法解析: %v":          "line %d cannot be parsed: %v",
+	"第%d行的记录被修改，或其前面的记录被删除": "the record on line %d was modified, or records before it were deleted",
+	"失败: ":              "failed: ",
+	"审计文件: ":            "Audit file: ",
+	"读取审计文件失败: ":        "Failed to read audit file: ",
+	"校验":                "Verify",
+	"还没有审计记录":           "No audit records yet",
+	"校验失败: ":            "Verification failed: ",
+	"写入审计文件校验失败: %v":    "Write audit file verification failed: %v",
+	"校验通过，共%d条记录":       "Verified, %d records",
+	"写入审计":              "Write audit",
+	"已生成审计密钥 %s":        "Generated audit key %s",
+	"读取审计密钥失败: %v":      "failed to read audit key: %v",
+	"审计密钥 %s 无效":        "audit key %s is invalid",
+	"写入后延时复核":           "Re-check after write",
+	"正在复核…":             "Re-checking…",
+	"复核读取失败: ":          "Re-check read failed: ",
+	"复核通过：%v后 %s 读回值一致": "Re-check passed: %[2]s unchanged after %[1]v",
+	"复核不一致：%v后 %s 读回 %s，可能被PLC程序改写": "Re-check mismatch: %[2]s reads %[3]s after %[1]v, the PLC program may have overwritten it",
+	"写入 %s 后复核不一致: 写入 %s，读回 %s":     "Re-check mismatch after writing %s: wrote %s, read back %s",
+	"已开启只读模式": "Read-only mode enabled",
+	"已关闭只读模式": "Read-only mode disabled",
+	"只读模式：写入、强制、RUN/STOP和时钟同步已禁用": "Read-only mode: writing, forcing, RUN/STOP and clock sync are disabled",
+	"留空表示不加锁": "Leave empty for no lock",
+	"开启只读模式":  "Enable Read-Only Mode",
+	"开启":      "Enable",
+	"解锁口令:":   "Unlock password:",
+	"只读模式":    "Read-Only Mode",
+	"只读模式由-readonly参数开启，本次运行不能关闭。": "Read-only mode was enabled with -readonly and cannot be turned off in this session.",
+	"口令不正确":              "incorrect password",
+	"读取只读模式设置失败: %v":     "failed to read the read-only mode settings: %v",
+	"解析只读模式设置 %s 失败: %v": "failed to parse the read-only mode settings %s: %v",
+	"保存只读模式设置失败: %v":     "failed to save the read-only mode settings: %v",
+	"%v，按只读模式运行":         "%v; running in read-only mode",
+	"关闭只读模式":             "Disable Read-Only Mode",
+	"解锁":                 "Unlock",
+	"只读模式：禁止写入、强制、RUN/STOP和设置时钟，界面中不能关闭": "read-only mode: disallow writing, forcing, RUN/STOP and setting the clock; cannot be turned off in the GUI",
+	"已写入":                    "Written",
+	"待写入":                    "Pending",
+	"第%d行  %s = %s (%s)  %s": "Line %d  %s = %s (%s)  %s",
+	"读取批量写入文件失败: %v":         "failed to read batch write file: %v",
+	"批量写入文件为空":               "batch write file is empty",
+	"未找到批量写入的表头（需要 地址 和 值 列）": "batch write header not found (address and value columns required)",
+	"无法解析: %v":                "cannot parse: %v",
+	"缺少值":                     "missing value",
+	"批量写入文件中没有数据行":            "no data rows in batch write file",
+	"已打开批量写入文件 %s，共%d行":       "Opened batch write file %s, %d rows",
+	"共%d行，其中%d行有错误，将写入%d行":    "%d rows, %d with errors, %d will be written",
+	"全部写入":                    "Write All",
+	"正在写入...":                 "Writing...",
+	"批量写入 %s 完成: 成功%d行，失败%d行": "Batch write %s finished: %d succeeded, %d failed",
+	"批量写入完成: 成功%d行，失败%d行":     "Batch write finished: %d succeeded, %d failed",
+	"复制报告":                    "Copy Report",
+	"文件: ":                    "File: ",
+	"批量写入":                    "Batch Write",
+	"从CSV批量写入...":             "Batch Write from CSV...",
+	"CSV需含 地址、值 列，类型列可省略，如 VW100,INT,1500": "CSV needs address and value columns, type is optional, e.g. VW100,INT,1500",
+	"connections第%d项缺少ip":                  "connections item %d is missing ip",
+	"配置文件 %s 有误: %v":                       "config file %s is invalid: %v",
+	"无效的轮询组 %q，可选: %s":                     "invalid poll group %q, choices: %s",
+	"%s 无效: %q，可选: %s":                     "%s is invalid: %q, choices: %s",
+	"启动时载入的配置文件（YAML或JSON），定义连接、状态表、轮询组、日志和报警": "config file (YAML or JSON) loaded at startup, defining connections, watch tags, poll groups, logging and alarms",
+	"已载入配置文件 %s": "Loaded config file %s",
+	"持续监控，按Ctrl+C停止；界面中为启动后自动连接并监控第一个标签页": "keep monitoring, press Ctrl+C to stop; in the GUI, connect and monitor the first tab on startup",
+	"没有最近的连接": "No recent connections",
+
+	// 下拉框选项和表头
+	"信息":          "Info",
+	"警告":          "Warning",
+	"错误":          "Error",
+	"全部":          "All",
+	"警告及错误":       "Warnings and errors",
+	"仅错误":         "Errors only",
+	"无符号16位":      "Unsigned 16-bit",
+	"有符号INT":      "Signed INT",
+	"十六进制(字)":     "Hex (word)",
+	"二进制(字节)":     "Binary (byte)",
+	"有符号DINT":     "Signed DINT",
+	"无符号DWORD":    "Unsigned DWORD",
+	"REAL(浮点)":    "REAL (float)",
+	"标准方块":        "Squares",
+	"紧凑(无间隙)":     "Compact (no gaps)",
+	"圆点(LED)":     "Dots (LED)",
+	"变化时发布":       "Publish on change",
+	"定时发布全部":      "Publish all periodically",
+	"15分钟":        "15 minutes",
+	"1小时":         "1 hour",
+	"8小时":         "8 hours",
+	"24小时":        "24 hours",
+	"7天":          "7 days",
+	"1分钟":         "1 minute",
+	"5分钟":         "5 minutes",
+	"30分钟":        "30 minutes",
+	"60分钟":        "60 minutes",
+	"名称":          "Name",
+	"类型":          "Type",
+	"值":           "Value",
+	"注释":          "Comment",
+	"类别":          "Kind",
+	"名称/标签":       "Name/label",
+	"位":           "Bit",
+	"字":           "Word",
+	"变量":          "Variable",
+	"DB1(失败回退MB)": "DB1 (fall back to MB)",
+	"跟随系统":        "Follow system",
+	"浅色":          "Light",
+	"深色":          "Dark",
+	"位7在左(7…0)":   "Bit 7 left (7…0)",
+	"位0在左(0…7)":   "Bit 0 left (0…7)",
+	"仅颜色":         "Color only",
+	"实心圆点":        "Filled dot",
+	"数字1":         "Glyph \"1\"",
+	"色盲友好(蓝/灰)":   "Colorblind-safe (blue/grey)",
+	"高对比(黄/黑)":    "High contrast (yellow/black)",
+	"仅DB1":        "DB1 only",
+	"仅MB":         "MB only",
+	"大端(ABCD)":    "Big endian (ABCD)",
+	"小端(DCBA)":    "Little endian (DCBA)",
+	"字交换(CDAB)":   "Word swap (CDAB)",
+	"字节交换(BADC)":  "Byte swap (BADC)",
+
+	// s7viewer返回的错误类别和操作
+	"连接已断开":       "connection lost",
+	"请求超时":        "request timed out",
+	"地址超出范围":      "address out of range",
+	"访问被拒绝":       "access denied",
+	"读取V区(DB1方式)": "Read V area (DB1)",
+	"读取V区(MB方式)":  "Read V area (MB)",
+	"写入V区":        "Write V area",
+	"读取V区":        "Read V area",
+	"读取I区":        "Read I area",
+	"读取Q区":        "Read Q area",
+	"读取M区":        "Read M area",
+	"读取SM区":       "Read SM area",
+	"读取T区":        "Read T area",
+	"读取C区":        "Read C area",
+	"读取AI区":       "Read AI area",
+	"读取AQ区":       "Read AQ area",
+}
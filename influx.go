@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// influxConfig InfluxDB v2写入设置
+type influxConfig struct {
+	URL       string // 如 http://localhost:8086
+	Org       string
+	Bucket    string
+	Token     string
+	BatchSize int // 累积多少个数据点后写入一次
+}
+
+// influxFlushInterval 不足一批的数据点最迟在该时间后写入
+const influxFlushInterval = 10 * time.Second
+
+// influxMaxBatches 缓冲的数据点上限（批大小的倍数），InfluxDB写入过慢时丢弃新的数据点，避免缓冲无限增长
+const influxMaxBatches = 10
+
+// influxWriter 将监控数据转换为行协议，由后台协程按批写入InfluxDB v2的 /api/v2/write。
+// 监控协程中调用add只追加到缓冲，不等待HTTP请求
+type influxWriter struct {
+	cfg    influxConfig
+	client *http.Client
+	kick   chan struct{} // 缓冲满一批或需要立即写入时通知后台协程
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu      sync.Mutex
+	lines   []string
+	last    map[string]float64 // 设置了死区时已写入的值
+	dropped int                // 缓冲已满时丢弃的数据点数，恢复后记录日志
+}
+
+func newInfluxWriter(cfg influxConfig) *influxWriter {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	w := &influxWriter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+		kick:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		last:   make(map[string]float64),
+	}
+	go w.run()
+	return w
+}
+
+// run 缓冲满一批、收到写入请求或定时写入缓冲的数据点，close后写出剩余的数据点
+func (w *influxWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(influxFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			if err := w.flush(); err != nil {
+				log.Printf("%v", err)
+			}
+			return
+		case <-w.kick:
+		case <-ticker.C:
+		}
+		if err := w.flush(); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+}
+
+// add 追加一次扫描的所有变量值（db不为nil时只追加变化超过死区的），累积到批大小后通知后台协程写入，不阻塞
+func (w *influxWriter) add(plc string, t time.Time, values map[string]string, db deadbands) {
+	if db != nil {
+		w.mu.Lock()
+		values = db.filter(w.last, values)
+		w.mu.Unlock()
+	}
+	tags := make([]string, 0, len(values))
+	for tag := range values {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	w.mu.Lock()
+	for _, tag := range tags {
+		if len(w.lines) >= w.cfg.BatchSize*influxMaxBatches {
+			w.dropped++
+			continue
+		}
+		w.lines = append(w.lines, influxLine(plc, tag, values[tag], t))
+	}
+	full := len(w.lines) >= w.cfg.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		w.requestFlush()
+	}
+}
+
+// influxLine 一个数据点的行协议
+func influxLine(plc, tag, value string, t time.Time) string {
+	return fmt.Sprintf("plc_value,plc=%s,tag=%s value=%s %d", escapeInfluxTag(plc), escapeInfluxTag(tag), value, t.UnixMilli())
+}
+
+// requestFlush 通知后台协程写入缓冲的数据点，不等待写入完成
+func (w *influxWriter) requestFlush() {
+	select {
+	case w.kick <- struct{}{}:
+	default:
+	}
+}
+
+// close 写出剩余的数据点并停止后台协程
+func (w *influxWriter) close() {
+	close(w.stop)
+	<-w.done
+}
+
+// flush 写入缓冲的数据点，只在后台协程中调用。写入失败时丢弃本批，避免缓冲无限增长
+func (w *influxWriter) flush() error {
+	w.mu.Lock()
+	lines := w.lines
+	w.lines = nil
+	if w.dropped > 0 {
+		log.Printf(tr("InfluxDB写入过慢，丢弃了%d个数据点"), w.dropped)
+		w.dropped = 0
+	}
+	w.mu.Unlock()
+	if len(lines) == 0 {
+		return nil
+	}
+
+	q := url.Values{"org": {w.cfg.Org}, "bucket": {w.cfg.Bucket}, "precision": {"ms"}}
+	endpoint := strings.TrimRight(w.cfg.URL, "/") + "/api/v2/write?" + q.Encode()
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBufferString(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+w.cfg.Token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf(tr("写入InfluxDB失败: %v"), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf(tr("写入InfluxDB失败: %s %s"), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func escapeInfluxTag(s string) string {
+	return influxTagEscaper.Replace(s)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInfluxLine(t *testing.T) {
+	at := time.UnixMilli(1700000000123)
+	tests := []struct {
+		plc, tag, value string
+		want            string
+	}{
+		{"192.168.2.1", "VW100", "1234", "plc_value,plc=192.168.2.1,tag=VW100 value=1234 1700000000123"},
+		{"192.168.2.1", "V100.3", "1", "plc_value,plc=192.168.2.1,tag=V100.3 value=1 1700000000123"},
+		{"line 1", "a=b,c", "-2.5", `plc_value,plc=line\ 1,tag=a\=b\,c value=-2.5 1700000000123`},
+	}
+	for _, tt := range tests {
+		if got := influxLine(tt.plc, tt.tag, tt.value, at); got != tt.want {
+			t.Errorf("influxLine(%q, %q, %q) = %q, want %q", tt.plc, tt.tag, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestInfluxWriterFlushesBatch(t *testing.T) {
+	type request struct {
+		query, auth, body string
+	}
+	got := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{r.URL.RawQuery, r.Header.Get("Authorization"), string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w := newInfluxWriter(influxConfig{URL: srv.URL + "/", Org: "plant", Bucket: "plc", Token: "secret", BatchSize: 2})
+	defer w.close()
+	at := time.UnixMilli(1000)
+	w.add("10.0.0.1", at, map[string]string{"VW2": "2", "VW0": "1"}, nil)
+
+	select {
+	case r := <-got:
+		if r.query != "bucket=plc&org=plant&precision=ms" {
+			t.Errorf("query = %q", r.query)
+		}
+		if r.auth != "Token secret" {
+			t.Errorf("Authorization = %q", r.auth)
+		}
+		want := "plc_value,plc=10.0.0.1,tag=VW0 value=1 1000\nplc_value,plc=10.0.0.1,tag=VW2 value=2 1000"
+		if r.body != want {
+			t.Errorf("body = %q, want %q", r.body, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("full batch not written within 2s")
+	}
+}
+
+func TestInfluxWriterAddDoesNotBlock(t *testing.T) {
+	// InfluxDB不应答，后台协程卡在写入中
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	w := newInfluxWriter(influxConfig{URL: srv.URL, Org: "plant", Bucket: "plc", BatchSize: 1})
+	defer w.close()
+	defer close(release)
+
+	begin := time.Now()
+	for i := range influxMaxBatches * 4 {
+		w.add("10.0.0.1", time.Now(), map[string]string{"VW0": strings.Repeat("1", i+1)}, nil)
+	}
+	if d := time.Since(begin); d > time.Second {
+		t.Errorf("add blocked for %v", d)
+	}
+	w.mu.Lock()
+	buffered, dropped := len(w.lines), w.dropped
+	w.mu.Unlock()
+	if buffered > influxMaxBatches || dropped == 0 {
+		t.Errorf("buffered %d points, dropped %d; want at most %d buffered and some dropped", buffered, dropped, influxMaxBatches)
+	}
+}
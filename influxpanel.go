@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// InfluxDB设置的偏好键（令牌不保存）
+const (
+	prefInfluxURL    = "influx.url"
+	prefInfluxOrg    = "influx.org"
+	prefInfluxBucket = "influx.bucket"
+	prefInfluxBatch  = "influx.batch"
+)
+
+// newInfluxPanel 创建InfluxDB写入设置面板。current返回启用中的写入器，未启用时为nil，
+// 可在监控协程中调用。
+func newInfluxPanel(prefs fyne.Preferences) (content fyne.CanvasObject, current func() *influxWriter) {
+	var mu sync.Mutex
+	var writer *influxWriter
+	current = func() *influxWriter {
+		mu.Lock()
+		defer mu.Unlock()
+		return writer
+	}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(prefs.StringWithFallback(prefInfluxURL, "http://localhost:8086"))
+	orgEntry := widget.NewEntry()
+	orgEntry.SetText(prefs.String(prefInfluxOrg))
+	bucketEntry := widget.NewEntry()
+	bucketEntry.SetText(prefs.StringWithFallback(prefInfluxBucket, "plc"))
+	tokenEntry := widget.NewPasswordEntry()
+	batchEntry := widget.NewEntry()
+	batchEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefInfluxBatch, 1000)))
+	statusLabel := widget.NewLabel(tr("InfluxDB: 未启用"))
+
+	enableCheck := widget.NewCheck(tr("写入InfluxDB（监控时）"), func(enabled bool) {
+		mu.Lock()
+		old := writer
+		writer = nil
+		mu.Unlock()
+		if old != nil {
+			go old.close()
+		}
+		if !enabled {
+			statusLabel.SetText(tr("InfluxDB: 未启用"))
+			return
+		}
+
+		batch, err := strconv.Atoi(strings.TrimSpace(batchEntry.Text))
+		cfg := influxConfig{
+			URL:       strings.TrimSpace(urlEntry.Text),
+			Org:       strings.TrimSpace(orgEntry.Text),
+			Bucket:    strings.TrimSpace(bucketEntry.Text),
+			Token:     strings.TrimSpace(tokenEntry.Text),
+			BatchSize: batch,
+		}
+		if err != nil || batch <= 0 || cfg.URL == "" || cfg.Org == "" || cfg.Bucket == "" {
+			statusLabel.SetText(tr("InfluxDB: 地址、组织、存储桶或批大小无效"))
+			return
+		}
+		prefs.SetString(prefInfluxURL, cfg.URL)
+		prefs.SetString(prefInfluxOrg, cfg.Org)
+		prefs.SetString(prefInfluxBucket, cfg.Bucket)
+		prefs.SetInt(prefInfluxBatch, batch)
+
+		mu.Lock()
+		writer = newInfluxWriter(cfg)
+		mu.Unlock()
+		statusLabel.SetText(tr("InfluxDB: 写入 ") + cfg.URL + " / " + cfg.Bucket)
+	})
+
+	content = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(tr("地址:"), urlEntry),
+			widget.NewFormItem(tr("组织 (org):"), orgEntry),
+			widget.NewFormItem(tr("存储桶 (bucket):"), bucketEntry),
+			widget.NewFormItem(tr("令牌 (token):"), tokenEntry),
+			widget.NewFormItem(tr("批大小 (数据点):"), batchEntry),
+		),
+		widget.NewLabel(tr("测量名 plc_value，标签 plc、tag（如 V100.3、VW100），字段 value。")),
+		enableCheck,
+		statusLabel,
+	)
+	return content, current
+}
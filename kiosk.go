@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 看板模式参数
+const (
+	kioskRetryInterval = 10 * time.Second // 未连接或未监控时重试的间隔
+	kioskMaxBytes      = 32               // 没有位标签时最多显示的字节数
+	kioskColumns       = 16               // 没有位标签时每行显示的位数
+	kioskHeaderSize    = 28
+	kioskLabelSize     = 36 // 有位标签时方块中文字的大小
+	kioskAddressSize   = 16 // 没有位标签时方块中地址的大小
+)
+
+// kioskTile 看板上的一个位：背景颜色表示值，文字为标签或地址
+type kioskTile struct {
+	addr s7Address
+	bg   *canvas.Rectangle
+	text *canvas.Text
+}
+
+// kioskView 全屏看板：隐藏所有输入控件，已加标签的位（没有标签时为整个网格）铺满屏幕，
+// 顶部显示PLC地址和连接状态。数据和状态由plcPanel在UI线程中推送。
+type kioskView struct {
+	content fyne.CanvasObject
+	labels  map[string]string
+	header  *canvas.Text
+	status  *canvas.Circle
+	body    *fyne.Container
+	tiles   []kioskTile
+	key     string // 当前方块对应的区域、起始地址和长度，变化时重建
+}
+
+func newKioskView(ip string, labels map[string]string) *kioskView {
+	k := &kioskView{
+		labels: labels,
+		header: canvas.NewText(ip, theme.Color(theme.ColorNameForeground)),
+		status: canvas.NewCircle(colorBitOff),
+		body:   container.NewStack(),
+	}
+	k.header.TextSize = kioskHeaderSize
+	k.header.TextStyle.Bold = true
+	k.content = container.NewBorder(
+		container.NewHBox(container.NewGridWrap(fyne.NewSquareSize(kioskHeaderSize), k.status), k.header),
+		nil, nil, nil, k.body)
+	return k
+}
+
+// setStatus 更新顶部的连接状态
+func (k *kioskView) setStatus(c color.Color, text string) {
+	k.status.FillColor = c
+	k.status.Refresh()
+	k.header.Text = text
+	k.header.Refresh()
+}
+
+// update 按最新数据设置每个方块的颜色，区域、起始地址或长度变化时重建方块
+func (k *kioskView) update(area string, start int, data []byte) {
+	if key := fmt.Sprintf("%s%d/%d", area, start, len(data)); key != k.key {
+		k.key = key
+		k.build(area, start, len(data))
+	}
+	for _, t := range k.tiles {
+		off := t.addr.byteOff - start
+		c := colorBitOff
+		if off >= 0 && off < len(data) && (data[off]>>t.addr.bit)&1 == 1 {
+			c = colorBitOn
+		}
+		t.bg.FillColor = c
+		t.bg.Refresh()
+		t.text.Color = contrastColor(c)
+		t.text.Refresh()
+	}
+}
+
+// build 有位标签时为该区域内已加标签的位各建一个大方块，否则按网格顺序显示前kioskMaxBytes字节
+func (k *kioskView) build(area string, start, length int) {
+	var addrs []s7Address
+	textSize := float32(kioskLabelSize)
+	for name := range k.labels {
+		a, err := parseAddress(name)
+		// T/C按编号寻址，没有位
+		if err == nil && a.area == area && a.size == "" && area != s7viewer.AreaT && area != s7viewer.AreaC &&
+			a.byteOff >= start && a.byteOff < start+length {
+			addrs = append(addrs, a)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		if addrs[i].byteOff != addrs[j].byteOff {
+			return addrs[i].byteOff < addrs[j].byteOff
+		}
+		return addrs[i].bit < addrs[j].bit
+	})
+	cols := kioskColumns
+	if len(addrs) == 0 {
+		textSize = kioskAddressSize
+		for i := 0; i < min(length, kioskMaxBytes)*8; i++ {
+			bit := 7 - i%8
+			if bitZeroLeft {
+				bit = i % 8
+			}
+			addrs = append(addrs, s7Address{area: area, byteOff: start + i/8, bit: bit})
+		}
+	} else {
+		// 方块数较少时按接近正方形排列
+		cols = 1
+		for cols*cols < len(addrs) {
+			cols++
+		}
+	}
+
+	k.tiles = k.tiles[:0]
+	objects := make([]fyne.CanvasObject, 0, len(addrs))
+	for _, a := range addrs {
+		name := a.String()
+		if label := k.labels[name]; label != "" {
+			name = label
+		}
+		t := kioskTile{addr: a, bg: canvas.NewRectangle(colorBitOff), text: canvas.NewText(name, color.Black)}
+		t.bg.StrokeColor = color.Black
+		t.bg.StrokeWidth = 2
+		t.text.TextSize = textSize
+		t.text.TextStyle.Bold = true
+		k.tiles = append(k.tiles, t)
+		objects = append(objects, container.NewStack(t.bg, container.NewCenter(t.text)))
+	}
+	k.body.Objects = []fyne.CanvasObject{container.NewGridWithColumns(max(1, cols), objects...)}
+	k.body.Refresh()
+}
+
+// startKiosk 将窗口切换为全屏看板显示p的数据，按Esc退出并调用onExit恢复原来的界面。
+// 看板期间每隔kioskRetryInterval检查一次，未连接时重新连接、未监控时开始监控，直到退出。
+func startKiosk(win fyne.Window, p *plcPanel, onExit func()) {
+	k := newKioskView(p.ip(), p.bitLabels())
+	k.setStatus(colorBitOff, p.ip())
+	p.onData = k.update
+	p.onStatus = k.setStatus
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(kioskRetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				fyne.Do(p.ensureMonitoring)
+			}
+		}
+	}()
+	p.ensureMonitoring()
+
+	c := win.Canvas()
+	prevKey := c.OnTypedKey()
+	c.SetOnTypedKey(func(e *fyne.KeyEvent) {
+		if e.Name != fyne.KeyEscape {
+			return
+		}
+		close(stop)
+		p.onData, p.onStatus = nil, nil
+		c.SetOnTypedKey(prevKey)
+		win.SetFullScreen(false)
+		onExit()
+	})
+	win.SetMainMenu(nil)
+	win.SetContent(k.content)
+	win.SetFullScreen(true)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// layoutEntry 数据块定义中的一个变量
+type layoutEntry struct {
+	Name    string
+	Addr    s7Address
+	Type    string
+	StrLen  int // 仅STRING使用，最大字符数
+	Comment string
+}
+
+// width 变量占用的字节数
+func (e layoutEntry) width() int {
+	return s7viewer.TypeWidth(e.Type, e.StrLen)
+}
+
+// 表头列名（中英文均可），用于识别CSV列
+var (
+	layoutNameColumns    = []string{"名称", "符号", "变量名", "name", "symbol"}
+	layoutAddressColumns = []string{"地址", "偏移", "偏移量", "address", "offset"}
+	layoutTypeColumns    = []string{"类型", "数据类型", "type", "datatype", "data type"}
+	layoutCommentColumns = []string{"注释", "描述", "comment", "description"}
+)
+
+// stringTypePattern 匹配 STRING、STRING[20]、STRING(20)
+var stringTypePattern = regexp.MustCompile(`^STRING(?:[\[(](\d+)[\])])?$`)
+
+// parseDataBlockLayout 解析从 STEP 7-Micro/WIN SMART 导出的数据块定义。
+// 支持两种格式：
+//   - 带表头的CSV/制表符文本，列为 名称/地址/类型/注释（类型列可省略，按地址宽度推断）
+//   - 数据块编辑器文本，每行 "地址 初始值 // 注释"，注释作为变量名
+//
+// 无法识别的行和不支持的类型不会中断解析，而是逐条记录在problems中。
+func parseDataBlockLayout(r io.Reader) (entries []layoutEntry, problems []string, err error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf(tr("读取数据块定义失败: %v"), err)
+	}
+
+	// 第一个非空、非注释行决定格式
+	first := -1
+	for i, line := range lines {
+		if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "//") && !strings.HasPrefix(t, "#") {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return nil, nil, errors.New(tr("数据块定义为空"))
+	}
+
+	if delim, cols, ok := detectLayoutHeader(lines[first]); ok {
+		entries, problems = parseLayoutTable(lines[first+1:], first+2, delim, cols)
+	} else {
+		entries, problems = parseLayoutDataBlock(lines)
+	}
+	if len(entries) == 0 {
+		return nil, problems, errors.New(tr("未找到可用的变量定义"))
+	}
+	return entries, problems, nil
+}
+
+// layoutColumns 各列在表格中的下标，-1表示不存在
+type layoutColumns struct {
+	name, address, dataType, comment int
+}
+
+// detectLayoutHeader 识别表头行的分隔符和列位置，至少需要地址列
+func detectLayoutHeader(line string) (rune, layoutColumns, bool) {
+	for _, delim := range []rune{'\t', ',', ';'} {
+		if !strings.ContainsRune(line, delim) {
+			continue
+		}
+		cols := layoutColumns{-1, -1, -1, -1}
+		for i, field := range strings.Split(line, string(delim)) {
+			field = strings.ToLower(strings.Trim(strings.TrimSpace(field), `"`))
+			switch {
+			case containsString(layoutNameColumns, field):
+				cols.name = i
+			case containsString(layoutAddressColumns, field):
+				cols.address = i
+			case containsString(layoutTypeColumns, field):
+				cols.dataType = i
+			case containsString(layoutCommentColumns, field):
+				cols.comment = i
+			}
+		}
+		if cols.address >= 0 {
+			return delim, cols, true
+		}
+	}
+	return 0, layoutColumns{}, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLayoutTable 按表头列位置解析CSV/制表符格式的数据行
+func parseLayoutTable(lines []string, firstLineNo int, delim rune, cols layoutColumns) ([]layoutEntry, []string) {
+	var entries []layoutEntry
+	var problems []string
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for i, line := range lines {
+		lineNo := firstLineNo + i
+		if t := strings.TrimSpace(line); t == "" || strings.HasPrefix(t, "//") || strings.HasPrefix(t, "#") {
+			continue
+		}
+
+		cr := csv.NewReader(strings.NewReader(line))
+		cr.Comma = delim
+		cr.LazyQuotes = true
+		cr.FieldsPerRecord = -1
+		record, err := cr.Read()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf(tr("第%d行: 无法解析: %v"), lineNo, err))
+			continue
+		}
+
+		entry, err := newLayoutEntry(field(record, cols.name), field(record, cols.address), field(record, cols.dataType), "")
+		if err != nil {
+			problems = append(problems, fmt.Sprintf(tr("第%d行: %v"), lineNo, err))
+			continue
+		}
+		entry.Comment = field(record, cols.comment)
+		entries = append(entries, entry)
+	}
+	return entries, problems
+}
+
+// parseLayoutDataBlock 解析数据块编辑器文本格式："VW2  1000  // 注释"
+func parseLayoutDataBlock(lines []string) ([]layoutEntry, []string) {
+	var entries []layoutEntry
+	var problems []string
+
+	for i, line := range lines {
+		lineNo := i + 1
+		body, comment := line, ""
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			body, comment = line[:idx], strings.TrimSpace(line[idx+2:])
+		}
+		fields := strings.Fields(body)
+		if len(fields) == 0 {
+			continue
+		}
+
+		initial := ""
+		if len(fields) > 1 {
+			initial = strings.Join(fields[1:], " ")
+		}
+		entry, err := newLayoutEntry(comment, fields[0], "", initial)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf(tr("第%d行: %v"), lineNo, err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, problems
+}
+
+// newLayoutEntry 由地址和类型文本构造变量定义。类型为空时按地址宽度推断，
+// VD地址的初始值含小数点时推断为REAL，VB地址的初始值带引号时推断为STRING。
+func newLayoutEntry(name, address, dataType, initial string) (layoutEntry, error) {
+	dataType = strings.ToUpper(strings.ReplaceAll(dataType, " ", ""))
+	strLen := 0
+	if m := stringTypePattern.FindStringSubmatch(dataType); m != nil {
+		dataType = s7viewer.TypeString
+		if m[1] != "" {
+			strLen, _ = strconv.Atoi(m[1])
+		}
+	}
+
+	addr, err := parseLayoutAddress(address, dataType)
+	if err != nil {
+		return layoutEntry{}, err
+	}
+
+	if dataType == "" {
+		switch addr.size {
+		case "":
+			dataType = s7viewer.TypeBool
+		case "B":
+			dataType = s7viewer.TypeByte
+			if s := strings.Trim(initial, `'"`); len(initial) >= 2 && s != initial {
+				dataType = s7viewer.TypeString
+				strLen = len(s)
+			}
+		case "W":
+			dataType = s7viewer.TypeInt
+		case "D":
+			dataType = s7viewer.TypeDInt
+			if strings.Contains(initial, ".") {
+				dataType = s7viewer.TypeReal
+			}
+		}
+	}
+
+	switch dataType {
+	case s7viewer.TypeBool, s7viewer.TypeByte, s7viewer.TypeWord, s7viewer.TypeInt, s7viewer.TypeDWord, s7viewer.TypeDInt, s7viewer.TypeReal, s7viewer.TypeString:
+	default:
+		return layoutEntry{}, fmt.Errorf(tr("不支持的类型 %q（支持 BOOL/BYTE/WORD/INT/DWORD/DINT/REAL/STRING）"), dataType)
+	}
+
+	// 地址宽度与类型必须一致
+	if dataType == s7viewer.TypeBool && addr.size != "" {
+		return layoutEntry{}, fmt.Errorf(tr("BOOL 需要位地址，实际为 %s"), addr)
+	}
+	if dataType != s7viewer.TypeBool && addr.size == "" {
+		return layoutEntry{}, fmt.Errorf(tr("%s 不能使用位地址 %s"), dataType, addr)
+	}
+	if dataType != s7viewer.TypeString && dataType != s7viewer.TypeBool && addr.width() != s7viewer.TypeWidth(dataType, 0) {
+		return layoutEntry{}, fmt.Errorf(tr("地址 %s 的宽度与类型 %s 不符"), addr, dataType)
+	}
+
+	if name == "" {
+		name = addr.String()
+	}
+	return layoutEntry{Name: name, Addr: addr, Type: dataType, StrLen: strLen}, nil
+}
+
+// parseLayoutAddress 解析地址列，既接受 VW100、V10.2 形式，也接受纯偏移 100、10.2（按类型补全宽度）
+func parseLayoutAddress(address, dataType string) (s7Address, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return s7Address{}, errors.New(tr("缺少地址"))
+	}
+	if !strings.HasPrefix(strings.ToUpper(address), "V") {
+		if _, err := strconv.ParseFloat(address, 64); err != nil {
+			return s7Address{}, fmt.Errorf(tr("无效的地址: %q"), address)
+		}
+		prefix := "VB"
+		switch {
+		case strings.Contains(address, "."):
+			prefix = "V"
+		case s7viewer.TypeWidth(dataType, 0) == 2:
+			prefix = "VW"
+		case s7viewer.TypeWidth(dataType, 0) == 4:
+			prefix = "VD"
+		}
+		address = prefix + address
+	}
+	return parseVAddress(address)
+}
+
+// layoutSpan 返回覆盖所有变量的字节范围
+func layoutSpan(entries []layoutEntry) (start, size int) {
+	if len(entries) == 0 {
+		return 0, 0
+	}
+	start, end := entries[0].Addr.byteOff, 0
+	for _, e := range entries {
+		if e.Addr.byteOff < start {
+			start = e.Addr.byteOff
+		}
+		if last := e.Addr.byteOff + e.width(); last > end {
+			end = last
+		}
+	}
+	return start, end - start
+}
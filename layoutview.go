@@ -0,0 +1,148 @@
+package main
+
+import (
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+var layoutTableHeaders = []string{"名称", "地址", "类型", "值", "注释"}
+
+// layoutTable 结构化视图，按导入的数据块定义逐项显示变量的当前值
+type layoutTable struct {
+	entries []layoutEntry
+	values  []string
+	table   *widget.Table
+	mu      sync.Mutex // 监控协程通过span读取entries
+
+	// labelOf 返回地址的位标签，变量没有注释时在注释列显示
+	labelOf func(addr string) string
+
+	// 最近一次用于解码的数据，供指标导出
+	lastStart int
+	lastData  []byte
+}
+
+func newLayoutTable() *layoutTable {
+	t := &layoutTable{}
+	t.table = widget.NewTable(
+		func() (int, int) { return t.count(), len(layoutTableHeaders) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(t.cellText(id.Row, id.Col))
+		})
+	t.table.ShowHeaderRow = true
+	t.table.CreateHeader = func() fyne.CanvasObject { return widget.NewLabel("") }
+	t.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 && id.Col < len(layoutTableHeaders) {
+			o.(*widget.Label).SetText(tr(layoutTableHeaders[id.Col]))
+		}
+	}
+	for col, width := range []float32{160, 90, 90, 160, 240} {
+		t.table.SetColumnWidth(col, width)
+	}
+	return t
+}
+
+func (t *layoutTable) cellText(row, col int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if row < 0 || row >= len(t.entries) {
+		return ""
+	}
+	e := t.entries[row]
+	switch col {
+	case 0:
+		return e.Name
+	case 1:
+		return e.Addr.String()
+	case 2:
+		return e.Type
+	case 3:
+		return t.values[row]
+	case 4:
+		if e.Comment == "" && t.labelOf != nil {
+			return t.labelOf(e.Addr.String())
+		}
+		return e.Comment
+	}
+	return ""
+}
+
+func (t *layoutTable) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.entries)
+}
+
+// currentEntries 返回当前导入的变量定义
+func (t *layoutTable) currentEntries() []layoutEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.entries
+}
+
+// snapshot 返回变量定义和最近一次解码使用的数据
+func (t *layoutTable) snapshot() ([]layoutEntry, int, []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.entries, t.lastStart, t.lastData
+}
+
+// valueRows 返回各变量最近一次的值，用于快照
+func (t *layoutTable) valueRows() []snapshotValue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rows := make([]snapshotValue, len(t.entries))
+	for i, e := range t.entries {
+		rows[i] = snapshotValue{Name: e.Name, Address: e.Addr.String(), Type: e.Type, Value: t.values[i]}
+	}
+	return rows
+}
+
+// span 返回覆盖所有变量的字节范围，未导入时size为0
+func (t *layoutTable) span() (start, size int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return layoutSpan(t.entries)
+}
+
+// items 返回每个变量对应的V区读取项，变量分散时用于批量读取
+func (t *layoutTable) items() []s7viewer.Item {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	items := make([]s7viewer.Item, len(t.entries))
+	for i, e := range t.entries {
+		items[i] = s7viewer.Item{Area: s7viewer.AreaV, Start: e.Addr.byteOff, Size: e.width()}
+	}
+	return items
+}
+
+// setEntries 替换表格中的变量定义，值清空等待下次读取
+func (t *layoutTable) setEntries(entries []layoutEntry) {
+	t.mu.Lock()
+	t.entries = entries
+	t.values = make([]string, len(entries))
+	t.lastData = nil
+	t.mu.Unlock()
+	t.table.Refresh()
+}
+
+// update 用从startAddress开始读取的数据按order的字节顺序解码每个变量
+func (t *layoutTable) update(order string, startAddress int, data []byte) {
+	t.mu.Lock()
+	t.lastStart, t.lastData = startAddress, data
+	for i, e := range t.entries {
+		v, err := s7viewer.DecodeValue(order, e.Type, data, e.Addr.byteOff-startAddress, e.Addr.bit, e.StrLen)
+		if err != nil {
+			v = tr("错误: ") + err.Error()
+		}
+		t.values[i] = v
+	}
+	t.mu.Unlock()
+	t.table.Refresh()
+}
@@ -1,123 +1,51 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"image/color"
+	"io"
 	"log"
 	"os"
-	"strconv"
-	"strings"
-	"sync"
-	"time"
+	"path/filepath"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/widget"
-	"github.com/robinson/gos7"
-)
+	"fyne.io/fyne/v2/dialog"
 
-const (
-	defaultRack = 0
-	defaultSlot = 1
+	"plc-binary-viewer/pkg/s7viewer"
 )
 
-type PLCBinaryViewer struct {
-	client   gos7.Client
-	handler  *gos7.TCPClientHandler
-	running  bool
-	stopChan chan bool
-	mu       sync.Mutex
-}
-
-func NewPLCBinaryViewer() *PLCBinaryViewer {
-	return &PLCBinaryViewer{
-		stopChan: make(chan bool),
-	}
-}
-
-func (p *PLCBinaryViewer) connectPLC(ip string) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	// 如果已存在连接，先断开
-	if p.client != nil {
-		p.disconnectPLC()
-		// 等待一小段时间确保连接完全断开
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	handler := gos7.NewTCPClientHandler(ip, defaultRack, defaultSlot)
-	handler.Timeout = 5 * time.Second
-	handler.IdleTimeout = 60 * time.Second
-	handler.Logger = log.New(os.Stdout, "s7: ", log.LstdFlags)
-
-	if err := handler.Connect(); err != nil {
-		return fmt.Errorf("连接PLC失败: %v", err)
-	}
+const defaultIP = "192.168.1.11"
 
-	p.handler = handler
-	p.client = gos7.NewClient(handler)
-	return nil
-}
-
-func (p *PLCBinaryViewer) disconnectPLC() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+const windowTitle = "S7-200 Smart V区二进制显示器 @Yuanxin E: wax_wane@qq.com "
 
-	if p.client != nil {
-		// 先断开客户端连接
-		if p.handler != nil {
-			p.handler.Close()
-		}
-		p.client = nil
-		p.handler = nil
-	}
-}
-
-func (p *PLCBinaryViewer) readVArea(startByte int, size int) ([]byte, error) {
-	p.mu.Lock()
-	client := p.client
-	p.mu.Unlock()
-
-	if client == nil {
-		return nil, fmt.Errorf("PLC未连接")
-	}
-
-	buffer := make([]byte, size)
+// 偏好设置键
+const (
+	prefGridStyle = "grid.style"
+	prefGridSize  = "grid.cellSize"
+	prefVAccess   = "plc.vAccess"
+	prefMutedRows = "grid.mutedRows"
+	prefMutedCols = "grid.mutedCols"
+	prefScanMs    = "monitor.intervalMs"
+	prefPLCTabs   = "plc.tabs"
+	prefFlashMs   = "grid.flashMs"
+	prefCoalesce  = "watch.coalesceGap"
+	prefLanguage  = "ui.language"
+	prefGridCols  = "grid.cols"
+	prefGridRows  = "grid.rows"
+	prefBitOrder  = "grid.bitOrder"
+
+	prefRegisterFormat = "display.registerFormat"
+	prefByteOrder      = "display.byteOrder"
+)
 
-	// 尝试通过DB1访问V区（S7-200 Smart的V区映射到DB1）
-	if err := client.AGReadDB(1, startByte, size, buffer); err != nil {
-		// 如果DB1方式失败，尝试直接MB方式
-		if err2 := client.AGReadMB(startByte, size, buffer); err2 != nil {
-			return nil, fmt.Errorf("读取V区失败: %v, MB方式失败: %v", err, err2)
-		}
+func boolToInt(b bool) int {
+	if b {
+		return 1
 	}
-	return buffer, nil
-}
-
-// readOnce 单次读取数据，返回原始字节数据
-func (p *PLCBinaryViewer) readOnce(startAddress int, length int) ([]byte, error) {
-	// 根据长度计算需要读取的字节数
-	bytesToRead := length
-	if bytesToRead <= 0 {
-		bytesToRead = 1
-	}
-
-	// 限制最大读取字节数（不超过32*20=640位，即80字节）
-	maxBytes := 80 // 640位 / 8位/字节
-	if bytesToRead > maxBytes {
-		bytesToRead = maxBytes
-	}
-
-	// 直接读取字节数据
-	data, err := p.readVArea(startAddress, bytesToRead)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
+	return 0
 }
 
 // convertBytesTo16BitInts 将字节数组按16位分组转换为十进制数值
@@ -137,277 +65,316 @@ func convertBytesTo16BitInts(bytes []byte) []int {
 	return result
 }
 
-func (p *PLCBinaryViewer) startMonitoring(startAddress int, length int, updateFunc func([]bool)) {
-	p.mu.Lock()
-	if p.running {
-		p.mu.Unlock()
-		return
+func main() {
+	headless := flag.Bool("headless", false, tr("无界面模式：连接PLC读取或监控后输出到标准输出"))
+	kiosk := flag.Bool("kiosk", false, tr("启动后以全屏看板模式显示第一个标签页的PLC，自动连接并监控，按Esc退出"))
+	openPath := flag.String("open", "", tr("启动后离线查看录制文件（.rec）或快照文件（JSON/CSV），不需要连接PLC"))
+	cliOpts := registerCLIFlags(flag.CommandLine)
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, tr("用法: %s [参数] [子命令]")+"\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+		fmt.Fprint(out, tr(cliCommandUsage))
+	}
+	flag.Parse()
+	var plant *plantConfig
+	if cliOpts.config != "" {
+		var err error
+		if plant, err = loadPlantConfig(cliOpts.config); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+		cliOpts.applyConfig(plant, flag.CommandLine)
+	}
+	if cliOpts.sim != "" {
+		sim, err := startSimulator(cliOpts.sim, cliOpts.simPattern)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		log.Printf(tr("S7模拟器已启动: %s（模式 %s）"), sim.addr(), cliOpts.simPattern)
+	}
+	if flag.NArg() > 0 {
+		if !isCLICommand(flag.Arg(0)) {
+			fmt.Fprintf(os.Stderr, tr("未知的命令: %s")+"\n", flag.Arg(0))
+			fmt.Fprint(os.Stderr, tr(cliCommandUsage))
+			os.Exit(exitUsage)
+		}
+		os.Exit(runCLICommand(cliOpts, flag.CommandLine, flag.Args()))
+	}
+	if *headless {
+		os.Exit(runHeadless(cliOpts))
+	}
+	preset, hasPreset, err := cliOpts.guiPreset(flag.CommandLine)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
 	}
-	p.running = true
-	stopChan := make(chan bool)
-	p.stopChan = stopChan
-	p.mu.Unlock()
-
-	go func(startAddr int, len int, updateFn func([]bool)) {
-		ticker := time.NewTicker(1000 * time.Millisecond) // 每1秒更新一次
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-stopChan:
-				return
-			case <-ticker.C:
-				// 根据长度计算需要读取的字节数
-				bytesToRead := len
-				if bytesToRead <= 0 {
-					bytesToRead = 1
-				}
 
-				// 限制最大读取字节数
-				if bytesToRead > 4 {
-					bytesToRead = 4
-				}
+	// 日志同时输出到终端和窗口内的日志面板
+	events := newEventLog()
+	log.SetOutput(io.MultiWriter(os.Stderr, events))
+
+	// 插件目录中的解码器在创建界面前注册，状态表的类型列表中才有自定义类型
+	loadPlugins()
+
+	myApp := app.NewWithID("plc.binary.viewer")
+	prefs := myApp.Preferences()
+	setLanguage(prefs.StringWithFallback(prefLanguage, currentLanguage()))
+	applyAppearance(myApp)
+	readOnlyForced = cliOpts.readOnly
+	readOnlyMode = cliOpts.readOnly || persistedReadOnly()
+	myWindow := myApp.NewWindow(tr(windowTitle))
+	myWindow.Resize(savedWindowSize(prefs))
+
+	// 每台PLC一个标签页，点击“+”添加，关闭标签页时断开对应的连接。
+	// 切换语言时重新创建标签页，panels和tabs随之替换。
+	var panels map[*container.TabItem]*plcPanel
+	var tabs *container.DocTabs
+	addPLC := func(ip string) *container.TabItem {
+		p := newPLCPanel(prefs, myWindow, ip)
+		item := container.NewTabItem(ip, p.content)
+		p.onIPChanged = func(ip string) {
+			item.Text = ip
+			tabs.Refresh()
+		}
+		panels[item] = p
+		return item
+	}
 
-				data, err := p.readVArea(startAddr, bytesToRead)
-				if err != nil {
-					log.Printf("读取数据失败: %v", err)
-					continue
+	// 可选的HTTP接口，plc参数按IP选择标签页，省略时使用第一个已连接的PLC
+	// resolve 按IP查找已连接的标签页，供HTTP和gRPC接口使用
+	resolve := func(plc string) (*s7viewer.Viewer, error) {
+		var found *s7viewer.Viewer
+		fyne.DoAndWait(func() {
+			for _, item := range tabs.Items {
+				p := panels[item]
+				if v := p.viewer(); v != nil && v.IsConnected() && (plc == "" || p.ip() == plc) {
+					found = v
+					return
 				}
-
-				// 将字节数据转换为布尔数组（二进制位）
-				totalBits := bytesToRead * 8
-				bits := make([]bool, totalBits)
-				for i, b := range data {
-					for j := 0; j < 8; j++ {
-						bitPos := i*8 + j
-						bits[bitPos] = (b>>(7-j))&1 == 1
+			}
+		})
+		if found == nil {
+			return nil, errors.New(tr("PLC未连接"))
+		}
+		return found, nil
+	}
+	if cliOpts.api != "" {
+		metrics := func() []metricsSource {
+			var sources []metricsSource
+			fyne.DoAndWait(func() {
+				for _, item := range tabs.Items {
+					if src, ok := panels[item].metrics(); ok {
+						sources = append(sources, src)
 					}
 				}
-
-				if updateFn != nil {
-					updateFn(bits)
-				}
-			}
+			})
+			return sources
 		}
-	}(startAddress, length, updateFunc)
-}
-
-func (p *PLCBinaryViewer) stopMonitoring() {
-	p.mu.Lock()
-	if p.running {
-		close(p.stopChan)
-		p.running = false
+		startAPIServer(cliOpts.api, metrics, resolve)
+	}
+	if cliOpts.grpc != "" {
+		startGRPCServer(cliOpts.grpc, resolve)
 	}
-	p.mu.Unlock()
-}
-
-func main() {
-	myApp := app.New()
-	myWindow := myApp.NewWindow("S7-200 Smart V区二进制显示器 @Yuanxin E: wax_wane@qq.com ")
-	myWindow.Resize(fyne.NewSize(900, 700))
-
-	// 创建全局viewer实例
-	var viewer *PLCBinaryViewer
-
-	// 创建输入控件
-	ipEntry := widget.NewEntry()
-	ipEntry.SetText("192.168.1.11")
-
-	addressEntry := widget.NewEntry()
-	addressEntry.SetText("100") // 默认从V100开始
-
-	lengthEntry := widget.NewEntry()
-	lengthEntry.SetText("1") // 默认长度为1字节
-
-	// 创建显示区域的容器
-	displayContainer := container.NewVBox()
-
-	// 创建寄存器内容显示文本框
-	registerContentEntry := widget.NewMultiLineEntry()
-	registerContentEntry.SetPlaceHolder("寄存器内容将以16位分组的十进制数值显示，用逗号分隔")
-	registerContentEntry.Wrapping = fyne.TextWrapOff // 修正：使用正确的类型
-	registerContentEntry.Resize(fyne.NewSize(850, 50))
 
-	// 创建连接按钮
-	connectButton := widget.NewButton("连接PLC", func() {
-		ip := strings.TrimSpace(ipEntry.Text)
-		if ip == "" {
-			log.Println("请输入PLC IP地址")
-			return
+	// sessions 返回各标签页的状态和选中的标签页，restoreSessions 按顺序恢复
+	sessions := func() ([]string, int) {
+		saved := make([]string, len(tabs.Items))
+		for i, item := range tabs.Items {
+			saved[i] = panels[item].session().String()
 		}
-
-		if viewer == nil {
-			viewer = NewPLCBinaryViewer()
+		return saved, tabs.SelectedIndex()
+	}
+	restoreSessions := func(saved []string, active int) {
+		for i, item := range tabs.Items {
+			if i < len(saved) {
+				if s, ok := parsePanelSession(saved[i]); ok {
+					panels[item].restoreSession(s)
+				}
+			}
 		}
-
-		if err := viewer.connectPLC(ip); err != nil {
-			log.Printf("连接失败: %v", err)
-			return
+		if active >= 0 && active < len(tabs.Items) {
+			tabs.SelectIndex(active)
 		}
+	}
 
-		log.Println("PLC连接成功!")
-	})
-
-	// 创建读取按钮（单次读取）
-	monitorButton := widget.NewButton("读取数据", func() {
-		if viewer == nil {
-			log.Println("请先连接PLC")
-			return
+	// closeAll 断开所有连接，返回各标签页的IP
+	closeAll := func() []string {
+		var ips []string
+		for _, item := range tabs.Items {
+			ips = append(ips, panels[item].ip())
+			panels[item].teardown()
 		}
+		return ips
+	}
 
-		addressStr := strings.TrimSpace(addressEntry.Text)
-		startAddress, err := strconv.Atoi(addressStr)
-		if err != nil {
-			log.Printf("无效的地址: %v", err)
-			return
-		}
+	// restoreUI 显示buildUI创建的菜单和内容，退出看板模式时也调用
+	var mainMenu *fyne.MainMenu
+	var mainContent fyne.CanvasObject
+	restoreUI := func() {
+		myWindow.SetMainMenu(mainMenu)
+		myWindow.SetContent(mainContent)
+	}
 
-		lengthStr := strings.TrimSpace(lengthEntry.Text)
-		length, err := strconv.Atoi(lengthStr)
-		if err != nil {
-			log.Printf("无效的长度: %v", err)
-			return
+	// buildUI 按当前语言创建菜单、标签页和日志面板，每个IP一个标签页
+	var buildUI func(ips []string)
+	buildUI = func(ips []string) {
+		if len(ips) == 0 {
+			ips = []string{defaultIP}
 		}
-
-		// 设置最大读取字节数（不超过显示区域容量）
-		const maxDisplayBytes = 80 // 32*20=640位 = 80字节
-		bytesToRead := length
-		if bytesToRead <= 0 {
-			bytesToRead = 1
+		readOnlyListeners = nil
+		panels = make(map[*container.TabItem]*plcPanel)
+		tabs = container.NewDocTabs()
+		tabs.CreateTab = func() *container.TabItem {
+			return addPLC(defaultIP)
 		}
-		if bytesToRead > maxDisplayBytes {
-			bytesToRead = maxDisplayBytes
+		tabs.CloseIntercept = func(item *container.TabItem) {
+			p := panels[item]
+			closeTab := func() {
+				p.teardown()
+				delete(panels, item)
+				tabs.Remove(item)
+			}
+			if !p.monitoring() {
+				closeTab()
+				return
+			}
+			dialog.ShowConfirm(tr("关闭标签页"), tr("该PLC正在监控，关闭将停止监控并断开连接。是否继续？"), func(ok bool) {
+				if ok {
+					closeTab()
+				}
+			}, myWindow)
 		}
-
-		// 创建固定大小的显示区域：32列 × 20行
-		const (
-			maxCols = 32
-			maxRows = 20
-		)
-
-		// 创建一个垂直容器来存放所有行
-		rowsContainer := container.NewVBox()
-
-		// 创建一个全局方块引用数组，用于后续更新
-		var squares [][]*canvas.Rectangle
-		for row := 0; row < maxRows; row++ {
-			rowSquares := make([]*canvas.Rectangle, maxCols)
-			squares = append(squares, rowSquares)
+		for _, ip := range ips {
+			tabs.Append(addPLC(ip))
 		}
 
-		// 创建32*20的网格
-		for row := 0; row < maxRows; row++ {
-			// 每行32个方块
-			rowGrid := container.NewGridWithColumns(maxCols)
-
-			for col := 0; col < maxCols; col++ {
-				// 创建灰色方块（初始状态）
-				square := canvas.NewRectangle(color.RGBA{R: 128, G: 128, B: 128, A: 255}) // 灰色表示未使用
-				square.SetMinSize(fyne.NewSize(25, 25))
-				squares[row][col] = square
-				rowGrid.Add(square)
+		// 切换语言后重新创建界面，有PLC已连接时先确认
+		switchLanguage := func(lang string) {
+			if lang == currentLanguage() {
+				return
 			}
-
-			rowsContainer.Add(rowGrid)
-		}
-
-		displayContainer.Objects = []fyne.CanvasObject{rowsContainer}
-		displayContainer.Refresh()
-
-		// 单次读取数据
-		dataBytes, err := viewer.readOnce(startAddress, bytesToRead)
-		if err != nil {
-			log.Printf("读取数据失败: %v", err)
-			return
+			apply := func() {
+				saved, active := sessions()
+				ips := closeAll()
+				prefs.SetString(prefLanguage, lang)
+				setLanguage(lang)
+				buildUI(ips)
+				restoreSessions(saved, active)
+			}
+			for _, p := range panels {
+				if v := p.viewer(); p.monitoring() || v != nil && v.IsConnected() {
+					dialog.ShowConfirm(tr("切换语言"), tr("切换语言将重新创建界面，停止所有监控并断开连接。是否继续？"), func(ok bool) {
+						if ok {
+							apply()
+						}
+					}, myWindow)
+					return
+				}
+			}
+			apply()
 		}
-
-		// 将字节数据转换为16位十进制数值
-		decValues := convertBytesTo16BitInts(dataBytes)
-		var decStr []string
-		for _, val := range decValues {
-			decStr = append(decStr, strconv.Itoa(val))
+		languageItems := make([]*fyne.MenuItem, len(languages))
+		for i, lang := range languages {
+			languageItems[i] = fyne.NewMenuItem(languageNames[lang], func() { switchLanguage(lang) })
+			languageItems[i].Checked = lang == currentLanguage()
 		}
-		registerContentEntry.SetText(strings.Join(decStr, ", "))
+		languageMenu := fyne.NewMenuItem(tr("语言"), nil)
+		languageMenu.ChildMenu = fyne.NewMenu("", languageItems...)
+		appearanceItem := fyne.NewMenuItem(tr("外观..."), func() {
+			showAppearanceDialog(myApp, myWindow, func() {
+				for _, p := range panels {
+					p.redraw()
+				}
+			})
+		})
 
-		// 将字节数据转换为二进制位并填充到32*20的网格中
-		for i := 0; i < len(dataBytes); i++ {
-			for j := 0; j < 8; j++ {
-				// 计算位的索引
-				bitIndex := i*8 + j
-				// 计算在网格中的位置
-				row := bitIndex / maxCols
-				col := bitIndex % maxCols
+		kioskItem := fyne.NewMenuItem(tr("看板模式"), func() {
+			if item := tabs.Selected(); item != nil {
+				startKiosk(myWindow, panels[item], restoreUI)
+			}
+		})
+
+		readOnlyItem := fyne.NewMenuItem(tr("只读模式"), func() { toggleReadOnly(myWindow) })
+
+		mainMenu = fyne.NewMainMenu(fyne.NewMenu(tr("设置"), languageMenu, appearanceItem, readOnlyItem), fyne.NewMenu(tr("显示"), kioskItem))
+		onReadOnlyChange(func(on bool) {
+			readOnlyItem.Checked = on
+			mainMenu.Refresh()
+		})
+		mainContent = container.NewBorder(nil, newEventLogPanel(myWindow, events), nil, nil, tabs)
+		myWindow.SetTitle(tr(windowTitle))
+		restoreUI()
+	}
 
-				// 检查是否在显示区域内
-				if row < maxRows && col < maxCols {
-					square := squares[row][col]
-					// 提取当前位的值（从高位到低位）
-					bitValue := (dataBytes[i] >> (7 - j)) & 1
-					if bitValue == 1 {
-						square.FillColor = color.RGBA{R: 0, G: 255, B: 0, A: 255} // 绿色表示1
-					} else {
-						square.FillColor = color.RGBA{R: 128, G: 128, B: 128, A: 255} // 灰色表示0
-					}
-					square.Refresh()
+	// 有配置文件时按配置创建标签页，否则恢复上次打开的PLC
+	if plant != nil {
+		plant.applyPrefs(prefs)
+		buildUI(plant.ips())
+		for i, item := range tabs.Items {
+			if i < len(plant.Connections) {
+				c := plant.Connections[i]
+				panels[item].applyProfile(c.profile)
+				if c.Monitor {
+					panels[item].ensureMonitoring()
 				}
 			}
 		}
-
-		// 对于未使用的网格部分，保持灰色状态
-		totalDataBits := len(dataBytes) * 8
-		for bitIndex := totalDataBits; bitIndex < maxRows*maxCols; bitIndex++ {
-			row := bitIndex / maxCols
-			col := bitIndex % maxCols
-			if row < maxRows && col < maxCols {
-				square := squares[row][col]
-				square.FillColor = color.RGBA{R: 128, G: 128, B: 128, A: 255} // 灰色表示未使用
-				square.Refresh()
+		log.Printf(tr("已载入配置文件 %s"), cliOpts.config)
+	} else {
+		buildUI(prefs.StringList(prefPLCTabs))
+		restoreSessions(prefs.StringList(prefSessionTabs), prefs.IntWithFallback(prefSessionActive, 0))
+	}
+	// 命令行指定的连接参数填入第一个标签页，-monitor时启动后自动连接并监控
+	if first := panels[tabs.Items[0]]; hasPreset || cliOpts.monitor {
+		if hasPreset {
+			if preset.IP == "" {
+				preset.IP = first.ip()
 			}
+			first.applyProfile(preset)
 		}
-	})
-
-	// 断开连接按钮
-	disconnectButton := widget.NewButton("断开连接", func() {
-		if viewer != nil {
-			viewer.disconnectPLC()
-			log.Println("PLC已断开连接")
+		if cliOpts.monitor {
+			first.ensureMonitoring()
+		}
+	}
+	installShortcuts(myWindow.Canvas(), func() *plcPanel {
+		if item := tabs.Selected(); item != nil {
+			return panels[item]
 		}
+		return nil
 	})
 
-	// 清除显示按钮
-	stopButton := widget.NewButton("清除显示", func() {
-		// 重新创建空的显示区域
-		displayContainer.Objects = nil
-		displayContainer.Refresh()
-		// 清除寄存器内容显示
-		registerContentEntry.SetText("")
+	// 关闭窗口前保存标签页并断开所有连接，有PLC正在监控时先确认
+	myWindow.SetCloseIntercept(func() {
+		quit := func() {
+			saved, active := sessions()
+			prefs.SetStringList(prefSessionTabs, saved)
+			prefs.SetInt(prefSessionActive, active)
+			if !myWindow.FullScreen() {
+				saveWindowSize(prefs, myWindow)
+			}
+			prefs.SetStringList(prefPLCTabs, closeAll())
+			myWindow.Close()
+		}
+		for _, p := range panels {
+			if p.monitoring() {
+				dialog.ShowConfirm(tr("退出"), tr("有PLC正在监控，退出将停止所有监控并断开连接。是否继续？"), func(ok bool) {
+					if ok {
+						quit()
+					}
+				}, myWindow)
+				return
+			}
+		}
+		quit()
 	})
 
-	// 布局
-	inputForm := container.NewVBox(
-		widget.NewForm(
-			widget.NewFormItem("PLC IP地址:", ipEntry),
-			widget.NewFormItem("起始地址 (V区):", addressEntry),
-			widget.NewFormItem("寄存器长度 (字节):", lengthEntry),
-		),
-		container.NewHBox(
-			connectButton,
-			disconnectButton,
-			monitorButton,
-			stopButton,
-		),
-	)
-
-	// 将寄存器内容显示放在输入表单和显示区域之间
-	content := container.NewBorder(
-		container.NewVBox(
-			inputForm,
-			widget.NewLabel("寄存器内容 (16位十进制数值):"),
-			registerContentEntry,
-		),
-		nil, nil, nil,
-		container.NewVScroll(displayContainer))
-
-	myWindow.SetContent(content)
+	if *openPath != "" {
+		panels[tabs.Items[0]].openFile(*openPath)
+	}
+	if *kiosk {
+		startKiosk(myWindow, panels[tabs.Items[0]], restoreUI)
+	}
 	myWindow.ShowAndRun()
 }
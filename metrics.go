@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// metricsSource 一台PLC的监控指标来源
+type metricsSource struct {
+	plc     string
+	viewer  *s7viewer.Viewer
+	entries []layoutEntry // 结构化视图中的变量，数值作为plc_value导出
+	start   int
+	data    []byte          // 最近一次读取的结构化视图数据
+	watch   []snapshotValue // 状态表各行当前显示的值，供网页看板使用
+}
+
+// writeMetrics 按Prometheus文本格式输出各PLC的运行指标和结构化视图中的变量值
+func writeMetrics(w io.Writer, sources []metricsSource) {
+	type sample struct {
+		labels string
+		value  float64
+	}
+	families := []struct {
+		name, typ, help string
+		get             func(s metricsSource, st s7viewer.Stats) []sample
+	}{
+		{"plc_connected", "gauge", "PLC是否已连接（1为已连接）", func(s metricsSource, st s7viewer.Stats) []sample {
+			return []sample{{plcLabel(s.plc), float64(boolToInt(st.Connected))}}
+		}},
+		{"plc_scan_duration_seconds", "gauge", "最近一次监控读取耗时", func(s metricsSource, st s7viewer.Stats) []sample {
+			return []sample{{plcLabel(s.plc), st.ScanDuration.Seconds()}}
+		}},
+		{"plc_scan_cycle_seconds", "gauge", "最近一次实测的扫描周期", func(s metricsSource, st s7viewer.Stats) []sample {
+			return []sample{{plcLabel(s.plc), st.Cycle.Seconds()}}
+		}},
+		{"plc_read_errors_total", "counter", "后台读取失败次数", func(s metricsSource, st s7viewer.Stats) []sample {
+			return []sample{{plcLabel(s.plc), float64(st.ReadErrors)}}
+		}},
+		{"plc_reconnects_total", "counter", "自动重连成功次数", func(s metricsSource, st s7viewer.Stats) []sample {
+			return []sample{{plcLabel(s.plc), float64(st.Reconnects)}}
+		}},
+		{"plc_value", "gauge", "结构化视图中的变量值（BOOL为0/1，不含STRING）", func(s metricsSource, _ s7viewer.Stats) []sample {
+			var out []sample
+			order := s7viewer.OrderBigEndian
+			if s.viewer != nil {
+				order = s.viewer.ByteOrder()
+			}
+			for _, e := range s.entries {
+				v, err := s7viewer.DecodeTyped(order, e.Type, s.data, e.Addr.byteOff-s.start, e.Addr.bit, e.StrLen)
+				if err != nil {
+					continue
+				}
+				f, ok := metricValue(v)
+				if !ok {
+					continue
+				}
+				labels := fmt.Sprintf(`plc="%s",name="%s",address="%s",type="%s"`,
+					escapeLabel(s.plc), escapeLabel(e.Name), e.Addr, e.Type)
+				out = append(out, sample{labels, f})
+			}
+			return out
+		}},
+	}
+
+	stats := make([]s7viewer.Stats, len(sources))
+	for i, s := range sources {
+		stats[i] = s.viewer.Stats()
+	}
+	for _, f := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+		for i, s := range sources {
+			for _, smp := range f.get(s, stats[i]) {
+				fmt.Fprintf(w, "%s{%s} %g\n", f.name, smp.labels, smp.value)
+			}
+		}
+	}
+}
+
+// metricValue 将解码后的值转换为浮点数，STRING不导出
+func metricValue(v any) (float64, bool) {
+	switch v := v.(type) {
+	case bool:
+		return float64(boolToInt(v)), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func plcLabel(plc string) string {
+	return fmt.Sprintf(`plc="%s"`, escapeLabel(plc))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+)
+
+// defaultModbusPort Modbus TCP的标准端口
+const defaultModbusPort = 502
+
+// Modbus功能码和异常码
+const (
+	modbusReadHolding      = 0x03
+	modbusReadInput        = 0x04
+	modbusIllegalFunction  = 0x01
+	modbusIllegalAddress   = 0x02
+	modbusIllegalValue     = 0x03
+	modbusMaxReadRegisters = 125
+)
+
+// modbusGateway 将监控的V区字节范围映射为Modbus保持寄存器并通过Modbus TCP提供，
+// 供只支持Modbus的旧HMI读取。寄存器0对应监控起始字节，每个寄存器两个字节（大端）。
+// 只支持读取（功能码03/04），写入请求返回非法功能异常。
+type modbusGateway struct {
+	listener net.Listener
+	mu       sync.Mutex
+	regs     []uint16
+	conns    map[net.Conn]bool
+}
+
+// startModbusGateway 在addr上开始监听Modbus TCP连接
+func startModbusGateway(addr string) (*modbusGateway, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	g := &modbusGateway{listener: l, conns: make(map[net.Conn]bool)}
+	go g.acceptLoop()
+	return g, nil
+}
+
+// update 用最新一次读取的数据刷新寄存器，奇数长度时最后一个寄存器低字节补0
+func (g *modbusGateway) update(data []byte) {
+	regs := make([]uint16, (len(data)+1)/2)
+	for i := range regs {
+		hi := uint16(data[2*i]) << 8
+		if 2*i+1 < len(data) {
+			hi |= uint16(data[2*i+1])
+		}
+		regs[i] = hi
+	}
+	g.mu.Lock()
+	g.regs = regs
+	g.mu.Unlock()
+}
+
+// close 停止监听并断开所有客户端
+func (g *modbusGateway) close() {
+	g.listener.Close()
+	g.mu.Lock()
+	for c := range g.conns {
+		c.Close()
+	}
+	g.mu.Unlock()
+}
+
+func (g *modbusGateway) acceptLoop() {
+	for {
+		conn, err := g.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf(tr("Modbus网关停止接受连接: %v"), err)
+			}
+			return
+		}
+		g.mu.Lock()
+		g.conns[conn] = true
+		g.mu.Unlock()
+		go g.serve(conn)
+	}
+}
+
+// serve 处理一个客户端的请求，直到连接关闭
+func (g *modbusGateway) serve(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		g.mu.Lock()
+		delete(g.conns, conn)
+		g.mu.Unlock()
+	}()
+
+	header := make([]byte, 7) // MBAP: 事务号、协议号、长度、单元号
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		// 协议号不为0的不是Modbus报文，按规范断开连接
+		length := int(binary.BigEndian.Uint16(header[4:]))
+		if binary.BigEndian.Uint16(header[2:]) != 0 || length < 2 || length > 256 {
+			return
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+
+		resp := g.handle(pdu)
+		frame := make([]byte, 0, 7+len(resp))
+		frame = append(frame, header[:4]...)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(resp)+1))
+		frame = append(frame, header[6])
+		frame = append(frame, resp...)
+		if _, err := conn.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+// handle 处理一个Modbus PDU并返回应答PDU
+func (g *modbusGateway) handle(pdu []byte) []byte {
+	fc := pdu[0]
+	if fc != modbusReadHolding && fc != modbusReadInput {
+		return []byte{fc | 0x80, modbusIllegalFunction}
+	}
+	// 请求不完整或寄存器个数超出1-125时为非法数据值，个数合法但超出映射范围时为非法地址
+	if len(pdu) != 5 {
+		return []byte{fc | 0x80, modbusIllegalValue}
+	}
+	start := int(binary.BigEndian.Uint16(pdu[1:]))
+	count := int(binary.BigEndian.Uint16(pdu[3:]))
+	if count < 1 || count > modbusMaxReadRegisters {
+		return []byte{fc | 0x80, modbusIllegalValue}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if start+count > len(g.regs) {
+		return []byte{fc | 0x80, modbusIllegalAddress}
+	}
+
+	resp := []byte{fc, byte(count * 2)}
+	for _, r := range g.regs[start : start+count] {
+		resp = binary.BigEndian.AppendUint16(resp, r)
+	}
+	return resp
+}
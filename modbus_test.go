@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestModbusHandle(t *testing.T) {
+	g := &modbusGateway{}
+	g.update([]byte{0x12, 0x34, 0x56, 0x78, 0x9A})
+	tests := []struct {
+		name string
+		pdu  []byte
+		want []byte
+	}{
+		{"read holding", []byte{0x03, 0x00, 0x00, 0x00, 0x02}, []byte{0x03, 0x04, 0x12, 0x34, 0x56, 0x78}},
+		{"read input", []byte{0x04, 0x00, 0x01, 0x00, 0x01}, []byte{0x04, 0x02, 0x56, 0x78}},
+		{"odd length padded", []byte{0x03, 0x00, 0x02, 0x00, 0x01}, []byte{0x03, 0x02, 0x9A, 0x00}},
+		{"write single register", []byte{0x06, 0x00, 0x00, 0x00, 0x01}, []byte{0x86, modbusIllegalFunction}},
+		{"truncated request", []byte{0x03, 0x00, 0x00}, []byte{0x83, modbusIllegalValue}},
+		{"zero quantity", []byte{0x03, 0x00, 0x00, 0x00, 0x00}, []byte{0x83, modbusIllegalValue}},
+		{"quantity above 125", []byte{0x03, 0x00, 0x00, 0x00, 0x7E}, []byte{0x83, modbusIllegalValue}},
+		{"past mapped range", []byte{0x03, 0x00, 0x02, 0x00, 0x02}, []byte{0x83, modbusIllegalAddress}},
+		{"start past end", []byte{0x04, 0x00, 0x10, 0x00, 0x01}, []byte{0x84, modbusIllegalAddress}},
+	}
+	for _, tt := range tests {
+		if got := g.handle(tt.pdu); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: handle(% X) = % X, want % X", tt.name, tt.pdu, got, tt.want)
+		}
+	}
+}
+
+// startModbusPipe 返回通过内存管道连接到网关的客户端一端，测试结束时关闭
+func startModbusPipe(t *testing.T, g *modbusGateway) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	g.conns[server] = true
+	go g.serve(server)
+	t.Cleanup(func() { client.Close() })
+	client.SetDeadline(time.Now().Add(2 * time.Second))
+	return client
+}
+
+func TestModbusServe(t *testing.T) {
+	g := &modbusGateway{conns: make(map[net.Conn]bool)}
+	g.update([]byte{0x12, 0x34})
+	client := startModbusPipe(t, g)
+
+	// 应答沿用请求的事务号和单元号
+	req := []byte{0xAB, 0xCD, 0x00, 0x00, 0x00, 0x06, 0x11, 0x03, 0x00, 0x00, 0x00, 0x01}
+	if _, err := client.Write(req); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+	want := []byte{0xAB, 0xCD, 0x00, 0x00, 0x00, 0x05, 0x11, 0x03, 0x02, 0x12, 0x34}
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(client, got); err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response = % X, want % X", got, want)
+	}
+}
+
+func TestModbusServeRejectsFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{"protocol id not 0", []byte{0x00, 0x01, 0x00, 0x01, 0x00, 0x06, 0x01, 0x03, 0x00, 0x00, 0x00, 0x01}},
+		{"length too short", []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x01}},
+		{"length too long", []byte{0x00, 0x01, 0x00, 0x00, 0x01, 0x01, 0x01}},
+	}
+	for _, tt := range tests {
+		g := &modbusGateway{conns: make(map[net.Conn]bool)}
+		g.update([]byte{0x12, 0x34})
+		client := startModbusPipe(t, g)
+		go client.Write(tt.frame)
+		// 网关不应答并断开连接
+		if n, err := client.Read(make([]byte, 16)); err != io.EOF {
+			t.Errorf("%s: read = %d bytes, %v; want connection closed", tt.name, n, err)
+		}
+	}
+}
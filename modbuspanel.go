@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newModbusPanel 创建Modbus TCP网关设置面板。current返回运行中的网关，未启用时为nil，
+// 可在监控协程中调用。
+func newModbusPanel() (content fyne.CanvasObject, current func() *modbusGateway) {
+	var mu sync.Mutex
+	var gateway *modbusGateway
+	current = func() *modbusGateway {
+		mu.Lock()
+		defer mu.Unlock()
+		return gateway
+	}
+
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(defaultModbusPort))
+	statusLabel := widget.NewLabel(tr("Modbus: 未启用"))
+
+	enableCheck := widget.NewCheck(tr("启用Modbus TCP网关"), func(enabled bool) {
+		mu.Lock()
+		old := gateway
+		gateway = nil
+		mu.Unlock()
+		if old != nil {
+			old.close()
+		}
+		if !enabled {
+			statusLabel.SetText(tr("Modbus: 未启用"))
+			return
+		}
+
+		port, err := strconv.Atoi(strings.TrimSpace(portEntry.Text))
+		if err != nil || port <= 0 || port > 65535 {
+			statusLabel.SetText(tr("Modbus: 端口无效"))
+			return
+		}
+		g, err := startModbusGateway(fmt.Sprintf(":%d", port))
+		if err != nil {
+			statusLabel.SetText(tr("Modbus: 启动失败"))
+			log.Printf(tr("Modbus网关启动失败: %v"), err)
+			return
+		}
+		mu.Lock()
+		gateway = g
+		mu.Unlock()
+		statusLabel.SetText(fmt.Sprintf(tr("Modbus: 监听端口 %d"), port))
+		log.Printf(tr("Modbus网关已启动，端口 %d"), port)
+	})
+
+	content = container.NewVBox(
+		widget.NewForm(widget.NewFormItem(tr("端口:"), portEntry)),
+		widget.NewLabel(tr("监控范围映射为保持寄存器：寄存器0 = 起始地址的字（高字节在前），依次递增。\n支持功能码03/04读取，开始监控后数据才可用。")),
+		enableCheck,
+		statusLabel,
+	)
+	return content, current
+}
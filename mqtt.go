@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MQTT发布方式
+const (
+	mqttOnChange = "变化时发布"
+	mqttInterval = "定时发布全部"
+)
+
+var mqttModes = []string{mqttOnChange, mqttInterval}
+
+const (
+	mqttKeepAlive    = 30 * time.Second
+	mqttWriteTimeout = 5 * time.Second
+	mqttRedialDelay  = 5 * time.Second
+	mqttRetryDelay   = mqttKeepAlive / 2 // QoS 1消息等待PUBACK的时间，超时后带DUP标志重发
+	mqttMaxInflight  = 256               // 等待PUBACK的消息数上限，超过时认为代理无响应并重连
+	mqttQueueSize    = 16                // 等待发布的扫描数上限，代理处理不过来时丢弃扫描，不阻塞监控
+)
+
+// mqttConfig MQTT发布设置
+type mqttConfig struct {
+	Broker   string // host:port，省略端口时使用1883
+	ClientID string
+	Username string
+	Password string
+	Prefix   string // 主题前缀，如 plant/line1，主题为 前缀/V100.3
+	QoS      byte   // 0或1
+	Retain   bool
+	Mode     string
+	Interval time.Duration // 定时发布的周期
+}
+
+// mqttConn 最小的MQTT 3.1.1客户端，只支持发布（QoS 0/1）和心跳
+type mqttConn struct {
+	conn     net.Conn
+	mu       sync.Mutex // 串行化写入，保护packetID和inflight
+	packetID uint16
+	inflight map[uint16]*mqttInflight // 已发送、等待PUBACK的QoS 1消息
+	done     chan struct{}
+}
+
+// mqttInflight 等待PUBACK的QoS 1消息
+type mqttInflight struct {
+	packet []byte
+	sent   time.Time
+}
+
+// dialMQTT 连接MQTT代理并完成CONNECT握手
+func dialMQTT(cfg mqttConfig) (*mqttConn, error) {
+	addr := cfg.Broker
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "1883")
+	}
+	conn, err := net.DialTimeout("tcp", addr, mqttWriteTimeout)
+	if err != nil {
+		return nil, fmt.Errorf(tr("连接MQTT代理失败: %v"), err)
+	}
+
+	var flags byte = 0x02 // clean session
+	payload := mqttString(cfg.ClientID)
+	if cfg.Username != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(cfg.Username)...)
+		if cfg.Password != "" {
+			flags |= 0x40
+			payload = append(payload, mqttString(cfg.Password)...)
+		}
+	}
+	body := append(mqttString("MQTT"), 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(mqttKeepAlive/time.Second))
+	body = append(body, payload...)
+
+	conn.SetDeadline(time.Now().Add(mqttWriteTimeout))
+	if _, err := conn.Write(mqttPacket(0x10, body)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf(tr("发送CONNECT失败: %v"), err)
+	}
+	r := bufio.NewReader(conn)
+	typ, ack, err := readMQTTPacket(r)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf(tr("等待CONNACK失败: %v"), err)
+	}
+	if typ != 0x20 || len(ack) < 2 {
+		conn.Close()
+		return nil, fmt.Errorf(tr("MQTT代理返回了意外的报文: 0x%02X"), typ)
+	}
+	if ack[1] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf(tr("MQTT代理拒绝连接，返回码 %d"), ack[1])
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := newMQTTConn(conn)
+	go c.readLoop(r)
+	go c.pingLoop()
+	return c, nil
+}
+
+func newMQTTConn(conn net.Conn) *mqttConn {
+	return &mqttConn{conn: conn, inflight: make(map[uint16]*mqttInflight), done: make(chan struct{})}
+}
+
+// readLoop 读取代理发来的报文，PUBACK确认对应的QoS 1消息，其他的（PINGRESP）丢弃。连接断开时关闭done
+func (c *mqttConn) readLoop(r *bufio.Reader) {
+	defer close(c.done)
+	for {
+		typ, body, err := readMQTTPacket(r)
+		if err != nil {
+			return
+		}
+		if typ == 0x40 && len(body) >= 2 {
+			c.mu.Lock()
+			delete(c.inflight, binary.BigEndian.Uint16(body))
+			c.mu.Unlock()
+		}
+	}
+}
+
+// pingLoop 定时发送心跳，并重发超时未确认的QoS 1消息
+func (c *mqttConn) pingLoop() {
+	ticker := time.NewTicker(mqttRetryDelay)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.retransmit(time.Now()); err != nil {
+				return
+			}
+			if err := c.write(mqttPacket(0xC0, nil)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// retransmit 重发在now之前mqttRetryDelay以上仍未收到PUBACK的消息，重发时置DUP标志
+func (c *mqttConn) retransmit(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range c.inflight {
+		if now.Sub(m.sent) < mqttRetryDelay {
+			continue
+		}
+		m.packet[0] |= 0x08
+		m.sent = now
+		if err := c.writeLocked(m.packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *mqttConn) write(packet []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writeLocked(packet)
+}
+
+// writeLocked 写入一个报文，调用方持有mu
+func (c *mqttConn) writeLocked(packet []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(mqttWriteTimeout))
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// publish 发布一条消息
+func (c *mqttConn) publish(topic string, payload []byte, qos byte, retain bool) error {
+	select {
+	case <-c.done:
+		return errors.New(tr("MQTT连接已断开"))
+	default:
+	}
+
+	header := byte(0x30) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	body := mqttString(topic)
+	if qos == 0 {
+		return c.writeLocked(mqttPacket(header, append(body, payload...)))
+	}
+
+	// QoS 1：记录为等待确认，收到PUBACK前由pingLoop定时重发
+	if len(c.inflight) >= mqttMaxInflight {
+		return fmt.Errorf(tr("MQTT代理有%d条消息未确认"), len(c.inflight))
+	}
+	c.packetID++
+	for c.packetID == 0 || c.inflight[c.packetID] != nil {
+		c.packetID++
+	}
+	body = binary.BigEndian.AppendUint16(body, c.packetID)
+	packet := mqttPacket(header, append(body, payload...))
+	c.inflight[c.packetID] = &mqttInflight{packet: packet, sent: time.Now()}
+	return c.writeLocked(packet)
+}
+
+func (c *mqttConn) close() {
+	c.write(mqttPacket(0xE0, nil))
+	c.conn.Close()
+}
+
+// mqttPacket 组装固定报头（类型和剩余长度）和报文体
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7F) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift > 21 {
+			return 0, nil, errors.New(tr("无效的剩余长度"))
+		}
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header & 0xF0, body, nil
+}
+
+func mqttString(s string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(s))), s...)
+}
+
+// mqttPublisher 将监控数据按位和字发布到MQTT。连接和发布在后台协程中进行，
+// 监控协程中调用update只把扫描放入队列，代理响应慢或连不上时不阻塞监控，可并发安全使用
+type mqttPublisher struct {
+	cfg      mqttConfig
+	scans    chan mqttScan
+	stop     chan struct{}
+	done     chan struct{}
+	statusFn func(string)
+
+	mu      sync.Mutex
+	dropped int // 队列满时丢弃的扫描数，恢复后记录日志
+
+	// 以下只在run协程中使用
+	conn     *mqttConn
+	lastDial time.Time
+	lastPub  time.Time
+	last     map[string]string  // 已发布的主题和值，用于变化检测
+	lastNum  map[string]float64 // 设置了死区时已发布的数值
+}
+
+// mqttScan 等待发布的一次扫描
+type mqttScan struct {
+	order, area string
+	start       int
+	data        []byte
+	db          deadbands
+}
+
+func newMQTTPublisher(cfg mqttConfig, statusFn func(string)) *mqttPublisher {
+	m := &mqttPublisher{
+		cfg:      cfg,
+		scans:    make(chan mqttScan, mqttQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		statusFn: statusFn,
+		last:     make(map[string]string),
+		lastNum:  make(map[string]float64),
+	}
+	go m.run()
+	return m
+}
+
+// update 将一次扫描得到的数据放入发布队列，队列满时丢弃并计数，不阻塞
+func (m *mqttPublisher) update(order, area string, start int, data []byte, db deadbands) {
+	select {
+	case m.scans <- mqttScan{order: order, area: area, start: start, data: append([]byte(nil), data...), db: db}:
+		m.mu.Lock()
+		if m.dropped > 0 {
+			log.Printf(tr("MQTT发布过慢，丢弃了%d次扫描"), m.dropped)
+			m.dropped = 0
+		}
+		m.mu.Unlock()
+	default:
+		m.mu.Lock()
+		m.dropped++
+		m.mu.Unlock()
+	}
+}
+
+// run 依次发布队列中的扫描，close后断开连接
+func (m *mqttPublisher) run() {
+	defer close(m.done)
+	for {
+		select {
+		case <-m.stop:
+			if m.conn != nil {
+				m.conn.close()
+				m.conn = nil
+			}
+			return
+		case scan := <-m.scans:
+			m.publish(scan)
+		}
+	}
+}
+
+// publish 发布一次扫描：每个位发布到 前缀/V100.3，每个字发布到 前缀/VW100。
+// 变化时发布的方式下，db不为nil时只发布变化超过死区的变量。连接断开后每隔几秒在下次扫描时自动重连，
+// 重连后全部变量重新发布，未确认的QoS 1消息随之补发。
+func (m *mqttPublisher) publish(scan mqttScan) {
+	if m.conn == nil {
+		if time.Since(m.lastDial) < mqttRedialDelay {
+			return
+		}
+		m.lastDial = time.Now()
+		conn, err := dialMQTT(m.cfg)
+		if err != nil {
+			m.status("MQTT: " + err.Error())
+			log.Printf(tr("MQTT连接失败: %v"), err)
+			return
+		}
+		m.conn = conn
+		m.last = make(map[string]string)
+		m.lastNum = make(map[string]float64)
+		m.status(tr("MQTT: 已连接 ") + m.cfg.Broker)
+	}
+
+	all := m.cfg.Mode == mqttInterval
+	if all {
+		if time.Since(m.lastPub) < m.cfg.Interval {
+			return
+		}
+		m.lastPub = time.Now()
+	}
+
+	db := scan.db
+	for topic, value := range tagValues(scan.order, scan.area, scan.start, scan.data) {
+		if !all && (db == nil && m.last[topic] == value || db != nil && !db.pass(m.lastNum, topic, value)) {
+			continue
+		}
+		if err := m.conn.publish(m.topic(topic), []byte(value), m.cfg.QoS, m.cfg.Retain); err != nil {
+			log.Printf(tr("MQTT发布失败: %v"), err)
+			m.status(tr("MQTT: 连接断开，等待重连"))
+			m.conn.close()
+			m.conn = nil
+			return
+		}
+		m.last[topic] = value
+	}
+}
+
+func (m *mqttPublisher) topic(name string) string {
+	prefix := strings.Trim(m.cfg.Prefix, "/")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+func (m *mqttPublisher) status(text string) {
+	if m.statusFn != nil {
+		m.statusFn(text)
+	}
+}
+
+// close 停止发布协程并断开MQTT连接，正在进行的连接或发布结束后返回
+func (m *mqttPublisher) close() {
+	close(m.stop)
+	<-m.done
+}
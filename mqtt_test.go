@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMQTTPacketRemainingLength(t *testing.T) {
+	tests := []struct {
+		n      int    // 报文体长度
+		header []byte // 固定报头：类型和剩余长度
+	}{
+		{0, []byte{0x30, 0x00}},
+		{127, []byte{0x30, 0x7F}},
+		{128, []byte{0x30, 0x80, 0x01}},
+		{16383, []byte{0x30, 0xFF, 0x7F}},
+		{16384, []byte{0x30, 0x80, 0x80, 0x01}},
+	}
+	for _, tt := range tests {
+		body := bytes.Repeat([]byte{0xA5}, tt.n)
+		packet := mqttPacket(0x30, body)
+		if got := packet[:len(packet)-tt.n]; !bytes.Equal(got, tt.header) {
+			t.Errorf("mqttPacket(%d bytes) header = % X, want % X", tt.n, got, tt.header)
+		}
+		typ, got, err := readMQTTPacket(bufio.NewReader(bytes.NewReader(packet)))
+		if err != nil || typ != 0x30 || !bytes.Equal(got, body) {
+			t.Errorf("readMQTTPacket(%d bytes) = %02X, %d bytes, %v", tt.n, typ, len(got), err)
+		}
+	}
+}
+
+func TestReadMQTTPacketMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+	}{
+		{"empty", nil},
+		{"truncated length", []byte{0x30, 0x80}},
+		{"length too long", []byte{0x30, 0x80, 0x80, 0x80, 0x80, 0x01}},
+		{"truncated body", []byte{0x30, 0x03, 'a'}},
+	}
+	for _, tt := range tests {
+		if _, _, err := readMQTTPacket(bufio.NewReader(bytes.NewReader(tt.packet))); err == nil {
+			t.Errorf("%s: readMQTTPacket(% X) error = nil", tt.name, tt.packet)
+		}
+	}
+}
+
+// startMQTTPipe 返回通过内存管道连接的mqttConn和代理一端，测试结束时关闭
+func startMQTTPipe(t *testing.T) (*mqttConn, net.Conn, *bufio.Reader) {
+	t.Helper()
+	client, broker := net.Pipe()
+	c := newMQTTConn(client)
+	go c.readLoop(bufio.NewReader(client))
+	t.Cleanup(func() {
+		client.Close()
+		broker.Close()
+	})
+	return c, broker, bufio.NewReader(broker)
+}
+
+func TestMQTTPublish(t *testing.T) {
+	tests := []struct {
+		name   string
+		qos    byte
+		retain bool
+		want   []byte
+	}{
+		{"qos 0", 0, false, []byte{0x30, 0x0B, 0x00, 0x06, 'p', '/', 'V', 'W', '0', '0', '1', '2', '3'}},
+		{"qos 0 retain", 0, true, []byte{0x31, 0x0B, 0x00, 0x06, 'p', '/', 'V', 'W', '0', '0', '1', '2', '3'}},
+		{"qos 1", 1, false, []byte{0x32, 0x0D, 0x00, 0x06, 'p', '/', 'V', 'W', '0', '0', 0x00, 0x01, '1', '2', '3'}},
+	}
+	for _, tt := range tests {
+		c, broker, r := startMQTTPipe(t)
+		errc := make(chan error, 1)
+		go func() { errc <- c.publish("p/VW00", []byte("123"), tt.qos, tt.retain) }()
+		got := make([]byte, len(tt.want))
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatalf("%s: read: %v", tt.name, err)
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("%s: publish: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: packet = % X, want % X", tt.name, got, tt.want)
+		}
+		c.mu.Lock()
+		inflight := len(c.inflight)
+		c.mu.Unlock()
+		if want := int(tt.qos); inflight != want {
+			t.Errorf("%s: %d messages in flight, want %d", tt.name, inflight, want)
+		}
+		broker.Close()
+	}
+}
+
+func TestMQTTPubackAndRetransmit(t *testing.T) {
+	c, broker, r := startMQTTPipe(t)
+
+	// 两条QoS 1消息，代理只确认第一条
+	go func() {
+		c.publish("a", []byte("1"), 1, false)
+		c.publish("b", []byte("2"), 1, false)
+	}()
+	for range 2 {
+		if _, _, err := readMQTTPacket(r); err != nil {
+			t.Fatalf("read PUBLISH: %v", err)
+		}
+	}
+	if _, err := broker.Write([]byte{0x40, 0x02, 0x00, 0x01}); err != nil {
+		t.Fatalf("write PUBACK: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c.mu.Lock()
+		_, acked := c.inflight[1]
+		_, pending := c.inflight[2]
+		c.mu.Unlock()
+		if !acked && pending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("inflight after PUBACK 1: acked=%v pending=%v", !acked, pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 未到重发时间时不重发，超时后只重发未确认的一条并带DUP标志
+	if err := c.retransmit(time.Now()); err != nil {
+		t.Fatalf("retransmit: %v", err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- c.retransmit(time.Now().Add(mqttRetryDelay)) }()
+	typ, body, err := readMQTTPacket(r)
+	if err != nil {
+		t.Fatalf("read retransmitted PUBLISH: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("retransmit: %v", err)
+	}
+	if typ != 0x30 || !bytes.Equal(body, []byte{0x00, 0x01, 'b', 0x00, 0x02, '2'}) {
+		t.Errorf("retransmitted packet = %02X % X", typ, body)
+	}
+	c.mu.Lock()
+	header := c.inflight[2].packet[0]
+	c.mu.Unlock()
+	if header != 0x3A {
+		t.Errorf("retransmitted header = %02X, want 3A (DUP, QoS 1)", header)
+	}
+}
+
+func TestMQTTPublisherUpdateDoesNotBlock(t *testing.T) {
+	// 代理接受连接但不应答CONNECT，发布协程卡在握手中
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	m := newMQTTPublisher(mqttConfig{Broker: ln.Addr().String(), Mode: mqttOnChange}, nil)
+
+	begin := time.Now()
+	for i := range mqttQueueSize * 4 {
+		m.update("", "V", i, []byte{byte(i)}, nil)
+	}
+	if d := time.Since(begin); d > time.Second {
+		t.Errorf("update blocked for %v", d)
+	}
+	m.mu.Lock()
+	dropped := m.dropped
+	m.mu.Unlock()
+	if dropped == 0 {
+		t.Error("dropped = 0 with the broker not answering, want scans dropped")
+	}
+
+	// 代理断开后握手失败，close随之返回
+	select {
+	case conn := <-accepted:
+		conn.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("publisher did not connect")
+	}
+	ln.Close()
+	m.close()
+}
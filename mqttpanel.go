@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MQTT设置的偏好键（密码不保存）
+const (
+	prefMQTTBroker   = "mqtt.broker"
+	prefMQTTUsername = "mqtt.username"
+	prefMQTTPrefix   = "mqtt.prefix"
+	prefMQTTQoS      = "mqtt.qos"
+	prefMQTTRetain   = "mqtt.retain"
+	prefMQTTMode     = "mqtt.mode"
+	prefMQTTInterval = "mqtt.intervalMs"
+)
+
+// newMQTTPanel 创建MQTT发布设置面板。current返回当前启用的发布器，未启用时为nil，
+// 可在监控协程中调用。
+func newMQTTPanel(prefs fyne.Preferences) (content fyne.CanvasObject, current func() *mqttPublisher) {
+	var mu sync.Mutex
+	var publisher *mqttPublisher
+	current = func() *mqttPublisher {
+		mu.Lock()
+		defer mu.Unlock()
+		return publisher
+	}
+
+	brokerEntry := widget.NewEntry()
+	brokerEntry.SetText(prefs.StringWithFallback(prefMQTTBroker, "127.0.0.1:1883"))
+	clientIDEntry := widget.NewEntry()
+	clientIDEntry.SetText(fmt.Sprintf("plc-viewer-%d", time.Now().UnixNano()%100000))
+	usernameEntry := widget.NewEntry()
+	usernameEntry.SetText(prefs.String(prefMQTTUsername))
+	passwordEntry := widget.NewPasswordEntry()
+	prefixEntry := widget.NewEntry()
+	prefixEntry.SetText(prefs.StringWithFallback(prefMQTTPrefix, "plant/line1"))
+	qosSelect := widget.NewSelect([]string{"0", "1"}, nil)
+	qosSelect.SetSelected(strconv.Itoa(prefs.IntWithFallback(prefMQTTQoS, 0)))
+	retainCheck := widget.NewCheck(tr("保留消息(Retain)"), nil)
+	retainCheck.SetChecked(prefs.Bool(prefMQTTRetain))
+	modeSelect := newTrSelect(mqttModes, nil)
+	modeSelect.SetSelected(tr(prefs.StringWithFallback(prefMQTTMode, mqttOnChange)))
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefMQTTInterval, 5000)))
+
+	statusLabel := widget.NewLabel(tr("MQTT: 未启用"))
+	setStatus := func(text string) {
+		fyne.Do(func() { statusLabel.SetText(text) })
+	}
+
+	enableCheck := widget.NewCheck(tr("启用MQTT发布（监控时发布）"), func(enabled bool) {
+		mu.Lock()
+		old := publisher
+		publisher = nil
+		mu.Unlock()
+		if old != nil {
+			go old.close()
+		}
+		if !enabled {
+			statusLabel.SetText(tr("MQTT: 未启用"))
+			return
+		}
+
+		broker := strings.TrimSpace(brokerEntry.Text)
+		ms, err := strconv.Atoi(strings.TrimSpace(intervalEntry.Text))
+		if broker == "" || err != nil || ms <= 0 {
+			statusLabel.SetText(tr("MQTT: 代理地址或发布周期无效"))
+			return
+		}
+		qos, _ := strconv.Atoi(qosSelect.Selected)
+		cfg := mqttConfig{
+			Broker:   broker,
+			ClientID: strings.TrimSpace(clientIDEntry.Text),
+			Username: strings.TrimSpace(usernameEntry.Text),
+			Password: passwordEntry.Text,
+			Prefix:   strings.TrimSpace(prefixEntry.Text),
+			QoS:      byte(qos),
+			Retain:   retainCheck.Checked,
+			Mode:     selectValue(modeSelect, mqttModes),
+			Interval: time.Duration(ms) * time.Millisecond,
+		}
+		prefs.SetString(prefMQTTBroker, cfg.Broker)
+		prefs.SetString(prefMQTTUsername, cfg.Username)
+		prefs.SetString(prefMQTTPrefix, cfg.Prefix)
+		prefs.SetInt(prefMQTTQoS, qos)
+		prefs.SetBool(prefMQTTRetain, cfg.Retain)
+		prefs.SetString(prefMQTTMode, cfg.Mode)
+		prefs.SetInt(prefMQTTInterval, ms)
+
+		mu.Lock()
+		publisher = newMQTTPublisher(cfg, setStatus)
+		mu.Unlock()
+		statusLabel.SetText(tr("MQTT: 等待监控数据"))
+		log.Printf(tr("已启用MQTT发布: %s, 主题前缀 %s"), cfg.Broker, cfg.Prefix)
+	})
+
+	content = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(tr("代理地址:"), brokerEntry),
+			widget.NewFormItem(tr("客户端ID:"), clientIDEntry),
+			widget.NewFormItem(tr("用户名:"), usernameEntry),
+			widget.NewFormItem(tr("密码:"), passwordEntry),
+			widget.NewFormItem(tr("主题前缀:"), prefixEntry),
+			widget.NewFormItem("QoS:", container.NewHBox(qosSelect, retainCheck)),
+			widget.NewFormItem(tr("发布方式:"), modeSelect),
+			widget.NewFormItem(tr("定时周期 (ms):"), intervalEntry),
+		),
+		widget.NewLabel(tr("主题格式: 前缀/V100.3（位，0/1）、前缀/VW100（字，十进制）")),
+		enableCheck,
+		statusLabel,
+	)
+	return content, current
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// notifyInfoDuration 提示信息显示多久后自动隐藏，错误需手动关闭
+const notifyInfoDuration = 4 * time.Second
+
+var colorNotifyInfo = color.RGBA{R: 30, G: 144, B: 255, A: 255} // 蓝色表示提示
+
+// notifyBar 窗口内的通知条，显示最近一次错误或提示。错误可展开查看技术细节
+// （操作、类别和gos7的原始错误），所有方法只在UI线程中调用。
+type notifyBar struct {
+	content *fyne.Container
+	mark    *canvas.Rectangle
+	message *widget.Label
+	detail  *widget.Label
+	toggle  *widget.Button
+	hideAt  time.Time // 提示的自动隐藏时间，错误为零值
+}
+
+func newNotifyBar() *notifyBar {
+	n := &notifyBar{
+		mark:    canvas.NewRectangle(colorStatusError),
+		message: widget.NewLabel(""),
+		detail:  widget.NewLabel(""),
+	}
+	n.mark.SetMinSize(fyne.NewSize(6, 0))
+	n.message.Wrapping = fyne.TextWrapWord
+	n.detail.Wrapping = fyne.TextWrapWord
+	n.detail.TextStyle = fyne.TextStyle{Monospace: true}
+	n.detail.Hide()
+
+	n.toggle = widget.NewButton(tr("详情"), func() {
+		if n.detail.Visible() {
+			n.detail.Hide()
+			n.toggle.SetText(tr("详情"))
+		} else {
+			n.detail.Show()
+			n.toggle.SetText(tr("收起"))
+		}
+	})
+	closeButton := widget.NewButton(tr("关闭"), n.hide)
+
+	n.content = container.NewBorder(nil, nil, n.mark, container.NewHBox(n.toggle, closeButton),
+		container.NewVBox(n.message, n.detail))
+	n.content.Hide()
+	return n
+}
+
+// showError 显示错误，summary说明失败的操作，技术细节收起在“详情”中
+func (n *notifyBar) showError(summary string, err error) {
+	n.hideAt = time.Time{}
+	n.mark.FillColor = colorStatusError
+	n.mark.Refresh()
+	n.message.SetText(fmt.Sprintf("%s %s: %s", time.Now().Format("15:04:05"), summary, errorText(err)))
+	n.detail.SetText(errorDetail(err))
+	n.detail.Hide()
+	n.toggle.SetText(tr("详情"))
+	n.toggle.Show()
+	n.content.Show()
+}
+
+// showInfo 显示提示，几秒后自动隐藏
+func (n *notifyBar) showInfo(text string) {
+	n.mark.FillColor = colorNotifyInfo
+	n.mark.Refresh()
+	n.message.SetText(fmt.Sprintf("%s %s", time.Now().Format("15:04:05"), text))
+	n.detail.Hide()
+	n.toggle.Hide()
+	n.content.Show()
+
+	hideAt := time.Now().Add(notifyInfoDuration)
+	n.hideAt = hideAt
+	time.AfterFunc(notifyInfoDuration, func() {
+		fyne.Do(func() {
+			// 期间显示了新的通知时不隐藏
+			if n.hideAt.Equal(hideAt) {
+				n.hide()
+			}
+		})
+	})
+}
+
+func (n *notifyBar) hide() {
+	n.hideAt = time.Time{}
+	n.content.Hide()
+}
+
+// errorText 返回错误在当前语言中的文本。PLC读写错误按操作和类别翻译，其它错误整体查找译文，
+// 找不到时显示原文。
+func errorText(err error) string {
+	s7err, ok := err.(*s7viewer.Error)
+	if !ok {
+		return tr(err.Error())
+	}
+	kind := s7err.Err.Error()
+	if s7err.Kind != nil {
+		kind = tr(s7err.Kind.Error())
+	}
+	return fmt.Sprintf(tr("%s失败: %s"), tr(s7err.Op), kind)
+}
+
+// errorDetail 返回错误的技术细节，PLC读写错误分行列出操作、类别和原始错误
+func errorDetail(err error) string {
+	var s7err *s7viewer.Error
+	if !errors.As(err, &s7err) {
+		return fmt.Sprintf("%v\n(%T)", err, err)
+	}
+	kind := tr("未归类")
+	if s7err.Kind != nil {
+		kind = tr(s7err.Kind.Error())
+	}
+	return fmt.Sprintf(tr("操作: %s\n类别: %s\n原始错误: %v"), tr(s7err.Op), kind, s7err.Err)
+}
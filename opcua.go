@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/ua"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// defaultOPCUAPort OPC UA的标准端口
+const defaultOPCUAPort = 4840
+
+// opcuaRestartDelay 状态表或标签修改后等待这么久再按新的变量重启服务，避免输入地址时反复重启
+const opcuaRestartDelay = time.Second
+
+// opcuaTag 发布为节点的一个状态表行
+type opcuaTag struct {
+	row      int    // 状态表中的行号
+	name     string // 节点名，取自位标签或符号名，没有时为地址
+	dataType string // 计算变量为空
+}
+
+// uniqueName 返回不在used中的名字并记入used：name已被使用时依次追加_2、_3……
+func uniqueName(used map[string]bool, name string) string {
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = name + "_" + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// opcuaBridge 内嵌的OPC UA服务器，将状态表中的变量发布为节点，
+// SCADA可以浏览并订阅与查看器轮询相同的数据。节点名取自位标签和导入的符号名。
+type opcuaBridge struct {
+	srv   *server.Server
+	port  int
+	tags  []opcuaTag
+	gen   int // 状态表的代次，只有同一代次的读取结果才按行号对应到节点
+	nodes []*server.Node
+	mu    sync.Mutex
+}
+
+// startOPCUABridge 在host:port上启动OPC UA服务（无加密、匿名访问），为每个变量创建一个节点。
+// gen为取得tags时状态表的代次
+func startOPCUABridge(host string, port int, tags []opcuaTag, gen int) (*opcuaBridge, error) {
+	srv := server.New(
+		server.EndPoint(host, port),
+		server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+	)
+	if err := srv.Start(context.Background()); err != nil {
+		return nil, fmt.Errorf(tr("启动OPC UA服务失败: %v"), err)
+	}
+
+	root, err := srv.Namespace(0)
+	if err != nil {
+		srv.Close()
+		return nil, fmt.Errorf(tr("启动OPC UA服务失败: %v"), err)
+	}
+	ns := server.NewNodeNameSpace(srv, "S7-200 SMART")
+	folder := ns.Objects()
+	root.Objects().AddRef(folder, id.HasComponent, true)
+
+	b := &opcuaBridge{srv: srv, port: port, tags: tags, gen: gen}
+	for _, t := range tags {
+		// 初始值为该类型的零值，计算变量为浮点数，字符串和无法解码的类型为空字符串
+		var zero any = float64(0)
+		if t.dataType != "" {
+			zero = ""
+			if v, err := s7viewer.DecodeTyped(s7viewer.OrderBigEndian, t.dataType, make([]byte, s7viewer.TypeWidth(t.dataType, 0)), 0, 0, 0); err == nil {
+				zero = v
+			}
+		}
+		node := ns.AddNewVariableStringNode(t.name, zero)
+		folder.AddRef(node, id.HasComponent, true)
+		b.nodes = append(b.nodes, node)
+	}
+	return b, nil
+}
+
+// update 用状态表的一次读取结果刷新各节点的值并通知订阅者，状态表在建立节点后有变化时忽略
+func (b *opcuaBridge) update(r *watchResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r.gen != b.gen {
+		return
+	}
+
+	now := time.Now()
+	for i, t := range b.tags {
+		if t.row >= len(r.typed) || r.typed[t.row] == nil {
+			continue
+		}
+		b.nodes[i].SetAttribute(ua.AttributeIDValue, &ua.DataValue{
+			EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+			Value:           ua.MustVariant(r.typed[t.row]),
+			SourceTimestamp: now,
+		})
+		b.srv.ChangeNotification(b.nodes[i].ID())
+	}
+}
+
+// setGen 状态表重新解析后变量没有变化时更新代次，之后的读取结果继续刷新节点
+func (b *opcuaBridge) setGen(gen int) {
+	b.mu.Lock()
+	b.gen = gen
+	b.mu.Unlock()
+}
+
+// close 停止OPC UA服务
+func (b *opcuaBridge) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.srv.Close()
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestUniqueName(t *testing.T) {
+	used := make(map[string]bool)
+	// 已有的名字与追加的序号相同时继续递增
+	tests := []struct {
+		name, want string
+	}{
+		{"Motor", "Motor"},
+		{"Motor_2", "Motor_2"},
+		{"Motor", "Motor_3"},
+		{"Motor", "Motor_4"},
+		{"VW100", "VW100"},
+		{"VW100", "VW100_2"},
+	}
+	for _, tt := range tests {
+		if got := uniqueName(used, tt.name); got != tt.want {
+			t.Errorf("uniqueName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
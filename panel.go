@@ -0,0 +1,1746 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// plcPanel 一台PLC的连接、读取、监控和显示界面。每个标签页一个实例，
+// 各自持有独立的viewer和监控协程。
+type plcPanel struct {
+	content fyne.CanvasObject
+
+	// ip 返回当前填写的PLC地址，用作标签页标题
+	ip func() string
+	// viewer 返回该标签页的viewer，尚未连接过时为nil
+	viewer func() *s7viewer.Viewer
+	// monitoring 返回该PLC是否正在监控
+	monitoring func() bool
+	// metrics 返回该PLC的指标来源，尚未连接过时ok为false
+	metrics func() (src metricsSource, ok bool)
+	// teardown 停止监控并断开连接，关闭标签页时调用，同时撤销该标签页在窗口标题中的报警
+	teardown func()
+	// redraw 按当前的颜色设置重绘位网格
+	redraw func()
+	// 快捷键对应的操作：读取、开始/停止监控、连接、清除显示，以及方向键移动网格的选择光标
+	read          func()
+	toggleMonitor func()
+	connect       func()
+	clearDisplay  func()
+	moveCursor    func(dx, dy int)
+	// bitLabels 返回位标签的副本，看板模式按标签显示位
+	bitLabels func() map[string]string
+	// ensureMonitoring 未连接时连接、已连接未监控时开始监控，看板模式定期调用
+	ensureMonitoring func()
+	// onData、onStatus 不为nil时，每次显示数据和连接状态变化后在UI线程中调用，供看板模式使用
+	onData   func(area string, start int, data []byte)
+	onStatus func(c color.Color, text string)
+	// openFile 离线查看录制或快照文件
+	openFile func(path string)
+	// applyProfile 填入连接配置（IP、连接参数、地址范围、扫描周期和位标签）
+	applyProfile func(pr profile)
+	// session 返回退出时保存的状态，restoreSession 在下次启动时恢复
+	session        func() panelSession
+	restoreSession func(s panelSession)
+	// onIPChanged 修改IP地址后调用，用于更新标签页标题
+	onIPChanged func(ip string)
+}
+
+// newPLCPanel 创建一个PLC标签页，initialIP为预填的IP地址
+func newPLCPanel(prefs fyne.Preferences, myWindow fyne.Window, initialIP string) *plcPanel {
+	panel := &plcPanel{}
+
+	// 本标签页的viewer实例
+	var viewer *s7viewer.Viewer
+	// byteOrder 本标签页多字节数值的字节顺序，连接时设置给viewer。只在UI线程中访问
+	byteOrder := s7viewer.OrderBigEndian
+	// connCtx 本次连接的context，断开连接时取消，尚未完成的读写随之返回。只在UI线程中访问
+	connCtx, cancelConn := context.WithCancel(context.Background())
+
+	// 创建输入控件
+	ipEntry := widget.NewEntry()
+	ipEntry.SetText(initialIP)
+	// 下拉列出最近成功连接的PLC
+	ipEntry.ActionItem = newRecentPLCButton(prefs, ipEntry)
+
+	// 连接参数：机架/槽位/端口/超时，经网关或连接其他CPU系列时修改
+	rackEntry := widget.NewEntry()
+	rackEntry.SetText(strconv.Itoa(s7viewer.DefaultRack))
+	slotEntry := widget.NewEntry()
+	slotEntry.SetText(strconv.Itoa(s7viewer.DefaultSlot))
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(s7viewer.DefaultPort))
+	timeoutEntry := widget.NewEntry()
+	timeoutEntry.SetText(strconv.Itoa(int(s7viewer.DefaultTimeout / time.Second))) // 连接超时（秒）
+	// 读写超时或连接断开后的重试次数和间隔（毫秒）
+	retryEntry := widget.NewEntry()
+	retryEntry.SetText(strconv.Itoa(s7viewer.DefaultRetryPolicy.Count))
+	retryDelayEntry := widget.NewEntry()
+	retryDelayEntry.SetText(strconv.Itoa(int(s7viewer.DefaultRetryPolicy.Delay / time.Millisecond)))
+
+	// connParams 读取界面上的连接参数
+	connParams := func() (s7viewer.Config, error) {
+		cfg := s7viewer.Config{IP: strings.TrimSpace(ipEntry.Text)}
+		var err error
+		if cfg.Rack, err = strconv.Atoi(strings.TrimSpace(rackEntry.Text)); err != nil || cfg.Rack < 0 || cfg.Rack > 7 {
+			return cfg, fmt.Errorf(tr("无效的机架号: %q（0-7）"), rackEntry.Text)
+		}
+		if cfg.Slot, err = strconv.Atoi(strings.TrimSpace(slotEntry.Text)); err != nil || cfg.Slot < 0 || cfg.Slot > 31 {
+			return cfg, fmt.Errorf(tr("无效的槽位号: %q（0-31）"), slotEntry.Text)
+		}
+		if cfg.Port, err = strconv.Atoi(strings.TrimSpace(portEntry.Text)); err != nil || cfg.Port <= 0 || cfg.Port > 65535 {
+			return cfg, fmt.Errorf(tr("无效的端口: %q"), portEntry.Text)
+		}
+		secs, err := strconv.ParseFloat(strings.TrimSpace(timeoutEntry.Text), 64)
+		if err != nil || secs <= 0 {
+			return cfg, fmt.Errorf(tr("无效的超时: %q"), timeoutEntry.Text)
+		}
+		cfg.Timeout = time.Duration(secs * float64(time.Second))
+		if cfg.Retry.Count, err = strconv.Atoi(strings.TrimSpace(retryEntry.Text)); err != nil || cfg.Retry.Count < 0 || cfg.Retry.Count > 10 {
+			return cfg, fmt.Errorf(tr("无效的重试次数: %q（0-10）"), retryEntry.Text)
+		}
+		ms, err := strconv.Atoi(strings.TrimSpace(retryDelayEntry.Text))
+		if err != nil || ms < 0 {
+			return cfg, fmt.Errorf(tr("无效的重试间隔: %q"), retryDelayEntry.Text)
+		}
+		cfg.Retry.Delay = time.Duration(ms) * time.Millisecond
+		return cfg, nil
+	}
+
+	// 存储区选择，默认V区
+	areaSelect := widget.NewSelect(s7viewer.MemoryAreas, nil)
+	areaSelect.SetSelected(s7viewer.AreaV)
+
+	addressEntry := widget.NewEntry()
+	addressEntry.SetText("100") // 默认从V100开始
+	addressEntry.SetPlaceHolder(tr("100 或 VW100、V100.3、M10.1、IW4"))
+
+	lengthEntry := widget.NewEntry()
+	lengthEntry.SetText("1") // 默认长度为1字节
+
+	// 输入S7地址时自动切换存储区并填入隐含长度
+	addressEntry.OnChanged = func(text string) {
+		if addr, err := parseAddress(text); err == nil {
+			areaSelect.SetSelected(addr.area)
+			lengthEntry.SetText(strconv.Itoa(addr.length()))
+		}
+	}
+
+	// 扫描周期（毫秒），修改后立即应用到正在运行的监控
+	scanInterval := time.Duration(prefs.IntWithFallback(prefScanMs, int(s7viewer.DefaultScanInterval/time.Millisecond))) * time.Millisecond
+	scanEntry := widget.NewEntry()
+	scanEntry.SetText(strconv.Itoa(int(scanInterval / time.Millisecond)))
+	cycleLabel := widget.NewLabel(tr("实际周期: -"))
+	scanEntry.OnSubmitted = func(text string) {
+		ms, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			log.Printf(tr("无效的扫描周期: %v"), err)
+			return
+		}
+		scanInterval = max(s7viewer.MinScanInterval, min(time.Duration(ms)*time.Millisecond, s7viewer.MaxScanInterval))
+		scanEntry.SetText(strconv.Itoa(int(scanInterval / time.Millisecond)))
+		prefs.SetInt(prefScanMs, int(scanInterval/time.Millisecond))
+		if viewer != nil {
+			viewer.SetScanInterval(scanInterval)
+		}
+		log.Printf(tr("扫描周期已设置为 %v"), scanInterval)
+	}
+
+	healthEntry := widget.NewEntry()
+	healthEntry.SetText(strconv.Itoa(int(s7viewer.DefaultHealthInterval / time.Second))) // 心跳检测周期（秒）
+
+	// 状态表和结构化视图读取时合并相邻地址的最大间隔（字节），-1表示不合并
+	coalesceGap := prefs.IntWithFallback(prefCoalesce, s7viewer.DefaultCoalesceGap)
+	gapEntry := widget.NewEntry()
+	gapEntry.SetText(strconv.Itoa(coalesceGap))
+	gapEntry.OnSubmitted = func(text string) {
+		gap, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			log.Printf(tr("无效的合并间隔: %v"), err)
+			return
+		}
+		coalesceGap = max(gap, -1)
+		gapEntry.SetText(strconv.Itoa(coalesceGap))
+		prefs.SetInt(prefCoalesce, coalesceGap)
+		if viewer != nil {
+			viewer.SetCoalesceGap(coalesceGap)
+		}
+		log.Printf(tr("合并间隔已设置为 %d字节"), coalesceGap)
+	}
+
+	// 位标签：地址（如 V100.3）到标签的映射，随连接配置保存
+	labels := make(map[string]string)
+	// labelsChanged 标签变化后刷新显示，在结构化视图创建后赋值
+	labelsChanged := func() {}
+
+	// 连接配置：选择已保存的PLC后填入IP、地址、长度、扫描周期和位标签
+	var profiles []profile
+	profilePath, err := profilesPath()
+	if err == nil {
+		profiles, err = loadProfiles(profilePath)
+	}
+	if err != nil {
+		log.Printf(tr("加载连接配置失败: %v"), err)
+	}
+	// applyProfile 填入连接配置的各项，选择已保存的PLC和载入配置文件时调用
+	applyProfile := func(pr profile) {
+		ipEntry.SetText(pr.IP)
+		rackEntry.SetText(strconv.Itoa(pr.Rack))
+		slotEntry.SetText(strconv.Itoa(pr.Slot))
+		if pr.Port > 0 {
+			portEntry.SetText(strconv.Itoa(pr.Port))
+		}
+		if pr.TimeoutMs > 0 {
+			timeoutEntry.SetText(strconv.FormatFloat(float64(pr.TimeoutMs)/1000, 'f', -1, 64))
+		}
+		retryEntry.SetText(strconv.Itoa(pr.Retries))
+		retryDelayEntry.SetText(strconv.Itoa(pr.RetryDelayMs))
+		if pr.Area != "" {
+			areaSelect.SetSelected(pr.Area)
+		}
+		if pr.Address != "" {
+			addressEntry.SetText(pr.Address)
+		}
+		if pr.Length > 0 {
+			lengthEntry.SetText(strconv.Itoa(pr.Length))
+		}
+		if pr.ScanMs > 0 {
+			scanEntry.SetText(strconv.Itoa(pr.ScanMs))
+			scanEntry.OnSubmitted(scanEntry.Text)
+		}
+		labels = maps.Clone(pr.Labels)
+		if pr.Colors != nil {
+			pr.Colors.apply(prefs)
+			panel.redraw()
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labelsChanged()
+		if pr.Name != "" {
+			log.Printf(tr("已载入配置 %s (%s)"), pr.Name, pr.IP)
+		}
+	}
+	profileSelect := widget.NewSelect(profileNames(profiles), func(name string) {
+		if pr, ok := findProfile(profiles, name); ok {
+			applyProfile(pr)
+		}
+	})
+	profileSelect.PlaceHolder = tr("选择已保存的PLC")
+
+	storeProfiles := func(updated []profile) {
+		if profilePath == "" {
+			dialog.ShowError(errors.New(tr("无法确定配置目录，配置未保存")), myWindow)
+			return
+		}
+		if err := saveProfiles(profilePath, updated); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		profiles = updated
+		profileSelect.Options = profileNames(profiles)
+		profileSelect.Refresh()
+	}
+
+	saveProfileButton := widget.NewButton(tr("保存配置"), func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(profileSelect.Selected)
+		nameEntry.SetPlaceHolder(tr("例如：1号线 包装机"))
+		dialog.ShowForm(tr("保存连接配置"), tr("保存"), tr("取消"),
+			[]*widget.FormItem{widget.NewFormItem(tr("名称:"), nameEntry)},
+			func(ok bool) {
+				name := strings.TrimSpace(nameEntry.Text)
+				if !ok || name == "" {
+					return
+				}
+				cfg, err := connParams()
+				if err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+				length, _ := strconv.Atoi(strings.TrimSpace(lengthEntry.Text))
+				storeProfiles(upsertProfile(profiles, profile{
+					Name:         name,
+					IP:           cfg.IP,
+					Rack:         cfg.Rack,
+					Slot:         cfg.Slot,
+					Port:         cfg.Port,
+					TimeoutMs:    int(cfg.Timeout / time.Millisecond),
+					Retries:      cfg.Retry.Count,
+					RetryDelayMs: int(cfg.Retry.Delay / time.Millisecond),
+					Area:         areaSelect.Selected,
+					Address:      strings.TrimSpace(addressEntry.Text),
+					Length:       length,
+					ScanMs:       int(scanInterval / time.Millisecond),
+					Labels:       maps.Clone(labels),
+					Colors:       currentProfileColors(prefs),
+				}))
+				profileSelect.SetSelected(name)
+				log.Printf(tr("已保存配置 %s"), name)
+			}, myWindow)
+	})
+
+	deleteProfileButton := widget.NewButton(tr("删除配置"), func() {
+		name := profileSelect.Selected
+		if name == "" {
+			return
+		}
+		dialog.ShowConfirm(tr("删除配置"), fmt.Sprintf(tr("删除配置 %s？"), name), func(ok bool) {
+			if !ok {
+				return
+			}
+			storeProfiles(removeProfile(profiles, name))
+			profileSelect.ClearSelected()
+			log.Printf(tr("已删除配置 %s"), name)
+		}, myWindow)
+	})
+
+	// saveLabels 标签变化后刷新显示，已选择连接配置时立即保存到该配置
+	saveLabels := func() {
+		labelsChanged()
+		if pr, found := findProfile(profiles, profileSelect.Selected); found {
+			pr.Labels = maps.Clone(labels)
+			storeProfiles(upsertProfile(profiles, pr))
+		} else {
+			log.Print(tr("未选择连接配置，位标签将在保存配置时一并保存"))
+		}
+	}
+
+	// editLabel 编辑位地址的标签（网格中右键点击位）
+	editLabel := func(addr string) {
+		labelEntry := widget.NewEntry()
+		labelEntry.SetText(labels[addr])
+		labelEntry.SetPlaceHolder(tr("例如：1号电机运行"))
+		dialog.ShowForm(tr("位标签 ")+addr, tr("确定"), tr("取消"),
+			[]*widget.FormItem{widget.NewFormItem(tr("标签:"), labelEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				if label := strings.TrimSpace(labelEntry.Text); label != "" {
+					labels[addr] = label
+				} else {
+					delete(labels, addr)
+				}
+				saveLabels()
+			}, myWindow)
+	}
+
+	// 写入后读回校验，默认开启
+	verifyWrite := true
+
+	// V区访问方式，默认DB1（失败回退MB）
+	vAccess := prefs.StringWithFallback(prefVAccess, s7viewer.VAccessAuto)
+	accessLabel := widget.NewLabel(tr("读取方式: ") + tr(vAccess))
+	accessSelect := newTrSelect(s7viewer.VAccessModes, func(mode string) {
+		vAccess = mode
+		prefs.SetString(prefVAccess, mode)
+		if viewer != nil {
+			viewer.SetVAccess(mode)
+		}
+		accessLabel.SetText(tr("读取方式: ") + tr(mode))
+	})
+	accessSelect.SetSelected(tr(vAccess))
+
+	// 连接状态指示灯和状态文字
+	statusDot := canvas.NewCircle(colorBitOff)
+	statusLabel := widget.NewLabel(tr("未连接"))
+	setStatus := func(c color.Color, text string) {
+		statusDot.FillColor = c
+		statusDot.Refresh()
+		statusLabel.SetText(text)
+		if panel.onStatus != nil {
+			panel.onStatus(c, text)
+		}
+	}
+
+	// 通知条：连接、读写等失败显示在窗口内，不只输出到日志
+	notify := newNotifyBar()
+	reportError := func(summary string, err error) {
+		log.Printf("%s: %v", summary, err)
+		notify.showError(summary, err)
+	}
+
+	// 创建显示区域的容器
+	displayContainer := container.NewVBox()
+
+	// 网格样式与缩放，持久化到偏好设置
+	gridStyle := prefs.StringWithFallback(prefGridStyle, gridStyleSquare)
+	cellSize := float32(prefs.FloatWithFallback(prefGridSize, defaultCellSize))
+
+	// 点击行/列标题屏蔽的行列
+	mask := newMuteMask(prefs.IntList(prefMutedRows), prefs.IntList(prefMutedCols))
+
+	// toggleBit 双击网格位时确认后写入取反值，只读模式下不响应，在读取按钮创建后赋值。
+	// 参数为相对起始地址的位索引（已计入分页偏移）
+	var toggleBit func(bitIndex int)
+
+	// lastData 记录最近一次显示的数据，切换样式时用于重绘
+	var lastData []byte
+	// lastCapture 记录最近一次成功读取的数据，用于导出
+	var lastCapture *capture
+	// recheck 通过viewer的写入成功后延时读回复核，开关和结果显示在写入面板
+	recheck := newWriteRechecker()
+	// exportNote 最近一次导出时填写的备注，预填到下次导出，随会话保存
+	var exportNote string
+
+	// grid 当前显示的网格，nil表示尚未显示
+	var grid *bitGrid
+	// stats 每次读取和扫描中各位的边沿次数和接通时间，边沿次数显示在悬停提示中
+	stats := &bitStats{}
+	statsTable := newBitStatsTable(stats, func(addr string) string { return labels[addr] })
+	// cursorBit 选择光标在当前页中的位索引，-1表示没有光标；重建网格时保留
+	cursorBit := -1
+	cursorLabel := widget.NewLabel("")
+
+	// 超过一页网格（默认32列×20行=80字节）的数据分页显示
+	gridCols := prefs.IntWithFallback(prefGridCols, defaultGridCols)
+	gridRows := prefs.IntWithFallback(prefGridRows, defaultGridRows)
+	bitZeroLeft = prefs.StringWithFallback(prefBitOrder, bitOrderMSBLeft) == bitOrderLSBLeft
+	page := 0
+	pageLabel := widget.NewLabel(tr("第 1/1 页"))
+	pageCount := func() int {
+		return max(1, (len(lastData)+gridCols*gridRows/8-1)/(gridCols*gridRows/8))
+	}
+
+	// 位变化高亮时长（毫秒），0表示关闭
+	flashMs := prefs.IntWithFallback(prefFlashMs, int(defaultFlashDuration/time.Millisecond))
+	flashEntry := widget.NewEntry()
+	flashEntry.SetText(strconv.Itoa(flashMs))
+	flashEntry.OnChanged = func(text string) {
+		ms, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || ms < 0 {
+			return
+		}
+		flashMs = ms
+		prefs.SetInt(prefFlashMs, ms)
+		if grid != nil {
+			grid.setFlash(time.Duration(ms) * time.Millisecond)
+		}
+	}
+
+	// showPage 将lastData中当前页的数据显示到网格。
+	// changes为true时与上次显示比较并高亮变化的位，翻页时不比较
+	showPage := func(changes bool) {
+		page = max(0, min(page, pageCount()-1))
+		size := grid.pageBytes()
+		from := min(page*size, len(lastData))
+		grid.setRowOffset(page * size)
+		pageData := lastData[from:min(from+size, len(lastData))]
+		if changes {
+			grid.showChanges(pageData)
+		} else {
+			grid.clearChanges()
+			grid.showBytes(pageData)
+		}
+		pageLabel.SetText(fmt.Sprintf(tr("第 %d/%d 页"), page+1, pageCount()))
+	}
+
+	// showCursor 在状态栏显示选择光标处的地址、值和标签
+	showCursor := func() {
+		if grid == nil || cursorBit < 0 || lastCapture == nil {
+			cursorLabel.SetText("")
+			return
+		}
+		addr := bitAddressName(lastCapture.Area, lastCapture.StartAddress, page*grid.pageBytes()*8+cursorBit)
+		text := addr
+		if v, ok := grid.bitValue(cursorBit); ok {
+			text = fmt.Sprintf("%s = %d", addr, v)
+		}
+		if label := labels[addr]; label != "" {
+			text += "  " + label
+		}
+		cursorLabel.SetText(text)
+	}
+
+	// showGrid 按当前样式重建网格并显示数据
+	showGrid := func(data []byte) {
+		grid = newBitGrid(gridCols, gridRows, gridStyle, cellSize, mask)
+		grid.setFlash(time.Duration(flashMs) * time.Millisecond)
+		grid.onMaskChanged = func() {
+			prefs.SetIntList(prefMutedRows, mask.rowList())
+			prefs.SetIntList(prefMutedCols, mask.colList())
+		}
+		grid.onBitDoubleTapped = func(bitIndex int) {
+			toggleBit(page*grid.pageBytes()*8 + bitIndex)
+		}
+		grid.bitAddress = func(bitIndex int) string {
+			if lastCapture == nil {
+				return ""
+			}
+			return bitAddressName(lastCapture.Area, lastCapture.StartAddress, page*grid.pageBytes()*8+bitIndex)
+		}
+		grid.bitLabel = func(addr string) string { return labels[addr] }
+		grid.bitEdges = func(bitIndex int) (int, int, bool) {
+			return stats.counts(page*grid.pageBytes()*8 + bitIndex)
+		}
+		grid.onBitSecondaryTapped = func(bitIndex int) {
+			if lastCapture != nil {
+				editLabel(bitAddressName(lastCapture.Area, lastCapture.StartAddress, page*grid.pageBytes()*8+bitIndex))
+			}
+		}
+		lastData = data
+		showPage(false)
+		grid.setCursor(cursorBit)
+		showCursor()
+		displayContainer.Objects = []fyne.CanvasObject{grid.content}
+		displayContainer.Refresh()
+	}
+
+	// updateGrid 在现有网格上原地更新数据，避免监控时反复重建
+	updateGrid := func(data []byte) {
+		if grid == nil {
+			showGrid(data)
+			return
+		}
+		lastData = data
+		showPage(true)
+		showCursor()
+	}
+
+	prevPageButton := widget.NewButton(tr("上一页"), func() {
+		if grid != nil && page > 0 {
+			page--
+			showPage(false)
+			showCursor()
+		}
+	})
+	nextPageButton := widget.NewButton(tr("下一页"), func() {
+		if grid != nil && page < pageCount()-1 {
+			page++
+			showPage(false)
+			showCursor()
+		}
+	})
+
+	styleSelect := newTrSelect(gridStyles, func(style string) {
+		gridStyle = style
+		prefs.SetString(prefGridStyle, style)
+		if grid != nil {
+			showGrid(lastData)
+		}
+	})
+	styleSelect.SetSelected(tr(gridStyle))
+
+	zoomSlider := widget.NewSlider(minCellSize, maxCellSize)
+	zoomSlider.SetValue(float64(cellSize))
+	zoomSlider.OnChangeEnded = func(v float64) {
+		cellSize = float32(v)
+		prefs.SetFloat(prefGridSize, v)
+		if grid != nil {
+			showGrid(lastData)
+		}
+	}
+
+	// 网格每行的位数、每页的行数和字节内位的顺序
+	colsSelect := widget.NewSelect(gridColumnChoices, func(text string) {
+		cols, _ := strconv.Atoi(text)
+		if cols == gridCols {
+			return
+		}
+		gridCols = cols
+		prefs.SetInt(prefGridCols, cols)
+		if grid != nil {
+			showGrid(lastData)
+		}
+	})
+	colsSelect.SetSelected(strconv.Itoa(gridCols))
+	rowsEntry := widget.NewEntry()
+	rowsEntry.SetText(strconv.Itoa(gridRows))
+	rowsEntry.OnSubmitted = func(text string) {
+		rows, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || rows < 1 || rows > maxGridRows {
+			notify.showInfo(fmt.Sprintf(tr("行数应为1-%d"), maxGridRows))
+			return
+		}
+		gridRows = rows
+		prefs.SetInt(prefGridRows, rows)
+		if grid != nil {
+			showGrid(lastData)
+		}
+	}
+	bitOrderSelect := newTrSelect(bitOrders, func(order string) {
+		bitZeroLeft = order == bitOrderLSBLeft
+		prefs.SetString(prefBitOrder, order)
+		if grid != nil {
+			showGrid(lastData)
+		}
+	})
+	bitOrderSelect.SetSelected(tr(prefs.StringWithFallback(prefBitOrder, bitOrderMSBLeft)))
+
+	// 条件报警：满足条件的上升沿时响铃并闪烁窗口标题
+	var alarm *quickAlarm
+	alarmEntry := widget.NewEntry()
+	alarmEntry.SetPlaceHolder(tr("例如 V100.0 == 1 或 VW120 > 500"))
+	alarmLabel := widget.NewLabel(tr("报警: 未启用"))
+	alarmCheck := widget.NewCheck(tr("启用报警"), func(enabled bool) {
+		alarm = nil
+		if !enabled {
+			alarmLabel.SetText(tr("报警: 未启用"))
+			return
+		}
+		cond, err := parseCondition(alarmEntry.Text)
+		if err != nil {
+			alarmLabel.SetText(tr("报警: 条件无效"))
+			log.Printf(tr("报警条件无效: %v"), err)
+			return
+		}
+		alarm = &quickAlarm{cond: cond}
+		alarmLabel.SetText(tr("报警: 已布防 [") + cond.text + "]")
+	})
+	alarmEntry.OnChanged = func(string) {
+		// 修改条件后需重新启用
+		alarmCheck.SetChecked(false)
+	}
+
+	// setTitleAlarm 设置本标签页在窗口标题中显示的报警，标签页关闭后不再设置
+	titleClosed := false
+	setTitleAlarm := func(text string) {
+		if !titleClosed {
+			setAlarmTitle(myWindow, panel, text)
+		}
+	}
+
+	// flashTitle 交替显示报警标题以提示用户
+	flashTitle := func(text string) {
+		go func() {
+			for i := 0; i < 6; i++ {
+				title := ""
+				if i%2 == 0 {
+					title = text
+				}
+				fyne.Do(func() { setTitleAlarm(title) })
+				time.Sleep(500 * time.Millisecond)
+			}
+			fyne.Do(func() { setTitleAlarm("") })
+		}()
+	}
+
+	// checkAlarm 每次读取到新数据后求值报警条件
+	checkAlarm := func(startAddress int, data []byte) {
+		if alarm.check(byteOrder, startAddress, data) {
+			text := alarm.cond.text
+			if label := labels[alarm.cond.addr.String()]; label != "" {
+				text += " (" + label + ")"
+			}
+			log.Printf(tr("报警触发: %s %s"), strings.TrimSpace(ipEntry.Text), text)
+			beep()
+			flashTitle(strings.TrimSpace(ipEntry.Text) + " " + text)
+			if prefs.BoolWithFallback(prefAlarmNotify, true) {
+				sendDesktopNotification(alarmEvent{time: time.Now(), plc: strings.TrimSpace(ipEntry.Text), tag: text})
+			}
+		}
+	}
+
+	// 结构化视图：显示导入的数据块定义中各变量的值
+	layoutView := newLayoutTable()
+	layoutView.labelOf = func(addr string) string { return labels[addr] }
+
+	// 状态表在下面创建，OPC UA服务发布其中的变量
+	var watch *watchTable
+
+	// OPC UA服务：将状态表中的变量发布为节点，节点名取自位标签和导入的符号名，读取或监控时刷新
+	var opcua *opcuaBridge
+	opcuaPortEntry := widget.NewEntry()
+	opcuaPortEntry.SetText(strconv.Itoa(defaultOPCUAPort))
+	opcuaLabel := widget.NewLabel(tr("OPC UA: 未启用"))
+	var opcuaCheck *widget.Check
+	// opcuaNameOf 返回地址的节点名：位标签，其次是结构化视图中同一地址的变量名（导入的符号）
+	opcuaNameOf := func(addr string) string {
+		if label := labels[addr]; label != "" {
+			return label
+		}
+		for _, e := range layoutView.currentEntries() {
+			if e.Addr.String() == addr {
+				return e.Name
+			}
+		}
+		return ""
+	}
+	// restartOPCUA 按状态表当前的变量（重新）启动服务，未勾选时停止。变量和节点名都没有变化时不重启
+	restartOPCUA := func() {
+		if !opcuaCheck.Checked {
+			if opcua != nil {
+				opcua.close()
+				opcua = nil
+			}
+			opcuaLabel.SetText(tr("OPC UA: 未启用"))
+			return
+		}
+		tags, gen := watch.opcuaTags(opcuaNameOf)
+		port, err := strconv.Atoi(strings.TrimSpace(opcuaPortEntry.Text))
+		if opcua != nil && opcua.port == port && slices.Equal(opcua.tags, tags) {
+			opcua.setGen(gen)
+			return
+		}
+		if opcua != nil {
+			opcua.close()
+			opcua = nil
+		}
+		if len(tags) == 0 {
+			opcuaLabel.SetText(tr("OPC UA: 状态表中没有变量"))
+			return
+		}
+		if err != nil || port <= 0 || port > 65535 {
+			opcuaLabel.SetText(tr("OPC UA: 端口无效"))
+			return
+		}
+		host, err := os.Hostname()
+		if err != nil {
+			host = "localhost"
+		}
+		b, err := startOPCUABridge(host, port, tags, gen)
+		if err != nil {
+			opcuaLabel.SetText(tr("OPC UA: 启动失败"))
+			log.Printf("%v", err)
+			return
+		}
+		opcua = b
+		opcuaLabel.SetText(fmt.Sprintf(tr("OPC UA: opc.tcp://%s:%d (%d个变量)"), host, port, len(tags)))
+		log.Printf(tr("OPC UA服务已启动: opc.tcp://%s:%d"), host, port)
+	}
+	// scheduleOPCUA 状态表、标签或符号变化后稍等再调用restartOPCUA，连续修改时只调用一次
+	var opcuaTimer *time.Timer
+	scheduleOPCUA := func() {
+		if !opcuaCheck.Checked {
+			return
+		}
+		if opcuaTimer != nil {
+			opcuaTimer.Stop()
+		}
+		opcuaTimer = time.AfterFunc(opcuaRestartDelay, func() { fyne.Do(restartOPCUA) })
+	}
+	labelsChanged = func() {
+		layoutView.table.Refresh()
+		scheduleOPCUA()
+	}
+	opcuaCheck = widget.NewCheck(tr("启用OPC UA服务"), func(bool) { restartOPCUA() })
+	importButton := widget.NewButton(tr("导入数据块"), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				log.Printf(tr("导入数据块失败: %v"), err)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+
+			entries, problems, err := parseDataBlockLayout(reader)
+			for _, p := range problems {
+				log.Printf(tr("数据块定义: %s"), p)
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf(tr("导入数据块失败: %v"), err), myWindow)
+				return
+			}
+			layoutView.setEntries(entries)
+			scheduleOPCUA()
+			log.Printf(tr("已导入%d个变量"), len(entries))
+			if len(problems) > 0 {
+				dialog.ShowInformation(tr("部分行未导入"),
+					fmt.Sprintf(tr("已导入%d个变量，以下%d行被跳过:\n%s"), len(entries), len(problems), strings.Join(problems, "\n")),
+					myWindow)
+			}
+		}, myWindow)
+	})
+
+	// 导入Micro/WIN SMART符号表：位符号作为位标签，V区符号作为结构化视图的变量
+	importSymbolsButton := widget.NewButton(tr("导入符号表"), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				log.Printf(tr("导入符号表失败: %v"), err)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+
+			symbols, problems, err := parseSymbolTable(reader)
+			for _, p := range problems {
+				log.Printf(tr("符号表: %s"), p)
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf(tr("导入符号表失败: %v"), err), myWindow)
+				return
+			}
+			imported := symbolLabels(symbols)
+			maps.Copy(labels, imported)
+			saveLabels()
+			entries := symbolLayoutEntries(symbols)
+			if len(entries) > 0 {
+				layoutView.setEntries(entries)
+			}
+			scheduleOPCUA()
+			log.Printf(tr("已导入%d个符号（%d个位标签，%d个V区变量）"), len(symbols), len(imported), len(entries))
+			if len(problems) > 0 {
+				dialog.ShowInformation(tr("部分行未导入"),
+					fmt.Sprintf(tr("已导入%d个符号，以下%d行被跳过:\n%s"), len(symbols), len(problems), strings.Join(problems, "\n")),
+					myWindow)
+			}
+		}, myWindow)
+	})
+
+	// 创建寄存器内容显示文本框
+	registerContentEntry := widget.NewMultiLineEntry()
+	registerContentEntry.SetPlaceHolder(tr("寄存器内容按所选格式显示，用逗号分隔"))
+	registerContentEntry.Wrapping = fyne.TextWrapOff // 修正：使用正确的类型
+	registerContentEntry.Resize(fyne.NewSize(850, 50))
+
+	// 寄存器内容的显示格式，切换时按最近一次读取的数据重新显示
+	registerFormat := prefs.StringWithFallback(prefRegisterFormat, formatUnsigned)
+	var lastRegisterData []byte
+	formatSelect := newTrSelect(registerFormats, func(format string) {
+		registerFormat = format
+		prefs.SetString(prefRegisterFormat, format)
+		if lastRegisterData != nil {
+			registerContentEntry.SetText(formatRegisters(format, byteOrder, lastRegisterData))
+		}
+	})
+	formatSelect.SetSelected(tr(registerFormat))
+
+	// 多字节数值的字节顺序，作用于本标签页的所有解码和写入
+	orderSelect := newTrSelect(s7viewer.ByteOrders, func(order string) {
+		byteOrder = order
+		if viewer != nil {
+			viewer.SetByteOrder(order)
+		}
+		prefs.SetString(prefByteOrder, order)
+		if lastRegisterData != nil {
+			registerContentEntry.SetText(formatRegisters(registerFormat, byteOrder, lastRegisterData))
+		}
+	})
+	orderSelect.SetSelected(tr(prefs.StringWithFallback(prefByteOrder, s7viewer.OrderBigEndian)))
+
+	// PLC信息页，连接成功后读取CPU信息和时钟
+	plcInfo := newPLCInfoPanel(myWindow, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx },
+		func() string { return strings.TrimSpace(ipEntry.Text) })
+	// 状态栏中的CPU运行状态和RUN/STOP按钮，连接期间持续刷新
+	cpu := newCPUControl(myWindow, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx },
+		func() string { return strings.TrimSpace(ipEntry.Text) })
+
+	// 强制表：连接期间反复写入强制的位，强制时窗口顶部显示横幅
+	forces := &forceTable{plc: func() string { return strings.TrimSpace(ipEntry.Text) }}
+	forceBanner, refreshForceBanner := newForceBanner(forces)
+	forcePanel, refreshForcePanel := newForcePanel(forces, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx })
+	forces.onChange = func() {
+		refreshForceBanner()
+		refreshForcePanel()
+	}
+
+	// 只读模式：Viewer拒绝所有写入，开启时释放全部强制
+	onReadOnlyChange(func(on bool) {
+		if viewer != nil {
+			viewer.SetReadOnly(on)
+		}
+		if on {
+			forces.releaseAll()
+		}
+	})
+
+	// 创建连接按钮
+	connectButton := widget.NewButton(tr("连接PLC"), func() {
+		ip := strings.TrimSpace(ipEntry.Text)
+		if ip == "" {
+			log.Println(tr("请输入PLC IP地址"))
+			notify.showInfo(tr("请输入PLC IP地址"))
+			return
+		}
+		cfg, err := connParams()
+		if err != nil {
+			reportError(tr("连接参数错误"), err)
+			return
+		}
+
+		if viewer == nil {
+			viewer = s7viewer.New()
+			viewer.SetReadOnly(isReadOnly())
+			viewer.SetVAccess(vAccess)
+			viewer.SetScanInterval(scanInterval)
+			viewer.SetVerifyWrite(verifyWrite)
+			viewer.SetCoalesceGap(coalesceGap)
+			viewer.SetByteOrder(byteOrder)
+		}
+
+		// 写入回调在写入的协程中调用，审计记录使用连接时的地址
+		viewer.SetWriteHandler(recheck.writeHandler(viewer, auditWriteHandler(func() string { return ip })))
+
+		forces.releaseAll()
+		cancelConn()
+		connCtx, cancelConn = context.WithCancel(context.Background())
+		if err := viewer.Connect(cfg); err != nil {
+			setStatus(colorStatusError, tr("连接失败"))
+			reportError(tr("连接失败"), err)
+			return
+		}
+
+		// 状态栏显示协商的PDU长度，决定单次请求能读取多少字节
+		connected := tr("已连接 ") + ip
+		if pdu := viewer.PDULength(); pdu > 0 {
+			connected += fmt.Sprintf(tr("（PDU %d字节）"), pdu)
+			log.Printf(tr("PLC连接成功! 协商的PDU长度: %d字节"), pdu)
+		} else {
+			log.Println(tr("PLC连接成功!"))
+		}
+		setStatus(colorBitOn, connected)
+		notify.showInfo(connected)
+		addRecentPLC(prefs, ip)
+		plcInfo.refresh()
+		cpu.start()
+
+		// 链路中断时自动重连，监控在重连成功后自动恢复
+		viewer.SetStateHandler(func(connected bool, text string) {
+			fyne.Do(func() {
+				if connected {
+					setStatus(colorBitOn, text)
+					notify.showInfo(text)
+				} else {
+					setStatus(colorStatusWarn, text)
+				}
+			})
+		})
+
+		// 监控读取失败已由viewer记录日志，这里只显示
+		viewer.SetErrorHandler(func(err error) {
+			fyne.Do(func() {
+				notify.showError(tr("监控读取失败"), err)
+				if grid != nil {
+					grid.setStale()
+				}
+			})
+		})
+
+		interval := s7viewer.DefaultHealthInterval
+		if secs, err := strconv.Atoi(strings.TrimSpace(healthEntry.Text)); err == nil && secs > 0 {
+			interval = time.Duration(secs) * time.Second
+		}
+		viewer.StartHealthCheck(interval, func(err error) {
+			fyne.Do(func() {
+				if err != nil {
+					setStatus(colorStatusError, tr("连接异常"))
+					reportError(tr("心跳检测失败"), err)
+					return
+				}
+				setStatus(colorBitOn, connected)
+			})
+		})
+	})
+
+	// 创建读取按钮（单次读取）
+	// parseRange 解析起始地址和长度，超出一页网格的数据分页显示
+	parseRange := func() (area string, startAddress int, bytesToRead int, ok bool) {
+		area = areaSelect.Selected
+		addressStr := strings.TrimSpace(addressEntry.Text)
+		startAddress, err := strconv.Atoi(addressStr)
+		if err != nil {
+			// 非纯数字时按S7地址解析，如 VW100、M10.1、IW4
+			addr, err := parseAddress(addressStr)
+			if err != nil {
+				log.Printf(tr("无效的地址: %v"), err)
+				return "", 0, 0, false
+			}
+			area, startAddress = addr.area, addr.byteOff
+		}
+
+		lengthStr := strings.TrimSpace(lengthEntry.Text)
+		length, err := strconv.Atoi(lengthStr)
+		if err != nil {
+			log.Printf(tr("无效的长度: %v"), err)
+			return "", 0, 0, false
+		}
+
+		bytesToRead = length
+		if bytesToRead <= 0 {
+			bytesToRead = 1
+		}
+		return area, startAddress, bytesToRead, true
+	}
+
+	// readLayout 读取结构化视图中所有变量覆盖的范围，未导入或读取失败时返回nil
+	readLayout := func(ctx context.Context) (int, []byte) {
+		start, size := layoutView.span()
+		if size == 0 {
+			return 0, nil
+		}
+		if size <= viewer.ChunkSize() {
+			data, err := viewer.ReadRange(ctx, s7viewer.AreaV, start, size)
+			if err != nil {
+				log.Printf(tr("读取结构化视图数据失败: %v"), err)
+				return 0, nil
+			}
+			return start, data
+		}
+
+		// 变量分散在较大范围内时只读取各变量本身，合并为多变量请求
+		items := layoutView.items()
+		if err := viewer.ReadItems(ctx, items); err != nil {
+			log.Printf(tr("读取结构化视图数据失败: %v"), err)
+			return 0, nil
+		}
+		data := make([]byte, size)
+		for _, it := range items {
+			if it.Err != nil {
+				log.Printf(tr("读取结构化视图数据失败: %v"), it.Err)
+				return 0, nil
+			}
+			copy(data[it.Start-start:], it.Data)
+		}
+		return start, data
+	}
+
+	// 计算变量：先于趋势图和状态表载入，它们的设置中可以引用计算变量；修改后状态表重新解析各行
+	computedSettings := newComputedSettings(prefs, func() {
+		if watch != nil {
+			watch.rowsChanged()
+		}
+	})
+
+	// 趋势图：V区字、双字和实数随时间的曲线
+	trendPanel, addTrend := newTrendPanel(prefs)
+	// 时序图：选定的位随时间的波形
+	timingPanel, addTiming := newTimingPanel(prefs, func(addr string) string { return labels[addr] })
+
+	// mutedBit 判断范围内的位是否被屏蔽：屏蔽按网格的行和列设置，每页相同
+	mutedBit := func(bitIndex int) bool {
+		return grid != nil && grid.isMuted(bitIndex%(grid.pageBytes()*8))
+	}
+
+	// showData 显示一次读取结果：寄存器内容、网格（原地更新）、报警、结构化视图、趋势图和时序图
+	showData := func(area string, startAddress int, dataBytes []byte, layoutStart int, layoutData []byte) {
+		// 按选择的显示格式显示寄存器内容
+		registerContentEntry.SetText(formatRegisters(registerFormat, byteOrder, dataBytes))
+		lastRegisterData = dataBytes
+
+		// 将字节数据转换为二进制位并填充到网格中
+		stats.update(time.Now(), area, startAddress, dataBytes, mutedBit)
+		statsTable.update()
+		updateGrid(dataBytes)
+		if panel.onData != nil {
+			panel.onData(area, startAddress, dataBytes)
+		}
+		if area == s7viewer.AreaV {
+			checkAlarm(startAddress, dataBytes)
+		}
+		addTrend(time.Now(), byteOrder, area, startAddress, dataBytes)
+		addTiming(time.Now(), area, startAddress, dataBytes)
+
+		if layoutData != nil {
+			layoutView.update(byteOrder, layoutStart, layoutData)
+		}
+
+		lastCapture = &capture{
+			Time:         time.Now(),
+			IP:           strings.TrimSpace(ipEntry.Text),
+			Area:         area,
+			StartAddress: startAddress,
+			Data:         dataBytes,
+		}
+	}
+
+	// showOffline 显示录制或快照文件中的数据，不需要连接PLC；监控运行时拒绝，返回false
+	showOffline := func(t time.Time, plc, area string, startAddress int, dataBytes []byte) bool {
+		if viewer != nil && viewer.IsMonitoring() {
+			log.Println(tr("请先停止监控再查看文件中的数据"))
+			return false
+		}
+		registerContentEntry.SetText(formatRegisters(registerFormat, byteOrder, dataBytes))
+		lastRegisterData = dataBytes
+		updateGrid(dataBytes)
+		addTrend(t, byteOrder, area, startAddress, dataBytes)
+		addTiming(t, area, startAddress, dataBytes)
+		if area == s7viewer.AreaV {
+			layoutView.update(byteOrder, startAddress, dataBytes)
+		}
+		lastCapture = &capture{Time: t, IP: plc, Area: area, StartAddress: startAddress, Data: dataBytes}
+		return true
+	}
+
+	// 录制与回放：监控时录制每次扫描，回放时按录制的时间在同样的界面中显示
+	recordPanel, currentRecorder, openRecording := newRecordPanel(prefs, myWindow, func(addr string) string { return labels[addr] }, func(t time.Time, h recordingHeader, data []byte) bool {
+		return showOffline(t, h.PLC, h.Area, h.Start, data)
+	})
+
+	// 状态表：按行指定地址和数据类型，与网格一起读取
+	watch = newWatchTable(prefs, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx }, nil)
+	watch.onRowsChanged = scheduleOPCUA
+	watch.onShow = func(r *watchResult) {
+		if opcua != nil {
+			opcua.update(r)
+		}
+	}
+
+	// 报警历史：状态表各行的报警条件触发或解除时记录，并执行勾选的通知动作；
+	// 未确认的报警使窗口标题一直闪烁
+	alarms := newAlarmList(prefs)
+	alarms.flash = setTitleAlarm
+	alarms.addAction(tr("响铃"), prefAlarmBeep, true, func(e alarmEvent) {
+		if !e.cleared {
+			beep()
+		}
+	})
+	alarms.addAction(tr("桌面通知"), prefAlarmNotify, true, sendDesktopNotification)
+	webhookSettings, sendWebhook := newWebhookSettings(prefs)
+	alarms.addAction(tr("推送Webhook（触发和解除）"), prefWebhookOn, false, sendWebhook)
+	watch.labelOf = func(addr string) string { return labels[addr] }
+	watch.onResetEdges = func() {
+		stats.reset()
+		statsTable.update()
+	}
+	watch.onAlarm = func(e alarmEvent) {
+		e.plc = strings.TrimSpace(ipEntry.Text)
+		alarms.add(e)
+	}
+
+	// readAndShow 单次读取配置的范围并显示
+	readAndShow := func() {
+		if viewer == nil {
+			log.Println(tr("请先连接PLC"))
+			notify.showInfo(tr("请先连接PLC"))
+			return
+		}
+
+		area, startAddress, bytesToRead, ok := parseRange()
+		if !ok {
+			return
+		}
+
+		// 单次读取数据
+		dataBytes, err := viewer.ReadRange(connCtx, area, startAddress, bytesToRead)
+		accessLabel.SetText(viewer.AccessStatus())
+		if err != nil {
+			// 读取失败时显示空白（全灰）网格
+			showGrid(nil)
+			reportError(tr("读取数据失败"), err)
+			return
+		}
+
+		layoutStart, layoutData := readLayout(connCtx)
+		showData(area, startAddress, dataBytes, layoutStart, layoutData)
+		watch.show(watch.poll(connCtx, viewer))
+	}
+
+	// 创建读取按钮（单次读取）
+	monitorButton := widget.NewButton(tr("读取数据"), readAndShow)
+
+	toggleBit = func(bitIndex int) {
+		if viewer == nil || lastCapture == nil || grid == nil || isReadOnly() {
+			return
+		}
+		if lastCapture.Area != s7viewer.AreaV {
+			log.Printf(tr("仅支持写入V区的位，当前为%s区"), lastCapture.Area)
+			return
+		}
+		if bitIndex/8 >= len(lastData) {
+			return
+		}
+		byteAddr := lastCapture.StartAddress + bitIndex/8
+		bit := 7 - bitIndex%8
+		current := (lastData[bitIndex/8]>>(7-bitIndex%8))&1 == 1
+
+		msg := fmt.Sprintf(tr("将 V%d.%d 从 %d 改为 %d？"), byteAddr, bit, boolToInt(current), boolToInt(!current))
+		dialog.ShowConfirm(tr("写入位"), msg, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := viewer.WriteVBit(connCtx, byteAddr, bit, !current); err != nil {
+				reportError(fmt.Sprintf(tr("写入 V%d.%d 失败"), byteAddr, bit), err)
+				return
+			}
+			log.Printf(tr("已写入 V%d.%d = %d"), byteAddr, bit, boolToInt(!current))
+			// 监控运行时由下一次扫描刷新，否则立即重读
+			if !viewer.IsMonitoring() {
+				readAndShow()
+			}
+		}, myWindow)
+	}
+
+	// 字/双字/REAL写入面板，写入后在未监控时立即重读
+	writePanel := newWritePanel(myWindow, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx }, func() {
+		if !viewer.IsMonitoring() {
+			readAndShow()
+		}
+	}, recheck)
+
+	watch.onWritten = func() {
+		if !viewer.IsMonitoring() {
+			readAndShow()
+		}
+	}
+
+	// MQTT发布：监控数据按位/字发布到代理
+	mqttPanel, currentMQTT := newMQTTPanel(prefs)
+
+	// 脚本：用户的Lua脚本在内嵌的gopher-lua中运行，接收扫描和报警事件，可读写变量、记录日志和产生通知
+	scriptPanel, currentScript := newScriptPanel(prefs, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx },
+		func() string { return strings.TrimSpace(ipEntry.Text) }, notify.showInfo, alarms.add)
+	alarms.addAction(tr("发送给脚本"), prefScriptAlarms, true, func(e alarmEvent) { sendScriptAlarm(currentScript, e) })
+
+	// 插件：插件目录中的接收端，勾选后监控数据写入其中
+	pluginPanel, currentSinks := newPluginPanel()
+
+	// Modbus TCP网关：监控范围映射为保持寄存器
+	modbusPanel, currentModbus := newModbusPanel()
+
+	// 历史库：监控数据的变化写入SQLite，可按变量查询
+	historianPanel, currentHistorian := newHistorianPanel(prefs, myWindow, func() string {
+		return strings.TrimSpace(ipEntry.Text)
+	})
+
+	// InfluxDB：监控数据按批写入时序数据库
+	influxPanel, currentInflux := newInfluxPanel(prefs)
+
+	// CSV记录：监控时每次扫描追加一行
+	logPanel, currentLog := newCSVLogPanel(prefs)
+	// 死区：CSV记录、MQTT和InfluxDB只输出变化超过死区的值
+	deadbandSettings, currentDeadbands := newDeadbandSettings(prefs)
+	// flushLog 停止监控或断开时写出缓冲的CSV记录、录制帧和InfluxDB数据点
+	flushLog := func() {
+		if r := currentRecorder(); r != nil {
+			if err := r.flush(); err != nil {
+				log.Printf(tr("写入录制文件失败: %v"), err)
+			}
+		}
+		if l := currentLog(); l != nil {
+			if err := l.flush(); err != nil {
+				log.Printf(tr("记录CSV失败: %v"), err)
+			}
+		}
+		if w := currentInflux(); w != nil {
+			w.requestFlush()
+		}
+	}
+
+	verifyCheck := widget.NewCheck(tr("写后校验"), func(verify bool) {
+		verifyWrite = verify
+		if viewer != nil {
+			viewer.SetVerifyWrite(verify)
+		}
+	})
+	verifyCheck.SetChecked(verifyWrite)
+
+	// 连续监控：后台周期读取，通过fyne.Do在UI线程原地更新显示
+	var startMonitorButton, stopMonitorButton *widget.Button
+	// stopGroups 停止状态表轮询组的后台读取，未在监控时为nil
+	var stopGroups context.CancelFunc
+	startMonitorButton = widget.NewButton(tr("开始监控"), func() {
+		if viewer == nil {
+			log.Println(tr("请先连接PLC"))
+			notify.showInfo(tr("请先连接PLC"))
+			return
+		}
+
+		area, startAddress, bytesToRead, ok := parseRange()
+		if !ok {
+			return
+		}
+
+		showGrid(nil)
+		plcIP := strings.TrimSpace(ipEntry.Text)
+		ctx := connCtx
+		viewer.Subscribe(ctx, area, startAddress, bytesToRead, func(data []byte) {
+			order := viewer.ByteOrder()
+			if pub := currentMQTT(); pub != nil {
+				pub.update(order, area, startAddress, data, currentDeadbands())
+			}
+			if g := currentModbus(); g != nil {
+				g.update(data)
+			}
+			liveStream.broadcast(plcIP, area, startAddress, data)
+			grpcStream.broadcast(plcIP, order, area, startAddress, data)
+			if h := currentHistorian(); h != nil {
+				if err := h.record(plcIP, time.Now(), tagValues(order, area, startAddress, data)); err != nil {
+					log.Printf(tr("写入历史库失败: %v"), err)
+				}
+			}
+			if w := currentInflux(); w != nil {
+				w.add(plcIP, time.Now(), tagValues(order, area, startAddress, data), currentDeadbands())
+			}
+			if l := currentLog(); l != nil {
+				if err := l.log(time.Now(), order, area, startAddress, data, currentDeadbands()); err != nil {
+					log.Printf(tr("记录CSV失败: %v"), err)
+				}
+			}
+			if r := currentRecorder(); r != nil {
+				if err := r.record(time.Now(), plcIP, area, startAddress, data); err != nil {
+					log.Printf(tr("写入录制文件失败: %v"), err)
+				}
+			}
+			layoutStart, layoutData := readLayout(ctx)
+			watched := watch.poll(ctx, viewer)
+			if s := currentScript(); s != nil {
+				s.scan(plcIP, scriptScanTags(order, area, startAddress, data))
+			}
+			for _, s := range currentSinks() {
+				if err := s.write(sinkScan{time: time.Now(), plc: plcIP, order: order, area: area, start: startAddress, data: data}); err != nil {
+					log.Printf(tr("写入接收端失败: %v"), err)
+				}
+			}
+			cycle := viewer.CycleTime()
+			fyne.Do(func() {
+				accessLabel.SetText(viewer.AccessStatus())
+				if cycle > 0 {
+					cycleLabel.SetText(fmt.Sprintf(tr("实际周期: %d ms"), cycle.Milliseconds()))
+				}
+				showData(area, startAddress, data, layoutStart, layoutData)
+				watch.show(watched)
+			})
+		})
+		// 状态表中单独设置了轮询组的行按各自的周期读取
+		if stopGroups != nil {
+			stopGroups()
+		}
+		groupCtx, cancelGroups := context.WithCancel(ctx)
+		stopGroups = cancelGroups
+		watch.startGroups(groupCtx, viewer)
+		startMonitorButton.Disable()
+		stopMonitorButton.Enable()
+		log.Printf(tr("开始监控 %s, 长度%d"), s7viewer.ByteAddressName(area, startAddress), bytesToRead)
+	})
+	stopMonitorButton = widget.NewButton(tr("停止监控"), func() {
+		if viewer != nil {
+			viewer.Unsubscribe()
+		}
+		if stopGroups != nil {
+			stopGroups()
+			stopGroups = nil
+		}
+		flushLog()
+		startMonitorButton.Enable()
+		stopMonitorButton.Disable()
+		log.Println(tr("已停止监控"))
+	})
+	stopMonitorButton.Disable()
+
+	// 导出按钮：可选填写备注后保存为CSV
+	exportButton := widget.NewButton(tr("导出CSV"), func() {
+		if lastCapture == nil {
+			log.Println(tr("没有可导出的数据，请先读取"))
+			return
+		}
+
+		noteEntry := widget.NewMultiLineEntry()
+		noteEntry.SetPlaceHolder(tr("例如：故障发生瞬间"))
+		noteEntry.SetText(exportNote)
+		dialog.ShowForm(tr("导出CSV"), tr("下一步"), tr("取消"),
+			[]*widget.FormItem{widget.NewFormItem(tr("备注 (可选):"), noteEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				c := *lastCapture
+				c.Note = noteEntry.Text
+				exportNote = noteEntry.Text
+
+				saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+					if err != nil {
+						reportError(tr("导出失败"), err)
+						return
+					}
+					if writer == nil {
+						return
+					}
+					defer writer.Close()
+
+					if err := writeCaptureCSV(writer, c); err != nil {
+						reportError(tr("导出失败"), err)
+						return
+					}
+					log.Printf(tr("已导出到 %s"), writer.URI().Path())
+				}, myWindow)
+				saveDialog.SetFileName(fmt.Sprintf("%s%d_%s.csv", c.Area, c.StartAddress, c.Time.Format("20060102_150405")))
+				saveDialog.Show()
+			}, myWindow)
+	})
+
+	// currentSnapshot 由最近一次读取的数据生成快照，尚未读取时ok为false
+	currentSnapshot := func() (snapshot, bool) {
+		if lastCapture == nil {
+			return snapshot{}, false
+		}
+		cfg, _ := connParams()
+		cfg.IP = lastCapture.IP
+		s := newSnapshot(*lastCapture, cfg, labels, append(layoutView.valueRows(), watch.valueRows()...))
+		s.PLC.CPU = newSnapshotCPU(plcInfo.current(lastCapture.IP))
+		s.ByteOrder = byteOrder
+		return s, true
+	}
+
+	// 报警邮件附上报警时的快照
+	emailSettings, sendEmail := newEmailSettings(prefs, currentSnapshot)
+	alarms.addAction(tr("发送邮件"), prefEmailOn, false, sendEmail)
+
+	// 快照对比：前后两个快照的位、字和变量差异
+	snapshotDiffPanel := newSnapshotDiffPanel(myWindow, currentSnapshot)
+
+	// 导出快照：原始字节、位状态、结构化视图和状态表的值以及连接信息，保存为JSON或CSV
+	snapshotButton := widget.NewButton(tr("导出快照"), func() {
+		if lastCapture == nil {
+			log.Println(tr("没有可导出的数据，请先读取"))
+			return
+		}
+		noteEntry := widget.NewMultiLineEntry()
+		noteEntry.SetPlaceHolder(tr("例如：工单号、故障现象"))
+		noteEntry.SetText(exportNote)
+		dialog.ShowForm(tr("导出快照"), tr("下一步"), tr("取消"),
+			[]*widget.FormItem{widget.NewFormItem(tr("备注 (可选):"), noteEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				snap, _ := currentSnapshot()
+				snap.Note = noteEntry.Text
+				exportNote = noteEntry.Text
+
+				saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+					if err != nil {
+						reportError(tr("导出快照失败"), err)
+						return
+					}
+					if writer == nil {
+						return
+					}
+					defer writer.Close()
+
+					// 按文件扩展名选择格式，默认JSON
+					write := writeSnapshotJSON
+					if strings.EqualFold(writer.URI().Extension(), ".csv") {
+						write = writeSnapshotCSV
+					}
+					if err := write(writer, snap); err != nil {
+						reportError(tr("导出快照失败"), err)
+						return
+					}
+					log.Printf(tr("已导出快照到 %s"), writer.URI().Path())
+				}, myWindow)
+				saveDialog.SetFileName(fmt.Sprintf(tr("快照_%s_%s.json"), snap.PLC.IP, snap.Time.Format("20060102_150405")))
+				saveDialog.Show()
+			}, myWindow)
+	})
+
+	// openFile 离线查看：打开录制文件（.rec）或快照文件（JSON/CSV），不需要连接PLC
+	var viewTabs *container.AppTabs
+	var recordTab *container.TabItem
+	openFile := func(path string) {
+		if strings.EqualFold(filepath.Ext(path), ".rec") {
+			openRecording(path)
+			viewTabs.Select(recordTab)
+		} else {
+			f, err := os.Open(path)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf(tr("打开文件失败: %v"), err), myWindow)
+				return
+			}
+			snap, err := readSnapshot(f)
+			f.Close()
+			var data []byte
+			if err == nil {
+				data, err = snap.data()
+			}
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			if !showOffline(snap.Time, snap.PLC.IP, snap.Area, snap.StartAddress, data) {
+				return
+			}
+			lastCapture.Note = snap.Note
+			exportNote = snap.Note
+		}
+		if viewer == nil || !viewer.IsConnected() {
+			setStatus(colorBitOff, tr("离线查看: ")+filepath.Base(path))
+		}
+		log.Printf(tr("已打开 %s"), path)
+	}
+	openFileButton := widget.NewButton(tr("打开文件"), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+			openFile(path)
+		}, myWindow)
+	})
+
+	// 断开连接按钮
+	// teardown 停止监控并断开连接
+	teardown := func() {
+		if viewer == nil {
+			return
+		}
+		forces.releaseAll()
+		cancelConn()
+		viewer.Unsubscribe()
+		if stopGroups != nil {
+			stopGroups()
+			stopGroups = nil
+		}
+		flushLog()
+		startMonitorButton.Enable()
+		stopMonitorButton.Disable()
+		viewer.Disconnect()
+		setStatus(colorBitOff, tr("未连接"))
+		cpu.reset()
+		log.Println(tr("PLC已断开连接"))
+	}
+
+	// confirmIfMonitoring 监控运行中时先确认再执行action
+	confirmIfMonitoring := func(title string, action func()) {
+		if viewer == nil || !viewer.IsMonitoring() {
+			action()
+			return
+		}
+		dialog.ShowConfirm(title, tr("监控正在运行，继续将停止监控并断开PLC连接。是否继续？"), func(ok bool) {
+			if ok {
+				action()
+			}
+		}, myWindow)
+	}
+
+	disconnectButton := widget.NewButton(tr("断开连接"), func() {
+		confirmIfMonitoring(tr("断开连接"), teardown)
+	})
+
+	// 清除显示按钮
+	stopButton := widget.NewButton(tr("清除显示"), func() {
+		// 重新创建空的显示区域
+		grid = nil
+		lastData = nil
+		page = 0
+		pageLabel.SetText(tr("第 1/1 页"))
+		lastCapture = nil
+		displayContainer.Objects = nil
+		displayContainer.Refresh()
+		// 清除寄存器内容显示
+		registerContentEntry.SetText("")
+		lastRegisterData = nil
+		cursorBit = -1
+		showCursor()
+		stats.reset()
+		statsTable.update()
+		watch.resetEdges()
+	})
+
+	// 搜索PLC：扫描网段找出未记录IP的CPU，点击结果填入IP地址
+	discoverButton := widget.NewButton(tr("搜索PLC"), func() {
+		rack, err := strconv.Atoi(strings.TrimSpace(rackEntry.Text))
+		if err != nil {
+			rack = s7viewer.DefaultRack
+		}
+		slot, err := strconv.Atoi(strings.TrimSpace(slotEntry.Text))
+		if err != nil {
+			slot = s7viewer.DefaultSlot
+		}
+		showDiscoverDialog(myWindow, ipEntry.Text, rack, slot, ipEntry.SetText)
+	})
+
+	// 布局
+	inputForm := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(tr("连接配置:"), container.NewBorder(nil, nil, nil, container.NewHBox(saveProfileButton, deleteProfileButton), profileSelect)),
+			widget.NewFormItem(tr("PLC IP地址:"), container.NewBorder(nil, nil, nil, discoverButton, ipEntry)),
+			widget.NewFormItem(tr("机架/槽位/端口/超时(秒):"), container.NewGridWithColumns(4, rackEntry, slotEntry, portEntry, timeoutEntry)),
+			widget.NewFormItem(tr("重试次数/间隔(ms):"), container.NewGridWithColumns(2, retryEntry, retryDelayEntry)),
+			widget.NewFormItem(tr("存储区:"), areaSelect),
+			widget.NewFormItem(tr("起始地址:"), addressEntry),
+			widget.NewFormItem(tr("寄存器长度 (字节, T/C为个数):"), lengthEntry),
+			widget.NewFormItem(tr("扫描周期 (ms, 回车应用):"), container.NewBorder(nil, nil, nil, cycleLabel, scanEntry)),
+			widget.NewFormItem(tr("心跳间隔 (秒):"), healthEntry),
+			widget.NewFormItem(tr("合并读取间隔 (字节, -1不合并, 回车应用):"), gapEntry),
+			widget.NewFormItem(tr("V区访问方式:"), container.NewHBox(accessSelect, accessLabel)),
+			widget.NewFormItem(tr("报警条件:"), container.NewBorder(nil, nil, nil, container.NewHBox(alarmCheck, alarmLabel), alarmEntry)),
+		),
+		container.NewHBox(
+			connectButton,
+			disconnectButton,
+			monitorButton,
+			startMonitorButton,
+			stopMonitorButton,
+			stopButton,
+			exportButton,
+			snapshotButton,
+			openFileButton,
+			importButton,
+			importSymbolsButton,
+			verifyCheck,
+			container.NewGridWrap(fyne.NewSquareSize(16), statusDot),
+			statusLabel,
+			cpu.content,
+			cursorLabel,
+			widget.NewLabel(tr("网格样式:")),
+			styleSelect,
+		),
+		widget.NewForm(
+			widget.NewFormItem(tr("方块大小:"), zoomSlider),
+			widget.NewFormItem(tr("每行位数/行数 (回车应用):"), container.NewHBox(colsSelect, rowsEntry, widget.NewLabel(tr("位顺序:")), bitOrderSelect)),
+			widget.NewFormItem(tr("变化高亮(毫秒):"), flashEntry),
+		),
+		container.NewHBox(prevPageButton, pageLabel, nextPageButton),
+	)
+
+	// 突发采样：停止监控后背靠背读取一小段范围，结果显示在时序图中
+	timingTab := container.NewTabItem(tr("时序图"), timingPanel)
+	burstPanel := newBurstPanel(prefs, myWindow, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx },
+		func() string { return strings.TrimSpace(ipEntry.Text) },
+		func(area string, start int, samples []s7viewer.Sample) {
+			for _, s := range samples {
+				addTiming(s.Time, area, start, s.Data)
+			}
+			viewTabs.Select(timingTab)
+		}, openRecording)
+
+	recordTab = container.NewTabItem(tr("录制回放"), container.NewVScroll(recordPanel))
+	viewTabs = container.NewAppTabs(
+		container.NewTabItem(tr("位网格"), container.NewVScroll(displayContainer)),
+		container.NewTabItem(tr("结构化视图"), container.NewBorder(
+			container.NewHBox(widget.NewLabel(tr("OPC UA端口:")), opcuaPortEntry, opcuaCheck, opcuaLabel),
+			nil, nil, nil, layoutView.table)),
+		container.NewTabItem(tr("趋势"), trendPanel),
+		timingTab,
+		container.NewTabItem(tr("状态表"), container.NewBorder(nil,
+			widget.NewAccordion(
+				widget.NewAccordionItem(tr("工程单位换算"), newEUScaleSettings(prefs)),
+				widget.NewAccordionItem(tr("计算变量"), computedSettings)),
+			nil, nil, watch.content)),
+		container.NewTabItem(tr("位统计"), statsTable.content),
+		container.NewTabItem(tr("报警历史"), alarms.content),
+		container.NewTabItem(tr("报警通知"), container.NewVScroll(container.NewVBox(alarms.actionBox, widget.NewSeparator(), webhookSettings, widget.NewSeparator(), emailSettings))),
+		container.NewTabItem(tr("快照对比"), snapshotDiffPanel),
+		container.NewTabItem(tr("PLC信息"), container.NewVScroll(plcInfo.content)),
+		container.NewTabItem(tr("特殊存储器"), newSMPanel(func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx })),
+		container.NewTabItem(tr("模拟量"), newAnalogPanel(prefs, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx })),
+		container.NewTabItem(tr("高速计数器"), newHSCPanel(prefs, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx })),
+		container.NewTabItem(tr("定时器/计数器"), newTCPanel(prefs, func() (*s7viewer.Viewer, context.Context) { return viewer, connCtx })),
+		container.NewTabItem(tr("写入"), writePanel),
+		container.NewTabItem(tr("强制"), forcePanel),
+		container.NewTabItem(tr("写入审计"), newAuditPanel()),
+		container.NewTabItem(tr("突发采样"), container.NewVScroll(burstPanel)),
+		container.NewTabItem("MQTT", mqttPanel),
+		container.NewTabItem("Modbus", modbusPanel),
+		container.NewTabItem(tr("脚本"), container.NewVScroll(scriptPanel)),
+		container.NewTabItem(tr("插件"), container.NewVScroll(pluginPanel)),
+		container.NewTabItem(tr("记录"), container.NewVScroll(container.NewVBox(logPanel, widget.NewSeparator(), deadbandSettings))),
+		recordTab,
+		container.NewTabItem(tr("历史"), historianPanel),
+		container.NewTabItem("InfluxDB", influxPanel),
+	)
+
+	// 将寄存器内容显示放在输入表单和显示区域之间
+	content := container.NewBorder(
+		container.NewVBox(
+			newReadOnlyBanner(),
+			forceBanner,
+			inputForm,
+			container.NewHBox(widget.NewLabel(tr("寄存器内容:")), formatSelect, widget.NewLabel(tr("字节顺序:")), orderSelect),
+			registerContentEntry,
+		),
+		notify.content, nil, nil,
+		viewTabs,
+	)
+
+	panel.content = content
+	panel.ip = func() string { return strings.TrimSpace(ipEntry.Text) }
+	panel.viewer = func() *s7viewer.Viewer { return viewer }
+	panel.monitoring = func() bool { return viewer != nil && viewer.IsMonitoring() }
+	panel.metrics = func() (metricsSource, bool) {
+		if viewer == nil {
+			return metricsSource{}, false
+		}
+		entries, start, data := layoutView.snapshot()
+		return metricsSource{plc: panel.ip(), viewer: viewer, entries: entries, start: start, data: data, watch: watch.valueRows()}, true
+	}
+	panel.teardown = func() {
+		teardown()
+		titleClosed = true
+		setAlarmTitle(myWindow, panel, "")
+	}
+	panel.openFile = openFile
+	panel.applyProfile = applyProfile
+	panel.session = func() panelSession {
+		return panelSession{
+			Area:    areaSelect.Selected,
+			Address: strings.TrimSpace(addressEntry.Text),
+			Length:  strings.TrimSpace(lengthEntry.Text),
+			View:    viewTabs.SelectedIndex(),
+			Note:    exportNote,
+		}
+	}
+	panel.restoreSession = func(s panelSession) {
+		if containsString(s7viewer.MemoryAreas, s.Area) {
+			areaSelect.SetSelected(s.Area)
+		}
+		if s.Address != "" {
+			addressEntry.SetText(s.Address)
+		}
+		if s.Length != "" {
+			lengthEntry.SetText(s.Length)
+		}
+		if s.View >= 0 && s.View < len(viewTabs.Items) {
+			viewTabs.SelectIndex(s.View)
+		}
+		exportNote = s.Note
+	}
+	panel.read = readAndShow
+	panel.toggleMonitor = func() {
+		if startMonitorButton.Disabled() {
+			stopMonitorButton.OnTapped()
+		} else {
+			startMonitorButton.OnTapped()
+		}
+	}
+	panel.connect = connectButton.OnTapped
+	panel.clearDisplay = stopButton.OnTapped
+	panel.moveCursor = func(dx, dy int) {
+		if grid == nil {
+			return
+		}
+		cursorBit = grid.moveCursor(dx, dy)
+		showCursor()
+	}
+	panel.bitLabels = func() map[string]string { return maps.Clone(labels) }
+	panel.ensureMonitoring = func() {
+		if viewer != nil && viewer.IsMonitoring() {
+			// 监控中链路中断由viewer自动重连
+			return
+		}
+		if viewer == nil || !viewer.IsConnected() {
+			connectButton.OnTapped()
+			if viewer == nil || !viewer.IsConnected() {
+				return
+			}
+		}
+		startMonitorButton.OnTapped()
+	}
+	addressEntry.OnSubmitted = func(string) { readAndShow() }
+	lengthEntry.OnSubmitted = func(string) { readAndShow() }
+	panel.redraw = func() {
+		if grid != nil {
+			showGrid(lastData)
+		}
+	}
+	ipEntry.OnChanged = func(text string) {
+		if panel.onIPChanged != nil {
+			panel.onIPChanged(strings.TrimSpace(text))
+		}
+	}
+	return panel
+}
@@ -0,0 +1,147 @@
+package s7viewer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+)
+
+// 存储区
+const (
+	AreaV  = "V"
+	AreaI  = "I"
+	AreaQ  = "Q"
+	AreaM  = "M"
+	AreaSM = "SM"
+	AreaT  = "T"
+	AreaC  = "C"
+	AreaAI = "AI"
+	AreaAQ = "AQ"
+)
+
+// MemoryAreas 可读取的存储区，按界面中的显示顺序排列
+var MemoryAreas = []string{AreaV, AreaI, AreaQ, AreaM, AreaSM, AreaT, AreaC, AreaAI, AreaAQ}
+
+// S7-200系列特有的区域代码（SM、AI、AQ），gos7没有对应的读取函数，通过AGReadMulti访问
+const (
+	S7AreaSM200   = 0x05
+	S7AreaAI200   = 0x06
+	S7AreaAQ200   = 0x07
+	S7WordLenByte = 0x02
+)
+
+// areaCodes V区以外各存储区的S7区域代码
+var areaCodes = map[string]int{
+	AreaI: S7AreaPE, AreaQ: S7AreaPA, AreaM: S7AreaMK, AreaT: S7AreaTM, AreaC: S7AreaCT,
+	AreaSM: S7AreaSM200, AreaAI: S7AreaAI200, AreaAQ: S7AreaAQ200,
+}
+
+// IsCounterArea T/C区按编号寻址，每个定时器/计数器的当前值占2字节
+func IsCounterArea(area string) bool {
+	return area == AreaT || area == AreaC
+}
+
+// ByteAddressName 返回存储区中某个字节（T/C区为编号）的显示名称，如 VB100、SMB0、AIW16、T37
+func ByteAddressName(area string, offset int) string {
+	switch area {
+	case AreaT, AreaC:
+		return fmt.Sprintf("%s%d", area, offset)
+	case AreaAI, AreaAQ:
+		return fmt.Sprintf("%sW%d", area, offset)
+	}
+	return fmt.Sprintf("%sB%d", area, offset)
+}
+
+// ReadArea 读取指定存储区的原始数据。V区沿用ReadV及其访问方式设置；
+// T/C区的start为起始编号、size为个数，返回size*2字节。
+func (p *Viewer) ReadArea(ctx context.Context, area string, start int, size int) ([]byte, error) {
+	if area == AreaV || area == "" {
+		return p.ReadV(ctx, start, size)
+	}
+
+	p.mu.Lock()
+	client := p.client
+	p.mu.Unlock()
+
+	if client == nil {
+		return nil, ErrNotConnected
+	}
+
+	bufSize := size
+	if IsCounterArea(area) {
+		bufSize = size * 2
+	}
+	buffer := make([]byte, bufSize)
+
+	code, ok := areaCodes[area]
+	if !ok {
+		return nil, fmt.Errorf("不支持的存储区: %s", area)
+	}
+	err := p.retry(ctx, func() error {
+		var err error
+		if ctxErr := p.do(ctx, func() { err = client.ReadArea(code, start, size, buffer) }); ctxErr != nil {
+			return ctxErr
+		}
+		return wrapError("读取"+area+"区", err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// WriteArea 向指定存储区写入字节数据，V区沿用WriteV；T/C区的start为起始编号，每个元素2字节。
+// 开启校验时写后读回比对。
+func (p *Viewer) WriteArea(ctx context.Context, area string, start int, data []byte) error {
+	if area == AreaV || area == "" {
+		return p.WriteV(ctx, start, data)
+	}
+	return p.observeWrite(ctx, area, start, data, func() error { return p.writeArea(ctx, area, start, data) })
+}
+
+func (p *Viewer) writeArea(ctx context.Context, area string, start int, data []byte) error {
+	p.mu.Lock()
+	client := p.client
+	verify := p.verifyWrite
+	p.mu.Unlock()
+
+	if client == nil {
+		return ErrNotConnected
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("写入数据为空")
+	}
+	code, ok := areaCodes[area]
+	if !ok || area == AreaAI {
+		return fmt.Errorf("不支持写入的存储区: %s", area)
+	}
+	if IsCounterArea(area) && len(data)%2 != 0 {
+		return fmt.Errorf("%s区每个元素2字节，写入长度应为偶数", area)
+	}
+
+	err := p.retry(ctx, func() error {
+		var err error
+		if ctxErr := p.do(ctx, func() { err = client.WriteArea(code, start, data) }); ctxErr != nil {
+			return ctxErr
+		}
+		return wrapError("写入"+area+"区", err)
+	})
+	if err != nil || !verify {
+		return err
+	}
+
+	size := len(data)
+	if IsCounterArea(area) {
+		size /= 2
+	}
+	readBack, err := p.ReadArea(ctx, area, start, size)
+	if err != nil {
+		return fmt.Errorf("写入后读回失败: %w", err)
+	}
+	if !bytes.Equal(readBack, data) {
+		return fmt.Errorf("写入失败: 读回值=% X, 期望=% X", readBack, data)
+	}
+	log.Printf("写入已验证: %s, %d字节", ByteAddressName(area, start), len(data))
+	return nil
+}
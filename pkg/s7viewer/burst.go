@@ -0,0 +1,43 @@
+package s7viewer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Sample 突发采样的一次读取结果
+type Sample struct {
+	Time time.Time // 请求发出与收到应答的中点，比读取完成的时间更接近PLC中数据的时刻
+	Data []byte
+}
+
+// Burst 突发采样：在duration内不等扫描周期、一个接一个地读取指定范围，返回带时间戳的采样，
+// 用于捕捉扫描周期内来不及看到的快速握手信号。范围必须能在一个请求中读完。
+// 得到maxSamples个采样（<=0表示不限）、duration结束或ctx被取消时返回已得到的采样；
+// 读取失败时同时返回已得到的采样和错误。
+// 监控运行时采样与监控读取交替进行，应先停止监控以获得最快的速度。
+func (p *Viewer) Burst(ctx context.Context, area string, start, length int, duration time.Duration, maxSamples int) ([]Sample, error) {
+	chunk := p.ChunkSize()
+	if IsCounterArea(area) {
+		chunk /= 2
+	}
+	if length <= 0 || length > chunk {
+		return nil, fmt.Errorf("突发采样的范围应为1到%d", chunk)
+	}
+
+	var samples []Sample
+	end := time.Now().Add(duration)
+	for time.Now().Before(end) && (maxSamples <= 0 || len(samples) < maxSamples) {
+		sent := time.Now()
+		data, err := p.ReadArea(ctx, area, start, length)
+		if ctx.Err() != nil {
+			return samples, nil
+		}
+		if err != nil {
+			return samples, err
+		}
+		samples = append(samples, Sample{Time: sent.Add(time.Since(sent) / 2), Data: data})
+	}
+	return samples, nil
+}
@@ -0,0 +1,59 @@
+package s7viewer
+
+// 多字节数值的字节顺序。PLC本身为大端，其他顺序用于第三方库按不同顺序写入V区的数据
+const (
+	OrderBigEndian    = "大端(ABCD)"
+	OrderLittleEndian = "小端(DCBA)"
+	OrderWordSwap     = "字交换(CDAB)"
+	OrderByteSwap     = "字节交换(BADC)"
+)
+
+// ByteOrders 可选的多字节数值字节序
+var ByteOrders = []string{OrderBigEndian, OrderLittleEndian, OrderWordSwap, OrderByteSwap}
+
+// normalizeByteOrder 返回order，无效的名称按大端处理
+func normalizeByteOrder(order string) string {
+	for _, o := range ByteOrders {
+		if o == order {
+			return order
+		}
+	}
+	return OrderBigEndian
+}
+
+// SetByteOrder 设置这台PLC的数据所用的字节顺序，无效的名称按大端处理。
+// 各标签页的Viewer分别设置，互不影响
+func (p *Viewer) SetByteOrder(order string) {
+	p.mu.Lock()
+	p.byteOrder = normalizeByteOrder(order)
+	p.mu.Unlock()
+}
+
+// ByteOrder 返回SetByteOrder设置的字节顺序，默认为大端
+func (p *Viewer) ByteOrder() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return normalizeByteOrder(p.byteOrder)
+}
+
+// ToBigEndian 将按order存放的2或4字节数值重排为大端，返回新切片。
+// 各种顺序的重排都是自身的逆操作，因此同一函数也用于编码写入数据。
+// 字交换对16位数值没有影响。
+func ToBigEndian(order string, b []byte) []byte {
+	out := append([]byte(nil), b...)
+	switch order {
+	case OrderLittleEndian:
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	case OrderWordSwap:
+		if len(out) == 4 {
+			out[0], out[1], out[2], out[3] = out[2], out[3], out[0], out[1]
+		}
+	case OrderByteSwap:
+		for i := 0; i+1 < len(out); i += 2 {
+			out[i], out[i+1] = out[i+1], out[i]
+		}
+	}
+	return out
+}
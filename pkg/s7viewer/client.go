@@ -0,0 +1,277 @@
+package s7viewer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robinson/gos7"
+)
+
+// S7Reader viewer访问PLC所用的最小接口。gos7Client是实际的实现，
+// 测试中可注入模拟实现，使读取、转换和监控逻辑脱离硬件运行。
+type S7Reader interface {
+	// Connect 建立连接，Close 断开连接
+	Connect() error
+	Close() error
+	// ReadDB/WriteDB 读写数据块，S7-200 SMART的V区即DB1
+	ReadDB(db, start, size int, buffer []byte) error
+	WriteDB(db, start int, data []byte) error
+	// ReadArea/WriteArea 按S7区域代码（S7AreaPE等）读写其他存储区，T/C区的start和size为编号和个数
+	ReadArea(area, start, size int, buffer []byte) error
+	WriteArea(area, start int, data []byte) error
+	// ReadMulti 用一个请求读取多个变量，各变量的错误写入其Error字段
+	ReadMulti(items []gos7.S7DataItem) error
+	// PDULength 返回连接时与PLC协商的PDU长度，未知时为0
+	PDULength() int
+}
+
+// gos7Client 基于gos7的S7Reader实现
+type gos7Client struct {
+	handler *gos7.TCPClientHandler
+	client  gos7.Client
+}
+
+// NewGos7Client 按连接参数创建客户端，调用Connect后才建立连接
+func NewGos7Client(cfg Config) S7Reader {
+	handler := gos7.NewTCPClientHandler(cfg.IP, cfg.Rack, cfg.Slot)
+	if cfg.Port != 0 && cfg.Port != DefaultPort {
+		// 非标准端口（如网关转发）写入地址
+		handler.Address = net.JoinHostPort(cfg.IP, strconv.Itoa(cfg.Port))
+	}
+	handler.Timeout = cfg.Timeout
+	if handler.Timeout <= 0 {
+		handler.Timeout = DefaultTimeout
+	}
+	handler.IdleTimeout = 60 * time.Second
+	handler.Logger = log.New(os.Stdout, "s7: ", log.LstdFlags)
+	return &gos7Client{handler: handler, client: gos7.NewClient(handler)}
+}
+
+func (c *gos7Client) Connect() error { return c.handler.Connect() }
+
+func (c *gos7Client) Close() error { return c.handler.Close() }
+
+func (c *gos7Client) PDULength() int { return c.handler.PDULength }
+
+func (c *gos7Client) ReadDB(db, start, size int, buffer []byte) error {
+	return c.client.AGReadDB(db, start, size, buffer)
+}
+
+func (c *gos7Client) WriteDB(db, start int, data []byte) error {
+	return c.client.AGWriteDB(db, start, len(data), data)
+}
+
+func (c *gos7Client) ReadArea(area, start, size int, buffer []byte) error {
+	switch area {
+	case S7AreaPE:
+		return c.client.AGReadEB(start, size, buffer)
+	case S7AreaPA:
+		return c.client.AGReadAB(start, size, buffer)
+	case S7AreaMK:
+		return c.client.AGReadMB(start, size, buffer)
+	case S7AreaTM:
+		return c.client.AGReadTM(start, size, buffer)
+	case S7AreaCT:
+		return c.client.AGReadCT(start, size, buffer)
+	}
+	// gos7没有单独读取函数的区域（S7-200的SM、AI、AQ）通过多变量读取访问
+	items := []gos7.S7DataItem{{Area: area, WordLen: S7WordLenByte, Start: start, Amount: size, Data: buffer}}
+	if err := c.ReadMulti(items); err != nil {
+		return err
+	}
+	if items[0].Error != "" {
+		return fmt.Errorf("%s", items[0].Error)
+	}
+	return nil
+}
+
+func (c *gos7Client) WriteArea(area, start int, data []byte) error {
+	switch area {
+	case S7AreaPE:
+		return c.client.AGWriteEB(start, len(data), data)
+	case S7AreaPA:
+		return c.client.AGWriteAB(start, len(data), data)
+	case S7AreaMK:
+		return c.client.AGWriteMB(start, len(data), data)
+	case S7AreaTM:
+		return c.client.AGWriteTM(start, len(data)/2, data)
+	case S7AreaCT:
+		return c.client.AGWriteCT(start, len(data)/2, data)
+	}
+	// S7-200的SM、AQ等区域与读取一样通过多变量请求写入
+	items := []gos7.S7DataItem{{Area: area, WordLen: S7WordLenByte, Start: start, Amount: len(data), Data: data}}
+	if err := c.client.AGWriteMulti(items, len(items)); err != nil {
+		return err
+	}
+	if items[0].Error != "" {
+		return fmt.Errorf("%s", items[0].Error)
+	}
+	return nil
+}
+
+func (c *gos7Client) ReadMulti(items []gos7.S7DataItem) error {
+	return c.client.AGReadMulti(items, len(items))
+}
+
+// 读取SZL和读写时钟的用户数据请求（含TPKT和COTP头）。gos7的GetOrderCode、GetCPUInfo、
+// PGClockRead在应答较短或请求组包时会越界panic，PGClockWrite在请求失败时解引用空指针，因此这些请求自行组包
+var (
+	// szlRequest SZL ID和索引在第29、31字节
+	szlRequest = []byte{
+		0x03, 0x00, 0x00, 0x21, 0x02, 0xF0, 0x80,
+		0x32, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x08,
+		0x00, 0x01, 0x12, 0x04, 0x11, 0x44, 0x01, 0x00,
+		0xFF, 0x09, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00,
+	}
+	readClockRequest = []byte{
+		0x03, 0x00, 0x00, 0x1D, 0x02, 0xF0, 0x80,
+		0x32, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x04,
+		0x00, 0x01, 0x12, 0x04, 0x11, 0x47, 0x01, 0x00,
+		0x0A, 0x00, 0x00, 0x00,
+	}
+	// setClockRequest 时间从第30字节开始：年份高两位，后跟BCD的DATE_AND_TIME
+	setClockRequest = []byte{
+		0x03, 0x00, 0x00, 0x27, 0x02, 0xF0, 0x80,
+		0x32, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x0E,
+		0x00, 0x01, 0x12, 0x04, 0x11, 0x47, 0x02, 0x00,
+		0xFF, 0x09, 0x00, 0x0A, 0x00, 0x20, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+)
+
+// userData 发送用户数据请求并检查应答的错误码，返回应答中数据部分之后的内容（第33字节起）
+func (c *gos7Client) userData(req []byte) ([]byte, error) {
+	resp, err := c.handler.Send(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 33 || resp[8] != 0x07 {
+		return nil, fmt.Errorf("无效的应答: % X", resp)
+	}
+	if code := binary.BigEndian.Uint16(resp[27:]); code != 0 {
+		return nil, fmt.Errorf("PLC应答错误码 %04X", code)
+	}
+	if resp[29] != 0xFF {
+		return nil, fmt.Errorf("PLC应答返回码 %02X", resp[29])
+	}
+	n := int(binary.BigEndian.Uint16(resp[31:]))
+	if 33+n > len(resp) {
+		return nil, fmt.Errorf("应答长度不足: % X", resp)
+	}
+	return resp[33 : 33+n], nil
+}
+
+// readSZL 读取一个SZL列表，返回各数据记录和每条记录的长度。只读取第一个分片，
+// S7-200 SMART的模块标识和CPU标识都能放进一个PDU
+func (c *gos7Client) readSZL(id, index uint16) (records []byte, size int, err error) {
+	req := append([]byte(nil), szlRequest...)
+	binary.BigEndian.PutUint16(req[29:], id)
+	binary.BigEndian.PutUint16(req[31:], index)
+	data, err := c.userData(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	// SZL ID、索引、记录长度、记录条数各2字节
+	if len(data) < 8 {
+		return nil, 0, fmt.Errorf("SZL %04X应答长度不足", id)
+	}
+	return data[8:], int(binary.BigEndian.Uint16(data[4:])), nil
+}
+
+// CPUInfo 读取订货号、固件版本（SZL 0x0011）和模块标识（SZL 0x001C）。
+// S7-200 SMART部分固件不支持模块标识，此时只返回订货号
+func (c *gos7Client) CPUInfo() (CPUInfo, error) {
+	var info CPUInfo
+	records, size, err := c.readSZL(0x0011, 0)
+	if err != nil {
+		return info, err
+	}
+	// 每条记录：索引、20字节订货号、模块类型、版本。索引1为CPU模块，索引7的最后3字节为固件版本
+	for r := records; size >= 28 && len(r) >= size; r = r[size:] {
+		switch binary.BigEndian.Uint16(r) {
+		case 0x0001:
+			info.OrderCode = strings.TrimSpace(string(r[2:22]))
+		case 0x0007:
+			info.Firmware = fmt.Sprintf("V%d.%d.%d", r[25], r[26], r[27])
+		}
+	}
+	if info.OrderCode == "" {
+		return info, fmt.Errorf("SZL 0011中没有CPU的订货号")
+	}
+	if records, size, err := c.readSZL(0x001C, 0); err == nil {
+		// 每条记录：索引和32字节的名称
+		for r := records; size >= 34 && len(r) >= size; r = r[size:] {
+			name := strings.TrimSpace(strings.TrimRight(string(r[2:34]), "\x00"))
+			switch binary.BigEndian.Uint16(r) {
+			case 0x0001:
+				info.ASName = name
+			case 0x0002:
+				info.ModuleName = name
+			case 0x0005:
+				info.SerialNumber = name
+			case 0x0007:
+				info.ModuleType = name
+			}
+		}
+	}
+	return info, nil
+}
+
+// ReadClock 读取CPU时钟
+func (c *gos7Client) ReadClock() (time.Time, error) {
+	data, err := c.userData(readClockRequest)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// 保留字节、年份高两位，后跟8字节BCD的DATE_AND_TIME
+	if len(data) < 10 {
+		return time.Time{}, fmt.Errorf("时钟应答长度不足")
+	}
+	var h gos7.Helper
+	return h.GetDateTimeAt(data, 2), nil
+}
+
+// WriteClock 设置CPU时钟
+func (c *gos7Client) WriteClock(t time.Time) error {
+	req := append([]byte(nil), setClockRequest...)
+	var h gos7.Helper
+	h.SetDateTimeAt(req, 31, t)
+	req[30] = byte(t.Year()/1000<<4 | t.Year()/100%10)
+	req[38] = req[38]&0xF0 | byte(t.Weekday()+1) // S7中1表示星期日
+	resp, err := c.handler.Send(req)
+	if err != nil {
+		return err
+	}
+	if len(resp) < 29 || resp[8] != 0x07 {
+		return fmt.Errorf("无效的应答: % X", resp)
+	}
+	if code := binary.BigEndian.Uint16(resp[27:]); code != 0 {
+		return fmt.Errorf("PLC应答错误码 %04X", code)
+	}
+	return nil
+}
+
+// CPUState 读取运行状态，gos7返回8表示RUN，4表示STOP
+func (c *gos7Client) CPUState() (CPUState, error) {
+	status, err := c.client.PLCGetStatus()
+	if err != nil {
+		return CPUStateUnknown, err
+	}
+	switch status {
+	case 8:
+		return CPUStateRun, nil
+	case 4:
+		return CPUStateStop, nil
+	}
+	return CPUStateUnknown, nil
+}
+
+// RunCPU 暖启动CPU，保持存储区中的数据
+func (c *gos7Client) RunCPU() error { return c.client.PLCHotStart() }
+
+func (c *gos7Client) StopCPU() error { return c.client.PLCStop() }
@@ -0,0 +1,219 @@
+package s7viewer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// S7数据类型
+const (
+	TypeBool   = "BOOL"
+	TypeByte   = "BYTE"
+	TypeWord   = "WORD"
+	TypeInt    = "INT"
+	TypeDWord  = "DWORD"
+	TypeDInt   = "DINT"
+	TypeReal   = "REAL"
+	TypeString = "STRING"
+	TypeBCD16  = "BCD16"
+	TypeBCD32  = "BCD32"
+	// TypeS7String S7标准字符串：最大长度字节、实际长度字节和字符。
+	// TypeString 为Micro/WIN SMART数据块的字符串：只有一个长度字节
+	TypeS7String = "S7STRING"
+)
+
+// DefaultStringLen S7-200 STRING 的默认最大字符数
+const DefaultStringLen = 254
+
+// TypeWidth 返回数据类型占用的字节数，STRING为长度字节加字符，自定义类型为解码器的宽度
+func TypeWidth(dataType string, strLen int) int {
+	switch dataType {
+	case TypeWord, TypeInt, TypeBCD16:
+		return 2
+	case TypeDWord, TypeDInt, TypeReal, TypeBCD32:
+		return 4
+	case TypeString:
+		if strLen <= 0 {
+			strLen = DefaultStringLen
+		}
+		return 1 + strLen
+	case TypeS7String:
+		if strLen <= 0 {
+			strLen = DefaultStringLen
+		}
+		return 2 + strLen
+	}
+	if d, ok := LookupDecoder(dataType); ok {
+		return d.Width()
+	}
+	return 1
+}
+
+// DecodeValue 按数据类型解码data中偏移off处的值并格式化为文本，16/32位数值按order的字节顺序，BOOL使用bit指定位号
+func DecodeValue(order, dataType string, data []byte, off, bit, strLen int) (string, error) {
+	v, err := DecodeTyped(order, dataType, data, off, bit, strLen)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case bool:
+		return strconv.Itoa(boolToInt(v)), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case string:
+		return v, nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// DecodeTyped 按数据类型和order的字节顺序解码data中偏移off处的值，返回对应的Go类型：
+// BOOL→bool，BYTE→uint8，WORD→uint16，INT→int16，DWORD→uint32，DINT→int32，REAL→float32，STRING/S7STRING→string，
+// BCD16/BCD32→uint32（含非0-9半字节时返回错误），自定义类型→解码器返回的string
+func DecodeTyped(order, dataType string, data []byte, off, bit, strLen int) (any, error) {
+	width := TypeWidth(dataType, strLen)
+	switch dataType {
+	case TypeString:
+		// 只需要长度字节在范围内，字符按实际长度读取
+		width = 1
+	case TypeS7String:
+		width = 2
+	}
+	if off < 0 || off+width > len(data) {
+		return nil, fmt.Errorf("地址超出读取范围")
+	}
+
+	b := data[off:]
+	if d, ok := LookupDecoder(dataType); ok {
+		return d.Decode(b[:width])
+	}
+	if width > 1 && dataType != TypeS7String {
+		// 16/32位数值按字节顺序重排为大端再解码
+		b = ToBigEndian(order, b[:width])
+	}
+	switch dataType {
+	case TypeBool:
+		return (b[0]>>bit)&1 == 1, nil
+	case TypeByte:
+		return b[0], nil
+	case TypeWord:
+		return binary.BigEndian.Uint16(b), nil
+	case TypeInt:
+		return int16(binary.BigEndian.Uint16(b)), nil
+	case TypeDWord:
+		return binary.BigEndian.Uint32(b), nil
+	case TypeDInt:
+		return int32(binary.BigEndian.Uint32(b)), nil
+	case TypeReal:
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), nil
+	case TypeBCD16:
+		return DecodeBCD(uint32(binary.BigEndian.Uint16(b)), 4)
+	case TypeBCD32:
+		return DecodeBCD(binary.BigEndian.Uint32(b), 8)
+	case TypeString:
+		n := int(b[0])
+		if 1+n > len(b) {
+			return nil, fmt.Errorf("字符串长度%d超出读取范围", n)
+		}
+		return string(b[1 : 1+n]), nil
+	case TypeS7String:
+		maxLen, n := int(b[0]), int(b[1])
+		if n > maxLen {
+			return nil, fmt.Errorf("字符串实际长度%d大于最大长度%d", n, maxLen)
+		}
+		if 2+n > len(b) {
+			return nil, fmt.Errorf("字符串长度%d超出读取范围", n)
+		}
+		return string(b[2 : 2+n]), nil
+	}
+	return nil, fmt.Errorf("不支持的数据类型: %s", dataType)
+}
+
+// EncodeValue 将文本值按数据类型和order的字节顺序编码，用于写入。STRING编码为长度字节加字符
+func EncodeValue(order, dataType string, text string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	switch dataType {
+	case TypeBool:
+		switch text {
+		case "1", "true", "TRUE", "on", "ON":
+			return []byte{1}, nil
+		case "0", "false", "FALSE", "off", "OFF":
+			return []byte{0}, nil
+		}
+		return nil, fmt.Errorf("无效的BOOL值: %q", text)
+	case TypeReal:
+		f, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return nil, fmt.Errorf("无效的REAL值: %q", text)
+		}
+		return ToBigEndian(order, binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(f)))), nil
+	case TypeString, TypeS7String:
+		// 长度字节加字符，只写入实际长度。S7STRING不含最大长度字节，由调用方从实际长度字节处写入
+		if len(text) > DefaultStringLen {
+			return nil, fmt.Errorf("字符串超过%d个字节", DefaultStringLen)
+		}
+		return append([]byte{byte(len(text))}, text...), nil
+	case TypeByte, TypeWord, TypeInt, TypeDWord, TypeDInt, TypeBCD16, TypeBCD32:
+	default:
+		return nil, fmt.Errorf("不支持写入的数据类型: %s", dataType)
+	}
+
+	// 整数类型先按有符号/无符号范围检查
+	var lo, hi int64
+	switch dataType {
+	case TypeByte:
+		lo, hi = 0, math.MaxUint8
+	case TypeWord:
+		lo, hi = 0, math.MaxUint16
+	case TypeInt:
+		lo, hi = math.MinInt16, math.MaxInt16
+	case TypeDWord:
+		lo, hi = 0, math.MaxUint32
+	case TypeDInt:
+		lo, hi = math.MinInt32, math.MaxInt32
+	case TypeBCD16:
+		lo, hi = 0, 9999
+	case TypeBCD32:
+		lo, hi = 0, 99999999
+	}
+	v, err := strconv.ParseInt(text, 0, 64)
+	if err != nil || v < lo || v > hi {
+		return nil, fmt.Errorf("%s 的取值范围为 %d 到 %d: %q", dataType, lo, hi, text)
+	}
+	if dataType == TypeBCD16 || dataType == TypeBCD32 {
+		v = int64(EncodeBCD(uint32(v)))
+	}
+
+	switch TypeWidth(dataType, 0) {
+	case 1:
+		return []byte{byte(v)}, nil
+	case 2:
+		return ToBigEndian(order, binary.BigEndian.AppendUint16(nil, uint16(v))), nil
+	}
+	return ToBigEndian(order, binary.BigEndian.AppendUint32(nil, uint32(v))), nil
+}
+
+// DecodeBCD 将digits位BCD码（每半字节一位十进制数）转换为数值
+func DecodeBCD(raw uint32, digits int) (uint32, error) {
+	var v uint32
+	for i := digits - 1; i >= 0; i-- {
+		d := (raw >> (4 * i)) & 0xF
+		if d > 9 {
+			return 0, fmt.Errorf("无效的BCD码: 0x%0*X", digits, raw)
+		}
+		v = v*10 + d
+	}
+	return v, nil
+}
+
+// EncodeBCD 将数值编码为BCD码，调用方保证位数不超过8位
+func EncodeBCD(v uint32) uint32 {
+	var raw uint32
+	for shift := 0; v > 0; shift += 4 {
+		raw |= (v % 10) << shift
+		v /= 10
+	}
+	return raw
+}
@@ -0,0 +1,67 @@
+package s7viewer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Decoder 自定义数据类型的解码器，如厂商专用的定点数或时间格式，可由插件注册
+type Decoder interface {
+	// Width 返回占用的字节数
+	Width() int
+	// Decode 将Width个字节（PLC中的原始顺序，不按设置的字节顺序重排）解码为文本
+	Decode(data []byte) (string, error)
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = make(map[string]Decoder)
+)
+
+// builtinTypes 内置的数据类型，自定义类型不能与之重名
+var builtinTypes = []string{TypeBool, TypeByte, TypeWord, TypeInt, TypeDWord, TypeDInt, TypeReal, TypeString, TypeBCD16, TypeBCD32, TypeS7String}
+
+// RegisterDecoder 注册名为name的自定义数据类型，名称不区分大小写，注册后按大写使用
+func RegisterDecoder(name string, d Decoder) error {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return fmt.Errorf("数据类型名称不能为空")
+	}
+	for _, t := range builtinTypes {
+		if t == name {
+			return fmt.Errorf("数据类型 %s 是内置类型", name)
+		}
+	}
+	if w := d.Width(); w <= 0 || w > 256 {
+		return fmt.Errorf("数据类型 %s 的宽度%d无效", name, w)
+	}
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	if _, ok := decoders[name]; ok {
+		return fmt.Errorf("数据类型 %s 已注册", name)
+	}
+	decoders[name] = d
+	return nil
+}
+
+// LookupDecoder 返回自定义数据类型的解码器
+func LookupDecoder(dataType string) (Decoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	d, ok := decoders[dataType]
+	return d, ok
+}
+
+// DecoderTypes 返回已注册的自定义数据类型，按名称排序
+func DecoderTypes() []string {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	names := make([]string, 0, len(decoders))
+	for name := range decoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
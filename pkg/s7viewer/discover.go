@@ -0,0 +1,153 @@
+package s7viewer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxDiscoverHosts 一次搜索允许的最大地址数（/20网段）
+const MaxDiscoverHosts = 4096
+
+// 搜索的默认参数
+const (
+	DefaultDiscoverTimeout = 500 * time.Millisecond
+	DefaultDiscoverWorkers = 64
+)
+
+// Device 搜索到的设备。S7为true表示S7连接成功，OrderCode为读到的订货号和版本（读取失败时为空）
+type Device struct {
+	IP        string
+	S7        bool
+	OrderCode string
+	Err       error // 确认时S7连接或读取订货号失败的原因
+}
+
+// DiscoverOptions 子网搜索的参数，零值使用默认值
+type DiscoverOptions struct {
+	Port    int           // 默认102
+	Timeout time.Duration // 每个地址的TCP连接超时
+	Workers int           // 并发连接数
+	// Confirm 为true时对端口开放的地址再建立S7连接并读取订货号，Rack、Slot为连接参数
+	Confirm    bool
+	Rack, Slot int
+	// Progress 不为nil时每搜索完一个地址调用，与found在同一协程串行调用
+	Progress func(done, total int)
+}
+
+// DiscoverHosts 返回CIDR网段中要搜索的地址（仅IPv4），/31和/32以外不含网络地址和广播地址
+func DiscoverHosts(cidr string) ([]string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		// 单个地址按/32处理
+		addr, addrErr := netip.ParseAddr(cidr)
+		if addrErr != nil {
+			return nil, fmt.Errorf("无效的网段: %q，应为如 192.168.1.0/24", cidr)
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	if !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("只支持IPv4网段: %s", cidr)
+	}
+	bits := 32 - prefix.Bits()
+	if bits > 12 {
+		return nil, fmt.Errorf("网段 %s 过大，最多搜索%d个地址", cidr, MaxDiscoverHosts)
+	}
+	prefix = prefix.Masked()
+	var hosts []string
+	for addr, i := prefix.Addr(), 0; i < 1<<bits; addr, i = addr.Next(), i+1 {
+		if bits >= 2 && (i == 0 || i == 1<<bits-1) {
+			continue
+		}
+		hosts = append(hosts, addr.String())
+	}
+	return hosts, nil
+}
+
+// Discover 搜索CIDR网段中S7端口开放的设备，每找到一个调用found（串行调用）。
+// ctx取消时尽快返回ctx.Err()，已找到的设备已通过found报告。
+func Discover(ctx context.Context, cidr string, opts DiscoverOptions, found func(Device)) error {
+	hosts, err := DiscoverHosts(cidr)
+	if err != nil {
+		return err
+	}
+	if opts.Port == 0 {
+		opts.Port = DefaultPort
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultDiscoverTimeout
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultDiscoverWorkers
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex // 串行调用found和Progress
+	done := 0
+	var wg sync.WaitGroup
+	for range min(opts.Workers, len(hosts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				dev, ok := probe(ctx, ip, opts)
+				mu.Lock()
+				done++
+				if ok {
+					found(dev)
+				}
+				if opts.Progress != nil {
+					opts.Progress(done, len(hosts))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, ip := range hosts {
+		select {
+		case jobs <- ip:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// probe 连接一个地址的S7端口，端口未开放时ok为false
+func probe(ctx context.Context, ip string, opts DiscoverOptions) (dev Device, ok bool) {
+	if ctx.Err() != nil {
+		return dev, false
+	}
+	d := net.Dialer{Timeout: opts.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(opts.Port)))
+	if err != nil {
+		return dev, false
+	}
+	conn.Close()
+	dev.IP = ip
+	if !opts.Confirm {
+		return dev, true
+	}
+
+	cfg := Config{IP: ip, Rack: opts.Rack, Slot: opts.Slot, Port: opts.Port, Timeout: opts.Timeout}
+	client := NewGos7Client(cfg)
+	if dev.Err = client.Connect(); dev.Err != nil {
+		return dev, true
+	}
+	defer client.Close()
+	dev.S7 = true
+	if sc, isSC := client.(SystemClient); isSC {
+		var info CPUInfo
+		if info, dev.Err = sc.CPUInfo(); dev.Err == nil {
+			dev.OrderCode = info.OrderCode + " " + info.Firmware
+		}
+	}
+	return dev, true
+}
@@ -0,0 +1,117 @@
+package s7viewer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 错误类别，可用errors.Is判断读写方法返回的错误
+var (
+	ErrNotConnected   = errors.New("PLC未连接")
+	ErrConnectionLost = errors.New("连接已断开")
+	ErrTimeout        = errors.New("请求超时")
+	ErrOutOfRange     = errors.New("地址超出范围")
+	ErrAccessDenied   = errors.New("访问被拒绝")
+	ErrNotSupported   = errors.New("客户端不支持该功能")
+	ErrReadOnly       = errors.New("只读模式，禁止写入和控制PLC")
+)
+
+// Error 一次PLC请求失败的错误。Kind为上面的错误类别之一，无法归类时为nil；
+// Err为gos7返回的原始错误，排查问题时使用。
+type Error struct {
+	Op   string // 失败的操作，如“读取V区(DB1方式)”
+	Kind error
+	Err  error
+}
+
+// Error 归类后的错误只显示类别，原始错误通过Err查看
+func (e *Error) Error() string {
+	if e.Kind != nil {
+		return e.Op + "失败: " + e.Kind.Error()
+	}
+	return e.Op + "失败: " + e.Err.Error()
+}
+
+// Unwrap 同时返回错误类别和原始错误，errors.Is/As对两者都有效
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// wrapError 将gos7返回的错误包装为*Error，err为nil时返回nil
+func wrapError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Op: op, Kind: classify(err), Err: err}
+}
+
+// classify 按错误类型和gos7的错误文本判断类别
+func classify(err error) error {
+	var ne net.Error
+	switch {
+	case errors.As(err, &ne) && ne.Timeout(), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, net.ErrClosed),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE):
+		return ErrConnectionLost
+	}
+
+	text := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(text, "timeout"), strings.Contains(text, "timed out"):
+		return ErrTimeout
+	case strings.Contains(text, "connection reset"), strings.Contains(text, "broken pipe"),
+		strings.Contains(text, "closed network connection"), strings.Contains(text, "connection refused"),
+		strings.Contains(text, "eof"):
+		return ErrConnectionLost
+	case strings.Contains(text, "out of range"), strings.Contains(text, "item not available"),
+		strings.Contains(text, "invalid transport size"), strings.Contains(text, "size error"):
+		return ErrOutOfRange
+	case strings.Contains(text, "access denied"), strings.Contains(text, "not authorized"),
+		strings.Contains(text, "protection"), strings.Contains(text, "password"):
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// retryable 返回错误是否可能在重试后恢复：超时和连接断开可以重试，地址越界和拒绝访问不行
+func retryable(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrConnectionLost)
+}
+
+// RetryPolicy 请求失败后的重试策略，只对超时和连接断开的错误重试
+type RetryPolicy struct {
+	Count int           // 重试次数，0表示不重试
+	Delay time.Duration // 每次重试前的等待时间
+}
+
+// DefaultRetryPolicy 界面和命令行默认使用的重试策略
+var DefaultRetryPolicy = RetryPolicy{Count: 2, Delay: 500 * time.Millisecond}
+
+// retry 按连接的重试策略执行fn，直到成功、错误不可重试、次数用完或ctx被取消
+func (p *Viewer) retry(ctx context.Context, fn func() error) error {
+	p.mu.Lock()
+	policy := p.conn.Retry
+	p.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.Count || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(policy.Delay):
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package s7viewer
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultHealthInterval 后台心跳检测的默认周期
+const DefaultHealthInterval = 10 * time.Second
+
+// StartHealthCheck 启动后台连接心跳检测，周期性读取V0的1个字节判断链路是否正常。
+// 数据监控或自动重连期间跳过检测（监控本身已在使用链路），断开连接时自动停止。
+func (p *Viewer) StartHealthCheck(interval time.Duration, statusFn func(error)) {
+	if interval <= 0 {
+		interval = DefaultHealthInterval
+	}
+
+	p.mu.Lock()
+	if p.healthStop != nil || p.client == nil {
+		p.mu.Unlock()
+		return
+	}
+	stopChan := make(chan bool)
+	p.healthStop = stopChan
+	p.mu.Unlock()
+
+	go func() {
+		// 断开连接时取消正在进行的检测读取
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-stopChan
+			cancel()
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopChan:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				busy := p.running || p.reconnectStop != nil
+				p.mu.Unlock()
+				if busy {
+					continue
+				}
+
+				_, err := p.ReadV(ctx, 0, 1)
+
+				// 检测期间可能已断开，此时不再上报
+				if ctx.Err() != nil {
+					return
+				}
+				if statusFn != nil {
+					statusFn(err)
+				}
+				p.noteReadResult(ctx, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,294 @@
+package s7viewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/robinson/gos7"
+)
+
+// S7协议的区域代码和字长，用于组装多变量读取请求
+const (
+	S7AreaPE       = 0x81
+	S7AreaPA       = 0x82
+	S7AreaMK       = 0x83
+	S7AreaDB       = 0x84
+	S7AreaCT       = 0x1C
+	S7AreaTM       = 0x1D
+	s7WordLenCount = 0x1C
+	s7WordLenTimer = 0x1D
+)
+
+// 多变量读取的限制。PDU为240字节时请求中每个变量占12字节，最多容纳19个，
+// 协商到更大的PDU时按比例增加；应答中每个变量有4字节头，数据按偶数字节对齐。
+const (
+	maxMultiItems     = 19
+	multiItemOverhead = 4
+)
+
+// DefaultCoalesceGap ReadItems默认的合并间隔（字节）。多读几个字节的代价远小于多一次请求，
+// 经VPN等高延迟链路访问时尤其明显。
+const DefaultCoalesceGap = 8
+
+// SetCoalesceGap 设置ReadItems合并相邻变量的最大间隔：同一存储区中间隔不超过gap字节
+// （T/C区为gap个）的变量合并为一次连续读取。0只合并紧邻或重叠的变量，负数不合并。
+func (p *Viewer) SetCoalesceGap(gap int) {
+	p.mu.Lock()
+	p.coalesceGap = gap
+	p.mu.Unlock()
+}
+
+// Item 批量读取中的一个变量
+type Item struct {
+	Area  string // 存储区，取值见MemoryAreas
+	Start int    // 起始字节，T/C区为起始编号
+	Size  int    // 字节数，T/C区为个数
+	Data  []byte // 读取结果，T/C区为Size*2字节
+	Err   error  // 该变量的读取错误
+}
+
+// byteCount 变量应答数据占用的字节数
+func (it *Item) byteCount() int {
+	if IsCounterArea(it.Area) {
+		return it.Size * 2
+	}
+	return it.Size
+}
+
+// ReadItems 将多个分散的变量合并为尽量少的S7多变量读取请求（AGReadMulti），
+// 结果和错误逐项写回items。同一存储区中相距不超过合并间隔（见SetCoalesceGap）的变量
+// 先合并为一段连续范围读取，例如V100、V102、V105合并为V100..V106；
+// 合并的范围读取失败时（例如其中某个变量越界），其中的变量改为逐个读取，错误仍逐项报告。
+// 超过单个PDU的变量改为分段读取；某个请求整体失败时（例如V区需要回退到MB方式），
+// 该请求中的变量逐个重读。只有PLC未连接或ctx已取消时才返回错误。
+func (p *Viewer) ReadItems(ctx context.Context, items []Item) error {
+	p.mu.Lock()
+	client := p.client
+	access := p.vAccess
+	gap := p.coalesceGap
+	p.mu.Unlock()
+
+	if client == nil {
+		return ErrNotConnected
+	}
+
+	for i := range items {
+		it := &items[i]
+		it.Data, it.Err = nil, nil
+		if it.Size <= 0 {
+			it.Err = fmt.Errorf("无效的长度: %d", it.Size)
+		}
+	}
+	if gap < 0 {
+		p.readItemsBatched(ctx, client, access, items)
+		return ctx.Err()
+	}
+
+	ranges, owner := coalesce(items, gap, p.ChunkSize()-multiItemOverhead-1)
+	p.readItemsBatched(ctx, client, access, ranges)
+
+	var retry []int
+	for i := range items {
+		it := &items[i]
+		if owner[i] < 0 {
+			continue
+		}
+		r := &ranges[owner[i]]
+		if r.Err != nil {
+			if r.Size == it.Size || ctx.Err() != nil {
+				it.Err = r.Err
+			} else {
+				retry = append(retry, i)
+			}
+			continue
+		}
+		off := (it.Start - r.Start) * (r.byteCount() / r.Size)
+		it.Data = append([]byte(nil), r.Data[off:off+it.byteCount()]...)
+	}
+
+	if len(retry) > 0 {
+		single := make([]Item, len(retry))
+		for k, i := range retry {
+			single[k] = Item{Area: items[i].Area, Start: items[i].Start, Size: items[i].Size}
+		}
+		p.readItemsBatched(ctx, client, access, single)
+		for k, i := range retry {
+			items[i].Data, items[i].Err = single[k].Data, single[k].Err
+		}
+	}
+	return ctx.Err()
+}
+
+// coalesce 将同一存储区中间隔不超过gap字节（T/C区为gap个）的有效变量合并为连续的读取范围，
+// 合并后的范围不超过limit字节。返回合并后的范围，以及每个变量所属范围的下标（无效变量为-1）。
+func coalesce(items []Item, gap, limit int) (ranges []Item, owner []int) {
+	order := make([]int, 0, len(items))
+	owner = make([]int, len(items))
+	for i := range items {
+		owner[i] = -1
+		if items[i].Err == nil {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := &items[order[a]], &items[order[b]]
+		if areaKey(x.Area) != areaKey(y.Area) {
+			return areaKey(x.Area) < areaKey(y.Area)
+		}
+		return x.Start < y.Start
+	})
+
+	for _, i := range order {
+		it := &items[i]
+		if n := len(ranges); n > 0 {
+			r := &ranges[n-1]
+			end := max(r.Start+r.Size, it.Start+it.Size)
+			merged := Item{Area: r.Area, Start: r.Start, Size: end - r.Start}
+			if areaKey(r.Area) == areaKey(it.Area) && it.Start <= r.Start+r.Size+gap && merged.byteCount() <= limit {
+				r.Size = merged.Size
+				owner[i] = n - 1
+				continue
+			}
+		}
+		ranges = append(ranges, Item{Area: it.Area, Start: it.Start, Size: it.Size})
+		owner[i] = len(ranges) - 1
+	}
+	return ranges, owner
+}
+
+// areaKey 存储区的比较键，空存储区按V区处理
+func areaKey(area string) string {
+	if area == "" {
+		return AreaV
+	}
+	return area
+}
+
+// readItemsBatched 将变量按PDU容量分组，每组用一次多变量请求读取，跳过已有错误的变量
+func (p *Viewer) readItemsBatched(ctx context.Context, client S7Reader, access string, items []Item) {
+	chunk := p.ChunkSize()
+	maxItems := maxMultiItems
+	if pdu := p.PDULength(); pdu > DefaultPDULength {
+		maxItems = maxMultiItems * pdu / DefaultPDULength
+	}
+
+	var batch []int
+	budget := 0
+	flush := func() {
+		if len(batch) > 0 {
+			p.readBatch(ctx, client, access, items, batch)
+		}
+		batch, budget = nil, 0
+	}
+
+	for i := range items {
+		it := &items[i]
+		if it.Err != nil {
+			continue
+		}
+
+		n := it.byteCount() + it.byteCount()%2 + multiItemOverhead
+		if n > chunk {
+			it.Data, it.Err = p.readChunked(ctx, it.Area, it.Start, it.Size)
+			continue
+		}
+		if len(batch) == maxItems || budget+n > chunk {
+			flush()
+		}
+		batch = append(batch, i)
+		budget += n
+	}
+	flush()
+}
+
+// readBatch 用一次AGReadMulti读取batch中的变量
+func (p *Viewer) readBatch(ctx context.Context, client S7Reader, access string, items []Item, batch []int) {
+	dataItems := make([]gos7.S7DataItem, 0, len(batch))
+	for _, i := range batch {
+		it := &items[i]
+		d, err := s7DataItem(it, access)
+		if err != nil {
+			it.Err = err
+			continue
+		}
+		dataItems = append(dataItems, d)
+	}
+	if len(dataItems) == 0 {
+		return
+	}
+
+	var err error
+	if ctxErr := p.do(ctx, func() { err = client.ReadMulti(dataItems) }); ctxErr != nil {
+		for _, i := range batch {
+			if items[i].Err == nil {
+				items[i].Err = ctxErr
+			}
+		}
+		return
+	}
+
+	if err != nil {
+		// 整个请求失败时逐项重读，由ReadArea处理V区回退等情况
+		for _, i := range batch {
+			it := &items[i]
+			if it.Err == nil {
+				it.Data, it.Err = p.ReadArea(ctx, it.Area, it.Start, it.Size)
+			}
+		}
+		return
+	}
+
+	k := 0
+	for _, i := range batch {
+		it := &items[i]
+		if it.Err != nil {
+			continue
+		}
+		d := dataItems[k]
+		k++
+		if d.Error != "" {
+			it.Err = wrapError("读取"+ByteAddressName(it.Area, it.Start), errors.New(d.Error))
+			continue
+		}
+		it.Data = d.Data
+	}
+}
+
+// s7DataItem 将变量转换为gos7的多变量读取项
+func s7DataItem(it *Item, access string) (gos7.S7DataItem, error) {
+	d := gos7.S7DataItem{
+		WordLen: S7WordLenByte,
+		Start:   it.Start,
+		Amount:  it.Size,
+		Data:    make([]byte, it.byteCount()),
+	}
+	switch it.Area {
+	case AreaV, "":
+		if access == VAccessMB {
+			d.Area = S7AreaMK
+		} else {
+			d.Area, d.DBNumber = S7AreaDB, 1
+		}
+	case AreaI:
+		d.Area = S7AreaPE
+	case AreaQ:
+		d.Area = S7AreaPA
+	case AreaM:
+		d.Area = S7AreaMK
+	case AreaSM:
+		d.Area = S7AreaSM200
+	case AreaAI:
+		d.Area = S7AreaAI200
+	case AreaAQ:
+		d.Area = S7AreaAQ200
+	case AreaT:
+		d.Area, d.WordLen = S7AreaTM, s7WordLenTimer
+	case AreaC:
+		d.Area, d.WordLen = S7AreaCT, s7WordLenCount
+	default:
+		return d, fmt.Errorf("不支持的存储区: %s", it.Area)
+	}
+	return d, nil
+}
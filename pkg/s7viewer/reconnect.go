@@ -0,0 +1,158 @@
+package s7viewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// 自动重连参数
+const (
+	reconnectThreshold  = 3 // 连续读取失败多少次后检查链路
+	reconnectMinBackoff = 1 * time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// SetStateHandler 设置连接状态回调，自动重连开始、重试和恢复时在后台协程中调用
+func (p *Viewer) SetStateHandler(fn func(connected bool, text string)) {
+	p.mu.Lock()
+	p.stateFn = fn
+	p.mu.Unlock()
+}
+
+// SetErrorHandler 设置后台监控读取失败时的回调，在监控协程中调用
+func (p *Viewer) SetErrorHandler(fn func(err error)) {
+	p.mu.Lock()
+	p.errorFn = fn
+	p.mu.Unlock()
+}
+
+func (p *Viewer) notifyError(err error) {
+	p.mu.Lock()
+	fn := p.errorFn
+	p.mu.Unlock()
+	if fn != nil {
+		fn(err)
+	}
+}
+
+func (p *Viewer) notifyState(connected bool, text string) {
+	p.mu.Lock()
+	fn := p.stateFn
+	p.mu.Unlock()
+	if fn != nil {
+		fn(connected, text)
+	}
+}
+
+// isReconnecting 返回是否正在自动重连，重连期间后台读取暂停
+func (p *Viewer) isReconnecting() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reconnectStop != nil
+}
+
+// noteReadResult 记录一次后台读取的结果。连续失败达到阈值后先读V0探测链路，
+// 探测也失败才认为连接已断开并启动自动重连（地址越界等错误不触发重连）。
+func (p *Viewer) noteReadResult(ctx context.Context, err error) {
+	p.mu.Lock()
+	if err == nil {
+		p.failures = 0
+		p.mu.Unlock()
+		return
+	}
+	p.readErrors++
+	// 地址越界和拒绝访问说明链路正常，不计入连续失败
+	if !errors.Is(err, ErrOutOfRange) && !errors.Is(err, ErrAccessDenied) {
+		p.failures++
+	}
+	trigger := p.failures >= reconnectThreshold && p.reconnectStop == nil && p.client != nil
+	p.mu.Unlock()
+	if !trigger {
+		return
+	}
+
+	_, probeErr := p.ReadV(ctx, 0, 1)
+	if ctx.Err() != nil {
+		return
+	}
+	if probeErr == nil {
+		p.mu.Lock()
+		p.failures = 0
+		p.mu.Unlock()
+		return
+	}
+	p.startReconnect(err)
+}
+
+// startReconnect 关闭失效的连接并在后台按指数退避重新连接。
+// 监控协程保持运行，重连成功后自动恢复读取。
+func (p *Viewer) startReconnect(cause error) {
+	p.mu.Lock()
+	if p.reconnectStop != nil || p.conn.IP == "" {
+		p.mu.Unlock()
+		return
+	}
+	stopChan := make(chan bool)
+	p.reconnectStop = stopChan
+	if p.client != nil {
+		p.client.Close()
+	}
+	p.client = nil
+	cfg := p.conn
+	p.mu.Unlock()
+
+	log.Printf("PLC %s 链路异常，开始自动重连: %v", cfg.IP, cause)
+	go p.reconnectLoop(cfg, stopChan)
+}
+
+func (p *Viewer) reconnectLoop(cfg Config, stopChan chan bool) {
+	backoff := reconnectMinBackoff
+	for attempt := 1; ; attempt++ {
+		wait := jitter(backoff)
+		p.notifyState(false, fmt.Sprintf("重连中 (第%d次, %.1f秒后)", attempt, wait.Seconds()))
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(wait):
+		}
+
+		client, err := p.dialPLC(cfg)
+
+		p.mu.Lock()
+		select {
+		case <-stopChan:
+			// 等待期间用户已断开或重新连接，丢弃本次结果
+			p.mu.Unlock()
+			if err == nil {
+				client.Close()
+			}
+			return
+		default:
+		}
+		if err == nil {
+			p.client = client
+			p.pduLength = client.PDULength()
+			p.reconnectStop = nil
+			p.failures = 0
+			p.reconnects++
+			p.mu.Unlock()
+
+			log.Printf("PLC %s 已重新连接（第%d次尝试）", cfg.IP, attempt)
+			p.notifyState(true, "已重连 "+cfg.IP)
+			return
+		}
+		p.mu.Unlock()
+
+		log.Printf("重连 %s 失败: %v", cfg.IP, err)
+		backoff = min(backoff*2, reconnectMaxBackoff)
+	}
+}
+
+// jitter 在退避时间上叠加±20%的随机抖动，避免多台PLC同时重连
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration((rand.Float64()*0.4-0.2)*float64(d))
+}
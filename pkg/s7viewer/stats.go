@@ -0,0 +1,25 @@
+package s7viewer
+
+import "time"
+
+// Stats viewer的内部运行指标
+type Stats struct {
+	Connected    bool
+	ScanDuration time.Duration // 最近一次监控读取耗时
+	Cycle        time.Duration // 最近一次实测的扫描周期
+	ReadErrors   int           // 后台读取失败累计次数
+	Reconnects   int           // 自动重连成功累计次数
+}
+
+// Stats 返回当前的运行指标
+func (p *Viewer) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Stats{
+		Connected:    p.client != nil,
+		ScanDuration: p.lastScan,
+		Cycle:        p.lastCycle,
+		ReadErrors:   p.readErrors,
+		Reconnects:   p.reconnects,
+	}
+}
@@ -0,0 +1,156 @@
+package s7viewer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CPUInfo CPU的型号和标识，用于把采集的数据对应到具体的CPU
+type CPUInfo struct {
+	OrderCode    string // 订货号，如 6ES7 288-1ST40-0AA1
+	Firmware     string // 固件版本，如 V2.5.0
+	ModuleType   string // 模块类型名称
+	SerialNumber string
+	ModuleName   string
+	ASName       string // 自动化系统名称
+}
+
+// CPUState CPU的运行状态
+type CPUState int
+
+const (
+	CPUStateUnknown CPUState = iota
+	CPUStateRun
+	CPUStateStop
+)
+
+func (s CPUState) String() string {
+	switch s {
+	case CPUStateRun:
+		return "RUN"
+	case CPUStateStop:
+		return "STOP"
+	}
+	return "未知"
+}
+
+// SystemClient S7Reader之外的系统功能（读取CPU信息等），gos7Client实现了它。
+// 测试用的模拟客户端可不实现，此时Viewer的相应方法返回ErrNotSupported。
+type SystemClient interface {
+	CPUInfo() (CPUInfo, error)
+	// ReadClock、WriteClock 读写CPU的实时时钟，时间没有时区，按墙上时间读写
+	ReadClock() (time.Time, error)
+	WriteClock(t time.Time) error
+	// CPUState 读取CPU的运行状态；RunCPU、StopCPU 将CPU切换到RUN或STOP
+	CPUState() (CPUState, error)
+	RunCPU() error
+	StopCPU() error
+}
+
+// systemClient 返回当前连接的SystemClient
+func (p *Viewer) systemClient() (SystemClient, error) {
+	p.mu.Lock()
+	client := p.client
+	p.mu.Unlock()
+	if client == nil {
+		return nil, ErrNotConnected
+	}
+	sc, ok := client.(SystemClient)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return sc, nil
+}
+
+// CPUInfo 读取CPU的订货号、固件版本、序列号和模块名
+func (p *Viewer) CPUInfo(ctx context.Context) (CPUInfo, error) {
+	sc, err := p.systemClient()
+	if err != nil {
+		return CPUInfo{}, err
+	}
+	var info CPUInfo
+	var readErr error
+	if err := p.do(ctx, func() { info, readErr = sc.CPUInfo() }); err != nil {
+		return CPUInfo{}, err
+	}
+	if readErr != nil {
+		return CPUInfo{}, wrapError("读取CPU信息", readErr)
+	}
+	return info, nil
+}
+
+// ReadClock 读取CPU的实时时钟。PLC的时钟没有时区，返回值按本机时区解释
+func (p *Viewer) ReadClock(ctx context.Context) (time.Time, error) {
+	sc, err := p.systemClient()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var t time.Time
+	var readErr error
+	if err := p.do(ctx, func() { t, readErr = sc.ReadClock() }); err != nil {
+		return time.Time{}, err
+	}
+	if readErr != nil {
+		return time.Time{}, wrapError("读取PLC时钟", readErr)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local), nil
+}
+
+// SetClock 将CPU的实时时钟设为t在本机时区的墙上时间
+func (p *Viewer) SetClock(ctx context.Context, t time.Time) error {
+	if p.ReadOnly() {
+		return ErrReadOnly
+	}
+	sc, err := p.systemClient()
+	if err != nil {
+		return err
+	}
+	var writeErr error
+	if err := p.do(ctx, func() { writeErr = sc.WriteClock(t.In(time.Local)) }); err != nil {
+		return err
+	}
+	return wrapError("设置PLC时钟", writeErr)
+}
+
+// CPUState 读取CPU当前的运行状态
+func (p *Viewer) CPUState(ctx context.Context) (CPUState, error) {
+	sc, err := p.systemClient()
+	if err != nil {
+		return CPUStateUnknown, err
+	}
+	var state CPUState
+	var readErr error
+	if err := p.do(ctx, func() { state, readErr = sc.CPUState() }); err != nil {
+		return CPUStateUnknown, err
+	}
+	if readErr != nil {
+		return CPUStateUnknown, wrapError("读取CPU状态", readErr)
+	}
+	return state, nil
+}
+
+// SetCPUState 将CPU切换到RUN或STOP，state为其他值时返回错误
+func (p *Viewer) SetCPUState(ctx context.Context, state CPUState) error {
+	if state != CPUStateRun && state != CPUStateStop {
+		return fmt.Errorf("无效的CPU状态: %v", state)
+	}
+	if p.ReadOnly() {
+		return ErrReadOnly
+	}
+	sc, err := p.systemClient()
+	if err != nil {
+		return err
+	}
+	var cmdErr error
+	if err := p.do(ctx, func() {
+		if state == CPUStateRun {
+			cmdErr = sc.RunCPU()
+		} else {
+			cmdErr = sc.StopCPU()
+		}
+	}); err != nil {
+		return err
+	}
+	return wrapError("切换CPU到"+state.String(), cmdErr)
+}
@@ -0,0 +1,583 @@
+// Package s7viewer 通过S7协议连接西门子S7-200 SMART，读取、写入、解码和监控V/I/Q/M/SM/T/C/AI/AQ存储区。
+//
+// 基本用法：
+//
+//	v := s7viewer.New()
+//	if err := v.Connect(s7viewer.DefaultConfig("192.168.1.11")); err != nil {
+//		log.Fatal(err)
+//	}
+//	defer v.Disconnect()
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+//	data, err := v.ReadRange(ctx, s7viewer.AreaV, 0, 100) // 单次读取VB0开始的100字节
+//	cancel()
+//
+//	v.Subscribe(context.Background(), s7viewer.AreaV, 0, 100, func(data []byte) {
+//		// 每个扫描周期在监控协程中调用
+//	})
+//	defer v.Unsubscribe()
+//
+// 读取结果为PLC中的原始字节（大端），用DecodeValue/DecodeTyped按数据类型解码，
+// 用EncodeValue编码后通过WriteV写入。
+//
+// 所有读写方法都接受context.Context：ctx被取消或超时时方法立即返回ctx.Err()，
+// 调用方可以在断开连接或退出程序时取消尚未完成的请求，而不必等待协议超时。
+package s7viewer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// 默认连接参数，适用于S7-200 SMART的以太网口
+const (
+	DefaultRack    = 0
+	DefaultSlot    = 1
+	DefaultPort    = 102 // ISO-on-TCP
+	DefaultTimeout = 5 * time.Second
+)
+
+// Config PLC连接参数。经网关访问或连接其他CPU系列时需修改机架/槽位/端口
+type Config struct {
+	IP      string
+	Rack    int
+	Slot    int
+	Port    int
+	Timeout time.Duration
+	Retry   RetryPolicy // 读写失败（超时、连接断开）后的重试策略，零值表示不重试
+}
+
+// DefaultConfig 返回S7-200 SMART的默认连接参数
+func DefaultConfig(ip string) Config {
+	return Config{IP: ip, Rack: DefaultRack, Slot: DefaultSlot, Port: DefaultPort, Timeout: DefaultTimeout, Retry: DefaultRetryPolicy}
+}
+
+// 监控扫描周期范围
+const (
+	MinScanInterval     = 100 * time.Millisecond
+	MaxScanInterval     = 60 * time.Second
+	DefaultScanInterval = 1000 * time.Millisecond
+)
+
+// V区访问方式。S7协议中V区就是DB1（区域0x84），gos7没有单独的VB读取接口，
+// 因此“原生VB”与DB1方式等价；部分网关把V区映射到M区，可选MB方式。
+const (
+	VAccessAuto = "DB1(失败回退MB)"
+	VAccessDB1  = "仅DB1"
+	VAccessMB   = "仅MB"
+)
+
+// VAccessModes 可选的V区访问方式
+var VAccessModes = []string{VAccessAuto, VAccessDB1, VAccessMB}
+
+// Viewer 一台PLC的连接。所有方法可在多个协程中并发调用，对PLC的读写请求按顺序发送。
+type Viewer struct {
+	client        S7Reader
+	Dial          func(cfg Config) S7Reader // 创建客户端，测试中替换为模拟实现
+	running       bool
+	stopChan      chan bool
+	verifyWrite   bool   // 写入后读回校验，默认开启
+	vAccess       string // V区访问方式
+	lastAccess    string // 最近一次读取实际使用的方式
+	lastAccessErr error
+	healthStop    chan bool // 后台心跳检测的停止信号，nil表示未运行
+	scanInterval  time.Duration
+	lastCycle     time.Duration // 最近一次实测的扫描周期
+	conn          Config        // 最近一次连接的参数，自动重连使用
+	pduLength     int           // 连接时协商的PDU长度，0表示未知
+	coalesceGap   int           // ReadItems合并相邻变量的最大间隔，负数表示不合并
+	failures      int           // 后台读取连续失败次数
+	readErrors    int           // 后台读取失败累计次数
+	reconnects    int           // 自动重连成功累计次数
+	lastScan      time.Duration // 最近一次监控读取耗时
+	reconnectStop chan bool     // 自动重连的停止信号，nil表示未在重连
+	stateFn       func(connected bool, text string)
+	errorFn       func(err error)
+	writeFn       func(WriteEvent)
+	readOnly      bool   // 只读模式下拒绝所有写入、RUN/STOP和设置时钟
+	byteOrder     string // 多字节数值的字节顺序，空表示大端
+	mu            sync.Mutex
+	io            chan struct{} // 容量为1，串行化对PLC的读写请求
+}
+
+// New 创建未连接的Viewer，默认使用gos7客户端、写入后读回校验和1秒的扫描周期
+func New() *Viewer {
+	return &Viewer{
+		stopChan:     make(chan bool),
+		verifyWrite:  true,
+		vAccess:      VAccessAuto,
+		scanInterval: DefaultScanInterval,
+		coalesceGap:  DefaultCoalesceGap,
+		Dial:         NewGos7Client,
+		io:           make(chan struct{}, 1),
+	}
+}
+
+// Connect 按连接参数连接PLC，已有连接或正在自动重连时先断开
+func (p *Viewer) Connect(cfg Config) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// 如果已存在连接或正在自动重连，先断开
+	if p.client != nil || p.reconnectStop != nil {
+		p.closeLocked()
+		// 等待一小段时间确保连接完全断开
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	client, err := p.dialPLC(cfg)
+	if err != nil {
+		return err
+	}
+
+	p.conn = cfg
+	p.failures = 0
+	p.client = client
+	p.pduLength = client.PDULength()
+	return nil
+}
+
+// dialPLC 按连接参数建立到PLC的连接
+func (p *Viewer) dialPLC(cfg Config) (S7Reader, error) {
+	client := p.Dial(cfg)
+	if err := client.Connect(); err != nil {
+		return nil, wrapError("连接PLC", err)
+	}
+	return client, nil
+}
+
+// Disconnect 断开连接，同时停止心跳检测和自动重连
+func (p *Viewer) Disconnect() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closeLocked()
+}
+
+// closeLocked 关闭连接并停止心跳检测和自动重连，调用方需持有p.mu
+func (p *Viewer) closeLocked() {
+	if p.healthStop != nil {
+		close(p.healthStop)
+		p.healthStop = nil
+	}
+	if p.reconnectStop != nil {
+		close(p.reconnectStop)
+		p.reconnectStop = nil
+	}
+
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+	p.pduLength = 0
+}
+
+// do 独占链路执行一次PLC请求。ctx在排队或请求期间被取消、超时时立即返回ctx.Err()；
+// gos7的请求本身无法中断，已发出的请求在后台等到应答或连接超时后才释放链路，
+// 保证前后请求的报文不会交错。
+func (p *Viewer) do(ctx context.Context, fn func()) error {
+	select {
+	case p.io <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		<-p.io
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer func() { <-p.io }()
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReadV 读取V区，按SetVAccess设置的方式访问DB1或MB
+func (p *Viewer) ReadV(ctx context.Context, startByte int, size int) ([]byte, error) {
+	p.mu.Lock()
+	client := p.client
+	access := p.vAccess
+	p.mu.Unlock()
+
+	if client == nil {
+		return nil, ErrNotConnected
+	}
+
+	buffer := make([]byte, size)
+
+	var used string
+	err := p.retry(ctx, func() error {
+		var readErr error
+		if err := p.do(ctx, func() {
+			used, readErr = readV(client, access, startByte, buffer)
+		}); err != nil {
+			return err
+		}
+		return readErr
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	p.mu.Lock()
+	p.lastAccess = used
+	p.lastAccessErr = err
+	p.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// readV 按访问方式读取V区到buffer，返回实际使用的方式
+func readV(client S7Reader, access string, startByte int, buffer []byte) (used string, readErr error) {
+	size := len(buffer)
+	switch access {
+	case VAccessDB1:
+		used = "DB1"
+		if err := client.ReadDB(1, startByte, size, buffer); err != nil {
+			readErr = wrapError("读取V区(DB1方式)", err)
+		}
+	case VAccessMB:
+		used = "MB"
+		if err := client.ReadArea(S7AreaMK, startByte, size, buffer); err != nil {
+			readErr = wrapError("读取V区(MB方式)", err)
+		}
+	default:
+		// 尝试通过DB1访问V区（S7-200 Smart的V区映射到DB1）
+		used = "DB1"
+		if err := client.ReadDB(1, startByte, size, buffer); err != nil {
+			// 如果DB1方式失败，尝试直接MB方式
+			used = "MB(DB1失败后回退)"
+			if err2 := client.ReadArea(S7AreaMK, startByte, size, buffer); err2 != nil {
+				readErr = &Error{Op: "读取V区", Kind: classify(err2), Err: fmt.Errorf("DB1方式: %v, MB方式: %w", err, err2)}
+			}
+		}
+	}
+	return used, readErr
+}
+
+// SetVAccess 设置V区访问方式
+func (p *Viewer) SetVAccess(access string) {
+	p.mu.Lock()
+	p.vAccess = access
+	p.mu.Unlock()
+}
+
+// AccessStatus 返回最近一次V区读取实际使用的方式及结果，用于排查映射问题
+func (p *Viewer) AccessStatus() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.lastAccess == "" {
+		return "读取方式: " + p.vAccess
+	}
+	if p.lastAccessErr != nil {
+		return "读取方式: " + p.lastAccess + " 失败"
+	}
+	return "读取方式: " + p.lastAccess + " 成功"
+}
+
+// WriteV 向V区写入字节数据，开启校验时写后读回比对
+func (p *Viewer) WriteV(ctx context.Context, startByte int, data []byte) error {
+	return p.observeWrite(ctx, AreaV, startByte, data, func() error { return p.writeV(ctx, startByte, data) })
+}
+
+func (p *Viewer) writeV(ctx context.Context, startByte int, data []byte) error {
+	p.mu.Lock()
+	client := p.client
+	verify := p.verifyWrite
+	access := p.vAccess
+	p.mu.Unlock()
+
+	if client == nil {
+		return ErrNotConnected
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("写入数据为空")
+	}
+
+	// 写入不做回退，避免误写M区；仅在明确选择MB方式时写M区
+	err := p.retry(ctx, func() error {
+		var err error
+		if ctxErr := p.do(ctx, func() {
+			if access == VAccessMB {
+				err = client.WriteArea(S7AreaMK, startByte, data)
+			} else {
+				err = client.WriteDB(1, startByte, data)
+			}
+		}); ctxErr != nil {
+			return ctxErr
+		}
+		return wrapError("写入V区", err)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !verify {
+		return nil
+	}
+
+	// 从写入的同一区域读回，不走DB1失败回退MB的读取方式，避免读到另一个区域的数据
+	readAccess := VAccessDB1
+	if access == VAccessMB {
+		readAccess = VAccessMB
+	}
+	readBack := make([]byte, len(data))
+	var readErr error
+	if err := p.do(ctx, func() { _, readErr = readV(client, readAccess, startByte, readBack) }); err != nil {
+		return fmt.Errorf("写入后读回失败: %w", err)
+	}
+	if readErr != nil {
+		return fmt.Errorf("写入后读回失败: %w", readErr)
+	}
+	if !bytes.Equal(readBack, data) {
+		return fmt.Errorf("写入失败: 读回值=% X, 期望=% X", readBack, data)
+	}
+	log.Printf("写入已验证: V%d, %d字节", startByte, len(data))
+	return nil
+}
+
+// WriteVBit 通过读-改-写修改V区单个位，写入经过WriteV的校验
+func (p *Viewer) WriteVBit(ctx context.Context, byteAddr, bit int, value bool) error {
+	if bit < 0 || bit > 7 {
+		return fmt.Errorf("位号超出范围(0-7): %d", bit)
+	}
+	if p.ReadOnly() {
+		return ErrReadOnly
+	}
+
+	current, err := p.ReadV(ctx, byteAddr, 1)
+	if err != nil {
+		return err
+	}
+
+	b := current[0]
+	if value {
+		b |= 1 << bit
+	} else {
+		b &^= 1 << bit
+	}
+	return p.WriteV(ctx, byteAddr, []byte{b})
+}
+
+// SetVerifyWrite 设置写入后是否读回校验
+func (p *Viewer) SetVerifyWrite(verify bool) {
+	p.mu.Lock()
+	p.verifyWrite = verify
+	p.mu.Unlock()
+}
+
+// VerifyWrite 返回写入后是否读回校验。开启时写入方法返回nil即表示读回值与写入值一致
+func (p *Viewer) VerifyWrite() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.verifyWrite
+}
+
+// MaxReadChunk PDU长度未知时单次请求读取的最大字节数。S7-200 SMART默认的PDU为240字节，
+// 扣除报文头后留出余量，超出部分由readChunked分段读取。
+const MaxReadChunk = 200
+
+// DefaultPDULength S7-200 SMART默认协商的PDU长度，部分固件版本可协商到480字节
+const DefaultPDULength = 240
+
+// pduReadOverhead 读取应答中报文头、参数和数据项头占用的字节数
+const pduReadOverhead = 18
+
+// PDULength 返回连接时与PLC协商的PDU长度，未连接或未知时为0
+func (p *Viewer) PDULength() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pduLength
+}
+
+// ChunkSize 返回单次请求可读取的最大字节数：按协商的PDU长度扣除报文头，PDU长度未知时为MaxReadChunk
+func (p *Viewer) ChunkSize() int {
+	if pdu := p.PDULength(); pdu > pduReadOverhead {
+		return pdu - pduReadOverhead
+	}
+	return MaxReadChunk
+}
+
+// ReadRange 单次读取数据，返回原始字节数据。超过单个PDU的范围自动分段读取后拼接。
+func (p *Viewer) ReadRange(ctx context.Context, area string, startAddress int, length int) ([]byte, error) {
+	if length <= 0 {
+		length = 1
+	}
+	return p.readChunked(ctx, area, startAddress, length)
+}
+
+// readChunked 将任意长度的读取拆分为多个不超过ChunkSize字节的请求，按顺序读取后拼接。
+// T/C区的起始地址和长度以元素为单位，每个元素2字节。
+func (p *Viewer) readChunked(ctx context.Context, area string, start int, size int) ([]byte, error) {
+	chunk := p.ChunkSize()
+	if IsCounterArea(area) {
+		chunk /= 2
+	}
+	data := make([]byte, 0, size)
+	for off := 0; off < size; off += chunk {
+		part, err := p.ReadArea(ctx, area, start+off, min(chunk, size-off))
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, part...)
+	}
+	return data, nil
+}
+
+// Subscribe 开始后台监控：按扫描周期读取指定范围，每次读取成功后在监控协程中调用updateFunc。
+// 已在监控时不做任何操作；读取失败只记录日志，连续失败时触发自动重连。
+// ctx被取消或调用Unsubscribe时监控结束，正在进行的读取随之取消。
+func (p *Viewer) Subscribe(ctx context.Context, area string, startAddress int, length int, updateFunc func([]byte)) {
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return
+	}
+	p.running = true
+	stopChan := make(chan bool)
+	p.stopChan = stopChan
+	p.mu.Unlock()
+
+	go func(startAddr int, length int, updateFn func([]byte)) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-stopChan:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		defer p.endMonitor(stopChan)
+
+		interval := p.ScanInterval()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastTick time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if !lastTick.IsZero() {
+					p.mu.Lock()
+					p.lastCycle = now.Sub(lastTick)
+					p.mu.Unlock()
+				}
+				lastTick = now
+
+				// 周期被修改后立即生效，无需重启监控
+				if d := p.ScanInterval(); d != interval {
+					interval = d
+					ticker.Reset(interval)
+				}
+
+				// 自动重连期间暂停读取，重连成功后继续
+				if p.isReconnecting() {
+					continue
+				}
+
+				readStart := time.Now()
+				data, err := p.ReadRange(ctx, area, startAddr, length)
+				// 读取期间可能已停止监控，此时丢弃结果，也不计入读取失败
+				if ctx.Err() != nil {
+					return
+				}
+				p.mu.Lock()
+				p.lastScan = time.Since(readStart)
+				p.mu.Unlock()
+				p.noteReadResult(ctx, err)
+				if err != nil {
+					log.Printf("读取数据失败: %v", err)
+					p.notifyError(err)
+					continue
+				}
+
+				if updateFn != nil {
+					updateFn(data)
+				}
+			}
+		}
+	}(startAddress, length, updateFunc)
+}
+
+// endMonitor 监控协程退出时调用。ctx被取消而非Unsubscribe结束时清除运行标志，
+// 之后可以重新开始监控
+func (p *Viewer) endMonitor(stopChan chan bool) {
+	p.mu.Lock()
+	if p.running && p.stopChan == stopChan {
+		p.running = false
+	}
+	p.mu.Unlock()
+}
+
+// SetScanInterval 设置监控扫描周期，超出范围时截断到[100ms, 60s]
+func (p *Viewer) SetScanInterval(d time.Duration) time.Duration {
+	d = max(MinScanInterval, min(d, MaxScanInterval))
+	p.mu.Lock()
+	p.scanInterval = d
+	p.mu.Unlock()
+	return d
+}
+
+// ScanInterval 返回当前的监控扫描周期
+func (p *Viewer) ScanInterval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.scanInterval
+}
+
+// CycleTime 返回最近一次实测的扫描周期，尚未测得时为0
+func (p *Viewer) CycleTime() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastCycle
+}
+
+// IsConnected 返回当前是否已连接（自动重连期间为false）
+func (p *Viewer) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.client != nil
+}
+
+// IsMonitoring 返回监控是否正在运行
+func (p *Viewer) IsMonitoring() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running
+}
+
+// Unsubscribe 停止后台监控，正在进行的读取结果被丢弃
+func (p *Viewer) Unsubscribe() {
+	p.mu.Lock()
+	if p.running {
+		close(p.stopChan)
+		p.running = false
+	}
+	p.mu.Unlock()
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
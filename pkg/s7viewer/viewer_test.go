@@ -0,0 +1,837 @@
+package s7viewer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/robinson/gos7"
+)
+
+// mockReader 内存中的S7Reader：V区为DB1，其他存储区按区域代码存放
+type mockReader struct {
+	mu       sync.Mutex
+	areas    map[int][]byte
+	dbErr    error // 非nil时ReadDB失败，用于测试V区回退
+	readOnly bool  // 为true时写入被忽略，用于测试写后校验
+	requests int   // 收到的读取请求数
+	closed   bool
+	block    chan struct{} // 非nil时ReadDB等到该通道关闭才返回，模拟无应答的PLC
+	pdu      int           // 协商的PDU长度，0表示未知
+	fail     []error       // ReadDB依次返回的错误，用完后正常读取
+}
+
+func newMockReader() *mockReader {
+	m := &mockReader{areas: make(map[int][]byte)}
+	for _, area := range []int{S7AreaDB, S7AreaPE, S7AreaPA, S7AreaMK, S7AreaSM200, S7AreaAI200, S7AreaAQ200, S7AreaTM, S7AreaCT} {
+		m.areas[area] = make([]byte, 1024)
+	}
+	return m
+}
+
+func (m *mockReader) Connect() error { return nil }
+
+func (m *mockReader) PDULength() int { return m.pdu }
+
+func (m *mockReader) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	return nil
+}
+
+// span 返回区域中的一段，T/C区按每个2字节换算
+func (m *mockReader) span(area, start, size int) ([]byte, error) {
+	if area == S7AreaTM || area == S7AreaCT {
+		start, size = start*2, size*2
+	}
+	buf := m.areas[area]
+	if start < 0 || start+size > len(buf) {
+		return nil, fmt.Errorf("地址越界")
+	}
+	return buf[start : start+size], nil
+}
+
+func (m *mockReader) ReadDB(db, start, size int, buffer []byte) error {
+	if m.block != nil {
+		<-m.block
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	if m.dbErr != nil {
+		return m.dbErr
+	}
+	if len(m.fail) > 0 {
+		err := m.fail[0]
+		m.fail = m.fail[1:]
+		return err
+	}
+	if db != 1 {
+		return fmt.Errorf("DB%d不存在", db)
+	}
+	b, err := m.span(S7AreaDB, start, size)
+	if err != nil {
+		return err
+	}
+	copy(buffer, b)
+	return nil
+}
+
+func (m *mockReader) WriteDB(db, start int, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.span(S7AreaDB, start, len(data))
+	if err != nil || m.readOnly {
+		return err
+	}
+	copy(b, data)
+	return nil
+}
+
+func (m *mockReader) ReadArea(area, start, size int, buffer []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	b, err := m.span(area, start, size)
+	if err != nil {
+		return err
+	}
+	copy(buffer, b)
+	return nil
+}
+
+func (m *mockReader) WriteArea(area, start int, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.span(area, start, len(data))
+	if err != nil || m.readOnly {
+		return err
+	}
+	copy(b, data)
+	return nil
+}
+
+func (m *mockReader) ReadMulti(items []gos7.S7DataItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	for i := range items {
+		it := &items[i]
+		b, err := m.span(it.Area, it.Start, it.Amount)
+		if err != nil {
+			it.Error = err.Error()
+			continue
+		}
+		copy(it.Data, b)
+	}
+	return nil
+}
+
+// newTestViewer 创建连接到m的viewer
+func newTestViewer(t *testing.T, m *mockReader) *Viewer {
+	t.Helper()
+	p := New()
+	p.Dial = func(Config) S7Reader { return m }
+	if err := p.Connect(Config{IP: "mock"}); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	return p
+}
+
+func TestReadVAreaFallsBackToMB(t *testing.T) {
+	m := newMockReader()
+	m.dbErr = fmt.Errorf("DB1不可用")
+	copy(m.areas[S7AreaMK][10:], []byte{0x12, 0x34})
+	p := newTestViewer(t, m)
+
+	data, err := p.ReadV(context.Background(), 10, 2)
+	if err != nil {
+		t.Fatalf("ReadV: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0x12, 0x34}) {
+		t.Errorf("ReadV = % X, want 12 34", data)
+	}
+	if s := p.AccessStatus(); !strings.Contains(s, "MB") || !strings.Contains(s, "成功") {
+		t.Errorf("AccessStatus = %q, want MB fallback success", s)
+	}
+
+	p.SetVAccess(VAccessDB1)
+	if _, err := p.ReadV(context.Background(), 10, 2); err == nil {
+		t.Error("ReadV with DB1 only: want error, got nil")
+	}
+}
+
+func TestReadOnceSplitsLongRanges(t *testing.T) {
+	m := newMockReader()
+	for i := range m.areas[S7AreaDB] {
+		m.areas[S7AreaDB][i] = byte(i)
+	}
+	p := newTestViewer(t, m)
+
+	data, err := p.ReadRange(context.Background(), AreaV, 100, 450)
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if !bytes.Equal(data, m.areas[S7AreaDB][100:550]) {
+		t.Error("ReadRange returned wrong data")
+	}
+	if want := (450 + MaxReadChunk - 1) / MaxReadChunk; m.requests != want {
+		t.Errorf("requests = %d, want %d", m.requests, want)
+	}
+}
+
+func TestReadAreaCounters(t *testing.T) {
+	m := newMockReader()
+	copy(m.areas[S7AreaTM][37*2:], []byte{0x01, 0x2C})
+	p := newTestViewer(t, m)
+
+	data, err := p.ReadRange(context.Background(), AreaT, 37, 1)
+	if err != nil {
+		t.Fatalf("ReadRange(T37): %v", err)
+	}
+	if got := binary.BigEndian.Uint16(data); got != 300 {
+		t.Errorf("T37 = %d, want 300", got)
+	}
+}
+
+func TestWriteVAreaVerifies(t *testing.T) {
+	m := newMockReader()
+	p := newTestViewer(t, m)
+
+	if err := p.WriteV(context.Background(), 20, []byte{0xAB, 0xCD}); err != nil {
+		t.Fatalf("WriteV: %v", err)
+	}
+	if !bytes.Equal(m.areas[S7AreaDB][20:22], []byte{0xAB, 0xCD}) {
+		t.Errorf("V20 = % X, want AB CD", m.areas[S7AreaDB][20:22])
+	}
+	if err := p.WriteVBit(context.Background(), 20, 0, true); err != nil {
+		t.Fatalf("WriteVBit: %v", err)
+	}
+	if m.areas[S7AreaDB][20] != 0xAB|1 {
+		t.Errorf("VB20 = %02X, want %02X", m.areas[S7AreaDB][20], 0xAB|1)
+	}
+
+	m.readOnly = true
+	if err := p.WriteV(context.Background(), 30, []byte{0x01}); err == nil {
+		t.Error("WriteV to read-only mock: want verify error, got nil")
+	}
+}
+
+func TestWriteVVerifiesSameArea(t *testing.T) {
+	m := newMockReader()
+	m.dbErr = fmt.Errorf("DB1不可用")
+	copy(m.areas[S7AreaMK][40:], []byte{0xAB, 0xCD})
+	p := newTestViewer(t, m)
+
+	// 写入DB1后读回失败时不能回退到M区，即使M区恰好是期望的值
+	err := p.WriteV(context.Background(), 40, []byte{0xAB, 0xCD})
+	if err == nil || !strings.Contains(err.Error(), "读回失败") {
+		t.Fatalf("WriteV with DB1 read failing: err = %v, want read-back error", err)
+	}
+
+	p.SetVerifyWrite(false)
+	if p.VerifyWrite() {
+		t.Fatal("VerifyWrite() = true after SetVerifyWrite(false)")
+	}
+	if err := p.WriteV(context.Background(), 40, []byte{0xAB, 0xCD}); err != nil {
+		t.Fatalf("WriteV without verify: %v", err)
+	}
+}
+
+func TestWriteArea(t *testing.T) {
+	m := newMockReader()
+	p := newTestViewer(t, m)
+
+	if err := p.WriteArea(context.Background(), AreaAQ, 16, []byte{0x6C, 0x00}); err != nil {
+		t.Fatalf("WriteArea(AQW16): %v", err)
+	}
+	if !bytes.Equal(m.areas[S7AreaAQ200][16:18], []byte{0x6C, 0x00}) {
+		t.Errorf("AQW16 = % X, want 6C 00", m.areas[S7AreaAQ200][16:18])
+	}
+	if err := p.WriteArea(context.Background(), AreaAI, 16, []byte{0, 0}); err == nil {
+		t.Error("WriteArea(AI): want error, got nil")
+	}
+	if err := p.WriteArea(context.Background(), AreaT, 37, []byte{0x01}); err == nil {
+		t.Error("WriteArea(T) with odd length: want error, got nil")
+	}
+
+	m.readOnly = true
+	if err := p.WriteArea(context.Background(), AreaAQ, 18, []byte{0x01, 0x00}); err == nil {
+		t.Error("WriteArea to read-only mock: want verify error, got nil")
+	}
+}
+
+func TestWriteHandler(t *testing.T) {
+	m := newMockReader()
+	m.areas[S7AreaDB][40] = 0x0F
+	p := newTestViewer(t, m)
+
+	var events []WriteEvent
+	p.SetWriteHandler(func(e WriteEvent) { events = append(events, e) })
+	ctx := context.Background()
+	if err := p.WriteVBit(ctx, 40, 7, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WriteArea(WithoutWriteHandler(ctx), AreaQ, 0, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+	e := events[0]
+	if e.Area != AreaV || e.Start != 40 || !bytes.Equal(e.Old, []byte{0x0F}) || !bytes.Equal(e.New, []byte{0x8F}) || e.Err != nil {
+		t.Errorf("event = %+v", e)
+	}
+}
+
+func TestReadItems(t *testing.T) {
+	m := newMockReader()
+	copy(m.areas[S7AreaDB][100:], []byte{0x41, 0x20, 0x00, 0x00})
+	m.areas[S7AreaMK][5] = 0x80
+	p := newTestViewer(t, m)
+
+	items := []Item{
+		{Area: AreaV, Start: 100, Size: 4},
+		{Area: AreaM, Start: 5, Size: 1},
+		{Area: AreaV, Start: 2000, Size: 2},
+	}
+	if err := p.ReadItems(context.Background(), items); err != nil {
+		t.Fatalf("ReadItems: %v", err)
+	}
+	if v, err := DecodeValue(OrderBigEndian, TypeReal, items[0].Data, 0, 0, 0); err != nil || v != "10" {
+		t.Errorf("VD100 as REAL = %q, %v, want 10", v, err)
+	}
+	if !bytes.Equal(items[1].Data, []byte{0x80}) || items[1].Err != nil {
+		t.Errorf("MB5 = % X, %v, want 80", items[1].Data, items[1].Err)
+	}
+	if items[2].Err == nil {
+		t.Error("VW2000: want out of range error, got nil")
+	}
+}
+
+func TestByteOrder(t *testing.T) {
+	data := []byte{0x12, 0x34, 0x56, 0x78}
+	tests := []struct {
+		order string
+		want  string
+	}{
+		{OrderBigEndian, "12345678"},
+		{OrderLittleEndian, "78563412"},
+		{OrderWordSwap, "56781234"},
+		{OrderByteSwap, "34127856"},
+	}
+	for _, tt := range tests {
+		got, err := DecodeValue(tt.order, TypeDWord, data, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("DecodeValue(%s): %v", tt.order, err)
+		}
+		want, _ := strconv.ParseUint(tt.want, 16, 32)
+		if got != strconv.FormatUint(want, 10) {
+			t.Errorf("DecodeValue(%s) = %s, want %d", tt.order, got, want)
+		}
+		// 编码是解码的逆操作
+		if enc, err := EncodeValue(tt.order, TypeDWord, got); err != nil || !bytes.Equal(enc, data) {
+			t.Errorf("EncodeValue(%s, %s) = % X, %v; want % X", tt.order, got, enc, err, data)
+		}
+	}
+
+	// 各Viewer的字节顺序互不影响
+	a, b := New(), New()
+	a.SetByteOrder(OrderLittleEndian)
+	b.SetByteOrder("无效")
+	if a.ByteOrder() != OrderLittleEndian || b.ByteOrder() != OrderBigEndian {
+		t.Errorf("ByteOrder = %q, %q; want %q, %q", a.ByteOrder(), b.ByteOrder(), OrderLittleEndian, OrderBigEndian)
+	}
+}
+
+func TestMonitoringDeliversScans(t *testing.T) {
+	m := newMockReader()
+	m.areas[S7AreaDB][0] = 0x5A
+	p := newTestViewer(t, m)
+	p.SetScanInterval(MinScanInterval)
+
+	got := make(chan []byte, 10)
+	p.Subscribe(context.Background(), AreaV, 0, 1, func(data []byte) { got <- data })
+	defer p.Unsubscribe()
+
+	select {
+	case data := <-got:
+		if !bytes.Equal(data, []byte{0x5A}) {
+			t.Errorf("scan = % X, want 5A", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no scan within 2s")
+	}
+	if !p.IsMonitoring() {
+		t.Error("IsMonitoring = false while running")
+	}
+}
+
+func TestBurstSamplesBackToBack(t *testing.T) {
+	m := newMockReader()
+	m.areas[S7AreaDB][50] = 0x01
+	p := newTestViewer(t, m)
+
+	samples, err := p.Burst(context.Background(), AreaV, 50, 2, time.Second, 20)
+	if err != nil {
+		t.Fatalf("Burst: %v", err)
+	}
+	if len(samples) != 20 {
+		t.Fatalf("len(samples) = %d, want 20", len(samples))
+	}
+	for i, s := range samples {
+		if !bytes.Equal(s.Data, []byte{0x01, 0x00}) {
+			t.Errorf("sample %d = % X, want 01 00", i, s.Data)
+		}
+		if i > 0 && s.Time.Before(samples[i-1].Time) {
+			t.Errorf("sample %d time goes backwards", i)
+		}
+	}
+
+	if _, err := p.Burst(context.Background(), AreaV, 0, p.ChunkSize()+1, time.Second, 0); err == nil {
+		t.Error("Burst beyond one PDU: want error, got nil")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if samples, err := p.Burst(ctx, AreaV, 50, 1, time.Second, 0); err != nil || len(samples) != 0 {
+		t.Errorf("Burst with cancelled ctx = %d samples, %v; want 0, nil", len(samples), err)
+	}
+}
+
+func TestDisconnectClosesClient(t *testing.T) {
+	m := newMockReader()
+	p := newTestViewer(t, m)
+	p.Disconnect()
+	if !m.closed {
+		t.Error("Disconnect did not close the client")
+	}
+	if p.IsConnected() {
+		t.Error("IsConnected = true after disconnect")
+	}
+	if _, err := p.ReadV(context.Background(), 0, 1); err == nil {
+		t.Error("ReadV after disconnect: want error, got nil")
+	}
+}
+
+func TestReadVHonorsDeadline(t *testing.T) {
+	m := newMockReader()
+	p := newTestViewer(t, m)
+	m.block = make(chan struct{})
+	defer close(m.block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	_, err := p.ReadV(ctx, 0, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ReadV err = %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(begin); d > time.Second {
+		t.Errorf("ReadV returned after %v, want about 50ms", d)
+	}
+
+	// 上一个请求仍在等待应答，排队中的请求同样按ctx返回
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	cancel2()
+	if _, err := p.ReadRange(ctx2, AreaI, 0, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadRange err = %v, want Canceled", err)
+	}
+}
+
+func TestWriteVReadBackHonorsDeadline(t *testing.T) {
+	m := newMockReader()
+	p := newTestViewer(t, m)
+	m.block = make(chan struct{})
+	defer close(m.block)
+
+	// 写入成功，读回时PLC无应答：按ctx返回，不等待仍在读取的协程
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := p.WriteV(ctx, 10, []byte{0x55})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WriteV err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestSubscribeStopsWhenContextCancelled(t *testing.T) {
+	m := newMockReader()
+	p := newTestViewer(t, m)
+	p.SetScanInterval(MinScanInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan []byte, 10)
+	p.Subscribe(ctx, AreaV, 0, 1, func(data []byte) { got <- data })
+	select {
+	case <-got:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no scan within 2s")
+	}
+
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for p.IsMonitoring() {
+		if time.Now().After(deadline) {
+			t.Fatal("IsMonitoring still true 2s after cancel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReadRangeUsesNegotiatedPDU(t *testing.T) {
+	m := newMockReader()
+	m.pdu = 480
+	p := newTestViewer(t, m)
+
+	if got := p.PDULength(); got != 480 {
+		t.Errorf("PDULength = %d, want 480", got)
+	}
+	if _, err := p.ReadRange(context.Background(), AreaV, 0, 450); err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if m.requests != 1 {
+		t.Errorf("requests = %d, want 1 with 480-byte PDU", m.requests)
+	}
+
+	p.Disconnect()
+	if got := p.ChunkSize(); got != MaxReadChunk {
+		t.Errorf("ChunkSize after disconnect = %d, want %d", got, MaxReadChunk)
+	}
+}
+
+func TestReadItemsCoalescesAdjacent(t *testing.T) {
+	m := newMockReader()
+	copy(m.areas[S7AreaDB][100:], []byte{0x00, 0x01, 0x00, 0x02, 0xFF, 0x03})
+	p := newTestViewer(t, m)
+
+	items := []Item{
+		{Area: AreaV, Start: 100, Size: 2},
+		{Area: AreaV, Start: 102, Size: 2},
+		{Area: AreaV, Start: 105, Size: 1},
+	}
+	if err := p.ReadItems(context.Background(), items); err != nil {
+		t.Fatalf("ReadItems: %v", err)
+	}
+	want := [][]byte{{0x00, 0x01}, {0x00, 0x02}, {0x03}}
+	for i, it := range items {
+		if it.Err != nil || !bytes.Equal(it.Data, want[i]) {
+			t.Errorf("item %d = % X, %v, want % X", i, it.Data, it.Err, want[i])
+		}
+	}
+	if m.requests != 1 {
+		t.Errorf("requests = %d, want 1", m.requests)
+	}
+
+	// 合并的范围中有越界变量时改为逐个读取，其他变量仍能读到
+	m.areas[S7AreaDB][1020] = 0x7E
+	items = []Item{{Area: AreaV, Start: 1020, Size: 1}, {Area: AreaV, Start: 1023, Size: 2}}
+	if err := p.ReadItems(context.Background(), items); err != nil {
+		t.Fatalf("ReadItems: %v", err)
+	}
+	if !bytes.Equal(items[0].Data, []byte{0x7E}) || items[0].Err != nil {
+		t.Errorf("VB1020 = % X, %v, want 7E", items[0].Data, items[0].Err)
+	}
+	if items[1].Err == nil {
+		t.Error("VW1023: want out of range error, got nil")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	items := []Item{
+		{Area: AreaV, Start: 105, Size: 1},
+		{Area: AreaM, Start: 0, Size: 1},
+		{Area: AreaV, Start: 100, Size: 2},
+		{Area: AreaV, Start: 102, Size: 2},
+		{Area: AreaV, Start: 120, Size: 2},
+	}
+	ranges, owner := coalesce(items, 2, 200)
+	got := make([]string, len(ranges))
+	for i, r := range ranges {
+		got[i] = fmt.Sprintf("%s%d+%d", r.Area, r.Start, r.Size)
+	}
+	if want := "M0+1 V100+6 V120+2"; strings.Join(got, " ") != want {
+		t.Errorf("ranges = %v, want %s", got, want)
+	}
+	if want := []int{1, 0, 1, 1, 2}; fmt.Sprint(owner) != fmt.Sprint(want) {
+		t.Errorf("owner = %v, want %v", owner, want)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	m := newMockReader()
+	m.areas[S7AreaDB][0] = 0x11
+	p := New()
+	p.Dial = func(Config) S7Reader { return m }
+	p.SetVAccess(VAccessDB1)
+	if err := p.Connect(Config{IP: "mock", Retry: RetryPolicy{Count: 2, Delay: time.Millisecond}}); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// 超时在重试后恢复
+	m.fail = []error{errors.New("read tcp: i/o timeout"), errors.New("read tcp: i/o timeout")}
+	data, err := p.ReadV(context.Background(), 0, 1)
+	if err != nil || !bytes.Equal(data, []byte{0x11}) {
+		t.Fatalf("ReadV = % X, %v, want 11", data, err)
+	}
+	if m.requests != 3 {
+		t.Errorf("requests = %d, want 3", m.requests)
+	}
+
+	// 地址越界不重试，错误可按类别判断
+	m.requests = 0
+	m.fail = []error{errors.New("CPU : Address out of range")}
+	_, err = p.ReadV(context.Background(), 0, 1)
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadV err = %v, want ErrOutOfRange", err)
+	}
+	var s7err *Error
+	if !errors.As(err, &s7err) || s7err.Err.Error() != "CPU : Address out of range" {
+		t.Errorf("ReadV err = %#v, want *Error wrapping the gos7 error", err)
+	}
+	if m.requests != 1 {
+		t.Errorf("requests = %d, want 1", m.requests)
+	}
+
+	p.Disconnect()
+	if _, err := p.ReadV(context.Background(), 0, 1); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("ReadV after disconnect err = %v, want ErrNotConnected", err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, ErrTimeout},
+		{io.EOF, ErrConnectionLost},
+		{fmt.Errorf("write: %w", syscall.ECONNRESET), ErrConnectionLost},
+		{errors.New("CPU : Item not available"), ErrOutOfRange},
+		{errors.New("CPU : Function not authorized for current protection level"), ErrAccessDenied},
+		{errors.New("ISO : Invalid PDU received"), nil},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.err); got != tt.want {
+			t.Errorf("classify(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// hexDecoder 测试用的自定义类型：3个字节按十六进制显示
+type hexDecoder struct{}
+
+func (hexDecoder) Width() int { return 3 }
+
+func (hexDecoder) Decode(data []byte) (string, error) { return fmt.Sprintf("%X", data), nil }
+
+func TestRegisterDecoder(t *testing.T) {
+	if err := RegisterDecoder("hex24", hexDecoder{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterDecoder("HEX24", hexDecoder{}); err == nil {
+		t.Error("duplicate registration succeeded")
+	}
+	if err := RegisterDecoder("real", hexDecoder{}); err == nil {
+		t.Error("registration of a built-in type succeeded")
+	}
+	if w := TypeWidth("HEX24", 0); w != 3 {
+		t.Errorf("TypeWidth = %d, want 3", w)
+	}
+	data := []byte{0x00, 0x12, 0xAB, 0x05}
+	if v, err := DecodeValue(OrderBigEndian, "HEX24", data, 1, 0, 0); err != nil || v != "12AB05" {
+		t.Errorf("DecodeValue = %q, %v, want 12AB05", v, err)
+	}
+	if _, err := DecodeValue(OrderBigEndian, "HEX24", data, 2, 0, 0); err == nil {
+		t.Error("decoding past the end succeeded")
+	}
+	if got := DecoderTypes(); fmt.Sprint(got) != "[HEX24]" {
+		t.Errorf("DecoderTypes = %v", got)
+	}
+}
+
+func TestDiscoverHosts(t *testing.T) {
+	hosts, err := DiscoverHosts("192.168.1.5/30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"192.168.1.5", "192.168.1.6"}; fmt.Sprint(hosts) != fmt.Sprint(want) {
+		t.Fatalf("hosts = %v, want %v", hosts, want)
+	}
+	if hosts, err := DiscoverHosts("10.0.0.7"); err != nil || len(hosts) != 1 || hosts[0] != "10.0.0.7" {
+		t.Fatalf("single address = %v, %v", hosts, err)
+	}
+	for _, cidr := range []string{"10.0.0.0/8", "fe80::/120", "abc"} {
+		if _, err := DiscoverHosts(cidr); err == nil {
+			t.Errorf("DiscoverHosts(%q) succeeded", cidr)
+		}
+	}
+}
+
+func TestDiscoverFindsOpenPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	var found []Device
+	var progress int
+	opts := DiscoverOptions{Port: port, Progress: func(done, total int) { progress = done }}
+	if err := Discover(context.Background(), "127.0.0.1/32", opts, func(d Device) { found = append(found, d) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].IP != "127.0.0.1" || found[0].S7 {
+		t.Fatalf("found = %+v", found)
+	}
+	if progress != 1 {
+		t.Errorf("progress = %d, want 1", progress)
+	}
+}
+
+// systemMock 带系统功能的模拟客户端
+type systemMock struct {
+	*mockReader
+	info  CPUInfo
+	clock time.Time
+	state CPUState
+}
+
+func (m *systemMock) CPUInfo() (CPUInfo, error) { return m.info, nil }
+
+func (m *systemMock) ReadClock() (time.Time, error) { return m.clock, nil }
+
+func (m *systemMock) WriteClock(t time.Time) error {
+	m.clock = t
+	return nil
+}
+
+func (m *systemMock) CPUState() (CPUState, error) { return m.state, nil }
+
+func (m *systemMock) RunCPU() error {
+	m.state = CPUStateRun
+	return nil
+}
+
+func (m *systemMock) StopCPU() error {
+	m.state = CPUStateStop
+	return nil
+}
+
+func TestCPUInfo(t *testing.T) {
+	p := newTestViewer(t, newMockReader())
+	if _, err := p.CPUInfo(context.Background()); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("CPUInfo without SystemClient: err = %v, want ErrNotSupported", err)
+	}
+
+	sm := &systemMock{mockReader: newMockReader(), info: CPUInfo{OrderCode: "6ES7 288-1ST40-0AA1", Firmware: "V2.5.0"}}
+	p = New()
+	p.Dial = func(Config) S7Reader { return sm }
+	if err := p.Connect(Config{IP: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Disconnect()
+	info, err := p.CPUInfo(context.Background())
+	if err != nil || info != sm.info {
+		t.Fatalf("CPUInfo = %+v, %v", info, err)
+	}
+}
+
+func TestClock(t *testing.T) {
+	sm := &systemMock{mockReader: newMockReader()}
+	p := New()
+	p.Dial = func(Config) S7Reader { return sm }
+	if err := p.Connect(Config{IP: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Disconnect()
+
+	// PLC时钟按墙上时间读写，UTC的同一时刻写入后读出为本机时区的同一墙上时间
+	set := time.Date(2024, 3, 1, 8, 30, 15, 0, time.Local)
+	if err := p.SetClock(context.Background(), set.UTC()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.ReadClock(context.Background())
+	if err != nil || !got.Equal(set) {
+		t.Fatalf("ReadClock = %v, %v; want %v", got, err, set)
+	}
+}
+
+func TestCPUState(t *testing.T) {
+	sm := &systemMock{mockReader: newMockReader(), state: CPUStateRun}
+	p := New()
+	p.Dial = func(Config) S7Reader { return sm }
+	if err := p.Connect(Config{IP: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Disconnect()
+
+	ctx := context.Background()
+	for _, want := range []CPUState{CPUStateStop, CPUStateRun} {
+		if err := p.SetCPUState(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := p.CPUState(ctx); err != nil || got != want {
+			t.Fatalf("CPUState = %v, %v; want %v", got, err, want)
+		}
+	}
+	if err := p.SetCPUState(ctx, CPUStateUnknown); err == nil {
+		t.Fatal("SetCPUState(CPUStateUnknown) succeeded")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	sm := &systemMock{mockReader: newMockReader(), state: CPUStateRun}
+	p := New()
+	p.Dial = func(Config) S7Reader { return sm }
+	if err := p.Connect(Config{IP: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Disconnect()
+
+	ctx := context.Background()
+	p.SetReadOnly(true)
+	for name, err := range map[string]error{
+		"WriteV":      p.WriteV(ctx, 0, []byte{1}),
+		"WriteVBit":   p.WriteVBit(ctx, 0, 0, true),
+		"WriteArea":   p.WriteArea(ctx, AreaQ, 0, []byte{1}),
+		"SetCPUState": p.SetCPUState(ctx, CPUStateStop),
+		"SetClock":    p.SetClock(ctx, time.Now()),
+	} {
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s in read-only mode: err = %v, want ErrReadOnly", name, err)
+		}
+	}
+	if sm.areas[S7AreaDB][0] != 0 || sm.areas[S7AreaPA][0] != 0 || sm.state != CPUStateRun {
+		t.Error("read-only mode changed the PLC")
+	}
+	if _, err := p.ReadV(ctx, 0, 1); err != nil {
+		t.Errorf("ReadV in read-only mode: %v", err)
+	}
+
+	p.SetReadOnly(false)
+	if err := p.WriteV(ctx, 0, []byte{1}); err != nil {
+		t.Errorf("WriteV after leaving read-only mode: %v", err)
+	}
+}
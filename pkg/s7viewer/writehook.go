@@ -0,0 +1,66 @@
+package s7viewer
+
+import (
+	"context"
+	"time"
+)
+
+// WriteEvent 通过Viewer的一次写入，供写入审计记录
+type WriteEvent struct {
+	Time  time.Time
+	Area  string
+	Start int    // 起始字节，T/C区为起始编号
+	Old   []byte // 写入前读到的值，读取失败时为nil
+	New   []byte
+	Err   error // 写入或写后校验失败的原因
+}
+
+type noWriteHandlerKey struct{}
+
+// WithoutWriteHandler 返回的ctx用于写入时不调用写入回调，如强制表按扫描周期的反复写入
+func WithoutWriteHandler(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noWriteHandlerKey{}, true)
+}
+
+// SetWriteHandler 设置写入回调。设置后每次写入前先读取原值，写入完成后在写入的协程中调用fn
+func (p *Viewer) SetWriteHandler(fn func(WriteEvent)) {
+	p.mu.Lock()
+	p.writeFn = fn
+	p.mu.Unlock()
+}
+
+// SetReadOnly 设置只读模式，开启后写入、RUN/STOP和设置时钟都返回ErrReadOnly
+func (p *Viewer) SetReadOnly(on bool) {
+	p.mu.Lock()
+	p.readOnly = on
+	p.mu.Unlock()
+}
+
+// ReadOnly 返回是否处于只读模式
+func (p *Viewer) ReadOnly() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.readOnly
+}
+
+// observeWrite 执行一次写入：只读模式下拒绝，设置了写入回调时先读取原值并在写入后报告
+func (p *Viewer) observeWrite(ctx context.Context, area string, start int, data []byte, write func() error) error {
+	p.mu.Lock()
+	fn, readOnly := p.writeFn, p.readOnly
+	p.mu.Unlock()
+	if readOnly {
+		return ErrReadOnly
+	}
+	if fn == nil || len(data) == 0 || ctx.Value(noWriteHandlerKey{}) != nil {
+		return write()
+	}
+
+	size := len(data)
+	if IsCounterArea(area) {
+		size /= 2
+	}
+	old, _ := p.ReadArea(ctx, area, start, size)
+	err := write()
+	fn(WriteEvent{Time: time.Now(), Area: area, Start: start, Old: old, New: append([]byte(nil), data...), Err: err})
+	return err
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// plcInfoPanel PLC信息页：连接后读取CPU的订货号、固件版本、序列号和模块名，
+// 导出的快照中附上这些信息，便于把采集的数据对应到具体的CPU。
+// 另显示PLC时钟与电脑时间的偏差，可将电脑时间写入PLC
+type plcInfoPanel struct {
+	content   fyne.CanvasObject
+	getViewer func() (*s7viewer.Viewer, context.Context)
+	getIP     func() string
+	win       fyne.Window
+
+	orderLabel, firmwareLabel, typeLabel, serialLabel, nameLabel, asLabel *widget.Label
+	status                                                                *widget.Label
+
+	// PLC时钟：读取时的PLC时间和相对电脑的偏差
+	clockLabel, driftLabel *widget.Label
+	clockStatus            *widget.Label
+
+	// 最近读到的信息及读取时的PLC地址，只在UI线程中访问
+	info *s7viewer.CPUInfo
+	ip   string
+}
+
+// newPLCInfoPanel 创建PLC信息页，getIP返回当前填写的PLC地址
+func newPLCInfoPanel(win fyne.Window, getViewer func() (*s7viewer.Viewer, context.Context), getIP func() string) *plcInfoPanel {
+	p := &plcInfoPanel{
+		win:           win,
+		getViewer:     getViewer,
+		getIP:         getIP,
+		orderLabel:    widget.NewLabel("-"),
+		firmwareLabel: widget.NewLabel("-"),
+		typeLabel:     widget.NewLabel("-"),
+		serialLabel:   widget.NewLabel("-"),
+		nameLabel:     widget.NewLabel("-"),
+		asLabel:       widget.NewLabel("-"),
+		status:        widget.NewLabel(tr("连接后自动读取")),
+		clockLabel:    widget.NewLabel("-"),
+		driftLabel:    widget.NewLabel("-"),
+		clockStatus:   widget.NewLabel(""),
+	}
+	syncButton := widget.NewButton(tr("同步时间"), p.syncClock)
+	disableInReadOnly(syncButton)
+	p.content = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(tr("订货号:"), p.orderLabel),
+			widget.NewFormItem(tr("固件版本:"), p.firmwareLabel),
+			widget.NewFormItem(tr("模块类型:"), p.typeLabel),
+			widget.NewFormItem(tr("序列号:"), p.serialLabel),
+			widget.NewFormItem(tr("模块名称:"), p.nameLabel),
+			widget.NewFormItem(tr("系统名称:"), p.asLabel),
+		),
+		container.NewHBox(widget.NewButton(tr("刷新"), p.refresh), p.status),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(tr("PLC时钟"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewForm(
+			widget.NewFormItem(tr("PLC时间:"), p.clockLabel),
+			widget.NewFormItem(tr("相对电脑:"), p.driftLabel),
+		),
+		container.NewHBox(
+			widget.NewButton(tr("读取时钟"), p.readClock),
+			syncButton,
+			p.clockStatus,
+		),
+	)
+	return p
+}
+
+// refresh 在后台读取CPU信息和时钟
+func (p *plcInfoPanel) refresh() {
+	viewer, ctx := p.getViewer()
+	if viewer == nil || !viewer.IsConnected() {
+		p.status.SetText(tr("PLC未连接"))
+		return
+	}
+	ip := p.getIP()
+	p.status.SetText(tr("正在读取…"))
+	p.readClock()
+	go func() {
+		info, err := viewer.CPUInfo(ctx)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf(tr("读取CPU信息失败: %v"), err)
+				p.status.SetText(tr("读取失败: ") + err.Error())
+				return
+			}
+			p.info, p.ip = &info, ip
+			for _, f := range []struct {
+				label *widget.Label
+				text  string
+			}{
+				{p.orderLabel, info.OrderCode}, {p.firmwareLabel, info.Firmware}, {p.typeLabel, info.ModuleType},
+				{p.serialLabel, info.SerialNumber}, {p.nameLabel, info.ModuleName}, {p.asLabel, info.ASName},
+			} {
+				if f.text == "" {
+					f.text = "-"
+				}
+				f.label.SetText(f.text)
+			}
+			p.status.SetText(tr("读取时间 ") + time.Now().Format("15:04:05"))
+			log.Printf(tr("CPU信息: %s %s，序列号 %s"), info.OrderCode, info.Firmware, info.SerialNumber)
+		})
+	}()
+}
+
+// current 返回ip对应PLC最近读到的CPU信息，未读到或已连接其他PLC时为nil
+func (p *plcInfoPanel) current(ip string) *s7viewer.CPUInfo {
+	if p.ip != ip {
+		return nil
+	}
+	return p.info
+}
+
+// readClock 在后台读取PLC时钟，与请求前后的电脑时间中点比较得到偏差
+func (p *plcInfoPanel) readClock() {
+	viewer, ctx := p.getViewer()
+	if viewer == nil || !viewer.IsConnected() {
+		p.clockStatus.SetText(tr("PLC未连接"))
+		return
+	}
+	p.clockStatus.SetText(tr("正在读取…"))
+	go func() {
+		before := time.Now()
+		plcTime, err := viewer.ReadClock(ctx)
+		pcTime := before.Add(time.Since(before) / 2)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf(tr("读取PLC时钟失败: %v"), err)
+				p.clockStatus.SetText(tr("读取失败: ") + err.Error())
+				return
+			}
+			p.clockLabel.SetText(plcTime.Format("2006-01-02 15:04:05"))
+			p.driftLabel.SetText(formatDrift(plcTime.Sub(pcTime)))
+			p.clockStatus.SetText(tr("读取时间 ") + pcTime.Format("15:04:05"))
+		})
+	}()
+}
+
+// syncClock 确认后将PLC时钟设为电脑时间，写入后重新读取以显示新的偏差
+func (p *plcInfoPanel) syncClock() {
+	viewer, ctx := p.getViewer()
+	if viewer == nil || !viewer.IsConnected() {
+		p.clockStatus.SetText(tr("PLC未连接"))
+		return
+	}
+	dialog.ShowConfirm(tr("同步时间"), fmt.Sprintf(tr("将PLC %s 的时钟设为电脑时间？"), p.getIP()), func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			err := viewer.SetClock(ctx, time.Now())
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf(tr("同步PLC时钟失败: %v"), err)
+					p.clockStatus.SetText(tr("同步失败: ") + err.Error())
+					return
+				}
+				log.Printf(tr("已将PLC %s 的时钟同步为电脑时间"), p.getIP())
+				p.readClock()
+			})
+		}()
+	}, p.win)
+}
+
+// formatDrift 显示PLC时钟相对电脑的偏差，精确到0.1秒
+func formatDrift(d time.Duration) string {
+	d = d.Round(100 * time.Millisecond)
+	switch {
+	case d > 0:
+		return fmt.Sprintf(tr("快 %v"), d)
+	case d < 0:
+		return fmt.Sprintf(tr("慢 %v"), -d)
+	}
+	return tr("一致")
+}
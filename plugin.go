@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 插件的种类
+const (
+	pluginSink    = "sink"    // 数据接收端：每次扫描的数据发给插件，如专有历史库
+	pluginDecoder = "decoder" // 解码器：自定义数据类型，在状态表中选择
+)
+
+// pluginDecodeTimeout 解码器插件应答一次解码的超时
+const pluginDecodeTimeout = time.Second
+
+// sinkScan 一次扫描的数据，写入各接收端
+type sinkScan struct {
+	time  time.Time
+	plc   string
+	order string // 字节顺序，展开变量值时使用
+	area  string
+	start int
+	data  []byte
+}
+
+// scanSink 数据接收端：监控时每次扫描的数据写入其中，可在监控协程中调用
+type scanSink interface {
+	write(s sinkScan) error
+	close() error
+}
+
+// pluginManifest 插件目录中的插件描述文件（*.json），插件以子进程运行，通过标准输入输出收发事件和命令（每行一个JSON，见pluginEvent）：
+//
+//	{"name":"historian","kind":"sink","command":"python hist.py"}
+//	{"name":"TIME32","kind":"decoder","command":"node time32.js","width":4}
+//
+// command中的相对路径相对于插件目录。
+type pluginManifest struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Command string `json:"command"`
+	Width   int    `json:"width,omitempty"`
+
+	dir string
+}
+
+// 已载入的插件，程序启动时载入一次
+var (
+	pluginsMu     sync.Mutex
+	loadedSinks   []pluginManifest
+	pluginsErrors []error
+)
+
+// pluginsDir 返回插件目录：用户配置目录下的 plc-binary-viewer/plugins
+func pluginsDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf(tr("无法确定配置目录: %v"), err)
+	}
+	return filepath.Join(dir, "plc-binary-viewer", "plugins"), nil
+}
+
+// loadPlugins 载入插件目录中的插件：解码器启动并注册为自定义数据类型，接收端在插件页中启用时才启动。
+// 目录不存在时没有插件。出错的插件被跳过，错误记录日志并在插件页中显示。
+func loadPlugins() {
+	dir, err := pluginsDir()
+	if err != nil {
+		addPluginError(err)
+		return
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		m, err := readPluginManifest(path)
+		if err == nil {
+			switch m.Kind {
+			case pluginSink:
+				pluginsMu.Lock()
+				loadedSinks = append(loadedSinks, m)
+				pluginsMu.Unlock()
+			case pluginDecoder:
+				err = startDecoderPlugin(m)
+			}
+		}
+		if err != nil {
+			addPluginError(fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		log.Printf(tr("已载入插件 %s（%s）"), m.Name, m.Kind)
+	}
+	// 自定义数据类型加入状态表的类型列表
+	watchTypes = append(watchTypes, s7viewer.DecoderTypes()...)
+}
+
+func addPluginError(err error) {
+	log.Printf(tr("载入插件失败: %v"), err)
+	pluginsMu.Lock()
+	pluginsErrors = append(pluginsErrors, err)
+	pluginsMu.Unlock()
+}
+
+// readPluginManifest 读取并检查插件描述文件
+func readPluginManifest(path string) (pluginManifest, error) {
+	var m pluginManifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf(tr("插件描述无效: %v"), err)
+	}
+	m.Name = strings.TrimSpace(m.Name)
+	m.dir = filepath.Dir(path)
+	switch {
+	case m.Name == "":
+		return m, errors.New(tr("插件缺少名称"))
+	case strings.TrimSpace(m.Command) == "":
+		return m, errors.New(tr("插件缺少命令"))
+	case m.Kind != pluginSink && m.Kind != pluginDecoder:
+		return m, fmt.Errorf(tr("未知的插件种类 %q，应为 sink 或 decoder"), m.Kind)
+	case m.Kind == pluginDecoder && m.Width <= 0:
+		return m, errors.New(tr("解码器插件需要设置宽度（字节数）"))
+	}
+	return m, nil
+}
+
+// sinkNames 返回插件目录中可启用的接收端，按名称排序
+func sinkNames() []string {
+	var names []string
+	pluginsMu.Lock()
+	for _, m := range loadedSinks {
+		names = append(names, m.Name)
+	}
+	pluginsMu.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// openSink 启动名为name的接收端
+func openSink(name string) (scanSink, error) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	for _, m := range loadedSinks {
+		if m.Name == name {
+			return startSinkPlugin(m)
+		}
+	}
+	return nil, fmt.Errorf(tr("没有名为 %s 的接收端"), name)
+}
+
+// processSink 以子进程运行的接收端插件，收到scan事件；处理不过来时丢弃扫描，不阻塞监控
+type processSink struct {
+	name   string
+	host   *pluginHost
+	exited bool // 已报告插件退出，之后的扫描直接丢弃
+}
+
+func startSinkPlugin(m pluginManifest) (scanSink, error) {
+	host, err := startPluginProcess(m.Name, m.Command, m.dir, handlePluginLog(m.Name), func(err error) {
+		log.Printf(tr("插件 %s 已退出: %v"), m.Name, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &processSink{name: m.Name, host: host}, nil
+}
+
+func (s *processSink) write(scan sinkScan) error {
+	select {
+	case <-s.host.exited:
+		if s.exited {
+			return nil
+		}
+		s.exited = true
+		return fmt.Errorf(tr("插件 %s 已退出"), s.name)
+	default:
+	}
+	start := scan.start
+	s.host.send(pluginEvent{Event: "scan", Time: scan.time.Format(time.RFC3339Nano), PLC: scan.plc, Area: scan.area, Start: &start,
+		Hex: hex.EncodeToString(scan.data), Tags: tagValues(scan.order, scan.area, scan.start, scan.data)})
+	return nil
+}
+
+func (s *processSink) close() error {
+	s.host.stop()
+	return nil
+}
+
+// handlePluginLog 插件只能使用log命令，其他命令记录日志后忽略
+func handlePluginLog(name string) func(h *pluginHost, c pluginCommand) {
+	return func(h *pluginHost, c pluginCommand) {
+		if c.Cmd == "log" {
+			log.Printf(tr("插件 %s: %s"), name, c.Text)
+		} else {
+			log.Printf(tr("插件 %s: 不支持的命令 %q"), name, c.Cmd)
+		}
+	}
+}
+
+// processDecoder 以子进程运行的解码器插件：发送 {"event":"decode","id":1,"hex":"0012AB05"}，
+// 插件以 {"cmd":"value","id":1,"value":"..."} 应答
+type processDecoder struct {
+	name  string
+	width int
+	host  *pluginHost
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[string]chan pluginCommand
+}
+
+// startDecoderPlugin 启动解码器插件并注册为自定义数据类型
+func startDecoderPlugin(m pluginManifest) error {
+	d := &processDecoder{name: m.Name, width: m.Width, pending: make(map[string]chan pluginCommand)}
+	host, err := startPluginProcess(m.Name, m.Command, m.dir, func(h *pluginHost, c pluginCommand) {
+		if c.Cmd != "value" {
+			handlePluginLog(m.Name)(h, c)
+			return
+		}
+		d.mu.Lock()
+		ch := d.pending[fmt.Sprint(c.ID)]
+		d.mu.Unlock()
+		if ch != nil {
+			select {
+			case ch <- c:
+			default: // 重复的应答
+			}
+		}
+	}, func(err error) {
+		log.Printf(tr("插件 %s 已退出: %v"), m.Name, err)
+	})
+	if err != nil {
+		return err
+	}
+	d.host = host
+	if err := s7viewer.RegisterDecoder(m.Name, d); err != nil {
+		host.stop()
+		return err
+	}
+	return nil
+}
+
+func (d *processDecoder) Width() int { return d.width }
+
+func (d *processDecoder) Decode(data []byte) (string, error) {
+	d.mu.Lock()
+	d.nextID++
+	id := d.nextID
+	key := strconv.Itoa(id)
+	ch := make(chan pluginCommand, 1)
+	d.pending[key] = ch
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.pending, key)
+		d.mu.Unlock()
+	}()
+
+	d.host.send(pluginEvent{Event: "decode", ID: id, Hex: hex.EncodeToString(data)})
+	select {
+	case c := <-ch:
+		if c.Error != "" {
+			return "", errors.New(c.Error)
+		}
+		return c.Value, nil
+	case <-d.host.exited:
+		return "", fmt.Errorf(tr("插件 %s 已退出"), d.name)
+	case <-time.After(pluginDecodeTimeout):
+		return "", fmt.Errorf(tr("插件 %s 应答超时"), d.name)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pluginQueueSize 等待发送给插件的事件数上限，插件处理不过来时丢弃扫描事件，不阻塞监控
+const pluginQueueSize = 64
+
+// pluginStopTimeout 停止插件时关闭输入后等待其自行退出的时间，超时后强制结束
+const pluginStopTimeout = 2 * time.Second
+
+// pluginEvent 发送给插件的事件，每行一个JSON：
+//
+//	scan    接收端每次扫描：area、start、hex（本次读取的原始字节）和tags（读取范围内各变量的值）
+//	decode  解码器的解码请求：id和hex
+type pluginEvent struct {
+	Event string            `json:"event"`
+	Time  string            `json:"time"`
+	PLC   string            `json:"plc,omitempty"`
+	Area  string            `json:"area,omitempty"`
+	Start *int              `json:"start,omitempty"`
+	Hex   string            `json:"hex,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	ID    any               `json:"id,omitempty"`
+}
+
+// pluginCommand 插件输出的命令，每行一个JSON：
+//
+//	{"cmd":"log","text":"..."}                  写入日志
+//	{"cmd":"value","id":1,"value":"..."}        解码器对decode的应答，失败时带error
+//
+// 不是JSON的输出行按普通输出写入日志，便于调试。
+type pluginCommand struct {
+	Cmd   string `json:"cmd"`
+	ID    any    `json:"id"`
+	Value string `json:"value"`
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+// pluginHost 以子进程运行的插件，可用任意解释器编写（python、node等），
+// 通过标准输入接收事件、标准输出发出命令，标准错误写入日志。
+type pluginHost struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	events chan pluginEvent
+	exited chan struct{}
+
+	mu      sync.Mutex
+	dropped int // 队列满时丢弃的事件数，恢复后记录日志
+}
+
+// startPluginProcess 启动插件，command为解释器及参数，以空格分隔，如 "python hist.py"，dir为工作目录（为空时不变）。
+// handle在读取输出的协程中依次处理每条命令，onExit在插件退出时调用。
+func startPluginProcess(name, command, dir string, handle func(h *pluginHost, c pluginCommand), onExit func(err error)) (*pluginHost, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf(tr("插件 %s 没有填写命令"), name)
+	}
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Dir = dir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf(tr("启动插件 %s 失败: %v"), name, err)
+	}
+	h := &pluginHost{name: name, cmd: cmd, stdin: stdin, events: make(chan pluginEvent, pluginQueueSize), exited: make(chan struct{})}
+
+	go h.writeEvents()
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf(tr("插件 %s: %s"), name, scanner.Text())
+		}
+	}()
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			var c pluginCommand
+			if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &c) != nil || c.Cmd == "" {
+				if line != "" {
+					log.Printf(tr("插件 %s: %s"), name, line)
+				}
+				continue
+			}
+			handle(h, c)
+		}
+		// 输出读完后才能Wait，否则可能丢失标准错误的最后几行
+		<-stderrDone
+		err := cmd.Wait()
+		close(h.exited)
+		onExit(err)
+	}()
+	return h, nil
+}
+
+// writeEvents 将队列中的事件依次写入插件的标准输入，插件退出后停止
+func (h *pluginHost) writeEvents() {
+	enc := json.NewEncoder(h.stdin)
+	for {
+		select {
+		case <-h.exited:
+			return
+		case e := <-h.events:
+			if err := enc.Encode(e); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// send 将事件放入发送队列。队列满时丢弃扫描事件，不阻塞；解码请求等待插件读取。
+func (h *pluginHost) send(e pluginEvent) {
+	if e.Time == "" {
+		e.Time = time.Now().Format(time.RFC3339Nano)
+	}
+	if e.Event == "decode" {
+		select {
+		case h.events <- e:
+		case <-h.exited:
+		}
+		return
+	}
+	select {
+	case h.events <- e:
+		h.mu.Lock()
+		if h.dropped > 0 {
+			log.Printf(tr("插件 %s 处理过慢，丢弃了%d个事件"), h.name, h.dropped)
+			h.dropped = 0
+		}
+		h.mu.Unlock()
+	default:
+		h.mu.Lock()
+		h.dropped++
+		h.mu.Unlock()
+	}
+}
+
+// stop 关闭插件的标准输入，插件应在读到结束时退出，超时后强制结束
+func (h *pluginHost) stop() {
+	h.stdin.Close()
+	select {
+	case <-h.exited:
+	case <-time.After(pluginStopTimeout):
+		h.cmd.Process.Kill()
+		<-h.exited
+	}
+}
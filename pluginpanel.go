@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// newPluginPanel 创建插件页：显示插件目录和载入结果，勾选接收端后监控数据写入其中。
+// current返回已启用的接收端，可在监控协程中调用。
+func newPluginPanel() (content fyne.CanvasObject, current func() []scanSink) {
+	var mu sync.Mutex
+	active := make(map[string]scanSink)
+	current = func() []scanSink {
+		mu.Lock()
+		defer mu.Unlock()
+		sinks := make([]scanSink, 0, len(active))
+		for _, s := range active {
+			sinks = append(sinks, s)
+		}
+		return sinks
+	}
+
+	dir, err := pluginsDir()
+	if err != nil {
+		dir = err.Error()
+	}
+	statusLabel := widget.NewLabel("")
+	sinkBox := container.NewVBox()
+	for _, name := range sinkNames() {
+		sinkBox.Add(widget.NewCheck(name, func(enabled bool) {
+			mu.Lock()
+			old := active[name]
+			delete(active, name)
+			mu.Unlock()
+			if old != nil {
+				go old.close()
+			}
+			if !enabled {
+				statusLabel.SetText(fmt.Sprintf(tr("已停用接收端 %s"), name))
+				return
+			}
+			s, err := openSink(name)
+			if err != nil {
+				log.Printf(tr("启用接收端 %s 失败: %v"), name, err)
+				statusLabel.SetText(fmt.Sprintf(tr("启用接收端 %s 失败: %v"), name, err))
+				return
+			}
+			mu.Lock()
+			active[name] = s
+			mu.Unlock()
+			statusLabel.SetText(fmt.Sprintf(tr("已启用接收端 %s，监控时写入"), name))
+		}))
+	}
+	if len(sinkBox.Objects) == 0 {
+		sinkBox.Add(widget.NewLabel(tr("没有接收端插件")))
+	}
+
+	decoders := s7viewer.DecoderTypes()
+	decoderText := tr("没有解码器插件")
+	if len(decoders) > 0 {
+		decoderText = strings.Join(decoders, ", ")
+	}
+	pluginsMu.Lock()
+	var errorLines []string
+	for _, err := range pluginsErrors {
+		errorLines = append(errorLines, err.Error())
+	}
+	pluginsMu.Unlock()
+
+	content = container.NewVBox(
+		widget.NewLabel(tr("插件目录: ")+dir),
+		widget.NewLabel(tr("每个插件为目录中的一个描述文件（*.json），如 {\"name\":\"historian\",\"kind\":\"sink\",\"command\":\"python hist.py\"} 或 "+
+			"{\"name\":\"TIME32\",\"kind\":\"decoder\",\"command\":\"node time32.js\",\"width\":4}。插件以子进程运行，每行一个JSON：\n"+
+			"接收端收到 {\"event\":\"scan\",\"area\":\"V\",\"start\":0,\"hex\":...,\"tags\":{...}}；"+
+			"解码器收到 {\"event\":\"decode\",\"id\":1,\"hex\":\"0012AB05\"}，以 {\"cmd\":\"value\",\"id\":1,\"value\":\"...\"} 应答，"+
+			"载入后可在状态表中选择该数据类型。插件在程序启动时载入。")),
+		widget.NewLabelWithStyle(tr("接收端"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		sinkBox,
+		statusLabel,
+		widget.NewLabelWithStyle(tr("解码器"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel(decoderText),
+	)
+	if len(errorLines) > 0 {
+		errLabel := widget.NewLabel(strings.Join(errorLines, "\n"))
+		errLabel.Importance = widget.DangerImportance
+		content.(*fyne.Container).Add(errLabel)
+	}
+	return content, current
+}
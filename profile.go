@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// profile 保存的PLC连接配置，按名称（如产线名）区分
+type profile struct {
+	Name      string `json:"name"`
+	IP        string `json:"ip"`
+	Rack      int    `json:"rack"`
+	Slot      int    `json:"slot"`
+	Port      int    `json:"port,omitempty"`      // 为0时使用102
+	TimeoutMs int    `json:"timeoutMs,omitempty"` // 为0时使用默认超时
+	// Retries 读写超时或连接断开后的重试次数，RetryDelayMs 每次重试前的等待时间
+	Retries      int    `json:"retries,omitempty"`
+	RetryDelayMs int    `json:"retryDelayMs,omitempty"`
+	Area         string `json:"area"`
+	Address      string `json:"address"`
+	Length       int    `json:"length"`
+	ScanMs       int    `json:"scanMs"`
+	// Labels 位地址（如 V100.3）对应的标签，显示在悬停提示、结构化视图和报警信息中
+	Labels map[string]string `json:"labels,omitempty"`
+	// Colors 位网格配色，载入配置时应用，为nil时不改变当前配色
+	Colors *profileColors `json:"colors,omitempty"`
+}
+
+// profilesPath 返回配置文件路径：用户配置目录下的 plc-binary-viewer/profiles.json
+func profilesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf(tr("无法确定配置目录: %v"), err)
+	}
+	return filepath.Join(dir, "plc-binary-viewer", "profiles.json"), nil
+}
+
+// loadProfiles 读取配置文件，文件不存在时返回空列表
+func loadProfiles(path string) ([]profile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(tr("读取配置文件失败: %v"), err)
+	}
+	var profiles []profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf(tr("解析配置文件 %s 失败: %v"), path, err)
+	}
+	return profiles, nil
+}
+
+// saveProfiles 按名称排序后写入配置文件，先写临时文件再替换，避免写到一半损坏原文件
+func saveProfiles(path string, profiles []profile) error {
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf(tr("创建配置目录失败: %v"), err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf(tr("保存配置文件失败: %v"), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf(tr("保存配置文件失败: %v"), err)
+	}
+	return nil
+}
+
+// upsertProfile 添加配置，同名配置被替换
+func upsertProfile(profiles []profile, p profile) []profile {
+	for i := range profiles {
+		if profiles[i].Name == p.Name {
+			profiles[i] = p
+			return profiles
+		}
+	}
+	return append(profiles, p)
+}
+
+// removeProfile 删除指定名称的配置
+func removeProfile(profiles []profile, name string) []profile {
+	var out []profile
+	for _, p := range profiles {
+		if p.Name != name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// findProfile 按名称查找配置
+func findProfile(profiles []profile, name string) (profile, bool) {
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return profile{}, false
+}
+
+// profileNames 返回所有配置名称，用于下拉框
+func profileNames(profiles []profile) []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return names
+}
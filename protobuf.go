@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// 精简的protobuf编解码，只支持gRPC接口用到的字段类型，避免引入protobuf库
+
+// protobuf线路类型
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+var errProtobuf = errors.New("protobuf消息格式错误")
+
+func pbAppendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// pbAppendVarint 追加varint字段，值为0时省略（proto3默认值）
+func pbAppendVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.AppendUvarint(pbAppendTag(b, field, pbVarint), v)
+}
+
+// pbAppendInt 追加int64字段，负数按补码编码
+func pbAppendInt(b []byte, field int, v int64) []byte {
+	return pbAppendVarint(b, field, uint64(v))
+}
+
+// pbAppendBytes 追加bytes、string或嵌套消息字段，空值时省略
+func pbAppendBytes(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(pbAppendTag(b, field, pbBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func pbAppendString(b []byte, field int, v string) []byte {
+	return pbAppendBytes(b, field, []byte(v))
+}
+
+// pbField 解码得到的一个字段：varint和定长字段的值在num中，长度分隔字段的内容在bytes中
+type pbField struct {
+	num   int
+	wire  int
+	value uint64
+	bytes []byte
+}
+
+// pbFields 解码消息的所有字段，未知字段由调用方忽略
+func pbFields(b []byte) ([]pbField, error) {
+	var fields []pbField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errProtobuf
+		}
+		b = b[n:]
+		f := pbField{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case pbVarint:
+			if f.value, n = binary.Uvarint(b); n <= 0 {
+				return nil, errProtobuf
+			}
+			b = b[n:]
+		case pbFixed64:
+			if len(b) < 8 {
+				return nil, errProtobuf
+			}
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case pbFixed32:
+			if len(b) < 4 {
+				return nil, errProtobuf
+			}
+			f.value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case pbBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || size > uint64(len(b)-n) {
+				return nil, errProtobuf
+			}
+			f.bytes, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			return nil, errProtobuf
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
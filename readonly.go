@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 解锁口令的派生参数：PBKDF2-SHA256，每次加锁生成新的随机盐
+const (
+	readOnlyKDFIterations = 600000
+	readOnlySaltSize      = 16
+	readOnlyKeySize       = 32
+)
+
+// readOnlyState 保存的只读模式设置，界面和命令行（子命令、无界面模式及其HTTP/gRPC接口）共用
+type readOnlyState struct {
+	On   bool   `json:"on"`
+	Salt string `json:"salt,omitempty"` // 解锁口令的盐（十六进制），为空表示未加锁
+	Hash string `json:"hash,omitempty"` // 由口令和盐派生的密钥（十六进制）
+}
+
+// readOnlyStatePath 返回只读模式设置文件：用户配置目录下的 plc-binary-viewer/readonly.json
+func readOnlyStatePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf(tr("无法确定配置目录: %v"), err)
+	}
+	return filepath.Join(dir, "plc-binary-viewer", "readonly.json"), nil
+}
+
+// loadReadOnlyState 读取只读模式设置，文件不存在时返回未开启
+func loadReadOnlyState(path string) (readOnlyState, error) {
+	var s readOnlyState
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf(tr("读取只读模式设置失败: %v"), err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf(tr("解析只读模式设置 %s 失败: %v"), path, err)
+	}
+	return s, nil
+}
+
+// saveReadOnlyState 写入只读模式设置，先写临时文件再替换
+func saveReadOnlyState(path string, s readOnlyState) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf(tr("创建配置目录失败: %v"), err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf(tr("保存只读模式设置失败: %v"), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf(tr("保存只读模式设置失败: %v"), err)
+	}
+	return nil
+}
+
+// persistedReadOnly 返回保存的设置是否开启了只读模式。设置文件无法读取时按已开启处理，宁可拒绝写入
+func persistedReadOnly() bool {
+	path, err := readOnlyStatePath()
+	if err == nil {
+		var s readOnlyState
+		if s, err = loadReadOnlyState(path); err == nil {
+			return s.On
+		}
+	}
+	log.Printf(tr("%v，按只读模式运行"), err)
+	return true
+}
+
+// lock 设置解锁口令，password为空时不加锁
+func (s *readOnlyState) lock(password string) error {
+	s.Salt, s.Hash = "", ""
+	if password == "" {
+		return nil
+	}
+	salt := make([]byte, readOnlySaltSize)
+	rand.Read(salt)
+	key, err := pbkdf2.Key(sha256.New, password, salt, readOnlyKDFIterations, readOnlyKeySize)
+	if err != nil {
+		return err
+	}
+	s.Salt, s.Hash = hex.EncodeToString(salt), hex.EncodeToString(key)
+	return nil
+}
+
+// locked 返回是否设置了解锁口令
+func (s readOnlyState) locked() bool {
+	return s.Hash != ""
+}
+
+// unlocks 返回password是否为解锁口令，未加锁时总是返回true
+func (s readOnlyState) unlocks(password string) bool {
+	if !s.locked() {
+		return true
+	}
+	salt, err := hex.DecodeString(s.Salt)
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(s.Hash)
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, readOnlyKDFIterations, readOnlyKeySize)
+	return err == nil && subtle.ConstantTimeCompare(key, want) == 1
+}
+
+// 只读模式的全局状态，只在UI线程中访问。
+// 开启后界面上的写入、强制、RUN/STOP和时钟同步被禁用，各Viewer也拒绝写入，可以把工具交给操作员使用
+var (
+	readOnlyMode      bool
+	readOnlyForced    bool         // 由-readonly参数开启，本次运行不能关闭
+	readOnlyListeners []func(bool) // 切换时调用，重新创建界面时清空
+)
+
+// isReadOnly 返回是否处于只读模式
+func isReadOnly() bool {
+	return readOnlyMode
+}
+
+// setReadOnly 切换只读模式并通知各标签页
+func setReadOnly(on bool) {
+	if on == readOnlyMode {
+		return
+	}
+	readOnlyMode = on
+	if on {
+		log.Println(tr("已开启只读模式"))
+	} else {
+		log.Println(tr("已关闭只读模式"))
+	}
+	for _, fn := range readOnlyListeners {
+		fn(on)
+	}
+}
+
+// onReadOnlyChange 注册只读模式切换时的回调，注册时按当前状态调用一次
+func onReadOnlyChange(fn func(bool)) {
+	readOnlyListeners = append(readOnlyListeners, fn)
+	fn(readOnlyMode)
+}
+
+// disableInReadOnly 只读模式下禁用objs
+func disableInReadOnly(objs ...fyne.Disableable) {
+	onReadOnlyChange(func(on bool) {
+		for _, o := range objs {
+			if on {
+				o.Disable()
+			} else {
+				o.Enable()
+			}
+		}
+	})
+}
+
+// newReadOnlyBanner 创建只读模式的提示，不在只读模式时隐藏
+func newReadOnlyBanner() fyne.CanvasObject {
+	banner := widget.NewLabelWithStyle(tr("只读模式：写入、强制、RUN/STOP和时钟同步已禁用"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	banner.Importance = widget.WarningImportance
+	onReadOnlyChange(func(on bool) {
+		if on {
+			banner.Show()
+		} else {
+			banner.Hide()
+		}
+	})
+	return banner
+}
+
+// toggleReadOnly 菜单中切换只读模式：开启时可设置解锁口令，关闭时须输入该口令。
+// 设置保存到readOnlyStatePath，命令行的子命令和无界面模式同样遵守
+func toggleReadOnly(win fyne.Window) {
+	path, err := readOnlyStatePath()
+	if err != nil {
+		dialog.ShowError(err, win)
+		return
+	}
+	if !readOnlyMode {
+		password := widget.NewPasswordEntry()
+		password.SetPlaceHolder(tr("留空表示不加锁"))
+		dialog.ShowForm(tr("开启只读模式"), tr("开启"), tr("取消"), []*widget.FormItem{
+			widget.NewFormItem(tr("解锁口令:"), password),
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			state := readOnlyState{On: true}
+			if err := state.lock(password.Text); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if err := saveReadOnlyState(path, state); err != nil {
+				dialog.ShowError(err, win)
+			}
+			setReadOnly(true)
+		}, win)
+		return
+	}
+
+	if readOnlyForced {
+		dialog.ShowInformation(tr("只读模式"), tr("只读模式由-readonly参数开启，本次运行不能关闭。"), win)
+		return
+	}
+	state, err := loadReadOnlyState(path)
+	if err != nil {
+		dialog.ShowError(err, win)
+		return
+	}
+	unlock := func() {
+		if err := saveReadOnlyState(path, readOnlyState{}); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		setReadOnly(false)
+	}
+	if !state.locked() {
+		unlock()
+		return
+	}
+	password := widget.NewPasswordEntry()
+	dialog.ShowForm(tr("关闭只读模式"), tr("解锁"), tr("取消"), []*widget.FormItem{
+		widget.NewFormItem(tr("解锁口令:"), password),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		if !state.unlocks(password.Text) {
+			dialog.ShowError(errors.New(tr("口令不正确")), win)
+			return
+		}
+		unlock()
+	}, win)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadOnlyStateLock(t *testing.T) {
+	var a, b readOnlyState
+	if err := a.lock("secret"); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if err := b.lock("secret"); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if !a.locked() || a.Salt == b.Salt || a.Hash == b.Hash {
+		t.Errorf("same password locked twice: %+v and %+v, want different salts and keys", a, b)
+	}
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"secret", true},
+		{"Secret", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := a.unlocks(tt.password); got != tt.want {
+			t.Errorf("unlocks(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	var open readOnlyState
+	if err := open.lock(""); err != nil || open.locked() || !open.unlocks("anything") {
+		t.Errorf("lock(\"\") = %+v, %v; want unlocked", open, err)
+	}
+}
+
+func TestReadOnlyStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plc-binary-viewer", "readonly.json")
+	if s, err := loadReadOnlyState(path); err != nil || s.On {
+		t.Fatalf("loadReadOnlyState(missing) = %+v, %v; want off", s, err)
+	}
+
+	saved := readOnlyState{On: true}
+	if err := saved.lock("1234"); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if err := saveReadOnlyState(path, saved); err != nil {
+		t.Fatalf("saveReadOnlyState: %v", err)
+	}
+	loaded, err := loadReadOnlyState(path)
+	if err != nil || loaded != saved || !loaded.unlocks("1234") {
+		t.Fatalf("loadReadOnlyState = %+v, %v; want %+v", loaded, err, saved)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadReadOnlyState(path); err == nil {
+		t.Error("loadReadOnlyState(corrupt) error = nil")
+	}
+}
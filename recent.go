@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// prefRecentPLCs 最近成功连接的PLC，每项为“IP|连接时间(RFC3339)”，新的在前
+const prefRecentPLCs = "plc.recent"
+
+// maxRecentPLCs 最多记住的最近连接数
+const maxRecentPLCs = 10
+
+// recentPLC 一次成功的连接
+type recentPLC struct {
+	IP   string
+	Time time.Time
+}
+
+// loadRecentPLCs 读取最近连接的PLC，新的在前
+func loadRecentPLCs(prefs fyne.Preferences) []recentPLC {
+	var recent []recentPLC
+	for _, item := range prefs.StringList(prefRecentPLCs) {
+		ip, stamp, _ := strings.Cut(item, "|")
+		if ip == "" {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, stamp)
+		recent = append(recent, recentPLC{IP: ip, Time: t})
+	}
+	return recent
+}
+
+// addRecentPLC 记录一次成功的连接：移到最前面，超出maxRecentPLCs的旧记录丢弃
+func addRecentPLC(prefs fyne.Preferences, ip string) {
+	list := []string{ip + "|" + time.Now().Format(time.RFC3339)}
+	for _, r := range loadRecentPLCs(prefs) {
+		if r.IP != ip && len(list) < maxRecentPLCs {
+			list = append(list, r.IP+"|"+r.Time.Format(time.RFC3339))
+		}
+	}
+	prefs.SetStringList(prefRecentPLCs, list)
+}
+
+// newRecentPLCButton 创建IP输入框右侧的下拉按钮，点击后列出最近连接的PLC及连接时间，选中后填入entry
+func newRecentPLCButton(prefs fyne.Preferences, entry *widget.Entry) *widget.Button {
+	button := widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), func() {
+		recent := loadRecentPLCs(prefs)
+		var items []*fyne.MenuItem
+		for _, r := range recent {
+			label := r.IP
+			if !r.Time.IsZero() {
+				label += "    " + r.Time.Format("2006-01-02 15:04")
+			}
+			items = append(items, fyne.NewMenuItem(label, func() { entry.SetText(r.IP) }))
+		}
+		if len(items) == 0 {
+			none := fyne.NewMenuItem(tr("没有最近的连接"), nil)
+			none.Disabled = true
+			items = append(items, none)
+		}
+		c := fyne.CurrentApp().Driver().CanvasForObject(entry)
+		if c == nil {
+			return
+		}
+		widget.ShowPopUpMenuAtRelativePosition(fyne.NewMenu("", items...), c, fyne.NewPos(0, entry.Size().Height), entry)
+	})
+	button.Importance = widget.LowImportance
+	return button
+}
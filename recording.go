@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordingMagic 录制文件的文件头标识
+const recordingMagic = "PLCREC1\n"
+
+// 帧类型：完整数据、与上一帧相同、只记录变化的字节
+const (
+	frameFull      = 0
+	frameUnchanged = 1
+	framePatch     = 2
+)
+
+// recordingHeader 录制文件头（JSON一行），记录监控的PLC和范围
+type recordingHeader struct {
+	PLC   string    `json:"plc"`
+	Area  string    `json:"area"`
+	Start int       `json:"start"`
+	Time  time.Time `json:"time"` // 第一帧之前的基准时间
+}
+
+// recordTrigger 条件录制的触发条件：位出现指定边沿时触发，保存触发前pre到最后一次触发后post的扫描
+type recordTrigger struct {
+	bit    s7Address
+	rising bool // true为上升沿（变为1），false为下降沿
+	pre    time.Duration
+	post   time.Duration
+}
+
+// bufferedFrame 条件录制等待触发时缓存在内存中的一次扫描
+type bufferedFrame struct {
+	t     time.Time
+	plc   string
+	area  string
+	start int
+	data  []byte
+}
+
+// recorder 将每次扫描写入紧凑的二进制录制文件。每帧为：距上一帧的毫秒数（uvarint）、帧类型，
+// 完整帧再跟长度和数据，变化帧跟变化字节数和每个字节的（偏移增量、值）。
+// 文件在第一帧时创建，名称带开始时间：night.rec 实际写入 night_20060102_150405.rec；
+// 监控范围改变时换新文件。可在监控协程中调用。
+//
+// 设置了trigger时像示波器一样条件录制：平时只在内存环形缓冲中保留最近pre的扫描，
+// 触发后先写出缓冲的扫描，再继续录制到最后一次触发后post，然后关闭文件，下次触发写入新文件。
+type recorder struct {
+	mu       sync.Mutex
+	basePath string
+	path     string
+	header   recordingHeader
+	file     *os.File
+	w        *bufio.Writer
+	last     time.Time
+	prev     []byte
+	frames   int
+
+	trigger  *recordTrigger
+	ring     []bufferedFrame
+	lastBit  int       // 触发位上一次的值，-1为未知
+	until    time.Time // 触发录制的结束时间，零值表示等待触发
+	captures int       // 已触发的次数
+	// onStatus 条件录制触发和保存时调用，在监控协程中调用，持有锁，不能再调用recorder的方法
+	onStatus func(text string)
+}
+
+func newRecorder(basePath string) *recorder {
+	return &recorder{basePath: basePath}
+}
+
+// newTriggeredRecorder 创建条件录制的录制器
+func newTriggeredRecorder(basePath string, trigger recordTrigger, onStatus func(text string)) *recorder {
+	return &recorder{basePath: basePath, trigger: &trigger, lastBit: -1, onStatus: onStatus}
+}
+
+// openLocked 创建录制文件并写入文件头
+func (r *recorder) openLocked(header recordingHeader) error {
+	ext := filepath.Ext(r.basePath)
+	path := strings.TrimSuffix(r.basePath, ext) + "_" + header.Time.Format("20060102_150405") + ext
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf(tr("创建录制文件失败: %v"), err)
+	}
+	w := bufio.NewWriter(f)
+	meta, _ := json.Marshal(header)
+	w.WriteString(recordingMagic)
+	w.Write(meta)
+	w.WriteByte('\n')
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf(tr("写入录制文件失败: %v"), err)
+	}
+	r.file, r.w, r.header, r.last, r.prev, r.path = f, w, header, header.Time, nil, path
+	return nil
+}
+
+// record 追加一帧，plc、area和start与当前文件不同时换新文件；条件录制时按触发条件缓存或写入
+func (r *recorder) record(t time.Time, plc, area string, start int, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.trigger == nil {
+		return r.writeLocked(t, plc, area, start, data)
+	}
+
+	if r.fired(area, start, data) {
+		if r.until.IsZero() {
+			r.captures++
+			r.status(fmt.Sprintf(tr("录制: 第%d次触发于 %s"), r.captures, t.Format("15:04:05.000")))
+		}
+		r.until = t.Add(r.trigger.post)
+	}
+	if r.until.IsZero() {
+		// 等待触发：缓存本次扫描，丢弃早于pre的
+		r.ring = append(r.ring, bufferedFrame{t, plc, area, start, append([]byte(nil), data...)})
+		n := 0
+		for n < len(r.ring)-1 && t.Sub(r.ring[n].t) > r.trigger.pre {
+			n++
+		}
+		r.ring = r.ring[n:]
+		return nil
+	}
+
+	for _, f := range r.ring {
+		if err := r.writeLocked(f.t, f.plc, f.area, f.start, f.data); err != nil {
+			return err
+		}
+	}
+	r.ring = nil
+	if err := r.writeLocked(t, plc, area, start, data); err != nil {
+		return err
+	}
+	if !t.Before(r.until) {
+		path := r.path
+		r.until = time.Time{}
+		if err := r.closeLocked(); err != nil {
+			return err
+		}
+		r.status(fmt.Sprintf(tr("录制: 已保存第%d次触发到 %s，等待下次触发"), r.captures, filepath.Base(path)))
+	}
+	return nil
+}
+
+// fired 触发位是否出现了指定的边沿。本次扫描不包含触发位时不触发。
+func (r *recorder) fired(area string, start int, data []byte) bool {
+	b := r.trigger.bit
+	off := b.byteOff - start
+	if b.area != area || off < 0 || off >= len(data) {
+		return false
+	}
+	v := int(data[off]>>b.bit) & 1
+	prev := r.lastBit
+	r.lastBit = v
+	if r.trigger.rising {
+		return prev == 0 && v == 1
+	}
+	return prev == 1 && v == 0
+}
+
+// status 记录条件录制的状态并通知界面
+func (r *recorder) status(text string) {
+	log.Print(text)
+	if r.onStatus != nil {
+		r.onStatus(text)
+	}
+}
+
+// writeLocked 写入一帧
+func (r *recorder) writeLocked(t time.Time, plc, area string, start int, data []byte) error {
+	if r.file == nil || r.header.PLC != plc || r.header.Area != area || r.header.Start != start {
+		if err := r.closeLocked(); err != nil {
+			return err
+		}
+		if err := r.openLocked(recordingHeader{PLC: plc, Area: area, Start: start, Time: t}); err != nil {
+			return err
+		}
+	}
+
+	// 时间按毫秒累加，避免舍入误差随帧数累积
+	delta := max(0, t.Sub(r.last).Milliseconds())
+	buf := binary.AppendUvarint(nil, uint64(delta))
+	switch {
+	case r.prev == nil || len(r.prev) != len(data):
+		buf = append(buf, frameFull)
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		buf = append(buf, data...)
+	default:
+		var patch []byte
+		changed, lastOff := 0, 0
+		for i := range data {
+			if data[i] != r.prev[i] {
+				patch = binary.AppendUvarint(patch, uint64(i-lastOff))
+				patch = append(patch, data[i])
+				changed++
+				lastOff = i
+			}
+		}
+		if changed == 0 {
+			buf = append(buf, frameUnchanged)
+		} else {
+			buf = append(buf, framePatch)
+			buf = binary.AppendUvarint(buf, uint64(changed))
+			buf = append(buf, patch...)
+		}
+	}
+	if _, err := r.w.Write(buf); err != nil {
+		return fmt.Errorf(tr("写入录制文件失败: %v"), err)
+	}
+	r.last = r.last.Add(time.Duration(delta) * time.Millisecond)
+	r.prev = append(r.prev[:0], data...)
+	r.frames++
+	return nil
+}
+
+// flush 将缓冲的帧写入磁盘
+func (r *recorder) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.w.Flush()
+}
+
+// close 写出缓冲并关闭文件，返回录制的总帧数
+func (r *recorder) close() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.frames, r.closeLocked()
+}
+
+func (r *recorder) closeLocked() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.w.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	return err
+}
+
+// recordedFrame 回放用的一帧
+type recordedFrame struct {
+	Time time.Time
+	Data []byte
+}
+
+// recording 载入内存的录制文件
+type recording struct {
+	Header recordingHeader
+	Frames []recordedFrame
+}
+
+// loadRecording 读取录制文件。文件末尾不完整的帧（如录制中断）被忽略。
+func loadRecording(path string) (*recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf(tr("打开录制文件失败: %v"), err)
+	}
+	defer f.Close()
+	return readRecording(bufio.NewReader(f))
+}
+
+func readRecording(r *bufio.Reader) (*recording, error) {
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != recordingMagic {
+		return nil, errors.New(tr("不是录制文件"))
+	}
+	meta, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, errors.New(tr("录制文件头不完整"))
+	}
+	rec := &recording{}
+	if err := json.Unmarshal(meta, &rec.Header); err != nil {
+		return nil, fmt.Errorf(tr("录制文件头无效: %v"), err)
+	}
+
+	t := rec.Header.Time
+	var prev []byte
+	for {
+		frame, err := readFrame(r, prev)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, fmt.Errorf(tr("第%d帧无效: %v"), len(rec.Frames)+1, err)
+		}
+		t = t.Add(time.Duration(frame.delta) * time.Millisecond)
+		rec.Frames = append(rec.Frames, recordedFrame{Time: t, Data: frame.data})
+		prev = frame.data
+	}
+	if len(rec.Frames) == 0 {
+		return nil, errors.New(tr("录制文件中没有数据"))
+	}
+	return rec, nil
+}
+
+type rawFrame struct {
+	delta uint64
+	data  []byte
+}
+
+// readFrame 读取一帧，变化帧在prev的副本上应用
+func readFrame(r *bufio.Reader, prev []byte) (rawFrame, error) {
+	var f rawFrame
+	var err error
+	if f.delta, err = binary.ReadUvarint(r); err != nil {
+		return f, err
+	}
+	kind, err := r.ReadByte()
+	if err != nil {
+		return f, io.ErrUnexpectedEOF
+	}
+	switch kind {
+	case frameFull:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return f, io.ErrUnexpectedEOF
+		}
+		if n > 1<<20 {
+			return f, fmt.Errorf(tr("帧长度%d过大"), n)
+		}
+		f.data = make([]byte, n)
+		if _, err := io.ReadFull(r, f.data); err != nil {
+			return f, io.ErrUnexpectedEOF
+		}
+	case frameUnchanged, framePatch:
+		if prev == nil {
+			return f, errors.New(tr("缺少完整帧"))
+		}
+		f.data = prev
+		if kind == frameUnchanged {
+			return f, nil
+		}
+		f.data = append([]byte(nil), prev...)
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return f, io.ErrUnexpectedEOF
+		}
+		off := 0
+		for range n {
+			d, err := binary.ReadUvarint(r)
+			if err != nil {
+				return f, io.ErrUnexpectedEOF
+			}
+			v, err := r.ReadByte()
+			if err != nil {
+				return f, io.ErrUnexpectedEOF
+			}
+			off += int(d)
+			if off >= len(f.data) {
+				return f, fmt.Errorf(tr("变化偏移%d超出帧长度"), off)
+			}
+			f.data[off] = v
+		}
+	default:
+		return f, fmt.Errorf(tr("未知的帧类型 %d"), kind)
+	}
+	return f, nil
+}
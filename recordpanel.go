@@ -0,0 +1,357 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 录制设置的偏好键
+const (
+	prefRecordPath = "record.path"
+	prefVCDBits    = "record.vcdBits" // 导出VCD的位，为空时导出所有位
+
+	prefRecordTrigger     = "record.trigger" // 条件录制
+	prefRecordTriggerBit  = "record.triggerBit"
+	prefRecordTriggerEdge = "record.triggerEdge"
+	prefRecordPre         = "record.preSeconds"
+	prefRecordPost        = "record.postSeconds"
+)
+
+// 条件录制的触发边沿
+const (
+	triggerRising  = "上升沿"
+	triggerFalling = "下降沿"
+)
+
+var triggerEdges = []string{triggerRising, triggerFalling}
+
+// maxTriggerSeconds 触发前后可保存的最长时间，触发前的扫描缓存在内存中
+const maxTriggerSeconds = 600
+
+// 回放速度
+var replaySpeeds = map[string]float64{"1x": 1, "2x": 2, "5x": 5, "10x": 10, "60x": 60}
+
+var replaySpeedNames = []string{"1x", "2x", "5x", "10x", "60x"}
+
+// replayTick 回放时推进时间的间隔
+const replayTick = 50 * time.Millisecond
+
+// newRecordPanel 创建录制与回放面板。current返回启用中的录制器，未启用时为nil，可在监控协程中调用；
+// open载入录制文件并显示第一帧，无需连接PLC。
+// 回放在UI线程中对每一帧调用show，show返回false（如正在监控）时回放暂停。
+// labelOf返回位地址的标签，导出VCD时作为信号名的一部分。
+func newRecordPanel(prefs fyne.Preferences, win fyne.Window, labelOf func(addr string) string, show func(t time.Time, h recordingHeader, data []byte) bool) (content fyne.CanvasObject, current func() *recorder, open func(path string)) {
+	var mu sync.Mutex
+	var rec *recorder
+	current = func() *recorder {
+		mu.Lock()
+		defer mu.Unlock()
+		return rec
+	}
+
+	defaultPath := "plc-scan.rec"
+	if home, err := os.UserHomeDir(); err == nil {
+		defaultPath = filepath.Join(home, "plc-scan.rec")
+	}
+	pathEntry := widget.NewEntry()
+	pathEntry.SetText(prefs.StringWithFallback(prefRecordPath, defaultPath))
+	recordStatus := widget.NewLabel(tr("录制: 未启用"))
+
+	// 条件录制：触发位出现指定边沿时保存触发前后的扫描
+	triggerCheck := widget.NewCheck(tr("条件触发"), func(on bool) { prefs.SetBool(prefRecordTrigger, on) })
+	triggerCheck.SetChecked(prefs.Bool(prefRecordTrigger))
+	triggerBitEntry := widget.NewEntry()
+	triggerBitEntry.SetPlaceHolder(tr("如 V50.0"))
+	triggerBitEntry.SetText(prefs.String(prefRecordTriggerBit))
+	triggerEdgeSelect := newTrSelect(triggerEdges, func(edge string) { prefs.SetString(prefRecordTriggerEdge, edge) })
+	triggerEdgeSelect.SetSelected(tr(prefs.StringWithFallback(prefRecordTriggerEdge, triggerRising)))
+	preEntry := widget.NewEntry()
+	preEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefRecordPre, 10)))
+	postEntry := widget.NewEntry()
+	postEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefRecordPost, 60)))
+
+	// triggerConfig 读取并保存触发条件
+	triggerConfig := func() (recordTrigger, error) {
+		addr, err := parseAddress(strings.TrimSpace(triggerBitEntry.Text))
+		if err == nil && (addr.size != "" || s7viewer.IsCounterArea(addr.area)) {
+			err = fmt.Errorf(tr("%s 不是位地址"), addr)
+		}
+		if err != nil {
+			return recordTrigger{}, err
+		}
+		pre, perr := strconv.Atoi(strings.TrimSpace(preEntry.Text))
+		post, serr := strconv.Atoi(strings.TrimSpace(postEntry.Text))
+		if perr != nil || serr != nil || pre < 0 || post < 0 || pre > maxTriggerSeconds || post > maxTriggerSeconds {
+			return recordTrigger{}, fmt.Errorf(tr("触发前后时间应为0到%d秒"), maxTriggerSeconds)
+		}
+		prefs.SetString(prefRecordTriggerBit, addr.String())
+		prefs.SetInt(prefRecordPre, pre)
+		prefs.SetInt(prefRecordPost, post)
+		return recordTrigger{
+			bit:    addr,
+			rising: selectValue(triggerEdgeSelect, triggerEdges) == triggerRising,
+			pre:    time.Duration(pre) * time.Second,
+			post:   time.Duration(post) * time.Second,
+		}, nil
+	}
+
+	recordCheck := widget.NewCheck(tr("录制（监控时）"), func(enabled bool) {
+		mu.Lock()
+		old := rec
+		rec = nil
+		mu.Unlock()
+		if old != nil {
+			n, err := old.close()
+			if err != nil {
+				log.Printf(tr("关闭录制文件失败: %v"), err)
+			}
+			log.Printf(tr("录制结束，共%d帧"), n)
+		}
+		if !enabled {
+			recordStatus.SetText(tr("录制: 未启用"))
+			return
+		}
+
+		path := strings.TrimSpace(pathEntry.Text)
+		if path == "" {
+			recordStatus.SetText(tr("录制: 路径无效"))
+			return
+		}
+		prefs.SetString(prefRecordPath, path)
+		r := newRecorder(path)
+		if triggerCheck.Checked {
+			trigger, err := triggerConfig()
+			if err != nil {
+				recordStatus.SetText(fmt.Sprintf(tr("录制: %v"), err))
+				return
+			}
+			r = newTriggeredRecorder(path, trigger, func(text string) {
+				fyne.Do(func() { recordStatus.SetText(text) })
+			})
+			recordStatus.SetText(fmt.Sprintf(tr("录制: 等待 %s %s，保存触发前%d秒、触发后%d秒"),
+				trigger.bit, triggerEdgeSelect.Selected, int(trigger.pre.Seconds()), int(trigger.post.Seconds())))
+			log.Printf(tr("开始条件录制到 %s"), path)
+		} else {
+			recordStatus.SetText(fmt.Sprintf(tr("录制: %s（文件名后追加开始时间）"), path))
+			log.Printf(tr("开始录制到 %s"), path)
+		}
+		mu.Lock()
+		rec = r
+		mu.Unlock()
+	})
+
+	// 回放状态只在UI线程中访问
+	var (
+		playing  *recording
+		index    int
+		pos      time.Time // 回放时钟，按速度推进
+		speed    = 1.0
+		stop     chan struct{}
+		updating bool // 程序设置进度条时不触发跳转
+	)
+
+	fileLabel := widget.NewLabel(tr("未打开录制"))
+	frameLabel := widget.NewLabel("")
+	seekSlider := widget.NewSlider(0, 1)
+	seekSlider.Step = 1
+	seekSlider.Disable()
+	var playButton *widget.Button
+
+	pause := func() {
+		if stop != nil {
+			close(stop)
+			stop = nil
+		}
+		playButton.SetText(tr("播放"))
+	}
+
+	// showFrame 显示第i帧并同步进度条和标签
+	showFrame := func(i int) {
+		index = i
+		f := playing.Frames[i]
+		updating = true
+		seekSlider.SetValue(float64(i))
+		updating = false
+		frameLabel.SetText(fmt.Sprintf(tr("第 %d/%d 帧  %s"), i+1, len(playing.Frames), f.Time.Format("2006-01-02 15:04:05.000")))
+		if !show(f.Time, playing.Header, f.Data) {
+			pause()
+		}
+	}
+
+	// step 回放时钟前进elapsed（乘以速度），显示到达的最后一帧，到末尾时暂停
+	step := func(elapsed time.Duration) {
+		if playing == nil || stop == nil {
+			return
+		}
+		pos = pos.Add(time.Duration(float64(elapsed) * speed))
+		j := index
+		for j+1 < len(playing.Frames) && !playing.Frames[j+1].Time.After(pos) {
+			j++
+		}
+		if j != index {
+			showFrame(j)
+		}
+		if stop != nil && index == len(playing.Frames)-1 {
+			pause()
+		}
+	}
+
+	playButton = widget.NewButton(tr("播放"), func() {
+		if playing == nil {
+			return
+		}
+		if stop != nil {
+			pause()
+			return
+		}
+		if index == len(playing.Frames)-1 {
+			showFrame(0)
+		}
+		pos = playing.Frames[index].Time
+		stop = make(chan struct{})
+		playButton.SetText(tr("暂停"))
+		go func(stop chan struct{}) {
+			ticker := time.NewTicker(replayTick)
+			defer ticker.Stop()
+			last := time.Now()
+			for {
+				select {
+				case <-stop:
+					return
+				case now := <-ticker.C:
+					elapsed := now.Sub(last)
+					last = now
+					fyne.Do(func() { step(elapsed) })
+				}
+			}
+		}(stop)
+	})
+	playButton.Disable()
+
+	seekSlider.OnChanged = func(v float64) {
+		if updating || playing == nil {
+			return
+		}
+		i := max(0, min(int(v), len(playing.Frames)-1))
+		showFrame(i)
+		pos = playing.Frames[i].Time
+	}
+
+	speedSelect := widget.NewSelect(replaySpeedNames, func(name string) {
+		speed = replaySpeeds[name]
+	})
+	speedSelect.SetSelected("1x")
+
+	open = func(path string) {
+		r, err := loadRecording(path)
+		if err != nil {
+			log.Printf("%v", err)
+			dialog.ShowError(err, win)
+			return
+		}
+		if len(r.Frames) == 0 {
+			dialog.ShowInformation(tr("打开录制"), tr("录制文件中没有数据帧"), win)
+			return
+		}
+		pause()
+		playing = r
+		fileLabel.SetText(fmt.Sprintf(tr("%s  %s %s，%d帧，时长 %s"), filepath.Base(path), r.Header.PLC,
+			s7viewer.ByteAddressName(r.Header.Area, r.Header.Start), len(r.Frames),
+			r.Frames[len(r.Frames)-1].Time.Sub(r.Header.Time).Round(time.Second)))
+		updating = true
+		seekSlider.Max = float64(len(r.Frames) - 1)
+		seekSlider.Value = -1
+		updating = false
+		seekSlider.Enable()
+		playButton.Enable()
+		showFrame(0)
+	}
+
+	// 导出VCD：将打开的录制中的位变化导出，在GTKWave、PulseView中查看
+	vcdBitsEntry := widget.NewEntry()
+	vcdBitsEntry.SetPlaceHolder(tr("要导出的位，如 V100.0, V100.3；为空时导出所有位"))
+	vcdBitsEntry.SetText(prefs.String(prefVCDBits))
+	vcdButton := widget.NewButton(tr("导出VCD"), func() {
+		if playing == nil {
+			log.Println(tr("请先打开录制"))
+			return
+		}
+		bits, err := parseBitList(vcdBitsEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if len(bits) == 0 {
+			bits = recordingBits(playing)
+		}
+		if len(bits) == 0 {
+			dialog.ShowError(errors.New(tr("录制中没有位地址")), win)
+			return
+		}
+		prefs.SetString(prefVCDBits, strings.TrimSpace(vcdBitsEntry.Text))
+		r := playing
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := writeVCD(writer, r, bits, labelOf); err != nil {
+				log.Printf(tr("导出VCD失败: %v"), err)
+				dialog.ShowError(err, win)
+				return
+			}
+			log.Printf(tr("已导出VCD到 %s"), writer.URI().Path())
+		}, win)
+		saveDialog.SetFileName(fmt.Sprintf("%s_%s.vcd", r.Header.PLC, r.Header.Time.Format("20060102_150405")))
+		saveDialog.Show()
+	})
+
+	openButton := widget.NewButton(tr("打开录制"), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+			open(path)
+		}, win)
+	})
+
+	content = container.NewVBox(
+		widget.NewLabelWithStyle(tr("录制"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewForm(widget.NewFormItem(tr("文件路径:"), pathEntry)),
+		widget.NewLabel(tr("监控时每次扫描写入一帧，未变化的扫描只记时间，变化时只记变化的字节。")),
+		container.NewHBox(triggerCheck, triggerBitEntry, triggerEdgeSelect,
+			widget.NewLabel(tr("触发前(秒):")), preEntry, widget.NewLabel(tr("触发后(秒):")), postEntry),
+		widget.NewLabel(tr("条件触发时只在内存中保留最近的扫描，触发后保存触发前后的数据，每次触发一个文件；修改条件后需重新勾选录制。")),
+		recordCheck,
+		recordStatus,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(tr("回放"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewHBox(openButton, playButton, widget.NewLabel(tr("速度:")), speedSelect),
+		fileLabel,
+		seekSlider,
+		frameLabel,
+		container.NewBorder(nil, nil, nil, vcdButton, vcdBitsEntry),
+		widget.NewLabel(tr("回放时在位网格、寄存器内容、结构化视图和趋势图中显示录制的数据，需先停止监控。")),
+	)
+	return content, current, open
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// prefEUScales 工程单位换算设置的偏好键
+const prefEUScales = "scale.rules"
+
+// euScale 线性换算：原始值rawMin~rawMax对应工程值euMin~euMax，unit为单位（可为空）
+type euScale struct {
+	rawMin, rawMax float64
+	euMin, euMax   float64
+	unit           string
+}
+
+// apply 将原始值换算为工程值，原始范围为0时返回euMin
+func (s euScale) apply(raw float64) float64 {
+	if s.rawMax == s.rawMin {
+		return s.euMin
+	}
+	return s.euMin + (raw-s.rawMin)*(s.euMax-s.euMin)/(s.rawMax-s.rawMin)
+}
+
+// raw 将工程值换算为原始值，为apply的逆运算，工程范围为0时返回rawMin
+func (s euScale) raw(eu float64) float64 {
+	if s.euMax == s.euMin {
+		return s.rawMin
+	}
+	return s.rawMin + (eu-s.euMin)*(s.rawMax-s.rawMin)/(s.euMax-s.euMin)
+}
+
+// counts 将工程值的差换算为原始计数的差，用于按工程单位设置的死区
+func (s euScale) counts(eu float64) float64 {
+	if s.euMax == s.euMin {
+		return eu
+	}
+	return eu * math.Abs((s.rawMax-s.rawMin)/(s.euMax-s.euMin))
+}
+
+// format 换算并格式化为带单位的文本，小数位数按一个原始计数对应的工程值确定，如 2350 → "23.50 °C"
+func (s euScale) format(raw float64) string {
+	decimals := 0
+	if s.rawMax != s.rawMin {
+		if step := math.Abs((s.euMax - s.euMin) / (s.rawMax - s.rawMin)); step > 0 && step < 1 {
+			decimals = min(int(math.Ceil(-math.Log10(step)-1e-9)), 6)
+		}
+	}
+	text := strconv.FormatFloat(s.apply(raw), 'f', decimals, 64)
+	if s.unit != "" {
+		text += " " + s.unit
+	}
+	return text
+}
+
+// euScales 各变量的换算，键为地址（VW320、AIW16，VR视为VD）
+type euScales map[string]euScale
+
+// parseEUScales 解析换算设置，每行（或分号分隔的每项）为“地址 原始最小 原始最大 工程最小 工程最大 [单位]”，
+// 如 "VW320 0 10000 0 100 °C"
+func parseEUScales(text string) (euScales, error) {
+	scales := make(euScales)
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ';' || r == '；' }) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 5 {
+			return nil, fmt.Errorf(tr("无效的换算设置: %q，示例: VW320 0 10000 0 100 °C"), strings.TrimSpace(line))
+		}
+		addr, _, err := parseWatchAddress(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		if addr.size == "" && !s7viewer.IsCounterArea(addr.area) {
+			return nil, fmt.Errorf(tr("%s 是位地址，不能换算"), addr)
+		}
+		var v [4]float64
+		for i := range v {
+			if v[i], err = strconv.ParseFloat(fields[i+1], 64); err != nil {
+				return nil, fmt.Errorf(tr("无效的数值: %q"), fields[i+1])
+			}
+		}
+		if v[0] == v[1] {
+			return nil, fmt.Errorf(tr("%s 的原始最小值和最大值不能相同"), addr)
+		}
+		scales[addr.String()] = euScale{rawMin: v[0], rawMax: v[1], euMin: v[2], euMax: v[3], unit: strings.Join(fields[5:], " ")}
+	}
+	return scales, nil
+}
+
+// 当前生效的换算，状态表、趋势图、报警和死区共用，可在监控协程中读取
+var (
+	euScalesMu     sync.Mutex
+	activeEUScales euScales
+)
+
+// euScaleOf 返回地址的换算，addr为s7Address.String()的格式
+func euScaleOf(addr string) (euScale, bool) {
+	euScalesMu.Lock()
+	defer euScalesMu.Unlock()
+	s, ok := activeEUScales[addr]
+	return s, ok
+}
+
+// newEUScaleSettings 创建工程单位换算设置，修改后立即生效
+func newEUScaleSettings(prefs fyne.Preferences) fyne.CanvasObject {
+	entry := widget.NewMultiLineEntry()
+	entry.SetMinRowsVisible(3)
+	entry.SetPlaceHolder(tr("每行一个变量：地址 原始最小 原始最大 工程最小 工程最大 单位，如 VW320 0 10000 0 100 °C"))
+	statusLabel := widget.NewLabel("")
+	apply := func(text string) {
+		scales, err := parseEUScales(text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf(tr("已设置%d个变量的换算"), len(scales)))
+		prefs.SetString(prefEUScales, text)
+		euScalesMu.Lock()
+		activeEUScales = scales
+		euScalesMu.Unlock()
+	}
+	entry.SetText(prefs.String(prefEUScales))
+	apply(entry.Text)
+	entry.OnChanged = apply
+
+	return container.NewVBox(
+		widget.NewLabel(tr("设置换算的变量在状态表、趋势图和报警中按工程单位显示和比较，快照导出中也是工程值。")),
+		entry,
+		statusLabel,
+	)
+}
+
+// parseLeadingFloat 解析文本开头的数值，忽略其后的单位，如 "23.50 °C"
+func parseLeadingFloat(s string) (float64, error) {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		s = fields[0]
+	}
+	return strconv.ParseFloat(s, 64)
+}
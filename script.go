@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// 用户脚本以内嵌的Lua解释器（gopher-lua）运行，不需要安装外部解释器。脚本定义以下全局函数，缺少的不调用：
+//
+//	function on_scan(tags, ip)   每次扫描：tags为本次读取范围内的各变量和计算变量的值（文本），ip为PLC地址
+//	function on_alarm(e)         报警触发或解除：e.tag、e.rule、e.value、e.cleared、e.plc、e.time
+//
+// 脚本通过plc表读写PLC和通知用户：
+//
+//	plc.read(tag)          读取变量（也可以是计算变量），返回值的文本，失败时返回nil和错误信息
+//	plc.write(tag, value)  写入V区，地址格式与HTTP接口相同，成功返回true，失败时返回nil和错误信息
+//	plc.log(text)          写入日志，print也写入日志
+//	plc.notify(text)       在窗口的通知条中提示
+//	plc.alarm{tag=..., text=..., value=..., cleared=false}  产生报警，执行报警通知的动作
+//
+// 只打开base、table、string和math库，脚本不能访问文件或启动进程。
+
+// scriptQueueSize 等待脚本处理的事件数上限，脚本处理不过来时丢弃扫描和报警事件，不阻塞监控和界面
+const scriptQueueSize = 64
+
+// scriptCallTimeout 加载脚本和每次调用on_scan、on_alarm的时间上限，超时的调用中止并记录日志，避免死循环卡住脚本。
+// 测试中缩短
+var scriptCallTimeout = 5 * time.Second
+
+// scriptAPI 脚本可调用的操作，由脚本面板按当前连接提供，在脚本的协程中调用
+type scriptAPI struct {
+	read   func(tag string) (string, error)
+	write  func(tag, value string) error
+	notify func(text string)
+	alarm  func(tag, text, value string, cleared bool)
+}
+
+// scriptEvent 等待脚本处理的一个事件：扫描或报警
+type scriptEvent struct {
+	plc   string
+	tags  map[string]string // 扫描的变量值，alarm非nil时不用
+	alarm *alarmEvent
+}
+
+// luaScript 运行中的脚本。Lua状态不能并发使用，所有调用都在run协程中进行
+type luaScript struct {
+	L      *lua.LState
+	api    scriptAPI
+	events chan scriptEvent
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	dropped int // 队列满时丢弃的事件数，恢复后记录日志
+}
+
+// startLuaScript 加载并运行脚本文件的顶层代码，出错时返回错误，成功后在后台处理事件直到stop
+func startLuaScript(path string, api scriptAPI) (*luaScript, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New(tr("请选择脚本文件"))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &luaScript{
+		L:      newScriptState(),
+		api:    api,
+		events: make(chan scriptEvent, scriptQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.registerAPI()
+	if err := s.call(func() error { return s.L.DoFile(path) }); err != nil {
+		s.L.Close()
+		cancel()
+		return nil, fmt.Errorf(tr("加载脚本失败: %v"), err)
+	}
+	go s.run()
+	return s, nil
+}
+
+// newScriptState 创建只打开安全库的Lua状态，去掉base库中读取文件和加载模块的函数
+func newScriptState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "require", "module"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	return L
+}
+
+// registerAPI 注册plc表，并将print改为写入日志
+func (s *luaScript) registerAPI() {
+	L := s.L
+	logText := func(L *lua.LState) int {
+		parts := make([]string, L.GetTop())
+		for i := range parts {
+			parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+		log.Printf(tr("脚本: %s"), strings.Join(parts, "\t"))
+		return 0
+	}
+	L.SetGlobal("print", L.NewFunction(logText))
+	L.SetGlobal("plc", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"log": logText,
+		"read": func(L *lua.LState) int {
+			v, err := s.api.read(L.CheckString(1))
+			if err != nil {
+				L.Push(lua.LNil)
+				L.Push(lua.LString(err.Error()))
+				return 2
+			}
+			L.Push(lua.LString(v))
+			return 1
+		},
+		"write": func(L *lua.LState) int {
+			// 数值和布尔值按文本写入，如 5、1.5、true
+			if err := s.api.write(L.CheckString(1), L.CheckAny(2).String()); err != nil {
+				L.Push(lua.LNil)
+				L.Push(lua.LString(err.Error()))
+				return 2
+			}
+			L.Push(lua.LTrue)
+			return 1
+		},
+		"notify": func(L *lua.LState) int {
+			s.api.notify(L.CheckString(1))
+			return 0
+		},
+		"alarm": func(L *lua.LState) int {
+			t := L.CheckTable(1)
+			field := func(name string) string {
+				if v := t.RawGetString(name); v != lua.LNil {
+					return v.String()
+				}
+				return ""
+			}
+			s.api.alarm(field("tag"), field("text"), field("value"), lua.LVAsBool(t.RawGetString("cleared")))
+			return 0
+		},
+	}))
+}
+
+// call 在超时限制下执行f，f中对Lua的调用超时后中止
+func (s *luaScript) call(f func() error) error {
+	ctx, cancel := context.WithTimeout(s.ctx, scriptCallTimeout)
+	defer cancel()
+	s.L.SetContext(ctx)
+	defer s.L.RemoveContext()
+	err := f()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf(tr("脚本执行超过%v，已中止"), scriptCallTimeout)
+	}
+	return err
+}
+
+// run 依次处理事件，stop后关闭Lua状态
+func (s *luaScript) run() {
+	defer close(s.done)
+	defer s.L.Close()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case e := <-s.events:
+			if err := s.dispatch(e); err != nil && s.ctx.Err() == nil {
+				log.Printf(tr("脚本出错: %v"), err)
+			}
+		}
+	}
+}
+
+// dispatch 调用事件对应的全局函数，脚本没有定义时忽略
+func (s *luaScript) dispatch(e scriptEvent) error {
+	L := s.L
+	var name string
+	var args []lua.LValue
+	if e.alarm != nil {
+		t := L.NewTable()
+		t.RawSetString("tag", lua.LString(e.alarm.tag))
+		t.RawSetString("rule", lua.LString(e.alarm.rule))
+		t.RawSetString("value", lua.LString(e.alarm.value))
+		t.RawSetString("cleared", lua.LBool(e.alarm.cleared))
+		t.RawSetString("plc", lua.LString(e.alarm.plc))
+		t.RawSetString("time", lua.LString(e.alarm.time.Format(time.RFC3339Nano)))
+		name, args = "on_alarm", []lua.LValue{t}
+	} else {
+		t := L.NewTable()
+		for k, v := range e.tags {
+			t.RawSetString(k, lua.LString(v))
+		}
+		name, args = "on_scan", []lua.LValue{t, lua.LString(e.plc)}
+	}
+	fn, ok := L.GetGlobal(name).(*lua.LFunction)
+	if !ok {
+		return nil
+	}
+	return s.call(func() error {
+		return L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, args...)
+	})
+}
+
+// send 将事件放入队列，队列满时丢弃并计数，不阻塞
+func (s *luaScript) send(e scriptEvent) {
+	select {
+	case s.events <- e:
+		s.mu.Lock()
+		if s.dropped > 0 {
+			log.Printf(tr("脚本处理过慢，丢弃了%d个事件"), s.dropped)
+			s.dropped = 0
+		}
+		s.mu.Unlock()
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
+
+// scan 发送一次扫描的变量值，可在监控协程中调用
+func (s *luaScript) scan(plc string, tags map[string]string) {
+	s.send(scriptEvent{plc: plc, tags: tags})
+}
+
+// alarm 发送报警事件
+func (s *luaScript) alarm(e alarmEvent) {
+	s.send(scriptEvent{plc: e.plc, alarm: &e})
+}
+
+// stop 中止正在执行的调用，丢弃未处理的事件，等待脚本协程结束
+func (s *luaScript) stop() {
+	s.cancel()
+	<-s.done
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeScriptAPI 记录脚本的调用，read从values中取值
+type fakeScriptAPI struct {
+	values map[string]string
+	calls  chan string // 每次调用一条，如 "write VW100=5"
+}
+
+func newFakeScriptAPI(values map[string]string) *fakeScriptAPI {
+	return &fakeScriptAPI{values: values, calls: make(chan string, 2*scriptQueueSize)}
+}
+
+func (f *fakeScriptAPI) api() scriptAPI {
+	return scriptAPI{
+		read: func(tag string) (string, error) {
+			f.calls <- "read " + tag
+			if v, ok := f.values[tag]; ok {
+				return v, nil
+			}
+			return "", errors.New("no such tag")
+		},
+		write: func(tag, value string) error {
+			f.calls <- "write " + tag + "=" + value
+			if tag == "VW0" {
+				return errors.New("read-only")
+			}
+			return nil
+		},
+		notify: func(text string) { f.calls <- "notify " + text },
+		alarm: func(tag, text, value string, cleared bool) {
+			f.calls <- "alarm " + tag + " " + text + " " + value + " " + map[bool]string{true: "cleared", false: "raised"}[cleared]
+		},
+	}
+}
+
+// next 等待脚本的下一次调用
+func (f *fakeScriptAPI) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case c := <-f.calls:
+		return c
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the script")
+		return ""
+	}
+}
+
+// startTestScript 将src写入临时文件并启动，测试结束时停止
+func startTestScript(t *testing.T, src string, api scriptAPI) *luaScript {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.lua")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := startLuaScript(path, api)
+	if err != nil {
+		t.Fatalf("startLuaScript: %v", err)
+	}
+	t.Cleanup(s.stop)
+	return s
+}
+
+func TestScriptScan(t *testing.T) {
+	f := newFakeScriptAPI(map[string]string{"VD200": "12.5"})
+	s := startTestScript(t, `
+function on_scan(tags, ip)
+  local level = tonumber(tags["VW100"])
+  if level > 100 then
+    local limit = plc.read("VD200")
+    plc.write("VW102", level - 100)
+    plc.write("V10.0", true)
+    plc.notify(ip .. " " .. limit)
+  end
+end
+`, f.api())
+
+	s.scan("192.168.2.1", map[string]string{"VW100": "50"})
+	s.scan("192.168.2.1", map[string]string{"VW100": "130"})
+	for _, want := range []string{"read VD200", "write VW102=30", "write V10.0=true", "notify 192.168.2.1 12.5"} {
+		if got := f.next(t); got != want {
+			t.Errorf("call = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestScriptReadWriteErrors(t *testing.T) {
+	f := newFakeScriptAPI(nil)
+	s := startTestScript(t, `
+function on_scan(tags)
+  local v, err = plc.read("VW2")
+  plc.notify(tostring(v) .. " " .. err)
+  local ok, err = plc.write("VW0", 1)
+  plc.notify(tostring(ok) .. " " .. err)
+end
+`, f.api())
+
+	s.scan("", nil)
+	for _, want := range []string{"read VW2", "notify nil no such tag", "write VW0=1", "notify nil read-only"} {
+		if got := f.next(t); got != want {
+			t.Errorf("call = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestScriptAlarm(t *testing.T) {
+	f := newFakeScriptAPI(nil)
+	s := startTestScript(t, `
+function on_alarm(e)
+  if not e.cleared then
+    plc.alarm{tag = "recipe", text = "check " .. e.tag, value = e.value}
+  end
+end
+`, f.api())
+
+	s.alarm(alarmEvent{time: time.Now(), plc: "10.0.0.1", tag: "VW100", rule: "> 100", value: "120"})
+	s.alarm(alarmEvent{time: time.Now(), plc: "10.0.0.1", tag: "VW100", rule: "> 100", value: "90", cleared: true})
+	s.alarm(alarmEvent{time: time.Now(), plc: "10.0.0.1", tag: "VW102", rule: "> 100", value: "101"})
+	for _, want := range []string{"alarm recipe check VW100 120 raised", "alarm recipe check VW102 101 raised"} {
+		if got := f.next(t); got != want {
+			t.Errorf("call = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestScriptErrorsDoNotStopScript(t *testing.T) {
+	old := scriptCallTimeout
+	scriptCallTimeout = 100 * time.Millisecond
+	defer func() { scriptCallTimeout = old }()
+
+	f := newFakeScriptAPI(nil)
+	s := startTestScript(t, `
+function on_scan(tags)
+  if tags.mode == "error" then error("boom") end
+  if tags.mode == "loop" then while true do end end
+  plc.notify("ok " .. tags.mode)
+end
+`, f.api())
+
+	// 运行时错误和死循环只中止这一次调用，之后的扫描照常处理
+	s.scan("", map[string]string{"mode": "error"})
+	s.scan("", map[string]string{"mode": "loop"})
+	s.scan("", map[string]string{"mode": "after"})
+	if got := f.next(t); got != "notify ok after" {
+		t.Errorf("call = %q, want notify ok after", got)
+	}
+}
+
+func TestScriptLoadErrors(t *testing.T) {
+	old := scriptCallTimeout
+	scriptCallTimeout = 100 * time.Millisecond
+	defer func() { scriptCallTimeout = old }()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"syntax error", "function on_scan(", "test.lua"},
+		{"runtime error", `error("bad config")`, "bad config"},
+		{"endless loop", "while true do end", "已中止"},
+		// 不能访问文件和启动进程
+		{"io library", `io.open("x")`, "attempt to index"},
+		{"os library", `os.execute("ls")`, "attempt to index"},
+		{"dofile", `dofile("x.lua")`, "attempt to call"},
+		{"require", `require("socket")`, "attempt to call"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "test.lua")
+		if err := os.WriteFile(path, []byte(tt.src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := startLuaScript(path, newFakeScriptAPI(nil).api())
+		if err == nil {
+			s.stop()
+			t.Errorf("%s: startLuaScript succeeded, want error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %q, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := startLuaScript(filepath.Join(t.TempDir(), "missing.lua"), scriptAPI{}); err == nil {
+		t.Error("startLuaScript(missing file) succeeded, want error")
+	}
+	if _, err := startLuaScript(" ", scriptAPI{}); err == nil {
+		t.Error("startLuaScript(\"\") succeeded, want error")
+	}
+}
+
+func TestScriptDropsEventsWhenBusy(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	f := newFakeScriptAPI(nil)
+	api := f.api()
+	api.notify = func(text string) {
+		if text == "first" {
+			close(started)
+			<-release
+		}
+		f.calls <- "notify " + text
+	}
+	s := startTestScript(t, `function on_scan(tags) plc.notify(tags.n) end`, api)
+
+	// 第一次扫描阻塞脚本，队列满后的扫描被丢弃，send不阻塞
+	s.scan("", map[string]string{"n": "first"})
+	<-started
+	for range scriptQueueSize + 10 {
+		s.scan("", map[string]string{"n": "queued"})
+	}
+	s.mu.Lock()
+	dropped := s.dropped
+	s.mu.Unlock()
+	if dropped != 10 {
+		t.Errorf("dropped = %d, want 10", dropped)
+	}
+	close(release)
+	if got := f.next(t); got != "notify first" {
+		t.Errorf("call = %q, want notify first", got)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 脚本设置的偏好键
+const (
+	prefScriptFile   = "script.file"
+	prefScriptAlarms = "alarm.script"
+)
+
+// newScriptPanel 创建脚本设置面板。current返回运行中的脚本，未启用时为nil，可在监控协程中调用。
+// getViewer返回当前连接，plc返回PLC地址，均在UI线程中调用；notify和alarm也在UI线程中调用，用于执行脚本的plc.notify和plc.alarm。
+func newScriptPanel(prefs fyne.Preferences, getViewer func() (*s7viewer.Viewer, context.Context), plc func() string,
+	notify func(text string), alarm func(alarmEvent)) (content fyne.CanvasObject, current func() *luaScript) {
+	var mu sync.Mutex
+	var script *luaScript
+	current = func() *luaScript {
+		mu.Lock()
+		defer mu.Unlock()
+		return script
+	}
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder(tr("Lua脚本文件，如 recipe.lua"))
+	pathEntry.SetText(prefs.String(prefScriptFile))
+	statusLabel := widget.NewLabel(tr("脚本: 未启用"))
+
+	// connection 在UI线程中取得当前连接：脚本在自己的协程中运行，连接和断开时UI线程会替换viewer和context
+	connection := func() (viewer *s7viewer.Viewer, ctx context.Context) {
+		fyne.DoAndWait(func() { viewer, ctx = getViewer() })
+		return viewer, ctx
+	}
+	api := scriptAPI{
+		read:  func(tag string) (string, error) { return scriptRead(connection, tag) },
+		write: func(tag, value string) error { return scriptWrite(connection, tag, value) },
+		notify: func(text string) {
+			fyne.Do(func() { notify(tr("脚本: ") + text) })
+		},
+		alarm: func(tag, text, value string, cleared bool) {
+			now := time.Now()
+			fyne.Do(func() {
+				alarm(alarmEvent{time: now, plc: plc(), tag: tag, rule: text, value: value, cleared: cleared})
+			})
+		},
+	}
+
+	var enableCheck *widget.Check
+	enableCheck = widget.NewCheck(tr("启用脚本"), func(enabled bool) {
+		mu.Lock()
+		old := script
+		script = nil
+		mu.Unlock()
+		if old != nil {
+			go old.stop()
+		}
+		if !enabled {
+			statusLabel.SetText(tr("脚本: 未启用"))
+			return
+		}
+
+		path := strings.TrimSpace(pathEntry.Text)
+		prefs.SetString(prefScriptFile, path)
+		s, err := startLuaScript(path, api)
+		if err != nil {
+			log.Printf("%v", err)
+			statusLabel.SetText(err.Error())
+			enableCheck.SetChecked(false)
+			return
+		}
+		mu.Lock()
+		script = s
+		mu.Unlock()
+		statusLabel.SetText(tr("脚本: 运行中"))
+		log.Printf(tr("已启动脚本: %s"), path)
+	})
+
+	content = container.NewVBox(
+		widget.NewForm(widget.NewFormItem(tr("脚本文件:"), pathEntry)),
+		enableCheck,
+		statusLabel,
+		widget.NewLabel(tr("脚本用Lua编写，由程序内嵌的解释器运行。定义 function on_scan(tags, ip) 在每次扫描时调用，"+
+			"tags为各变量的值，如 tags[\"VW100\"]；定义 function on_alarm(e) 在报警触发或解除时调用（e.tag、e.rule、e.value、e.cleared）。\n"+
+			"可调用 plc.read(tag)、plc.write(tag, value)（失败时返回nil和错误信息）、plc.log(text)、plc.notify(text) "+
+			"和 plc.alarm{tag=..., text=..., value=...}；print写入日志。\n"+
+			"脚本不能访问文件或启动进程，每次调用超过5秒时中止。修改脚本后重新勾选启用以重新加载。")),
+	)
+	return content, current
+}
+
+// scriptRead 读取变量的当前值，tag为地址或计算变量名
+func scriptRead(getViewer func() (*s7viewer.Viewer, context.Context), tag string) (string, error) {
+	if c := currentComputed(); c != nil && c.has(tag) {
+		v, ok := computedValue(tag)
+		if !ok {
+			return "", fmt.Errorf(tr("%s 没有值"), tag)
+		}
+		return formatComputed(v), nil
+	}
+	addr, dataType, err := parseWatchAddress(tag)
+	if err != nil {
+		return "", err
+	}
+	viewer, ctx := getViewer()
+	if viewer == nil {
+		return "", errors.New(tr("请先连接PLC"))
+	}
+	items := []s7viewer.Item{watchSpec{addr: addr, dataType: dataType}.item()}
+	if err := viewer.ReadItems(ctx, items); err != nil {
+		return "", err
+	}
+	if items[0].Err != nil {
+		return "", items[0].Err
+	}
+	return s7viewer.DecodeValue(viewer.ByteOrder(), dataType, items[0].Data, 0, addr.bit, 0)
+}
+
+// scriptWrite 写入V区变量，地址格式与HTTP接口相同
+func scriptWrite(getViewer func() (*s7viewer.Viewer, context.Context), tag, value string) error {
+	addr, dataType, err := parseAPIAddress(tag)
+	if err != nil {
+		return err
+	}
+	viewer, ctx := getViewer()
+	if viewer == nil {
+		return errors.New(tr("请先连接PLC"))
+	}
+	data, err := s7viewer.EncodeValue(viewer.ByteOrder(), dataType, strings.TrimSpace(value))
+	if err != nil {
+		return err
+	}
+	if dataType == s7viewer.TypeBool {
+		err = viewer.WriteVBit(ctx, addr.byteOff, addr.bit, data[0] == 1)
+	} else {
+		err = viewer.WriteV(ctx, addr.byteOff, data)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf(tr("脚本写入 %s = %s"), addr, value)
+	return nil
+}
+
+// scriptScanTags 一次扫描发送给脚本的变量：读取范围内的各变量和计算变量最近一次的值
+func scriptScanTags(order, area string, start int, data []byte) map[string]string {
+	tags := tagValues(order, area, start, data)
+	if c := currentComputed(); c != nil {
+		for _, t := range c.tags {
+			if v, ok := computedValue(t.name); ok {
+				tags[t.name] = formatComputed(v)
+			}
+		}
+	}
+	return tags
+}
+
+// sendScriptAlarm 将报警事件发送给运行中的脚本
+func sendScriptAlarm(current func() *luaScript, e alarmEvent) {
+	if s := current(); s != nil {
+		s.alarm(e)
+	}
+}
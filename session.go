@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// 会话的偏好键：退出时保存，下次启动时恢复。各标签页的IP保存在plc.tabs，状态表保存在watch.rows
+const (
+	prefSessionTabs   = "session.tabs"      // 各标签页的状态，与plc.tabs一一对应
+	prefSessionActive = "session.activeTab" // 选中的标签页
+	prefWindowWidth   = "window.width"
+	prefWindowHeight  = "window.height"
+)
+
+// defaultWindowSize 首次启动时的窗口大小
+var defaultWindowSize = fyne.NewSize(900, 700)
+
+// panelSession 一个标签页在退出时保存的状态：读取的存储区、地址、长度、选中的视图和导出备注
+type panelSession struct {
+	Area    string
+	Address string
+	Length  string
+	View    int    // 视图标签页的序号
+	Note    string // 最近一次导出时填写的备注
+}
+
+// String 保存到偏好设置的形式，如 "V|100|4|2|故障发生瞬间"。备注放在最后，可以含有|
+func (s panelSession) String() string {
+	return strings.Join([]string{s.Area, s.Address, s.Length, strconv.Itoa(s.View), s.Note}, "|")
+}
+
+// parsePanelSession 解析保存的状态，格式不对时ok为false。旧版本保存的状态没有备注
+func parsePanelSession(s string) (panelSession, bool) {
+	fields := strings.SplitN(s, "|", 5)
+	if len(fields) < 4 {
+		return panelSession{}, false
+	}
+	view, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return panelSession{}, false
+	}
+	session := panelSession{Area: fields[0], Address: fields[1], Length: fields[2], View: view}
+	if len(fields) == 5 {
+		session.Note = fields[4]
+	}
+	return session, true
+}
+
+// savedWindowSize 返回上次退出时的窗口大小
+func savedWindowSize(prefs fyne.Preferences) fyne.Size {
+	w := prefs.FloatWithFallback(prefWindowWidth, float64(defaultWindowSize.Width))
+	h := prefs.FloatWithFallback(prefWindowHeight, float64(defaultWindowSize.Height))
+	if w < 200 || h < 200 {
+		return defaultWindowSize
+	}
+	return fyne.NewSize(float32(w), float32(h))
+}
+
+// saveWindowSize 保存窗口大小
+func saveWindowSize(prefs fyne.Preferences, win fyne.Window) {
+	size := win.Canvas().Size()
+	prefs.SetFloat(prefWindowWidth, float64(size.Width))
+	prefs.SetFloat(prefWindowHeight, float64(size.Height))
+}
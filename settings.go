@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 外观设置的偏好键，颜色保存为#RRGGBB，空串表示默认颜色
+const (
+	prefTheme      = "ui.theme"
+	prefAccent     = "ui.accentColor"
+	prefColorOn    = "grid.colorOn"
+	prefColorOff   = "grid.colorOff"
+	prefColorFlash = "grid.colorFlash"
+	prefColorMuted = "grid.colorMuted"
+	prefColorStale = "grid.colorStale"
+	prefBitMarker  = "grid.bitMarker"
+)
+
+// 界面主题
+const (
+	themeSystem = "跟随系统"
+	themeLight  = "浅色"
+	themeDark   = "深色"
+)
+
+var themeModes = []string{themeSystem, themeLight, themeDark}
+
+// 位网格的默认颜色，外观设置可修改colorBitOn等变量
+var (
+	defaultColorBitOn    = colorBitOn
+	defaultColorBitOff   = colorBitOff
+	defaultColorBitFlash = colorBitFlash
+	defaultColorBitMuted = colorBitMuted
+	defaultColorBitStale = colorBitStale
+)
+
+// gridPalette 预设的位网格配色，颜色为空串时使用默认颜色
+type gridPalette struct {
+	name                         string
+	on, off, flash, muted, stale string
+}
+
+// gridPalettes 可选的预设配色。色盲友好的配色取自Okabe-Ito色板，红绿色盲也能区分0和1。
+var gridPalettes = []gridPalette{
+	{name: "默认"},
+	{name: "色盲友好(蓝/灰)", on: "#0072B2", off: "#C8C8C8", flash: "#E69F00", muted: "#505050", stale: "#CC79A7"},
+	{name: "高对比(黄/黑)", on: "#F0E442", off: "#3C3C3C", flash: "#56B4E9", muted: "#1E1E1E", stale: "#D55E00"},
+}
+
+// appTheme 在Fyne默认主题上固定明暗并替换强调色，未设置的部分使用默认主题
+type appTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+	forced  bool        // 为false时跟随系统的明暗设置
+	accent  color.Color // 为nil时使用默认强调色
+}
+
+func (t *appTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if t.forced {
+		variant = t.variant
+	}
+	if t.accent != nil && (name == theme.ColorNamePrimary || name == theme.ColorNameFocus) {
+		return t.accent
+	}
+	return t.Theme.Color(name, variant)
+}
+
+// applyAppearance 按偏好设置应用主题和位网格颜色
+func applyAppearance(a fyne.App) {
+	prefs := a.Preferences()
+	t := &appTheme{Theme: theme.DefaultTheme()}
+	switch prefs.StringWithFallback(prefTheme, themeSystem) {
+	case themeLight:
+		t.forced, t.variant = true, theme.VariantLight
+	case themeDark:
+		t.forced, t.variant = true, theme.VariantDark
+	}
+	if c, ok := parseHexColor(prefs.String(prefAccent)); ok {
+		t.accent = c
+	}
+	a.Settings().SetTheme(t)
+	applyGridColors(prefs)
+	bitMarker = prefs.StringWithFallback(prefBitMarker, markerNone)
+}
+
+// applyGridColors 按偏好设置更新位网格的颜色，在下次绘制网格时生效
+func applyGridColors(prefs fyne.Preferences) {
+	colorBitOn = prefColor(prefs, prefColorOn, defaultColorBitOn)
+	colorBitOff = prefColor(prefs, prefColorOff, defaultColorBitOff)
+	colorBitFlash = prefColor(prefs, prefColorFlash, defaultColorBitFlash)
+	colorBitMuted = prefColor(prefs, prefColorMuted, defaultColorBitMuted)
+	colorBitStale = prefColor(prefs, prefColorStale, defaultColorBitStale)
+}
+
+// profileColors 连接配置中保存的位网格配色（#RRGGBB，空串为默认颜色），
+// 使不同产线的颜色约定与各自的HMI一致
+type profileColors struct {
+	On     string `json:"on,omitempty"`
+	Off    string `json:"off,omitempty"`
+	Unused string `json:"unused,omitempty"`
+	Stale  string `json:"stale,omitempty"`
+}
+
+// currentProfileColors 返回偏好设置中当前的配色，保存连接配置时使用
+func currentProfileColors(prefs fyne.Preferences) *profileColors {
+	return &profileColors{
+		On:     prefs.String(prefColorOn),
+		Off:    prefs.String(prefColorOff),
+		Unused: prefs.String(prefColorMuted),
+		Stale:  prefs.String(prefColorStale),
+	}
+}
+
+// apply 将配置中的配色写入偏好设置并应用
+func (c *profileColors) apply(prefs fyne.Preferences) {
+	prefs.SetString(prefColorOn, c.On)
+	prefs.SetString(prefColorOff, c.Off)
+	prefs.SetString(prefColorMuted, c.Unused)
+	prefs.SetString(prefColorStale, c.Stale)
+	applyGridColors(prefs)
+}
+
+func prefColor(prefs fyne.Preferences, key string, fallback color.RGBA) color.RGBA {
+	if c, ok := parseHexColor(prefs.String(key)); ok {
+		return c
+	}
+	return fallback
+}
+
+// parseHexColor 解析#RRGGBB格式的颜色
+func parseHexColor(s string) (color.RGBA, bool) {
+	var r, g, b uint8
+	if len(s) != 7 {
+		return color.RGBA{}, false
+	}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: r, G: g, B: b, A: 255}, true
+}
+
+// hexColor 将颜色格式化为#RRGGBB
+func hexColor(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02X%02X%02X", rgba.R, rgba.G, rgba.B)
+}
+
+// colorSetting 外观设置中的一个颜色项：色块加“选择”和“默认”按钮，value为空串表示默认颜色
+type colorSetting struct {
+	value    string
+	fallback color.Color
+	swatch   *canvas.Rectangle
+	content  fyne.CanvasObject
+}
+
+func newColorSetting(win fyne.Window, title, value string, fallback color.Color) *colorSetting {
+	s := &colorSetting{value: value, fallback: fallback, swatch: canvas.NewRectangle(fallback)}
+	s.swatch.SetMinSize(fyne.NewSize(48, 24))
+	s.swatch.StrokeColor = color.Gray{Y: 96}
+	s.swatch.StrokeWidth = 1
+	s.update()
+
+	pick := widget.NewButton(tr("选择..."), func() {
+		picker := dialog.NewColorPicker(title, "", func(c color.Color) {
+			s.set(hexColor(c))
+		}, win)
+		picker.Advanced = true
+		picker.Show()
+		picker.SetColor(s.color())
+	})
+	reset := widget.NewButton(tr("默认"), func() { s.set("") })
+	s.content = container.NewHBox(s.swatch, pick, reset)
+	return s
+}
+
+func (s *colorSetting) color() color.Color {
+	if c, ok := parseHexColor(s.value); ok {
+		return c
+	}
+	return s.fallback
+}
+
+func (s *colorSetting) set(value string) {
+	s.value = value
+	s.update()
+}
+
+func (s *colorSetting) update() {
+	s.swatch.FillColor = s.color()
+	s.swatch.Refresh()
+}
+
+// showAppearanceDialog 显示外观设置：界面主题、强调色和位网格颜色（保存连接配置时一并保存）。
+// 确定后保存并立即应用，onApplied用于重绘已显示的网格。
+func showAppearanceDialog(a fyne.App, win fyne.Window, onApplied func()) {
+	prefs := a.Preferences()
+	themeSelect := newTrSelect(themeModes, nil)
+	themeSelect.SetSelected(tr(prefs.StringWithFallback(prefTheme, themeSystem)))
+
+	accent := newColorSetting(win, tr("强调色"), prefs.String(prefAccent), theme.DefaultTheme().Color(theme.ColorNamePrimary, a.Settings().ThemeVariant()))
+	on := newColorSetting(win, tr("1的颜色"), prefs.String(prefColorOn), defaultColorBitOn)
+	off := newColorSetting(win, tr("0的颜色"), prefs.String(prefColorOff), defaultColorBitOff)
+	flash := newColorSetting(win, tr("变化高亮颜色"), prefs.String(prefColorFlash), defaultColorBitFlash)
+	muted := newColorSetting(win, tr("未使用的颜色"), prefs.String(prefColorMuted), defaultColorBitMuted)
+	stale := newColorSetting(win, tr("读取失败/过期的颜色"), prefs.String(prefColorStale), defaultColorBitStale)
+
+	// 选择预设配色时填入各颜色项，确定后才保存
+	paletteNames := make([]string, len(gridPalettes))
+	for i, p := range gridPalettes {
+		paletteNames[i] = p.name
+	}
+	paletteSelect := newTrSelect(paletteNames, func(name string) {
+		for _, p := range gridPalettes {
+			if p.name == name {
+				on.set(p.on)
+				off.set(p.off)
+				flash.set(p.flash)
+				muted.set(p.muted)
+				stale.set(p.stale)
+			}
+		}
+	})
+	paletteSelect.PlaceHolder = tr("选择预设配色")
+	markerSelect := newTrSelect(bitMarkers, nil)
+	markerSelect.SetSelected(tr(prefs.StringWithFallback(prefBitMarker, markerNone)))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(tr("主题:"), themeSelect),
+		widget.NewFormItem(tr("强调色:"), accent.content),
+		widget.NewFormItem(tr("配色方案:"), paletteSelect),
+		widget.NewFormItem(tr("1的颜色:"), on.content),
+		widget.NewFormItem(tr("0的颜色:"), off.content),
+		widget.NewFormItem(tr("变化高亮:"), flash.content),
+		widget.NewFormItem(tr("未使用(屏蔽):"), muted.content),
+		widget.NewFormItem(tr("读取失败/过期:"), stale.content),
+		widget.NewFormItem(tr("1的标记:"), markerSelect),
+	}
+	dialog.ShowForm(tr("外观"), tr("确定"), tr("取消"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		prefs.SetString(prefTheme, selectValue(themeSelect, themeModes))
+		prefs.SetString(prefAccent, accent.value)
+		prefs.SetString(prefColorOn, on.value)
+		prefs.SetString(prefColorOff, off.value)
+		prefs.SetString(prefColorFlash, flash.value)
+		prefs.SetString(prefColorMuted, muted.value)
+		prefs.SetString(prefColorStale, stale.value)
+		prefs.SetString(prefBitMarker, selectValue(markerSelect, bitMarkers))
+		applyAppearance(a)
+		onApplied()
+	}, win)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// installShortcuts 注册窗口的快捷键，作用于current返回的当前标签页：
+// 回车读取、F5开始/停止监控、Ctrl+K连接、Ctrl+L清除显示、方向键移动网格的选择光标。
+// 输入框获得焦点时按键由输入框处理（地址和长度输入框中回车同样读取），点击空白处取消焦点。
+func installShortcuts(c fyne.Canvas, current func() *plcPanel) {
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		if p := current(); p != nil {
+			p.connect()
+		}
+	})
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyL, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		if p := current(); p != nil {
+			p.clearDisplay()
+		}
+	})
+	c.SetOnTypedKey(func(e *fyne.KeyEvent) {
+		p := current()
+		if p == nil {
+			return
+		}
+		switch e.Name {
+		case fyne.KeyReturn, fyne.KeyEnter:
+			p.read()
+		case fyne.KeyF5:
+			p.toggleMonitor()
+		case fyne.KeyLeft:
+			p.moveCursor(-1, 0)
+		case fyne.KeyRight:
+			p.moveCursor(1, 0)
+		case fyne.KeyUp:
+			p.moveCursor(0, -1)
+		case fyne.KeyDown:
+			p.moveCursor(0, 1)
+		}
+	})
+}
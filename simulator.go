@@ -0,0 +1,593 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 模拟器各存储区的大小（字节），按S7-200 SMART的地址范围取整。T/C区为编号个数×2字节
+var simAreaSizes = map[byte]int{
+	s7viewer.S7AreaDB:    16384,
+	s7viewer.S7AreaPE:    32,
+	s7viewer.S7AreaPA:    32,
+	s7viewer.S7AreaMK:    32,
+	s7viewer.S7AreaSM200: 1536,
+	s7viewer.S7AreaAI200: 112,
+	s7viewer.S7AreaAQ200: 112,
+	s7viewer.S7AreaTM:    256 * 2,
+	s7viewer.S7AreaCT:    256 * 2,
+}
+
+// simWritable 允许客户端写入的区域，输入、特殊存储器和模拟量输入只读
+var simWritable = map[byte]bool{s7viewer.S7AreaDB: true, s7viewer.S7AreaPA: true, s7viewer.S7AreaMK: true, s7viewer.S7AreaAQ200: true, s7viewer.S7AreaTM: true, s7viewer.S7AreaCT: true}
+
+// 模拟器的变化模式
+const (
+	simPatternAll     = "all"     // 以下全部
+	simPatternStatic  = "static"  // 不变，只响应写入
+	simPatternCounter = "counter" // VW0每100ms加1
+	simPatternWalk    = "walk"    // VB2、VB3中的一个位每0.5秒移动一位（流水灯），同时输出到QB0
+	simPatternSine    = "sine"    // VD4为10秒周期的正弦REAL，VW8为其×1000的INT
+	simPatternRandom  = "random"  // VB10-VB17每秒随机变化
+)
+
+var simPatterns = []string{simPatternAll, simPatternStatic, simPatternCounter, simPatternWalk, simPatternSine, simPatternRandom}
+
+// simTick 模拟器更新数据的间隔
+const simTick = 100 * time.Millisecond
+
+// simPDULength 协商的最大PDU长度，与S7-200 SMART一致
+const simPDULength = 240
+
+// S7协议中的应答返回码
+const (
+	s7RetOK           = 0xFF
+	s7RetAccessDenied = 0x03
+	s7RetAddressError = 0x05
+	s7RetTypeError    = 0x06
+	s7RetNoObject     = 0x0A
+)
+
+// S7用户数据（userdata）报文的功能组
+const (
+	s7GroupCPU   = 0x04 // CPU功能：读取SZL
+	s7GroupClock = 0x07 // 时钟：子功能1读取，2设置
+)
+
+// 模拟的CPU：ST40，固件V2.8.0
+var (
+	simOrderCode  = "6ES7 288-1ST40-0AA1"
+	simFirmware   = [3]byte{2, 8, 0}
+	simComponents = []struct {
+		index uint16
+		name  string
+	}{
+		{0x0001, "S7-200 SMART"},
+		{0x0002, "CPU ST40"},
+		{0x0003, ""},
+		{0x0004, "Original Siemens Equipment"},
+		{0x0005, "SIM-00000001"},
+		{0x0007, "CPU ST40 (DC/DC/DC)"},
+	}
+)
+
+// s7ErrSZLNotAvailable 用户数据应答中的错误码：请求的SZL不存在
+const s7ErrSZLNotAvailable = 0xD401
+
+// simPLC 进程内的S7服务器模拟器：实现ISO-on-TCP连接、PDU协商、读写变量、RUN/STOP，
+// 以及读取CPU状态和读写时钟的用户数据请求。
+// 各存储区为内存缓冲区，V区（DB1）可写，并按pattern周期变化，不连接硬件即可演示和测试。
+// SM0.0始终为1，SM0.5为1秒周期的时钟脉冲。STOP时数据不再变化，输出清零。
+type simPLC struct {
+	mu          sync.Mutex
+	areas       map[byte][]byte
+	pattern     string
+	tick        int
+	running     bool          // CPU处于RUN
+	clockOffset time.Duration // PLC时钟与本机时钟之差，设置时钟时修改
+
+	listener net.Listener
+	conns    map[net.Conn]bool
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// startSimulator 在addr（如 127.0.0.1:1102）上启动模拟器
+func startSimulator(addr, pattern string) (*simPLC, error) {
+	if !containsString(simPatterns, pattern) {
+		return nil, fmt.Errorf(tr("无效的模拟模式: %s（可选 %v）"), pattern, simPatterns)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf(tr("启动S7模拟器失败: %v"), err)
+	}
+	s := &simPLC{
+		areas:    make(map[byte][]byte),
+		pattern:  pattern,
+		running:  true,
+		listener: l,
+		conns:    make(map[net.Conn]bool),
+		stop:     make(chan struct{}),
+	}
+	for area, size := range simAreaSizes {
+		s.areas[area] = make([]byte, size)
+	}
+	s.areas[s7viewer.S7AreaSM200][0] = 0x01 // SM0.0 始终为1
+
+	s.wg.Add(2)
+	go s.acceptLoop()
+	go s.updateLoop()
+	return s, nil
+}
+
+// addr 返回实际监听的地址，端口为0时由系统分配
+func (s *simPLC) addr() string {
+	return s.listener.Addr().String()
+}
+
+// close 停止监听、断开所有客户端并等待协程退出
+func (s *simPLC) close() {
+	close(s.stop)
+	s.listener.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// setBytes 直接写入某个区域，用于准备测试数据
+func (s *simPLC) setBytes(area byte, start int, data []byte) {
+	s.mu.Lock()
+	copy(s.areas[area][start:], data)
+	s.mu.Unlock()
+}
+
+// bytes 返回某个区域的一段数据的副本
+func (s *simPLC) bytes(area byte, start, size int) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte(nil), s.areas[area][start:start+size]...)
+}
+
+func (s *simPLC) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// updateLoop 按模式周期修改数据
+func (s *simPLC) updateLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(simTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.update()
+		}
+	}
+}
+
+func (s *simPLC) update() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tick++
+	t := s.tick
+	v := s.areas[s7viewer.S7AreaDB]
+	on := func(p string) bool { return s.pattern == simPatternAll || s.pattern == p }
+
+	// SM0.5：0.5秒为1、0.5秒为0
+	if t%5 == 0 {
+		s.areas[s7viewer.S7AreaSM200][0] ^= 1 << 5
+	}
+	if !s.running {
+		return
+	}
+	if on(simPatternCounter) {
+		binary.BigEndian.PutUint16(v[0:], binary.BigEndian.Uint16(v[0:])+1)
+	}
+	if on(simPatternWalk) && t%5 == 0 {
+		bit := uint16(1) << (15 - (t/5)%16)
+		binary.BigEndian.PutUint16(v[2:], bit)
+		s.areas[s7viewer.S7AreaPA][0] = byte(bit>>8) | byte(bit)
+	}
+	if on(simPatternSine) {
+		x := math.Sin(2 * math.Pi * float64(t) * simTick.Seconds() / 10)
+		binary.BigEndian.PutUint32(v[4:], math.Float32bits(float32(x)))
+		binary.BigEndian.PutUint16(v[8:], uint16(int16(x*1000)))
+	}
+	if on(simPatternRandom) && t%10 == 0 {
+		rand.Read(v[10:18])
+	}
+}
+
+// serve 处理一个客户端连接，直到连接关闭或收到无法解析的报文
+func (s *simPLC) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	for {
+		req, err := readTPKT(conn)
+		if err != nil {
+			return
+		}
+		resp := s.handle(req)
+		if resp == nil {
+			log.Printf(tr("S7模拟器: 无法识别的报文 % X"), req)
+			return
+		}
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+// readTPKT 读取一个完整的TPKT报文（含4字节头）
+func readTPKT(r io.Reader) ([]byte, error) {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint16(head[2:]))
+	if head[0] != 0x03 || n < 7 {
+		return nil, fmt.Errorf(tr("无效的TPKT头: % X"), head)
+	}
+	packet := make([]byte, n)
+	copy(packet, head)
+	if _, err := io.ReadFull(r, packet[4:]); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+// handle 处理一个请求报文，返回应答；不支持的功能应答S7错误，报文无法解析时返回nil
+func (s *simPLC) handle(req []byte) []byte {
+	if len(req) < 10 {
+		return nil
+	}
+	switch req[5] {
+	case 0xE0: // COTP连接请求：原样返回参数，类型改为连接确认并交换引用号
+		resp := append([]byte(nil), req...)
+		resp[5] = 0xD0
+		resp[6], resp[7], resp[8], resp[9] = req[8], req[9], req[6], req[7]
+		return resp
+	case 0xF0: // COTP数据
+	default:
+		return nil
+	}
+
+	pdu := req[7:]
+	if len(pdu) < 10 || pdu[0] != 0x32 {
+		return nil
+	}
+	parLen := int(binary.BigEndian.Uint16(pdu[6:]))
+	dataLen := int(binary.BigEndian.Uint16(pdu[8:]))
+	if len(pdu) < 10+parLen+dataLen || parLen < 2 {
+		return nil
+	}
+	params := pdu[10 : 10+parLen]
+	data := pdu[10+parLen : 10+parLen+dataLen]
+	ref := pdu[4:6]
+
+	switch pdu[1] {
+	case 0x01: // 作业
+	case 0x07: // 用户数据
+		return s.handleUserData(ref, params, data)
+	default:
+		return s7AckError(ref, 0x81, 0x04)
+	}
+
+	switch params[0] {
+	case 0xF0: // 通信设置：按请求与模拟器上限中较小的PDU长度应答
+		if len(params) < 8 {
+			return nil
+		}
+		pduLen := min(int(binary.BigEndian.Uint16(params[6:])), simPDULength)
+		resp := []byte{0xF0, 0x00, 0x00, 0x01, 0x00, 0x01, 0, 0}
+		binary.BigEndian.PutUint16(resp[6:], uint16(pduLen))
+		return s7AckData(ref, resp, nil)
+	case 0x04: // 读变量
+		count := int(params[1])
+		if len(params) < 2+count*12 {
+			return nil
+		}
+		var out []byte
+		for i := range count {
+			code, ts, buf := s.readItem(params[2+i*12 : 14+i*12])
+			if code != s7RetOK {
+				out = append(out, code, 0x00, 0x00, 0x00)
+				continue
+			}
+			n := len(buf)
+			if ts == 0x04 {
+				n *= 8 // 以位为单位的长度
+			}
+			out = append(out, code, ts, byte(n>>8), byte(n))
+			out = append(out, buf...)
+			if len(buf)%2 != 0 && i < count-1 {
+				out = append(out, 0x00)
+			}
+		}
+		return s7AckData(ref, []byte{0x04, byte(count)}, out)
+	case 0x05: // 写变量
+		count := int(params[1])
+		if len(params) < 2+count*12 {
+			return nil
+		}
+		var out []byte
+		for i := range count {
+			if len(data) < 4 {
+				return nil
+			}
+			n := int(binary.BigEndian.Uint16(data[2:]))
+			if data[1] == 0x03 || data[1] == 0x04 || data[1] == 0x05 {
+				n = (n + 7) / 8
+			}
+			if len(data) < 4+n {
+				return nil
+			}
+			out = append(out, s.writeItem(params[2+i*12:14+i*12], data[4:4+n]))
+			if n%2 != 0 {
+				n++
+			}
+			data = data[min(4+n, len(data)):]
+		}
+		return s7AckData(ref, []byte{0x05, byte(count)}, out)
+	case 0x28: // PI服务，gos7只用它暖启动或冷启动程序
+		return s.setRunning(ref, 0x28, true)
+	case 0x29: // 停止
+		return s.setRunning(ref, 0x29, false)
+	}
+	return s7AckError(ref, 0x81, 0x04)
+}
+
+// setRunning 切换RUN/STOP。已处于该状态时按PLC的习惯在参数中附带原因码（RUN 0x02，STOP 0x07）
+func (s *simPLC) setRunning(ref []byte, function byte, run bool) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running == run {
+		reason := byte(0x02)
+		if !run {
+			reason = 0x07
+		}
+		return s7AckData(ref, []byte{function, reason}, nil)
+	}
+	s.running = run
+	if !run {
+		clear(s.areas[s7viewer.S7AreaPA])
+	}
+	return s7AckData(ref, []byte{function}, nil)
+}
+
+// handleUserData 处理用户数据请求：读取SZL和读写时钟，其他功能应答错误码
+func (s *simPLC) handleUserData(ref, params, data []byte) []byte {
+	if len(params) < 8 {
+		return nil
+	}
+	group, sub, seq := params[5]&0x0F, params[6], params[7]
+	switch {
+	case group == s7GroupCPU && sub == 0x01 && len(data) >= 8:
+		id := binary.BigEndian.Uint16(data[4:])
+		index := binary.BigEndian.Uint16(data[6:])
+		if records, size, ok := s.szl(id, index); ok {
+			return s7UserData(ref, group, sub, seq, 0, szlData(id, index, size, records))
+		}
+		return s7UserData(ref, group, sub, seq, s7ErrSZLNotAvailable, []byte{0x0A, 0x00, 0x00, 0x00})
+	case group == s7GroupClock && sub == 0x01:
+		s.mu.Lock()
+		now := time.Now().Add(s.clockOffset)
+		s.mu.Unlock()
+		return s7UserData(ref, group, sub, seq, 0, append([]byte{0xFF, 0x09, 0x00, 0x0A}, s7DateTime(now)...))
+	case group == s7GroupClock && sub == 0x02 && len(data) >= 14:
+		t, ok := parseS7DateTime(data[4:14])
+		if !ok {
+			return s7UserData(ref, group, sub, seq, 0xD602, []byte{0x0A, 0x00, 0x00, 0x00})
+		}
+		s.mu.Lock()
+		s.clockOffset = time.Until(t)
+		s.mu.Unlock()
+		return s7UserData(ref, group, sub, seq, 0, []byte{0x0A, 0x00, 0x00, 0x00})
+	}
+	return s7UserData(ref, group, sub, seq, 0x8104, []byte{0x0A, 0x00, 0x00, 0x00})
+}
+
+// szl 返回SZL列表的数据记录和每条记录的长度，不支持的列表ok为false
+func (s *simPLC) szl(id, index uint16) (records []byte, size int, ok bool) {
+	switch id {
+	case 0x0011: // 模块标识：索引1为CPU的订货号，索引7为固件版本
+		cpu := simIdentRecord(0x0001, simOrderCode, 0x0000, 0x0001)
+		firmware := simIdentRecord(0x0007, "", 0x0000, uint16('V')<<8|uint16(simFirmware[0]))
+		binary.BigEndian.PutUint16(firmware[26:], uint16(simFirmware[1])<<8|uint16(simFirmware[2]))
+		return append(cpu, firmware...), len(cpu), true
+	case 0x001C: // CPU标识：各记录为索引和32字节的名称
+		var records []byte
+		for _, c := range simComponents {
+			record := make([]byte, 34)
+			binary.BigEndian.PutUint16(record, c.index)
+			copy(record[2:], c.name)
+			records = append(records, record...)
+		}
+		return records, 34, true
+	case 0x0424: // 当前运行状态，第4字节为新状态：0x08 RUN，0x04 STOP
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		record := make([]byte, 20)
+		binary.BigEndian.PutUint16(record, 0x4303) // 事件：状态切换
+		record[2] = 0xFF
+		record[3] = 0x04
+		if s.running {
+			record[3] = 0x08
+		}
+		return record, len(record), true
+	}
+	return nil, 0, false
+}
+
+// simIdentRecord 组装SZL 0x0011的一条记录：索引、20字节订货号（空格补齐）、模块类型和版本
+func simIdentRecord(index uint16, orderCode string, moduleType, version uint16) []byte {
+	record := make([]byte, 28)
+	binary.BigEndian.PutUint16(record, index)
+	copy(record[2:22], fmt.Sprintf("%-20s", orderCode))
+	binary.BigEndian.PutUint16(record[22:], moduleType)
+	binary.BigEndian.PutUint16(record[24:], version)
+	return record
+}
+
+// szlData 组装读取SZL应答的数据部分：返回码、传输类型、长度、SZL ID和索引、记录长度和条数，后跟各记录
+func szlData(id, index uint16, size int, records []byte) []byte {
+	n := 8 + len(records)
+	data := []byte{0xFF, 0x09, byte(n >> 8), byte(n), byte(id >> 8), byte(id), byte(index >> 8), byte(index), 0, byte(size), 0, 0}
+	if size > 0 {
+		binary.BigEndian.PutUint16(data[10:], uint16(len(records)/size))
+	}
+	return append(data, records...)
+}
+
+// s7DateTime 按时钟应答的格式编码时间：保留字节和年份高两位后跟BCD的DATE_AND_TIME
+func s7DateTime(t time.Time) []byte {
+	bcd := func(v int) byte { return byte(v/10<<4 | v%10) }
+	ms := t.Nanosecond() / int(time.Millisecond)
+	return []byte{0x00, bcd(t.Year() / 100), bcd(t.Year() % 100), bcd(int(t.Month())), bcd(t.Day()),
+		bcd(t.Hour()), bcd(t.Minute()), bcd(t.Second()), bcd(ms / 10), bcd(ms%10)<<4 | bcd(int(t.Weekday())+1)}
+}
+
+// parseS7DateTime 解析设置时钟请求中的时间，格式同s7DateTime
+func parseS7DateTime(b []byte) (time.Time, bool) {
+	var v [8]int
+	for i := range v {
+		x := b[i+2]
+		if x>>4 > 9 || x&0x0F > 9 {
+			return time.Time{}, false
+		}
+		v[i] = int(x>>4)*10 + int(x&0x0F)
+	}
+	year := 1900 + v[0]
+	if v[0] < 90 {
+		year = 2000 + v[0]
+	}
+	if v[1] < 1 || v[1] > 12 || v[2] < 1 || v[2] > 31 || v[3] > 23 || v[4] > 59 || v[5] > 59 {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month(v[1]), v[2], v[3], v[4], v[5], (v[6]*10+v[7]/10)*int(time.Millisecond), time.Local), true
+}
+
+// itemRange 解析变量描述（12字节），返回区域缓冲区和字节范围
+func (s *simPLC) itemRange(item []byte) (buf []byte, from, to int, code byte) {
+	if item[0] != 0x12 || item[1] != 0x0A || item[2] != 0x10 {
+		return nil, 0, 0, s7RetTypeError
+	}
+	wordLen := item[3]
+	amount := int(binary.BigEndian.Uint16(item[4:]))
+	db := int(binary.BigEndian.Uint16(item[6:]))
+	area := item[8]
+	address := int(item[9])<<16 | int(item[10])<<8 | int(item[11])
+
+	buf, ok := s.areas[area]
+	if !ok || (area == s7viewer.S7AreaDB && db != 1) {
+		return nil, 0, 0, s7RetNoObject
+	}
+	switch {
+	case area == s7viewer.S7AreaTM || area == s7viewer.S7AreaCT:
+		from, to = address*2, (address+amount)*2
+	case wordLen == s7viewer.S7WordLenByte:
+		from, to = address>>3, address>>3+amount
+	default:
+		return nil, 0, 0, s7RetTypeError
+	}
+	if to > len(buf) || amount == 0 {
+		return nil, 0, 0, s7RetAddressError
+	}
+	return buf, from, to, s7RetOK
+}
+
+func (s *simPLC) readItem(item []byte) (code, transport byte, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf, from, to, code := s.itemRange(item)
+	if code != s7RetOK {
+		return code, 0, nil
+	}
+	transport = 0x04
+	if item[8] == s7viewer.S7AreaTM || item[8] == s7viewer.S7AreaCT {
+		transport = 0x09 // 以字节为单位的长度
+	}
+	return code, transport, append([]byte(nil), buf[from:to]...)
+}
+
+func (s *simPLC) writeItem(item []byte, data []byte) byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf, from, to, code := s.itemRange(item)
+	if code != s7RetOK {
+		return code
+	}
+	if !simWritable[item[8]] {
+		return s7RetAccessDenied
+	}
+	if len(data) != to-from {
+		return s7RetAddressError
+	}
+	copy(buf[from:to], data)
+	return s7RetOK
+}
+
+// s7AckData 组装应答报文：TPKT、COTP数据头和S7应答头（无错误）后跟参数和数据
+func s7AckData(ref, params, data []byte) []byte {
+	return s7Ack(ref, 0, 0, params, data)
+}
+
+// s7AckError 组装只有错误类别和错误码的应答
+func s7AckError(ref []byte, class, code byte) []byte {
+	return s7Ack(ref, class, code, nil, nil)
+}
+
+// s7Ack 组装作业的应答报文
+func s7Ack(ref []byte, class, code byte, params, data []byte) []byte {
+	n := 4 + 3 + 12 + len(params) + len(data)
+	resp := make([]byte, 0, n)
+	resp = append(resp, 0x03, 0x00, byte(n>>8), byte(n))
+	resp = append(resp, 0x02, 0xF0, 0x80)
+	resp = append(resp, 0x32, 0x03, 0x00, 0x00, ref[0], ref[1],
+		byte(len(params)>>8), byte(len(params)), byte(len(data)>>8), byte(len(data)), class, code)
+	resp = append(resp, params...)
+	return append(resp, data...)
+}
+
+// s7UserData 组装用户数据的应答报文，errCode为0表示成功。data含返回码、传输类型和长度
+func s7UserData(ref []byte, group, sub, seq byte, errCode uint16, data []byte) []byte {
+	params := []byte{0x00, 0x01, 0x12, 0x08, 0x12, 0x80 | group, sub, seq, 0x00, 0x00, byte(errCode >> 8), byte(errCode)}
+	n := 4 + 3 + 10 + len(params) + len(data)
+	resp := make([]byte, 0, n)
+	resp = append(resp, 0x03, 0x00, byte(n>>8), byte(n))
+	resp = append(resp, 0x02, 0xF0, 0x80)
+	resp = append(resp, 0x32, 0x07, 0x00, 0x00, ref[0], ref[1],
+		byte(len(params)>>8), byte(len(params)), byte(len(data)>>8), byte(len(data)))
+	resp = append(resp, params...)
+	return append(resp, data...)
+}
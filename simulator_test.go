@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// connectSimulator 启动模拟器并用gos7客户端连接，测试结束时断开并关闭模拟器
+func connectSimulator(t *testing.T, pattern string) (*simPLC, *s7viewer.Viewer) {
+	t.Helper()
+	sim, err := startSimulator("127.0.0.1:0", pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(sim.addr())
+	cfg := s7viewer.DefaultConfig(host)
+	cfg.Port, _ = strconv.Atoi(port)
+	cfg.Timeout = 2 * time.Second
+	viewer := s7viewer.New()
+	if err := viewer.Connect(cfg); err != nil {
+		sim.close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viewer.Disconnect()
+		sim.close()
+	})
+	return sim, viewer
+}
+
+func TestSimulatorReadWrite(t *testing.T) {
+	sim, viewer := connectSimulator(t, simPatternStatic)
+	ctx := context.Background()
+
+	sim.setBytes(s7viewer.S7AreaDB, 100, []byte{0x12, 0x34, 0x56, 0x78})
+	sim.setBytes(s7viewer.S7AreaPE, 0, []byte{0x81})
+	if got, err := viewer.ReadRange(ctx, "V", 100, 4); err != nil || !bytes.Equal(got, []byte{0x12, 0x34, 0x56, 0x78}) {
+		t.Fatalf("ReadRange(V100, 4) = % X, %v", got, err)
+	}
+	if got, err := viewer.ReadRange(ctx, "I", 0, 1); err != nil || !bytes.Equal(got, []byte{0x81}) {
+		t.Fatalf("ReadRange(I0, 1) = % X, %v", got, err)
+	}
+
+	// 奇数长度的写入，检验数据部分的填充字节
+	if err := viewer.WriteV(ctx, 200, []byte{0xAA, 0xBB, 0xCC}); err != nil {
+		t.Fatalf("WriteV: %v", err)
+	}
+	if got := sim.bytes(s7viewer.S7AreaDB, 199, 5); !bytes.Equal(got, []byte{0x00, 0xAA, 0xBB, 0xCC, 0x00}) {
+		t.Errorf("V199-V203 after WriteV = % X", got)
+	}
+}
+
+func TestSimulatorWriteBit(t *testing.T) {
+	sim, viewer := connectSimulator(t, simPatternStatic)
+	ctx := context.Background()
+
+	sim.setBytes(s7viewer.S7AreaDB, 20, []byte{0xA0, 0xFF})
+	if err := viewer.WriteVBit(ctx, 20, 0, true); err != nil {
+		t.Fatalf("WriteVBit(V20.0, 1): %v", err)
+	}
+	if err := viewer.WriteVBit(ctx, 20, 7, false); err != nil {
+		t.Fatalf("WriteVBit(V20.7, 0): %v", err)
+	}
+	if got := sim.bytes(s7viewer.S7AreaDB, 20, 2); !bytes.Equal(got, []byte{0x21, 0xFF}) {
+		t.Errorf("V20-V21 after WriteVBit = % X, want 21 FF", got)
+	}
+}
+
+// simJob 组装一个作业请求报文
+func simJob(params, data []byte) []byte {
+	n := 4 + 3 + 10 + len(params) + len(data)
+	req := []byte{0x03, 0x00, byte(n >> 8), byte(n), 0x02, 0xF0, 0x80,
+		0x32, 0x01, 0x00, 0x00, 0x00, 0x01, byte(len(params) >> 8), byte(len(params)), byte(len(data) >> 8), byte(len(data))}
+	req = append(req, params...)
+	return append(req, data...)
+}
+
+// simItem 组装变量描述：按字节访问area的db块从start开始的amount字节
+func simItem(area byte, db, start, amount int) []byte {
+	address := start * 8
+	return []byte{0x12, 0x0A, 0x10, s7viewer.S7WordLenByte, byte(amount >> 8), byte(amount), byte(db >> 8), byte(db),
+		area, byte(address >> 16), byte(address >> 8), byte(address)}
+}
+
+func TestSimulatorItemErrors(t *testing.T) {
+	sim, err := startSimulator("127.0.0.1:0", simPatternStatic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.close()
+
+	read := func(item []byte) []byte { return simJob(append([]byte{0x04, 0x01}, item...), nil) }
+	write := func(item []byte, data ...byte) []byte {
+		return simJob(append([]byte{0x05, 0x01}, item...), append([]byte{0x00, 0x04, 0x00, byte(len(data) * 8)}, data...))
+	}
+	tests := []struct {
+		name string
+		req  []byte
+		want byte // 应答数据部分的第一个字节：返回码
+	}{
+		{"read V", read(simItem(s7viewer.S7AreaDB, 1, 0, 2)), s7RetOK},
+		{"read DB2", read(simItem(s7viewer.S7AreaDB, 2, 0, 2)), s7RetNoObject},
+		{"read past end", read(simItem(s7viewer.S7AreaDB, 1, 16383, 2)), s7RetAddressError},
+		{"read unknown area", read(simItem(0x99, 0, 0, 1)), s7RetNoObject},
+		{"write V", write(simItem(s7viewer.S7AreaDB, 1, 0, 1), 0x55), s7RetOK},
+		{"write input", write(simItem(s7viewer.S7AreaPE, 0, 0, 1), 0x55), s7RetAccessDenied},
+		{"write length mismatch", write(simItem(s7viewer.S7AreaDB, 1, 0, 2), 0x55), s7RetAddressError},
+	}
+	for _, tt := range tests {
+		resp := sim.handle(tt.req)
+		if len(resp) < 22 || resp[8] != 0x03 {
+			t.Errorf("%s: handle() = % X, want ack data", tt.name, resp)
+			continue
+		}
+		if got := resp[21]; got != tt.want {
+			t.Errorf("%s: return code = %02X, want %02X", tt.name, got, tt.want)
+		}
+	}
+	if got := sim.bytes(s7viewer.S7AreaPE, 0, 1); got[0] != 0 {
+		t.Errorf("input area written: % X", got)
+	}
+}
+
+func TestSimulatorUnsupportedPDU(t *testing.T) {
+	sim, err := startSimulator("127.0.0.1:0", simPatternStatic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.close()
+
+	// 下载块（0x1A）模拟器不支持，应答错误类别和错误码而不是断开连接
+	resp := sim.handle(simJob([]byte{0x1A, 0x00}, nil))
+	if len(resp) < 19 || resp[8] != 0x03 || resp[17] != 0x81 || resp[18] != 0x04 {
+		t.Fatalf("handle(download) = % X, want ack with error 81 04", resp)
+	}
+	// 不是S7协议的数据无法应答
+	if resp := sim.handle([]byte{0x03, 0x00, 0x00, 0x0B, 0x02, 0xF0, 0x80, 0x00, 0x00, 0x00, 0x00}); resp != nil {
+		t.Errorf("handle(garbage) = % X, want nil", resp)
+	}
+}
+
+func TestSimulatorAnswersUnsupportedUserData(t *testing.T) {
+	sim, err := startSimulator("127.0.0.1:0", simPatternStatic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.close()
+
+	// 用户数据，功能组5（安全）子功能1：模拟器不支持，应答错误码而不是断开连接
+	req := []byte{0x03, 0x00, 0x00, 0x21, 0x02, 0xF0, 0x80,
+		0x32, 0x07, 0x00, 0x00, 0x00, 0x07, 0x00, 0x08, 0x00, 0x08,
+		0x00, 0x01, 0x12, 0x04, 0x11, 0x45, 0x01, 0x00,
+		0xFF, 0x09, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00}
+	resp := sim.handle(req)
+	if len(resp) < 29 || resp[8] != 0x07 {
+		t.Fatalf("handle() = % X, want userdata response", resp)
+	}
+	if code := int(resp[27])<<8 | int(resp[28]); code == 0 {
+		t.Errorf("error code = 0, want non-zero")
+	}
+}
+
+func TestSimulatorCPUInfo(t *testing.T) {
+	_, viewer := connectSimulator(t, simPatternStatic)
+	info, err := viewer.CPUInfo(context.Background())
+	if err != nil {
+		t.Fatalf("CPUInfo: %v", err)
+	}
+	want := s7viewer.CPUInfo{
+		OrderCode:    simOrderCode,
+		Firmware:     "V2.8.0",
+		ModuleType:   "CPU ST40 (DC/DC/DC)",
+		SerialNumber: "SIM-00000001",
+		ModuleName:   "CPU ST40",
+		ASName:       "S7-200 SMART",
+	}
+	if info != want {
+		t.Errorf("CPUInfo = %+v, want %+v", info, want)
+	}
+	// 读取SZL后连接仍可用
+	if _, err := viewer.ReadRange(context.Background(), "V", 0, 4); err != nil {
+		t.Fatalf("ReadRange after CPUInfo: %v", err)
+	}
+}
+
+func TestSimulatorSurvivesStatusPoll(t *testing.T) {
+	_, viewer := connectSimulator(t, simPatternStatic)
+	ctx := context.Background()
+
+	// 与CPU控制面板一样反复查询运行状态，连接不能被断开
+	for range 3 {
+		state, err := viewer.CPUState(ctx)
+		if err != nil {
+			t.Fatalf("CPUState: %v", err)
+		}
+		if state != s7viewer.CPUStateRun {
+			t.Fatalf("CPUState = %v, want RUN", state)
+		}
+	}
+	if _, err := viewer.ReadRange(ctx, "V", 0, 4); err != nil {
+		t.Fatalf("ReadRange after status poll: %v", err)
+	}
+
+	if err := viewer.SetCPUState(ctx, s7viewer.CPUStateStop); err != nil {
+		t.Fatalf("SetCPUState(STOP): %v", err)
+	}
+	if state, err := viewer.CPUState(ctx); err != nil || state != s7viewer.CPUStateStop {
+		t.Fatalf("CPUState after STOP = %v, %v", state, err)
+	}
+	if err := viewer.SetCPUState(ctx, s7viewer.CPUStateRun); err != nil {
+		t.Fatalf("SetCPUState(RUN): %v", err)
+	}
+	if state, err := viewer.CPUState(ctx); err != nil || state != s7viewer.CPUStateRun {
+		t.Fatalf("CPUState after RUN = %v, %v", state, err)
+	}
+}
+
+func TestSimulatorReadClock(t *testing.T) {
+	_, viewer := connectSimulator(t, simPatternStatic)
+	got, err := viewer.ReadClock(context.Background())
+	if err != nil {
+		t.Fatalf("ReadClock: %v", err)
+	}
+	if d := time.Since(got); d < -2*time.Second || d > 2*time.Second {
+		t.Errorf("ReadClock = %v, want about %v", got, time.Now())
+	}
+}
+
+func TestSimulatorSetClock(t *testing.T) {
+	_, viewer := connectSimulator(t, simPatternStatic)
+	ctx := context.Background()
+	set := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	if err := viewer.SetClock(ctx, set); err != nil {
+		t.Fatalf("SetClock: %v", err)
+	}
+	got, err := viewer.ReadClock(ctx)
+	if err != nil {
+		t.Fatalf("ReadClock: %v", err)
+	}
+	if d := got.Sub(set); d < 0 || d > 2*time.Second {
+		t.Errorf("ReadClock = %v, want about %v", got, set)
+	}
+}
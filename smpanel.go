@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// smReadBytes 特殊存储器视图读取的字节数，SMB0到SMB27覆盖下面所有条目
+const smReadBytes = 28
+
+// smRow 特殊存储器中一个常用的位或字：bit为-1时是从offset开始的字（毫秒值）
+type smRow struct {
+	offset, bit int
+	desc        string
+}
+
+// smRows S7-200 SMART常用的特殊存储器，含义见系统手册的“特殊存储器(SM)”一节
+var smRows = []smRow{
+	{0, 0, "始终为1"},
+	{0, 1, "首次扫描为1（只在第一个扫描周期接通，通信读取通常看不到）"},
+	{0, 2, "保持性数据丢失"},
+	{0, 3, "上电后进入RUN的第一个扫描周期为1"},
+	{0, 4, "1分钟时钟脉冲（30秒1、30秒0）"},
+	{0, 5, "1秒时钟脉冲（0.5秒1、0.5秒0）"},
+	{0, 6, "扫描周期时钟（每个扫描周期翻转）"},
+	{1, 0, "运算结果为0"},
+	{1, 1, "运算溢出或数值非法"},
+	{1, 2, "运算结果为负"},
+	{1, 3, "除数为0"},
+	{1, 4, "填表指令表已满"},
+	{1, 5, "LIFO/FIFO表为空"},
+	{1, 6, "BCD转二进制的数值非法"},
+	{1, 7, "ASCII转十六进制的字符非法"},
+	{4, 0, "通信中断队列溢出"},
+	{4, 1, "输入中断队列溢出"},
+	{4, 2, "定时中断队列溢出"},
+	{4, 3, "运行时编程错误"},
+	{4, 4, "全局中断已允许"},
+	{4, 5, "端口0发送器空闲"},
+	{4, 6, "端口1发送器空闲"},
+	{4, 7, "有强制的数据"},
+	{5, 0, "I/O错误"},
+	{22, -1, "上次扫描时间"},
+	{24, -1, "最短扫描时间"},
+	{26, -1, "最长扫描时间"},
+}
+
+// address 条目的地址，如 SM0.1、SMW22
+func (r smRow) address() string {
+	if r.bit < 0 {
+		return fmt.Sprintf("SMW%d", r.offset)
+	}
+	return fmt.Sprintf("SM%d.%d", r.offset, r.bit)
+}
+
+// value 从SMB0起的数据中取出条目的值，字按大端序解释为毫秒
+func (r smRow) value(data []byte) string {
+	if r.bit < 0 {
+		if r.offset+2 > len(data) {
+			return "-"
+		}
+		return fmt.Sprintf(tr("%d 毫秒"), binary.BigEndian.Uint16(data[r.offset:]))
+	}
+	if r.offset >= len(data) {
+		return "-"
+	}
+	if data[r.offset]>>r.bit&1 == 1 {
+		return "1"
+	}
+	return "0"
+}
+
+// newSMPanel 创建特殊存储器视图：读取SMB0起的字节，把常用的SM位和扫描时间译为文字说明
+func newSMPanel(getViewer func() (*s7viewer.Viewer, context.Context)) fyne.CanvasObject {
+	values := make([]*widget.Label, len(smRows))
+	grid := container.NewGridWithColumns(3,
+		widget.NewLabelWithStyle(tr("地址"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(tr("值"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(tr("含义"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	for i, r := range smRows {
+		values[i] = widget.NewLabel("-")
+		desc := widget.NewLabel(tr(r.desc))
+		desc.Wrapping = fyne.TextWrapWord
+		grid.Add(widget.NewLabel(r.address()))
+		grid.Add(values[i])
+		grid.Add(desc)
+	}
+	statusLabel := widget.NewLabel("")
+
+	show := func(data []byte, err error) {
+		if err != nil {
+			statusLabel.SetText(tr("读取失败: ") + err.Error())
+			return
+		}
+		for i, r := range smRows {
+			values[i].SetText(r.value(data))
+		}
+		statusLabel.SetText(tr("读取时间 ") + time.Now().Format("15:04:05"))
+	}
+	read := func(ctx context.Context, viewer *s7viewer.Viewer) {
+		data, err := viewer.ReadArea(ctx, s7viewer.AreaSM, 0, smReadBytes)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf(tr("读取特殊存储器失败: %v"), err)
+		}
+		fyne.Do(func() { show(data, err) })
+	}
+
+	readButton, autoCheck := newReadControls(getViewer, statusLabel, read)
+
+	return container.NewBorder(
+		container.NewHBox(readButton, autoCheck, statusLabel),
+		nil, nil, nil,
+		container.NewVScroll(grid),
+	)
+}
+
+// autoRefreshInterval 特殊存储器、模拟量等视图自动刷新的周期
+const autoRefreshInterval = time.Second
+
+// newReadControls 创建视图的“读取”按钮和“自动刷新”勾选框，read在后台协程中调用。
+// 自动刷新在取消勾选或连接断开时停止，未连接时在status中提示。
+func newReadControls(getViewer func() (*s7viewer.Viewer, context.Context), status *widget.Label,
+	read func(ctx context.Context, viewer *s7viewer.Viewer)) (*widget.Button, *widget.Check) {
+	readButton := widget.NewButton(tr("读取"), func() {
+		viewer, ctx := getViewer()
+		if viewer == nil || !viewer.IsConnected() {
+			status.SetText(tr("PLC未连接"))
+			return
+		}
+		go read(ctx, viewer)
+	})
+
+	var stopRefresh context.CancelFunc
+	autoCheck := widget.NewCheck(tr("自动刷新（1秒）"), nil)
+	autoCheck.OnChanged = func(on bool) {
+		if stopRefresh != nil {
+			stopRefresh()
+			stopRefresh = nil
+		}
+		if !on {
+			return
+		}
+		viewer, connCtx := getViewer()
+		if viewer == nil || !viewer.IsConnected() {
+			status.SetText(tr("PLC未连接"))
+			autoCheck.SetChecked(false)
+			return
+		}
+		ctx, cancel := context.WithCancel(connCtx)
+		stopRefresh = cancel
+		go func() {
+			ticker := time.NewTicker(autoRefreshInterval)
+			defer ticker.Stop()
+			for {
+				read(ctx, viewer)
+				select {
+				case <-ctx.Done():
+					if connCtx.Err() != nil {
+						fyne.Do(func() { autoCheck.SetChecked(false) })
+					}
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+	return readButton, autoCheck
+}
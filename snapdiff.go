@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// snapshotChange 两个快照之间变化的一个位、字或变量
+type snapshotChange struct {
+	Kind    string // 位/字/变量
+	Address string
+	Name    string // 位标签或变量名
+	Before  string
+	After   string
+}
+
+// snapshotDiff 两个快照的差异。ChangedBits为后快照数据中变化的位索引（网格顺序），用于高亮
+type snapshotDiff struct {
+	Changes     []snapshotChange
+	ChangedBits []int
+}
+
+// diffSnapshots 比较两个同一存储区的快照，只比较两者地址重叠的部分。
+// 位和字按地址对齐比较，字按后快照的字节顺序解码，变量按地址和类型匹配。
+func diffSnapshots(before, after snapshot) (snapshotDiff, error) {
+	var d snapshotDiff
+	if before.Area != after.Area {
+		return d, fmt.Errorf(tr("两个快照的存储区不同（%s / %s）"), before.Area, after.Area)
+	}
+	a, err := before.data()
+	if err != nil {
+		return d, err
+	}
+	b, err := after.data()
+	if err != nil {
+		return d, err
+	}
+
+	// T/C区的起始地址为编号，每个编号2字节
+	unit := 1
+	if s7viewer.IsCounterArea(after.Area) {
+		unit = 2
+	}
+	shift := (before.StartAddress - after.StartAddress) * unit // before[0] 在 after 中的字节偏移
+	from := max(0, shift)
+	to := min(len(b), shift+len(a))
+
+	labels := make(map[string]string)
+	for _, bit := range after.Bits {
+		if bit.Label != "" {
+			labels[bit.Address] = bit.Label
+		}
+	}
+
+	for i := from; i < to; i++ {
+		diff := a[i-shift] ^ b[i]
+		for bit := range 8 {
+			if diff&(0x80>>bit) == 0 {
+				continue
+			}
+			index := i*8 + bit
+			addr := bitAddressName(after.Area, after.StartAddress, index)
+			d.ChangedBits = append(d.ChangedBits, index)
+			d.Changes = append(d.Changes, snapshotChange{
+				Kind:    "位",
+				Address: addr,
+				Name:    labels[addr],
+				Before:  strconv.Itoa(int(a[i-shift]>>(7-bit)) & 1),
+				After:   strconv.Itoa(int(b[i]>>(7-bit)) & 1),
+			})
+		}
+	}
+
+	// 字按后快照起始地址的偶数偏移对齐
+	for i := from + from%2; i+1 < to; i += 2 {
+		wa := binary.BigEndian.Uint16(s7viewer.ToBigEndian(after.ByteOrder, a[i-shift:i-shift+2]))
+		wb := binary.BigEndian.Uint16(s7viewer.ToBigEndian(after.ByteOrder, b[i:i+2]))
+		if wa == wb {
+			continue
+		}
+		d.Changes = append(d.Changes, snapshotChange{
+			Kind:    "字",
+			Address: wordAddressName(after.Area, after.StartAddress, i),
+			Before:  fmt.Sprintf("%d (%04X)", wa, wa),
+			After:   fmt.Sprintf("%d (%04X)", wb, wb),
+		})
+	}
+
+	old := make(map[string]snapshotValue)
+	for _, v := range before.Values {
+		old[v.Address+"|"+v.Type] = v
+	}
+	for _, v := range after.Values {
+		prev, ok := old[v.Address+"|"+v.Type]
+		if !ok || prev.Value == v.Value {
+			continue
+		}
+		d.Changes = append(d.Changes, snapshotChange{Kind: "变量", Address: v.Address, Name: v.Name, Before: prev.Value, After: v.Value})
+	}
+	return d, nil
+}
+
+// wordAddressName 返回从start开始的数据中偏移off处的字地址，如 VW100；T/C区为编号
+func wordAddressName(area string, start, off int) string {
+	switch area {
+	case s7viewer.AreaT, s7viewer.AreaC:
+		return s7viewer.ByteAddressName(area, start+off/2)
+	case s7viewer.AreaAI, s7viewer.AreaAQ:
+		return s7viewer.ByteAddressName(area, start+off)
+	}
+	return fmt.Sprintf("%sW%d", area, start+off)
+}
+
+// readSnapshot 读取导出的快照文件，JSON和CSV格式均可
+func readSnapshot(r io.Reader) (snapshot, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return snapshot{}, fmt.Errorf(tr("读取快照失败: %v"), err)
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\uFEFF"))
+	if t := bytes.TrimSpace(raw); len(t) > 0 && t[0] == '{' {
+		var s snapshot
+		if err := json.Unmarshal(t, &s); err != nil {
+			return snapshot{}, fmt.Errorf(tr("解析快照失败: %v"), err)
+		}
+		return s, nil
+	}
+	return readSnapshotCSV(raw)
+}
+
+var (
+	snapshotTimePattern  = regexp.MustCompile(`^# 采集时间: (.+)$`)
+	snapshotPLCPattern   = regexp.MustCompile(`^# PLC: ([^,]*), 机架 (\d+), 槽位 (\d+), 端口 (\d+)$`)
+	snapshotRangePattern = regexp.MustCompile(`^# 起始地址: (\S+), 长度: (\d+)字节$`)
+	snapshotBytePattern  = regexp.MustCompile(`^(\d+)`)
+)
+
+// readSnapshotCSV 解析writeSnapshotCSV写出的文件
+func readSnapshotCSV(raw []byte) (snapshot, error) {
+	var s snapshot
+	var data []byte
+	var body []string
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !strings.HasPrefix(line, "#") {
+			body = append(body, line)
+			continue
+		}
+		if m := snapshotTimePattern.FindStringSubmatch(line); m != nil {
+			s.Time, _ = time.ParseInLocation("2006-01-02 15:04:05.000", m[1], time.Local)
+		} else if m := snapshotPLCPattern.FindStringSubmatch(line); m != nil {
+			s.PLC.IP = m[1]
+			s.PLC.Rack, _ = strconv.Atoi(m[2])
+			s.PLC.Slot, _ = strconv.Atoi(m[3])
+			s.PLC.Port, _ = strconv.Atoi(m[4])
+		} else if m := snapshotRangePattern.FindStringSubmatch(line); m != nil {
+			addr, err := parseAddress(m[1])
+			if err != nil {
+				return snapshot{}, fmt.Errorf(tr("快照起始地址无效: %v"), err)
+			}
+			s.Area, s.StartAddress = addr.area, addr.byteOff
+		} else if order, ok := strings.CutPrefix(line, "# 字节顺序: "); ok {
+			s.ByteOrder = order
+		} else if note, ok := strings.CutPrefix(line, "# 备注: "); ok {
+			s.Note = strings.TrimPrefix(s.Note+"\n"+note, "\n")
+		}
+	}
+	if s.Area == "" {
+		return snapshot{}, errors.New(tr("不是有效的快照文件：缺少起始地址"))
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.Join(body, "\n"))).ReadAll()
+	if err != nil {
+		return snapshot{}, fmt.Errorf(tr("解析快照失败: %v"), err)
+	}
+	for _, rec := range records {
+		if len(rec) < 5 {
+			continue
+		}
+		switch rec[0] {
+		case "字节":
+			m := snapshotBytePattern.FindStringSubmatch(rec[4])
+			if m == nil {
+				return snapshot{}, fmt.Errorf(tr("快照中 %s 的值无效: %q"), rec[1], rec[4])
+			}
+			v, _ := strconv.Atoi(m[1])
+			data = append(data, byte(v))
+		case "位":
+			v, _ := strconv.Atoi(rec[4])
+			s.Bits = append(s.Bits, snapshotBit{Address: rec[1], Value: v, Label: rec[2]})
+		case "变量":
+			s.Values = append(s.Values, snapshotValue{Address: rec[1], Name: rec[2], Type: rec[3], Value: rec[4]})
+		}
+	}
+	s.Length = len(data)
+	s.Hex = strings.ToUpper(hex.EncodeToString(data))
+	return s, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 快照对比网格最多显示的行数（每行4字节）
+const snapshotDiffMaxRows = 64
+
+var snapshotDiffHeaders = []string{"类别", "地址", "名称/标签", "前", "后"}
+
+// newSnapshotDiffPanel 创建快照对比面板：前后快照可从文件载入或记录当前读取的数据，
+// 对比后在网格中高亮变化的位，并在表格中列出变化的位、字和变量。
+// current返回由最近一次读取生成的快照，尚未读取时ok为false。
+func newSnapshotDiffPanel(myWindow fyne.Window, current func() (snapshot, bool)) fyne.CanvasObject {
+	var before, after *snapshot
+	var changes []snapshotChange
+
+	beforeLabel := widget.NewLabel(tr("前: 未载入"))
+	afterLabel := widget.NewLabel(tr("后: 未载入"))
+	summaryLabel := widget.NewLabel("")
+	gridBox := container.NewVBox()
+
+	table := widget.NewTable(
+		func() (int, int) { return len(changes), len(snapshotDiffHeaders) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			c := changes[id.Row]
+			o.(*widget.Label).SetText([]string{tr(c.Kind), c.Address, c.Name, c.Before, c.After}[id.Col])
+		})
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject { return widget.NewLabel("") }
+	table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 && id.Col < len(snapshotDiffHeaders) {
+			o.(*widget.Label).SetText(tr(snapshotDiffHeaders[id.Col]))
+		}
+	}
+	for col, width := range []float32{60, 110, 180, 140, 140} {
+		table.SetColumnWidth(col, width)
+	}
+
+	compare := func() {
+		if before == nil || after == nil {
+			return
+		}
+		d, err := diffSnapshots(*before, *after)
+		if err != nil {
+			summaryLabel.SetText(err.Error())
+			changes = nil
+			table.Refresh()
+			return
+		}
+		changes = d.Changes
+		table.Refresh()
+
+		bits, words, values := 0, 0, 0
+		for _, c := range changes {
+			switch c.Kind {
+			case "位":
+				bits++
+			case "字":
+				words++
+			default:
+				values++
+			}
+		}
+		summaryLabel.SetText(fmt.Sprintf(tr("位变化 %d 个，字变化 %d 个，变量变化 %d 个"), bits, words, values))
+
+		// 网格显示后快照的数据，变化的位加边框
+		data, _ := after.data()
+		rows := max(1, min(snapshotDiffMaxRows, (len(data)+3)/4))
+		grid := newBitGrid(32, rows, gridStyleSquare, 16, nil)
+		start, area := after.StartAddress, after.Area
+		grid.bitAddress = func(bitIndex int) string { return bitAddressName(area, start, bitIndex) }
+		grid.showBytes(data[:min(len(data), rows*4)])
+		grid.setMarked(d.ChangedBits)
+		gridBox.Objects = []fyne.CanvasObject{grid.content}
+		gridBox.Refresh()
+	}
+
+	set := func(target **snapshot, label *widget.Label, prefix string, s snapshot, source string) {
+		*target = &s
+		label.SetText(fmt.Sprintf(tr("%s: %s %s %s (%d字节)"), prefix, source, s.Time.Format("2006-01-02 15:04:05"),
+			s7viewer.ByteAddressName(s.Area, s.StartAddress), s.Length))
+		compare()
+	}
+	load := func(target **snapshot, label *widget.Label, prefix string) func() {
+		return func() {
+			dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+				if err != nil {
+					log.Printf(tr("载入快照失败: %v"), err)
+					return
+				}
+				if reader == nil {
+					return
+				}
+				defer reader.Close()
+				s, err := readSnapshot(reader)
+				if err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+				set(target, label, prefix, s, reader.URI().Name())
+			}, myWindow)
+		}
+	}
+	record := func(target **snapshot, label *widget.Label, prefix string) func() {
+		return func() {
+			s, ok := current()
+			if !ok {
+				log.Println(tr("没有可记录的数据，请先读取"))
+				return
+			}
+			set(target, label, prefix, s, tr("当前"))
+		}
+	}
+
+	return container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(
+				widget.NewButton(tr("载入前快照"), load(&before, beforeLabel, tr("前"))),
+				widget.NewButton(tr("记录当前为前"), record(&before, beforeLabel, tr("前"))),
+				beforeLabel,
+			),
+			container.NewHBox(
+				widget.NewButton(tr("载入后快照"), load(&after, afterLabel, tr("后"))),
+				widget.NewButton(tr("记录当前为后"), record(&after, afterLabel, tr("后"))),
+				afterLabel,
+			),
+			summaryLabel,
+		),
+		nil, nil, nil,
+		container.NewVSplit(container.NewScroll(gridBox), table),
+	)
+}
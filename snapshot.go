@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// snapshot 某一时刻的完整PLC状态：原始字节、各位状态、解码后的变量值和连接信息，
+// 可保存为JSON或CSV附在维修工单中
+type snapshot struct {
+	Time         time.Time       `json:"time"`
+	PLC          snapshotConn    `json:"plc"`
+	Area         string          `json:"area"`
+	StartAddress int             `json:"startAddress"`
+	Length       int             `json:"length"`
+	Hex          string          `json:"hex"`                 // 原始字节的十六进制文本
+	ByteOrder    string          `json:"byteOrder,omitempty"` // 采集时标签页的字节顺序，空表示大端
+	Bits         []snapshotBit   `json:"bits"`
+	Values       []snapshotValue `json:"values,omitempty"`
+	Note         string          `json:"note,omitempty"`
+}
+
+type snapshotConn struct {
+	IP   string       `json:"ip"`
+	Rack int          `json:"rack"`
+	Slot int          `json:"slot"`
+	Port int          `json:"port"`
+	CPU  *snapshotCPU `json:"cpu,omitempty"`
+}
+
+// snapshotCPU 采集时连接的CPU，连接后读到CPU信息时才有
+type snapshotCPU struct {
+	OrderCode    string `json:"orderCode"`
+	Firmware     string `json:"firmware"`
+	ModuleType   string `json:"moduleType,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	ModuleName   string `json:"moduleName,omitempty"`
+}
+
+func newSnapshotCPU(info *s7viewer.CPUInfo) *snapshotCPU {
+	if info == nil {
+		return nil
+	}
+	return &snapshotCPU{OrderCode: info.OrderCode, Firmware: info.Firmware, ModuleType: info.ModuleType,
+		SerialNumber: info.SerialNumber, ModuleName: info.ModuleName}
+}
+
+type snapshotBit struct {
+	Address string `json:"address"`
+	Value   int    `json:"value"`
+	Label   string `json:"label,omitempty"`
+}
+
+// snapshotValue 结构化视图或状态表中的一个变量
+type snapshotValue struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+}
+
+// newSnapshot 由一次读取的数据生成快照，labels为位标签（可为nil）
+func newSnapshot(c capture, conn s7viewer.Config, labels map[string]string, values []snapshotValue) snapshot {
+	s := snapshot{
+		Time:         c.Time,
+		PLC:          snapshotConn{IP: conn.IP, Rack: conn.Rack, Slot: conn.Slot, Port: conn.Port},
+		Area:         c.Area,
+		StartAddress: c.StartAddress,
+		Length:       len(c.Data),
+		Hex:          strings.ToUpper(hex.EncodeToString(c.Data)),
+		Values:       values,
+		Note:         c.Note,
+	}
+	for i := range len(c.Data) * 8 {
+		addr := bitAddressName(c.Area, c.StartAddress, i)
+		s.Bits = append(s.Bits, snapshotBit{
+			Address: addr,
+			Value:   int(c.Data[i/8]>>(7-i%8)) & 1,
+			Label:   labels[addr],
+		})
+	}
+	return s
+}
+
+// data 返回快照中的原始字节
+func (s snapshot) data() ([]byte, error) {
+	data, err := hex.DecodeString(s.Hex)
+	if err != nil {
+		return nil, fmt.Errorf(tr("快照中的原始数据无效: %v"), err)
+	}
+	return data, nil
+}
+
+// writeSnapshotJSON 将快照写为缩进的JSON
+func writeSnapshotJSON(w io.Writer, s snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// writeSnapshotCSV 将快照写为CSV：采集信息以#注释行写在文件头，
+// 数据行的类别列为 字节/位/变量
+func writeSnapshotCSV(w io.Writer, s snapshot) error {
+	header := []string{
+		"# 采集时间: " + s.Time.Format("2006-01-02 15:04:05.000"),
+		fmt.Sprintf("# PLC: %s, 机架 %d, 槽位 %d, 端口 %d", s.PLC.IP, s.PLC.Rack, s.PLC.Slot, s.PLC.Port),
+		fmt.Sprintf("# 起始地址: %s, 长度: %d字节", s7viewer.ByteAddressName(s.Area, s.StartAddress), s.Length),
+	}
+	if cpu := s.PLC.CPU; cpu != nil {
+		header = append(header, fmt.Sprintf("# CPU: %s %s, 序列号 %s, 模块名称 %s", cpu.OrderCode, cpu.Firmware, cpu.SerialNumber, cpu.ModuleName))
+	}
+	if s.ByteOrder != "" && s.ByteOrder != s7viewer.OrderBigEndian {
+		header = append(header, "# 字节顺序: "+s.ByteOrder)
+	}
+	if note := strings.TrimSpace(s.Note); note != "" {
+		for _, line := range strings.Split(note, "\n") {
+			header = append(header, "# 备注: "+strings.TrimRight(line, "\r"))
+		}
+	}
+	for _, line := range header {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	data, err := s.data()
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"类别", "地址", "名称", "类型", "值"})
+	for i, b := range data {
+		offset := s.StartAddress + i
+		if s7viewer.IsCounterArea(s.Area) {
+			offset = s.StartAddress + i/2
+		}
+		cw.Write([]string{"字节", s7viewer.ByteAddressName(s.Area, offset), "", s7viewer.TypeByte, fmt.Sprintf("%d (%02X, %08b)", b, b, b)})
+	}
+	for _, b := range s.Bits {
+		cw.Write([]string{"位", b.Address, b.Label, s7viewer.TypeBool, strconv.Itoa(b.Value)})
+	}
+	for _, v := range s.Values {
+		cw.Write([]string{"变量", v.Address, v.Name, v.Type, v.Value})
+	}
+	cw.Flush()
+	return cw.Error()
+}
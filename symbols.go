@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// symbolHeaderSearchLines 表头前可能有标题行（如表名），最多在前几行中查找表头
+const symbolHeaderSearchLines = 10
+
+// symbol 符号表中的一项
+type symbol struct {
+	Name    string
+	Addr    s7Address
+	Comment string
+}
+
+// parseSymbolTable 解析 STEP 7-Micro/WIN SMART 导出的符号表（CSV或制表符文本）。
+// 表头需含 符号/地址 列（中英文均可），注释列可省略；地址可带%前缀，支持所有存储区。
+// 无法识别的行不会中断解析，而是逐条记录在problems中。
+func parseSymbolTable(r io.Reader) (symbols []symbol, problems []string, err error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf(tr("读取符号表失败: %v"), err)
+	}
+
+	header := -1
+	var delim rune
+	var cols layoutColumns
+	for i := 0; i < len(lines) && i < symbolHeaderSearchLines; i++ {
+		if d, c, ok := detectLayoutHeader(lines[i]); ok && c.name >= 0 {
+			header, delim, cols = i, d, c
+			break
+		}
+	}
+	if header < 0 {
+		return nil, nil, errors.New(tr("未找到符号表表头（需要 符号 和 地址 列）"))
+	}
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for i, line := range lines[header+1:] {
+		lineNo := header + 2 + i
+		if strings.TrimSpace(strings.ReplaceAll(line, string(delim), "")) == "" {
+			continue
+		}
+
+		cr := csv.NewReader(strings.NewReader(line))
+		cr.Comma = delim
+		cr.LazyQuotes = true
+		cr.FieldsPerRecord = -1
+		record, err := cr.Read()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf(tr("第%d行: 无法解析: %v"), lineNo, err))
+			continue
+		}
+
+		name := field(record, cols.name)
+		address := strings.TrimPrefix(field(record, cols.address), "%")
+		if name == "" || address == "" {
+			problems = append(problems, fmt.Sprintf(tr("第%d行: 缺少符号或地址"), lineNo))
+			continue
+		}
+		addr, err := parseAddress(address)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf(tr("第%d行: %v"), lineNo, err))
+			continue
+		}
+		symbols = append(symbols, symbol{Name: name, Addr: addr, Comment: field(record, cols.comment)})
+	}
+	if len(symbols) == 0 {
+		return nil, problems, errors.New(tr("符号表中没有可用的符号"))
+	}
+	return symbols, problems, nil
+}
+
+// symbolLayoutEntries 将V区符号转换为结构化视图的变量定义，类型按地址宽度推断
+// （位为BOOL、VB为BYTE、VW为INT、VD为DINT）。其他存储区的符号不在结构化视图中显示。
+func symbolLayoutEntries(symbols []symbol) []layoutEntry {
+	var entries []layoutEntry
+	for _, s := range symbols {
+		if s.Addr.area != s7viewer.AreaV {
+			continue
+		}
+		entry, err := newLayoutEntry(s.Name, s.Addr.String(), "", "")
+		if err != nil {
+			continue
+		}
+		entry.Comment = s.Comment
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// symbolLabels 返回位地址符号对应的位标签，如 I0.0 -> 启动按钮
+func symbolLabels(symbols []symbol) map[string]string {
+	labels := make(map[string]string)
+	for _, s := range symbols {
+		if s.Addr.size == "" && !s7viewer.IsCounterArea(s.Addr.area) {
+			labels[s.Addr.String()] = s.Name
+		}
+	}
+	return labels
+}
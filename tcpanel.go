@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 定时器/计数器视图设置的偏好键
+const (
+	prefTCArea    = "tc.area"
+	prefTCStart   = "tc.start"
+	prefTCCount   = "tc.count"
+	prefTCPresets = "tc.presets" // 各定时器/计数器的预设值，每项为“编号=预设值”，如 T37=100
+)
+
+// maxTCCount 定时器/计数器视图一次最多显示的个数
+const maxTCCount = 64
+
+// timerResolution 返回S7-200 SMART定时器的时基：T0、T64、T32、T96为1毫秒，
+// T1~T4、T65~T68、T33~T36、T97~T100为10毫秒，其余为100毫秒
+func timerResolution(n int) time.Duration {
+	switch {
+	case n == 0 || n == 64 || n == 32 || n == 96:
+		return time.Millisecond
+	case n >= 1 && n <= 4, n >= 65 && n <= 68, n >= 33 && n <= 36, n >= 97 && n <= 100:
+		return 10 * time.Millisecond
+	}
+	return 100 * time.Millisecond
+}
+
+// tcRow 定时器/计数器视图中的一行
+type tcRow struct {
+	name        string // 如 T37、C10
+	number      int
+	valueLabel  *widget.Label
+	timeLabel   *widget.Label
+	presetEntry *widget.Entry
+	bitLabel    *widget.Label
+	value       int // 最近读到的当前值，-1表示尚未读取
+}
+
+// show 显示当前值、定时器的时间和状态位。通信读不到定时器/计数器位，
+// 填写了预设值时按“当前值≥预设值”推算（适用于TON、TONR和CTU）
+func (r *tcRow) show(value int) {
+	r.value = value
+	r.valueLabel.SetText(strconv.Itoa(value))
+	if strings.HasPrefix(r.name, s7viewer.AreaT) {
+		r.timeLabel.SetText((time.Duration(value) * timerResolution(r.number)).String())
+	}
+	preset, err := strconv.Atoi(strings.TrimSpace(r.presetEntry.Text))
+	switch {
+	case err != nil:
+		r.bitLabel.SetText("-")
+	case value >= preset:
+		r.bitLabel.SetText("1")
+	default:
+		r.bitLabel.SetText("0")
+	}
+}
+
+// newTCPanel 创建定时器/计数器视图：读取T或C区一段编号的当前值，显示定时时间和状态位，
+// 用于排查定时器预设值导致的顺序控制问题
+func newTCPanel(prefs fyne.Preferences, getViewer func() (*s7viewer.Viewer, context.Context)) fyne.CanvasObject {
+	areaSelect := widget.NewSelect([]string{s7viewer.AreaT, s7viewer.AreaC}, nil)
+	areaSelect.SetSelected(prefs.StringWithFallback(prefTCArea, s7viewer.AreaT))
+	startEntry := widget.NewEntry()
+	startEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefTCStart, 37)))
+	countEntry := widget.NewEntry()
+	countEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefTCCount, 8)))
+	statusLabel := widget.NewLabel("")
+
+	presets := make(map[string]string)
+	for _, item := range prefs.StringList(prefTCPresets) {
+		if name, value, ok := strings.Cut(item, "="); ok {
+			presets[name] = value
+		}
+	}
+	savePresets := func() {
+		var list []string
+		for name, value := range presets {
+			list = append(list, name+"="+value)
+		}
+		prefs.SetStringList(prefTCPresets, list)
+	}
+
+	// 当前显示的区域和行，只在UI线程中修改
+	var (
+		area  string
+		start int
+		rows  []*tcRow
+	)
+	table := container.NewVBox()
+
+	rebuild := func() {
+		n, err := strconv.Atoi(strings.TrimSpace(startEntry.Text))
+		count, cerr := strconv.Atoi(strings.TrimSpace(countEntry.Text))
+		if err != nil || cerr != nil || n < 0 || n > 255 || count <= 0 || count > maxTCCount || n+count > 256 {
+			statusLabel.SetText(fmt.Sprintf(tr("起始编号应为0到255，个数应为1到%d"), maxTCCount))
+			return
+		}
+		area, start = areaSelect.Selected, n
+		prefs.SetString(prefTCArea, area)
+		prefs.SetInt(prefTCStart, start)
+		prefs.SetInt(prefTCCount, count)
+
+		header := []fyne.CanvasObject{
+			widget.NewLabelWithStyle(tr("编号"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(tr("当前值"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(tr("定时时间"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(tr("预设值"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(tr("状态位"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		}
+		grid := container.NewGridWithColumns(len(header), header...)
+		rows = nil
+		for i := range count {
+			r := &tcRow{
+				name:        s7viewer.ByteAddressName(area, start+i),
+				number:      start + i,
+				valueLabel:  widget.NewLabel("-"),
+				timeLabel:   widget.NewLabel("-"),
+				presetEntry: widget.NewEntry(),
+				bitLabel:    widget.NewLabel("-"),
+				value:       -1,
+			}
+			if area == s7viewer.AreaT {
+				r.timeLabel.SetText(timerResolution(r.number).String() + tr(" 时基"))
+			} else {
+				r.timeLabel.SetText("")
+			}
+			r.presetEntry.SetPlaceHolder(tr("选填"))
+			r.presetEntry.SetText(presets[r.name])
+			r.presetEntry.OnChanged = func(text string) {
+				if text = strings.TrimSpace(text); text == "" {
+					delete(presets, r.name)
+				} else {
+					presets[r.name] = text
+				}
+				savePresets()
+				if r.value >= 0 {
+					r.show(r.value)
+				}
+			}
+			rows = append(rows, r)
+			grid.Add(widget.NewLabel(r.name))
+			grid.Add(r.valueLabel)
+			grid.Add(r.timeLabel)
+			grid.Add(r.presetEntry)
+			grid.Add(r.bitLabel)
+		}
+		table.Objects = []fyne.CanvasObject{grid}
+		table.Refresh()
+		statusLabel.SetText("")
+	}
+	rebuild()
+
+	// read 一次读取当前显示的所有编号，每个2字节
+	read := func(ctx context.Context, viewer *s7viewer.Viewer) {
+		var (
+			readArea  string
+			readStart int
+			current   []*tcRow
+		)
+		fyne.DoAndWait(func() { readArea, readStart, current = area, start, rows })
+		if len(current) == 0 {
+			return
+		}
+		data, err := viewer.ReadArea(ctx, readArea, readStart, len(current))
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf(tr("读取%s区失败: %v"), readArea, err)
+		}
+		fyne.Do(func() {
+			if err != nil {
+				statusLabel.SetText(tr("读取失败: ") + err.Error())
+				return
+			}
+			for i, r := range current {
+				if 2*i+2 <= len(data) {
+					r.show(int(int16(binary.BigEndian.Uint16(data[2*i:]))))
+				}
+			}
+			statusLabel.SetText(tr("读取时间 ") + time.Now().Format("15:04:05"))
+		})
+	}
+	readButton, autoCheck := newReadControls(getViewer, statusLabel, read)
+
+	return container.NewBorder(
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem(tr("存储区:"), container.NewGridWithColumns(6,
+					areaSelect, widget.NewLabel(tr("起始编号:")), startEntry,
+					widget.NewLabel(tr("个数:")), countEntry, widget.NewButton(tr("应用"), rebuild))),
+			),
+			container.NewHBox(readButton, autoCheck, statusLabel),
+			widget.NewLabel(tr("通信读不到定时器/计数器位，填写预设值后按“当前值≥预设值”推算状态位（TON、TONR、CTU）。")),
+		),
+		nil, nil, nil,
+		container.NewVScroll(table),
+	)
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 时序图的布局：左侧为位名称，每个位一行波形，底部为时间刻度
+const (
+	timingMarginLeft   = 140
+	timingMarginTop    = 22
+	timingMarginBottom = 18
+	timingRowHeight    = 30
+	timingHigh         = 6  // 高电平距离行顶部的距离
+	timingLow          = 24 // 低电平距离行顶部的距离
+	timingSnap         = 6  // 光标吸附到边沿的像素距离
+	timingMaxTraces    = 16
+	timingMaxChanges   = 100000 // 每个位保留的最大变化数，超出时丢弃最早的
+)
+
+// 时序图可选的时间跨度范围
+const (
+	timingMinSpan     = 10 * time.Millisecond
+	timingMaxSpan     = time.Hour
+	timingDefaultSpan = 10 * time.Second
+)
+
+type timingChange struct {
+	t time.Time
+	v bool
+}
+
+// timingTrace 一个位的波形：第一次采样的值和之后的每次变化
+type timingTrace struct {
+	addr    s7Address
+	name    string
+	changes []timingChange
+}
+
+// timingChart 逻辑分析仪式的时序图：每个位一行波形，由扫描历史绘制。
+// 滚轮缩放，拖动平移，左键和右键分别放置光标A和B（靠近边沿时吸附），显示两光标的时间差。
+type timingChart struct {
+	widget.BaseWidget
+
+	mu     sync.Mutex
+	traces []*timingTrace
+	span   time.Duration
+	// follow 为true时时间轴终点跟随最新采样，否则停在end
+	follow bool
+	end    time.Time
+	latest time.Time
+	// 光标A、B的时刻，零值表示未放置
+	cursorA, cursorB time.Time
+	labelOf          func(addr string) string
+}
+
+func newTimingChart() *timingChart {
+	c := &timingChart{span: timingDefaultSpan, follow: true}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// setBits 替换要显示的位，已有采样清空
+func (c *timingChart) setBits(bits []s7Address) {
+	c.mu.Lock()
+	c.traces = nil
+	for _, b := range bits {
+		c.traces = append(c.traces, &timingTrace{addr: b, name: b.String()})
+	}
+	c.cursorA, c.cursorB = time.Time{}, time.Time{}
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// clear 清空采样和光标
+func (c *timingChart) clear() {
+	c.mu.Lock()
+	for _, tr := range c.traces {
+		tr.changes = nil
+	}
+	c.cursorA, c.cursorB = time.Time{}, time.Time{}
+	c.latest = time.Time{}
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// addSample 从一次读取结果中取出各位的值，只记录变化。时间倒退（回放时向前跳转）时清空已有采样。
+func (c *timingChart) addSample(t time.Time, area string, start int, data []byte) {
+	c.mu.Lock()
+	backwards := t.Before(c.latest)
+	c.latest = t
+	for _, tr := range c.traces {
+		if backwards {
+			tr.changes = nil
+		}
+		off := tr.addr.byteOff - start
+		if tr.addr.area != area || off < 0 || off >= len(data) {
+			continue
+		}
+		v := (data[off]>>tr.addr.bit)&1 == 1
+		if n := len(tr.changes); n == 0 || tr.changes[n-1].v != v {
+			tr.changes = append(tr.changes, timingChange{t, v})
+			if len(tr.changes) > timingMaxChanges {
+				tr.changes = append([]timingChange(nil), tr.changes[len(tr.changes)-timingMaxChanges/2:]...)
+			}
+		}
+	}
+	follow := c.follow
+	c.mu.Unlock()
+	if follow {
+		c.Refresh()
+	}
+}
+
+// zoom 按factor缩放时间跨度（<1为放大），at为保持不动的横坐标，<0时以中点为准
+func (c *timingChart) zoom(factor float64, at float32) {
+	c.mu.Lock()
+	plotW := c.Size().Width - timingMarginLeft
+	end := c.viewEnd()
+	frac := 0.5
+	if at >= timingMarginLeft && plotW > 0 {
+		frac = float64((at - timingMarginLeft) / plotW)
+	}
+	pivot := end.Add(-time.Duration((1 - frac) * float64(c.span)))
+	span := time.Duration(float64(c.span) * factor)
+	span = min(max(span, timingMinSpan), timingMaxSpan)
+	c.span = span
+	if !c.follow {
+		c.end = pivot.Add(time.Duration((1 - frac) * float64(span)))
+	}
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// setFollow 为true时回到最新采样并跟随
+func (c *timingChart) setFollow(follow bool) {
+	c.mu.Lock()
+	c.end = c.viewEnd()
+	c.follow = follow
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// clearCursors 移除光标A和B
+func (c *timingChart) clearCursors() {
+	c.mu.Lock()
+	c.cursorA, c.cursorB = time.Time{}, time.Time{}
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// viewEnd 返回时间轴的终点。调用方需持有c.mu
+func (c *timingChart) viewEnd() time.Time {
+	if !c.follow {
+		return c.end
+	}
+	if !c.latest.IsZero() && time.Since(c.latest) > trendReplayLag {
+		return c.latest
+	}
+	return time.Now()
+}
+
+func (c *timingChart) Scrolled(e *fyne.ScrollEvent) {
+	factor := 0.8
+	if e.Scrolled.DY < 0 {
+		factor = 1 / factor
+	}
+	c.zoom(factor, e.Position.X)
+}
+
+func (c *timingChart) Dragged(e *fyne.DragEvent) {
+	c.mu.Lock()
+	plotW := c.Size().Width - timingMarginLeft
+	if plotW > 0 {
+		c.end = c.viewEnd().Add(-time.Duration(float64(e.Dragged.DX) / float64(plotW) * float64(c.span)))
+		c.follow = false
+	}
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *timingChart) DragEnd() {}
+
+func (c *timingChart) Tapped(e *fyne.PointEvent) {
+	c.placeCursor(e.Position, false)
+}
+
+func (c *timingChart) TappedSecondary(e *fyne.PointEvent) {
+	c.placeCursor(e.Position, true)
+}
+
+// placeCursor 在pos处放置光标A或B，附近有边沿时吸附到边沿
+func (c *timingChart) placeCursor(pos fyne.Position, b bool) {
+	c.mu.Lock()
+	size := c.Size()
+	plotW := size.Width - timingMarginLeft
+	if pos.X < timingMarginLeft || plotW <= 0 {
+		c.mu.Unlock()
+		return
+	}
+	end := c.viewEnd()
+	begin := end.Add(-c.span)
+	perPixel := float64(c.span) / float64(plotW)
+	t := begin.Add(time.Duration(float64(pos.X-timingMarginLeft) * perPixel))
+
+	// 优先吸附到光标所在行的边沿，不在任何行上时吸附到所有行中最近的边沿
+	best, bestDist := t, math.Inf(1)
+	row := int((pos.Y - timingMarginTop) / timingRowHeight)
+	for i, tr := range c.traces {
+		if row >= 0 && row < len(c.traces) && i != row {
+			continue
+		}
+		for _, ch := range tr.changes[min(1, len(tr.changes)):] {
+			if d := math.Abs(float64(ch.t.Sub(t))) / perPixel; d <= timingSnap && d < bestDist {
+				best, bestDist = ch.t, d
+			}
+		}
+	}
+	if b {
+		c.cursorB = best
+	} else {
+		c.cursorA = best
+	}
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *timingChart) CreateRenderer() fyne.WidgetRenderer {
+	bg := canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))
+	return &timingRenderer{chart: c, bg: bg}
+}
+
+type timingRenderer struct {
+	chart   *timingChart
+	bg      *canvas.Rectangle
+	objects []fyne.CanvasObject
+}
+
+func (r *timingRenderer) Layout(size fyne.Size) { r.build(size) }
+
+func (r *timingRenderer) MinSize() fyne.Size {
+	r.chart.mu.Lock()
+	n := len(r.chart.traces)
+	r.chart.mu.Unlock()
+	return fyne.NewSize(400, timingMarginTop+timingMarginBottom+float32(max(n, 1))*timingRowHeight)
+}
+
+func (r *timingRenderer) Refresh() {
+	r.build(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *timingRenderer) Objects() []fyne.CanvasObject { return r.objects }
+
+func (r *timingRenderer) Destroy() {}
+
+// build 按当前尺寸重新生成背景、波形、时间刻度和光标
+func (r *timingRenderer) build(size fyne.Size) {
+	c := r.chart
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r.bg.Move(fyne.NewPos(timingMarginLeft, 0))
+	r.bg.Resize(fyne.NewSize(size.Width-timingMarginLeft, size.Height-timingMarginBottom))
+	objects := []fyne.CanvasObject{r.bg}
+
+	plotW := size.Width - timingMarginLeft
+	if plotW <= 0 {
+		r.objects = objects
+		return
+	}
+	end := c.viewEnd()
+	begin := end.Add(-c.span)
+	x := func(t time.Time) float32 {
+		return timingMarginLeft + float32(float64(t.Sub(begin))/float64(c.span))*plotW
+	}
+	clampX := func(t time.Time) float32 {
+		return min(max(x(t), timingMarginLeft), size.Width)
+	}
+
+	fg := theme.Color(theme.ColorNameForeground)
+	sep := theme.Color(theme.ColorNameSeparator)
+	for i, tr := range c.traces {
+		top := timingMarginTop + float32(i)*timingRowHeight
+		name := tr.name
+		if c.labelOf != nil {
+			if label := c.labelOf(tr.name); label != "" {
+				name += " " + label
+			}
+		}
+		text := canvas.NewText(name, fg)
+		text.TextSize = 11
+		text.Move(fyne.NewPos(4, top+8))
+		divider := canvas.NewLine(sep)
+		divider.Position1 = fyne.NewPos(0, top+timingRowHeight)
+		divider.Position2 = fyne.NewPos(size.Width, top+timingRowHeight)
+		objects = append(objects, text, divider)
+
+		col := trendColors[i%len(trendColors)]
+		level := func(v bool) float32 {
+			if v {
+				return top + timingHigh
+			}
+			return top + timingLow
+		}
+		// 每段从一次变化持续到下一次变化（最后一段到最新采样），同一像素内的多次变化画成竖线
+		for j, ch := range tr.changes {
+			segEnd := c.latest
+			if j+1 < len(tr.changes) {
+				segEnd = tr.changes[j+1].t
+			}
+			if segEnd.Before(begin) || ch.t.After(end) {
+				continue
+			}
+			x1, x2 := clampX(ch.t), clampX(segEnd)
+			line := canvas.NewLine(col)
+			line.StrokeWidth = 2
+			line.Position1 = fyne.NewPos(x1, level(ch.v))
+			line.Position2 = fyne.NewPos(max(x2, x1+1), level(ch.v))
+			objects = append(objects, line)
+			if j > 0 && !ch.t.Before(begin) {
+				edge := canvas.NewLine(col)
+				edge.StrokeWidth = 2
+				edge.Position1 = fyne.NewPos(x1, top+timingHigh)
+				edge.Position2 = fyne.NewPos(x1, top+timingLow)
+				objects = append(objects, edge)
+			}
+		}
+	}
+
+	// 时间刻度：起止时间和跨度
+	for i, t := range []time.Time{begin, end} {
+		label := canvas.NewText(t.Format("15:04:05.000"), fg)
+		label.TextSize = 10
+		if i == 0 {
+			label.Move(fyne.NewPos(timingMarginLeft, size.Height-timingMarginBottom+2))
+		} else {
+			label.Move(fyne.NewPos(size.Width-label.MinSize().Width, size.Height-timingMarginBottom+2))
+		}
+		objects = append(objects, label)
+	}
+	spanLabel := canvas.NewText(fmt.Sprintf(tr("跨度 %s"), formatTimingDelta(c.span)), fg)
+	spanLabel.TextSize = 10
+	spanLabel.Move(fyne.NewPos(timingMarginLeft+(plotW-spanLabel.MinSize().Width)/2, size.Height-timingMarginBottom+2))
+	objects = append(objects, spanLabel)
+
+	// 光标和读数
+	readout := ""
+	for _, cur := range []struct {
+		name string
+		t    time.Time
+		col  color.Color
+	}{{"A", c.cursorA, theme.Color(theme.ColorNamePrimary)}, {"B", c.cursorB, theme.Color(theme.ColorNameError)}} {
+		if cur.t.IsZero() {
+			continue
+		}
+		readout += fmt.Sprintf("%s %s   ", cur.name, cur.t.Format("15:04:05.000"))
+		if cur.t.Before(begin) || cur.t.After(end) {
+			continue
+		}
+		line := canvas.NewLine(cur.col)
+		line.StrokeWidth = 1.5
+		line.Position1 = fyne.NewPos(x(cur.t), timingMarginTop)
+		line.Position2 = fyne.NewPos(x(cur.t), size.Height-timingMarginBottom)
+		name := canvas.NewText(cur.name, cur.col)
+		name.TextSize = 11
+		name.TextStyle.Bold = true
+		name.Move(fyne.NewPos(x(cur.t)+3, timingMarginTop-16))
+		objects = append(objects, line, name)
+	}
+	if !c.cursorA.IsZero() && !c.cursorB.IsZero() {
+		d := c.cursorB.Sub(c.cursorA)
+		readout += "Δ " + formatTimingDelta(d)
+		if d != 0 {
+			readout += fmt.Sprintf(" (%.3g Hz)", 1/math.Abs(d.Seconds()))
+		}
+	}
+	if readout != "" {
+		text := canvas.NewText(readout, fg)
+		text.TextSize = 11
+		text.TextStyle.Bold = true
+		text.Move(fyne.NewPos(4, 2))
+		objects = append(objects, text)
+	}
+
+	r.objects = objects
+}
+
+// formatTimingDelta 格式化时间差，按大小选择ms或s
+func formatTimingDelta(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%s%.1f ms", sign, float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%s%.3f s", sign, d.Seconds())
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// prefTimingBits 时序图显示的位的偏好键
+const prefTimingBits = "timing.bits"
+
+// newTimingPanel 创建时序图面板。add在UI线程中以每次读取的结果及其采集时间调用，
+// labelOf返回位地址的标签，显示在位名称后。
+func newTimingPanel(prefs fyne.Preferences, labelOf func(addr string) string) (content fyne.CanvasObject, add func(t time.Time, area string, start int, data []byte)) {
+	chart := newTimingChart()
+	chart.labelOf = labelOf
+
+	bitsEntry := widget.NewEntry()
+	bitsEntry.SetPlaceHolder(tr("如 V100.0, V100.3, M0.1"))
+	bitsEntry.SetText(prefs.String(prefTimingBits))
+	statusLabel := widget.NewLabel(tr("滚轮缩放，拖动平移；左键放置光标A，右键放置光标B，靠近边沿时自动吸附"))
+
+	apply := func() {
+		bits, err := parseBitList(bitsEntry.Text)
+		if err == nil && len(bits) > timingMaxTraces {
+			err = fmt.Errorf(tr("最多同时显示%d个位"), timingMaxTraces)
+		}
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		prefs.SetString(prefTimingBits, strings.TrimSpace(bitsEntry.Text))
+		chart.setBits(bits)
+	}
+	bitsEntry.OnSubmitted = func(string) { apply() }
+	applyButton := widget.NewButton(tr("应用"), apply)
+
+	zoomInButton := widget.NewButton(tr("放大"), func() { chart.zoom(0.5, -1) })
+	zoomOutButton := widget.NewButton(tr("缩小"), func() { chart.zoom(2, -1) })
+	latestButton := widget.NewButton(tr("最新"), func() { chart.setFollow(true) })
+	clearCursorsButton := widget.NewButton(tr("清除光标"), chart.clearCursors)
+	clearButton := widget.NewButton(tr("清空"), chart.clear)
+
+	if bitsEntry.Text != "" {
+		apply()
+	}
+
+	content = container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel(tr("位:")),
+			container.NewHBox(applyButton, zoomInButton, zoomOutButton, latestButton, clearCursorsButton, clearButton),
+			bitsEntry),
+		statusLabel, nil, nil,
+		container.NewVScroll(chart),
+	)
+	return content, chart.addSample
+}
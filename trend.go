@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 趋势图时间窗口
+var trendWindows = map[string]time.Duration{
+	"1分钟":  time.Minute,
+	"5分钟":  5 * time.Minute,
+	"15分钟": 15 * time.Minute,
+	"30分钟": 30 * time.Minute,
+	"60分钟": 60 * time.Minute,
+}
+
+var trendWindowNames = []string{"1分钟", "5分钟", "15分钟", "30分钟", "60分钟"}
+
+// 各曲线依次使用的颜色
+var trendColors = []color.Color{
+	color.RGBA{R: 0, G: 200, B: 0, A: 255},
+	color.RGBA{R: 30, G: 144, B: 255, A: 255},
+	color.RGBA{R: 255, G: 140, B: 0, A: 255},
+	color.RGBA{R: 220, G: 20, B: 60, A: 255},
+	color.RGBA{R: 186, G: 85, B: 211, A: 255},
+	color.RGBA{R: 0, G: 206, B: 209, A: 255},
+}
+
+// trendReplayLag 最新采样早于当前时间超过该值时视为回放，时间轴终点跟随采样时间
+const trendReplayLag = 10 * time.Second
+
+// 绘图区边距：左侧留给纵轴刻度，底部留给时间刻度
+const (
+	trendMarginLeft   = 64
+	trendMarginBottom = 18
+	trendMarginTop    = 18
+)
+
+type trendPoint struct {
+	t time.Time
+	v float64
+}
+
+// trendSeries 一条曲线：一个VW/VD/VR变量及其采样
+type trendSeries struct {
+	addr     s7Address
+	dataType string
+	computed string // 非空时为计算变量，采样为其最近一次的计算结果
+	name     string
+	unit     string // 设置了工程单位换算时的单位，采样为工程值
+	points   []trendPoint
+}
+
+// parseTrendSeries 解析逗号或空格分隔的变量列表，如 "VW100, VD200, VR300, flow"，flow为计算变量
+func parseTrendSeries(text string) ([]*trendSeries, error) {
+	var series []*trendSeries
+	computed := currentComputed()
+	for _, f := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '，' || r == ' ' || r == ';' }) {
+		addr, dataType, err := parseWriteAddress(f)
+		if err != nil {
+			if computed != nil && computed.has(f) {
+				series = append(series, &trendSeries{computed: f, name: f})
+				continue
+			}
+			return nil, err
+		}
+		series = append(series, &trendSeries{addr: addr, dataType: dataType, name: strings.ToUpper(f)})
+	}
+	if len(series) > len(trendColors) {
+		return nil, fmt.Errorf(tr("最多同时显示%d个变量"), len(trendColors))
+	}
+	return series, nil
+}
+
+// sample 从一次读取中按order的字节顺序取该变量的值，设置了换算时为工程值；计算变量取最近一次的计算结果
+func (s *trendSeries) sample(order string, start int, data []byte) (float64, bool) {
+	if s.computed != "" {
+		return computedValue(s.computed)
+	}
+	v, err := s7viewer.DecodeTyped(order, s.dataType, data, s.addr.byteOff-start, 0, 0)
+	if err != nil {
+		return 0, false
+	}
+	f, _ := metricValue(v)
+	s.unit = ""
+	if sc, ok := euScaleOf(s.addr.String()); ok {
+		f, s.unit = sc.apply(f), sc.unit
+	}
+	return f, true
+}
+
+// trendChart 基于canvas绘制的时间趋势图，纵轴自动缩放，可暂停，鼠标悬停显示读数
+type trendChart struct {
+	widget.BaseWidget
+
+	mu     sync.Mutex
+	series []*trendSeries
+	window time.Duration
+	paused bool
+	frozen time.Time // 暂停时刻，暂停期间时间轴停在这里
+	cursor float32   // 鼠标横坐标，<0表示不在图内
+	// latest 最新采样的时间。回放录制时采样时间早于当前时间，时间轴以它为终点
+	latest time.Time
+}
+
+func newTrendChart() *trendChart {
+	c := &trendChart{window: 5 * time.Minute, cursor: -1}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// setSeries 替换要绘制的变量，已有采样清空
+func (c *trendChart) setSeries(series []*trendSeries) {
+	c.mu.Lock()
+	c.series = series
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *trendChart) setWindow(d time.Duration) {
+	c.mu.Lock()
+	c.window = d
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// setPaused 暂停时继续采样，但画面停在暂停时刻，便于观察
+func (c *trendChart) setPaused(paused bool) {
+	c.mu.Lock()
+	c.paused = paused
+	c.frozen = c.now()
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// addSample 从一次V区读取结果中取出各变量的值，超出最大窗口的旧采样被丢弃。
+// 时间倒退（回放时向前跳转）时清空已有采样。
+func (c *trendChart) addSample(t time.Time, order string, start int, data []byte) {
+	c.mu.Lock()
+	keep := t.Add(-trendWindows[trendWindowNames[len(trendWindowNames)-1]])
+	backwards := t.Before(c.latest)
+	c.latest = t
+	for _, s := range c.series {
+		if backwards {
+			s.points = nil
+		}
+		f, ok := s.sample(order, start, data)
+		if !ok {
+			continue
+		}
+		s.points = append(s.points, trendPoint{t, f})
+		drop := 0
+		for drop < len(s.points) && s.points[drop].t.Before(keep) {
+			drop++
+		}
+		s.points = s.points[drop:]
+	}
+	paused := c.paused
+	c.mu.Unlock()
+	if !paused {
+		c.Refresh()
+	}
+}
+
+// now 返回时间轴的终点：通常为当前时间，回放时为最新采样时间。调用方需持有c.mu
+func (c *trendChart) now() time.Time {
+	if !c.latest.IsZero() && time.Since(c.latest) > trendReplayLag {
+		return c.latest
+	}
+	return time.Now()
+}
+
+func (c *trendChart) MouseIn(e *desktop.MouseEvent) { c.MouseMoved(e) }
+
+func (c *trendChart) MouseMoved(e *desktop.MouseEvent) {
+	c.mu.Lock()
+	c.cursor = e.Position.X
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *trendChart) MouseOut() {
+	c.mu.Lock()
+	c.cursor = -1
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+func (c *trendChart) CreateRenderer() fyne.WidgetRenderer {
+	bg := canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))
+	return &trendRenderer{chart: c, bg: bg}
+}
+
+type trendRenderer struct {
+	chart   *trendChart
+	bg      *canvas.Rectangle
+	objects []fyne.CanvasObject
+}
+
+func (r *trendRenderer) Layout(size fyne.Size) { r.build(size) }
+
+func (r *trendRenderer) MinSize() fyne.Size { return fyne.NewSize(300, 200) }
+
+func (r *trendRenderer) Refresh() {
+	r.build(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *trendRenderer) Objects() []fyne.CanvasObject { return r.objects }
+
+func (r *trendRenderer) Destroy() {}
+
+// build 按当前尺寸重新生成背景、刻度、曲线、图例和光标读数
+func (r *trendRenderer) build(size fyne.Size) {
+	c := r.chart
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r.bg.Move(fyne.NewPos(trendMarginLeft, 0))
+	r.bg.Resize(fyne.NewSize(size.Width-trendMarginLeft, size.Height-trendMarginBottom))
+	objects := []fyne.CanvasObject{r.bg}
+
+	end := c.now()
+	if c.paused {
+		end = c.frozen
+	}
+	begin := end.Add(-c.window)
+	plotW := size.Width - trendMarginLeft
+	plotH := size.Height - trendMarginBottom - trendMarginTop
+	if plotW <= 0 || plotH <= 0 {
+		r.objects = objects
+		return
+	}
+
+	// 纵轴按窗口内的最小/最大值自动缩放，上下各留5%
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range c.series {
+		for _, p := range s.points {
+			if p.t.Before(begin) || p.t.After(end) {
+				continue
+			}
+			lo, hi = math.Min(lo, p.v), math.Max(hi, p.v)
+		}
+	}
+	if math.IsInf(lo, 0) {
+		lo, hi = 0, 1
+	}
+	if hi == lo {
+		lo, hi = lo-1, hi+1
+	}
+	pad := (hi - lo) * 0.05
+	lo, hi = lo-pad, hi+pad
+
+	x := func(t time.Time) float32 {
+		return trendMarginLeft + float32(t.Sub(begin).Seconds()/c.window.Seconds())*plotW
+	}
+	y := func(v float64) float32 {
+		return trendMarginTop + float32((hi-v)/(hi-lo))*plotH
+	}
+
+	// 刻度：纵轴上中下三档，横轴标出起止时间
+	fg := theme.Color(theme.ColorNameForeground)
+	for _, v := range []float64{hi, (hi + lo) / 2, lo} {
+		label := canvas.NewText(strconv.FormatFloat(v, 'g', 5, 64), fg)
+		label.TextSize = 10
+		label.Move(fyne.NewPos(2, y(v)-7))
+		grid := canvas.NewLine(theme.Color(theme.ColorNameSeparator))
+		grid.Position1 = fyne.NewPos(trendMarginLeft, y(v))
+		grid.Position2 = fyne.NewPos(size.Width, y(v))
+		objects = append(objects, grid, label)
+	}
+	for i, t := range []time.Time{begin, end} {
+		label := canvas.NewText(t.Format("15:04:05"), fg)
+		label.TextSize = 10
+		if i == 0 {
+			label.Move(fyne.NewPos(trendMarginLeft, size.Height-trendMarginBottom+2))
+		} else {
+			label.Alignment = fyne.TextAlignTrailing
+			label.Move(fyne.NewPos(size.Width-label.MinSize().Width, size.Height-trendMarginBottom+2))
+		}
+		objects = append(objects, label)
+	}
+
+	// 曲线和图例
+	legendX := float32(trendMarginLeft + 4)
+	for i, s := range c.series {
+		col := trendColors[i%len(trendColors)]
+		var prev *trendPoint
+		for j := range s.points {
+			p := &s.points[j]
+			if p.t.Before(begin) || p.t.After(end) {
+				continue
+			}
+			// 同一像素列内的采样只画第一个，长窗口下避免生成过多线段
+			if prev != nil && x(p.t)-x(prev.t) < 1 && j < len(s.points)-1 {
+				continue
+			}
+			if prev != nil {
+				line := canvas.NewLine(col)
+				line.StrokeWidth = 1.5
+				line.Position1 = fyne.NewPos(x(prev.t), y(prev.v))
+				line.Position2 = fyne.NewPos(x(p.t), y(p.v))
+				objects = append(objects, line)
+			}
+			prev = p
+		}
+		name := s.name
+		if s.unit != "" {
+			name += " [" + s.unit + "]"
+		}
+		legend := canvas.NewText(name, col)
+		legend.TextSize = 11
+		legend.Move(fyne.NewPos(legendX, 2))
+		legendX += legend.MinSize().Width + 12
+		objects = append(objects, legend)
+	}
+
+	// 光标：竖线和各曲线在该时刻的读数
+	if c.cursor >= trendMarginLeft && c.cursor <= size.Width {
+		t := begin.Add(time.Duration(float64(c.cursor-trendMarginLeft) / float64(plotW) * float64(c.window)))
+		line := canvas.NewLine(fg)
+		line.Position1 = fyne.NewPos(c.cursor, trendMarginTop)
+		line.Position2 = fyne.NewPos(c.cursor, trendMarginTop+plotH)
+		objects = append(objects, line)
+
+		parts := []string{t.Format("15:04:05")}
+		for _, s := range c.series {
+			if v, ok := valueAt(s.points, t); ok {
+				parts = append(parts, fmt.Sprintf("%s=%s", s.name, strconv.FormatFloat(v, 'g', 6, 64)))
+			}
+		}
+		readout := canvas.NewText(strings.Join(parts, "  "), fg)
+		readout.TextSize = 11
+		readout.TextStyle.Bold = true
+		px := c.cursor + 6
+		if w := readout.MinSize().Width; px+w > size.Width {
+			px = c.cursor - 6 - w
+		}
+		readout.Move(fyne.NewPos(px, trendMarginTop+2))
+		objects = append(objects, readout)
+	}
+
+	r.objects = objects
+}
+
+// valueAt 返回t时刻（含）之前最近一次采样的值
+func valueAt(points []trendPoint, t time.Time) (float64, bool) {
+	for i := len(points) - 1; i >= 0; i-- {
+		if !points[i].t.After(t) {
+			return points[i].v, true
+		}
+	}
+	return 0, false
+}
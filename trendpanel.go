@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 趋势图设置的偏好键
+const (
+	prefTrendVars   = "trend.vars"
+	prefTrendWindow = "trend.window"
+)
+
+// newTrendPanel 创建趋势图面板。add在UI线程中以每次V区读取的结果、采集时间和字节顺序调用，
+// 变量不在读取范围内时该曲线本次没有采样。
+func newTrendPanel(prefs fyne.Preferences) (content fyne.CanvasObject, add func(t time.Time, order, area string, start int, data []byte)) {
+	chart := newTrendChart()
+
+	varsEntry := widget.NewEntry()
+	varsEntry.SetPlaceHolder(tr("如 VW100, VD200, VR300 或计算变量名"))
+	varsEntry.SetText(prefs.String(prefTrendVars))
+	statusLabel := widget.NewLabel("")
+
+	apply := func() {
+		series, err := parseTrendSeries(varsEntry.Text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		statusLabel.SetText("")
+		prefs.SetString(prefTrendVars, strings.TrimSpace(varsEntry.Text))
+		chart.setSeries(series)
+	}
+	varsEntry.OnSubmitted = func(string) { apply() }
+	applyButton := widget.NewButton(tr("应用"), apply)
+
+	windowSelect := newTrSelect(trendWindowNames, func(name string) {
+		prefs.SetString(prefTrendWindow, name)
+		chart.setWindow(trendWindows[name])
+	})
+	windowSelect.SetSelected(tr(prefs.StringWithFallback(prefTrendWindow, "5分钟")))
+
+	pauseCheck := widget.NewCheck(tr("暂停"), chart.setPaused)
+
+	if varsEntry.Text != "" {
+		apply()
+	}
+
+	add = func(t time.Time, order, area string, start int, data []byte) {
+		if area != s7viewer.AreaV {
+			return
+		}
+		chart.addSample(t, order, start, data)
+	}
+
+	content = container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel(tr("变量:")),
+			container.NewHBox(applyButton, widget.NewLabel(tr("时间范围:")), windowSelect, pauseCheck),
+			varsEntry),
+		statusLabel, nil, nil,
+		chart,
+	)
+	return content, add
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// 终端界面：在没有图形桌面的服务器上通过SSH查看位网格和状态表。
+// 只使用ANSI转义序列，不依赖终端库，每次扫描重绘整个屏幕，按Ctrl+C退出。
+
+// tuiBytesPerRow 位网格每行显示的字节数
+const tuiBytesPerRow = 4
+
+// ANSI转义序列
+const (
+	ansiAltScreen  = "\x1b[?1049h\x1b[?25l" // 切换到备用屏幕并隐藏光标
+	ansiMainScreen = "\x1b[?25h\x1b[?1049l"
+	ansiHome       = "\x1b[H"
+	ansiClearRest  = "\x1b[J"
+	ansiClearLine  = "\x1b[K"
+	ansiReset      = "\x1b[0m"
+	ansiBold       = "\x1b[1m"
+	ansiDim        = "\x1b[2m"
+	ansiReverse    = "\x1b[7m"
+	ansiGreen      = "\x1b[32m"
+	ansiRed        = "\x1b[31m"
+)
+
+// parseWatchList 解析 -watch 的变量列表，逗号分隔，可用“:类型”指定数据类型，如 VW100,VD4:REAL,M10.0
+func parseWatchList(list string) ([]watchSpec, error) {
+	var specs []watchSpec
+	for _, f := range strings.Split(list, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		addrText, dataType, hasType := strings.Cut(f, ":")
+		addr, defaultType, err := parseWatchAddress(addrText)
+		if err != nil {
+			return nil, err
+		}
+		if !hasType {
+			dataType = defaultType
+		} else if dataType = strings.ToUpper(strings.TrimSpace(dataType)); !containsString(watchTypes, dataType) {
+			return nil, fmt.Errorf(tr("未知的数据类型: %s"), dataType)
+		} else if err := checkWatchType(addr, dataType); err != nil {
+			return nil, err
+		}
+		specs = append(specs, watchSpec{addr: addr, dataType: dataType})
+	}
+	return specs, nil
+}
+
+// tuiScreen 终端界面的状态，监控协程和错误回调都会重绘，用mu串行
+type tuiScreen struct {
+	mu       sync.Mutex
+	out      *bufio.Writer
+	header   string
+	area     string
+	start    int
+	specs    []watchSpec
+	data     []byte
+	prev     []byte
+	values   []string
+	lastErr  error
+	lastRead time.Time
+}
+
+// runTUI 连接PLC并持续监控，在终端中显示位网格和 -watch 指定的变量，返回进程退出码
+func runTUI(o *cliOptions) int {
+	area, start, length, err := o.parseRange()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	specs, err := parseWatchList(o.watch)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	viewer, code := o.connect()
+	if viewer == nil {
+		return code
+	}
+	defer viewer.Disconnect()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	s := &tuiScreen{
+		out:   bufio.NewWriter(os.Stdout),
+		area:  area,
+		start: start,
+		specs: specs,
+		header: fmt.Sprintf(tr("%s  %s 起 %d %s  扫描周期 %v  按Ctrl+C退出"),
+			o.ip, s7viewer.ByteAddressName(area, start), length, tr(areaUnit(area)), o.interval),
+	}
+	fmt.Print(ansiAltScreen)
+	defer fmt.Print(ansiMainScreen)
+
+	viewer.SetErrorHandler(func(err error) {
+		s.mu.Lock()
+		s.lastErr = err
+		s.drawLocked()
+		s.mu.Unlock()
+	})
+	viewer.SetScanInterval(o.interval)
+	viewer.Subscribe(ctx, area, start, length, func(data []byte) {
+		values := readTUIWatch(ctx, viewer, specs)
+		s.mu.Lock()
+		s.prev, s.data = s.data, append([]byte(nil), data...)
+		s.values, s.lastErr, s.lastRead = values, nil, time.Now()
+		s.drawLocked()
+		s.mu.Unlock()
+	})
+	<-ctx.Done()
+	viewer.Unsubscribe()
+	return exitOK
+}
+
+// areaUnit 读取长度的单位，T/C区按个数
+func areaUnit(area string) string {
+	if s7viewer.IsCounterArea(area) {
+		return "个"
+	}
+	return "字节"
+}
+
+// readTUIWatch 读取状态表变量并格式化为显示文本，读取失败的行显示错误
+func readTUIWatch(ctx context.Context, viewer *s7viewer.Viewer, specs []watchSpec) []string {
+	if len(specs) == 0 {
+		return nil
+	}
+	items := make([]s7viewer.Item, len(specs))
+	for i, s := range specs {
+		items[i] = s.item()
+	}
+	values := make([]string, len(specs))
+	if err := viewer.ReadItems(ctx, items); err != nil {
+		for i := range values {
+			values[i] = tr("错误: ") + err.Error()
+		}
+		return values
+	}
+	for i, it := range items {
+		if it.Err != nil {
+			values[i] = tr("错误: ") + it.Err.Error()
+			continue
+		}
+		v, err := s7viewer.DecodeValue(viewer.ByteOrder(), specs[i].dataType, it.Data, 0, specs[i].addr.bit, 0)
+		if err != nil {
+			v = tr("错误: ") + err.Error()
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// drawLocked 重绘整个屏幕：标题、状态行、位网格（1为绿色，本次变化的位反色显示）和状态表
+func (s *tuiScreen) drawLocked() {
+	w := s.out
+	w.WriteString(ansiHome)
+	fmt.Fprintf(w, "%s%s%s%s\n", ansiBold, s.header, ansiReset, ansiClearLine)
+	switch {
+	case s.lastErr != nil:
+		fmt.Fprintf(w, "%s%s%s%s\n", ansiRed, s.lastErr, ansiReset, ansiClearLine)
+	case s.data == nil:
+		fmt.Fprintf(w, "%s%s\n", tr("等待数据…"), ansiClearLine)
+	default:
+		fmt.Fprintf(w, tr("最近读取 %s")+"%s\n", s.lastRead.Format("15:04:05.000"), ansiClearLine)
+	}
+	w.WriteString(ansiClearLine + "\n")
+
+	for i := 0; i < len(s.data); i += tuiBytesPerRow {
+		fmt.Fprintf(w, "%-8s", s.byteName(i))
+		end := min(i+tuiBytesPerRow, len(s.data))
+		for k := i; k < end; k++ {
+			w.WriteString("  ")
+			for bit := 7; bit >= 0; bit-- {
+				on := s.data[k]>>bit&1 == 1
+				changed := k < len(s.prev) && (s.data[k]^s.prev[k])>>bit&1 == 1
+				switch {
+				case changed:
+					w.WriteString(ansiReverse)
+				case !on:
+					w.WriteString(ansiDim)
+				}
+				if on {
+					w.WriteString(ansiGreen + "1")
+				} else {
+					w.WriteString("0")
+				}
+				w.WriteString(ansiReset)
+			}
+		}
+		w.WriteString(strings.Repeat(" ", (tuiBytesPerRow-(end-i))*10))
+		fmt.Fprintf(w, "   %s%s\n", formatHex(s.data[i:end]), ansiClearLine)
+	}
+
+	if len(s.specs) > 0 {
+		fmt.Fprintf(w, "%s\n%s%s%s%s\n", ansiClearLine, ansiBold, tr("状态表"), ansiReset, ansiClearLine)
+		for i, spec := range s.specs {
+			value := ""
+			if i < len(s.values) {
+				value = s.values[i]
+			}
+			fmt.Fprintf(w, "%-10s %-9s %s%s\n", spec.addr, spec.dataType, value, ansiClearLine)
+		}
+	}
+	w.WriteString(ansiClearRest)
+	w.Flush()
+}
+
+// byteName 第i个字节所在的地址，T/C区每个元素占2字节
+func (s *tuiScreen) byteName(i int) string {
+	if s7viewer.IsCounterArea(s.area) {
+		return s7viewer.ByteAddressName(s.area, s.start+i/2)
+	}
+	return s7viewer.ByteAddressName(s.area, s.start+i)
+}
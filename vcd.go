@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// vcdID 第i个信号在VCD文件中的标识符，由可打印字符 ! 到 ~ 组成
+func vcdID(i int) string {
+	var id []byte
+	for {
+		id = append(id, byte('!'+i%94))
+		if i = i/94 - 1; i < 0 {
+			return string(id)
+		}
+	}
+}
+
+// vcdName 信号名：地址中的点换成下划线（GTKWave把点当作层级分隔），有标签时追加在后面，空白换成下划线
+func vcdName(addr s7Address, label string) string {
+	name := strings.ReplaceAll(addr.String(), ".", "_")
+	if label != "" {
+		name += "_" + label
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// recordingBits 录制中所有的位地址，T/C区没有位地址时返回nil
+func recordingBits(rec *recording) []s7Address {
+	if s7viewer.IsCounterArea(rec.Header.Area) {
+		return nil
+	}
+	n := 0
+	for _, f := range rec.Frames {
+		n = max(n, len(f.Data))
+	}
+	var bits []s7Address
+	for i := range n {
+		for b := range 8 {
+			bits = append(bits, s7Address{area: rec.Header.Area, byteOff: rec.Header.Start + i, bit: b})
+		}
+	}
+	return bits
+}
+
+// writeVCD 将录制中的位写成VCD（Value Change Dump）文件，可在GTKWave、PulseView中查看。
+// 时间单位为毫秒，从录制开始计时；只写出变化，帧中不包含的位保持上一个值（开始时为x）。
+// labelOf返回位地址的标签，可为nil。
+func writeVCD(w io.Writer, rec *recording, bits []s7Address, labelOf func(addr string) string) error {
+	for _, b := range bits {
+		if b.area != rec.Header.Area || b.byteOff < rec.Header.Start {
+			return fmt.Errorf(tr("%s 不在录制范围内"), b)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "$date %s $end\n", rec.Header.Time.Format("2006-01-02 15:04:05.000"))
+	fmt.Fprintf(bw, "$version %s $end\n", strings.TrimSpace(windowTitle))
+	fmt.Fprintf(bw, "$comment PLC %s %s $end\n", rec.Header.PLC, s7viewer.ByteAddressName(rec.Header.Area, rec.Header.Start))
+	fmt.Fprintf(bw, "$timescale 1ms $end\n")
+	fmt.Fprintf(bw, "$scope module plc $end\n")
+	for i, b := range bits {
+		label := ""
+		if labelOf != nil {
+			label = labelOf(b.String())
+		}
+		fmt.Fprintf(bw, "$var wire 1 %s %s $end\n", vcdID(i), vcdName(b, label))
+	}
+	fmt.Fprintf(bw, "$upscope $end\n$enddefinitions $end\n")
+
+	// values 各位当前的值，'x'为未知
+	values := make([]byte, len(bits))
+	for i := range values {
+		values[i] = 'x'
+	}
+	fmt.Fprintf(bw, "#0\n$dumpvars\n")
+	for i := range bits {
+		fmt.Fprintf(bw, "x%s\n", vcdID(i))
+	}
+	fmt.Fprintf(bw, "$end\n")
+
+	last := int64(0)
+	for _, f := range rec.Frames {
+		t := max(f.Time.Sub(rec.Header.Time).Milliseconds(), 0)
+		for i, b := range bits {
+			off := b.byteOff - rec.Header.Start
+			if off >= len(f.Data) {
+				continue
+			}
+			v := '0' + (f.Data[off]>>b.bit)&1
+			if v == values[i] {
+				continue
+			}
+			if t != last {
+				fmt.Fprintf(bw, "#%d\n", t)
+				last = t
+			}
+			values[i] = v
+			fmt.Fprintf(bw, "%c%s\n", v, vcdID(i))
+		}
+	}
+	// 末尾写出最后一帧的时间，查看器按此显示录制的总时长
+	if end := rec.Frames[len(rec.Frames)-1].Time.Sub(rec.Header.Time).Milliseconds(); end > last {
+		fmt.Fprintf(bw, "#%d\n", end)
+	}
+	return bw.Flush()
+}
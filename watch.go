@@ -0,0 +1,732 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// prefWatchRows 状态表的行（"地址|类型|报警条件|轮询周期"），重启后恢复
+const prefWatchRows = "watch.rows"
+
+// watchWithScan 轮询组：随网格一起按扫描周期读取
+const watchWithScan = "随扫描"
+
+// 状态表行可选的轮询组：随扫描，或按自己的周期单独读取，如握手位200 ms、温度5 s
+var (
+	watchRateNames = []string{watchWithScan, "100 ms", "200 ms", "500 ms", "1 s", "2 s", "5 s", "10 s", "30 s", "60 s"}
+	watchRates     = map[string]time.Duration{
+		"100 ms": 100 * time.Millisecond, "200 ms": 200 * time.Millisecond, "500 ms": 500 * time.Millisecond,
+		"1 s": time.Second, "2 s": 2 * time.Second, "5 s": 5 * time.Second, "10 s": 10 * time.Second,
+		"30 s": 30 * time.Second, "60 s": time.Minute,
+	}
+)
+
+// watchGroupTick 轮询组调度的时间粒度
+const watchGroupTick = 50 * time.Millisecond
+
+// watchTypes 状态表可选的数据类型
+var watchTypes = []string{s7viewer.TypeBool, s7viewer.TypeByte, s7viewer.TypeWord, s7viewer.TypeInt, s7viewer.TypeDWord, s7viewer.TypeDInt, s7viewer.TypeReal, s7viewer.TypeBCD16, s7viewer.TypeBCD32, s7viewer.TypeString, s7viewer.TypeS7String}
+
+// parseWatchAddress 解析状态表地址，支持所有存储区，VR视为REAL类型的VD。
+// 返回地址和按宽度推断的默认类型。
+func parseWatchAddress(s string) (s7Address, string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	isReal := strings.HasPrefix(s, "VR")
+	if isReal {
+		s = "VD" + s[2:]
+	}
+	addr, err := parseAddress(s)
+	if err != nil {
+		return s7Address{}, "", err
+	}
+	switch {
+	case isReal:
+		return addr, s7viewer.TypeReal, nil
+	case s7viewer.IsCounterArea(addr.area):
+		return addr, s7viewer.TypeInt, nil
+	case addr.size == "":
+		return addr, s7viewer.TypeBool, nil
+	case addr.size == "B":
+		return addr, s7viewer.TypeByte, nil
+	case addr.size == "W":
+		return addr, s7viewer.TypeInt, nil
+	}
+	return addr, s7viewer.TypeDInt, nil
+}
+
+// checkWatchType 检查数据类型与地址宽度是否一致；STRING和插件的自定义类型需要字节地址，从该字节开始读取
+func checkWatchType(addr s7Address, dataType string) error {
+	if _, ok := s7viewer.LookupDecoder(dataType); ok {
+		if addr.size != "B" || s7viewer.IsCounterArea(addr.area) {
+			return fmt.Errorf(tr("%s 需要字节地址，如 VB100"), dataType)
+		}
+		return nil
+	}
+	switch {
+	case s7viewer.IsCounterArea(addr.area) && dataType != s7viewer.TypeInt && dataType != s7viewer.TypeWord:
+		return fmt.Errorf(tr("%s区的当前值只能按 INT 或 WORD 显示"), addr.area)
+	case dataType == s7viewer.TypeBool && addr.size != "":
+		return fmt.Errorf(tr("BOOL 需要位地址，实际为 %s"), addr)
+	case dataType != s7viewer.TypeBool && addr.size == "" && !s7viewer.IsCounterArea(addr.area):
+		return fmt.Errorf(tr("%s 不能使用位地址 %s"), dataType, addr)
+	case (dataType == s7viewer.TypeString || dataType == s7viewer.TypeS7String) && addr.size != "B":
+		return fmt.Errorf(tr("%s 需要字节地址，如 VB100"), dataType)
+	case dataType != s7viewer.TypeBool && dataType != s7viewer.TypeString && dataType != s7viewer.TypeS7String && addr.width() != s7viewer.TypeWidth(dataType, 0):
+		return fmt.Errorf(tr("地址 %s 的宽度与类型 %s 不符"), addr, dataType)
+	}
+	return nil
+}
+
+// watchSpec 一行已解析的地址和类型，err非nil时该行不读取。rate为该行轮询组的周期，0表示随扫描读取。
+// computed非空时该行显示该计算变量的值，随扫描计算，不读取地址。
+type watchSpec struct {
+	addr     s7Address
+	dataType string
+	rate     time.Duration
+	computed string
+	err      error
+}
+
+// item 该行对应的读取项
+func (s watchSpec) item() s7viewer.Item {
+	if s7viewer.IsCounterArea(s.addr.area) {
+		return s7viewer.Item{Area: s.addr.area, Start: s.addr.byteOff, Size: 1}
+	}
+	return s7viewer.Item{Area: s.addr.area, Start: s.addr.byteOff, Size: s7viewer.TypeWidth(s.dataType, 0)}
+}
+
+// decodeFrom 从一次读取的area区start起按order存放的数据中解码该变量，变量不在数据范围内时ok为false
+func (s watchSpec) decodeFrom(order, area string, start int, data []byte) (v any, ok bool, err error) {
+	if s.addr.area != area {
+		return nil, false, nil
+	}
+	off, width := s.addr.byteOff-start, s7viewer.TypeWidth(s.dataType, 0)
+	switch {
+	case s7viewer.IsCounterArea(area):
+		off, width = off*2, 2
+	case s.dataType == s7viewer.TypeString, s.dataType == s7viewer.TypeS7String:
+		width = 1 // 字符串按实际长度解码，只要求长度字节在范围内
+	}
+	if off < 0 || off+width > len(data) {
+		return nil, false, nil
+	}
+	v, err = s7viewer.DecodeTyped(order, s.dataType, data, off, s.addr.bit, 0)
+	return v, true, err
+}
+
+type watchRow struct {
+	addrEntry  *widget.Entry
+	typeSelect *widget.Select
+	valueLabel *widget.Label
+	newEntry   *widget.Entry
+	alarmEntry *widget.Entry
+	rateSelect *widget.Select
+	edgeLabel  *widget.Label
+
+	// edges BOOL行每次读取的值的边沿计数，edgeKey为计数时的地址和类型，变化时清零
+	edges   edgeCount
+	edgeKey string
+
+	// 报警条件，alarmKey为解析时的地址、类型和条件，变化时重置报警状态
+	rule     *alarmRule
+	alarmKey string
+	alarm    alarmState
+	// value 最近一次读取解码并换算后的数值，用于判断报警条件，hasValue为false时没有数值（未读取或出错）
+	value    float64
+	hasValue bool
+}
+
+// watchResult 一次读取得到的各行值，gen用于丢弃行变化前发起的读取结果
+type watchResult struct {
+	gen     int
+	values  []string
+	typed   []any           // 各行解码后的值，供OPC UA发布，计算变量为float64，未读取或出错时为nil
+	numbers map[int]float64 // 各行解码并换算后的数值，供判断报警条件，未读取、出错或不是数值的行没有
+}
+
+// watchTable 状态表：每行为地址、数据类型、当前值和待写入的新值，与网格一起读取
+type watchTable struct {
+	prefs     fyne.Preferences
+	getViewer func() (*s7viewer.Viewer, context.Context)
+	onWritten func()
+	// labelOf 返回地址的位标签，onAlarm在报警触发或解除时调用，均在UI线程中调用
+	labelOf func(addr string) string
+	onAlarm func(alarmEvent)
+	// onResetEdges 点击边沿计数清零时调用，用于同时清零网格的计数
+	onResetEdges func()
+	// onRowsChanged 各行重新解析后调用，onShow 显示一次读取结果后调用，均在UI线程中调用
+	onRowsChanged func()
+	onShow        func(*watchResult)
+
+	mu    sync.Mutex // 监控协程通过poll读取specs
+	specs []watchSpec
+	gen   int
+
+	rows   []*watchRow
+	box    *fyne.Container
+	status *widget.Label
+	// alarmErrShown 表示status中显示的是报警条件错误，条件改正后清除
+	alarmErrShown bool
+	// writing 表示writeAll正在后台写入，期间再次点击写入不响应
+	writing bool
+	content fyne.CanvasObject
+}
+
+// newWatchTable 创建状态表。getViewer返回当前连接及其context（断开连接时取消），
+// onWritten在写入成功后调用，用于刷新显示。
+func newWatchTable(prefs fyne.Preferences, getViewer func() (*s7viewer.Viewer, context.Context), onWritten func()) *watchTable {
+	w := &watchTable{prefs: prefs, getViewer: getViewer, onWritten: onWritten}
+	w.box = container.NewVBox()
+	w.status = widget.NewLabel("")
+
+	for _, saved := range prefs.StringList(prefWatchRows) {
+		fields := strings.SplitN(saved, "|", 4)
+		fields = append(fields, "", "", "")
+		w.addRow(fields[0], fields[1], fields[2], fields[3])
+	}
+	if len(w.rows) == 0 {
+		w.addRow("", "", "", "")
+	}
+
+	header := container.NewGridWithColumns(8,
+		widget.NewLabel(tr("地址")), widget.NewLabel(tr("数据类型")), widget.NewLabel(tr("当前值")), widget.NewLabel(tr("边沿次数")),
+		widget.NewLabel(tr("新值")), widget.NewLabel(tr("报警条件")), widget.NewLabel(tr("轮询组")), widget.NewLabel(""))
+	addButton := widget.NewButton(tr("添加行"), func() { w.addRow("", "", "", "") })
+	writeButton := widget.NewButton(tr("写入新值"), w.writeAll)
+	disableInReadOnly(writeButton)
+	resetEdgesButton := widget.NewButton(tr("边沿计数清零"), func() {
+		w.resetEdges()
+		if w.onResetEdges != nil {
+			w.onResetEdges()
+		}
+	})
+
+	w.content = container.NewBorder(
+		header,
+		container.NewHBox(addButton, writeButton, resetEdgesButton, w.status),
+		nil, nil,
+		container.NewVScroll(w.box),
+	)
+	return w
+}
+
+// addRow 添加一行，dataType为空时按地址推断，alarm为报警条件（可为空），rate为轮询组（为空时随扫描）
+func (w *watchTable) addRow(address, dataType, alarm, rate string) {
+	row := &watchRow{
+		addrEntry:  widget.NewEntry(),
+		typeSelect: widget.NewSelect(watchTypes, nil),
+		valueLabel: widget.NewLabel(""),
+		newEntry:   widget.NewEntry(),
+		alarmEntry: widget.NewEntry(),
+		edgeLabel:  widget.NewLabel(""),
+		edges:      newEdgeCount(),
+	}
+	row.addrEntry.SetPlaceHolder(tr("如 V100.0、VW10、VR20、MB0 或计算变量名"))
+	row.addrEntry.SetText(address)
+	row.newEntry.SetPlaceHolder(tr("新值"))
+	row.alarmEntry.SetPlaceHolder(tr("如 > 1500 或 == 1 for > 5 s"))
+	row.alarmEntry.SetText(alarm)
+	if dataType != "" {
+		row.typeSelect.SetSelected(dataType)
+	} else if _, t, err := parseWatchAddress(address); err == nil {
+		row.typeSelect.SetSelected(t)
+	}
+
+	// 输入地址时按宽度自动选择类型
+	row.addrEntry.OnChanged = func(s string) {
+		if _, t, err := parseWatchAddress(s); err == nil {
+			row.typeSelect.SetSelected(t)
+		}
+		w.rowsChanged()
+	}
+	row.typeSelect.OnChanged = func(string) { w.rowsChanged() }
+	row.alarmEntry.OnChanged = func(string) { w.rowsChanged() }
+	row.rateSelect = newTrSelect(watchRateNames, func(string) { w.rowsChanged() })
+	if _, ok := watchRates[rate]; !ok {
+		rate = watchWithScan
+	}
+	row.rateSelect.SetSelected(tr(rate))
+
+	var line *fyne.Container
+	removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		for i, r := range w.rows {
+			if r == row {
+				w.rows = append(w.rows[:i:i], w.rows[i+1:]...)
+				break
+			}
+		}
+		w.box.Remove(line)
+		w.rowsChanged()
+	})
+	line = container.NewGridWithColumns(8, row.addrEntry, row.typeSelect, row.valueLabel, row.edgeLabel, row.newEntry, row.alarmEntry, row.rateSelect, removeButton)
+
+	w.rows = append(w.rows, row)
+	w.box.Add(line)
+	w.rowsChanged()
+}
+
+// rowsChanged 重新解析各行并保存到偏好设置，之前发起的读取结果作废
+func (w *watchTable) rowsChanged() {
+	specs := make([]watchSpec, len(w.rows))
+	var saved []string
+	var alarmErr error
+	computed := currentComputed()
+	for i, r := range w.rows {
+		text := strings.TrimSpace(r.addrEntry.Text)
+		addr, _, err := parseWatchAddress(text)
+		dataType := r.typeSelect.Selected
+		rate := selectValue(r.rateSelect, watchRateNames)
+		spec := watchSpec{addr: addr, dataType: dataType, rate: watchRates[rate]}
+		if err != nil && computed != nil && computed.has(text) {
+			spec.computed, err = text, nil
+		} else if err == nil {
+			err = checkWatchType(addr, dataType)
+		}
+		spec.err = err
+		specs[i] = spec
+		if text != "" {
+			saved = append(saved, text+"|"+dataType+"|"+strings.TrimSpace(r.alarmEntry.Text)+"|"+rate)
+		}
+		if key := spec.tag() + "|" + dataType; key != r.edgeKey {
+			r.edgeKey = key
+			r.edges = newEdgeCount()
+			r.edgeLabel.SetText("")
+		}
+		if rerr := w.parseRule(r, spec); rerr != nil && alarmErr == nil {
+			alarmErr = rerr
+		}
+		if text == "" {
+			r.valueLabel.SetText("")
+			r.hasValue = false
+		} else if err != nil {
+			r.valueLabel.SetText(err.Error())
+			r.hasValue = false
+		}
+	}
+	w.prefs.SetStringList(prefWatchRows, saved)
+	if alarmErr != nil {
+		w.status.SetText(alarmErr.Error())
+	} else if w.alarmErrShown {
+		w.status.SetText("")
+	}
+	w.alarmErrShown = alarmErr != nil
+
+	w.mu.Lock()
+	w.specs = specs
+	w.gen++
+	w.mu.Unlock()
+	if w.onRowsChanged != nil {
+		w.onRowsChanged()
+	}
+}
+
+// parseRule 解析一行的报警条件，地址、类型或条件变化时重置该行的报警状态（不产生解除事件）。
+// 该行地址有错误时不启用报警。计算变量行的条件可以省略或带上变量名，如 > 5 或 flow > 5。
+func (w *watchTable) parseRule(r *watchRow, spec watchSpec) error {
+	text := strings.TrimSpace(r.alarmEntry.Text)
+	key := spec.tag() + "|" + spec.dataType + "|" + text
+	if key == r.alarmKey {
+		return nil
+	}
+	r.alarmKey = key
+	r.rule, r.alarm = nil, alarmState{}
+	setImportance(r.valueLabel, widget.MediumImportance)
+	if text == "" || spec.err != nil {
+		return nil
+	}
+	if _, decoder := s7viewer.LookupDecoder(spec.dataType); spec.computed == "" &&
+		(spec.dataType == s7viewer.TypeString || spec.dataType == s7viewer.TypeS7String || decoder) {
+		return fmt.Errorf(tr("报警条件: %s 不支持 %s 类型"), spec.addr, spec.dataType)
+	}
+	cond := text
+	if spec.computed != "" {
+		cond = strings.TrimPrefix(cond, spec.computed)
+	}
+	rule, err := parseAlarmRule(cond, spec.addr)
+	if err != nil {
+		return fmt.Errorf(tr("报警条件: %s: %v"), spec.tag(), err)
+	}
+	rule.text = text
+	r.rule = rule
+	return nil
+}
+
+// checkAlarms 用各行最近一次读取的数值更新报警状态，触发的行高亮显示
+func (w *watchTable) checkAlarms(now time.Time) {
+	for _, r := range w.rows {
+		if r.rule == nil || !r.hasValue || !r.alarm.update(r.rule, r.value, now) {
+			continue
+		}
+		if r.alarm.active {
+			setImportance(r.valueLabel, widget.DangerImportance)
+		} else {
+			setImportance(r.valueLabel, widget.MediumImportance)
+		}
+		if w.onAlarm == nil {
+			continue
+		}
+		tag := strings.TrimSpace(r.addrEntry.Text)
+		if addr, _, err := parseWatchAddress(tag); err == nil {
+			tag = addr.String()
+		}
+		if w.labelOf != nil {
+			if label := w.labelOf(tag); label != "" {
+				tag += " (" + label + ")"
+			}
+		}
+		w.onAlarm(alarmEvent{time: now, tag: tag, rule: r.rule.text, value: r.valueLabel.Text, cleared: !r.alarm.active})
+	}
+}
+
+// setImportance 设置标签的显示样式，报警中的值以警示颜色显示
+func setImportance(l *widget.Label, imp widget.Importance) {
+	if l.Importance != imp {
+		l.Importance = imp
+		l.Refresh()
+	}
+}
+
+// poll 读取随扫描的有效行的当前值，可在监控协程中调用。未连接时返回nil。
+func (w *watchTable) poll(ctx context.Context, viewer *s7viewer.Viewer) *watchResult {
+	return w.pollRates(ctx, viewer, func(rate time.Duration) bool { return rate == 0 })
+}
+
+// pollRates 读取轮询组满足due的有效行，其他行的值为空。随扫描读取时同时读取计算变量引用的地址并重新计算。
+func (w *watchTable) pollRates(ctx context.Context, viewer *s7viewer.Viewer, due func(rate time.Duration) bool) *watchResult {
+	w.mu.Lock()
+	specs, gen := w.specs, w.gen
+	w.mu.Unlock()
+	if viewer == nil {
+		return nil
+	}
+
+	var items []s7viewer.Item
+	var index []int
+	for i, s := range specs {
+		if s.err == nil && s.computed == "" && due(s.rate) {
+			items = append(items, s.item())
+			index = append(index, i)
+		}
+	}
+	var computed *computedSet
+	if due(0) {
+		computed = currentComputed()
+	}
+	rowItems := len(items)
+	if computed != nil {
+		for _, s := range computed.specs {
+			items = append(items, s.item())
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if err := viewer.ReadItems(ctx, items); err != nil {
+		return nil
+	}
+
+	result := &watchResult{gen: gen, values: make([]string, len(specs)), typed: make([]any, len(specs)), numbers: make(map[int]float64)}
+	if computed != nil {
+		w.evaluateComputed(viewer.ByteOrder(), computed, items[rowItems:], specs, result)
+	}
+	for k, it := range items[:rowItems] {
+		i, s := index[k], specs[index[k]]
+		if it.Err != nil {
+			result.values[i] = tr("错误: ") + it.Err.Error()
+			continue
+		}
+		// 设置了换算的变量显示工程值和单位，按工程值判断报警条件
+		sc, scaled := euScaleOf(s.addr.String())
+		scaled = scaled && s.dataType != s7viewer.TypeBool
+		v, err := s7viewer.DecodeValue(viewer.ByteOrder(), s.dataType, it.Data, 0, s.addr.bit, 0)
+		if err != nil {
+			v = tr("错误: ") + err.Error()
+		} else if scaled {
+			if raw, err := strconv.ParseFloat(v, 64); err == nil {
+				v = sc.format(raw)
+			}
+		}
+		result.values[i] = v
+		if err != nil {
+			continue
+		}
+		result.typed[i], _ = s7viewer.DecodeTyped(viewer.ByteOrder(), s.dataType, it.Data, 0, s.addr.bit, 0)
+		if f, ok := metricValue(result.typed[i]); ok {
+			if scaled {
+				f = sc.apply(f)
+			}
+			result.numbers[i] = f
+		}
+	}
+	return result
+}
+
+// evaluateComputed 用读取到的原始值重新计算计算变量，保存结果供趋势图使用，并填入计算变量行的值
+func (w *watchTable) evaluateComputed(order string, computed *computedSet, items []s7viewer.Item, specs []watchSpec, result *watchResult) {
+	vars := make(map[string]float64)
+	for k, it := range items {
+		s := computed.specs[k]
+		if it.Err != nil {
+			continue
+		}
+		v, err := s7viewer.DecodeTyped(order, s.dataType, it.Data, 0, s.addr.bit, 0)
+		if err != nil {
+			continue
+		}
+		if f, ok := metricValue(v); ok {
+			vars[s.specKey()] = f
+		}
+	}
+	results, errs := computed.evaluate(vars)
+	setComputedResults(results)
+	for i, s := range specs {
+		if s.computed == "" || s.err != nil {
+			continue
+		}
+		if err, ok := errs[s.computed]; ok {
+			result.values[i] = tr("错误: ") + err.Error()
+		} else {
+			result.values[i] = formatComputed(results[s.computed])
+			result.typed[i] = results[s.computed]
+			result.numbers[i] = results[s.computed]
+		}
+	}
+}
+
+// startGroups 监控期间在后台按各轮询组自己的周期读取非随扫描的行，与扫描读取交替进行，
+// 结果在UI线程中显示。ctx被取消时停止。
+func (w *watchTable) startGroups(ctx context.Context, viewer *s7viewer.Viewer) {
+	go func() {
+		ticker := time.NewTicker(watchGroupTick)
+		defer ticker.Stop()
+		// next 各轮询周期下次读取的时间
+		next := make(map[time.Duration]time.Time)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				due := make(map[time.Duration]bool)
+				for _, rate := range watchRates {
+					if !now.Before(next[rate]) {
+						due[rate] = true
+					}
+				}
+				r := w.pollRates(ctx, viewer, func(rate time.Duration) bool { return rate > 0 && due[rate] })
+				if ctx.Err() != nil {
+					return
+				}
+				for rate := range due {
+					next[rate] = now.Add(rate)
+				}
+				if r != nil {
+					fyne.Do(func() { w.show(r) })
+				}
+			}
+		}
+	}()
+}
+
+// show 在UI线程中显示poll的结果，行已变化时忽略
+func (w *watchTable) show(r *watchResult) {
+	if r == nil {
+		return
+	}
+	w.mu.Lock()
+	stale := r.gen != w.gen
+	w.mu.Unlock()
+	if stale {
+		return
+	}
+	for i, row := range w.rows {
+		if i < len(r.values) && r.values[i] != "" {
+			row.valueLabel.SetText(r.values[i])
+			row.value, row.hasValue = r.numbers[i]
+			if row.typeSelect.Selected == s7viewer.TypeBool && (r.values[i] == "0" || r.values[i] == "1") {
+				row.edges.update(int(r.values[i][0] - '0'))
+				row.edgeLabel.SetText(formatEdges(row.edges.rising, row.edges.falling))
+			}
+		}
+	}
+	w.checkAlarms(time.Now())
+	if w.onShow != nil {
+		w.onShow(r)
+	}
+}
+
+// opcuaTags 返回可发布为OPC UA节点的有效行和当前的代次。nameOf返回地址的变量名，
+// 没有时节点名为地址；计算变量以变量名为节点名。重名时追加序号
+func (w *watchTable) opcuaTags(nameOf func(addr string) string) ([]opcuaTag, int) {
+	w.mu.Lock()
+	specs, gen := w.specs, w.gen
+	w.mu.Unlock()
+
+	var tags []opcuaTag
+	used := make(map[string]bool)
+	for i, s := range specs {
+		switch {
+		case s.err != nil:
+		case s.computed != "":
+			tags = append(tags, opcuaTag{row: i, name: uniqueName(used, s.computed)})
+		default:
+			name := nameOf(s.addr.String())
+			if name == "" {
+				name = s.addr.String()
+			}
+			tags = append(tags, opcuaTag{row: i, name: uniqueName(used, name), dataType: s.dataType})
+		}
+	}
+	return tags, gen
+}
+
+// resetEdges 清零各行的边沿计数
+func (w *watchTable) resetEdges() {
+	for _, r := range w.rows {
+		r.edges = newEdgeCount()
+		r.edgeLabel.SetText("")
+	}
+}
+
+// valueRows 返回各有效行当前显示的值，用于快照
+func (w *watchTable) valueRows() []snapshotValue {
+	var rows []snapshotValue
+	computed := currentComputed()
+	for _, r := range w.rows {
+		text := strings.TrimSpace(r.addrEntry.Text)
+		addr, _, err := parseWatchAddress(text)
+		if err != nil {
+			if computed != nil && computed.has(text) {
+				rows = append(rows, snapshotValue{Address: text, Type: computedType, Value: r.valueLabel.Text})
+			}
+			continue
+		}
+		rows = append(rows, snapshotValue{Address: addr.String(), Type: r.typeSelect.Selected, Value: r.valueLabel.Text})
+	}
+	return rows
+}
+
+// writeAll 写入所有填写了新值的行（仅V区），写入在后台进行，结果回到UI线程显示，成功写入的行清空新值
+func (w *watchTable) writeAll() {
+	viewer, ctx := w.getViewer()
+	if viewer == nil {
+		w.status.SetText(tr("请先连接PLC"))
+		return
+	}
+	if w.writing {
+		return
+	}
+
+	// 先在UI线程中检查和编码所有行，有错误时一项也不写入
+	type watchWrite struct {
+		row            *watchRow
+		addr           s7Address
+		dataType, text string
+		data           []byte
+	}
+	var writes []watchWrite
+	for _, row := range w.rows {
+		text := strings.TrimSpace(row.newEntry.Text)
+		if text == "" {
+			continue
+		}
+		addr, _, err := parseWatchAddress(row.addrEntry.Text)
+		dataType := row.typeSelect.Selected
+		if err == nil {
+			err = checkWatchType(addr, dataType)
+		}
+		if err == nil && addr.area != s7viewer.AreaV {
+			err = fmt.Errorf(tr("仅支持写入V区，%s 未写入"), addr)
+		}
+		var data []byte
+		if err == nil {
+			data, err = s7viewer.EncodeValue(viewer.ByteOrder(), dataType, text)
+		}
+		if err != nil {
+			w.status.SetText(err.Error())
+			log.Printf(tr("写入 %s 失败: %v"), strings.TrimSpace(row.addrEntry.Text), err)
+			return
+		}
+		writes = append(writes, watchWrite{row: row, addr: addr, dataType: dataType, text: text, data: data})
+	}
+	if len(writes) == 0 {
+		return
+	}
+
+	// 在后台依次写入，不阻塞界面，遇到错误时停止
+	w.writing = true
+	w.status.SetText(tr("正在写入..."))
+	go func() {
+		written := 0
+		var failed error
+		for _, wr := range writes {
+			if err := writeVValue(ctx, viewer, wr.addr, wr.dataType, wr.data); err != nil {
+				failed = err
+				log.Printf(tr("写入 %s 失败: %v"), wr.addr, err)
+				break
+			}
+			log.Printf(tr("已写入 %s = %s (%s)"), wr.addr, wr.text, wr.dataType)
+			written++
+		}
+		fyne.Do(func() {
+			w.writing = false
+			for _, wr := range writes[:written] {
+				// 写入期间改过的新值保留
+				if strings.TrimSpace(wr.row.newEntry.Text) == wr.text {
+					wr.row.newEntry.SetText("")
+				}
+			}
+			if failed != nil {
+				w.status.SetText(failed.Error())
+			} else {
+				status := fmt.Sprintf(tr("已写入%d项"), written)
+				if viewer.VerifyWrite() {
+					status += tr("，写入已验证")
+				}
+				w.status.SetText(status)
+			}
+			if written > 0 && w.onWritten != nil {
+				w.onWritten()
+			}
+		})
+	}()
+}
+
+// writeVValue 按类型写入V区地址addr：BOOL写单个位，S7STRING保留最大长度字节，其余直接写入编码后的数据
+func writeVValue(ctx context.Context, viewer *s7viewer.Viewer, addr s7Address, dataType string, data []byte) error {
+	switch dataType {
+	case s7viewer.TypeBool:
+		return viewer.WriteVBit(ctx, addr.byteOff, addr.bit, data[0] == 1)
+	case s7viewer.TypeS7String:
+		return writeS7String(ctx, viewer, addr.byteOff, data)
+	}
+	return viewer.WriteV(ctx, addr.byteOff, data)
+}
+
+// writeS7String 写入S7字符串：保留PLC中已有的最大长度字节，从实际长度字节处写入data（长度字节加字符）。
+// 最大长度为0（未初始化）时按默认长度一并写入。
+func writeS7String(ctx context.Context, viewer *s7viewer.Viewer, byteOff int, data []byte) error {
+	head, err := viewer.ReadV(ctx, byteOff, 1)
+	if err != nil {
+		return err
+	}
+	maxLen := int(head[0])
+	if maxLen == 0 {
+		return viewer.WriteV(ctx, byteOff, append([]byte{s7viewer.DefaultStringLen}, data...))
+	}
+	if n := int(data[0]); n > maxLen {
+		return fmt.Errorf(tr("字符串长度%d超过最大长度%d"), n, maxLen)
+	}
+	return viewer.WriteV(ctx, byteOff+1, data)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Webhook设置的偏好键
+const (
+	prefWebhookURLs = "alarm.webhookURLs"
+	prefWebhookKind = "alarm.webhookKind"
+	prefWebhookOn   = "alarm.webhook"
+)
+
+// Webhook消息模板
+const (
+	webhookAuto     = "按地址识别"
+	webhookGeneric  = "通用JSON"
+	webhookWeCom    = "企业微信机器人"
+	webhookDingTalk = "钉钉机器人"
+)
+
+var webhookKinds = []string{webhookAuto, webhookGeneric, webhookWeCom, webhookDingTalk}
+
+// webhookTimeout 单次推送的超时
+const webhookTimeout = 10 * time.Second
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// webhookKindOf 返回推送到url使用的模板，kind为按地址识别时按机器人的域名判断
+func webhookKindOf(kind, endpoint string) string {
+	if kind != webhookAuto {
+		return kind
+	}
+	switch {
+	case strings.Contains(endpoint, "qyapi.weixin.qq.com"):
+		return webhookWeCom
+	case strings.Contains(endpoint, "oapi.dingtalk.com"):
+		return webhookDingTalk
+	}
+	return webhookGeneric
+}
+
+// webhookPayload 按模板生成报警消息。企业微信和钉钉机器人使用文本消息，
+// 内容以“PLC报警”开头，便于钉钉机器人按关键词校验。
+func webhookPayload(kind string, e alarmEvent) ([]byte, error) {
+	if kind == webhookGeneric {
+		event := "trigger"
+		if e.cleared {
+			event = "clear"
+		}
+		return json.Marshal(map[string]string{
+			"event":     event,
+			"time":      e.time.Format(time.RFC3339),
+			"plc":       e.plc,
+			"tag":       e.tag,
+			"condition": e.rule,
+			"value":     e.value,
+		})
+	}
+	text := map[string]string{"content": tr("PLC报警") + " " + e.String()}
+	return json.Marshal(map[string]any{"msgtype": "text", "text": text})
+}
+
+// postWebhook 推送一条报警消息。企业微信和钉钉在HTTP 200时仍可能通过errcode返回错误。
+// 地址中的key相当于密码，错误信息中不包含完整地址。
+func postWebhook(endpoint, kind string, e alarmEvent) error {
+	kind = webhookKindOf(kind, endpoint)
+	body, err := webhookPayload(kind, e)
+	if err != nil {
+		return err
+	}
+	resp, err := webhookClient.Post(endpoint, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return fmt.Errorf(tr("推送Webhook失败: %v"), err)
+	}
+	defer resp.Body.Close()
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf(tr("推送Webhook失败: %s %s"), resp.Status, strings.TrimSpace(string(reply)))
+	}
+	if kind == webhookWeCom || kind == webhookDingTalk {
+		var r struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if json.Unmarshal(reply, &r) == nil && r.ErrCode != 0 {
+			return fmt.Errorf(tr("推送Webhook失败: errcode=%d %s"), r.ErrCode, r.ErrMsg)
+		}
+	}
+	return nil
+}
+
+// newWebhookSettings 创建Webhook设置：每行一个地址，报警触发和解除时推送到所有地址。
+// send在后台推送，失败时记录日志，在UI线程中调用。
+func newWebhookSettings(prefs fyne.Preferences) (content fyne.CanvasObject, send func(alarmEvent)) {
+	urlsEntry := widget.NewMultiLineEntry()
+	urlsEntry.SetMinRowsVisible(2)
+	urlsEntry.SetPlaceHolder(tr("每行一个地址，如 https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=..."))
+	urlsEntry.SetText(prefs.String(prefWebhookURLs))
+	urlsEntry.OnChanged = func(s string) { prefs.SetString(prefWebhookURLs, s) }
+	kindSelect := newTrSelect(webhookKinds, func(kind string) { prefs.SetString(prefWebhookKind, kind) })
+	kindSelect.SetSelected(tr(prefs.StringWithFallback(prefWebhookKind, webhookAuto)))
+	statusLabel := widget.NewLabel("")
+
+	send = func(e alarmEvent) {
+		var urls []string
+		for _, line := range strings.Split(urlsEntry.Text, "\n") {
+			if u := strings.TrimSpace(line); u != "" {
+				urls = append(urls, u)
+			}
+		}
+		kind := selectValue(kindSelect, webhookKinds)
+		for _, u := range urls {
+			go func() {
+				err := postWebhook(u, kind, e)
+				if err != nil {
+					host := u
+					if parsed, perr := url.Parse(u); perr == nil {
+						host = parsed.Host
+					}
+					err = fmt.Errorf("%s: %w", host, err)
+					log.Printf("%v", err)
+				}
+				fyne.Do(func() {
+					if err != nil {
+						statusLabel.SetText(err.Error())
+					} else {
+						statusLabel.SetText(fmt.Sprintf(tr("已推送 %s"), e.time.Format("15:04:05")))
+					}
+				})
+			}()
+		}
+	}
+	testButton := widget.NewButton(tr("发送测试"), func() {
+		send(alarmEvent{time: time.Now(), plc: "-", tag: tr("测试"), rule: "-", value: "-"})
+	})
+
+	content = widget.NewForm(
+		widget.NewFormItem(tr("Webhook地址:"), urlsEntry),
+		widget.NewFormItem(tr("消息模板:"), container.NewHBox(kindSelect, testButton, statusLabel)),
+	)
+	return content, send
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// websocketGUID RFC 6455握手使用的固定GUID
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket操作码
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+// liveFrame 每次扫描推送给浏览器的数据帧
+type liveFrame struct {
+	Time    time.Time `json:"time"`
+	PLC     string    `json:"plc"`
+	Address string    `json:"address"` // 起始地址，如 VB100
+	Data    []int     `json:"data"`    // 原始字节
+	Bits    []string  `json:"bits"`    // 每字节的二进制位（高位在前，与位网格一致）
+}
+
+func newLiveFrame(plc, area string, start int, data []byte) liveFrame {
+	f := liveFrame{
+		Time:    time.Now(),
+		PLC:     plc,
+		Address: s7viewer.ByteAddressName(area, start),
+		Data:    make([]int, len(data)),
+		Bits:    make([]string, len(data)),
+	}
+	for i, b := range data {
+		f.Data[i] = int(b)
+		f.Bits[i] = fmt.Sprintf("%08b", b)
+	}
+	return f
+}
+
+// liveStream 进程内的实时数据推送中心，监控协程通过它向所有WebSocket客户端广播
+var liveStream = &wsHub{clients: make(map[*wsClient]bool)}
+
+type wsHub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]bool
+}
+
+type wsClient struct {
+	conn net.Conn
+	plc  string      // 只接收该PLC的数据，为空时接收全部
+	send chan []byte // 待发送的帧，写满时丢弃新帧，避免慢客户端拖慢监控
+}
+
+// active 返回是否有客户端连接，没有时监控协程无需组装数据帧
+func (h *wsHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients) > 0
+}
+
+// broadcast 将一次扫描的数据推送给订阅了该PLC的客户端
+func (h *wsHub) broadcast(plc, area string, start int, data []byte) {
+	if !h.active() {
+		return
+	}
+	payload, err := json.Marshal(newLiveFrame(plc, area, start, data))
+	if err != nil {
+		log.Printf(tr("实时数据编码失败: %v"), err)
+		return
+	}
+	frame := wsFrame(wsOpText, payload)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if c.plc != "" && c.plc != plc {
+			continue
+		}
+		select {
+		case c.send <- frame:
+		default:
+		}
+	}
+}
+
+// ServeHTTP 完成WebSocket握手后持续推送数据帧，可用 ?plc=IP 只订阅一台PLC
+func (h *wsHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, tr("需要WebSocket连接"), http.StatusBadRequest)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, tr("不支持WebSocket"), http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		log.Printf(tr("WebSocket握手失败: %v"), err)
+		return
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+
+	c := &wsClient{conn: conn, plc: r.URL.Query().Get("plc"), send: make(chan []byte, 16)}
+	h.mu.Lock()
+	h.clients[c] = true
+	h.mu.Unlock()
+
+	go c.writeLoop()
+	c.readLoop(rw.Reader)
+
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+	close(c.send)
+}
+
+func (c *wsClient) writeLoop() {
+	defer c.conn.Close()
+	for frame := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err := c.conn.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+// readLoop 读取客户端帧：应答ping，收到close或连接出错时返回
+func (c *wsClient) readLoop(r *bufio.Reader) {
+	for {
+		op, payload, err := readWSFrame(r)
+		if err != nil {
+			return
+		}
+		switch op {
+		case wsOpClose:
+			select {
+			case c.send <- wsFrame(wsOpClose, nil):
+			default:
+			}
+			return
+		case wsOpPing:
+			select {
+			case c.send <- wsFrame(wsOpPong, payload):
+			default:
+			}
+		}
+	}
+}
+
+// wsFrame 组装服务端到客户端的单帧消息（不加掩码）
+func wsFrame(op byte, payload []byte) []byte {
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	return append(frame, payload...)
+}
+
+// readWSFrame 读取客户端发来的一帧（客户端帧必须带掩码），只用于处理控制帧
+func readWSFrame(r *bufio.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op := head[0] & 0x0F
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > 1<<16 {
+		return 0, nil, fmt.Errorf(tr("WebSocket帧过大: %d"), n)
+	}
+	var mask [4]byte
+	if head[1]&0x80 != 0 {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"plc-binary-viewer/pkg/s7viewer"
+)
+
+// writeRecheckDelay 写入后延时复核的等待时间，PLC程序在此期间的扫描中改写的值会被发现
+const writeRecheckDelay = 500 * time.Millisecond
+
+// writeRechecker 写入后延时复核：通过Viewer的每次写入（写入面板、批量写入、状态表、网格和模拟量输出）
+// 成功后等待writeRecheckDelay再读回一次，与写入的值比较。强制表的反复写入不经过写入回调，不复核
+type writeRechecker struct {
+	check *widget.Check
+	label *widget.Label // 最近一次复核的结果，一致时为绿色，被改写时为红色
+	on    atomic.Bool   // check的勾选状态，在写入的协程中读取
+	seq   atomic.Int64  // 每次复核加1，丢弃之前写入的复核结果
+}
+
+func newWriteRechecker() *writeRechecker {
+	r := &writeRechecker{label: widget.NewLabel("")}
+	r.check = widget.NewCheck(tr("写入后延时复核"), func(on bool) { r.on.Store(on) })
+	r.check.SetChecked(true)
+	return r
+}
+
+// writeHandler 返回Viewer的写入回调：先调用next（如写入审计），写入成功时再启动复核
+func (r *writeRechecker) writeHandler(viewer *s7viewer.Viewer, next func(s7viewer.WriteEvent)) func(s7viewer.WriteEvent) {
+	return func(e s7viewer.WriteEvent) {
+		if next != nil {
+			next(e)
+		}
+		if e.Err != nil || !r.on.Load() {
+			return
+		}
+		seq := r.seq.Add(1)
+		addr := s7viewer.ByteAddressName(e.Area, e.Start)
+		fyne.Do(func() {
+			if seq == r.seq.Load() {
+				r.label.Importance = widget.MediumImportance
+				r.label.SetText(tr("正在复核…"))
+			}
+		})
+		go func() {
+			time.Sleep(writeRecheckDelay)
+			size := len(e.New)
+			if s7viewer.IsCounterArea(e.Area) {
+				size /= 2
+			}
+			// 期间断开连接时不再复核
+			if !viewer.IsConnected() {
+				return
+			}
+			readBack, err := viewer.ReadArea(context.Background(), e.Area, e.Start, size)
+			fyne.Do(func() {
+				if seq != r.seq.Load() {
+					return
+				}
+				switch {
+				case err != nil:
+					r.label.Importance = widget.WarningImportance
+					r.label.SetText(tr("复核读取失败: ") + err.Error())
+				case bytes.Equal(readBack, e.New):
+					r.label.Importance = widget.SuccessImportance
+					r.label.SetText(fmt.Sprintf(tr("复核通过：%v后 %s 读回值一致"), writeRecheckDelay, addr))
+				default:
+					r.label.Importance = widget.DangerImportance
+					r.label.SetText(fmt.Sprintf(tr("复核不一致：%v后 %s 读回 %s，可能被PLC程序改写"), writeRecheckDelay, addr, formatHex(readBack)))
+					log.Printf(tr("写入 %s 后复核不一致: 写入 %s，读回 %s"), addr, formatHex(e.New), formatHex(readBack))
+				}
+			})
+		}()
+	}
+}
+
+// writeTypes 写入面板可选的数据类型
+var writeTypes = []string{s7viewer.TypeByte, s7viewer.TypeWord, s7viewer.TypeInt, s7viewer.TypeDWord, s7viewer.TypeDInt, s7viewer.TypeReal}
+
+// parseWriteAddress 解析写入地址，返回地址和按宽度推断的默认类型。
+// 除VB/VW/VD外还接受VR作为VD的REAL写法。
+func parseWriteAddress(s string) (s7Address, string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	isReal := strings.HasPrefix(s, "VR")
+	if isReal {
+		s = "VD" + s[2:]
+	}
+
+	addr, err := parseVAddress(s)
+	if err != nil {
+		return s7Address{}, "", err
+	}
+	switch {
+	case addr.size == "":
+		return s7Address{}, "", errors.New(tr("位地址请在网格中双击写入"))
+	case isReal:
+		return addr, s7viewer.TypeReal, nil
+	case addr.size == "B":
+		return addr, s7viewer.TypeByte, nil
+	case addr.size == "W":
+		return addr, s7viewer.TypeInt, nil
+	}
+	return addr, s7viewer.TypeDInt, nil
+}
+
+// newWritePanel 创建字节/字/双字/REAL写入面板，也可从CSV文件批量写入。getViewer返回当前连接及其context，
+// onWritten在写入成功后调用，用于刷新显示。面板中显示recheck的开关和最近一次复核的结果。
+func newWritePanel(win fyne.Window, getViewer func() (*s7viewer.Viewer, context.Context), onWritten func(), recheck *writeRechecker) fyne.CanvasObject {
+	addrEntry := widget.NewEntry()
+	addrEntry.SetPlaceHolder(tr("例如 VW100、VD200、VR104"))
+
+	typeSelect := widget.NewSelect(writeTypes, nil)
+	typeSelect.SetSelected(s7viewer.TypeInt)
+
+	// 输入地址时按宽度自动选择类型
+	addrEntry.OnChanged = func(s string) {
+		if _, dataType, err := parseWriteAddress(s); err == nil {
+			typeSelect.SetSelected(dataType)
+		}
+	}
+
+	valueEntry := widget.NewEntry()
+	valueEntry.SetPlaceHolder(tr("十进制，整数也可用0x前缀"))
+
+	resultLabel := widget.NewLabel("")
+
+	writeButton := widget.NewButton(tr("写入"), func() {
+		viewer, ctx := getViewer()
+		if viewer == nil {
+			resultLabel.SetText(tr("请先连接PLC"))
+			return
+		}
+
+		addr, _, err := parseWriteAddress(addrEntry.Text)
+		if err != nil {
+			resultLabel.SetText(err.Error())
+			return
+		}
+		dataType := typeSelect.Selected
+		if s7viewer.TypeWidth(dataType, 0) != addr.width() {
+			resultLabel.SetText(fmt.Sprintf(tr("地址 %s 的宽度与类型 %s 不符"), addr, dataType))
+			return
+		}
+
+		order := viewer.ByteOrder()
+		data, err := s7viewer.EncodeValue(order, dataType, valueEntry.Text)
+		if err != nil {
+			resultLabel.SetText(err.Error())
+			return
+		}
+
+		if err := viewer.WriteV(ctx, addr.byteOff, data); err != nil {
+			resultLabel.SetText(err.Error())
+			log.Printf(tr("写入 %s 失败: %v"), addr, err)
+			return
+		}
+		result := fmt.Sprintf(tr("已写入 %s = %s (%s)"), addr, strings.TrimSpace(valueEntry.Text), dataType)
+		if viewer.VerifyWrite() {
+			result += tr("，写入已验证")
+		}
+		resultLabel.SetText(result)
+		log.Printf(tr("已写入 %s = %s (%s)"), addr, strings.TrimSpace(valueEntry.Text), dataType)
+		if onWritten != nil {
+			onWritten()
+		}
+
+	})
+	batchButton := widget.NewButton(tr("从CSV批量写入..."), func() { showBatchWrite(win, getViewer, onWritten) })
+	disableInReadOnly(writeButton, batchButton)
+
+	return container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(tr("地址:"), addrEntry),
+			widget.NewFormItem(tr("数据类型:"), typeSelect),
+			widget.NewFormItem(tr("值:"), valueEntry),
+		),
+		container.NewHBox(writeButton, recheck.check, resultLabel),
+		recheck.label,
+		widget.NewSeparator(),
+		container.NewHBox(batchButton, widget.NewLabel(tr("CSV需含 地址、值 列，类型列可省略，如 VW100,INT,1500"))),
+	)
+}